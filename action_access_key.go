@@ -16,6 +16,31 @@ func (c *Client) NewAccessKey(ctx context.Context, rawXpubKey string, opts ...Mo
 	// Check for existing NewRelic transaction
 	ctx = c.GetOrStartTxn(ctx, "new_access_key")
 
+	return c.newAccessKey(ctx, rawXpubKey, nil, time.Time{}, opts...)
+}
+
+// NewScopedAccessKey will create a new access key for the given xpub, limited by the scope
+// and (optionally) expiring at the given time (zero time = never expires)
+//
+// opts are options and can include "metadata"
+func (c *Client) NewScopedAccessKey(ctx context.Context, rawXpubKey string, scope *AccessKeyScope,
+	expiresAt time.Time, opts ...ModelOps) (*AccessKey, error) {
+
+	// Check for existing NewRelic transaction
+	ctx = c.GetOrStartTxn(ctx, "new_scoped_access_key")
+
+	// Make sure the key does not expire right away
+	if !expiresAt.IsZero() && !expiresAt.After(time.Now().UTC()) {
+		return nil, ErrAccessKeyExpiresInPast
+	}
+
+	return c.newAccessKey(ctx, rawXpubKey, scope, expiresAt, opts...)
+}
+
+// newAccessKey will create and save a new access key for the given xpub
+func (c *Client) newAccessKey(ctx context.Context, rawXpubKey string, scope *AccessKeyScope,
+	expiresAt time.Time, opts ...ModelOps) (*AccessKey, error) {
+
 	// Validate that the value is an xPub
 	_, err := utils.ValidateXPub(rawXpubKey)
 	if err != nil {
@@ -38,6 +63,15 @@ func (c *Client) NewAccessKey(ctx context.Context, rawXpubKey string, opts ...Mo
 		xPub.ID, c.DefaultModelOptions(append(opts, New())...)...,
 	)
 
+	// Set the scope & expiry
+	if scope != nil {
+		accessKey.Scope = *scope
+	}
+	if !expiresAt.IsZero() {
+		accessKey.ExpiresAt.Valid = true
+		accessKey.ExpiresAt.Time = expiresAt.UTC()
+	}
+
 	// Save the model
 	if err = accessKey.Save(ctx); err != nil {
 		return nil, err
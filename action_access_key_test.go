@@ -0,0 +1,58 @@
+package bux
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestClient_NewScopedAccessKey will test the method NewScopedAccessKey()
+func TestClient_NewScopedAccessKey(t *testing.T) {
+	t.Run("valid scoped key", func(t *testing.T) {
+		ctx, client, deferMe := initSimpleTestCase(t)
+		defer deferMe()
+
+		expiresAt := time.Now().UTC().Add(time.Hour)
+		accessKey, err := client.NewScopedAccessKey(ctx, testXPub, &AccessKeyScope{
+			Permissions: []AccessKeyPermission{AccessKeyPermissionRead, AccessKeyPermissionCreateDestination},
+		}, expiresAt)
+		require.NoError(t, err)
+		require.NotNil(t, accessKey)
+		assert.Equal(t, testXPubID, accessKey.XpubID)
+		assert.True(t, accessKey.ExpiresAt.Valid)
+		assert.True(t, accessKey.Scope.HasPermission(AccessKeyPermissionCreateDestination))
+		assert.False(t, accessKey.Scope.HasPermission(AccessKeyPermissionSend))
+		assert.NotEqual(t, "", accessKey.Key)
+	})
+
+	t.Run("no expiry", func(t *testing.T) {
+		ctx, client, deferMe := initSimpleTestCase(t)
+		defer deferMe()
+
+		accessKey, err := client.NewScopedAccessKey(ctx, testXPub, nil, time.Time{})
+		require.NoError(t, err)
+		require.NotNil(t, accessKey)
+		assert.False(t, accessKey.ExpiresAt.Valid)
+		assert.True(t, accessKey.Scope.IsUnrestricted())
+	})
+
+	t.Run("error - expires in the past", func(t *testing.T) {
+		ctx, client, deferMe := initSimpleTestCase(t)
+		defer deferMe()
+
+		_, err := client.NewScopedAccessKey(ctx, testXPub, nil, time.Now().UTC().Add(-time.Minute))
+		require.ErrorIs(t, err, ErrAccessKeyExpiresInPast)
+	})
+
+	t.Run("error - invalid scope", func(t *testing.T) {
+		ctx, client, deferMe := initSimpleTestCase(t)
+		defer deferMe()
+
+		_, err := client.NewScopedAccessKey(ctx, testXPub, &AccessKeyScope{
+			AllowedIPs: []string{"not-an-ip"},
+		}, time.Time{})
+		require.ErrorIs(t, err, ErrInvalidAccessKeyScope)
+	})
+}
@@ -0,0 +1,205 @@
+package bux
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/BuxOrg/bux/utils"
+	"github.com/mrz1836/go-datastore"
+)
+
+// NewBatchPayout will resolve all recipients and create the first draft transaction of the batch payout
+//
+// Recipients are resolved concurrently (paymail lookups are rate limited) and split into multiple
+// transactions when the output or size limits are reached. Each draft must be signed and recorded
+// (RecordTransaction) before calling ProcessBatchPayout to create the next (chained) draft.
+//
+// ctx is the context
+// rawXpubKey is the raw xPub key
+// recipients are the outputs to pay
+// config is the BatchPayoutConfig (limits, fees & sync)
+// opts are additional model options to be applied
+func (c *Client) NewBatchPayout(ctx context.Context, rawXpubKey string, recipients []*TransactionOutput,
+	config *BatchPayoutConfig, opts ...ModelOps,
+) (*BatchPayout, error) {
+	// Check for existing NewRelic transaction
+	ctx = c.GetOrStartTxn(ctx, "new_batch_payout")
+
+	if len(recipients) == 0 {
+		return nil, ErrMissingBatchRecipients
+	}
+	if config == nil {
+		config = &BatchPayoutConfig{}
+	}
+
+	// Create the batch model
+	batch := newBatchPayout(
+		rawXpubKey, recipients, config,
+		c.DefaultModelOptions(append(opts, New())...)...,
+	)
+
+	// Resolve all recipients (before taking the xPub lock)
+	if err := batch.resolveRecipients(ctx); err != nil {
+		return nil, err
+	}
+
+	// Split the resolved recipients into transactions
+	if batch.splitRecipients() == 0 {
+		batch.Status = BatchPayoutStatusError
+		if err := batch.Save(ctx); err != nil {
+			return nil, err
+		}
+		return batch, ErrBatchRecipientsUnresolved
+	}
+
+	// Create the lock and set the release for after the function completes
+	unlock, err := newWaitWriteLock(
+		ctx, fmt.Sprintf(lockKeyProcessXpub, batch.XpubID), c.Cachestore(),
+	)
+	defer unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	// Create the first draft (spending any utxos of the xPub)
+	if _, err = batch.createDraft(ctx, 0, nil); err != nil {
+		return nil, err
+	}
+
+	// Save the model
+	if err = batch.Save(ctx); err != nil {
+		return nil, err
+	}
+
+	return batch, nil
+}
+
+// ProcessBatchPayout will check the current draft of the batch payout and create the next draft
+//
+// The next draft spends the carry & change outputs of the previous (recorded) transaction.
+// If the current draft expired or was canceled, it will be re-created.
+func (c *Client) ProcessBatchPayout(ctx context.Context, rawXpubKey, id string) (*BatchPayout, error) {
+	// Check for existing NewRelic transaction
+	ctx = c.GetOrStartTxn(ctx, "process_batch_payout")
+
+	xPubID := utils.Hash(rawXpubKey)
+
+	// Create the lock and set the release for after the function completes
+	unlock, err := newWaitWriteLock(
+		ctx, fmt.Sprintf(lockKeyProcessXpub, xPubID), c.Cachestore(),
+	)
+	defer unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	// Get the batch payout
+	var batch *BatchPayout
+	if batch, err = getBatchPayout(
+		ctx, xPubID, id, append(c.DefaultModelOptions(), WithXPub(rawXpubKey))...,
+	); err != nil {
+		return nil, err
+	} else if batch == nil {
+		return nil, ErrBatchPayoutNotFound
+	} else if batch.Status == BatchPayoutStatusError {
+		return batch, ErrBatchPayoutFailed
+	} else if batch.isFinished() {
+		return batch, ErrBatchPayoutComplete
+	}
+
+	// Get the current draft
+	index := len(batch.Transactions) - 1
+	current := batch.Transactions[index]
+	var draft *DraftTransaction
+	if draft, err = getDraftTransactionID(
+		ctx, xPubID, current.DraftID, c.DefaultModelOptions()...,
+	); err != nil {
+		return nil, err
+	} else if draft == nil {
+		return nil, ErrDraftNotFound
+	}
+
+	switch draft.Status {
+	case DraftStatusCanceled, DraftStatusExpired:
+		// Re-create the draft, spending the same chained utxos
+		var fromUtxos []*UtxoPointer
+		if index > 0 {
+			var previous *DraftTransaction
+			if previous, err = getDraftTransactionID(
+				ctx, xPubID, batch.Transactions[index-1].DraftID, c.DefaultModelOptions()...,
+			); err != nil {
+				return nil, err
+			} else if previous == nil {
+				return nil, ErrDraftNotFound
+			}
+			fromUtxos = getChainedUtxos(previous, batch.Transactions[index-1].CarryAddress)
+		}
+		if _, err = batch.createDraft(ctx, index, fromUtxos); err != nil {
+			return nil, err
+		}
+	case DraftStatusComplete:
+
+		// The transaction was rejected by the network (IE: lost a double spend)
+		var syncTx *SyncTransaction
+		if syncTx, err = GetSyncTransactionByID(
+			ctx, draft.FinalTxID, c.DefaultModelOptions()...,
+		); err != nil {
+			return nil, err
+		} else if syncTx != nil && syncTx.BroadcastStatus == SyncStatusCanceled {
+			batch.Transactions[index].TxID = draft.FinalTxID
+			batch.failTransaction(index, "transaction was not broadcast: "+syncTx.Results.LastMessage)
+			if err = batch.Save(ctx); err != nil {
+				return nil, err
+			}
+			return batch, ErrBatchPayoutFailed
+		}
+
+		batch.completeTransaction(index, draft.FinalTxID)
+
+		// Create the next draft in the chain
+		if !batch.isFinished() {
+			if _, err = batch.createDraft(
+				ctx, index+1, getChainedUtxos(draft, current.CarryAddress),
+			); err != nil {
+				return nil, err
+			}
+		}
+	default:
+		return batch, ErrBatchPayoutDraftPending
+	}
+
+	// Save the model
+	if err = batch.Save(ctx); err != nil {
+		return nil, err
+	}
+
+	return batch, nil
+}
+
+// GetBatchPayout will get a batch payout from the Datastore
+func (c *Client) GetBatchPayout(ctx context.Context, xPubID, id string) (*BatchPayout, error) {
+	// Check for existing NewRelic transaction
+	ctx = c.GetOrStartTxn(ctx, "get_batch_payout")
+
+	batch, err := getBatchPayout(ctx, xPubID, id, c.DefaultModelOptions()...)
+	if err != nil {
+		return nil, err
+	} else if batch == nil {
+		return nil, ErrBatchPayoutNotFound
+	}
+
+	return batch, nil
+}
+
+// GetBatchPayouts will get all the batch payouts from the Datastore
+func (c *Client) GetBatchPayouts(ctx context.Context, metadataConditions *Metadata,
+	conditions *map[string]interface{}, queryParams *datastore.QueryParams, opts ...ModelOps,
+) ([]*BatchPayout, error) {
+	// Check for existing NewRelic transaction
+	ctx = c.GetOrStartTxn(ctx, "get_batch_payouts")
+
+	return getBatchPayouts(
+		ctx, metadataConditions, conditions, queryParams,
+		c.DefaultModelOptions(opts...)...,
+	)
+}
@@ -0,0 +1,115 @@
+package bux
+
+import (
+	"testing"
+
+	"github.com/libsv/go-bk/bip32"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_BatchPayout(t *testing.T) {
+	t.Run("missing recipients", func(t *testing.T) {
+		ctx, client, deferMe := initSimpleTestCase(t)
+		defer deferMe()
+
+		_, err := client.NewBatchPayout(ctx, testXPub, nil, nil)
+		require.ErrorIs(t, err, ErrMissingBatchRecipients)
+	})
+
+	t.Run("no resolved recipients", func(t *testing.T) {
+		ctx, client, deferMe := initSimpleTestCase(t)
+		defer deferMe()
+
+		batch, err := client.NewBatchPayout(ctx, testXPub, []*TransactionOutput{{
+			To:       "invalid-address",
+			Satoshis: 1000,
+		}}, nil)
+		require.ErrorIs(t, err, ErrBatchRecipientsUnresolved)
+		require.NotNil(t, batch)
+		assert.Equal(t, BatchPayoutStatusError, batch.Status)
+	})
+
+	t.Run("chained transactions", func(t *testing.T) {
+		ctx, client, deferMe := initSimpleTestCase(t)
+		defer deferMe()
+
+		xPriv, err := bip32.NewKeyFromString(testXPriv)
+		require.NoError(t, err)
+
+		recipients := []*TransactionOutput{
+			{To: testExternalAddress, Satoshis: 1000},
+			{To: "1A1PjKqjWMNBzTVdcBru27EV1PHcXWc63W", Satoshis: 2000},
+			{To: "invalid-address", Satoshis: 1000},
+			{To: testExternalAddress, Satoshis: 3000},
+		}
+
+		var batch *BatchPayout
+		batch, err = client.NewBatchPayout(ctx, testXPub, recipients, &BatchPayoutConfig{
+			MaxOutputsPerTransaction: 2,
+			Sync:                     &SyncConfig{},
+		})
+		require.NoError(t, err)
+		require.NotNil(t, batch)
+		assert.Equal(t, BatchPayoutStatusProcessing, batch.Status)
+		assert.Equal(t, BatchRecipientStatusFailed, batch.Recipients[2].Status)
+		require.Len(t, batch.Transactions, 1)
+		assert.NotEmpty(t, batch.Transactions[0].CarryAddress)
+
+		// Cannot continue before the first draft is recorded
+		_, err = client.ProcessBatchPayout(ctx, testXPub, batch.ID)
+		require.ErrorIs(t, err, ErrBatchPayoutDraftPending)
+
+		// Sign & record all drafts in the chain
+		for index := 0; index < 2; index++ {
+			var draft *DraftTransaction
+			draft, err = getDraftTransactionID(
+				ctx, testXPubID, batch.Transactions[index].DraftID, client.DefaultModelOptions()...,
+			)
+			require.NoError(t, err)
+			require.NotNil(t, draft)
+
+			var hex string
+			hex, err = draft.SignInputs(xPriv)
+			require.NoError(t, err)
+
+			var transaction *Transaction
+			transaction, err = client.RecordTransaction(ctx, testXPub, hex, draft.ID, client.DefaultModelOptions()...)
+			require.NoError(t, err)
+
+			batch, err = client.ProcessBatchPayout(ctx, testXPub, batch.ID)
+			require.NoError(t, err)
+			assert.Equal(t, transaction.ID, batch.Transactions[index].TxID)
+		}
+
+		// The second transaction spends the carry output of the first
+		var draft *DraftTransaction
+		draft, err = getDraftTransactionID(ctx, testXPubID, batch.Transactions[1].DraftID, client.DefaultModelOptions()...)
+		require.NoError(t, err)
+		require.NotEmpty(t, draft.Configuration.Inputs)
+		for _, input := range draft.Configuration.Inputs {
+			assert.Equal(t, batch.Transactions[0].TxID, input.TransactionID)
+		}
+
+		// One recipient could not be resolved
+		assert.Equal(t, BatchPayoutStatusPartial, batch.Status)
+		require.Len(t, batch.FailedRecipients(), 1)
+		assert.Equal(t, "invalid-address", batch.FailedRecipients()[0].Output.To)
+		for index, recipient := range batch.Recipients {
+			if index == 2 {
+				continue
+			}
+			assert.Equal(t, BatchRecipientStatusComplete, recipient.Status)
+			assert.Equal(t, batch.Transactions[recipient.TransactionIndex].TxID, recipient.TxID)
+		}
+
+		_, err = client.ProcessBatchPayout(ctx, testXPub, batch.ID)
+		require.ErrorIs(t, err, ErrBatchPayoutComplete)
+
+		// Fetch the batch from the datastore
+		batch, err = client.GetBatchPayout(ctx, testXPubID, batch.ID)
+		require.NoError(t, err)
+		assert.Equal(t, BatchPayoutStatusPartial, batch.Status)
+		assert.Len(t, batch.Recipients, 4)
+	})
+}
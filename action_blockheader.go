@@ -2,14 +2,24 @@ package bux
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
+	"strings"
+	"time"
 
+	"github.com/BuxOrg/bux/notifications"
 	"github.com/libsv/go-bc"
 	"github.com/mrz1836/go-datastore"
+	customTypes "github.com/mrz1836/go-datastore/custom_types"
 )
 
 // RecordBlockHeader will save a block header into the Datastore
 //
+// The block header is validated (proof-of-work, difficulty & height continuity) unless disabled on the client.
+// A competing block header is kept as orphaned until its chain is longer than the current chain,
+// then the chain is reorganized to the longest chain.
+//
 // hash is the hash of the block header
 // bh is the block header data
 // opts are model options and can include "metadata"
@@ -38,11 +48,89 @@ func (c *Client) RecordBlockHeader(ctx context.Context, hash string, height uint
 		return nil, err
 	}
 
-	// Process & save the transaction model
+	// Check for an existing block header (a block can return to the chain after being orphaned)
+	var existing *BlockHeader
+	if existing, err = getBlockHeaderRecordByID(ctx, id, c.DefaultModelOptions(opts...)...); err != nil {
+		return nil, err
+	} else if existing != nil && !existing.Orphaned.Valid {
+		return existing, nil
+	}
+
+	// Validate the block header
+	if c.IsBlockHeaderValidationEnabled() {
+		rules := c.difficultyRules()
+		if err = validateBlockHeader(id, &bh, rules.PowLimit); err != nil {
+			return nil, err
+		} else if err = blockHeader.validateHeight(ctx); err != nil {
+			return nil, err
+		} else if err = blockHeader.validateDifficulty(ctx, rules); err != nil {
+			return nil, err
+		}
+	}
+
+	// Get the current tip of the chain
+	var tip *BlockHeader
+	if tip, err = getLastBlockHeader(ctx, c.DefaultModelOptions(opts...)...); err != nil {
+		return nil, err
+	}
+
+	// Detect a chain reorganization (orphan any block headers that are no longer on the longest chain)
+	var reorgHeight uint32
+	var reorg, moreWork bool
+	if reorgHeight, reorg, err = blockHeader.detectReorg(ctx); err != nil {
+		return nil, err
+	} else if reorg {
+		if moreWork, err = blockHeader.hasMoreWork(ctx, tip); err != nil {
+			return nil, err
+		}
+	}
+	if reorg && !moreWork {
+
+		// The competing chain is not longer (yet), keep the block header as orphaned
+		if existing != nil {
+			return existing, nil
+		}
+		blockHeader.Orphaned = customTypes.NullTime{NullTime: sql.NullTime{
+			Time:  time.Now().UTC(),
+			Valid: true,
+		}}
+		if err = blockHeader.Save(ctx); err != nil {
+			return nil, err
+		}
+		return blockHeader, nil
+	} else if reorg {
+		if err = blockHeader.reorganizeChain(ctx, reorgHeight); err != nil {
+			return nil, err
+		}
+	}
+
+	// Restore the orphaned block header
+	if existing != nil {
+		existing.Orphaned.Valid = false
+		blockHeader = existing
+	}
+
+	// Process & save the block header model
 	if err = blockHeader.Save(ctx); err != nil {
 		return nil, err
 	}
 
+	// The tip of the longest chain changed
+	if tip == nil || height > tip.Height || reorg {
+
+		// Update the transactions that reached the finality confirmations
+		if !blockHeader.skipFinality {
+			if err = processFinalTransactions(
+				ctx, c.FinalityConfirmations(), c.DefaultModelOptions(opts...)...,
+			); err != nil {
+				return nil, err
+			}
+		}
+
+		// Fire notifications (new tip of the longest chain)
+		notify(notifications.EventTypeChainTip, blockHeader)
+	}
+
 	// Return the response
 	return blockHeader, nil
 }
@@ -114,3 +202,48 @@ func (c *Client) GetBlockHeaderByHeight(ctx context.Context, height uint32) (*Bl
 	// Get the block header by height
 	return getBlockHeaderByHeight(ctx, height, c.DefaultModelOptions()...)
 }
+
+// GetBlockHeaderByHash will get the block header by hash (including orphaned block headers)
+func (c *Client) GetBlockHeaderByHash(ctx context.Context, hash string) (*BlockHeader, error) {
+
+	// Check for existing NewRelic transaction
+	ctx = c.GetOrStartTxn(ctx, "get_block_header_by_hash")
+
+	// Get the block header by hash
+	return getBlockHeaderByID(ctx, hash, c.DefaultModelOptions()...)
+}
+
+// GetMerkleRootAtHeight will get the merkle root of the block (on the longest chain) at the given height
+func (c *Client) GetMerkleRootAtHeight(ctx context.Context, height uint32) (string, error) {
+
+	// Check for existing NewRelic transaction
+	ctx = c.GetOrStartTxn(ctx, "get_merkle_root_at_height")
+
+	// Get the block header by height
+	blockHeader, err := getBlockHeaderByHeight(ctx, height, c.DefaultModelOptions()...)
+	if err != nil {
+		return "", err
+	} else if blockHeader == nil {
+		return "", ErrBlockHeaderNotFound
+	}
+
+	return blockHeader.HashMerkleRoot, nil
+}
+
+// IsValidMerkleRoot will check if the merkle root is the merkle root of the block (on the longest chain)
+// at the given height (SPV)
+func (c *Client) IsValidMerkleRoot(ctx context.Context, root string, height uint32) (bool, error) {
+
+	// Check for existing NewRelic transaction
+	ctx = c.GetOrStartTxn(ctx, "is_valid_merkle_root")
+
+	// Get the merkle root at the height
+	merkleRoot, err := c.GetMerkleRootAtHeight(ctx, height)
+	if errors.Is(err, ErrBlockHeaderNotFound) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	return len(root) > 0 && strings.EqualFold(merkleRoot, root), nil
+}
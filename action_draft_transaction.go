@@ -2,6 +2,7 @@ package bux
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/mrz1836/go-datastore"
 )
@@ -60,3 +61,71 @@ func (c *Client) GetDraftTransactionsCount(ctx context.Context, metadataConditio
 
 	return count, nil
 }
+
+// ApproveDraftTransaction will approve a draft transaction that is pending approval
+//
+// approverXPubID is the xPub ID of the approver (an admin key, or a known xPub in the approvers of the policy),
+// it must be the xPub that authenticated the request (ctx)
+// The draft can be recorded once it has received all the required approvals
+func (c *Client) ApproveDraftTransaction(ctx context.Context, id, approverXPubID, note string,
+	opts ...ModelOps) (*DraftTransaction, error) {
+
+	// Check for existing NewRelic transaction
+	ctx = c.GetOrStartTxn(ctx, "approve_draft_transaction")
+
+	return c.decideDraftTransaction(ctx, id, approverXPubID, note, DraftApprovalStatusApproved, opts...)
+}
+
+// RejectDraftTransaction will reject a draft transaction that is pending approval (releases the reserved utxos)
+//
+// approverXPubID is the xPub ID of the approver (an admin key, or a known xPub in the approvers of the policy),
+// it must be the xPub that authenticated the request (ctx)
+func (c *Client) RejectDraftTransaction(ctx context.Context, id, approverXPubID, note string,
+	opts ...ModelOps) (*DraftTransaction, error) {
+
+	// Check for existing NewRelic transaction
+	ctx = c.GetOrStartTxn(ctx, "reject_draft_transaction")
+
+	return c.decideDraftTransaction(ctx, id, approverXPubID, note, DraftApprovalStatusRejected, opts...)
+}
+
+// decideDraftTransaction will approve or reject the draft transaction
+func (c *Client) decideDraftTransaction(ctx context.Context, id, approverXPubID, note string,
+	status DraftApprovalStatus, opts ...ModelOps) (*DraftTransaction, error) {
+
+	// The approver must be the xPub (or admin) that authenticated the request
+	if authXPubID, _ := ctx.Value(ParamXPubHashKey).(string); len(approverXPubID) == 0 || authXPubID != approverXPubID {
+		return nil, ErrDraftApproverNotAuthenticated
+	}
+
+	// Create the lock and set the release for after the function completes
+	unlock, err := newWaitWriteLock(
+		ctx, fmt.Sprintf(lockKeyApproveDraft, id), c.Cachestore(),
+	)
+	defer unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	// Get the draft transaction
+	var draftTransaction *DraftTransaction
+	if draftTransaction, err = getDraftTransactionID(
+		ctx, "", id, c.DefaultModelOptions(opts...)...,
+	); err != nil {
+		return nil, err
+	} else if draftTransaction == nil {
+		return nil, ErrDraftNotFound
+	}
+
+	// Approve or reject
+	if status == DraftApprovalStatusRejected {
+		err = draftTransaction.reject(ctx, approverXPubID, note)
+	} else {
+		err = draftTransaction.approve(ctx, approverXPubID, note)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return draftTransaction, nil
+}
@@ -0,0 +1,164 @@
+package bux
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/BuxOrg/bux/utils"
+	"github.com/mrz1836/go-datastore"
+)
+
+// NewImportJob will start a new (background) import job of the xPub and all related destinations and transactions
+//
+// The import job is processed by the task manager in small steps and resumed after a restart. Use GetImportJob()
+// to check the progress (or listen for the progress notifications), or CancelImportJob() to stop the import.
+//
+// ctx is the context
+// xPubKey is the raw public xPub
+// config is the ImportJobConfig (gap limit, import profile or derivation paths)
+// opts are additional model options to be applied
+func (c *Client) NewImportJob(ctx context.Context, xPubKey string, config *ImportJobConfig,
+	opts ...ModelOps,
+) (*ImportJob, error) {
+	// Check for existing NewRelic transaction
+	ctx = c.GetOrStartTxn(ctx, "new_import_job")
+
+	// Validate the xPub
+	if _, err := utils.ValidateXPub(xPubKey); err != nil {
+		return nil, err
+	}
+
+	// Make sure the xPub exists
+	xPub, err := getXpubByID(ctx, utils.Hash(xPubKey), c.DefaultModelOptions()...)
+	if err != nil {
+		return nil, err
+	} else if xPub == nil {
+		return nil, ErrMissingXpub
+	}
+
+	if config == nil {
+		config = &ImportJobConfig{}
+	} else if err = config.validate(); err != nil {
+		return nil, err
+	}
+
+	// Create the import job model
+	job := newImportJob(
+		xPubKey, config,
+		c.DefaultModelOptions(append(opts, New())...)...,
+	)
+
+	// Save the model
+	if err = job.Save(ctx); err != nil {
+		return nil, err
+	}
+
+	return job, nil
+}
+
+// GetImportJob will get an import job from the Datastore
+func (c *Client) GetImportJob(ctx context.Context, xPubID, id string) (*ImportJob, error) {
+	// Check for existing NewRelic transaction
+	ctx = c.GetOrStartTxn(ctx, "get_import_job")
+
+	job, err := getImportJob(ctx, xPubID, id, c.DefaultModelOptions()...)
+	if err != nil {
+		return nil, err
+	} else if job == nil {
+		return nil, ErrImportJobNotFound
+	}
+
+	return job, nil
+}
+
+// GetImportJobs will get all the import jobs from the Datastore
+func (c *Client) GetImportJobs(ctx context.Context, metadataConditions *Metadata,
+	conditions *map[string]interface{}, queryParams *datastore.QueryParams, opts ...ModelOps,
+) ([]*ImportJob, error) {
+	// Check for existing NewRelic transaction
+	ctx = c.GetOrStartTxn(ctx, "get_import_jobs")
+
+	return getImportJobs(
+		ctx, metadataConditions, conditions, queryParams,
+		c.DefaultModelOptions(opts...)...,
+	)
+}
+
+// CancelImportJob will cancel an import job that has not finished yet
+//
+// Waits for the current step of the import job to finish, destinations & transactions that
+// were already imported are kept
+func (c *Client) CancelImportJob(ctx context.Context, xPubID, id string) (*ImportJob, error) {
+	// Check for existing NewRelic transaction
+	ctx = c.GetOrStartTxn(ctx, "cancel_import_job")
+
+	// Create the lock and set the release for after the function completes
+	unlock, err := newWaitWriteLock(
+		ctx, fmt.Sprintf(lockKeyProcessImportJob, id), c.Cachestore(),
+	)
+	defer unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	// Get the import job
+	var job *ImportJob
+	if job, err = getImportJob(ctx, xPubID, id, c.DefaultModelOptions()...); err != nil {
+		return nil, err
+	} else if job == nil {
+		return nil, ErrImportJobNotFound
+	} else if job.Status == ImportJobStatusCanceled {
+		return job, nil
+	} else if job.isFinished() {
+		return job, ErrImportJobFinished
+	}
+
+	// Cancel the import job
+	job.Status = ImportJobStatusCanceled
+	if err = job.Save(ctx); err != nil {
+		return nil, err
+	}
+
+	return job, nil
+}
+
+// ResumeImportJob will resume an import job that stopped on an error (IE: after the retries of a failed step)
+//
+// The import job continues from the saved state (cursors & pending transactions)
+func (c *Client) ResumeImportJob(ctx context.Context, xPubID, id string) (*ImportJob, error) {
+	// Check for existing NewRelic transaction
+	ctx = c.GetOrStartTxn(ctx, "resume_import_job")
+
+	// Create the lock and set the release for after the function completes
+	unlock, err := newWaitWriteLock(
+		ctx, fmt.Sprintf(lockKeyProcessImportJob, id), c.Cachestore(),
+	)
+	defer unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	// Get the import job
+	var job *ImportJob
+	if job, err = getImportJob(ctx, xPubID, id, c.DefaultModelOptions()...); err != nil {
+		return nil, err
+	} else if job == nil {
+		return nil, ErrImportJobNotFound
+	} else if !job.isFinished() {
+		return job, nil
+	} else if job.Status != ImportJobStatusError {
+		return job, ErrImportJobFinished
+	}
+
+	// Resume the import job (processed by the task manager)
+	job.Status = ImportJobStatusProcessing
+	job.Error = ""
+	job.Retries = 0
+	job.RetryAt = time.Time{}
+	if err = job.Save(ctx); err != nil {
+		return nil, err
+	}
+
+	return job, nil
+}
@@ -0,0 +1,197 @@
+package bux
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestClient_NewImportJob will test the method NewImportJob()
+func TestClient_NewImportJob(t *testing.T) {
+
+	t.Run("error - missing xpub", func(t *testing.T) {
+		ctx, client, deferMe := CreateTestSQLiteClient(t, false, false, WithCustomTaskManager(&taskManagerMockBase{}))
+		defer deferMe()
+
+		job, err := client.NewImportJob(ctx, testXPub, nil)
+		require.ErrorIs(t, err, ErrMissingXpub)
+		require.Nil(t, job)
+	})
+
+	t.Run("error - invalid xpub", func(t *testing.T) {
+		ctx, client, deferMe := CreateTestSQLiteClient(t, false, false, WithCustomTaskManager(&taskManagerMockBase{}))
+		defer deferMe()
+
+		job, err := client.NewImportJob(ctx, "invalid-xpub", nil)
+		require.Error(t, err)
+		require.Nil(t, job)
+	})
+
+	t.Run("error - unknown import profile", func(t *testing.T) {
+		ctx, client, deferMe := initImportJobTestCase(t)
+		defer deferMe()
+
+		job, err := client.NewImportJob(ctx, testXPub, &ImportJobConfig{Profile: "unknown"})
+		require.ErrorIs(t, err, ErrUnknownImportProfile)
+		require.Nil(t, job)
+	})
+
+	t.Run("error - hardened import path", func(t *testing.T) {
+		ctx, client, deferMe := initImportJobTestCase(t)
+		defer deferMe()
+
+		job, err := client.NewImportJob(ctx, testXPub, &ImportJobConfig{Paths: []string{"m/44'/236'/0'/0/*"}})
+		require.ErrorIs(t, err, ErrInvalidImportPath)
+		require.Nil(t, job)
+	})
+
+	t.Run("new job", func(t *testing.T) {
+		ctx, client, deferMe := initImportJobTestCase(t)
+		defer deferMe()
+
+		job, err := client.NewImportJob(ctx, testXPub, &ImportJobConfig{GapLimit: 20})
+		require.NoError(t, err)
+		require.NotNil(t, job)
+		assert.Equal(t, ImportJobStatusPending, job.Status)
+
+		job, err = client.GetImportJob(ctx, testXPubID, job.ID)
+		require.NoError(t, err)
+		assert.Equal(t, 20, job.Configuration.GapLimit)
+		assert.Equal(t, ImportProfileBux, job.Configuration.Profile)
+		assert.Equal(t, []string{"0/*", "1/*"}, job.Configuration.Paths)
+		assert.Equal(t, testXPub, job.XpubKey)
+
+		var jobs []*ImportJob
+		jobs, err = client.GetImportJobs(ctx, nil, &map[string]interface{}{xPubIDField: testXPubID}, nil)
+		require.NoError(t, err)
+		require.Len(t, jobs, 1)
+	})
+}
+
+// TestClient_GetImportJob will test the method GetImportJob()
+func TestClient_GetImportJob(t *testing.T) {
+
+	t.Run("error - not found", func(t *testing.T) {
+		ctx, client, deferMe := CreateTestSQLiteClient(t, false, false, WithCustomTaskManager(&taskManagerMockBase{}))
+		defer deferMe()
+
+		job, err := client.GetImportJob(ctx, testXPubID, testTxID)
+		require.ErrorIs(t, err, ErrImportJobNotFound)
+		require.Nil(t, job)
+	})
+}
+
+// TestClient_CancelImportJob will test the method CancelImportJob()
+func TestClient_CancelImportJob(t *testing.T) {
+
+	t.Run("error - not found", func(t *testing.T) {
+		ctx, client, deferMe := CreateTestSQLiteClient(t, false, false, WithCustomTaskManager(&taskManagerMockBase{}))
+		defer deferMe()
+
+		job, err := client.CancelImportJob(ctx, testXPubID, testTxID)
+		require.ErrorIs(t, err, ErrImportJobNotFound)
+		require.Nil(t, job)
+	})
+
+	t.Run("cancel job", func(t *testing.T) {
+		ctx, client, deferMe := initImportJobTestCase(t)
+		defer deferMe()
+
+		job, err := client.NewImportJob(ctx, testXPub, nil)
+		require.NoError(t, err)
+
+		job, err = client.CancelImportJob(ctx, testXPubID, job.ID)
+		require.NoError(t, err)
+		assert.Equal(t, ImportJobStatusCanceled, job.Status)
+
+		// Already canceled
+		job, err = client.CancelImportJob(ctx, testXPubID, job.ID)
+		require.NoError(t, err)
+		assert.Equal(t, ImportJobStatusCanceled, job.Status)
+	})
+
+	t.Run("error - already finished", func(t *testing.T) {
+		ctx, client, deferMe := initImportJobTestCase(t)
+		defer deferMe()
+
+		job, err := client.NewImportJob(ctx, testXPub, nil)
+		require.NoError(t, err)
+
+		_, err = processImportJob(ctx, client, job.ID, 0)
+		require.NoError(t, err)
+
+		job, err = client.CancelImportJob(ctx, testXPubID, job.ID)
+		require.ErrorIs(t, err, ErrImportJobFinished)
+		assert.Equal(t, ImportJobStatusComplete, job.Status)
+	})
+}
+
+// TestClient_ResumeImportJob will test the method ResumeImportJob()
+func TestClient_ResumeImportJob(t *testing.T) {
+
+	t.Run("error - not found", func(t *testing.T) {
+		ctx, client, deferMe := CreateTestSQLiteClient(t, false, false, WithCustomTaskManager(&taskManagerMockBase{}))
+		defer deferMe()
+
+		job, err := client.ResumeImportJob(ctx, testXPubID, testTxID)
+		require.ErrorIs(t, err, ErrImportJobNotFound)
+		require.Nil(t, job)
+	})
+
+	t.Run("resume job", func(t *testing.T) {
+		ctx, client, deferMe := initImportJobTestCase(t)
+		defer deferMe()
+
+		job, err := client.NewImportJob(ctx, testXPub, nil)
+		require.NoError(t, err)
+
+		// Stopped on an error
+		job.Status = ImportJobStatusError
+		job.Error = "provider is not available"
+		job.Retries = defaultImportMaxRetries
+		require.NoError(t, job.Save(ctx))
+
+		job, err = client.ResumeImportJob(ctx, testXPubID, job.ID)
+		require.NoError(t, err)
+		assert.Equal(t, ImportJobStatusProcessing, job.Status)
+		assert.Empty(t, job.Error)
+		assert.Equal(t, 0, job.Retries)
+
+		job, err = processImportJob(ctx, client, job.ID, 0)
+		require.NoError(t, err)
+		assert.Equal(t, ImportJobStatusComplete, job.Status)
+
+		// Complete jobs can not be resumed
+		job, err = client.ResumeImportJob(ctx, testXPubID, job.ID)
+		require.ErrorIs(t, err, ErrImportJobFinished)
+		assert.Equal(t, ImportJobStatusComplete, job.Status)
+	})
+}
+
+// TestClient_ImportXpub will test the method ImportXpub()
+func TestClient_ImportXpub(t *testing.T) {
+
+	t.Run("error - missing xpub", func(t *testing.T) {
+		ctx, client, deferMe := CreateTestSQLiteClient(t, false, false, WithCustomTaskManager(&taskManagerMockBase{}))
+		defer deferMe()
+
+		results, err := client.ImportXpub(ctx, testXPub)
+		require.ErrorIs(t, err, ErrMissingXpub)
+		require.Nil(t, results)
+	})
+
+	t.Run("import until finished", func(t *testing.T) {
+		ctx, client, deferMe := initImportJobTestCase(t)
+		defer deferMe()
+
+		results, err := client.ImportXpub(ctx, testXPub)
+		require.NoError(t, err)
+		require.NotNil(t, results)
+		assert.Equal(t, testXPub, results.Key)
+		assert.Equal(t, 20, results.ExternalAddresses)
+		assert.Equal(t, 10, results.InternalAddresses)
+		assert.Equal(t, 1, results.TransactionsFound)
+		assert.Equal(t, 1, results.TransactionsImported)
+	})
+}
@@ -4,7 +4,6 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"math"
 	"time"
 
 	"github.com/BuxOrg/bux/chainstate"
@@ -278,6 +277,13 @@ func (c *Client) GetTransaction(ctx context.Context, xPubID, txID string) (*Tran
 		return nil, ErrMissingTransaction
 	}
 
+	// Set the number of confirmations
+	if err = setTransactionsConfirmations(
+		ctx, []*Transaction{transaction}, c.DefaultModelOptions()...,
+	); err != nil {
+		return nil, err
+	}
+
 	return transaction, nil
 }
 
@@ -287,6 +293,22 @@ func (c *Client) GetTransactionByID(ctx context.Context, txID string) (*Transact
 	return c.GetTransaction(ctx, "", txID)
 }
 
+// GetTransactionBroadcastAttempts will get the response of each provider to the broadcasts of a transaction
+func (c *Client) GetTransactionBroadcastAttempts(ctx context.Context, txID string) ([]*chainstate.BroadcastAttempt, error) {
+	// Check for existing NewRelic transaction
+	ctx = c.GetOrStartTxn(ctx, "get_transaction_broadcast_attempts")
+
+	// Get the sync transaction (broadcast results)
+	syncTx, err := GetSyncTransactionByID(ctx, txID, c.DefaultModelOptions()...)
+	if err != nil {
+		return nil, err
+	} else if syncTx == nil {
+		return nil, ErrMissingTransaction
+	}
+
+	return syncTx.Results.BroadcastAttempts, nil
+}
+
 // GetTransactionByHex will get a transaction from the Datastore by its full hex string
 // uses GetTransaction
 func (c *Client) GetTransactionByHex(ctx context.Context, hex string) (*Transaction, error) {
@@ -314,6 +336,13 @@ func (c *Client) GetTransactions(ctx context.Context, metadataConditions *Metada
 		return nil, err
 	}
 
+	// Set the number of confirmations
+	if err = setTransactionsConfirmations(
+		ctx, transactions, c.DefaultModelOptions(opts...)...,
+	); err != nil {
+		return nil, err
+	}
+
 	return transactions, nil
 }
 
@@ -377,9 +406,37 @@ func (c *Client) GetTransactionsByXpubID(ctx context.Context, xPubID string, met
 		return nil, err
 	}
 
+	// Set the number of confirmations
+	if err = setTransactionsConfirmations(
+		ctx, transactions, c.DefaultModelOptions()...,
+	); err != nil {
+		return nil, err
+	}
+
 	return transactions, nil
 }
 
+// GetUnconfirmedTransactionsByXpubID will get all transactions for a given xpub with fewer than the
+// given confirmations from the Datastore (IE: all incoming transactions with fewer than 6 confirmations)
+//
+// ctx is the context
+// xPubID is the xPub ID
+// confirmations is the number of confirmations (transactions with fewer confirmations are returned)
+// metadataConditions is added to the request for searching
+// conditions is added the request for searching (can include "direction")
+func (c *Client) GetUnconfirmedTransactionsByXpubID(ctx context.Context, xPubID string, confirmations uint64,
+	metadataConditions *Metadata, conditions *map[string]interface{}, queryParams *datastore.QueryParams,
+) ([]*Transaction, error) {
+	// Check for existing NewRelic transaction
+	ctx = c.GetOrStartTxn(ctx, "get_unconfirmed_transactions")
+
+	// Get the transactions
+	return getUnconfirmedTransactionsByXpubID(
+		ctx, xPubID, confirmations, metadataConditions, conditions, queryParams,
+		c.DefaultModelOptions()...,
+	)
+}
+
 // GetTransactionsByXpubIDCount will get the count of all transactions matching the search criteria
 func (c *Client) GetTransactionsByXpubIDCount(ctx context.Context, xPubID string, metadataConditions *Metadata,
 	conditions *map[string]interface{},
@@ -481,76 +538,5 @@ func (c *Client) RevertTransaction(ctx context.Context, id string) error {
 	//
 	// Revert transaction and all related elements
 	//
-
-	// mark output utxos as deleted (no way to delete from Bux yet)
-	for _, utxo := range utxos {
-		utxo.enrich(ModelUtxo, c.DefaultModelOptions()...)
-		utxo.SpendingTxID.Valid = true
-		utxo.SpendingTxID.String = "deleted"
-		utxo.DeletedAt.Valid = true
-		utxo.DeletedAt.Time = time.Now()
-		if err = utxo.Save(ctx); err != nil {
-			return err
-		}
-	}
-
-	// remove output values of transaction from all xpubs
-	var xpub *Xpub
-	for xpubID, outputValue := range transaction.XpubOutputValue {
-		if xpub, err = c.GetXpubByID(ctx, xpubID); err != nil {
-			return err
-		}
-		if outputValue > 0 {
-			xpub.CurrentBalance -= uint64(outputValue)
-		} else {
-			xpub.CurrentBalance += uint64(math.Abs(float64(outputValue)))
-		}
-
-		if err = xpub.Save(ctx); err != nil {
-			return err
-		}
-	}
-
-	// set any inputs (spent utxos) used in this transaction back to not spent
-	var utxo *Utxo
-	for _, input := range draftTransaction.Configuration.Inputs {
-		if utxo, err = c.GetUtxoByTransactionID(ctx, input.TransactionID, input.OutputIndex); err != nil {
-			return err
-		}
-		utxo.SpendingTxID.Valid = false
-		utxo.SpendingTxID.String = ""
-		if err = utxo.Save(ctx); err != nil {
-			return err
-		}
-	}
-
-	// cancel sync transaction
-	var syncTransaction *SyncTransaction
-	if syncTransaction, err = GetSyncTransactionByID(ctx, transaction.ID, c.DefaultModelOptions()...); err != nil {
-		return err
-	}
-	syncTransaction.BroadcastStatus = SyncStatusCanceled
-	syncTransaction.P2PStatus = SyncStatusCanceled
-	syncTransaction.SyncStatus = SyncStatusCanceled
-	if err = syncTransaction.Save(ctx); err != nil {
-		return err
-	}
-
-	// revert transaction
-	// this takes the transaction out of any possible list view of the owners of the xpubs,
-	// but keeps a record of what went down
-	if transaction.Metadata == nil {
-		transaction.Metadata = Metadata{}
-	}
-	transaction.Metadata["XpubInIDs"] = transaction.XpubInIDs
-	transaction.Metadata["XpubOutIDs"] = transaction.XpubOutIDs
-	transaction.Metadata["XpubOutputValue"] = transaction.XpubOutputValue
-	transaction.XpubInIDs = IDs{"reverted"}
-	transaction.XpubOutIDs = IDs{"reverted"}
-	transaction.XpubOutputValue = XpubOutputValue{"reverted": 0}
-	transaction.DeletedAt.Valid = true
-	transaction.DeletedAt.Time = time.Now()
-	err = transaction.Save(ctx)
-
-	return err
+	return revertTransaction(ctx, transaction, nil)
 }
@@ -187,9 +187,9 @@ func Test_RevertTransaction(t *testing.T) {
 	})
 }
 
-func initRevertTransactionData(t *testing.T) (context.Context, ClientInterface, *Transaction, *bip32.ExtendedKey, func()) {
+func initRevertTransactionData(t *testing.T, opts ...ClientOps) (context.Context, ClientInterface, *Transaction, *bip32.ExtendedKey, func()) {
 	// this creates an xpub, destination and utxo
-	ctx, client, deferMe := initSimpleTestCase(t)
+	ctx, client, deferMe := initSimpleTestCase(t, opts...)
 
 	// we need a draft transaction, otherwise we cannot revert
 	draftTransaction := newDraftTransaction(
@@ -2,12 +2,9 @@ package bux
 
 import (
 	"context"
-	"sort"
+	"errors"
 
-	"github.com/BuxOrg/bux/utils"
-	"github.com/libsv/go-bt"
 	"github.com/mrz1836/go-datastore"
-	"github.com/mrz1836/go-whatsonchain"
 )
 
 // NewXpub will parse the xPub and save it into the Datastore
@@ -95,156 +92,90 @@ func (c *Client) UpdateXpubMetadata(ctx context.Context, xPubID string, metadata
 	return xPub, nil
 }
 
-// ImportXpub will import a given xPub and all related destinations and transactions
-//
-// xPubKey is the raw public xPub
-func (c *Client) ImportXpub(ctx context.Context, xPubKey string, opts ...ModelOps) (*ImportResults, error) {
+// UpdateXpubSpendingLimits will update the spending limits of an existing xPub (nil removes the limits)
+func (c *Client) UpdateXpubSpendingLimits(ctx context.Context, xPubID string, limits *SpendingLimits) (*Xpub, error) {
+
+	// Check for existing NewRelic transaction
+	ctx = c.GetOrStartTxn(ctx, "update_xpub_spending_limits")
 
-	// Validate the xPub
-	xPub, err := utils.ValidateXPub(xPubKey)
+	// Get the xPub
+	xPub, err := c.GetXpubByID(ctx, xPubID)
 	if err != nil {
 		return nil, err
 	}
 
-	// Start an accumulator
-	results := &ImportResults{Key: xPub.String()}
-
-	// Set the WOC client
-	woc := c.Chainstate().WhatsOnChain()
-	var allTransactions []*whatsonchain.HistoryRecord
-
-	// Assume gap of 10 addresses, if no txs are found for 10
-	maxGapLimit := 10
-
-	// internal/external derivations, assume we've found everything
-	gapHit := false
-	for !gapHit {
-
-		// Derive internal addresses until depth
-		c.Logger().Info(ctx, "Deriving internal addresses...")
-		addressList := whatsonchain.AddressList{}
-		var addresses []string
-		if addresses, err = c.deriveAddresses(
-			ctx, xPub.String(), utils.ChainInternal, maxGapLimit,
-		); err != nil {
-			return nil, err
-		}
-		results.InternalAddresses += maxGapLimit
-		addressList.Addresses = append(addressList.Addresses, addresses...)
-
-		// Derive external addresses until gap limit
-		c.Logger().Info(ctx, "Deriving external addresses...")
-		if addresses, err = c.deriveAddresses(
-			ctx, xPub.String(), utils.ChainExternal, maxGapLimit,
-		); err != nil {
-			return nil, err
-		}
-		results.ExternalAddresses += maxGapLimit
-		addressList.Addresses = append(addressList.Addresses, addresses...)
-
-		// Get all transactions for those addresses
-		/*transactions, addressesWithTransactions, err := getAllTransactionsFromAddresses(
-			ctx, woc, addressList,
-		)*/
-		var transactions []*whatsonchain.HistoryRecord
-		transactions, err = getTransactionsFromAddressesViaBitbus(addressList.Addresses)
-		if err != nil {
-			return nil, err
-		}
-		if len(transactions) == 0 {
-			gapHit = true
-		}
-		// results.AddressesWithTransactions = append(results.AddressesWithTransactions, addressesWithTransactions...)
-		allTransactions = append(allTransactions, transactions...)
+	// Update the limits
+	xPub.SpendingLimits = SpendingLimits{}
+	if limits != nil {
+		xPub.SpendingLimits = *limits
 	}
-	// Remove any duplicate transactions from all historical txs
-	allTransactions = removeDuplicates(allTransactions)
-
-	// Set all the hashes
-	txHashes := whatsonchain.TxHashes{}
-	for _, t := range allTransactions {
-		txHashes.TxIDs = append(txHashes.TxIDs, t.TxHash)
-		results.TransactionsFound++
+
+	// Save the model
+	if err = xPub.Save(ctx); err != nil {
+		return nil, err
 	}
 
-	// Run the bulk transaction processor
-	var rawTxs []string
-	var txInfos whatsonchain.TxList
-	if txInfos, err = woc.BulkRawTransactionDataProcessor(
-		ctx, &txHashes,
-	); err != nil {
+	// Return the model
+	return xPub, nil
+}
+
+// UpdateXpubMaxUnconfirmedAncestors will update the maximum depth of the unconfirmed ancestor chain of an
+// existing xPub (nil removes the limit of the xPub, the client default is used)
+func (c *Client) UpdateXpubMaxUnconfirmedAncestors(ctx context.Context, xPubID string,
+	depth *uint32) (*Xpub, error) {
+
+	// Check for existing NewRelic transaction
+	ctx = c.GetOrStartTxn(ctx, "update_xpub_max_unconfirmed_ancestors")
+
+	// Get the xPub
+	xPub, err := c.GetXpubByID(ctx, xPubID)
+	if err != nil {
 		return nil, err
 	}
 
-	// Loop and build from the inputs
-	var tx *bt.Tx
-	for i := 0; i < len(txInfos); i++ {
-		if tx, err = bt.NewTxFromString(
-			txInfos[i].Hex,
-		); err != nil {
-			return nil, err
-		}
-		var inputs []whatsonchain.VinInfo
-		for _, in := range tx.Inputs {
-			// todo: upgrade and use go-bt v2
-			vin := whatsonchain.VinInfo{
-				TxID: in.PreviousTxID,
-			}
-			inputs = append(inputs, vin)
-		}
-		txInfos[i].Vin = inputs
-		rawTxs = append(rawTxs, txInfos[i].Hex)
+	// Update the limit
+	xPub.MaxUnconfirmedAncestors = nil
+	if depth != nil {
+		limit := *depth
+		xPub.MaxUnconfirmedAncestors = &limit
+	}
+
+	// Save the model
+	if err = xPub.Save(ctx); err != nil {
+		return nil, err
 	}
 
-	// Sort all transactions by block height
-	c.Logger().Info(ctx, "Sorting transactions to be recorded...")
-	sort.Slice(txInfos, func(i, j int) bool {
-		return txInfos[i].BlockHeight < txInfos[j].BlockHeight
-	})
-
-	// Sort transactions that are in the same block by previous tx
-	for i := 0; i < len(txInfos); i++ {
-		info := txInfos[i]
-		bh := info.BlockHeight
-		var sameBlockTxs []*whatsonchain.TxInfo
-		sameBlockTxs = append(sameBlockTxs, info)
-
-		// Loop through all remaining txs until block height is not the same
-		for j := i + 1; j < len(txInfos); j++ {
-			if txInfos[j].BlockHeight == bh {
-				sameBlockTxs = append(sameBlockTxs, txInfos[j])
-			} else {
-				break
-			}
-		}
-		if len(sameBlockTxs) == 1 {
-			continue
-		}
-
-		// Sort transactions by whether previous txs are referenced in the inputs
-		sort.Slice(sameBlockTxs, func(i, j int) bool {
-			for _, in := range sameBlockTxs[i].Vin {
-				if in.TxID == sameBlockTxs[j].Hash {
-					return false
-				}
-			}
-			return true
-		})
-		copy(txInfos[i:i+len(sameBlockTxs)], sameBlockTxs)
-		i += len(sameBlockTxs) - 1
+	// Return the model
+	return xPub, nil
+}
+
+// ImportXpub will import a given xPub and all related destinations and transactions
+//
+// The import is processed as an import job until finished (using the bux derivation layout), use NewImportJob()
+// to import (large) xPubs in the background or to use another import profile (derivation layout)
+//
+// xPubKey is the raw public xPub
+func (c *Client) ImportXpub(ctx context.Context, xPubKey string, opts ...ModelOps) (*ImportResults, error) {
+
+	// Start the import job
+	job, err := c.NewImportJob(ctx, xPubKey, nil)
+	if err != nil {
+		return nil, err
 	}
 
-	// Record transactions in bux
-	for _, rawTx := range rawTxs {
-		if _, err = c.RecordTransaction(
-			ctx, xPubKey, rawTx, "", opts...,
-		); err != nil {
-			return nil, err
-		}
-		results.TransactionsImported++
+	// Process all the steps of the import job
+	if job, err = processImportJob(ctx, c, job.ID, 0, opts...); err != nil {
+		return nil, err
+	} else if job.Status == ImportJobStatusCanceled {
+		return nil, ErrImportJobCanceled
+	} else if job.Status == ImportJobStatusError {
+		return nil, errors.New(job.Error)
 	}
 
-	return results, nil
+	// Return the results (with the key that was imported)
+	results := job.Results
+	results.Key = xPubKey
+	return &results, nil
 }
 
 // GetXPubs gets all xpubs matching the conditions
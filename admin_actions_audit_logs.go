@@ -0,0 +1,92 @@
+package bux
+
+import (
+	"context"
+
+	"github.com/mrz1836/go-datastore"
+)
+
+// GetAuditLogs will get all the audit logs (entries) matching the conditions
+func (c *Client) GetAuditLogs(ctx context.Context, metadataConditions *Metadata,
+	conditions *map[string]interface{}, queryParams *datastore.QueryParams, opts ...ModelOps) ([]*AuditLog, error) {
+
+	// Check for existing NewRelic transaction
+	ctx = c.GetOrStartTxn(ctx, "admin_get_audit_logs")
+
+	// Get the audit logs
+	auditLogs, err := getAuditLogs(
+		ctx, metadataConditions, conditions, queryParams,
+		c.DefaultModelOptions(opts...)...,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return auditLogs, nil
+}
+
+// GetAuditLogsCount will get a count of all the audit logs (entries) matching the conditions
+func (c *Client) GetAuditLogsCount(ctx context.Context, metadataConditions *Metadata,
+	conditions *map[string]interface{}, opts ...ModelOps) (int64, error) {
+
+	// Check for existing NewRelic transaction
+	ctx = c.GetOrStartTxn(ctx, "admin_get_audit_logs_count")
+
+	// Get the audit logs count
+	count, err := getAuditLogsCount(
+		ctx, metadataConditions, conditions,
+		c.DefaultModelOptions(opts...)...,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// VerifyAuditLog will verify the hash chain of the complete audit log
+//
+// The unsealed entries are sealed first, returns ErrAuditLogTampered if any sealed entry was changed, removed or inserted
+func (c *Client) VerifyAuditLog(ctx context.Context) error {
+
+	// Check for existing NewRelic transaction
+	ctx = c.GetOrStartTxn(ctx, "admin_verify_audit_log")
+
+	// Seal the entries that are not chained yet
+	err := sealAuditLogs(ctx, c, true)
+	if err != nil {
+		return err
+	}
+
+	var previousHash string
+	sequence := uint64(1)
+	conditions := map[string]interface{}{
+		sequenceField: map[string]interface{}{
+			"$gt": 0,
+		},
+	}
+	queryParams := &datastore.QueryParams{
+		Page:          1,
+		PageSize:      defaultAuditLogVerifyPageSize,
+		OrderByField:  sequenceField,
+		SortDirection: datastore.SortAsc,
+	}
+
+	// Walk the chain (page by page)
+	for {
+		var auditLogs []*AuditLog
+		if auditLogs, err = getAuditLogs(
+			ctx, nil, &conditions, queryParams, c.DefaultModelOptions()...,
+		); err != nil {
+			return err
+		}
+		if sequence, err = verifyAuditLogs(auditLogs, previousHash, sequence); err != nil {
+			return err
+		}
+		if len(auditLogs) < queryParams.PageSize {
+			return nil
+		}
+		previousHash = auditLogs[len(auditLogs)-1].Hash
+		queryParams.Page++
+	}
+}
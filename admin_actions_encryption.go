@@ -0,0 +1,231 @@
+package bux
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/BuxOrg/bux/utils"
+	"github.com/mrz1836/go-datastore"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// encryptionRotateTask is the name of the (cron) task for rotating the encryption
+const encryptionRotateTask = "encryption_rotate"
+
+// RotateEncryption will re-encrypt all the sensitive values that are not encrypted using the active key,
+// and encrypt the sensitive values that are not encrypted yet (IE: after enabling the encryption)
+//
+// Only the encrypted columns are updated (no model hooks), and only if the record did not change in the
+// meantime (changed records are rotated by the next run). The old key(s) can be removed once all records
+// are rotated (returns the number of records that were re-encrypted)
+func (c *Client) RotateEncryption(ctx context.Context) (int, error) {
+
+	// Check for existing NewRelic transaction
+	ctx = c.GetOrStartTxn(ctx, "admin_rotate_encryption")
+
+	// No encryption provider
+	if c.Encryption() == nil {
+		return 0, ErrMissingEncryptionProvider
+	}
+
+	// Only one rotation at a time
+	unlock, err := newWriteLock(ctx, lockKeyRotateEncryption, c.Cachestore())
+	defer unlock()
+	if err != nil {
+		return 0, err
+	}
+
+	// Rotate all the models (the audit log is append-only)
+	fingerprint := encryptionFingerprint(c)
+	rotated, skipped := 0, 0
+	for _, model := range c.options.models.models {
+		if model.(ModelInterface).GetModelName() == ModelAuditLog.String() {
+			continue
+		}
+		var count, changed int
+		count, changed, err = c.rotateModelEncryption(ctx, model)
+		rotated += count
+		skipped += changed
+		if err != nil {
+			return rotated, err
+		}
+	}
+
+	// Records changed during the rotation (rotated by the next run)
+	if skipped > 0 {
+		c.Logger().Info(ctx, fmt.Sprintf(
+			"encryption rotated to key %s: %d records re-encrypted, %d records changed during the rotation",
+			c.Encryption().ActiveKeyID(), rotated, skipped,
+		))
+		return rotated, nil
+	}
+
+	// Remember the completed rotation (skips the rotation task until the key changes)
+	if err = c.Cachestore().Set(ctx, cacheKeyEncryptionRotation, fingerprint); err != nil {
+		return rotated, err
+	}
+
+	c.Logger().Info(ctx, fmt.Sprintf(
+		"encryption rotated to key %s: %d records re-encrypted", c.Encryption().ActiveKeyID(), rotated,
+	))
+	return rotated, nil
+}
+
+// rotateModelEncryption will re-encrypt the sensitive values of all the records of the model (page by page)
+//
+// Returns the number of records that were re-encrypted, and the number of records that changed in the meantime
+func (c *Client) rotateModelEncryption(ctx context.Context, model interface{}) (rotated, skipped int, err error) {
+	queryParams := &datastore.QueryParams{
+		Page:          1,
+		PageSize:      defaultEncryptionPageSize,
+		OrderByField:  idField,
+		SortDirection: datastore.SortAsc,
+	}
+
+	modelType := reflect.TypeOf(model).Elem()
+	for {
+		records := reflect.New(reflect.SliceOf(modelType))
+		if err = getModels(
+			ctx, c.Datastore(), records.Interface(), nil, queryParams, defaultDatabaseReadTimeout,
+		); err != nil {
+			if errors.Is(err, datastore.ErrNoResults) {
+				return rotated, skipped, nil
+			}
+			return
+		}
+
+		list := records.Elem()
+		for index := 0; index < list.Len(); index++ {
+			record, ok := list.Index(index).Addr().Interface().(ModelInterface)
+			if !ok {
+				return rotated, skipped, nil
+			}
+			encrypter, ok := record.(sensitiveFieldsEncrypter)
+			if !ok {
+				return rotated, skipped, nil
+			}
+			record.SetOptions(c.DefaultModelOptions()...)
+
+			// The stored values are the condition of the update
+			stored := encrypter.getEncryptedFields()
+			updatedAt, _ := list.Index(index).FieldByName("UpdatedAt").Interface().(time.Time)
+
+			var changed, updated bool
+			if changed, err = encrypter.encryptSensitiveFields(); err != nil {
+				return
+			} else if !changed {
+				continue
+			}
+			if updated, err = c.saveRotatedModel(
+				ctx, record, stored, encrypter.getEncryptedFields(), updatedAt,
+			); err != nil {
+				return
+			} else if !updated {
+				skipped++
+				continue
+			}
+			rotated++
+		}
+
+		if list.Len() < queryParams.PageSize {
+			return rotated, skipped, nil
+		}
+		queryParams.Page++
+	}
+}
+
+// saveRotatedModel will update the re-encrypted columns of the record (without firing any model hooks)
+//
+// The update is conditional on the stored (old) values of the changed columns (the updated_at of the record
+// on Mongo), returns false if the record changed in the meantime
+func (c *Client) saveRotatedModel(ctx context.Context, model ModelInterface, stored, fields map[string]interface{},
+	updatedAt time.Time) (bool, error) {
+
+	// Only the changed columns (sorted for a stable query)
+	columns := make([]string, 0, len(fields))
+	for column, value := range fields {
+		if !reflect.DeepEqual(stored[column], value) {
+			columns = append(columns, column)
+		}
+	}
+	if len(columns) == 0 {
+		return true, nil
+	}
+	sort.Strings(columns)
+
+	ds := c.Datastore()
+	if ds.Engine() == datastore.MongoDB {
+		filter := bson.M{"_id": model.GetID()}
+		if updatedAt.IsZero() {
+			filter[updatedAtField] = bson.M{"$exists": false}
+		} else {
+			filter[updatedAtField] = updatedAt
+		}
+		set := bson.M{}
+		for _, column := range columns {
+			if value, ok := stored[column].(string); ok {
+				filter[column] = value
+			}
+			switch value := fields[column].(type) {
+			case Metadata:
+				set[column] = &value
+			case XpubMetadata:
+				set[column] = &value
+			default:
+				set[column] = value
+			}
+		}
+		result, err := ds.GetMongoCollection(model.GetModelTableName()).UpdateOne(
+			ctx, filter, bson.M{"$set": set},
+		)
+		if err != nil {
+			return false, err
+		}
+		return result.MatchedCount > 0, nil
+	}
+
+	// Quote the columns for the SQL engine
+	quote := `"`
+	if ds.Engine() == datastore.MySQL {
+		quote = "`"
+	}
+
+	sets := make([]string, 0, len(columns))
+	conditions := []string{quote + idField + quote + " = ?"}
+	values := make([]interface{}, 0, 2*len(columns)+1)
+	for _, column := range columns {
+		sets = append(sets, quote+column+quote+" = ?")
+		values = append(values, fields[column])
+	}
+	values = append(values, model.GetID())
+	for _, column := range columns {
+		if _, ok := stored[column].(string); !ok && ds.Engine() == datastore.MySQL {
+			conditions = append(conditions, quote+column+quote+" = CAST(? AS JSON)")
+		} else {
+			conditions = append(conditions, quote+column+quote+" = ?")
+		}
+		values = append(values, stored[column])
+	}
+
+	tx := ds.Raw("").Exec(
+		"UPDATE "+quote+ds.GetTableName(model.GetModelTableName())+quote+
+			" SET "+strings.Join(sets, ", ")+" WHERE "+strings.Join(conditions, " AND "),
+		values...,
+	)
+	if tx.Error != nil {
+		return false, tx.Error
+	}
+	return tx.RowsAffected > 0, nil
+}
+
+// encryptionFingerprint will return the fingerprint of the encryption (active key id & sensitive metadata keys)
+func encryptionFingerprint(client ClientInterface) string {
+	keys := append([]string{}, client.SensitiveMetadataKeys()...)
+	sort.Strings(keys)
+	return utils.Hash(client.Encryption().ActiveKeyID() + "|" + strings.Join(keys, ","))
+}
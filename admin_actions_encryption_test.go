@@ -0,0 +1,233 @@
+package bux
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestClient_RotateEncryption will test the method RotateEncryption()
+func TestClient_RotateEncryption(t *testing.T) {
+
+	t.Run("error - no encryption", func(t *testing.T) {
+		ctx, client, deferMe := CreateTestSQLiteClient(t, false, false)
+		defer deferMe()
+
+		rotated, err := client.RotateEncryption(ctx)
+		require.ErrorIs(t, err, ErrMissingEncryptionProvider)
+		assert.Equal(t, 0, rotated)
+	})
+
+	t.Run("rotate keys", func(t *testing.T) {
+		kms := newTestLocalKMS(t)
+		provider, err := NewEnvelopeEncryption(kms, "")
+		require.NoError(t, err)
+
+		ctx, client, deferMe := CreateTestSQLiteClient(
+			t, false, false, WithEncryptionProvider(provider), WithSensitiveMetadata("secret"),
+			WithAutoMigrate(&PaymailAddress{}),
+		)
+		defer deferMe()
+
+		// Create the records (encrypted using key-1)
+		_, err = client.NewXpub(ctx, testXPub, append(
+			client.DefaultModelOptions(), WithMetadatas(Metadata{"secret": "my-secret", "public": "value"}),
+		)...)
+		require.NoError(t, err)
+
+		_, err = client.NewPaymailAddress(ctx, testXPub, testPaymail, testPublicName, testAvatar, client.DefaultModelOptions()...)
+		require.NoError(t, err)
+
+		var xPub *Xpub
+		xPub, err = getXpubByID(ctx, testXPubID, client.DefaultModelOptions()...)
+		require.NoError(t, err)
+		require.NotNil(t, xPub)
+		assert.Equal(t, "value", xPub.Metadata["public"])
+		encrypted, ok := getEncryptedMetadataValue(xPub.Metadata["secret"])
+		require.True(t, ok)
+		assert.Equal(t, true, provider.IsCurrent(encrypted))
+
+		var paymailAddress *PaymailAddress
+		paymailAddress, err = getPaymailAddress(ctx, testPaymail, client.DefaultModelOptions()...)
+		require.NoError(t, err)
+		require.NotNil(t, paymailAddress)
+		assert.Equal(t, true, provider.IsCurrent(paymailAddress.ExternalXpubKey))
+
+		// Nothing to rotate
+		var rotated int
+		rotated, err = client.RotateEncryption(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 0, rotated)
+
+		// Rotate to key-2
+		kms.activeKeyID = "key-2"
+		rotated, err = client.RotateEncryption(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 2, rotated)
+
+		// Remove the old key (all values are encrypted using key-2)
+		delete(kms.keys, "key-1")
+
+		xPub, err = getXpubByID(ctx, testXPubID, client.DefaultModelOptions()...)
+		require.NoError(t, err)
+		require.NotNil(t, xPub)
+		encrypted, ok = getEncryptedMetadataValue(xPub.Metadata["secret"])
+		require.True(t, ok)
+		assert.Equal(t, true, provider.IsCurrent(encrypted))
+
+		var metadata Metadata
+		metadata, err = client.DecryptMetadata(xPub.Metadata)
+		require.NoError(t, err)
+		assert.Equal(t, "my-secret", metadata["secret"])
+
+		paymailAddress, err = getPaymailAddress(ctx, testPaymail, client.DefaultModelOptions()...)
+		require.NoError(t, err)
+		require.NotNil(t, paymailAddress)
+		assert.Equal(t, true, provider.IsCurrent(paymailAddress.ExternalXpubKey))
+
+		_, err = paymailAddress.GetIdentityXpub()
+		require.NoError(t, err)
+	})
+
+	t.Run("encrypt existing values", func(t *testing.T) {
+		ctx, client, deferMe := CreateTestSQLiteClient(t, false, false, WithAutoMigrate(&PaymailAddress{}))
+		defer deferMe()
+
+		// Plain text values (no encryption)
+		_, err := client.NewXpub(ctx, testXPub, append(
+			client.DefaultModelOptions(), WithMetadatas(Metadata{"secret": "my-secret"}),
+		)...)
+		require.NoError(t, err)
+
+		_, err = client.NewPaymailAddress(ctx, testXPub, testPaymail, testPublicName, testAvatar, client.DefaultModelOptions()...)
+		require.NoError(t, err)
+
+		// Enable the encryption
+		c := client.(*Client)
+		c.options.encryption.provider = NewStaticKeyEncryption(testEncryption)
+		c.options.encryption.sensitiveMetadata = []string{"secret"}
+
+		var rotated int
+		rotated, err = client.RotateEncryption(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 2, rotated)
+
+		var xPub *Xpub
+		xPub, err = getXpubByID(ctx, testXPubID, client.DefaultModelOptions()...)
+		require.NoError(t, err)
+		require.NotNil(t, xPub)
+		_, ok := getEncryptedMetadataValue(xPub.Metadata["secret"])
+		assert.True(t, ok)
+
+		var paymailAddress *PaymailAddress
+		paymailAddress, err = getPaymailAddress(ctx, testPaymail, client.DefaultModelOptions()...)
+		require.NoError(t, err)
+		require.NotNil(t, paymailAddress)
+		assert.NotEqual(t, externalXPubID, paymailAddress.ExternalXpubKey)
+
+		_, err = paymailAddress.GetExternalXpub()
+		require.NoError(t, err)
+	})
+
+	t.Run("transaction xpub metadata", func(t *testing.T) {
+		ctx, client, deferMe := CreateTestSQLiteClient(t, false, true, WithCustomTaskManager(&taskManagerMockBase{}))
+		defer deferMe()
+
+		// Plain text values (no encryption)
+		transaction := newTransaction(testTxHex, append(client.DefaultModelOptions(), New())...)
+		transaction.XpubMetadata = XpubMetadata{testXPubID: Metadata{"secret": "my-secret", "public": "value"}}
+		require.NoError(t, transaction.Save(ctx))
+
+		// Enable the encryption
+		c := client.(*Client)
+		c.options.encryption.provider = NewStaticKeyEncryption(testEncryption)
+		c.options.encryption.sensitiveMetadata = []string{"secret"}
+
+		rotated, err := client.RotateEncryption(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 1, rotated)
+
+		transaction, err = getTransactionByID(ctx, "", transaction.ID, client.DefaultModelOptions()...)
+		require.NoError(t, err)
+		require.NotNil(t, transaction)
+		assert.Equal(t, "value", transaction.XpubMetadata[testXPubID]["public"])
+		_, ok := getEncryptedMetadataValue(transaction.XpubMetadata[testXPubID]["secret"])
+		assert.True(t, ok)
+
+		var metadata Metadata
+		metadata, err = client.DecryptMetadata(transaction.XpubMetadata[testXPubID])
+		require.NoError(t, err)
+		assert.Equal(t, "my-secret", metadata["secret"])
+	})
+
+	t.Run("changed records are not overwritten", func(t *testing.T) {
+		ctx, client, deferMe := CreateTestSQLiteClient(t, false, false)
+		defer deferMe()
+
+		xPub, err := client.NewXpub(ctx, testXPub, append(
+			client.DefaultModelOptions(), WithMetadatas(Metadata{"secret": "my-secret"}),
+		)...)
+		require.NoError(t, err)
+		stored := xPub.getEncryptedFields()
+
+		// Enable the encryption
+		c := client.(*Client)
+		c.options.encryption.provider = NewStaticKeyEncryption(testEncryption)
+		c.options.encryption.sensitiveMetadata = []string{"secret"}
+
+		// The record changed after it was read for the rotation
+		_, err = client.UpdateXpubMetadata(ctx, xPub.ID, Metadata{"other": "value"})
+		require.NoError(t, err)
+
+		var changed, updated bool
+		changed, err = xPub.encryptSensitiveFields()
+		require.NoError(t, err)
+		require.True(t, changed)
+		updated, err = c.saveRotatedModel(ctx, xPub, stored, xPub.getEncryptedFields(), xPub.UpdatedAt)
+		require.NoError(t, err)
+		assert.False(t, updated)
+
+		xPub, err = getXpubByID(ctx, testXPubID, client.DefaultModelOptions()...)
+		require.NoError(t, err)
+		assert.Equal(t, "value", xPub.Metadata["other"])
+		_, ok := getEncryptedMetadataValue(xPub.Metadata["secret"])
+		assert.True(t, ok)
+	})
+}
+
+// Test_taskRotateEncryption will test the method taskRotateEncryption()
+func Test_taskRotateEncryption(t *testing.T) {
+	kms := newTestLocalKMS(t)
+	provider, err := NewEnvelopeEncryption(kms, "")
+	require.NoError(t, err)
+
+	ctx, client, deferMe := CreateTestSQLiteClient(
+		t, false, false, WithEncryptionProvider(provider), WithSensitiveMetadata("secret"),
+	)
+	defer deferMe()
+
+	_, err = client.NewXpub(ctx, testXPub, append(
+		client.DefaultModelOptions(), WithMetadatas(Metadata{"secret": "my-secret"}),
+	)...)
+	require.NoError(t, err)
+
+	// Rotate to key-2 (using the task)
+	kms.activeKeyID = "key-2"
+	err = taskRotateEncryption(ctx, client.Logger(), WithClient(client))
+	require.NoError(t, err)
+
+	var xPub *Xpub
+	xPub, err = getXpubByID(ctx, testXPubID, client.DefaultModelOptions()...)
+	require.NoError(t, err)
+	require.NotNil(t, xPub)
+	encrypted, ok := getEncryptedMetadataValue(xPub.Metadata["secret"])
+	require.True(t, ok)
+	assert.Equal(t, true, provider.IsCurrent(encrypted))
+
+	// Completed rotation is remembered
+	var fingerprint string
+	fingerprint, err = client.Cachestore().Get(ctx, cacheKeyEncryptionRotation)
+	require.NoError(t, err)
+	assert.Equal(t, encryptionFingerprint(client), fingerprint)
+}
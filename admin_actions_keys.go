@@ -0,0 +1,208 @@
+package bux
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/BuxOrg/bux/utils"
+	"github.com/mrz1836/go-datastore"
+)
+
+// BootstrapAdminKey will add the xPub as the first admin key (super admin)
+//
+// Only allowed when there are no admin keys in the Datastore yet (including revoked keys),
+// all the other admin keys are added by a super admin using NewAdminKey()
+// opts are options and can include "metadata"
+func (c *Client) BootstrapAdminKey(ctx context.Context, rawXpubKey string, opts ...ModelOps) (*AdminKey, error) {
+
+	// Check for existing NewRelic transaction
+	ctx = c.GetOrStartTxn(ctx, "admin_bootstrap_admin_key")
+
+	// Validate that the value is an xPub
+	if _, err := utils.ValidateXPub(rawXpubKey); err != nil {
+		return nil, err
+	}
+
+	// Create the lock and set the release for after the function completes
+	unlock, err := newWaitWriteLock(ctx, lockKeyBootstrapAdminKey, c.Cachestore())
+	defer unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	// Only without any admin keys
+	var count int64
+	if count, err = getAdminKeysCount(
+		ctx, nil, nil, c.DefaultModelOptions(opts...)...,
+	); err != nil {
+		return nil, err
+	} else if count > 0 {
+		return nil, ErrAdminKeysExist
+	}
+
+	// Save the model
+	xPubID := utils.Hash(rawXpubKey)
+	adminKey := newAdminKey(xPubID, AdminRoleSuperAdmin, "", c.DefaultModelOptions(append(opts, New())...)...)
+	if err = adminKey.Save(ctx); err != nil {
+		return nil, err
+	}
+
+	c.Logger().Info(ctx, fmt.Sprintf(
+		"admin key %s bootstrapped with role %s", xPubID, AdminRoleSuperAdmin,
+	))
+
+	// Return the created model
+	return adminKey, nil
+}
+
+// NewAdminKey will add the xPub as an admin key with the given role
+//
+// byAdminXPubID is the xPub ID of the admin adding the key (must be a super admin)
+// opts are options and can include "metadata"
+func (c *Client) NewAdminKey(ctx context.Context, rawXpubKey string, role AdminRole,
+	byAdminXPubID string, opts ...ModelOps) (*AdminKey, error) {
+
+	// Check for existing NewRelic transaction
+	ctx = c.GetOrStartTxn(ctx, "admin_new_admin_key")
+	opts = append(opts, WithAdminXPubID(byAdminXPubID))
+
+	// Validate that the value is an xPub
+	if _, err := utils.ValidateXPub(rawXpubKey); err != nil {
+		return nil, err
+	} else if !role.IsValid() {
+		return nil, ErrInvalidAdminRole
+	}
+
+	// Make sure the admin is allowed to manage the admin keys
+	if err := c.checkAdminCanManageAdmins(ctx, byAdminXPubID); err != nil {
+		return nil, err
+	}
+
+	// Check for an existing key (a revoked key is re-activated)
+	xPubID := utils.Hash(rawXpubKey)
+	adminKey, err := getAdminKey(ctx, xPubID, c.DefaultModelOptions(opts...)...)
+	if err != nil {
+		return nil, err
+	} else if adminKey == nil {
+		adminKey = newAdminKey(xPubID, role, byAdminXPubID, c.DefaultModelOptions(append(opts, New())...)...)
+	} else if adminKey.IsActive() {
+		return nil, ErrAdminKeyExists
+	} else {
+		adminKey.CreatedBy = byAdminXPubID
+		adminKey.Role = role
+		adminKey.RevokedAt.Valid = false
+		adminKey.RevokedBy = ""
+	}
+
+	// Save the model
+	if err = adminKey.Save(ctx); err != nil {
+		return nil, err
+	}
+
+	c.Logger().Info(ctx, fmt.Sprintf(
+		"admin key %s added with role %s by %s", xPubID, role, byAdminXPubID,
+	))
+
+	// Return the created model
+	return adminKey, nil
+}
+
+// RevokeAdminKey will revoke the admin key of the xPub (by xPub ID)
+//
+// byAdminXPubID is the xPub ID of the admin revoking the key (must be a super admin)
+// opts are options and can include "metadata"
+func (c *Client) RevokeAdminKey(ctx context.Context, xPubID, byAdminXPubID string,
+	opts ...ModelOps) (*AdminKey, error) {
+
+	// Check for existing NewRelic transaction
+	ctx = c.GetOrStartTxn(ctx, "admin_revoke_admin_key")
+	opts = append(opts, WithAdminXPubID(byAdminXPubID))
+
+	// Make sure the admin is allowed to manage the admin keys
+	if err := c.checkAdminCanManageAdmins(ctx, byAdminXPubID); err != nil {
+		return nil, err
+	}
+
+	// Get the admin key
+	adminKey, err := getAdminKey(ctx, xPubID, c.DefaultModelOptions(opts...)...)
+	if err != nil {
+		return nil, err
+	} else if adminKey == nil || !adminKey.IsActive() {
+		return nil, ErrMissingAdminKey
+	}
+
+	adminKey.RevokedAt.Valid = true
+	adminKey.RevokedAt.Time = time.Now().UTC()
+	adminKey.RevokedBy = byAdminXPubID
+
+	// Save the model
+	if err = adminKey.Save(ctx); err != nil {
+		return nil, err
+	}
+
+	c.Logger().Info(ctx, fmt.Sprintf(
+		"admin key %s revoked by %s", xPubID, byAdminXPubID,
+	))
+
+	// Return the updated model
+	return adminKey, nil
+}
+
+// GetAdminKey will get the (active) admin key of the xPub (by xPub ID)
+func (c *Client) GetAdminKey(ctx context.Context, xPubID string) (*AdminKey, error) {
+
+	// Check for existing NewRelic transaction
+	ctx = c.GetOrStartTxn(ctx, "admin_get_admin_key")
+
+	// Get the admin key
+	adminKey, err := getAdminKeyWithCache(ctx, c, xPubID, c.DefaultModelOptions()...)
+	if err != nil {
+		return nil, err
+	} else if adminKey == nil || !adminKey.IsActive() {
+		return nil, ErrMissingAdminKey
+	}
+
+	return adminKey, nil
+}
+
+// GetAdminKeys will get all the admin keys (including revoked keys) from the Datastore
+func (c *Client) GetAdminKeys(ctx context.Context, metadataConditions *Metadata,
+	conditions *map[string]interface{}, queryParams *datastore.QueryParams, opts ...ModelOps) ([]*AdminKey, error) {
+
+	// Check for existing NewRelic transaction
+	ctx = c.GetOrStartTxn(ctx, "admin_get_admin_keys")
+
+	// Get the admin keys
+	adminKeys, err := getAdminKeys(
+		ctx, metadataConditions, conditions, queryParams,
+		c.DefaultModelOptions(opts...)...,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return adminKeys, nil
+}
+
+// checkAdminCanManageAdmins will make sure the admin (by xPub ID) can add or revoke admin keys
+//
+// The configured admin xPubs (adminXPubs, no admin key in the Datastore) are super admins,
+// using the role of the authenticated request
+func (c *Client) checkAdminCanManageAdmins(ctx context.Context, adminXPubID string) error {
+	if len(adminXPubID) == 0 {
+		return ErrNotAdminKey
+	} else if getAdminRoleFromContext(ctx, adminXPubID) == AdminRoleSuperAdmin {
+		return nil
+	}
+
+	adminKey, err := getAdminKeyWithCache(ctx, c, adminXPubID, c.DefaultModelOptions()...)
+	if err != nil {
+		return err
+	} else if adminKey == nil || !adminKey.IsActive() {
+		return ErrNotAdminKey
+	} else if !adminKey.Role.CanManageAdmins() {
+		return ErrAdminNotAllowed
+	}
+	return nil
+}
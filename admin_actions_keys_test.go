@@ -0,0 +1,208 @@
+package bux
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/BuxOrg/bux/utils"
+	"github.com/bitcoinschema/go-bitcoin/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestAdminXPub will create a new random xPub (and its id) for the admin tests
+func newTestAdminXPub(t *testing.T) (string, string) {
+	key, err := bitcoin.GenerateHDKey(bitcoin.SecureSeedLength)
+	require.NoError(t, err)
+	var xPub string
+	xPub, err = bitcoin.GetExtendedPublicKey(key)
+	require.NoError(t, err)
+	return xPub, utils.Hash(xPub)
+}
+
+// addTestAdminKey will add the admin key with the given role
+func addTestAdminKey(ctx context.Context, t *testing.T, client ClientInterface, xPubID string, role AdminRole) {
+	adminKey := newAdminKey(xPubID, role, "", append(client.DefaultModelOptions(), New())...)
+	require.NoError(t, adminKey.Save(ctx))
+}
+
+// TestAdminRole_IsValid will test the methods of AdminRole
+func TestAdminRole_IsValid(t *testing.T) {
+	t.Parallel()
+
+	assert.True(t, AdminRoleSuperAdmin.IsValid())
+	assert.True(t, AdminRoleAuditor.IsValid())
+	assert.True(t, AdminRoleSupport.IsValid())
+	assert.False(t, AdminRole("").IsValid())
+	assert.False(t, AdminRole("root").IsValid())
+
+	assert.True(t, AdminRoleAuditor.IsReadOnly())
+	assert.False(t, AdminRoleSupport.IsReadOnly())
+	assert.True(t, AdminRoleSuperAdmin.CanManageAdmins())
+	assert.False(t, AdminRoleSupport.CanManageAdmins())
+	assert.False(t, AdminRoleAuditor.CanManageAdmins())
+}
+
+// TestClient_NewAdminKey will test the method NewAdminKey()
+func TestClient_NewAdminKey(t *testing.T) {
+
+	t.Run("bootstrap and add by super admin", func(t *testing.T) {
+		ctx, client, deferMe := CreateTestSQLiteClient(t, false, false)
+		defer deferMe()
+
+		// Without an admin
+		superXPub, superID := newTestAdminXPub(t)
+		_, err := client.NewAdminKey(ctx, superXPub, AdminRoleSuperAdmin, "")
+		require.ErrorIs(t, err, ErrNotAdminKey)
+
+		var adminKey *AdminKey
+		adminKey, err = client.BootstrapAdminKey(ctx, superXPub)
+		require.NoError(t, err)
+		assert.Equal(t, superID, adminKey.ID)
+		assert.Equal(t, AdminRoleSuperAdmin, adminKey.Role)
+		assert.Empty(t, adminKey.CreatedBy)
+
+		auditorXPub, auditorID := newTestAdminXPub(t)
+		adminKey, err = client.NewAdminKey(ctx, auditorXPub, AdminRoleAuditor, superID)
+		require.NoError(t, err)
+		assert.Equal(t, superID, adminKey.CreatedBy)
+
+		adminKey, err = client.GetAdminKey(ctx, auditorID)
+		require.NoError(t, err)
+		assert.Equal(t, AdminRoleAuditor, adminKey.Role)
+
+		var adminKeys []*AdminKey
+		adminKeys, err = client.GetAdminKeys(ctx, nil, nil, nil)
+		require.NoError(t, err)
+		assert.Len(t, adminKeys, 2)
+
+		// Already an admin
+		_, err = client.NewAdminKey(ctx, auditorXPub, AdminRoleSupport, superID)
+		require.ErrorIs(t, err, ErrAdminKeyExists)
+
+		// Only the first admin key can be bootstrapped
+		otherXPub, _ := newTestAdminXPub(t)
+		_, err = client.BootstrapAdminKey(ctx, otherXPub)
+		require.ErrorIs(t, err, ErrAdminKeysExist)
+	})
+
+	t.Run("invalid role", func(t *testing.T) {
+		ctx, client, deferMe := CreateTestSQLiteClient(t, false, false)
+		defer deferMe()
+
+		xPub, _ := newTestAdminXPub(t)
+		_, err := client.NewAdminKey(ctx, xPub, AdminRole("root"), "")
+		require.ErrorIs(t, err, ErrInvalidAdminRole)
+	})
+
+	t.Run("only super admins can add keys", func(t *testing.T) {
+		ctx, client, deferMe := CreateTestSQLiteClient(t, false, false)
+		defer deferMe()
+
+		_, supportID := newTestAdminXPub(t)
+		addTestAdminKey(ctx, t, client, supportID, AdminRoleSupport)
+
+		xPub, xPubID := newTestAdminXPub(t)
+		_, err := client.NewAdminKey(ctx, xPub, AdminRoleSupport, supportID)
+		require.ErrorIs(t, err, ErrAdminNotAllowed)
+
+		_, err = client.NewAdminKey(ctx, xPub, AdminRoleSupport, xPubID)
+		require.ErrorIs(t, err, ErrNotAdminKey)
+	})
+	t.Run("configured admin xpubs are super admins", func(t *testing.T) {
+		_, client, deferMe := CreateTestSQLiteClient(t, false, false)
+		defer deferMe()
+
+		key, err := bitcoin.GenerateHDKey(bitcoin.SecureSeedLength)
+		require.NoError(t, err)
+		var adminXPub string
+		adminXPub, err = bitcoin.GetExtendedPublicKey(key)
+		require.NoError(t, err)
+
+		var req *http.Request
+		req, err = http.NewRequestWithContext(context.Background(), http.MethodGet, "", bytes.NewReader([]byte(`{}`)))
+		require.NoError(t, err)
+		require.NoError(t, SetSignature(&req.Header, key, `{}`))
+
+		// no admin key in the Datastore, the xPub is in the configured admin xPubs
+		req, err = client.AuthenticateRequest(context.Background(), req, []string{adminXPub}, true, true, false)
+		require.NoError(t, err)
+
+		xPub, _ := newTestAdminXPub(t)
+		var adminKey *AdminKey
+		adminKey, err = client.NewAdminKey(req.Context(), xPub, AdminRoleSupport, utils.Hash(adminXPub))
+		require.NoError(t, err)
+		assert.Equal(t, utils.Hash(adminXPub), adminKey.CreatedBy)
+
+		// not the admin of the request
+		otherXPub, otherID := newTestAdminXPub(t)
+		_, err = client.NewAdminKey(req.Context(), otherXPub, AdminRoleSupport, otherID)
+		require.ErrorIs(t, err, ErrNotAdminKey)
+	})
+}
+
+// TestClient_RevokeAdminKey will test the method RevokeAdminKey()
+func TestClient_RevokeAdminKey(t *testing.T) {
+
+	t.Run("revoke and re-activate", func(t *testing.T) {
+		ctx, client, deferMe := CreateTestSQLiteClient(t, false, false)
+		defer deferMe()
+
+		superXPub, superID := newTestAdminXPub(t)
+		_, err := client.BootstrapAdminKey(ctx, superXPub)
+		require.NoError(t, err)
+
+		supportXPub, supportID := newTestAdminXPub(t)
+		_, err = client.NewAdminKey(ctx, supportXPub, AdminRoleSupport, superID)
+		require.NoError(t, err)
+
+		// Load into the cache
+		_, err = client.GetAdminKey(ctx, supportID)
+		require.NoError(t, err)
+
+		var adminKey *AdminKey
+		adminKey, err = client.RevokeAdminKey(ctx, supportID, superID)
+		require.NoError(t, err)
+		assert.True(t, adminKey.RevokedAt.Valid)
+		assert.Equal(t, superID, adminKey.RevokedBy)
+
+		// Cache is updated
+		_, err = client.GetAdminKey(ctx, supportID)
+		require.ErrorIs(t, err, ErrMissingAdminKey)
+
+		// Already revoked
+		_, err = client.RevokeAdminKey(ctx, supportID, superID)
+		require.ErrorIs(t, err, ErrMissingAdminKey)
+
+		// Re-activate with another role
+		adminKey, err = client.NewAdminKey(ctx, supportXPub, AdminRoleAuditor, superID)
+		require.NoError(t, err)
+		assert.False(t, adminKey.RevokedAt.Valid)
+		assert.Empty(t, adminKey.RevokedBy)
+
+		adminKey, err = client.GetAdminKey(ctx, supportID)
+		require.NoError(t, err)
+		assert.Equal(t, AdminRoleAuditor, adminKey.Role)
+	})
+
+	t.Run("only super admins can revoke keys", func(t *testing.T) {
+		ctx, client, deferMe := CreateTestSQLiteClient(t, false, false)
+		defer deferMe()
+
+		_, auditorID := newTestAdminXPub(t)
+		addTestAdminKey(ctx, t, client, auditorID, AdminRoleAuditor)
+
+		_, err := client.RevokeAdminKey(ctx, auditorID, auditorID)
+		require.ErrorIs(t, err, ErrAdminNotAllowed)
+
+		_, err = client.RevokeAdminKey(ctx, auditorID, "")
+		require.ErrorIs(t, err, ErrNotAdminKey)
+
+		_, superID := newTestAdminXPub(t)
+		addTestAdminKey(ctx, t, client, superID, AdminRoleSuperAdmin)
+		_, err = client.RevokeAdminKey(ctx, utils.Hash("unknown"), superID)
+		require.ErrorIs(t, err, ErrMissingAdminKey)
+	})
+}
@@ -0,0 +1,357 @@
+package bux
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/BuxOrg/bux/utils"
+)
+
+// XpubExport is a portable archive of all the data related to an xPub (see ExportXpubData())
+type XpubExport struct {
+	AccessKeys       []*AccessKey      `json:"access_keys"`
+	Destinations     []*Destination    `json:"destinations"`
+	ExportedAt       time.Time         `json:"exported_at"`
+	PaymailAddresses []*PaymailAddress `json:"paymail_addresses"`
+	Transactions     []*Transaction    `json:"transactions"`
+	Utxos            []*Utxo           `json:"utxos"`
+	Xpub             *Xpub             `json:"xpub"`
+}
+
+// ErasureResults are the results from erasing the personal data of an xPub (number of records changed per model)
+type ErasureResults struct {
+	AccessKeys        int    `json:"access_keys"`
+	AuditLogs         int    `json:"audit_logs"`
+	Destinations      int    `json:"destinations"`
+	DraftTransactions int    `json:"draft_transactions"`
+	PaymailAddresses  int    `json:"paymail_addresses"`
+	Transactions      int    `json:"transactions"`
+	Utxos             int    `json:"utxos"`
+	XpubID            string `json:"xpub_id"`
+}
+
+// ExportXpubData will export all the data related to the xPub (data export request)
+//
+// Sensitive metadata (and the external xPub of the paymail addresses) is decrypted, and the transactions only
+// contain the metadata & values of the given xPub
+func (c *Client) ExportXpubData(ctx context.Context, xPubID string) (*XpubExport, error) {
+
+	// Check for existing NewRelic transaction
+	ctx = c.GetOrStartTxn(ctx, "admin_export_xpub_data")
+
+	// Get the xPub
+	xPub, err := getXpubByID(ctx, xPubID, c.DefaultModelOptions()...)
+	if err != nil {
+		return nil, err
+	} else if xPub == nil {
+		return nil, ErrMissingXpub
+	}
+
+	export := &XpubExport{
+		ExportedAt: time.Now().UTC(),
+		Xpub:       xPub,
+	}
+	if err = c.decryptModelMetadata(&xPub.Model); err != nil {
+		return nil, err
+	}
+
+	// Get the access keys
+	if export.AccessKeys, err = getAccessKeysByXPubID(
+		ctx, xPubID, nil, nil, nil, c.DefaultModelOptions()...,
+	); err != nil {
+		return nil, err
+	}
+	for _, accessKey := range export.AccessKeys {
+		if err = c.decryptModelMetadata(&accessKey.Model); err != nil {
+			return nil, err
+		}
+	}
+
+	// Get the destinations
+	if export.Destinations, err = getDestinationsByXpubID(
+		ctx, xPubID, nil, nil, nil, c.DefaultModelOptions()...,
+	); err != nil {
+		return nil, err
+	}
+	for _, destination := range export.Destinations {
+		if err = c.decryptModelMetadata(&destination.Model); err != nil {
+			return nil, err
+		}
+	}
+
+	// Get the paymail addresses
+	if export.PaymailAddresses, err = getPaymailAddresses(
+		ctx, nil, &map[string]interface{}{xPubIDField: xPubID}, nil, c.DefaultModelOptions()...,
+	); err != nil {
+		return nil, err
+	}
+	for _, paymailAddress := range export.PaymailAddresses {
+		if err = c.decryptModelMetadata(&paymailAddress.Model); err != nil {
+			return nil, err
+		}
+		paymailAddress.enrich(ModelPaymailAddress, c.DefaultModelOptions()...)
+		if _, err = paymailAddress.GetExternalXpub(); err != nil {
+			return nil, err
+		}
+		paymailAddress.ExternalXpubKey = paymailAddress.externalXpubKeyDecrypted
+	}
+
+	// Get the transactions (only the xPub specific metadata & values)
+	var transactions []*Transaction
+	if transactions, err = getTransactionsByXpubID(
+		ctx, xPubID, nil, nil, nil, c.DefaultModelOptions()...,
+	); err != nil {
+		return nil, err
+	}
+	export.Transactions = make([]*Transaction, 0, len(transactions))
+	for _, transaction := range transactions {
+		transaction.Display()
+		if err = c.decryptModelMetadata(&transaction.Model); err != nil {
+			return nil, err
+		}
+		export.Transactions = append(export.Transactions, transaction)
+	}
+
+	// Get the utxos
+	if export.Utxos, err = getUtxosByXpubID(
+		ctx, xPubID, nil, nil, nil, c.DefaultModelOptions()...,
+	); err != nil {
+		return nil, err
+	}
+	for _, utxo := range export.Utxos {
+		if err = c.decryptModelMetadata(&utxo.Model); err != nil {
+			return nil, err
+		}
+	}
+
+	return export, nil
+}
+
+// EraseXpub will remove the personal data of the xPub from all the models (data deletion request)
+//
+// The metadata of the xPub, access keys, destinations, draft transactions and utxos is removed, access keys are
+// revoked and paymail addresses are anonymized & deleted. The transactions keep all the ledger data (hex, xPub ids
+// and values), only the xPub specific metadata is removed (and the metadata of transactions that are not shared
+// with any other xPub). The audit log entries are not changed (hash chain), only their personal data (metadata,
+// alias, domain, public name & avatar) is erased: the chained changes only hold a salted digest of these fields (see
+// AuditLog), and the personal data is redacted in the entries of the erasure.
+func (c *Client) EraseXpub(ctx context.Context, xPubID string) (*ErasureResults, error) {
+
+	// Check for existing NewRelic transaction
+	ctx = c.GetOrStartTxn(ctx, "admin_erase_xpub")
+
+	// Do not write the erased personal data to the audit log
+	ctx = withAuditPersonalDataRedacted(ctx)
+
+	// No new transactions for this xPub while erasing
+	unlock, err := newWaitWriteLock(
+		ctx, fmt.Sprintf(lockKeyProcessXpub, xPubID), c.Cachestore(),
+	)
+	defer unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	// Get the xPub
+	var xPub *Xpub
+	if xPub, err = getXpubByID(ctx, xPubID, c.DefaultModelOptions()...); err != nil {
+		return nil, err
+	} else if xPub == nil {
+		return nil, ErrMissingXpub
+	}
+
+	results := &ErasureResults{XpubID: xPubID}
+
+	// The records of the xPub (erase the personal data of their audit log entries)
+	recordIDs := []string{xPubID}
+
+	// Revoke the access keys
+	var accessKeys []*AccessKey
+	if accessKeys, err = getAccessKeysByXPubID(
+		ctx, xPubID, nil, nil, nil, c.DefaultModelOptions()...,
+	); err != nil {
+		return nil, err
+	}
+	for _, accessKey := range accessKeys {
+		recordIDs = append(recordIDs, accessKey.ID)
+		if len(accessKey.Metadata) == 0 && accessKey.RevokedAt.Valid {
+			continue
+		}
+		accessKey.Metadata = nil
+		if !accessKey.RevokedAt.Valid {
+			accessKey.RevokedAt.Valid = true
+			accessKey.RevokedAt.Time = time.Now()
+		}
+		if err = accessKey.Save(ctx); err != nil {
+			return nil, err
+		}
+		results.AccessKeys++
+	}
+
+	// Remove the metadata of the destinations (destinations are kept for monitoring & shared transactions)
+	var destinations []*Destination
+	if destinations, err = getDestinationsByXpubID(
+		ctx, xPubID, nil, nil, nil, c.DefaultModelOptions()...,
+	); err != nil {
+		return nil, err
+	}
+	for _, destination := range destinations {
+		recordIDs = append(recordIDs, destination.ID)
+		if len(destination.Metadata) == 0 {
+			continue
+		}
+		destination.Metadata = nil
+		if err = destination.Save(ctx); err != nil {
+			return nil, err
+		}
+		results.Destinations++
+	}
+
+	// Remove the metadata of the draft transactions
+	var drafts []*DraftTransaction
+	if drafts, err = getDraftTransactions(
+		ctx, nil, &map[string]interface{}{xPubIDField: xPubID}, nil, c.DefaultModelOptions()...,
+	); err != nil {
+		return nil, err
+	}
+	for _, draft := range drafts {
+		recordIDs = append(recordIDs, draft.ID)
+		if len(draft.Metadata) == 0 {
+			continue
+		}
+		draft.enrich(ModelDraftTransaction, c.DefaultModelOptions()...)
+		draft.Metadata = nil
+		if err = draft.Save(ctx); err != nil {
+			return nil, err
+		}
+		results.DraftTransactions++
+	}
+
+	// Anonymize & delete the paymail addresses
+	var ids []string
+	if ids, err = c.erasePaymailAddresses(ctx, xPubID); err != nil {
+		return nil, err
+	}
+	results.PaymailAddresses = len(ids)
+	recordIDs = append(recordIDs, ids...)
+
+	// Remove the xPub specific metadata of the transactions
+	if results.Transactions, ids, err = c.eraseTransactionsMetadata(ctx, xPubID); err != nil {
+		return nil, err
+	}
+	recordIDs = append(recordIDs, ids...)
+
+	// Remove the metadata of the utxos
+	var utxos []*Utxo
+	if utxos, err = getUtxosByXpubID(
+		ctx, xPubID, nil, nil, nil, c.DefaultModelOptions()...,
+	); err != nil {
+		return nil, err
+	}
+	for _, utxo := range utxos {
+		recordIDs = append(recordIDs, utxo.ID)
+		if len(utxo.Metadata) == 0 {
+			continue
+		}
+		utxo.Metadata = nil
+		if err = utxo.Save(ctx); err != nil {
+			return nil, err
+		}
+		results.Utxos++
+	}
+
+	// Remove the metadata of the xPub (the xPub is kept for the balance & the ledger)
+	xPub.Metadata = nil
+	if err = xPub.Save(ctx); err != nil {
+		return nil, err
+	}
+
+	// Erase the personal data of the audit log entries of the records (and made by the xPub)
+	if results.AuditLogs, err = eraseAuditLogsPersonalData(ctx, c, xPubID, recordIDs); err != nil {
+		return nil, err
+	}
+
+	c.Logger().Info(ctx, fmt.Sprintf("erased personal data of xpub %s", xPubID))
+	return results, nil
+}
+
+// erasePaymailAddresses will anonymize & soft delete all the paymail addresses of the xPub (returns their ids)
+func (c *Client) erasePaymailAddresses(ctx context.Context, xPubID string) ([]string, error) {
+	paymailAddresses, err := getPaymailAddresses(
+		ctx, nil, &map[string]interface{}{xPubIDField: xPubID}, nil, c.DefaultModelOptions()...,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, 0, len(paymailAddresses))
+	for _, paymailAddress := range paymailAddresses {
+		ids = append(ids, paymailAddress.ID)
+		paymailAddress.enrich(ModelPaymailAddress, c.DefaultModelOptions()...)
+
+		// Random alias & domain (unique index on Alias/Domain)
+		if paymailAddress.Alias, err = utils.RandomHex(16); err != nil {
+			return nil, err
+		}
+		if paymailAddress.Domain, err = utils.RandomHex(16); err != nil {
+			return nil, err
+		}
+		paymailAddress.Avatar = ""
+		paymailAddress.Metadata = nil
+		paymailAddress.PublicName = ""
+		if !paymailAddress.DeletedAt.Valid {
+			paymailAddress.DeletedAt.Valid = true
+			paymailAddress.DeletedAt.Time = time.Now()
+		}
+		if err = paymailAddress.Save(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	return ids, nil
+}
+
+// eraseTransactionsMetadata will remove the xPub specific metadata of all the transactions of the xPub
+//
+// The (shared) metadata is only removed if the transaction is not shared with any other xPub (returns the number
+// of erased transactions and the ids of all the transactions)
+func (c *Client) eraseTransactionsMetadata(ctx context.Context, xPubID string) (int, []string, error) {
+	transactions, err := getTransactionsByXpubID(
+		ctx, xPubID, nil, nil, nil, c.DefaultModelOptions()...,
+	)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	erased := 0
+	ids := make([]string, 0, len(transactions))
+	for _, transaction := range transactions {
+		ids = append(ids, transaction.ID)
+		_, hasXpubMetadata := transaction.XpubMetadata[xPubID]
+		isShared := transaction.isSharedWithOtherXpubs(xPubID)
+		if !hasXpubMetadata && (isShared || len(transaction.Metadata) == 0) {
+			continue
+		}
+
+		delete(transaction.XpubMetadata, xPubID)
+		if !isShared {
+			transaction.Metadata = nil
+		}
+
+		// Do not copy the (shared) metadata back into the xPub specific metadata (see Save())
+		transaction.XPubID = ""
+		if err = transaction.Save(ctx); err != nil {
+			return erased, nil, err
+		}
+		erased++
+	}
+
+	return erased, ids, nil
+}
+
+// decryptModelMetadata will decrypt the sensitive metadata of the model (for exporting)
+func (c *Client) decryptModelMetadata(m *Model) (err error) {
+	m.Metadata, err = c.DecryptMetadata(m.Metadata)
+	return
+}
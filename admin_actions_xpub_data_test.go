@@ -0,0 +1,270 @@
+package bux
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testOtherXPubID = "62910a1ecbc7728afad563ab3f8aa70568ed934d1e0383cb1bbbfb1bc8f2afe5"
+
+// initXpubDataTestCase will create an xPub with metadata on all the related models
+//
+// The first transaction only belongs to the xPub, the second transaction is shared with another xPub (returns the ID)
+func initXpubDataTestCase(t *testing.T, opts ...ClientOps) (context.Context, ClientInterface, string, func()) {
+	ctx, client, deferMe := CreateTestSQLiteClient(
+		t, false, true, append([]ClientOps{
+			WithCustomTaskManager(&taskManagerMockBase{}), WithAutoMigrate(&PaymailAddress{}),
+		}, opts...)...,
+	)
+
+	xPub := newXpub(testXPub, append(client.DefaultModelOptions(), New())...)
+	xPub.Metadata = Metadata{"name": "Tester"}
+	err := xPub.Save(ctx)
+	require.NoError(t, err)
+
+	destination := newDestination(testXPubID, testLockingScript, append(client.DefaultModelOptions(), New())...)
+	destination.Metadata = Metadata{"label": "savings"}
+	err = destination.Save(ctx)
+	require.NoError(t, err)
+
+	utxo := newUtxo(testXPubID, testTxID, testLockingScript, 0, 100000, append(client.DefaultModelOptions(), New())...)
+	utxo.Metadata = Metadata{"label": "salary"}
+	err = utxo.Save(ctx)
+	require.NoError(t, err)
+
+	_, err = client.NewAccessKey(ctx, testXPub, WithMetadatas(Metadata{"device": "phone"}))
+	require.NoError(t, err)
+
+	_, err = client.NewPaymailAddress(
+		ctx, testXPub, testPaymail, testPublicName, testAvatar,
+		append(client.DefaultModelOptions(), WithMetadatas(Metadata{"email": "tester@tester.com"}))...,
+	)
+	require.NoError(t, err)
+
+	tx := newTransaction(testTxHex, append(client.DefaultModelOptions(), New())...)
+	tx.XpubInIDs = IDs{testXPubID}
+	tx.Metadata = Metadata{"note": "private"}
+	tx.XpubMetadata = XpubMetadata{testXPubID: Metadata{"xpub-note": "mine"}}
+	err = tx.Save(ctx)
+	require.NoError(t, err)
+
+	tx = newTransaction(testTx2Hex, append(client.DefaultModelOptions(), New())...)
+	tx.XpubInIDs = IDs{testXPubID}
+	tx.XpubOutIDs = IDs{testOtherXPubID}
+	tx.Metadata = Metadata{"note": "shared"}
+	tx.XpubMetadata = XpubMetadata{
+		testXPubID:      Metadata{"xpub-note": "mine"},
+		testOtherXPubID: Metadata{"xpub-note": "theirs"},
+	}
+	err = tx.Save(ctx)
+	require.NoError(t, err)
+
+	return ctx, client, tx.ID, deferMe
+}
+
+// TestClient_ExportXpubData will test the method ExportXpubData()
+func TestClient_ExportXpubData(t *testing.T) {
+
+	t.Run("error - missing xpub", func(t *testing.T) {
+		ctx, client, deferMe := CreateTestSQLiteClient(t, false, false, WithCustomTaskManager(&taskManagerMockBase{}))
+		defer deferMe()
+
+		export, err := client.ExportXpubData(ctx, testXPubID)
+		require.ErrorIs(t, err, ErrMissingXpub)
+		require.Nil(t, export)
+	})
+
+	t.Run("export all data", func(t *testing.T) {
+		ctx, client, _, deferMe := initXpubDataTestCase(t, WithSensitiveMetadata("email"), WithEncryption(testEncryption))
+		defer deferMe()
+
+		export, err := client.ExportXpubData(ctx, testXPubID)
+		require.NoError(t, err)
+		require.NotNil(t, export)
+
+		assert.False(t, export.ExportedAt.IsZero())
+		require.NotNil(t, export.Xpub)
+		assert.Equal(t, testXPubID, export.Xpub.ID)
+		assert.Equal(t, "Tester", export.Xpub.Metadata["name"])
+
+		require.Len(t, export.AccessKeys, 1)
+		assert.Equal(t, "phone", export.AccessKeys[0].Metadata["device"])
+
+		require.Len(t, export.Destinations, 1)
+		assert.Equal(t, "savings", export.Destinations[0].Metadata["label"])
+
+		// Sensitive metadata is decrypted
+		require.Len(t, export.PaymailAddresses, 1)
+		assert.Equal(t, "tester@tester.com", export.PaymailAddresses[0].Metadata["email"])
+		assert.Equal(t, externalXPubID, export.PaymailAddresses[0].ExternalXpubKey)
+
+		require.NotEmpty(t, export.Utxos)
+
+		// Only the xPub specific metadata
+		require.Len(t, export.Transactions, 2)
+		for _, transaction := range export.Transactions {
+			assert.Equal(t, "mine", transaction.Metadata["xpub-note"])
+			assert.Nil(t, transaction.XpubMetadata)
+		}
+	})
+}
+
+// TestClient_EraseXpub will test the method EraseXpub()
+func TestClient_EraseXpub(t *testing.T) {
+
+	t.Run("error - missing xpub", func(t *testing.T) {
+		ctx, client, deferMe := CreateTestSQLiteClient(t, false, false, WithCustomTaskManager(&taskManagerMockBase{}))
+		defer deferMe()
+
+		results, err := client.EraseXpub(ctx, testXPubID)
+		require.ErrorIs(t, err, ErrMissingXpub)
+		require.Nil(t, results)
+	})
+
+	t.Run("personal data is redacted in the audit log", func(t *testing.T) {
+		ctx, client, _, deferMe := initXpubDataTestCase(t, WithAuditLog())
+		defer deferMe()
+
+		erasedAt := time.Now().UTC().UnixNano()
+		_, err := client.EraseXpub(ctx, testXPubID)
+		require.NoError(t, err)
+
+		conditions := map[string]interface{}{
+			timestampField: map[string]interface{}{
+				"$gt": erasedAt,
+			},
+		}
+		var auditLogs []*AuditLog
+		auditLogs, err = client.GetAuditLogs(ctx, nil, &conditions, nil)
+		require.NoError(t, err)
+		require.NotEmpty(t, auditLogs)
+		for _, auditLog := range auditLogs {
+			for _, value := range []string{"Tester", "savings", "salary", "phone", "private", "mine", testPublicName} {
+				assert.NotContains(t, auditLog.Changes, value)
+			}
+		}
+		require.NoError(t, client.VerifyAuditLog(ctx))
+	})
+
+	t.Run("personal data is erased from the earlier audit log entries", func(t *testing.T) {
+		ctx, client, _, deferMe := initXpubDataTestCase(t, WithAuditLog())
+		defer deferMe()
+		require.NoError(t, client.VerifyAuditLog(ctx))
+
+		personalData := []string{"Tester", "savings", "salary", "phone", "private", "mine", testPublicName}
+
+		// The chained changes only hold the digests of the personal data
+		auditLogs, err := client.GetAuditLogs(ctx, nil, nil, nil)
+		require.NoError(t, err)
+		require.NotEmpty(t, auditLogs)
+		withPersonalData := 0
+		for _, auditLog := range auditLogs {
+			for _, value := range personalData {
+				assert.NotContains(t, auditLog.Changes, value)
+			}
+			if len(auditLog.PersonalData) > 0 {
+				withPersonalData++
+			}
+		}
+		require.Greater(t, withPersonalData, 0)
+
+		var results *ErasureResults
+		results, err = client.EraseXpub(ctx, testXPubID)
+		require.NoError(t, err)
+		assert.Equal(t, withPersonalData, results.AuditLogs)
+
+		auditLogs, err = client.GetAuditLogs(ctx, nil, nil, nil)
+		require.NoError(t, err)
+		for _, auditLog := range auditLogs {
+			for _, value := range personalData {
+				assert.NotContains(t, auditLog.PersonalData, value)
+			}
+		}
+
+		// The chain is still valid
+		require.NoError(t, client.VerifyAuditLog(ctx))
+	})
+
+	t.Run("erase all data", func(t *testing.T) {
+		ctx, client, sharedTxID, deferMe := initXpubDataTestCase(t)
+		defer deferMe()
+
+		results, err := client.EraseXpub(ctx, testXPubID)
+		require.NoError(t, err)
+		require.NotNil(t, results)
+		assert.Equal(t, testXPubID, results.XpubID)
+		assert.Equal(t, 1, results.AccessKeys)
+		assert.Equal(t, 1, results.Destinations)
+		assert.Equal(t, 1, results.PaymailAddresses)
+		assert.Equal(t, 2, results.Transactions)
+		assert.Equal(t, 1, results.Utxos)
+
+		// The xPub is kept (without metadata)
+		var xPub *Xpub
+		xPub, err = getXpubByID(ctx, testXPubID, client.DefaultModelOptions()...)
+		require.NoError(t, err)
+		require.NotNil(t, xPub)
+		assert.Nil(t, xPub.Metadata)
+
+		var accessKeys []*AccessKey
+		accessKeys, err = getAccessKeysByXPubID(ctx, testXPubID, nil, nil, nil, client.DefaultModelOptions()...)
+		require.NoError(t, err)
+		require.Len(t, accessKeys, 1)
+		assert.Nil(t, accessKeys[0].Metadata)
+		assert.True(t, accessKeys[0].RevokedAt.Valid)
+
+		var destinations []*Destination
+		destinations, err = getDestinationsByXpubID(ctx, testXPubID, nil, nil, nil, client.DefaultModelOptions()...)
+		require.NoError(t, err)
+		require.Len(t, destinations, 1)
+		assert.Nil(t, destinations[0].Metadata)
+
+		// The paymail address is anonymized
+		var paymailAddress *PaymailAddress
+		paymailAddress, err = getPaymailAddress(ctx, testPaymail, client.DefaultModelOptions()...)
+		require.NoError(t, err)
+		assert.Nil(t, paymailAddress)
+
+		var paymailAddresses []*PaymailAddress
+		paymailAddresses, err = getPaymailAddresses(
+			ctx, nil, &map[string]interface{}{xPubIDField: testXPubID}, nil, client.DefaultModelOptions()...,
+		)
+		require.NoError(t, err)
+		require.Len(t, paymailAddresses, 1)
+		assert.True(t, paymailAddresses[0].DeletedAt.Valid)
+		assert.Equal(t, "", paymailAddresses[0].PublicName)
+		assert.Equal(t, "", paymailAddresses[0].Avatar)
+		assert.Nil(t, paymailAddresses[0].Metadata)
+
+		// The transactions keep the ledger data
+		var tx *Transaction
+		tx, err = getTransactionByID(ctx, "", testTxID, client.DefaultModelOptions()...)
+		require.NoError(t, err)
+		require.NotNil(t, tx)
+		assert.Nil(t, tx.Metadata)
+		assert.NotContains(t, tx.XpubMetadata, testXPubID)
+		assert.Contains(t, tx.XpubInIDs, testXPubID)
+		assert.Equal(t, testTxHex, tx.Hex)
+
+		// The shared metadata is kept for the other xPub
+		tx, err = getTransactionByID(ctx, "", sharedTxID, client.DefaultModelOptions()...)
+		require.NoError(t, err)
+		require.NotNil(t, tx)
+		assert.Equal(t, "shared", tx.Metadata["note"])
+		assert.NotContains(t, tx.XpubMetadata, testXPubID)
+		assert.Equal(t, Metadata{"xpub-note": "theirs"}, tx.XpubMetadata[testOtherXPubID])
+		assert.Contains(t, tx.XpubInIDs, testXPubID)
+
+		// Nothing left to erase
+		results, err = client.EraseXpub(ctx, testXPubID)
+		require.NoError(t, err)
+		assert.Equal(t, 0, results.AccessKeys)
+		assert.Equal(t, 0, results.Destinations)
+		assert.Equal(t, 0, results.Transactions)
+		assert.Equal(t, 0, results.Utxos)
+	})
+}
@@ -0,0 +1,88 @@
+/*
+Package auth is a lightweight package for signing and verifying BUX requests
+
+The package has no dependencies on the engine (no datastore, cachestore etc.) and can be used by
+Go consumers (clients) of a BUX server to sign the requests using an xPriv or an access key
+*/
+package auth
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BuxOrg/bux/utils"
+)
+
+const (
+	// HeaderXPub is the header to use for authentication (raw xPub)
+	HeaderXPub = "bux-auth-xpub"
+
+	// HeaderAccessKey is the header to use for access key authentication (access public key)
+	HeaderAccessKey = "bux-auth-key"
+
+	// HeaderSignature is the given signature (body + timestamp)
+	HeaderSignature = "bux-auth-signature"
+
+	// HeaderHash hash of the body coming from the request
+	HeaderHash = "bux-auth-hash"
+
+	// HeaderNonce random nonce for the request
+	HeaderNonce = "bux-auth-nonce"
+
+	// HeaderTime the time of the request, only valid for 30 seconds
+	HeaderTime = "bux-auth-time"
+
+	// HeaderAuthorization is the header for bearer token authentication (Authorization: Bearer <token>)
+	HeaderAuthorization = "Authorization"
+
+	// BearerPrefix is the prefix of the bearer token in the Authorization header
+	BearerPrefix = "Bearer "
+
+	// SignatureTTL is the max TTL for a signature to be valid
+	SignatureTTL = 20 * time.Second
+)
+
+// Payload is the authentication payload for checking or creating a signature
+type Payload struct {
+	AccessKey    string `json:"access_key,omitempty"` // Access public key (access key signatures only)
+	AuthHash     string `json:"auth_hash"`            // Hash of the body contents
+	AuthNonce    string `json:"auth_nonce"`           // Random nonce (also used for deriving the signing key of an xPub)
+	AuthTime     int64  `json:"auth_time"`            // Time of the signature (unix milliseconds)
+	BodyContents string `json:"body_contents"`        // Body contents of the request
+	Signature    string `json:"signature"`            // Signature (bitcoin signed message)
+	XPub         string `json:"xpub,omitempty"`       // Raw xPub (xPub signatures only)
+}
+
+// GetPayloadFromHeader will get the signature payload from the request header & body contents
+//
+// Returns nil if the header is nil
+func GetPayloadFromHeader(header http.Header, bodyContents string) *Payload {
+	if header == nil {
+		return nil
+	}
+	authTime, _ := strconv.ParseInt(header.Get(HeaderTime), 10, 64)
+	return &Payload{
+		AccessKey:    strings.TrimSpace(header.Get(HeaderAccessKey)),
+		AuthHash:     header.Get(HeaderHash),
+		AuthNonce:    header.Get(HeaderNonce),
+		AuthTime:     authTime,
+		BodyContents: bodyContents,
+		Signature:    header.Get(HeaderSignature),
+		XPub:         strings.TrimSpace(header.Get(HeaderXPub)),
+	}
+}
+
+// CreateBodyHash will create the hash of the body, removing any carriage returns
+func CreateBodyHash(bodyContents string) string {
+	return utils.Hash(strings.TrimSuffix(bodyContents, "\n"))
+}
+
+// GetSigningMessage will build the signing message string
+//
+// key is the raw xPub or the access public key
+func GetSigningMessage(key string, payload *Payload) string {
+	return fmt.Sprintf("%s%s%s%d", key, payload.AuthHash, payload.AuthNonce, payload.AuthTime)
+}
@@ -0,0 +1,169 @@
+package auth
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/libsv/go-bk/bip32"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testAccessKey       = "9b2a4421edd88782a193ea8195cce1fe9b632df575c88d70f20a1fdf6835b764"
+	testAccessKeyPublic = "02719a5e3623bee13f8116f1db4ee54603c993e020087960f31d2e0b4cbd97d175"
+	testAuthNonce       = "dec0535f13b7ed61c2b188b7fe8fd5f578d6931aa90b6063c653ce0f8eefacf1"
+	testAuthTime        = int64(1643828414038)
+	testBodyContents    = `{"test_field":"test_value"}`
+	testBodyHash        = "5858adf09a0cc01f6d3a4d377f010408313031bb96b40d98e6edccf18c26464e"
+	testEmptyBodyHash   = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+	testXPriv           = "xprv9s21ZrQH143K3N6qVJQAu4EP51qMcyrKYJLkLgmYXgz58xmVxVLSsbx2DfJUtjcnXK8NdvkHMKfmmg5AJT2nqqRWUrjSHX29qEJwBgBPkJQ"
+	testXPub            = "xpub661MyMwAqRbcFrBJbKwBGCB7d3fr2SaAuXGM95BA62X41m6eW2ehRQGW4xLi9wkEXUGnQZYxVVj4PxXnyrLk7jdqvBAs1Qq9gf6ykMvjR7J"
+)
+
+// testVector is a known signature (the signatures are deterministic for the same key, nonce, time & body)
+//
+// Can be used to verify the signing of a client implementation (in any language)
+type testVector struct {
+	name         string
+	accessKey    string // Access key (private key hex), empty for an xPub signature
+	bodyContents string
+	bodyHash     string
+	signature    string
+	xPriv        string // xPriv, empty for an access key signature
+}
+
+// testVectors all use the testAuthNonce & testAuthTime
+var testVectors = []testVector{
+	{
+		name:         "xpub - body",
+		bodyContents: testBodyContents,
+		bodyHash:     testBodyHash,
+		signature:    "HwjJboQqRpx04pZyS23ZIEazJoCfVvDMz+P8Z9ap8M/8B3RiuT2qSN38drbSYLzg0EsO7okkyNkvOYvga67ylvk=",
+		xPriv:        testXPriv,
+	},
+	{
+		name:         "xpub - empty body",
+		bodyContents: "",
+		bodyHash:     testEmptyBodyHash,
+		signature:    "ICcCaI40X6sIkR+wFfs8Ql7MoGMzkTQ58bV1FMgnxSIjIuTe64Umm+2jyszepQUFHmgmqkyyBeY4U63eimUqWm0=",
+		xPriv:        testXPriv,
+	},
+	{
+		name:         "access key - body",
+		accessKey:    testAccessKey,
+		bodyContents: testBodyContents,
+		bodyHash:     testBodyHash,
+		signature:    "IDnz9G1mYfDLGihPFUXXsshlrnQ/YzYQtV5GThvI+8ulBiwuDn1cWjf5b5/T7vGg4TqrTLT4MkliEKT7NPANN9c=",
+	},
+	{
+		name:         "access key - empty body",
+		accessKey:    testAccessKey,
+		bodyContents: "",
+		bodyHash:     testEmptyBodyHash,
+		signature:    "H9WEhSMZlS44ivxbo3yYGNN6vror+5SVX6T9klyLJTWIAgtdneyU2eatgDWBf3KEeC/asebyjN1yu4Eqv7AzfNQ=",
+	},
+}
+
+// TestTestVectors will test the signing and verification of the known test vectors
+func TestTestVectors(t *testing.T) {
+	t.Parallel()
+
+	for _, vector := range testVectors {
+		t.Run(vector.name, func(t *testing.T) {
+			var (
+				err     error
+				payload *Payload
+			)
+			if len(vector.xPriv) > 0 {
+				var xPriv *bip32.ExtendedKey
+				xPriv, err = bip32.NewKeyFromString(vector.xPriv)
+				require.NoError(t, err)
+
+				payload, err = createSignature(xPriv, vector.bodyContents, testAuthNonce, testAuthTime)
+				require.NoError(t, err)
+				assert.Equal(t, testXPub, payload.XPub)
+				assert.Equal(t, "", payload.AccessKey)
+			} else {
+				payload, err = createSignatureAccessKey(vector.accessKey, vector.bodyContents, testAuthNonce, testAuthTime)
+				require.NoError(t, err)
+				assert.Equal(t, testAccessKeyPublic, payload.AccessKey)
+				assert.Equal(t, "", payload.XPub)
+			}
+
+			assert.Equal(t, vector.bodyHash, payload.AuthHash)
+			assert.Equal(t, testAuthNonce, payload.AuthNonce)
+			assert.Equal(t, testAuthTime, payload.AuthTime)
+			assert.Equal(t, vector.signature, payload.Signature)
+
+			// Verify the known signature
+			payload.BodyContents = vector.bodyContents
+			if len(payload.XPub) > 0 {
+				assert.NoError(t, VerifyXPub(testXPub, payload))
+			} else {
+				assert.NoError(t, VerifyAccessKey(testAccessKeyPublic, payload))
+			}
+		})
+	}
+}
+
+// TestGetPayloadFromHeader will test the method GetPayloadFromHeader()
+func TestGetPayloadFromHeader(t *testing.T) {
+	t.Parallel()
+
+	t.Run("nil header", func(t *testing.T) {
+		assert.Nil(t, GetPayloadFromHeader(nil, testBodyContents))
+	})
+
+	t.Run("empty header", func(t *testing.T) {
+		payload := GetPayloadFromHeader(http.Header{}, testBodyContents)
+		require.NotNil(t, payload)
+		assert.Equal(t, testBodyContents, payload.BodyContents)
+		assert.Equal(t, int64(0), payload.AuthTime)
+		assert.Equal(t, "", payload.Signature)
+	})
+
+	t.Run("valid header", func(t *testing.T) {
+		xPriv, err := bip32.NewKeyFromString(testXPriv)
+		require.NoError(t, err)
+
+		header := http.Header{}
+		err = SetSignature(&header, xPriv, testBodyContents)
+		require.NoError(t, err)
+
+		payload := GetPayloadFromHeader(header, testBodyContents)
+		require.NotNil(t, payload)
+		assert.Equal(t, testXPub, payload.XPub)
+		assert.Equal(t, testBodyHash, payload.AuthHash)
+		assert.Equal(t, header.Get(HeaderNonce), payload.AuthNonce)
+		assert.Equal(t, header.Get(HeaderTime), fmt.Sprintf("%d", payload.AuthTime))
+		assert.Equal(t, header.Get(HeaderSignature), payload.Signature)
+
+		require.NoError(t, CheckRequirements(payload, 0))
+		require.NoError(t, VerifyXPub(payload.XPub, payload))
+	})
+}
+
+// TestCreateBodyHash will test the method CreateBodyHash()
+func TestCreateBodyHash(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, testBodyHash, CreateBodyHash(testBodyContents))
+	assert.Equal(t, testBodyHash, CreateBodyHash(testBodyContents+"\n"))
+	assert.Equal(t, testEmptyBodyHash, CreateBodyHash(""))
+}
+
+// TestGetSigningMessage will test the method GetSigningMessage()
+func TestGetSigningMessage(t *testing.T) {
+	t.Parallel()
+
+	t.Run("valid format", func(t *testing.T) {
+		message := GetSigningMessage(testXPub, &Payload{
+			AuthHash:  testBodyHash,
+			AuthNonce: "auth-nonce",
+			AuthTime:  12345678,
+		})
+		assert.Equal(t, fmt.Sprintf("%s%s%s%d", testXPub, testBodyHash, "auth-nonce", 12345678), message)
+	})
+}
@@ -0,0 +1,27 @@
+package auth
+
+import "errors"
+
+// ErrMissingSignature is when the signature is missing from the request
+var ErrMissingSignature = errors.New("signature missing")
+
+// ErrAuthHashMismatch is when the auth hash does not match the body hash
+var ErrAuthHashMismatch = errors.New("auth hash and body hash do not match")
+
+// ErrSignatureExpired is when the signature TTL expired
+var ErrSignatureExpired = errors.New("signature has expired")
+
+// ErrSignatureInFuture is when the signature time is too far in the future (beyond the allowed clock skew)
+var ErrSignatureInFuture = errors.New("signature time is in the future")
+
+// ErrSignatureInvalid is when the signature failed to be valid
+var ErrSignatureInvalid = errors.New("signature invalid")
+
+// ErrMissingXPriv is when the xPriv is missing
+var ErrMissingXPriv = errors.New("missing xPriv key")
+
+// ErrMissingAccessKey is when the access key is missing
+var ErrMissingAccessKey = errors.New("missing access key")
+
+// ErrMissingHeader is when the header is missing (nil)
+var ErrMissingHeader = errors.New("missing header")
@@ -0,0 +1,175 @@
+package auth
+
+import (
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/BuxOrg/bux/utils"
+	"github.com/bitcoinschema/go-bitcoin/v2"
+	"github.com/libsv/go-bk/bec"
+	"github.com/libsv/go-bk/bip32"
+)
+
+// CreateSignature will create a signature payload for the given key & body contents
+func CreateSignature(xPriv *bip32.ExtendedKey, bodyString string) (*Payload, error) {
+
+	// auth_nonce is a random unique string to seed the signing message
+	// this can be checked server side to make sure the request is not being replayed
+	nonce, err := utils.RandomHex(32)
+	if err != nil {
+		return nil, err
+	}
+
+	// auth_time is the current time and makes sure a request can not be sent after the TTL
+	return createSignature(xPriv, bodyString, nonce, time.Now().UnixMilli())
+}
+
+// CreateSignatureAccessKey will create a signature payload for the given access key (private key hex) & body contents
+func CreateSignatureAccessKey(privateKeyHex, bodyString string) (*Payload, error) {
+
+	// auth_nonce is a random unique string to seed the signing message
+	// this can be checked server side to make sure the request is not being replayed
+	nonce, err := utils.RandomHex(32)
+	if err != nil {
+		return nil, err
+	}
+
+	return createSignatureAccessKey(privateKeyHex, bodyString, nonce, time.Now().UnixMilli())
+}
+
+// SetSignature will set the signature (and xPub) on the header for the request
+func SetSignature(header *http.Header, xPriv *bip32.ExtendedKey, bodyString string) error {
+
+	// Create the signature
+	payload, err := CreateSignature(xPriv, bodyString)
+	if err != nil {
+		return err
+	} else if header == nil {
+		return ErrMissingHeader
+	}
+
+	// Set the auth header
+	header.Set(HeaderXPub, payload.XPub)
+
+	return SetSignatureHeaders(header, payload)
+}
+
+// SetSignatureFromAccessKey will set the signature (and access public key) on the header for the request
+func SetSignatureFromAccessKey(header *http.Header, privateKeyHex, bodyString string) error {
+
+	// Create the signature
+	payload, err := CreateSignatureAccessKey(privateKeyHex, bodyString)
+	if err != nil {
+		return err
+	} else if header == nil {
+		return ErrMissingHeader
+	}
+
+	// Set the auth header
+	header.Set(HeaderAccessKey, payload.AccessKey)
+
+	return SetSignatureHeaders(header, payload)
+}
+
+// SetSignatureHeaders will set the signature headers (hash, nonce, time & signature) of the payload
+func SetSignatureHeaders(header *http.Header, payload *Payload) error {
+	if header == nil {
+		return ErrMissingHeader
+	} else if payload == nil {
+		return ErrMissingSignature
+	}
+
+	// Create the auth header hash
+	header.Set(HeaderHash, payload.AuthHash)
+
+	// Set the nonce
+	header.Set(HeaderNonce, payload.AuthNonce)
+
+	// Set the time
+	header.Set(HeaderTime, fmt.Sprintf("%d", payload.AuthTime))
+
+	// Set the signature
+	header.Set(HeaderSignature, payload.Signature)
+
+	return nil
+}
+
+// createSignature will create a signature for the given key, body contents, nonce & time
+func createSignature(xPriv *bip32.ExtendedKey, bodyString, nonce string,
+	authTime int64) (payload *Payload, err error) {
+
+	// No key?
+	if xPriv == nil {
+		err = ErrMissingXPriv
+		return
+	}
+
+	// Get the xPub
+	payload = &Payload{AuthNonce: nonce}
+	if payload.XPub, err = bitcoin.GetExtendedPublicKey(
+		xPriv,
+	); err != nil { // Should never error if key is correct
+		return nil, err
+	}
+
+	// Derive the address for signing
+	var key *bip32.ExtendedKey
+	if key, err = utils.DeriveChildKeyFromHex(
+		xPriv, payload.AuthNonce,
+	); err != nil {
+		return nil, err
+	}
+
+	var privateKey *bec.PrivateKey
+	if privateKey, err = bitcoin.GetPrivateKeyFromHDKey(key); err != nil {
+		return nil, err // Should never error if key is correct
+	}
+
+	return createSignatureCommon(payload, payload.XPub, bodyString, authTime, privateKey)
+}
+
+// createSignatureAccessKey will create a signature for the given access key, body contents, nonce & time
+func createSignatureAccessKey(privateKeyHex, bodyString, nonce string,
+	authTime int64) (*Payload, error) {
+
+	// No key?
+	if privateKeyHex == "" {
+		return nil, ErrMissingAccessKey
+	}
+
+	privateKey, err := bitcoin.PrivateKeyFromString(privateKeyHex)
+	if err != nil {
+		return nil, err
+	}
+
+	// Get the access public key
+	payload := &Payload{
+		AccessKey: hex.EncodeToString(privateKey.PubKey().SerialiseCompressed()),
+		AuthNonce: nonce,
+	}
+
+	return createSignatureCommon(payload, payload.AccessKey, bodyString, authTime, privateKey)
+}
+
+// createSignatureCommon will create a signature (bitcoin signed message) on the payload
+func createSignatureCommon(payload *Payload, key, bodyString string, authTime int64,
+	privateKey *bec.PrivateKey) (*Payload, error) {
+
+	// Create the auth header hash
+	payload.AuthHash = utils.Hash(bodyString)
+	payload.AuthTime = authTime
+
+	// Signature, using bitcoin signMessage
+	var err error
+	if payload.Signature, err = bitcoin.SignMessage(
+		hex.EncodeToString(privateKey.Serialise()),
+		GetSigningMessage(key, payload),
+		true,
+	); err != nil {
+		return nil, err
+	}
+
+	return payload, nil
+}
@@ -0,0 +1,163 @@
+package auth
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/BuxOrg/bux/utils"
+	"github.com/bitcoinschema/go-bitcoin/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCreateSignature will test the method CreateSignature()
+func TestCreateSignature(t *testing.T) {
+	t.Parallel()
+
+	t.Run("valid signature", func(t *testing.T) {
+		key, err := bitcoin.GenerateHDKey(bitcoin.SecureSeedLength)
+		require.NoError(t, err)
+		require.NotNil(t, key)
+
+		var payload *Payload
+		payload, err = CreateSignature(key, testBodyContents)
+		require.NoError(t, err)
+		require.NotNil(t, payload)
+
+		assert.Equal(t, utils.XpubKeyLength, len(payload.XPub))
+		assert.Equal(t, 64, len(payload.AuthHash))
+		assert.Equal(t, 64, len(payload.AuthNonce))
+		assert.Greater(t, payload.AuthTime, time.Now().Add(-1*time.Second).UnixMilli())
+		assert.Greater(t, len(payload.Signature), 40)
+
+		payload.BodyContents = testBodyContents
+		require.NoError(t, CheckRequirements(payload, 0))
+		require.NoError(t, VerifyXPub(payload.XPub, payload))
+	})
+
+	t.Run("error - missing key", func(t *testing.T) {
+		payload, err := CreateSignature(nil, testBodyContents)
+		require.ErrorIs(t, err, ErrMissingXPriv)
+		require.Nil(t, payload)
+	})
+
+	t.Run("empty body - valid signature", func(t *testing.T) {
+		key, err := bitcoin.GenerateHDKey(bitcoin.SecureSeedLength)
+		require.NoError(t, err)
+		require.NotNil(t, key)
+
+		var payload *Payload
+		payload, err = CreateSignature(key, "")
+		require.NoError(t, err)
+		require.NotNil(t, payload)
+
+		require.NoError(t, CheckRequirements(payload, 0))
+		require.NoError(t, VerifyXPub(payload.XPub, payload))
+	})
+}
+
+// TestCreateSignatureAccessKey will test the method CreateSignatureAccessKey()
+func TestCreateSignatureAccessKey(t *testing.T) {
+	t.Parallel()
+
+	t.Run("valid signature", func(t *testing.T) {
+		payload, err := CreateSignatureAccessKey(testAccessKey, testBodyContents)
+		require.NoError(t, err)
+		require.NotNil(t, payload)
+
+		assert.Equal(t, testAccessKeyPublic, payload.AccessKey)
+		assert.Equal(t, testBodyHash, payload.AuthHash)
+		assert.Equal(t, 64, len(payload.AuthNonce))
+
+		payload.BodyContents = testBodyContents
+		require.NoError(t, CheckRequirements(payload, 0))
+		require.NoError(t, VerifyAccessKey(payload.AccessKey, payload))
+	})
+
+	t.Run("error - missing key", func(t *testing.T) {
+		payload, err := CreateSignatureAccessKey("", testBodyContents)
+		require.ErrorIs(t, err, ErrMissingAccessKey)
+		require.Nil(t, payload)
+	})
+
+	t.Run("error - invalid key", func(t *testing.T) {
+		payload, err := CreateSignatureAccessKey("invalid-key", testBodyContents)
+		require.Error(t, err)
+		require.Nil(t, payload)
+	})
+}
+
+// TestSetSignature will test the method SetSignature()
+func TestSetSignature(t *testing.T) {
+	t.Parallel()
+
+	t.Run("error - bad signature", func(t *testing.T) {
+		err := SetSignature(nil, nil, testBodyContents)
+		require.Error(t, err)
+	})
+
+	t.Run("error - missing header", func(t *testing.T) {
+		key, err := bitcoin.GenerateHDKey(bitcoin.SecureSeedLength)
+		require.NoError(t, err)
+
+		err = SetSignature(nil, key, testBodyContents)
+		require.ErrorIs(t, err, ErrMissingHeader)
+	})
+
+	t.Run("valid set headers", func(t *testing.T) {
+		emptyHeaders := &http.Header{}
+
+		key, err := bitcoin.GenerateHDKey(bitcoin.SecureSeedLength)
+		require.NoError(t, err)
+		require.NotNil(t, key)
+
+		var xPub string
+		xPub, err = bitcoin.GetExtendedPublicKey(key)
+		require.NoError(t, err)
+		require.NotEmpty(t, xPub)
+
+		err = SetSignature(emptyHeaders, key, testBodyContents)
+		require.NoError(t, err)
+
+		assert.Equal(t, xPub, emptyHeaders.Get(HeaderXPub))
+		assert.NotEmpty(t, emptyHeaders.Get(HeaderHash))
+		assert.NotEmpty(t, emptyHeaders.Get(HeaderNonce))
+		assert.NotEmpty(t, emptyHeaders.Get(HeaderTime))
+		assert.NotEmpty(t, emptyHeaders.Get(HeaderSignature))
+
+		authTime, _ := strconv.Atoi(emptyHeaders.Get(HeaderTime))
+		err = VerifyXPub(xPub, &Payload{
+			AuthHash:     emptyHeaders.Get(HeaderHash),
+			AuthNonce:    emptyHeaders.Get(HeaderNonce),
+			AuthTime:     int64(authTime),
+			BodyContents: testBodyContents,
+			Signature:    emptyHeaders.Get(HeaderSignature),
+		})
+		require.NoError(t, err)
+	})
+}
+
+// TestSetSignatureFromAccessKey will test the method SetSignatureFromAccessKey()
+func TestSetSignatureFromAccessKey(t *testing.T) {
+	t.Parallel()
+
+	t.Run("error - missing key", func(t *testing.T) {
+		err := SetSignatureFromAccessKey(&http.Header{}, "", testBodyContents)
+		require.ErrorIs(t, err, ErrMissingAccessKey)
+	})
+
+	t.Run("valid set headers", func(t *testing.T) {
+		header := http.Header{}
+		err := SetSignatureFromAccessKey(&header, testAccessKey, testBodyContents)
+		require.NoError(t, err)
+
+		assert.Equal(t, testAccessKeyPublic, header.Get(HeaderAccessKey))
+		assert.Equal(t, "", header.Get(HeaderXPub))
+
+		payload := GetPayloadFromHeader(header, testBodyContents)
+		require.NoError(t, CheckRequirements(payload, 0))
+		require.NoError(t, VerifyAccessKey(payload.AccessKey, payload))
+	})
+}
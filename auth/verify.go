@@ -0,0 +1,105 @@
+package auth
+
+import (
+	"time"
+
+	"github.com/BuxOrg/bux/utils"
+	"github.com/bitcoinschema/go-bitcoin/v2"
+	"github.com/libsv/go-bt/v2/bscript"
+)
+
+// CheckRequirements will check the payload for basic signature requirements (signature, body hash & time)
+//
+// clockSkew is the allowed clock difference between the client and the server
+func CheckRequirements(payload *Payload, clockSkew time.Duration) error {
+
+	// Check that we have a signature
+	if payload == nil || payload.Signature == "" {
+		return ErrMissingSignature
+	}
+
+	// Check the auth hash vs the body hash
+	if payload.AuthHash != CreateBodyHash(payload.BodyContents) {
+		return ErrAuthHashMismatch
+	}
+
+	// Check the auth timestamp
+	authTime := time.UnixMilli(payload.AuthTime)
+	now := time.Now().UTC()
+	if now.After(authTime.Add(SignatureTTL + clockSkew)) {
+		return ErrSignatureExpired
+	} else if authTime.After(now.Add(clockSkew)) {
+		return ErrSignatureInFuture
+	}
+	return nil
+}
+
+// VerifyXPub will verify the signature of the payload for the xPub
+//
+// Only the signature is verified, use CheckRequirements() for the body hash & time
+func VerifyXPub(xPub string, payload *Payload) error {
+
+	// Validate that the xPub is an HD key (length, validation)
+	if _, err := utils.ValidateXPub(xPub); err != nil {
+		return err
+	}
+
+	// Cannot be nil
+	if payload == nil {
+		return ErrMissingSignature
+	}
+
+	// Get the key from xPub
+	key, err := bitcoin.GetHDKeyFromExtendedPublicKey(xPub)
+	if err != nil {
+		return err
+	}
+
+	// Derive the address for signing
+	if key, err = utils.DeriveChildKeyFromHex(key, payload.AuthNonce); err != nil {
+		return err
+	}
+
+	var address *bscript.Address
+	if address, err = bitcoin.GetAddressFromHDKey(key); err != nil {
+		return err // Should never error
+	}
+
+	// Return the error if verification fails
+	if err = bitcoin.VerifyMessage(
+		address.AddressString,
+		payload.Signature,
+		GetSigningMessage(xPub, payload),
+	); err != nil {
+		return ErrSignatureInvalid
+	}
+	return nil
+}
+
+// VerifyAccessKey will verify the signature of the payload for the access public key (hex)
+//
+// Only the signature is verified, use CheckRequirements() for the body hash & time
+func VerifyAccessKey(publicKeyHex string, payload *Payload) error {
+
+	// Cannot be nil
+	if payload == nil {
+		return ErrMissingSignature
+	}
+
+	address, err := bitcoin.GetAddressFromPubKeyString(
+		publicKeyHex, true,
+	)
+	if err != nil {
+		return err
+	}
+
+	// Return the error if verification fails
+	if err = bitcoin.VerifyMessage(
+		address.AddressString,
+		payload.Signature,
+		GetSigningMessage(publicKeyHex, payload),
+	); err != nil {
+		return ErrSignatureInvalid
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testSignature is the xPub signature of the first test vector
+const testSignature = "HwjJboQqRpx04pZyS23ZIEazJoCfVvDMz+P8Z9ap8M/8B3RiuT2qSN38drbSYLzg0EsO7okkyNkvOYvga67ylvk="
+
+// TestCheckRequirements will test the method CheckRequirements()
+func TestCheckRequirements(t *testing.T) {
+	t.Parallel()
+
+	newPayload := func(authTime time.Time) *Payload {
+		return &Payload{
+			AuthHash:     testBodyHash,
+			BodyContents: testBodyContents,
+			Signature:    testSignature,
+			AuthTime:     authTime.UnixMilli(),
+		}
+	}
+
+	t.Run("error - missing payload", func(t *testing.T) {
+		err := CheckRequirements(nil, 0)
+		assert.ErrorIs(t, err, ErrMissingSignature)
+	})
+
+	t.Run("error - missing signature", func(t *testing.T) {
+		err := CheckRequirements(&Payload{}, 0)
+		assert.ErrorIs(t, err, ErrMissingSignature)
+	})
+
+	t.Run("error - auth hash mismatch", func(t *testing.T) {
+		payload := newPayload(time.Now())
+		payload.AuthHash = "bad-hash"
+		err := CheckRequirements(payload, 0)
+		assert.ErrorIs(t, err, ErrAuthHashMismatch)
+	})
+
+	t.Run("error - signature expired", func(t *testing.T) {
+		err := CheckRequirements(newPayload(time.UnixMilli(testAuthTime)), 0)
+		assert.ErrorIs(t, err, ErrSignatureExpired)
+	})
+
+	t.Run("error - time is wrong", func(t *testing.T) {
+		err := CheckRequirements(newPayload(time.UnixMilli(0)), 0)
+		assert.ErrorIs(t, err, ErrSignatureExpired)
+	})
+
+	t.Run("error - time in the future", func(t *testing.T) {
+		err := CheckRequirements(newPayload(time.Now().Add(10*time.Second)), 5*time.Second)
+		assert.ErrorIs(t, err, ErrSignatureInFuture)
+	})
+
+	t.Run("time in the future - within skew", func(t *testing.T) {
+		err := CheckRequirements(newPayload(time.Now().Add(3*time.Second)), 5*time.Second)
+		assert.NoError(t, err)
+	})
+
+	t.Run("expired - within skew", func(t *testing.T) {
+		err := CheckRequirements(newPayload(time.Now().Add(-1*(SignatureTTL+3*time.Second))), 5*time.Second)
+		assert.NoError(t, err)
+	})
+
+	t.Run("error - expired - no skew", func(t *testing.T) {
+		err := CheckRequirements(newPayload(time.Now().Add(-1*(SignatureTTL+3*time.Second))), 0)
+		assert.ErrorIs(t, err, ErrSignatureExpired)
+	})
+}
+
+// TestVerifyXPub will test the method VerifyXPub()
+func TestVerifyXPub(t *testing.T) {
+	t.Parallel()
+
+	newPayload := func() *Payload {
+		return &Payload{
+			AuthHash:     testBodyHash,
+			AuthNonce:    testAuthNonce,
+			AuthTime:     testAuthTime,
+			BodyContents: testBodyContents,
+			Signature:    testSignature,
+		}
+	}
+
+	t.Run("valid signature", func(t *testing.T) {
+		require.NoError(t, VerifyXPub(testXPub, newPayload()))
+	})
+
+	t.Run("error - missing payload", func(t *testing.T) {
+		err := VerifyXPub(testXPub, nil)
+		assert.ErrorIs(t, err, ErrMissingSignature)
+	})
+
+	t.Run("error - bad xpub", func(t *testing.T) {
+		err := VerifyXPub("invalid-key", newPayload())
+		require.Error(t, err)
+	})
+
+	t.Run("error - different nonce", func(t *testing.T) {
+		payload := newPayload()
+		payload.AuthNonce = testBodyHash
+		err := VerifyXPub(testXPub, payload)
+		assert.ErrorIs(t, err, ErrSignatureInvalid)
+	})
+
+	t.Run("error - different time", func(t *testing.T) {
+		payload := newPayload()
+		payload.AuthTime++
+		err := VerifyXPub(testXPub, payload)
+		assert.ErrorIs(t, err, ErrSignatureInvalid)
+	})
+}
+
+// TestVerifyAccessKey will test the method VerifyAccessKey()
+func TestVerifyAccessKey(t *testing.T) {
+	t.Parallel()
+
+	newPayload := func() *Payload {
+		return &Payload{
+			AuthHash:     testBodyHash,
+			AuthNonce:    testAuthNonce,
+			AuthTime:     testAuthTime,
+			BodyContents: testBodyContents,
+			Signature:    "IDnz9G1mYfDLGihPFUXXsshlrnQ/YzYQtV5GThvI+8ulBiwuDn1cWjf5b5/T7vGg4TqrTLT4MkliEKT7NPANN9c=",
+		}
+	}
+
+	t.Run("valid signature", func(t *testing.T) {
+		require.NoError(t, VerifyAccessKey(testAccessKeyPublic, newPayload()))
+	})
+
+	t.Run("error - missing payload", func(t *testing.T) {
+		err := VerifyAccessKey(testAccessKeyPublic, nil)
+		assert.ErrorIs(t, err, ErrMissingSignature)
+	})
+
+	t.Run("error - bad key", func(t *testing.T) {
+		err := VerifyAccessKey("invalid-key", newPayload())
+		require.Error(t, err)
+	})
+
+	t.Run("error - xpub signature", func(t *testing.T) {
+		payload := newPayload()
+		payload.Signature = testSignature
+		err := VerifyAccessKey(testAccessKeyPublic, payload)
+		assert.ErrorIs(t, err, ErrSignatureInvalid)
+	})
+}
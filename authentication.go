@@ -3,42 +3,57 @@ package bux
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
-	"strconv"
 	"strings"
 	"time"
 
+	"github.com/BuxOrg/bux/auth"
 	"github.com/BuxOrg/bux/utils"
-	"github.com/bitcoinschema/go-bitcoin/v2"
 	"github.com/libsv/go-bk/bip32"
-	"github.com/libsv/go-bt/v2/bscript"
+	"github.com/mrz1836/go-cachestore"
 )
 
 // AuthenticateRequest will parse the incoming request for the associated authentication header,
 // and it will check the Key/Signature
 //
-// Sets req.Context(xpub) and req.Context(xpub_hash)
+// Sets req.Context(xpub), req.Context(xpub_hash) and req.Context(access_key_scope) (effective scope)
+//
+// Admins are the xPubs in adminXPubs (super admins) or the active admin keys in the Datastore,
+// admin requests also set req.Context(auth_admin_role)
+//
+// A bearer token (see NewAuthToken) in the Authorization header can be used instead of the xPub/access key & signature
 func (c *Client) AuthenticateRequest(ctx context.Context, req *http.Request, adminXPubs []string,
 	adminRequired, requireSigning, signingDisabled bool) (*http.Request, error) {
 
 	// Get the xPub/Access Key from the header
 	xPub := strings.TrimSpace(req.Header.Get(AuthHeader))
 	authAccessKey := strings.TrimSpace(req.Header.Get(AuthAccessKey))
-	if len(xPub) == 0 && len(authAccessKey) == 0 { // No value found
-		return req, ErrMissingAuthHeader
+	if len(xPub) == 0 && len(authAccessKey) == 0 {
+		// Bearer token (session) instead of a signature
+		if token := getBearerToken(req); len(token) > 0 {
+			return c.authenticateRequestToken(ctx, req, token, adminXPubs, adminRequired)
+		}
+		return req, ErrMissingAuthHeader // No value found
 	}
 
-	// Check for admin key
+	// Check for admin key (config list, or an admin key in the Datastore)
+	xPubID := utils.Hash(xPub)
+	var adminRole AdminRole
 	if adminRequired {
-		if !utils.StringInSlice(xPub, adminXPubs) {
+		var err error
+		if adminRole, err = c.getAdminRole(ctx, xPub, xPubID, adminXPubs); err != nil {
+			return req, err
+		} else if len(adminRole) == 0 {
 			return req, ErrNotAdminKey
 		}
 	}
 
-	xPubID := utils.Hash(xPub)
 	xPubOrAccessKey := xPub
+	scope := &AccessKeyScope{} // xPub has full access
+	accessKeyID := ""
 	if xPub != "" {
 		// Validate that the xPub is an HD key (length, validation)
 		if _, err := utils.ValidateXPub(xPubOrAccessKey); err != nil {
@@ -53,9 +68,15 @@ func (c *Client) AuthenticateRequest(ctx context.Context, req *http.Request, adm
 		}
 		if accessKey == nil || accessKey.RevokedAt.Valid {
 			return req, ErrAuthAccessKeyNotFound
+		} else if accessKey.IsExpired() {
+			return req, ErrAccessKeyExpired
+		} else if !accessKey.Scope.IsIPAllowed(c.requestIP(req)) {
+			return req, ErrAccessKeyIPNotAllowed
 		}
 
 		xPubID = accessKey.XpubID
+		accessKeyID = accessKey.ID
+		scope = &accessKey.Scope
 	}
 
 	if req.Body == nil {
@@ -71,14 +92,7 @@ func (c *Client) AuthenticateRequest(ctx context.Context, req *http.Request, adm
 
 	req.Body = io.NopCloser(bytes.NewReader(b))
 
-	authTime, _ := strconv.Atoi(req.Header.Get(AuthHeaderTime))
-	authData := &AuthPayload{
-		AuthHash:     req.Header.Get(AuthHeaderHash),
-		AuthNonce:    req.Header.Get(AuthHeaderNonce),
-		AuthTime:     int64(authTime),
-		BodyContents: string(b),
-		Signature:    req.Header.Get(AuthSignature),
-	}
+	authData := auth.GetPayloadFromHeader(req.Header, string(b))
 
 	// adminRequired will always force checking of a signature
 	if (requireSigning || adminRequired) && !signingDisabled {
@@ -98,90 +112,86 @@ func (c *Client) AuthenticateRequest(ctx context.Context, req *http.Request, adm
 	}
 
 	req = setOnRequest(req, ParamAdminRequest, adminRequired)
+	if adminRequired {
+		req = setOnRequest(req, ParamAdminRole, string(adminRole))
+		c.Logger().Info(ctx, fmt.Sprintf(
+			"admin request by %s (%s): %s %s", xPubID, adminRole, req.Method, req.URL.Path,
+		))
+	}
+	req = setOnRequest(req, ParamAccessKeyScope, scope)
+	req = setOnRequest(req, ParamAccessKeyID, accessKeyID)
 
 	// Set the data back onto the request
 	return setOnRequest(setOnRequest(req, ParamXPubKey, xPub), ParamXPubHashKey, xPubID), nil
 }
 
-// checkSignature check the signature for the provided auth payload
-func (c *Client) checkSignature(ctx context.Context, xPubOrAccessKey string, auth *AuthPayload) error {
-
-	// Check that we have the basic signature components
-	if err := checkSignatureRequirements(auth); err != nil {
-		return err
-	}
-
-	// Check xPub vs Access Key
-	if strings.Contains(xPubOrAccessKey, "xpub") && len(xPubOrAccessKey) > 64 {
-		return verifyKeyXPub(xPubOrAccessKey, auth)
-	}
-	return verifyAccessKey(ctx, xPubOrAccessKey, auth, c.DefaultModelOptions()...)
-}
-
-// checkSignatureRequirements will check the payload for basic signature requirements
-func checkSignatureRequirements(auth *AuthPayload) error {
-
-	// Check that we have a signature
-	if auth == nil || auth.Signature == "" {
-		return ErrMissingSignature
-	}
-
-	// Check the auth hash vs the body hash
-	bodyHash := createBodyHash(auth.BodyContents)
-	if auth.AuthHash != bodyHash {
-		return ErrAuhHashMismatch
+// getAdminRole will get the admin role of the xPub (empty if not an admin)
+//
+// xPubs in the adminXPubs list (config) are super admins, otherwise the admin key is resolved from the Datastore (cached)
+func (c *Client) getAdminRole(ctx context.Context, xPub, xPubID string, adminXPubs []string) (AdminRole, error) {
+	if len(xPub) == 0 {
+		return "", nil
+	} else if utils.StringInSlice(xPub, adminXPubs) {
+		return AdminRoleSuperAdmin, nil
 	}
 
-	// Check the auth timestamp
-	if time.Now().UTC().After(time.UnixMilli(auth.AuthTime).Add(AuthSignatureTTL)) {
-		return ErrSignatureExpired
+	adminKey, err := getAdminKeyWithCache(ctx, c, xPubID, c.DefaultModelOptions()...)
+	if err != nil {
+		return "", err
+	} else if adminKey == nil || !adminKey.IsActive() {
+		return "", nil
 	}
-	return nil
+	return adminKey.Role, nil
 }
 
-// verifyKeyXPub will verify the xPub key and the signature payload
-func verifyKeyXPub(xPub string, auth *AuthPayload) error {
+// checkSignature check the signature for the provided auth payload
+func (c *Client) checkSignature(ctx context.Context, xPubOrAccessKey string, payload *AuthPayload) error {
 
-	// Validate that the xPub is an HD key (length, validation)
-	if _, err := utils.ValidateXPub(xPub); err != nil {
+	// Check that we have the basic signature components
+	if err := auth.CheckRequirements(payload, c.options.authClockSkew); err != nil {
 		return err
 	}
 
-	// Cannot be nil
-	if auth == nil {
-		return ErrMissingSignature
+	// Check xPub vs Access Key
+	var err error
+	if strings.Contains(xPubOrAccessKey, "xpub") && len(xPubOrAccessKey) > 64 {
+		err = auth.VerifyXPub(xPubOrAccessKey, payload)
+	} else {
+		err = verifyAccessKey(ctx, xPubOrAccessKey, payload, c.DefaultModelOptions()...)
 	}
-
-	// Get the key from xPub
-	key, err := bitcoin.GetHDKeyFromExtendedPublicKey(xPub)
 	if err != nil {
 		return err
 	}
 
-	// Derive the address for signing
-	if key, err = utils.DeriveChildKeyFromHex(key, auth.AuthNonce); err != nil {
-		return err
-	}
+	// Only a valid signature can use up the nonce
+	return c.checkSignatureNonce(ctx, xPubOrAccessKey, payload)
+}
 
-	var address *bscript.Address
-	if address, err = bitcoin.GetAddressFromHDKey(key); err != nil {
-		return err // Should never error
+// checkSignatureNonce will reject a nonce that was already used by the xPub/access key (replayed request)
+//
+// The nonce is remembered in the cachestore (shared across cluster nodes) for as long as the signature can be valid
+func (c *Client) checkSignatureNonce(ctx context.Context, xPubOrAccessKey string, payload *AuthPayload) error {
+	if len(payload.AuthNonce) == 0 {
+		return ErrMissingSignatureNonce
 	}
 
-	// Return the error if verification fails
-	message := getSigningMessage(xPub, auth)
-	if err = bitcoin.VerifyMessage(
-		address.AddressString,
-		auth.Signature,
-		message,
+	// TTL (in seconds) covers the signature TTL and the clock skew on both sides
+	ttl := int64((AuthSignatureTTL+2*c.options.authClockSkew)/time.Second) + 1
+
+	// A lock can only be created once (atomic, until it expires)
+	if _, err := c.Cachestore().WriteLock(
+		ctx, fmt.Sprintf(lockKeyAuthNonce, utils.Hash(xPubOrAccessKey), payload.AuthNonce), ttl,
 	); err != nil {
-		return ErrSignatureInvalid
+		if errors.Is(err, cachestore.ErrLockCreateFailed) {
+			return ErrSignatureNonceReused
+		}
+		return err
 	}
 	return nil
 }
 
-// verifyAccessKey will verify the access key and the signature payload
-func verifyAccessKey(ctx context.Context, key string, auth *AuthPayload, opts ...ModelOps) error {
+// verifyAccessKey will verify the access key (Datastore) and the signature payload
+func verifyAccessKey(ctx context.Context, key string, payload *AuthPayload, opts ...ModelOps) error {
 
 	// Get access key from DB
 	// todo: add caching in the future, faster than DB
@@ -192,85 +202,35 @@ func verifyAccessKey(ctx context.Context, key string, auth *AuthPayload, opts ..
 		return ErrUnknownAccessKey
 	} else if accessKey.RevokedAt.Valid {
 		return ErrAccessKeyRevoked
-	}
-
-	var address *bscript.Address
-	if address, err = bitcoin.GetAddressFromPubKeyString(
-		key, true,
-	); err != nil {
-		return err
+	} else if accessKey.IsExpired() {
+		return ErrAccessKeyExpired
 	}
 
 	// Return the error if verification fails
-	if err = bitcoin.VerifyMessage(
-		address.AddressString,
-		auth.Signature,
-		getSigningMessage(key, auth),
-	); err != nil {
-		return ErrSignatureInvalid
-	}
-	return nil
+	return auth.VerifyAccessKey(key, payload)
 }
 
 // SetSignature will set the signature on the header for the request
+//
+// Clients should use the auth package (auth.SetSignature) instead of importing the engine
 func SetSignature(header *http.Header, xPriv *bip32.ExtendedKey, bodyString string) error {
-
-	// Create the signature
-	authData, err := createSignature(xPriv, bodyString)
-	if err != nil {
-		return err
-	}
-
-	// Set the auth header
-	header.Set(AuthHeader, authData.xPub)
-
-	return setSignatureHeaders(header, authData)
+	return auth.SetSignature(header, xPriv, bodyString)
 }
 
 // SetSignatureFromAccessKey will set the signature on the header for the request from an access key
+//
+// Clients should use the auth package (auth.SetSignatureFromAccessKey) instead of importing the engine
 func SetSignatureFromAccessKey(header *http.Header, privateKeyHex, bodyString string) error {
-
-	// Create the signature
-	authData, err := createSignatureAccessKey(privateKeyHex, bodyString)
-	if err != nil {
-		return err
-	}
-
-	// Set the auth header
-	header.Set(AuthAccessKey, authData.accessKey)
-
-	return setSignatureHeaders(header, authData)
-}
-
-func setSignatureHeaders(header *http.Header, authData *AuthPayload) error {
-
-	// Create the auth header hash
-	header.Set(AuthHeaderHash, authData.AuthHash)
-
-	// Set the nonce
-	header.Set(AuthHeaderNonce, authData.AuthNonce)
-
-	// Set the time
-	header.Set(AuthHeaderTime, fmt.Sprintf("%d", authData.AuthTime))
-
-	// Set the signature
-	header.Set(AuthSignature, authData.Signature)
-
-	return nil
+	return auth.SetSignatureFromAccessKey(header, privateKeyHex, bodyString)
 }
 
 // CreateSignature will create a signature for the given key & body contents
 func CreateSignature(xPriv *bip32.ExtendedKey, bodyString string) (string, error) {
-	authData, err := createSignature(xPriv, bodyString)
+	payload, err := auth.CreateSignature(xPriv, bodyString)
 	if err != nil {
 		return "", err
 	}
-	return authData.Signature, nil
-}
-
-// getSigningMessage will build the signing message string
-func getSigningMessage(xPub string, auth *AuthPayload) string {
-	return fmt.Sprintf("%s%s%s%d", xPub, auth.AuthHash, auth.AuthNonce, auth.AuthTime)
+	return payload.Signature, nil
 }
 
 // GetXpubFromRequest gets the stored xPub from the request if found
@@ -288,6 +248,42 @@ func IsAdminRequest(req *http.Request) (bool, bool) {
 	return getBoolFromRequest(req, ParamAdminRequest)
 }
 
+// GetAdminRoleFromRequest gets the stored admin role from the request if found (admin requests only)
+func GetAdminRoleFromRequest(req *http.Request) (AdminRole, bool) {
+	role, ok := getFromRequest(req, ParamAdminRole)
+	return AdminRole(role), ok
+}
+
+// GetAccessKeyScopeFromRequest gets the stored effective scope from the request if found
+//
+// Requests authenticated with an xPub have an unrestricted scope
+func GetAccessKeyScopeFromRequest(req *http.Request) (*AccessKeyScope, bool) {
+	scope, ok := req.Context().Value(ParamAccessKeyScope).(*AccessKeyScope)
+	return scope, ok
+}
+
+// GetAccessKeyIDFromRequest gets the stored access key ID from the request if found
+//
+// The access key spending limits apply to drafts created with the request context (or using WithAccessKeyID())
+func GetAccessKeyIDFromRequest(req *http.Request) (string, bool) {
+	return getFromRequest(req, ParamAccessKeyID)
+}
+
+// getAdminRoleFromContext gets the role of the admin that authenticated the request (empty if not the given xPub ID)
+func getAdminRoleFromContext(ctx context.Context, xPubID string) AdminRole {
+	if authXPubID, _ := ctx.Value(ParamXPubHashKey).(string); len(xPubID) == 0 || authXPubID != xPubID {
+		return ""
+	}
+	role, _ := ctx.Value(ParamAdminRole).(string)
+	return AdminRole(role)
+}
+
+// getAccessKeyIDFromContext gets the ID of the access key that authenticated the request (empty if not found)
+func getAccessKeyIDFromContext(ctx context.Context) string {
+	accessKeyID, _ := ctx.Value(ParamAccessKeyID).(string)
+	return accessKeyID
+}
+
 // GetXpubHashFromRequest gets the stored xPub hash from the request if found
 func GetXpubHashFromRequest(req *http.Request) (string, bool) {
 	return getFromRequest(req, ParamXPubHashKey)
@@ -2,50 +2,49 @@ package bux
 
 import (
 	"context"
-	"encoding/hex"
 	"net/http"
 	"strings"
-	"time"
 
-	"github.com/BuxOrg/bux/utils"
-	"github.com/bitcoinschema/go-bitcoin/v2"
-	"github.com/libsv/go-bk/bec"
-	"github.com/libsv/go-bk/bip32"
+	"github.com/BuxOrg/bux/auth"
 )
 
 const (
 	// AuthHeader is the header to use for authentication (raw xPub)
-	AuthHeader = "bux-auth-xpub"
+	AuthHeader = auth.HeaderXPub
 
 	// AuthAccessKey is the header to use for access key authentication (access public key)
-	AuthAccessKey = "bux-auth-key"
+	AuthAccessKey = auth.HeaderAccessKey
 
 	// AuthSignature is the given signature (body + timestamp)
-	AuthSignature = "bux-auth-signature"
+	AuthSignature = auth.HeaderSignature
 
 	// AuthHeaderHash hash of the body coming from the request
-	AuthHeaderHash = "bux-auth-hash"
+	AuthHeaderHash = auth.HeaderHash
 
 	// AuthHeaderNonce random nonce for the request
-	AuthHeaderNonce = "bux-auth-nonce"
+	AuthHeaderNonce = auth.HeaderNonce
 
 	// AuthHeaderTime the time of the request, only valid for 30 seconds
-	AuthHeaderTime = "bux-auth-time"
+	AuthHeaderTime = auth.HeaderTime
+
+	// AuthHeaderAuthorization is the header for bearer token authentication (Authorization: Bearer <token>)
+	AuthHeaderAuthorization = auth.HeaderAuthorization
+
+	// AuthBearerPrefix is the prefix of the bearer token in the Authorization header
+	AuthBearerPrefix = auth.BearerPrefix
 
 	// AuthSignatureTTL is the max TTL for a signature to be valid
-	AuthSignatureTTL = 20 * time.Second
+	AuthSignatureTTL = auth.SignatureTTL
+
+	// headerForwardedFor is the header with the client IP set by (trusted) proxies
+	headerForwardedFor = "X-Forwarded-For"
+
+	// headerRealIP is the header with the client IP set by a (trusted) proxy
+	headerRealIP = "X-Real-IP"
 )
 
-// AuthPayload is the authentication payload for checking or creating a signature
-type AuthPayload struct {
-	AuthHash     string `json:"auth_hash"`
-	AuthNonce    string `json:"auth_nonce"`
-	AuthTime     int64  `json:"auth_time"`
-	BodyContents string `json:"body_contents"`
-	Signature    string `json:"signature"`
-	xPub         string
-	accessKey    string
-}
+// AuthPayload is the authentication payload for checking or creating a signature (see the auth package)
+type AuthPayload = auth.Payload
 
 // ParamRequestKey for context key
 type ParamRequestKey string
@@ -60,111 +59,21 @@ const (
 	// ParamAdminRequest the request parameter whether this is an admin request
 	ParamAdminRequest ParamRequestKey = "auth_admin"
 
+	// ParamAdminRole the request parameter for the role of the admin (admin requests only)
+	ParamAdminRole ParamRequestKey = "auth_admin_role"
+
 	// ParamAuthSigned the request parameter that says whether the request was signed
 	ParamAuthSigned ParamRequestKey = "auth_signed"
-)
-
-// createBodyHash will create the hash of the body, removing any carriage returns
-func createBodyHash(bodyContents string) string {
-	return utils.Hash(strings.TrimSuffix(bodyContents, "\n"))
-}
 
-// createSignature will create a signature for the given key & body contents
-func createSignature(xPriv *bip32.ExtendedKey, bodyString string) (payload *AuthPayload, err error) {
+	// ParamAccessKeyScope the request parameter for the effective access key scope
+	ParamAccessKeyScope ParamRequestKey = "access_key_scope"
 
-	// No key?
-	if xPriv == nil {
-		err = ErrMissingXPriv
-		return
-	}
-
-	// Get the xPub
-	payload = new(AuthPayload)
-	if payload.xPub, err = bitcoin.GetExtendedPublicKey(
-		xPriv,
-	); err != nil { // Should never error if key is correct
-		return
-	}
-
-	// auth_nonce is a random unique string to seed the signing message
-	// this can be checked server side to make sure the request is not being replayed
-	if payload.AuthNonce, err = utils.RandomHex(32); err != nil { // Should never error if key is correct
-		return
-	}
-
-	// Derive the address for signing
-	var key *bip32.ExtendedKey
-	if key, err = utils.DeriveChildKeyFromHex(
-		xPriv, payload.AuthNonce,
-	); err != nil {
-		return
-	}
-
-	var privateKey *bec.PrivateKey
-	if privateKey, err = bitcoin.GetPrivateKeyFromHDKey(key); err != nil {
-		return // Should never error if key is correct
-	}
-
-	return createSignatureCommon(payload, bodyString, privateKey)
-}
-
-// createSignatureAccessKey will create a signature for the given access key & body contents
-func createSignatureAccessKey(privateKeyHex, bodyString string) (payload *AuthPayload, err error) {
-
-	// No key?
-	if privateKeyHex == "" {
-		err = ErrMissingAccessKey
-		return
-	}
+	// ParamAccessKeyID the request parameter for the access key ID (empty if authenticated with an xPub)
+	ParamAccessKeyID ParamRequestKey = "access_key_id"
 
-	var privateKey *bec.PrivateKey
-	if privateKey, err = bitcoin.PrivateKeyFromString(
-		privateKeyHex,
-	); err != nil {
-		return
-	}
-	publicKey := privateKey.PubKey()
-
-	// Get the xPub
-	payload = new(AuthPayload)
-	payload.accessKey = hex.EncodeToString(publicKey.SerialiseCompressed())
-
-	// auth_nonce is a random unique string to seed the signing message
-	// this can be checked server side to make sure the request is not being replayed
-	payload.AuthNonce, err = utils.RandomHex(32)
-	if err != nil {
-		return nil, err
-	}
-
-	return createSignatureCommon(payload, bodyString, privateKey)
-}
-
-// createSignatureCommon will create a signature
-func createSignatureCommon(payload *AuthPayload, bodyString string, privateKey *bec.PrivateKey) (*AuthPayload, error) {
-
-	// Create the auth header hash
-	payload.AuthHash = utils.Hash(bodyString)
-
-	// auth_time is the current time and makes sure a request can not be sent after 30 secs
-	payload.AuthTime = time.Now().UnixMilli()
-
-	key := payload.xPub
-	if key == "" && payload.accessKey != "" {
-		key = payload.accessKey
-	}
-
-	// Signature, using bitcoin signMessage
-	var err error
-	if payload.Signature, err = bitcoin.SignMessage(
-		hex.EncodeToString(privateKey.Serialise()),
-		getSigningMessage(key, payload),
-		true,
-	); err != nil {
-		return nil, err
-	}
-
-	return payload, nil
-}
+	// ParamAuthTokenID the request parameter for the ID of the bearer token (bearer requests only)
+	ParamAuthTokenID ParamRequestKey = "auth_token_id"
+)
 
 // setOnRequest will set the value on the request with the given key
 func setOnRequest(req *http.Request, keyName ParamRequestKey, value interface{}) *http.Request {
@@ -182,3 +91,30 @@ func getBoolFromRequest(req *http.Request, key ParamRequestKey) (v bool, ok bool
 	v, ok = req.Context().Value(key).(bool)
 	return
 }
+
+// requestIP will get the IP of the client that made the request
+//
+// The X-Forwarded-For and X-Real-IP headers are only used if the request comes from a trusted proxy,
+// the client IP is the right-most IP in X-Forwarded-For that is not a trusted proxy
+func (c *Client) requestIP(req *http.Request) string {
+	remoteIP := parseIP(req.RemoteAddr)
+	if remoteIP == nil || !ipInList(remoteIP, c.options.trustedProxies) {
+		return req.RemoteAddr
+	}
+
+	if forwardedFor := req.Header.Values(headerForwardedFor); len(forwardedFor) > 0 {
+		ips := strings.Split(strings.Join(forwardedFor, ","), ",")
+		for i := len(ips) - 1; i >= 0; i-- {
+			ip := parseIP(ips[i])
+			if ip == nil {
+				break
+			} else if !ipInList(ip, c.options.trustedProxies) {
+				return ip.String()
+			}
+		}
+	}
+	if realIP := parseIP(req.Header.Get(headerRealIP)); realIP != nil {
+		return realIP.String()
+	}
+	return req.RemoteAddr
+}
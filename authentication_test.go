@@ -3,14 +3,15 @@ package bux
 import (
 	"bytes"
 	"context"
-	"fmt"
 	"net/http"
 	"strconv"
 	"testing"
 	"time"
 
+	"github.com/BuxOrg/bux/auth"
 	"github.com/BuxOrg/bux/utils"
 	"github.com/bitcoinschema/go-bitcoin/v2"
+	"github.com/libsv/go-bk/bip32"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -23,13 +24,12 @@ const (
 	// testSignatureAuthNonce = `dec0535f13b7ed61c2b188b7fe8fd5f578d6931aa90b6063c653ce0f8eefacf1`
 	// testSignatureAuthTime  = "1643828414038"
 	// testSignatureXpub     = `xpub661MyMwAqRbcFnj7dmEoX4ULYMJ2vxFBkH3oGrpuQMHTMpxUEGND1UXwskzgtUj6R7i9dRNGYj6NYuXWKVM5yAJYjSGuvBJfDTpqjsh8a3T`
-	testAccessKeyPKH      = "b97e4834a13d188ab0588dc2aaff11a6658771cd"
-	testBodyContents      = `{"test_field":"test_value"}`
-	testEncryption        = "35dbe09a941a90a5f59e57020face68860d7b284b7b2973a58de8b4242ec5a925a40ac2933b7e45e78a0b3a13123520e46f9566815589ba2d345577dadee0d5e"
-	testSignature         = `HxNguR72c6BV7tKNn5BQ3/mS2+RX3BGyQHFfVfQ3v4mVdAuh+w32QsFYxsB13KiXuRJ7ZnN7C8RhkAtLi/qvH88=`
-	testSignatureAuthHash = `5858adf09a0cc01f6d3a4d377f010408313031bb96b40d98e6edccf18c26464e`
-	testXpubAuth          = "xpub661MyMwAqRbcH3WGvLjupmr43L1GVH3MP2WQWvdreDraBeFJy64Xxv4LLX9ZVWWz3ZjZkMuZtSsc9qH9JZR74bR4PWkmtEvP423r6DJR8kA"
-	testXpubAuthHash      = "d8c2bed524071d72d859caf90da5f448b5861cd4d4fd47697f94166c13c5a987"
+	testAccessKeyPKH = "b97e4834a13d188ab0588dc2aaff11a6658771cd"
+	testBodyContents = `{"test_field":"test_value"}`
+	testEncryption   = "35dbe09a941a90a5f59e57020face68860d7b284b7b2973a58de8b4242ec5a925a40ac2933b7e45e78a0b3a13123520e46f9566815589ba2d345577dadee0d5e"
+	testSignature    = `HxNguR72c6BV7tKNn5BQ3/mS2+RX3BGyQHFfVfQ3v4mVdAuh+w32QsFYxsB13KiXuRJ7ZnN7C8RhkAtLi/qvH88=`
+	testXpubAuth     = "xpub661MyMwAqRbcH3WGvLjupmr43L1GVH3MP2WQWvdreDraBeFJy64Xxv4LLX9ZVWWz3ZjZkMuZtSsc9qH9JZR74bR4PWkmtEvP423r6DJR8kA"
+	testXpubAuthHash = "d8c2bed524071d72d859caf90da5f448b5861cd4d4fd47697f94166c13c5a987"
 )
 
 // TestClient_AuthenticateRequest will test the method AuthenticateRequest()
@@ -97,7 +97,7 @@ func TestClient_AuthenticateRequest(t *testing.T) {
 		require.NotNil(t, req)
 
 		var authData *AuthPayload
-		authData, err = createSignature(key, `{}`)
+		authData, err = auth.CreateSignature(key, `{}`)
 		require.NoError(t, err)
 		require.NotNil(t, authData)
 
@@ -108,7 +108,7 @@ func TestClient_AuthenticateRequest(t *testing.T) {
 		defer deferMe()
 
 		req, err = client.AuthenticateRequest(
-			context.Background(), req, []string{authData.xPub}, false, false, false,
+			context.Background(), req, []string{authData.XPub}, false, false, false,
 		)
 		require.NoError(t, err)
 		require.NotNil(t, req)
@@ -180,7 +180,7 @@ func TestClient_AuthenticateRequest(t *testing.T) {
 		require.NotNil(t, req)
 
 		var authData *AuthPayload
-		authData, err = createSignature(key, `{}`)
+		authData, err = auth.CreateSignature(key, `{}`)
 		require.NoError(t, err)
 		require.NotNil(t, authData)
 
@@ -191,7 +191,7 @@ func TestClient_AuthenticateRequest(t *testing.T) {
 		defer deferMe()
 
 		req, err = client.AuthenticateRequest(
-			context.Background(), req, []string{authData.xPub}, true, false, false,
+			context.Background(), req, []string{authData.XPub}, true, false, false,
 		)
 		require.NoError(t, err)
 		require.NotNil(t, req)
@@ -290,7 +290,7 @@ func TestClient_AuthenticateRequest(t *testing.T) {
 
 		var authData *AuthPayload
 		// AuthAccessKey
-		authData, err = createSignatureAccessKey(testAccessKeyPKH, `{}`)
+		authData, err = auth.CreateSignatureAccessKey(testAccessKeyPKH, `{}`)
 		require.NoError(t, err)
 		require.NotNil(t, authData)
 
@@ -298,7 +298,7 @@ func TestClient_AuthenticateRequest(t *testing.T) {
 		require.NoError(t, err)
 
 		_, err = client.AuthenticateRequest(
-			context.Background(), req, []string{authData.xPub}, false, true, false,
+			context.Background(), req, []string{authData.XPub}, false, true, false,
 		)
 		require.ErrorIs(t, err, ErrAuthAccessKeyNotFound)
 	})
@@ -317,7 +317,7 @@ func TestClient_AuthenticateRequest(t *testing.T) {
 
 		var authData *AuthPayload
 		// AuthAccessKey
-		authData, err = createSignatureAccessKey(accessKey.Key, `{}`)
+		authData, err = auth.CreateSignatureAccessKey(accessKey.Key, `{}`)
 		require.NoError(t, err)
 		require.NotNil(t, authData)
 
@@ -325,7 +325,7 @@ func TestClient_AuthenticateRequest(t *testing.T) {
 		require.NoError(t, err)
 
 		req, err = client.AuthenticateRequest(
-			context.Background(), req, []string{authData.xPub}, false, true, false,
+			context.Background(), req, []string{authData.XPub}, false, true, false,
 		)
 		require.NoError(t, err)
 		require.NotNil(t, req)
@@ -346,7 +346,7 @@ func TestClient_AuthenticateRequest(t *testing.T) {
 
 		var authData *AuthPayload
 		// AuthAccessKey
-		authData, err = createSignatureAccessKey(accessKey.Key, `{}`)
+		authData, err = auth.CreateSignatureAccessKey(accessKey.Key, `{}`)
 		require.NoError(t, err)
 		require.NotNil(t, authData)
 
@@ -354,7 +354,7 @@ func TestClient_AuthenticateRequest(t *testing.T) {
 		require.NoError(t, err)
 
 		req, err = client.AuthenticateRequest(
-			context.Background(), req, []string{authData.xPub}, false, false, false,
+			context.Background(), req, []string{authData.XPub}, false, false, false,
 		)
 		require.NoError(t, err)
 		require.NotNil(t, req)
@@ -362,122 +362,291 @@ func TestClient_AuthenticateRequest(t *testing.T) {
 	})
 }
 
-// Test_verifyKeyXPub will test the method verifyKeyXPub()
-func Test_verifyKeyXPub(t *testing.T) {
-	t.Parallel()
+// TestClient_AuthenticateRequest_Scope will test the scoped & expiring access keys of AuthenticateRequest()
+func TestClient_AuthenticateRequest_Scope(t *testing.T) {
 
-	t.Run("error - missing auth data", func(t *testing.T) {
+	// newScopedRequest will create a signed request for the given access key
+	newScopedRequest := func(t *testing.T, accessKey *AccessKey, remoteAddr string) *http.Request {
+		req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, "", bytes.NewReader([]byte(`{}`)))
+		require.NoError(t, err)
+		require.NotNil(t, req)
+		req.RemoteAddr = remoteAddr
+		err = SetSignatureFromAccessKey(&req.Header, accessKey.Key, `{}`)
+		require.NoError(t, err)
+		return req
+	}
 
-		err := verifyKeyXPub(testXpubAuth, nil)
-		require.Error(t, err)
-		assert.ErrorIs(t, err, ErrMissingSignature)
-	})
+	t.Run("xpub - unrestricted scope", func(t *testing.T) {
+		key, err := bitcoin.GenerateHDKey(bitcoin.SecureSeedLength)
+		require.NoError(t, err)
 
-	t.Run("error - missing auth signature", func(t *testing.T) {
-		err := checkSignatureRequirements(&AuthPayload{})
-		require.Error(t, err)
-		assert.ErrorIs(t, err, ErrMissingSignature)
+		var req *http.Request
+		req, err = http.NewRequestWithContext(context.Background(), http.MethodGet, "", bytes.NewReader([]byte(`{}`)))
+		require.NoError(t, err)
+		err = SetSignature(&req.Header, key, `{}`)
+		require.NoError(t, err)
+
+		_, client, deferMe := CreateTestSQLiteClient(t, false, false)
+		defer deferMe()
+
+		req, err = client.AuthenticateRequest(context.Background(), req, []string{}, false, true, false)
+		require.NoError(t, err)
+
+		scope, ok := GetAccessKeyScopeFromRequest(req)
+		require.True(t, ok)
+		assert.True(t, scope.IsUnrestricted())
+		assert.True(t, scope.HasPermission(AccessKeyPermissionSend))
 	})
 
-	t.Run("error - auth hash mismatch", func(t *testing.T) {
-		err := checkSignatureRequirements(&AuthPayload{
-			AuthHash:     "bad-hash",
-			BodyContents: testBodyContents,
-			Signature:    testSignature,
-		})
-		require.Error(t, err)
-		assert.ErrorIs(t, err, ErrAuhHashMismatch)
+	t.Run("access key - scope on request", func(t *testing.T) {
+		ctx, client, deferMe := CreateTestSQLiteClient(t, false, false)
+		defer deferMe()
+
+		accessKey := newAccessKey(testXPubID, append(client.DefaultModelOptions(), New())...)
+		accessKey.Scope = AccessKeyScope{Permissions: []AccessKeyPermission{AccessKeyPermissionRead}}
+		err := accessKey.Save(ctx)
+		require.NoError(t, err)
+
+		var req *http.Request
+		req, err = client.AuthenticateRequest(
+			context.Background(), newScopedRequest(t, accessKey, "127.0.0.1:1234"), []string{}, false, true, false,
+		)
+		require.NoError(t, err)
+
+		accessKeyID, ok := GetAccessKeyIDFromRequest(req)
+		require.True(t, ok)
+		assert.Equal(t, accessKey.ID, accessKeyID)
+
+		scope, ok := GetAccessKeyScopeFromRequest(req)
+		require.True(t, ok)
+		assert.False(t, scope.IsUnrestricted())
+		assert.True(t, scope.HasPermission(AccessKeyPermissionRead))
+		assert.False(t, scope.HasPermission(AccessKeyPermissionSend))
 	})
 
-	t.Run("error - signature expired", func(t *testing.T) {
-		err := checkSignatureRequirements(&AuthPayload{
-			AuthHash:     testSignatureAuthHash,
-			BodyContents: testBodyContents,
-			Signature:    testSignature,
-			AuthTime:     1643828414038,
-		})
-		require.Error(t, err)
-		assert.ErrorIs(t, err, ErrSignatureExpired)
+	t.Run("error - access key expired", func(t *testing.T) {
+		ctx, client, deferMe := CreateTestSQLiteClient(t, false, false)
+		defer deferMe()
+
+		accessKey := newAccessKey(testXPubID, append(client.DefaultModelOptions(), New())...)
+		accessKey.ExpiresAt.Valid = true
+		accessKey.ExpiresAt.Time = time.Now().UTC().Add(-1 * time.Minute)
+		err := accessKey.Save(ctx)
+		require.NoError(t, err)
+
+		_, err = client.AuthenticateRequest(
+			context.Background(), newScopedRequest(t, accessKey, "127.0.0.1:1234"), []string{}, false, true, false,
+		)
+		require.ErrorIs(t, err, ErrAccessKeyExpired)
 	})
 
-	t.Run("error - bad xpub", func(t *testing.T) {
-		err := verifyKeyXPub("invalid-key", &AuthPayload{
-			AuthHash:     testSignatureAuthHash,
-			BodyContents: testBodyContents,
-			Signature:    testSignature,
-			AuthTime:     time.Now().UnixMilli(),
-		})
-		require.Error(t, err)
+	t.Run("access key - not expired yet", func(t *testing.T) {
+		ctx, client, deferMe := CreateTestSQLiteClient(t, false, false)
+		defer deferMe()
+
+		accessKey := newAccessKey(testXPubID, append(client.DefaultModelOptions(), New())...)
+		accessKey.ExpiresAt.Valid = true
+		accessKey.ExpiresAt.Time = time.Now().UTC().Add(1 * time.Hour)
+		err := accessKey.Save(ctx)
+		require.NoError(t, err)
+
+		_, err = client.AuthenticateRequest(
+			context.Background(), newScopedRequest(t, accessKey, "127.0.0.1:1234"), []string{}, false, true, false,
+		)
+		require.NoError(t, err)
 	})
 
-	t.Run("error - invalid signature - time is wrong", func(t *testing.T) {
-		err := checkSignatureRequirements(&AuthPayload{
-			AuthHash:     testSignatureAuthHash,
-			BodyContents: testBodyContents,
-			Signature:    testSignature,
-			AuthTime:     0,
-		})
-		require.Error(t, err)
-		assert.ErrorIs(t, err, ErrSignatureExpired)
+	t.Run("access key - ip restriction", func(t *testing.T) {
+		ctx, client, deferMe := CreateTestSQLiteClient(t, false, false)
+		defer deferMe()
+
+		accessKey := newAccessKey(testXPubID, append(client.DefaultModelOptions(), New())...)
+		accessKey.Scope = AccessKeyScope{AllowedIPs: []string{"10.0.0.0/8"}}
+		err := accessKey.Save(ctx)
+		require.NoError(t, err)
+
+		_, err = client.AuthenticateRequest(
+			context.Background(), newScopedRequest(t, accessKey, "127.0.0.1:1234"), []string{}, false, true, false,
+		)
+		require.ErrorIs(t, err, ErrAccessKeyIPNotAllowed)
+
+		_, err = client.AuthenticateRequest(
+			context.Background(), newScopedRequest(t, accessKey, "10.1.2.3:1234"), []string{}, false, true, false,
+		)
+		require.NoError(t, err)
 	})
+	t.Run("access key - ip restriction behind a trusted proxy", func(t *testing.T) {
+		ctx, client, deferMe := CreateTestSQLiteClient(t, false, false, WithTrustedProxies("127.0.0.1"))
+		defer deferMe()
 
-	t.Run("valid signature", func(t *testing.T) {
-		key, err := bitcoin.GenerateHDKey(bitcoin.SecureSeedLength)
+		accessKey := newAccessKey(testXPubID, append(client.DefaultModelOptions(), New())...)
+		accessKey.Scope = AccessKeyScope{AllowedIPs: []string{"10.0.0.0/8"}}
+		err := accessKey.Save(ctx)
 		require.NoError(t, err)
-		require.NotNil(t, key)
 
-		authData, err2 := createSignature(key, testBodyContents)
-		require.NoError(t, err2)
-		require.NotNil(t, authData)
+		// the client IP is set by the trusted proxy
+		req := newScopedRequest(t, accessKey, "127.0.0.1:1234")
+		req.Header.Set("X-Forwarded-For", "192.168.1.1, 10.1.2.3")
+		_, err = client.AuthenticateRequest(context.Background(), req, []string{}, false, true, false)
+		require.NoError(t, err)
 
-		err = verifyKeyXPub(authData.xPub, &AuthPayload{
-			AuthHash:     authData.AuthHash,
-			AuthNonce:    authData.AuthNonce,
-			AuthTime:     authData.AuthTime,
-			BodyContents: testBodyContents,
-			Signature:    authData.Signature,
-		})
+		req = newScopedRequest(t, accessKey, "127.0.0.1:1234")
+		req.Header.Set("X-Real-IP", "10.1.2.3")
+		_, err = client.AuthenticateRequest(context.Background(), req, []string{}, false, true, false)
 		require.NoError(t, err)
+
+		// the (spoofed) client IP comes before the trusted proxy
+		req = newScopedRequest(t, accessKey, "127.0.0.1:1234")
+		req.Header.Set("X-Forwarded-For", "10.1.2.3, 192.168.1.1")
+		_, err = client.AuthenticateRequest(context.Background(), req, []string{}, false, true, false)
+		require.ErrorIs(t, err, ErrAccessKeyIPNotAllowed)
+
+		// the headers are ignored if the request is not from a trusted proxy
+		req = newScopedRequest(t, accessKey, "192.168.1.1:1234")
+		req.Header.Set("X-Forwarded-For", "10.1.2.3")
+		req.Header.Set("X-Real-IP", "10.1.2.3")
+		_, err = client.AuthenticateRequest(context.Background(), req, []string{}, false, true, false)
+		require.ErrorIs(t, err, ErrAccessKeyIPNotAllowed)
 	})
 }
 
-// TestCreateSignature will test the method CreateSignature()
-func TestCreateSignature(t *testing.T) {
-	t.Parallel()
+// TestClient_AuthenticateRequest_Replay will test the nonce replay protection of AuthenticateRequest()
+func TestClient_AuthenticateRequest_Replay(t *testing.T) {
 
-	t.Run("valid signature", func(t *testing.T) {
+	// newSignedRequest will create a request with the given signed headers
+	newSignedRequest := func(t *testing.T, header http.Header) *http.Request {
+		req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, "", bytes.NewReader([]byte(`{}`)))
+		require.NoError(t, err)
+		require.NotNil(t, req)
+		req.Header = header.Clone()
+		return req
+	}
+
+	t.Run("xpub - replayed request", func(t *testing.T) {
 		key, err := bitcoin.GenerateHDKey(bitcoin.SecureSeedLength)
 		require.NoError(t, err)
-		require.NotNil(t, key)
 
-		var sig string
-		sig, err = CreateSignature(key, testBodyContents)
+		header := http.Header{}
+		err = SetSignature(&header, key, `{}`)
 		require.NoError(t, err)
-		require.NotNil(t, sig)
-		assert.Greater(t, len(sig), 40)
+
+		_, client, deferMe := CreateTestSQLiteClient(t, false, false)
+		defer deferMe()
+
+		_, err = client.AuthenticateRequest(
+			context.Background(), newSignedRequest(t, header), []string{}, false, true, false,
+		)
+		require.NoError(t, err)
+
+		_, err = client.AuthenticateRequest(
+			context.Background(), newSignedRequest(t, header), []string{}, false, true, false,
+		)
+		require.ErrorIs(t, err, ErrSignatureNonceReused)
 	})
 
-	t.Run("missing key", func(t *testing.T) {
-		sig, err := CreateSignature(nil, testBodyContents)
-		require.Error(t, err)
-		assert.Equal(t, "", sig)
+	t.Run("xpub - replayed request - not required", func(t *testing.T) {
+		key, err := bitcoin.GenerateHDKey(bitcoin.SecureSeedLength)
+		require.NoError(t, err)
+
+		header := http.Header{}
+		err = SetSignature(&header, key, `{}`)
+		require.NoError(t, err)
+
+		_, client, deferMe := CreateTestSQLiteClient(t, false, false)
+		defer deferMe()
+
+		var req *http.Request
+		req, err = client.AuthenticateRequest(
+			context.Background(), newSignedRequest(t, header), []string{}, false, false, false,
+		)
+		require.NoError(t, err)
+		assert.Equal(t, true, req.Context().Value(ParamAuthSigned))
+
+		// the replayed request is not considered signed
+		req, err = client.AuthenticateRequest(
+			context.Background(), newSignedRequest(t, header), []string{}, false, false, false,
+		)
+		require.NoError(t, err)
+		assert.Equal(t, false, req.Context().Value(ParamAuthSigned))
 	})
 
-	t.Run("missing body contents - still has signature", func(t *testing.T) {
+	t.Run("access key - replayed request", func(t *testing.T) {
+		ctx, client, deferMe := CreateTestSQLiteClient(t, false, false)
+		defer deferMe()
+
+		accessKey := newAccessKey(testXPubID, append(client.DefaultModelOptions(), New())...)
+		err := accessKey.Save(ctx)
+		require.NoError(t, err)
+
+		header := http.Header{}
+		err = SetSignatureFromAccessKey(&header, accessKey.Key, `{}`)
+		require.NoError(t, err)
+
+		_, err = client.AuthenticateRequest(
+			context.Background(), newSignedRequest(t, header), []string{}, false, false, false,
+		)
+		require.NoError(t, err)
+
+		_, err = client.AuthenticateRequest(
+			context.Background(), newSignedRequest(t, header), []string{}, false, false, false,
+		)
+		require.ErrorIs(t, err, ErrSignatureNonceReused)
+	})
+
+	t.Run("invalid signature does not use the nonce", func(t *testing.T) {
 		key, err := bitcoin.GenerateHDKey(bitcoin.SecureSeedLength)
 		require.NoError(t, err)
-		require.NotNil(t, key)
 
-		var sig string
-		sig, err = CreateSignature(key, "")
+		header := http.Header{}
+		err = SetSignature(&header, key, `{}`)
+		require.NoError(t, err)
+
+		_, client, deferMe := CreateTestSQLiteClient(t, false, false)
+		defer deferMe()
+
+		badHeader := header.Clone()
+		badHeader.Set(AuthSignature, testSignature)
+		_, err = client.AuthenticateRequest(
+			context.Background(), newSignedRequest(t, badHeader), []string{}, false, true, false,
+		)
+		require.ErrorIs(t, err, ErrSignatureInvalid)
+
+		_, err = client.AuthenticateRequest(
+			context.Background(), newSignedRequest(t, header), []string{}, false, true, false,
+		)
 		require.NoError(t, err)
-		require.NotNil(t, sig)
-		assert.Greater(t, len(sig), 40)
 	})
 }
 
-// Test_createSignature will test the method createSignature()
-func Test_createSignature(t *testing.T) {
+// Test_checkSignatureNonce will test the method checkSignatureNonce()
+func Test_checkSignatureNonce(t *testing.T) {
+
+	t.Run("error - missing nonce", func(t *testing.T) {
+		_, client, deferMe := CreateTestSQLiteClient(t, false, false)
+		defer deferMe()
+
+		err := client.(*Client).checkSignatureNonce(context.Background(), testXpubAuth, &AuthPayload{})
+		require.ErrorIs(t, err, ErrMissingSignatureNonce)
+	})
+
+	t.Run("same nonce - different keys", func(t *testing.T) {
+		_, client, deferMe := CreateTestSQLiteClient(t, false, false)
+		defer deferMe()
+
+		payload := &AuthPayload{AuthNonce: "test-nonce"}
+		err := client.(*Client).checkSignatureNonce(context.Background(), testXpubAuth, payload)
+		require.NoError(t, err)
+
+		err = client.(*Client).checkSignatureNonce(context.Background(), testXPub, payload)
+		require.NoError(t, err)
+
+		err = client.(*Client).checkSignatureNonce(context.Background(), testXpubAuth, payload)
+		require.ErrorIs(t, err, ErrSignatureNonceReused)
+	})
+}
+
+// TestCreateSignature will test the method CreateSignature()
+func TestCreateSignature(t *testing.T) {
 	t.Parallel()
 
 	t.Run("valid signature", func(t *testing.T) {
@@ -485,55 +654,29 @@ func Test_createSignature(t *testing.T) {
 		require.NoError(t, err)
 		require.NotNil(t, key)
 
-		var authData *AuthPayload
-		authData, err = createSignature(key, testBodyContents)
-		require.NoError(t, err)
-		require.NotNil(t, authData)
-
-		assert.Equal(t, utils.XpubKeyLength, len(authData.xPub))
-		assert.Equal(t, 64, len(authData.AuthHash))
-		assert.Equal(t, 64, len(authData.AuthNonce))
-		assert.Greater(t, authData.AuthTime, time.Now().Add(-1*time.Second).UnixMilli())
-
-		err = verifyKeyXPub(authData.xPub, &AuthPayload{
-			AuthHash:     authData.AuthHash,
-			AuthNonce:    authData.AuthNonce,
-			AuthTime:     authData.AuthTime,
-			BodyContents: testBodyContents,
-			Signature:    authData.Signature,
-		})
+		var sig string
+		sig, err = CreateSignature(key, testBodyContents)
 		require.NoError(t, err)
+		require.NotNil(t, sig)
+		assert.Greater(t, len(sig), 40)
 	})
 
-	t.Run("error - missing key", func(t *testing.T) {
-		authData, err := createSignature(nil, testBodyContents)
+	t.Run("missing key", func(t *testing.T) {
+		sig, err := CreateSignature(nil, testBodyContents)
 		require.Error(t, err)
-		require.Nil(t, authData)
+		assert.Equal(t, "", sig)
 	})
 
-	t.Run("error - empty body - valid signature", func(t *testing.T) {
+	t.Run("missing body contents - still has signature", func(t *testing.T) {
 		key, err := bitcoin.GenerateHDKey(bitcoin.SecureSeedLength)
 		require.NoError(t, err)
 		require.NotNil(t, key)
 
-		var authData *AuthPayload
-		authData, err = createSignature(key, "")
-		require.NoError(t, err)
-		require.NotNil(t, authData)
-
-		assert.Equal(t, utils.XpubKeyLength, len(authData.xPub))
-		assert.Equal(t, 64, len(authData.AuthHash))
-		assert.Equal(t, 64, len(authData.AuthNonce))
-		assert.Greater(t, authData.AuthTime, time.Now().Add(-1*time.Second).UnixMilli())
-
-		err = verifyKeyXPub(authData.xPub, &AuthPayload{
-			AuthHash:     authData.AuthHash,
-			AuthNonce:    authData.AuthNonce,
-			AuthTime:     authData.AuthTime,
-			BodyContents: "",
-			Signature:    authData.Signature,
-		})
+		var sig string
+		sig, err = CreateSignature(key, "")
 		require.NoError(t, err)
+		require.NotNil(t, sig)
+		assert.Greater(t, len(sig), 40)
 	})
 }
 
@@ -568,7 +711,7 @@ func TestSetSignature(t *testing.T) {
 		assert.NotEmpty(t, emptyHeaders.Get(AuthSignature))
 
 		authTime, _ := strconv.Atoi(emptyHeaders.Get(AuthHeaderTime))
-		err = verifyKeyXPub(xPub, &AuthPayload{
+		err = auth.VerifyXPub(xPub, &AuthPayload{
 			AuthHash:     emptyHeaders.Get(AuthHeaderHash),
 			AuthNonce:    emptyHeaders.Get(AuthHeaderNonce),
 			AuthTime:     int64(authTime),
@@ -579,20 +722,6 @@ func TestSetSignature(t *testing.T) {
 	})
 }
 
-// Test_getSigningMessage will test the method Test_getSigningMessage()
-func Test_getSigningMessage(t *testing.T) {
-	t.Parallel()
-
-	t.Run("valid format", func(t *testing.T) {
-		message := getSigningMessage(testXpubAuth, &AuthPayload{
-			AuthHash:  testXpubAuthHash,
-			AuthNonce: "auth-nonce",
-			AuthTime:  12345678,
-		})
-		assert.Equal(t, fmt.Sprintf("%s%s%s%d", testXpubAuth, testXpubAuthHash, "auth-nonce", 12345678), message)
-	})
-}
-
 // TestGetXpubFromRequest will test the method GetXpubFromRequest()
 func TestGetXpubFromRequest(t *testing.T) {
 	t.Parallel()
@@ -685,3 +814,70 @@ func TestGetXpubHashFromRequest(t *testing.T) {
 		assert.Equal(t, false, success)
 	})
 }
+
+// TestClient_AuthenticateRequest_AdminKey will test AuthenticateRequest() with admin keys from the Datastore
+func TestClient_AuthenticateRequest_AdminKey(t *testing.T) {
+
+	// newAdminRequest will create a signed request for the given key
+	newAdminRequest := func(t *testing.T, key *bip32.ExtendedKey) *http.Request {
+		req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, "", bytes.NewReader([]byte(`{}`)))
+		require.NoError(t, err)
+		require.NoError(t, SetSignature(&req.Header, key, `{}`))
+		return req
+	}
+
+	t.Run("admin key in the datastore", func(t *testing.T) {
+		ctx, client, deferMe := CreateTestSQLiteClient(t, false, false)
+		defer deferMe()
+
+		key, err := bitcoin.GenerateHDKey(bitcoin.SecureSeedLength)
+		require.NoError(t, err)
+		var xPub string
+		xPub, err = bitcoin.GetExtendedPublicKey(key)
+		require.NoError(t, err)
+
+		// Not an admin (yet)
+		_, err = client.AuthenticateRequest(ctx, newAdminRequest(t, key), []string{}, true, true, false)
+		require.ErrorIs(t, err, ErrNotAdminKey)
+
+		superXPub, superID := newTestAdminXPub(t)
+		_, err = client.BootstrapAdminKey(ctx, superXPub)
+		require.NoError(t, err)
+		_, err = client.NewAdminKey(ctx, xPub, AdminRoleAuditor, superID)
+		require.NoError(t, err)
+
+		var req *http.Request
+		req, err = client.AuthenticateRequest(ctx, newAdminRequest(t, key), []string{}, true, true, false)
+		require.NoError(t, err)
+
+		role, ok := GetAdminRoleFromRequest(req)
+		require.True(t, ok)
+		assert.Equal(t, AdminRoleAuditor, role)
+
+		// Revoked keys are not admins anymore
+		_, err = client.RevokeAdminKey(ctx, utils.Hash(xPub), superID)
+		require.NoError(t, err)
+
+		_, err = client.AuthenticateRequest(ctx, newAdminRequest(t, key), []string{}, true, true, false)
+		require.ErrorIs(t, err, ErrNotAdminKey)
+	})
+
+	t.Run("admin xpubs (config) are super admins", func(t *testing.T) {
+		ctx, client, deferMe := CreateTestSQLiteClient(t, false, false)
+		defer deferMe()
+
+		key, err := bitcoin.GenerateHDKey(bitcoin.SecureSeedLength)
+		require.NoError(t, err)
+		var xPub string
+		xPub, err = bitcoin.GetExtendedPublicKey(key)
+		require.NoError(t, err)
+
+		var req *http.Request
+		req, err = client.AuthenticateRequest(ctx, newAdminRequest(t, key), []string{xPub}, true, true, false)
+		require.NoError(t, err)
+
+		role, ok := GetAdminRoleFromRequest(req)
+		require.True(t, ok)
+		assert.Equal(t, AdminRoleSuperAdmin, role)
+	})
+}
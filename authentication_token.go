@@ -0,0 +1,169 @@
+package bux
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/BuxOrg/bux/utils"
+	"github.com/mrz1836/go-cachestore"
+)
+
+// AuthToken is a short-lived bearer token for an xPub or access key (session)
+//
+// The token itself is only returned on creation, the cachestore only knows the hash (ID) of the token
+type AuthToken struct {
+	AccessKeyID string          `json:"access_key_id,omitempty"` // Access key that created the token (empty for xPub)
+	CreatedAt   time.Time       `json:"created_at"`              // When the token was created
+	ExpiresAt   time.Time       `json:"expires_at"`              // When the token expires
+	ID          string          `json:"id"`                      // Hash of the token
+	Scope       *AccessKeyScope `json:"scope,omitempty"`         // Effective scope of the token
+	Token       string          `json:"token,omitempty"`         // Used on "CREATE", shown to the user "once" only
+	XPub        string          `json:"xpub,omitempty"`          // Raw xPub (empty for access keys)
+	XpubID      string          `json:"xpub_id"`                 // xPub ID of the token
+}
+
+// IsExpired will return true if the token has expired
+func (t *AuthToken) IsExpired() bool {
+	return !t.ExpiresAt.After(time.Now().UTC())
+}
+
+// NewAuthToken will create a new bearer token for the xPub or access key of the (signed) request
+//
+// The request must be signed (proving ownership of the key), the token can then be used in the
+// Authorization header (Bearer) instead of signing every request
+func (c *Client) NewAuthToken(ctx context.Context, req *http.Request) (*AuthToken, error) {
+
+	// Check for existing NewRelic transaction
+	ctx = c.GetOrStartTxn(ctx, "new_auth_token")
+
+	// A token can not be used to create a new token
+	if len(strings.TrimSpace(req.Header.Get(AuthHeader))) == 0 &&
+		len(strings.TrimSpace(req.Header.Get(AuthAccessKey))) == 0 {
+		return nil, ErrMissingAuthHeader
+	}
+
+	// Prove the ownership of the key (signature is required)
+	req, err := c.AuthenticateRequest(ctx, req, nil, false, true, false)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create the token
+	var token string
+	if token, err = utils.RandomHex(32); err != nil {
+		return nil, err
+	}
+	authToken := &AuthToken{
+		CreatedAt: time.Now().UTC(),
+		ID:        utils.Hash(token),
+	}
+	authToken.ExpiresAt = authToken.CreatedAt.Add(c.options.authTokenTTL)
+	authToken.AccessKeyID, _ = GetAccessKeyIDFromRequest(req)
+	authToken.Scope, _ = GetAccessKeyScopeFromRequest(req)
+	authToken.XPub, _ = GetXpubFromRequest(req)
+	authToken.XpubID, _ = GetXpubIDFromRequest(req)
+
+	// Store the token (without the token itself)
+	if err = c.Cachestore().SetModel(
+		ctx, fmt.Sprintf(cacheKeyAuthToken, authToken.ID), authToken, c.options.authTokenTTL,
+	); err != nil {
+		return nil, err
+	}
+
+	// Return the token (only time the token is shown)
+	authToken.Token = token
+	return authToken, nil
+}
+
+// RevokeAuthToken will revoke the bearer token
+func (c *Client) RevokeAuthToken(ctx context.Context, token string) error {
+
+	// Check for existing NewRelic transaction
+	ctx = c.GetOrStartTxn(ctx, "revoke_auth_token")
+
+	return c.Cachestore().Delete(ctx, fmt.Sprintf(cacheKeyAuthToken, utils.Hash(token)))
+}
+
+// getAuthToken will get the (valid) auth token from the cachestore
+func (c *Client) getAuthToken(ctx context.Context, token string) (*AuthToken, error) {
+	authToken := new(AuthToken)
+	if err := c.Cachestore().GetModel(
+		ctx, fmt.Sprintf(cacheKeyAuthToken, utils.Hash(token)), authToken,
+	); err != nil {
+		if errors.Is(err, cachestore.ErrKeyNotFound) {
+			return nil, ErrAuthTokenInvalid
+		}
+		return nil, err
+	} else if authToken.IsExpired() {
+		return nil, ErrAuthTokenInvalid
+	}
+	return authToken, nil
+}
+
+// authenticateRequestToken will authenticate the request using the bearer token
+func (c *Client) authenticateRequestToken(ctx context.Context, req *http.Request, token string,
+	adminXPubs []string, adminRequired bool) (*http.Request, error) {
+
+	authToken, err := c.getAuthToken(ctx, token)
+	if err != nil {
+		return req, err
+	}
+
+	// Access keys are checked on every request (revoked or expired keys invalidate the token)
+	if len(authToken.AccessKeyID) > 0 {
+		var accessKey *AccessKey
+		if accessKey, err = getAccessKey(ctx, authToken.AccessKeyID, c.DefaultModelOptions()...); err != nil {
+			return req, err
+		} else if accessKey == nil || accessKey.RevokedAt.Valid {
+			return req, ErrAuthAccessKeyNotFound
+		} else if accessKey.IsExpired() {
+			return req, ErrAccessKeyExpired
+		} else if !accessKey.Scope.IsIPAllowed(c.requestIP(req)) {
+			return req, ErrAccessKeyIPNotAllowed
+		}
+	}
+
+	// Check for admin key
+	if adminRequired {
+		var adminRole AdminRole
+		if adminRole, err = c.getAdminRole(ctx, authToken.XPub, authToken.XpubID, adminXPubs); err != nil {
+			return req, err
+		} else if len(adminRole) == 0 {
+			return req, ErrNotAdminKey
+		}
+		req = setOnRequest(req, ParamAdminRole, string(adminRole))
+	}
+
+	scope := authToken.Scope
+	if scope == nil {
+		scope = &AccessKeyScope{}
+	}
+
+	// Ownership of the key was proven when the token was created
+	req = setOnRequest(req, ParamAuthSigned, true)
+	req = setOnRequest(req, ParamAdminRequest, adminRequired)
+	req = setOnRequest(req, ParamAccessKeyScope, scope)
+	req = setOnRequest(req, ParamAccessKeyID, authToken.AccessKeyID)
+	req = setOnRequest(req, ParamAuthTokenID, authToken.ID)
+
+	// Set the data back onto the request
+	return setOnRequest(setOnRequest(req, ParamXPubKey, authToken.XPub), ParamXPubHashKey, authToken.XpubID), nil
+}
+
+// getBearerToken will get the bearer token from the Authorization header (if found)
+func getBearerToken(req *http.Request) string {
+	authorization := strings.TrimSpace(req.Header.Get(AuthHeaderAuthorization))
+	if len(authorization) > len(AuthBearerPrefix) && strings.EqualFold(authorization[:len(AuthBearerPrefix)], AuthBearerPrefix) {
+		return strings.TrimSpace(authorization[len(AuthBearerPrefix):])
+	}
+	return ""
+}
+
+// GetAuthTokenIDFromRequest gets the stored auth token ID from the request if found (bearer requests only)
+func GetAuthTokenIDFromRequest(req *http.Request) (string, bool) {
+	return getFromRequest(req, ParamAuthTokenID)
+}
@@ -0,0 +1,173 @@
+package bux
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/bitcoinschema/go-bitcoin/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestBearerRequest will create a new request with the bearer token
+func newTestBearerRequest(t *testing.T, token string) *http.Request {
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, "", bytes.NewReader([]byte(`{}`)))
+	require.NoError(t, err)
+	req.Header.Set(AuthHeaderAuthorization, AuthBearerPrefix+token)
+	return req
+}
+
+// Test_getBearerToken will test the method getBearerToken()
+func Test_getBearerToken(t *testing.T) {
+	t.Parallel()
+
+	req := newTestBearerRequest(t, "test-token")
+	assert.Equal(t, "test-token", getBearerToken(req))
+
+	req.Header.Set(AuthHeaderAuthorization, "bearer  test-token ")
+	assert.Equal(t, "test-token", getBearerToken(req))
+
+	req.Header.Set(AuthHeaderAuthorization, "Basic dGVzdA==")
+	assert.Equal(t, "", getBearerToken(req))
+
+	req.Header.Del(AuthHeaderAuthorization)
+	assert.Equal(t, "", getBearerToken(req))
+}
+
+// TestClient_NewAuthToken will test the methods NewAuthToken() and RevokeAuthToken()
+func TestClient_NewAuthToken(t *testing.T) {
+
+	t.Run("xpub token", func(t *testing.T) {
+		ctx, client, deferMe := CreateTestSQLiteClient(t, false, false)
+		defer deferMe()
+
+		key, err := bitcoin.GenerateHDKey(bitcoin.SecureSeedLength)
+		require.NoError(t, err)
+		var xPub string
+		xPub, err = bitcoin.GetExtendedPublicKey(key)
+		require.NoError(t, err)
+
+		var req *http.Request
+		req, err = http.NewRequestWithContext(context.Background(), http.MethodPost, "", bytes.NewReader([]byte(`{}`)))
+		require.NoError(t, err)
+		require.NoError(t, SetSignature(&req.Header, key, `{}`))
+
+		var authToken *AuthToken
+		authToken, err = client.NewAuthToken(ctx, req)
+		require.NoError(t, err)
+		require.NotNil(t, authToken)
+		assert.Len(t, authToken.Token, 64)
+		assert.Equal(t, xPub, authToken.XPub)
+		assert.True(t, authToken.ExpiresAt.After(time.Now().UTC().Add(defaultAuthTokenTTL-time.Minute)))
+
+		// Use the token (no signature)
+		req, err = client.AuthenticateRequest(ctx, newTestBearerRequest(t, authToken.Token), []string{}, false, true, false)
+		require.NoError(t, err)
+
+		value, ok := GetXpubFromRequest(req)
+		require.True(t, ok)
+		assert.Equal(t, xPub, value)
+		value, ok = GetAuthTokenIDFromRequest(req)
+		require.True(t, ok)
+		assert.Equal(t, authToken.ID, value)
+		assert.Equal(t, true, req.Context().Value(ParamAuthSigned))
+
+		// Admin (config list)
+		_, err = client.AuthenticateRequest(ctx, newTestBearerRequest(t, authToken.Token), []string{}, true, true, false)
+		require.ErrorIs(t, err, ErrNotAdminKey)
+		_, err = client.AuthenticateRequest(ctx, newTestBearerRequest(t, authToken.Token), []string{xPub}, true, true, false)
+		require.NoError(t, err)
+
+		// A token can not create a new token
+		_, err = client.NewAuthToken(ctx, newTestBearerRequest(t, authToken.Token))
+		require.ErrorIs(t, err, ErrMissingAuthHeader)
+
+		// Revoke the token
+		require.NoError(t, client.RevokeAuthToken(ctx, authToken.Token))
+		_, err = client.AuthenticateRequest(ctx, newTestBearerRequest(t, authToken.Token), []string{}, false, true, false)
+		require.ErrorIs(t, err, ErrAuthTokenInvalid)
+	})
+
+	t.Run("unsigned request", func(t *testing.T) {
+		ctx, client, deferMe := CreateTestSQLiteClient(t, false, false)
+		defer deferMe()
+
+		req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, "", bytes.NewReader([]byte(`{}`)))
+		require.NoError(t, err)
+		req.Header.Set(AuthHeader, testXpubAuth)
+
+		_, err = client.NewAuthToken(ctx, req)
+		require.ErrorIs(t, err, ErrMissingSignature)
+	})
+
+	t.Run("unknown token", func(t *testing.T) {
+		ctx, client, deferMe := CreateTestSQLiteClient(t, false, false)
+		defer deferMe()
+
+		_, err := client.AuthenticateRequest(ctx, newTestBearerRequest(t, "unknown"), []string{}, false, true, false)
+		require.ErrorIs(t, err, ErrAuthTokenInvalid)
+	})
+
+	t.Run("expired token", func(t *testing.T) {
+		ctx, client, deferMe := CreateTestSQLiteClient(t, false, false, WithAuthTokenTTL(time.Millisecond))
+		defer deferMe()
+
+		key, err := bitcoin.GenerateHDKey(bitcoin.SecureSeedLength)
+		require.NoError(t, err)
+
+		var req *http.Request
+		req, err = http.NewRequestWithContext(context.Background(), http.MethodPost, "", bytes.NewReader([]byte(`{}`)))
+		require.NoError(t, err)
+		require.NoError(t, SetSignature(&req.Header, key, `{}`))
+
+		var authToken *AuthToken
+		authToken, err = client.NewAuthToken(ctx, req)
+		require.NoError(t, err)
+
+		time.Sleep(5 * time.Millisecond)
+		_, err = client.AuthenticateRequest(ctx, newTestBearerRequest(t, authToken.Token), []string{}, false, true, false)
+		require.ErrorIs(t, err, ErrAuthTokenInvalid)
+	})
+
+	t.Run("access key token", func(t *testing.T) {
+		ctx, client, deferMe := CreateTestSQLiteClient(t, false, false)
+		defer deferMe()
+
+		accessKey := newAccessKey(testXPubID, append(client.DefaultModelOptions(), New())...)
+		accessKey.Scope = AccessKeyScope{Permissions: []AccessKeyPermission{AccessKeyPermissionRead}}
+		require.NoError(t, accessKey.Save(ctx))
+
+		req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, "", bytes.NewReader([]byte(`{}`)))
+		require.NoError(t, err)
+		require.NoError(t, SetSignatureFromAccessKey(&req.Header, accessKey.Key, `{}`))
+
+		var authToken *AuthToken
+		authToken, err = client.NewAuthToken(ctx, req)
+		require.NoError(t, err)
+		assert.Equal(t, accessKey.ID, authToken.AccessKeyID)
+		assert.Equal(t, testXPubID, authToken.XpubID)
+
+		req, err = client.AuthenticateRequest(ctx, newTestBearerRequest(t, authToken.Token), []string{}, false, true, false)
+		require.NoError(t, err)
+
+		scope, ok := GetAccessKeyScopeFromRequest(req)
+		require.True(t, ok)
+		assert.True(t, scope.HasPermission(AccessKeyPermissionRead))
+		assert.False(t, scope.HasPermission(AccessKeyPermissionSend))
+
+		// Access keys are never admins
+		_, err = client.AuthenticateRequest(ctx, newTestBearerRequest(t, authToken.Token), []string{}, true, true, false)
+		require.ErrorIs(t, err, ErrNotAdminKey)
+
+		// Revoking the access key invalidates the token
+		accessKey.RevokedAt.Valid = true
+		accessKey.RevokedAt.Time = time.Now().UTC()
+		require.NoError(t, accessKey.Save(ctx))
+
+		_, err = client.AuthenticateRequest(ctx, newTestBearerRequest(t, authToken.Token), []string{}, false, true, false)
+		require.ErrorIs(t, err, ErrAuthAccessKeyNotFound)
+	})
+}
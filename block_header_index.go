@@ -0,0 +1,269 @@
+package bux
+
+import (
+	"context"
+	"database/sql"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/libsv/go-bc"
+	"github.com/libsv/go-bt/v2"
+	"github.com/mrz1836/go-datastore"
+	customTypes "github.com/mrz1836/go-datastore/custom_types"
+)
+
+// CompactBlockHeader is the compact form of a block header (80-byte raw header keyed by hash & height)
+type CompactBlockHeader struct {
+	Hash     string    `json:"hash"`     // Block hash
+	Height   uint32    `json:"height"`   // Block height
+	Orphaned time.Time `json:"orphaned"` // Zero if the block is on the longest chain
+	Raw      [80]byte  `json:"raw"`      // Raw block header
+	Synced   time.Time `json:"synced"`   // Zero if the block has not been synced
+}
+
+// BlockHeaderIndex is the in-memory index of the (compact) block headers
+//
+// The index is loaded on startup and kept current when block headers are saved (IE: by the monitor or the
+// header sync), all the block header lookups use the index in place of Datastore round-trips.
+// The block header models in the Datastore are the store of record: a lookup that misses the index
+// falls back to the Datastore (and adds the found block header to the index)
+type BlockHeaderIndex struct {
+	byHash   map[string]*CompactBlockHeader
+	byHeight map[uint32]*CompactBlockHeader // Longest chain only
+	loadedAt time.Time
+	mu       sync.RWMutex
+	tip      *CompactBlockHeader
+}
+
+// newBlockHeaderIndex will return an empty block header index
+func newBlockHeaderIndex() *BlockHeaderIndex {
+	return &BlockHeaderIndex{
+		byHash:   make(map[string]*CompactBlockHeader),
+		byHeight: make(map[uint32]*CompactBlockHeader),
+	}
+}
+
+// newCompactBlockHeader will create the compact block header from the block header model
+func newCompactBlockHeader(m *BlockHeader) *CompactBlockHeader {
+	h := &CompactBlockHeader{
+		Hash:   m.ID,
+		Height: m.Height,
+	}
+	binary.LittleEndian.PutUint32(h.Raw[0:4], m.Version)
+	copy(h.Raw[4:36], reversedHex(m.HashPreviousBlock, 32))
+	copy(h.Raw[36:68], reversedHex(m.HashMerkleRoot, 32))
+	binary.LittleEndian.PutUint32(h.Raw[68:72], m.Time)
+	copy(h.Raw[72:76], reversedHex(m.Bits, 4))
+	binary.LittleEndian.PutUint32(h.Raw[76:80], m.Nonce)
+	if m.Orphaned.Valid {
+		h.Orphaned = m.Orphaned.Time
+	}
+	if m.Synced.Valid {
+		h.Synced = m.Synced.Time
+	}
+	return h
+}
+
+// reversedHex will decode the hex value (left padded to the size) in reversed (little endian) byte order
+func reversedHex(value string, size int) []byte {
+	if len(value) < size*2 {
+		value = strings.Repeat("0", size*2-len(value)) + value
+	}
+	b, err := hex.DecodeString(value)
+	if err != nil || len(b) != size {
+		return make([]byte, size)
+	}
+	return bt.ReverseBytes(b)
+}
+
+// Header will return the parsed block header
+func (h *CompactBlockHeader) Header() (*bc.BlockHeader, error) {
+	return bc.NewBlockHeaderFromBytes(h.Raw[:])
+}
+
+// blockHeader will return the (read-only) block header model
+//
+// The model is not loaded from the Datastore, use getBlockHeaderRecordByID() to update a block header
+func (h *CompactBlockHeader) blockHeader(opts ...ModelOps) *BlockHeader {
+	m := &BlockHeader{
+		ID:     h.Hash,
+		Height: h.Height,
+		Model:  *NewBaseModel(ModelBlockHeader, opts...),
+	}
+	if bh, err := h.Header(); err == nil {
+		m.setHeaderInfo(*bh)
+	}
+	if !h.Orphaned.IsZero() {
+		m.Orphaned = customTypes.NullTime{NullTime: sql.NullTime{Time: h.Orphaned, Valid: true}}
+	}
+	if !h.Synced.IsZero() {
+		m.Synced = customTypes.NullTime{NullTime: sql.NullTime{Time: h.Synced, Valid: true}}
+	}
+	return m
+}
+
+// GetByHash will return the block header by hash (including orphaned block headers)
+func (i *BlockHeaderIndex) GetByHash(hash string) *CompactBlockHeader {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	return i.byHash[hash]
+}
+
+// GetByHeight will return the block header (on the longest chain) at the given height
+func (i *BlockHeaderIndex) GetByHeight(height uint32) *CompactBlockHeader {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	return i.byHeight[height]
+}
+
+// GetPrevious will return the highest block header (on the longest chain) below the given height
+func (i *BlockHeaderIndex) GetPrevious(height uint32) *CompactBlockHeader {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	if i.tip == nil || height == 0 {
+		return nil
+	}
+	if height > i.tip.Height {
+		return i.tip
+	}
+	for num := height; num > 0; num-- {
+		if h, ok := i.byHeight[num-1]; ok {
+			return h
+		}
+	}
+	return nil
+}
+
+// Len will return the number of block headers in the index (including orphaned block headers)
+func (i *BlockHeaderIndex) Len() int {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	return len(i.byHash)
+}
+
+// Tip will return the tip of the longest chain
+func (i *BlockHeaderIndex) Tip() *CompactBlockHeader {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	return i.tip
+}
+
+// put will add (or update) the block header in the index
+func (i *BlockHeaderIndex) put(m *BlockHeader) {
+	i.putCompact(newCompactBlockHeader(m))
+}
+
+// putCompact will add (or update) the compact block header in the index
+func (i *BlockHeaderIndex) putCompact(h *CompactBlockHeader) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	i.byHash[h.Hash] = h
+	if h.Orphaned.IsZero() {
+		i.byHeight[h.Height] = h
+		if i.tip == nil || h.Height >= i.tip.Height {
+			i.tip = h
+		}
+		return
+	}
+
+	// Remove the orphaned block header from the longest chain
+	if current, ok := i.byHeight[h.Height]; ok && current.Hash == h.Hash {
+		delete(i.byHeight, h.Height)
+		if i.tip != nil && i.tip.Hash == h.Hash {
+			i.tip = nil
+			for num := h.Height; num > 0; num-- {
+				if previous, found := i.byHeight[num-1]; found {
+					i.tip = previous
+					break
+				}
+			}
+		}
+	}
+}
+
+// putMissed will add a block header to the index that was loaded from the Datastore after an index miss
+// (IE: saved by another server since the last refresh)
+func (i *BlockHeaderIndex) putMissed(m *BlockHeader) {
+	if i != nil {
+		i.put(m)
+	}
+}
+
+// load will load all the (compact) block headers from the Datastore (in pages)
+func (i *BlockHeaderIndex) load(ctx context.Context, ds datastore.ClientInterface) error {
+	return i.loadSince(ctx, ds, time.Time{})
+}
+
+// refresh will load the block headers that were saved since the last load (IE: by another server)
+func (i *BlockHeaderIndex) refresh(ctx context.Context, ds datastore.ClientInterface) error {
+	i.mu.RLock()
+	since := i.loadedAt
+	i.mu.RUnlock()
+	return i.loadSince(ctx, ds, since)
+}
+
+// loadSince will load the compact block headers created or updated since the given time (all if zero)
+//
+// The compact records are loaded in pages by height (keyset: height > last loaded height, no offsets)
+func (i *BlockHeaderIndex) loadSince(ctx context.Context, ds datastore.ClientInterface, since time.Time) error {
+	startedAt := time.Now().UTC()
+
+	var updated []map[string]interface{}
+	if !since.IsZero() {
+
+		// Overlap the previous load (records saved while loading)
+		since = since.Add(-defaultHeaderIndexOverlap)
+		updated = []map[string]interface{}{{
+			createdAtField: map[string]interface{}{"$gte": since},
+		}, {
+			updatedAtField: map[string]interface{}{"$gte": since},
+		}}
+	}
+
+	lastHeight := int64(-1)
+	for {
+		conditions := map[string]interface{}{}
+		if len(updated) > 0 {
+			conditions["$or"] = updated
+		}
+		if lastHeight >= 0 {
+			conditions[heightField] = map[string]interface{}{"$gt": lastHeight}
+		}
+
+		var records []*compactBlockHeaderResult
+		if err := ds.GetModels(
+			ctx, &[]*CompactBlockHeaderRecord{}, conditions, &datastore.QueryParams{
+				Page:          1,
+				PageSize:      defaultHeaderIndexPageSize,
+				OrderByField:  heightField,
+				SortDirection: datastore.SortAsc,
+			}, &records, databaseLongReadTimeout,
+		); err != nil {
+			if errors.Is(err, datastore.ErrNoResults) {
+				break
+			}
+			return err
+		}
+
+		keep, next, done := heightPage(len(records), defaultHeaderIndexPageSize, func(index int) uint32 {
+			return records[index].Height
+		})
+		for _, record := range records[:keep] {
+			i.putCompact(record.compact())
+		}
+		if done {
+			break
+		}
+		lastHeight = next
+	}
+
+	i.mu.Lock()
+	i.loadedAt = startedAt
+	i.mu.Unlock()
+	return nil
+}
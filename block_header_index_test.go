@@ -0,0 +1,248 @@
+package bux
+
+import (
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/BuxOrg/bux/chainstate"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testBlockHeaderRaw1 is the raw (80-byte) block header of block 1
+const testBlockHeaderRaw1 = "010000006fe28c0ab6f1b372c1a6a246ae63f74f931e8365e15a089c68d6190000000000982051fd1e4ba744bbbe680e1fee14677ba1a3c3540bf7b1cdb606e857233e0e61bc6649ffff001d01e36299"
+
+// testIndexBlockHeader will return a (fake) block header model for the index
+func testIndexBlockHeader(hash string, height uint32, previousHash string, orphaned bool) *BlockHeader {
+	m := &BlockHeader{
+		ID:                hash,
+		Height:            height,
+		HashPreviousBlock: previousHash,
+	}
+	if orphaned {
+		m.Orphaned.Valid = true
+		m.Orphaned.Time = time.Now().UTC()
+	}
+	return m
+}
+
+// Test_newCompactBlockHeader will test the method newCompactBlockHeader()
+func Test_newCompactBlockHeader(t *testing.T) {
+	t.Parallel()
+
+	t.Run("raw block header", func(t *testing.T) {
+		bh, err := newBlockHeaderFromInfo(testBlockHeaderInfos[1], chainstate.MainNet.PowLimit())
+		require.NoError(t, err)
+
+		m := newBlockHeader(testBlockHash1, 1, *bh)
+		h := newCompactBlockHeader(m)
+		assert.Equal(t, testBlockHash1, h.Hash)
+		assert.Equal(t, uint32(1), h.Height)
+		assert.Equal(t, testBlockHeaderRaw1, hex.EncodeToString(h.Raw[:]))
+		assert.True(t, h.Orphaned.IsZero())
+		assert.True(t, h.Synced.IsZero())
+
+		var header *BlockHeader
+		header = h.blockHeader()
+		assert.Equal(t, m.ID, header.ID)
+		assert.Equal(t, m.Height, header.Height)
+		assert.Equal(t, m.Bits, header.Bits)
+		assert.Equal(t, m.HashMerkleRoot, header.HashMerkleRoot)
+		assert.Equal(t, m.HashPreviousBlock, header.HashPreviousBlock)
+		assert.Equal(t, m.Nonce, header.Nonce)
+		assert.Equal(t, m.Time, header.Time)
+		assert.Equal(t, m.Version, header.Version)
+		assert.False(t, header.Orphaned.Valid)
+	})
+
+	t.Run("imported bits", func(t *testing.T) {
+		m := testIndexBlockHeader(testBlockHash1, 1, testBlockHash0, true)
+		m.Bits = "1d00ffff"
+		m.Synced.Valid = true
+		m.Synced.Time = time.Now().UTC()
+
+		h := newCompactBlockHeader(m)
+		assert.Equal(t, "ffff001d", hex.EncodeToString(h.Raw[72:76]))
+		assert.False(t, h.Orphaned.IsZero())
+
+		header := h.blockHeader()
+		assert.Equal(t, "1d00ffff", header.Bits)
+		assert.True(t, header.Orphaned.Valid)
+		assert.True(t, header.Synced.Valid)
+	})
+}
+
+// TestBlockHeaderIndex will test the methods of the BlockHeaderIndex
+func TestBlockHeaderIndex(t *testing.T) {
+	t.Parallel()
+
+	t.Run("empty index", func(t *testing.T) {
+		index := newBlockHeaderIndex()
+		assert.Equal(t, 0, index.Len())
+		assert.Nil(t, index.Tip())
+		assert.Nil(t, index.GetByHash(testBlockHash0))
+		assert.Nil(t, index.GetByHeight(0))
+		assert.Nil(t, index.GetPrevious(1))
+	})
+
+	t.Run("longest chain", func(t *testing.T) {
+		index := newBlockHeaderIndex()
+		index.put(testIndexBlockHeader(testBlockHash0, 0, testBlockHash0, false))
+		index.put(testIndexBlockHeader(testBlockHash1, 1, testBlockHash0, false))
+		index.put(testIndexBlockHeader(testBlockHash2, 2, testBlockHash1, false))
+		index.put(testIndexBlockHeader(testBlockHash2B, 2, testBlockHash1, true))
+
+		assert.Equal(t, 4, index.Len())
+		assert.Equal(t, testBlockHash2, index.Tip().Hash)
+		assert.Equal(t, testBlockHash2, index.GetByHeight(2).Hash)
+		assert.False(t, index.GetByHash(testBlockHash2B).Orphaned.IsZero())
+		assert.Equal(t, testBlockHash1, index.GetPrevious(2).Hash)
+		assert.Equal(t, testBlockHash2, index.GetPrevious(10).Hash)
+		assert.Nil(t, index.GetPrevious(0))
+	})
+
+	t.Run("orphaned tip", func(t *testing.T) {
+		index := newBlockHeaderIndex()
+		index.put(testIndexBlockHeader(testBlockHash0, 0, testBlockHash0, false))
+		index.put(testIndexBlockHeader(testBlockHash1, 1, testBlockHash0, false))
+		index.put(testIndexBlockHeader(testBlockHash2, 2, testBlockHash1, false))
+
+		index.put(testIndexBlockHeader(testBlockHash2, 2, testBlockHash1, true))
+		assert.Equal(t, testBlockHash1, index.Tip().Hash)
+		assert.Nil(t, index.GetByHeight(2))
+		assert.NotNil(t, index.GetByHash(testBlockHash2))
+
+		index.put(testIndexBlockHeader(testBlockHash2B, 2, testBlockHash1, false))
+		assert.Equal(t, testBlockHash2B, index.Tip().Hash)
+		assert.Equal(t, testBlockHash2B, index.GetByHeight(2).Hash)
+	})
+}
+
+// Test_heightPage will test the method heightPage()
+func Test_heightPage(t *testing.T) {
+	t.Parallel()
+
+	pageHeights := func(heights ...uint32) func(index int) uint32 {
+		return func(index int) uint32 {
+			return heights[index]
+		}
+	}
+
+	t.Run("empty page", func(t *testing.T) {
+		_, _, done := heightPage(0, 3, pageHeights())
+		assert.True(t, done)
+	})
+
+	t.Run("last page", func(t *testing.T) {
+		keep, last, done := heightPage(2, 3, pageHeights(5, 6))
+		assert.Equal(t, 2, keep)
+		assert.Equal(t, int64(6), last)
+		assert.True(t, done)
+	})
+
+	t.Run("full page", func(t *testing.T) {
+		keep, last, done := heightPage(3, 3, pageHeights(5, 6, 7))
+		assert.Equal(t, 2, keep)
+		assert.Equal(t, int64(6), last)
+		assert.False(t, done)
+	})
+
+	t.Run("full page ending with a fork", func(t *testing.T) {
+		keep, last, done := heightPage(3, 3, pageHeights(5, 6, 6))
+		assert.Equal(t, 1, keep)
+		assert.Equal(t, int64(5), last)
+		assert.False(t, done)
+	})
+
+	t.Run("full page at the same height", func(t *testing.T) {
+		keep, last, done := heightPage(3, 3, pageHeights(6, 6, 6))
+		assert.Equal(t, 3, keep)
+		assert.Equal(t, int64(6), last)
+		assert.False(t, done)
+	})
+}
+
+// TestBlockHeaderIndex_load will test the methods load() and refresh()
+func TestBlockHeaderIndex_load(t *testing.T) {
+	ctx, client, deferMe := initBlockHeaderReorgTestCase(t)
+	defer deferMe()
+
+	require.NotNil(t, client.BlockHeaderIndex())
+
+	recordTestBlockHeader(ctx, t, client, testBlockHash0, 0, testBlockHash0)
+	recordTestBlockHeader(ctx, t, client, testBlockHash1, 1, testBlockHash0)
+	recordTestBlockHeader(ctx, t, client, testBlockHash1B, 1, testBlockHash0)
+	assert.Equal(t, 3, client.BlockHeaderIndex().Len())
+
+	t.Run("load", func(t *testing.T) {
+		index := newBlockHeaderIndex()
+		require.NoError(t, index.load(ctx, client.Datastore()))
+		assert.Equal(t, 3, index.Len())
+		assert.Equal(t, testBlockHash1, index.Tip().Hash)
+		assert.False(t, index.GetByHash(testBlockHash1B).Orphaned.IsZero())
+	})
+
+	t.Run("refresh", func(t *testing.T) {
+		index := newBlockHeaderIndex()
+		require.NoError(t, index.load(ctx, client.Datastore()))
+
+		// saved by another client (not in the index)
+		blockHeader := testIndexBlockHeader(testBlockHash2, 2, testBlockHash1, false)
+		blockHeader.Model = *NewBaseModel(ModelBlockHeader, append(client.DefaultModelOptions(), New())...)
+		require.NoError(t, blockHeader.Save(ctx))
+		assert.Nil(t, index.GetByHash(testBlockHash2))
+
+		require.NoError(t, index.refresh(ctx, client.Datastore()))
+		assert.Equal(t, 4, index.Len())
+		assert.Equal(t, testBlockHash2, index.Tip().Hash)
+	})
+
+	t.Run("lookups fall back to the Datastore", func(t *testing.T) {
+		index := newBlockHeaderIndex()
+		client.(*Client).options.headerIndex = index
+		opts := client.DefaultModelOptions()
+
+		blockHeader, err := getLastBlockHeader(ctx, opts...)
+		require.NoError(t, err)
+		require.NotNil(t, blockHeader)
+		assert.Equal(t, testBlockHash2, blockHeader.ID)
+
+		blockHeader, err = getBlockHeaderByID(ctx, testBlockHash1B, opts...)
+		require.NoError(t, err)
+		require.NotNil(t, blockHeader)
+		assert.True(t, blockHeader.Orphaned.Valid)
+
+		blockHeader, err = getBlockHeaderByHeight(ctx, 0, opts...)
+		require.NoError(t, err)
+		require.NotNil(t, blockHeader)
+		assert.Equal(t, testBlockHash0, blockHeader.ID)
+
+		// the found block headers are added to the index
+		assert.Equal(t, 3, index.Len())
+		assert.Equal(t, testBlockHash2, index.Tip().Hash)
+		assert.NotNil(t, index.GetByHeight(0))
+	})
+
+	t.Run("missing compact records are migrated", func(t *testing.T) {
+
+		// imported (not saved with the compact record)
+		bh, err := newBlockHeaderFromInfo(testBlockHeaderInfos[3], chainstate.MainNet.PowLimit())
+		require.NoError(t, err)
+		blockHeader := newBlockHeader(testBlockHash3, 3, *bh, client.DefaultModelOptions()...)
+		require.NoError(t, client.Datastore().CreateInBatches(ctx, []*BlockHeader{blockHeader}, 1))
+
+		index := newBlockHeaderIndex()
+		require.NoError(t, index.load(ctx, client.Datastore()))
+		assert.Nil(t, index.GetByHash(testBlockHash3))
+
+		require.NoError(t, migrateCompactBlockHeaders(ctx, client.Datastore(), client.DefaultModelOptions()...))
+		require.NoError(t, index.load(ctx, client.Datastore()))
+		require.NotNil(t, index.GetByHash(testBlockHash3))
+		assert.Equal(t, newCompactBlockHeader(blockHeader).Raw, index.GetByHash(testBlockHash3).Raw)
+		assert.Equal(t, 5, index.Len())
+
+		// nothing left to migrate
+		require.NoError(t, migrateCompactBlockHeaders(ctx, client.Datastore(), client.DefaultModelOptions()...))
+	})
+}
@@ -0,0 +1,185 @@
+package chainstate
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+
+	"github.com/mrz1836/go-nownodes"
+	"github.com/mrz1836/go-whatsonchain"
+)
+
+// AddressHistoryRecord is a transaction found in the history of an address
+type AddressHistoryRecord struct {
+	BlockHeight int64  `json:"block_height"` // Zero if not known (or unconfirmed)
+	TxID        string `json:"tx_id"`
+}
+
+// RawTransaction is the raw transaction data (hex) from an address history provider
+type RawTransaction struct {
+	BlockHeight int64  `json:"block_height"` // Zero if not known (or unconfirmed)
+	Hex         string `json:"hex"`
+	TxID        string `json:"tx_id"`
+}
+
+// whatsOnChainHistoryProvider is the address history provider using WhatsOnChain
+type whatsOnChainHistoryProvider struct {
+	client whatsonchain.ClientInterface
+}
+
+// NewWhatsOnChainHistoryProvider will return an address history provider using WhatsOnChain
+func NewWhatsOnChainHistoryProvider(client whatsonchain.ClientInterface) AddressHistoryProvider {
+	return &whatsOnChainHistoryProvider{client: client}
+}
+
+// AddressHistory will return the transaction history of an address
+func (p *whatsOnChainHistoryProvider) AddressHistory(ctx context.Context, address string) ([]*AddressHistoryRecord, error) {
+	history, err := p.client.AddressHistory(ctx, address)
+	if err != nil {
+		return nil, err
+	}
+	records := make([]*AddressHistoryRecord, 0, len(history))
+	for _, record := range history {
+		records = append(records, &AddressHistoryRecord{
+			BlockHeight: record.Height,
+			TxID:        record.TxHash,
+		})
+	}
+	return records, nil
+}
+
+// Name will return the name of the provider
+func (p *whatsOnChainHistoryProvider) Name() string {
+	return ProviderWhatsOnChain
+}
+
+// RawTransactions will return the raw transactions for the given tx ids
+func (p *whatsOnChainHistoryProvider) RawTransactions(ctx context.Context, txIDs []string) ([]*RawTransaction, error) {
+	txInfos, err := p.client.BulkRawTransactionDataProcessor(
+		ctx, &whatsonchain.TxHashes{TxIDs: txIDs},
+	)
+	if err != nil {
+		return nil, err
+	}
+	transactions := make([]*RawTransaction, 0, len(txInfos))
+	for _, info := range txInfos {
+		transactions = append(transactions, &RawTransaction{
+			BlockHeight: info.BlockHeight,
+			Hex:         info.Hex,
+			TxID:        info.TxID,
+		})
+	}
+	return transactions, nil
+}
+
+// nowNodesHistoryProvider is the address history provider using NowNodes
+type nowNodesHistoryProvider struct {
+	client nownodes.ClientInterface
+}
+
+// NewNowNodesHistoryProvider will return an address history provider using NowNodes
+func NewNowNodesHistoryProvider(client nownodes.ClientInterface) AddressHistoryProvider {
+	return &nowNodesHistoryProvider{client: client}
+}
+
+// AddressHistory will return the transaction history of an address
+//
+// NowNodes does not return the block height in the address details
+func (p *nowNodesHistoryProvider) AddressHistory(ctx context.Context, address string) ([]*AddressHistoryRecord, error) {
+	info, err := p.client.GetAddress(ctx, nownodes.BSV, address)
+	if err != nil {
+		return nil, err
+	}
+	records := make([]*AddressHistoryRecord, 0, len(info.TxIDs))
+	for _, txID := range info.TxIDs {
+		records = append(records, &AddressHistoryRecord{TxID: txID})
+	}
+	return records, nil
+}
+
+// Name will return the name of the provider
+func (p *nowNodesHistoryProvider) Name() string {
+	return ProviderNowNodes
+}
+
+// RawTransactions will return the raw transactions for the given tx ids
+func (p *nowNodesHistoryProvider) RawTransactions(ctx context.Context, txIDs []string) ([]*RawTransaction, error) {
+	transactions := make([]*RawTransaction, 0, len(txIDs))
+	for _, txID := range txIDs {
+		info, err := p.client.GetTransaction(ctx, nownodes.BSV, txID)
+		if err != nil {
+			return nil, err
+		}
+		transactions = append(transactions, &RawTransaction{
+			BlockHeight: info.BlockHeight,
+			Hex:         info.Hex,
+			TxID:        info.TxID,
+		})
+	}
+	return transactions, nil
+}
+
+// fixtureHistoryProvider is the address history provider using a local (JSON) fixture
+type fixtureHistoryProvider struct {
+	Addresses    map[string][]string        `json:"addresses"`
+	Transactions map[string]*RawTransaction `json:"transactions"`
+}
+
+// NewFixtureHistoryProvider will return an address history provider using a JSON fixture (no network)
+//
+// Format: {"addresses": {"<address>": ["<tx_id>"]}, "transactions": {"<tx_id>": {"block_height": 1, "hex": "<hex>"}}}
+func NewFixtureHistoryProvider(data []byte) (AddressHistoryProvider, error) {
+	p := new(fixtureHistoryProvider)
+	if err := json.Unmarshal(data, p); err != nil {
+		return nil, ErrInvalidAddressHistoryFixture
+	}
+	for txID, transaction := range p.Transactions {
+		if transaction == nil || len(transaction.Hex) == 0 {
+			return nil, ErrInvalidAddressHistoryFixture
+		}
+		transaction.TxID = txID
+	}
+	return p, nil
+}
+
+// LoadFixtureHistoryProvider will return an address history provider using a JSON fixture file
+func LoadFixtureHistoryProvider(path string) (AddressHistoryProvider, error) {
+	data, err := os.ReadFile(path) //nolint:gosec // the path is provided by the configuration
+	if err != nil {
+		return nil, err
+	}
+	return NewFixtureHistoryProvider(data)
+}
+
+// AddressHistory will return the transaction history of an address
+func (p *fixtureHistoryProvider) AddressHistory(_ context.Context, address string) ([]*AddressHistoryRecord, error) {
+	records := make([]*AddressHistoryRecord, 0, len(p.Addresses[address]))
+	for _, txID := range p.Addresses[address] {
+		record := &AddressHistoryRecord{TxID: txID}
+		if transaction, ok := p.Transactions[txID]; ok {
+			record.BlockHeight = transaction.BlockHeight
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// Name will return the name of the provider
+func (p *fixtureHistoryProvider) Name() string {
+	return ProviderFixture
+}
+
+// RawTransactions will return the raw transactions for the given tx ids (unknown transactions are skipped)
+func (p *fixtureHistoryProvider) RawTransactions(_ context.Context, txIDs []string) ([]*RawTransaction, error) {
+	transactions := make([]*RawTransaction, 0, len(txIDs))
+	for _, txID := range txIDs {
+		if transaction, ok := p.Transactions[txID]; ok {
+			transactions = append(transactions, &RawTransaction{
+				BlockHeight: transaction.BlockHeight,
+				Hex:         transaction.Hex,
+				TxID:        txID,
+			})
+		}
+	}
+	return transactions, nil
+}
@@ -0,0 +1,179 @@
+package chainstate
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mrz1836/go-nownodes"
+	"github.com/mrz1836/go-whatsonchain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testHistoryAddress = "1WLucQHxqVN94QcdVkuARq78dm7JLYk2S"
+
+// whatsOnChainHistory is a mock for the address history using WhatsOnChain
+type whatsOnChainHistory struct {
+	whatsOnChainBase
+}
+
+func (w *whatsOnChainHistory) AddressHistory(_ context.Context, address string) (whatsonchain.AddressHistory, error) {
+	if address != testHistoryAddress {
+		return nil, nil
+	}
+	return whatsonchain.AddressHistory{{
+		Height: onChainExample1BlockHeight,
+		TxHash: onChainExample1TxID,
+	}}, nil
+}
+
+func (w *whatsOnChainHistory) BulkRawTransactionDataProcessor(_ context.Context,
+	hashes *whatsonchain.TxHashes,
+) (whatsonchain.TxList, error) {
+	var txList whatsonchain.TxList
+	for _, txID := range hashes.TxIDs {
+		if txID == onChainExample1TxID {
+			txList = append(txList, &whatsonchain.TxInfo{
+				BlockHeight: onChainExample1BlockHeight,
+				Hex:         onChainExample1TxHex,
+				TxID:        onChainExample1TxID,
+			})
+		}
+	}
+	return txList, nil
+}
+
+// nowNodesHistory is a mock for the address history using NowNodes
+type nowNodesHistory struct {
+	nowNodesTxOnChain
+}
+
+func (n *nowNodesHistory) GetAddress(_ context.Context, _ nownodes.Blockchain,
+	address string,
+) (*nownodes.AddressInfo, error) {
+	info := &nownodes.AddressInfo{Address: address}
+	if address == testHistoryAddress {
+		info.TxIDs = []string{onChainExample1TxID}
+	}
+	return info, nil
+}
+
+// testHistoryFixture is a fixture with a single address and transaction
+const testHistoryFixture = `{
+	"addresses": {"` + testHistoryAddress + `": ["` + onChainExample1TxID + `"]},
+	"transactions": {"` + onChainExample1TxID + `": {"block_height": 723229, "hex": "` + onChainExample1TxHex + `"}}
+}`
+
+// TestWhatsOnChainHistoryProvider will test the WhatsOnChain address history provider
+func TestWhatsOnChainHistoryProvider(t *testing.T) {
+	t.Parallel()
+
+	provider := NewWhatsOnChainHistoryProvider(&whatsOnChainHistory{})
+	assert.Equal(t, ProviderWhatsOnChain, provider.Name())
+
+	t.Run("address history", func(t *testing.T) {
+		records, err := provider.AddressHistory(context.Background(), testHistoryAddress)
+		require.NoError(t, err)
+		require.Len(t, records, 1)
+		assert.Equal(t, onChainExample1TxID, records[0].TxID)
+		assert.Equal(t, onChainExample1BlockHeight, records[0].BlockHeight)
+	})
+
+	t.Run("raw transactions", func(t *testing.T) {
+		transactions, err := provider.RawTransactions(context.Background(), []string{onChainExample1TxID})
+		require.NoError(t, err)
+		require.Len(t, transactions, 1)
+		assert.Equal(t, onChainExample1TxID, transactions[0].TxID)
+		assert.Equal(t, onChainExample1TxHex, transactions[0].Hex)
+		assert.Equal(t, onChainExample1BlockHeight, transactions[0].BlockHeight)
+	})
+}
+
+// TestNowNodesHistoryProvider will test the NowNodes address history provider
+func TestNowNodesHistoryProvider(t *testing.T) {
+	t.Parallel()
+
+	provider := NewNowNodesHistoryProvider(&nowNodesHistory{})
+	assert.Equal(t, ProviderNowNodes, provider.Name())
+
+	t.Run("address history", func(t *testing.T) {
+		records, err := provider.AddressHistory(context.Background(), testHistoryAddress)
+		require.NoError(t, err)
+		require.Len(t, records, 1)
+		assert.Equal(t, onChainExample1TxID, records[0].TxID)
+		assert.Equal(t, int64(0), records[0].BlockHeight)
+	})
+
+	t.Run("raw transactions", func(t *testing.T) {
+		transactions, err := provider.RawTransactions(context.Background(), []string{onChainExample1TxID})
+		require.NoError(t, err)
+		require.Len(t, transactions, 1)
+		assert.Equal(t, onChainExample1TxHex, transactions[0].Hex)
+		assert.Equal(t, onChainExample1BlockHeight, transactions[0].BlockHeight)
+	})
+
+	t.Run("transaction not found", func(t *testing.T) {
+		transactions, err := NewNowNodesHistoryProvider(&nowNodesTxNotFound{}).RawTransactions(
+			context.Background(), []string{onChainExample1TxID},
+		)
+		require.Error(t, err)
+		assert.Nil(t, transactions)
+	})
+}
+
+// TestFixtureHistoryProvider will test the fixture address history provider
+func TestFixtureHistoryProvider(t *testing.T) {
+	t.Parallel()
+
+	t.Run("invalid json", func(t *testing.T) {
+		provider, err := NewFixtureHistoryProvider([]byte(`{invalid`))
+		require.ErrorIs(t, err, ErrInvalidAddressHistoryFixture)
+		assert.Nil(t, provider)
+	})
+
+	t.Run("missing transaction hex", func(t *testing.T) {
+		provider, err := NewFixtureHistoryProvider([]byte(`{"transactions": {"` + onChainExample1TxID + `": {}}}`))
+		require.ErrorIs(t, err, ErrInvalidAddressHistoryFixture)
+		assert.Nil(t, provider)
+	})
+
+	t.Run("valid fixture", func(t *testing.T) {
+		provider, err := NewFixtureHistoryProvider([]byte(testHistoryFixture))
+		require.NoError(t, err)
+		assert.Equal(t, ProviderFixture, provider.Name())
+
+		var records []*AddressHistoryRecord
+		records, err = provider.AddressHistory(context.Background(), testHistoryAddress)
+		require.NoError(t, err)
+		require.Len(t, records, 1)
+		assert.Equal(t, onChainExample1TxID, records[0].TxID)
+		assert.Equal(t, onChainExample1BlockHeight, records[0].BlockHeight)
+
+		records, err = provider.AddressHistory(context.Background(), "unknown")
+		require.NoError(t, err)
+		assert.Empty(t, records)
+
+		var transactions []*RawTransaction
+		transactions, err = provider.RawTransactions(
+			context.Background(), []string{onChainExample1TxID, "unknown"},
+		)
+		require.NoError(t, err)
+		require.Len(t, transactions, 1)
+		assert.Equal(t, onChainExample1TxID, transactions[0].TxID)
+		assert.Equal(t, onChainExample1TxHex, transactions[0].Hex)
+	})
+
+	t.Run("load from file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "history.json")
+		require.NoError(t, os.WriteFile(path, []byte(testHistoryFixture), 0o600))
+
+		provider, err := LoadFixtureHistoryProvider(path)
+		require.NoError(t, err)
+		require.NotNil(t, provider)
+
+		_, err = LoadFixtureHistoryProvider(filepath.Join(t.TempDir(), "missing.json"))
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,192 @@
+package chainstate
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// BlockHeaderInfo is a block header from a block headers source
+type BlockHeaderInfo struct {
+	Bits           string `json:"bits"` // Compact target in hex (IE: 1d00ffff)
+	Hash           string `json:"hash"`
+	HashMerkleRoot string `json:"hash_merkle_root"`
+	HashPrevBlock  string `json:"hash_previous_block"`
+	Height         uint32 `json:"height"`
+	Nonce          uint32 `json:"nonce"`
+	Time           uint32 `json:"time"`
+	Version        uint32 `json:"version"`
+}
+
+// pulseHeadersSource is the block headers source using a Pulse-like headers service (HTTP API)
+type pulseHeadersSource struct {
+	authToken  string
+	httpClient HTTPInterface
+	url        string
+}
+
+// pulseHeader is a block header returned by the headers service
+type pulseHeader struct {
+	CreationTimestamp uint32 `json:"creationTimestamp"`
+	DifficultyTarget  uint32 `json:"difficultyTarget"`
+	Hash              string `json:"hash"`
+	MerkleRoot        string `json:"merkleRoot"`
+	Nonce             uint32 `json:"nonce"`
+	PrevBlockHash     string `json:"prevBlockHash"`
+	Version           uint32 `json:"version"`
+}
+
+// pulseTip is the tip of the longest chain returned by the headers service
+type pulseTip struct {
+	Header pulseHeader `json:"header"`
+	Height uint32      `json:"height"`
+}
+
+// NewPulseHeadersSource will return a block headers source using a Pulse-like headers service
+//
+// url is the base url of the service, authToken is optional (sent as a bearer token)
+func NewPulseHeadersSource(url, authToken string, httpClient HTTPInterface) BlockHeadersSource {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &pulseHeadersSource{
+		authToken:  authToken,
+		httpClient: httpClient,
+		url:        strings.TrimSuffix(url, "/"),
+	}
+}
+
+// GetHeadersByHeight will return the block headers (on the longest chain) starting at the given height
+func (p *pulseHeadersSource) GetHeadersByHeight(ctx context.Context, height uint32,
+	count int) ([]*BlockHeaderInfo, error) {
+
+	var headers []*pulseHeader
+	if err := p.request(
+		ctx, fmt.Sprintf("/api/v1/chain/header/byHeight?height=%d&count=%d", height, count), &headers,
+	); err != nil {
+		return nil, err
+	}
+
+	infos := make([]*BlockHeaderInfo, 0, len(headers))
+	for index, header := range headers {
+		infos = append(infos, header.info(height+uint32(index)))
+	}
+	return infos, nil
+}
+
+// GetTipHeight will return the height of the tip of the longest chain
+func (p *pulseHeadersSource) GetTipHeight(ctx context.Context) (uint32, error) {
+	tip := new(pulseTip)
+	if err := p.request(ctx, "/api/v1/chain/tip/longest", tip); err != nil {
+		return 0, err
+	}
+	return tip.Height, nil
+}
+
+// Name will return the name of the source
+func (p *pulseHeadersSource) Name() string {
+	return ProviderPulse
+}
+
+// request will fire a GET request to the headers service and decode the JSON response
+func (p *pulseHeadersSource) request(ctx context.Context, path string, result interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.url+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if len(p.authToken) > 0 {
+		req.Header.Set("Authorization", "Bearer "+p.authToken)
+	}
+
+	var resp *http.Response
+	if resp, err = p.httpClient.Do(req); err != nil {
+		return err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%w: status code %d", ErrBlockHeadersSourceRequest, resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(result)
+}
+
+// info will return the block header info (at the given height)
+func (h *pulseHeader) info(height uint32) *BlockHeaderInfo {
+	return &BlockHeaderInfo{
+		Bits:           fmt.Sprintf("%08x", h.DifficultyTarget),
+		Hash:           h.Hash,
+		HashMerkleRoot: h.MerkleRoot,
+		HashPrevBlock:  h.PrevBlockHash,
+		Height:         height,
+		Nonce:          h.Nonce,
+		Time:           h.CreationTimestamp,
+		Version:        h.Version,
+	}
+}
+
+// staticHeadersSource is a local (in memory) block headers source, IE: a stand-in for testing or offline usage
+type staticHeadersSource struct {
+	headers map[uint32]*BlockHeaderInfo
+	tip     uint32
+}
+
+// NewStaticHeadersSource will return a local block headers source using the given block headers
+func NewStaticHeadersSource(headers []*BlockHeaderInfo) BlockHeadersSource {
+	s := &staticHeadersSource{
+		headers: make(map[uint32]*BlockHeaderInfo, len(headers)),
+	}
+	for _, header := range headers {
+		s.headers[header.Height] = header
+		if header.Height > s.tip {
+			s.tip = header.Height
+		}
+	}
+	return s
+}
+
+// LoadStaticHeadersSource will return a local block headers source using a JSON file (a list of block headers)
+func LoadStaticHeadersSource(path string) (BlockHeadersSource, error) {
+	data, err := os.ReadFile(path) //nolint:gosec // the path is provided by the configuration
+	if err != nil {
+		return nil, err
+	}
+	var headers []*BlockHeaderInfo
+	if err = json.Unmarshal(data, &headers); err != nil {
+		return nil, ErrInvalidBlockHeadersFile
+	}
+	return NewStaticHeadersSource(headers), nil
+}
+
+// GetHeadersByHeight will return the block headers starting at the given height (until the first missing height)
+func (s *staticHeadersSource) GetHeadersByHeight(_ context.Context, height uint32,
+	count int) ([]*BlockHeaderInfo, error) {
+
+	headers := make([]*BlockHeaderInfo, 0, count)
+	for num := height; len(headers) < count; num++ {
+		header, ok := s.headers[num]
+		if !ok {
+			break
+		}
+		headers = append(headers, header)
+	}
+	return headers, nil
+}
+
+// GetTipHeight will return the height of the highest block header
+func (s *staticHeadersSource) GetTipHeight(_ context.Context) (uint32, error) {
+	if len(s.headers) == 0 {
+		return 0, ErrBlockHeadersSourceEmpty
+	}
+	return s.tip, nil
+}
+
+// Name will return the name of the source
+func (s *staticHeadersSource) Name() string {
+	return ProviderStatic
+}
@@ -0,0 +1,137 @@
+package chainstate
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testBlockHeaders are the first block headers of the main network
+var testBlockHeaders = []*BlockHeaderInfo{{
+	Bits:           "1d00ffff",
+	Hash:           "000000000019d6689c085ae165831e934ff763ae46a2a6c172b3f1b60a8ce26f",
+	HashMerkleRoot: "4a5e1e4baab89f3a32518a88c31bc87f618f76673e2cc77ab2127b7afdeda33b",
+	HashPrevBlock:  "0000000000000000000000000000000000000000000000000000000000000000",
+	Height:         0,
+	Nonce:          2083236893,
+	Time:           1231006505,
+	Version:        1,
+}, {
+	Bits:           "1d00ffff",
+	Hash:           "00000000839a8e6886ab5951d76f411475428afc90947ee320161bbf18eb6048",
+	HashMerkleRoot: "0e3e2357e806b6cdb1f70b54c3a3a17b6714ee1f0e68bebb44a74b1efd512098",
+	HashPrevBlock:  "000000000019d6689c085ae165831e934ff763ae46a2a6c172b3f1b60a8ce26f",
+	Height:         1,
+	Nonce:          2573394689,
+	Time:           1231469665,
+	Version:        1,
+}}
+
+// TestPulseHeadersSource will test the Pulse-like block headers source
+func TestPulseHeadersSource(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer "+testDummyKey {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/api/v1/chain/tip/longest":
+			_, _ = w.Write([]byte(`{"header":{"hash":"` + testBlockHeaders[1].Hash + `"},"height":1}`))
+		case "/api/v1/chain/header/byHeight":
+			assert.Equal(t, "1", r.URL.Query().Get("height"))
+			assert.Equal(t, "10", r.URL.Query().Get("count"))
+			_, _ = w.Write([]byte(`[{
+				"hash":"` + testBlockHeaders[1].Hash + `",
+				"version":1,
+				"prevBlockHash":"` + testBlockHeaders[1].HashPrevBlock + `",
+				"merkleRoot":"` + testBlockHeaders[1].HashMerkleRoot + `",
+				"creationTimestamp":1231469665,
+				"difficultyTarget":486604799,
+				"nonce":2573394689
+			}]`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	source := NewPulseHeadersSource(server.URL+"/", testDummyKey, nil)
+	assert.Equal(t, ProviderPulse, source.Name())
+
+	t.Run("tip height", func(t *testing.T) {
+		height, err := source.GetTipHeight(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, uint32(1), height)
+	})
+
+	t.Run("headers by height", func(t *testing.T) {
+		headers, err := source.GetHeadersByHeight(context.Background(), 1, 10)
+		require.NoError(t, err)
+		require.Len(t, headers, 1)
+		assert.Equal(t, testBlockHeaders[1], headers[0])
+	})
+
+	t.Run("request error", func(t *testing.T) {
+		_, err := NewPulseHeadersSource(server.URL, "", nil).GetTipHeight(context.Background())
+		require.ErrorIs(t, err, ErrBlockHeadersSourceRequest)
+	})
+}
+
+// TestStaticHeadersSource will test the local block headers source
+func TestStaticHeadersSource(t *testing.T) {
+	t.Parallel()
+
+	t.Run("empty source", func(t *testing.T) {
+		source := NewStaticHeadersSource(nil)
+		_, err := source.GetTipHeight(context.Background())
+		require.ErrorIs(t, err, ErrBlockHeadersSourceEmpty)
+	})
+
+	t.Run("headers by height", func(t *testing.T) {
+		source := NewStaticHeadersSource(testBlockHeaders)
+		assert.Equal(t, ProviderStatic, source.Name())
+
+		height, err := source.GetTipHeight(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, uint32(1), height)
+
+		var headers []*BlockHeaderInfo
+		headers, err = source.GetHeadersByHeight(context.Background(), 0, 10)
+		require.NoError(t, err)
+		assert.Equal(t, testBlockHeaders, headers)
+
+		headers, err = source.GetHeadersByHeight(context.Background(), 1, 1)
+		require.NoError(t, err)
+		require.Len(t, headers, 1)
+		assert.Equal(t, uint32(1), headers[0].Height)
+
+		headers, err = source.GetHeadersByHeight(context.Background(), 5, 10)
+		require.NoError(t, err)
+		assert.Empty(t, headers)
+	})
+
+	t.Run("load from file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "headers.json")
+		require.NoError(t, os.WriteFile(path, []byte(`[{"hash":"`+testBlockHeaders[0].Hash+`","height":0}]`), 0o600))
+
+		source, err := LoadStaticHeadersSource(path)
+		require.NoError(t, err)
+		var headers []*BlockHeaderInfo
+		headers, err = source.GetHeadersByHeight(context.Background(), 0, 1)
+		require.NoError(t, err)
+		require.Len(t, headers, 1)
+		assert.Equal(t, testBlockHeaders[0].Hash, headers[0].Hash)
+
+		require.NoError(t, os.WriteFile(path, []byte(`{invalid`), 0o600))
+		_, err = LoadStaticHeadersSource(path)
+		require.ErrorIs(t, err, ErrInvalidBlockHeadersFile)
+	})
+}
@@ -26,20 +26,42 @@ var (
 		"missing inputs", // Returned from mAPI for a valid tx that is on-chain
 	}
 
+	// broadcastDoubleSpendErrors are a list of errors when the inputs were already spent (on-chain)
+	broadcastDoubleSpendErrors = []string{
+		"bad-txns-inputs-spent", // {"error": "-25: bad-txns-inputs-spent"}
+		"bad_txns_inputs_spent", // BAD_TXNS_INPUTS_SPENT
+		"double spend",          // Double spend detected
+	}
+
+	// broadcastConflictErrors are a list of errors when the inputs are spent by a transaction in the mempool
+	broadcastConflictErrors = []string{
+		"txn-mempool-conflict", // {"error": "-26: 258: txn-mempool-conflict"}
+		"txn_mempool_conflict", // TXN_MEMPOOL_CONFLICT
+		"mempool conflict",     // Mempool conflict
+	}
+
+	// broadcastTooLongChainErrors are a list of errors when the unconfirmed ancestor chain is too long
+	broadcastTooLongChainErrors = []string{
+		"too-long-mempool-chain",   // {"error": "-26: too-long-mempool-chain"}
+		"too_long_non_final_chain", // TOO_LONG_NON_FINAL_CHAIN
+	}
+
+	// broadcastFeeErrors are a list of errors when the fee was not accepted
+	broadcastFeeErrors = []string{
+		"mempool min fee not met", // {"error": "-26: 66: mempool min fee not met"}
+		"insufficient priority",   // {"error": "-26: 66: insufficient priority"}
+		"tx_fee_too_low",          // TX_FEE_TOO_LOW
+	}
+
 	/*
-		TXN_ALREADY_KNOWN (suppressed - returns as success: true)
-		TXN_ALREADY_IN_MEMPOOL (suppressed - returns as success: true)
-		TXN_MEMPOOL_CONFLICT
+		Not classified (returned as ErrBroadcastRejected):
 		NON_FINAL_POOL_FULL
-		TOO_LONG_NON_FINAL_CHAIN
 		BAD_TXNS_INPUTS_TOO_LARGE
-		BAD_TXNS_INPUTS_SPENT
 		NON_BIP68_FINAL
 		TOO_LONG_VALIDATION_TIME
 		BAD_TXNS_NONSTANDARD_INPUTS
 		ABSURDLY_HIGH_FEE
 		DUST
-		TX_FEE_TOO_LOW
 	*/
 )
 
@@ -47,22 +69,30 @@ var (
 //
 // NOTE: if successful (in-mempool), no error will be returned
 // NOTE: function register the fastest successful broadcast into 'completeChannel' so client doesn't need to wait for other providers
-func (c *Client) broadcast(ctx context.Context, id, hex string, timeout time.Duration, completeChannel, errorChannel chan string) {
+func (c *Client) broadcast(ctx context.Context, id, hex string, timeout time.Duration, attempts *broadcastAttempts,
+	completeChannel, errorChannel chan string) {
 	// Create a context (to cancel or timeout)
 	ctxWithCancel, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
+	// Skip the failing providers (circuit breakers) and order them by the routing policy
+	providers := routeBroadcastProviders(c, createActiveProviders(c, id, hex))
+	if c.options.config.broadcastRouting != RoutingAll {
+		c.broadcastInOrder(ctxWithCancel, ctx, providers, id, timeout, attempts, completeChannel, errorChannel)
+		return
+	}
+
 	var wg sync.WaitGroup
 
 	resultsChannel := make(chan broadcastResult)
 	status := newBroadcastStatus(completeChannel)
 
-	for _, broadcastProvider := range createActiveProviders(c, id, hex) {
+	for _, broadcastProvider := range providers {
 		wg.Add(1)
 		go func(provider txBroadcastProvider) {
 			defer wg.Done()
 			broadcastToProvider(ctxWithCancel, ctx, provider, id, c, timeout,
-				resultsChannel, status)
+				attempts, resultsChannel, status)
 		}(broadcastProvider)
 	}
 
@@ -87,9 +117,57 @@ func (c *Client) broadcast(ctx context.Context, id, hex string, timeout time.Dur
 	}
 }
 
+// broadcastInOrder will broadcast to one provider at a time until the first successful broadcast
+//
+// NOTE: a rejection of the transaction (IE: double spend) is final, the next providers are not used
+func (c *Client) broadcastInOrder(ctx, fallbackCtx context.Context, providers []txBroadcastProvider, id string,
+	timeout time.Duration, attempts *broadcastAttempts, completeChannel, errorChannel chan string) {
+
+	var errorMessages []string
+	for _, provider := range providers {
+		err := broadcastWithProvider(ctx, fallbackCtx, provider, id, c, timeout, attempts)
+		if err == nil {
+			debugLog(c, id, fmt.Sprintf("successful broadcast to %s", provider.getName()))
+			completeChannel <- provider.getName()
+			close(completeChannel)
+			return
+		}
+
+		debugLog(c, id, fmt.Sprintf("broadcast error: %s from provider %s", err, provider.getName()))
+		errorMessages = append(errorMessages, provider.getName()+": "+err.Error())
+		if isBroadcastRejection(err.Error()) || ctx.Err() != nil {
+			break
+		}
+	}
+
+	close(completeChannel)
+	if len(errorMessages) > 0 {
+		errorChannel <- strings.Join(errorMessages, ", ")
+	}
+}
+
+// routeBroadcastProviders will return the providers to use (by the routing policy and the circuit breakers)
+func routeBroadcastProviders(c *Client, providers []txBroadcastProvider) []txBroadcastProvider {
+	names := make([]string, 0, len(providers))
+	for _, provider := range providers {
+		names = append(names, provider.getName())
+	}
+
+	routed := make([]txBroadcastProvider, 0, len(providers))
+	for _, index := range c.options.health.route(c.options.config.broadcastRouting, names) {
+		routed = append(routed, providers[index])
+	}
+	return routed
+}
+
 func createActiveProviders(c *Client, txID, txHex string) []txBroadcastProvider {
 	providers := make([]txBroadcastProvider, 0, 10)
 
+	if shouldBroadcastToNode(c) {
+		pvdr := nodeBroadcastProvider{txID: txID, txHex: txHex}
+		providers = append(providers, &pvdr)
+	}
+
 	if shouldBroadcastWithMAPI(c) {
 		for _, miner := range c.options.config.minercraftConfig.broadcastMiners {
 			if miner == nil {
@@ -123,34 +201,51 @@ func shouldBroadcastToWhatsOnChain(c *Client) bool {
 	return !utils.StringInSlice(ProviderWhatsOnChain, c.options.config.excludedProviders)
 }
 
+func shouldBroadcastToNode(c *Client) bool {
+	return !utils.StringInSlice(ProviderNode, c.options.config.excludedProviders) &&
+		c.Node() != nil // Only if the node is configured
+}
+
 func shouldBroadcastToNowNodes(c *Client) bool {
 	return !utils.StringInSlice(ProviderNowNodes, c.options.config.excludedProviders) &&
 		c.NowNodes() != nil // Only if NowNodes is loaded (requires API key)
 }
 
 func broadcastToProvider(ctx, fallbackCtx context.Context, provider txBroadcastProvider, txID string,
-	c *Client, fallbackTimeout time.Duration,
+	c *Client, fallbackTimeout time.Duration, attempts *broadcastAttempts,
 	resultsChannel chan broadcastResult, status *broadcastStatus,
 ) {
-	bErr := provider.broadcast(ctx, c)
+	if bErr := broadcastWithProvider(ctx, fallbackCtx, provider, txID, c, fallbackTimeout, attempts); bErr != nil {
+		resultsChannel <- newErrorResult(bErr, provider.getName())
+		return
+	}
 
-	if bErr != nil {
-		// check in Mempool as fallback - if transaction is there -> GREAT SUCCESS
-		// Check error response for "questionable errors"/(TX FAILURE)
-		if doesErrorContain(bErr.Error(), broadcastQuestionableErrors) {
-			bErr = checkInMempool(fallbackCtx, c, txID, bErr.Error(), fallbackTimeout)
-		}
+	// successful broadcast or found in mempool
+	status.tryCompleteWithSuccess(provider.getName())
+	resultsChannel <- newSuccessResult(provider.getName())
+}
+
+// broadcastWithProvider will broadcast to the provider, record the health of the provider and the attempt
+func broadcastWithProvider(ctx, fallbackCtx context.Context, provider txBroadcastProvider, txID string,
+	c *Client, fallbackTimeout time.Duration, attempts *broadcastAttempts,
+) error {
+	attempt := &BroadcastAttempt{Provider: provider.getName(), RequestedAt: time.Now().UTC()}
+	bErr := provider.broadcast(ctx, c, attempt)
+	attempt.Latency = time.Since(attempt.RequestedAt)
+	c.options.health.record(provider.getName(), attempt.Latency, bErr)
+	attempt.complete(bErr)
 
-		if bErr != nil {
-			resultsChannel <- newErrorResult(bErr, provider.getName())
+	// check in Mempool as fallback - if transaction is there -> GREAT SUCCESS
+	// Check error response for "questionable errors"/(TX FAILURE)
+	if bErr != nil && doesErrorContain(bErr.Error(), broadcastQuestionableErrors) {
+		if bErr = checkInMempool(fallbackCtx, c, txID, bErr.Error(), fallbackTimeout); bErr == nil {
+			attempt.Outcome = BroadcastOutcomeInMempool
 		}
 	}
 
-	// successful broadcast or found in mempool
-	if bErr == nil {
-		status.tryCompleteWithSuccess(provider.getName())
-		resultsChannel <- newSuccessResult(provider.getName())
-	}
+	// The attempt is added before the result is used (see Client.BroadcastWithAttempts)
+	attempts.add(attempt)
+	return bErr
 }
 
 // checkInMempool is a quick check to see if the tx is in mempool (or on-chain)
@@ -0,0 +1,120 @@
+package chainstate
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// BroadcastOutcome is the classified outcome of a broadcast attempt
+type BroadcastOutcome string
+
+const (
+	// BroadcastOutcomeSuccess is a transaction accepted by the provider (or already known)
+	BroadcastOutcomeSuccess BroadcastOutcome = "success"
+
+	// BroadcastOutcomeInMempool is a questionable error, but the transaction was found in the mempool (or on-chain)
+	BroadcastOutcomeInMempool BroadcastOutcome = "in_mempool"
+
+	// BroadcastOutcomeDoubleSpend is a transaction with inputs already spent (on-chain)
+	BroadcastOutcomeDoubleSpend BroadcastOutcome = "double_spend"
+
+	// BroadcastOutcomeMempoolConflict is a transaction with inputs spent by a transaction in the mempool
+	BroadcastOutcomeMempoolConflict BroadcastOutcome = "mempool_conflict"
+
+	// BroadcastOutcomeTooLongChain is a transaction with a too long chain of unconfirmed ancestors
+	BroadcastOutcomeTooLongChain BroadcastOutcome = "too_long_chain"
+
+	// BroadcastOutcomeFeeTooLow is a transaction with a fee that was not accepted
+	BroadcastOutcomeFeeTooLow BroadcastOutcome = "fee_too_low"
+
+	// BroadcastOutcomeRejected is a transaction rejected by the provider (any other reason)
+	BroadcastOutcomeRejected BroadcastOutcome = "rejected"
+
+	// BroadcastOutcomeError is a failure of the provider (IE: timeout, server error, rate limit)
+	BroadcastOutcomeError BroadcastOutcome = "error"
+)
+
+// broadcastProviderErrors are a list of errors when the provider failed (not a response about the transaction)
+var broadcastProviderErrors = []string{
+	"connection refused",
+	"context deadline exceeded",
+	"eof",
+	"status code",
+	"timeout",
+}
+
+// BroadcastAttempt is the response of a single provider to a broadcast
+type BroadcastAttempt struct {
+	Latency         time.Duration    `json:"latency"`                    // Duration of the request to the provider
+	MinerID         string           `json:"miner_id,omitempty"`         // Miner ID (if returned by the provider)
+	Outcome         BroadcastOutcome `json:"outcome"`                    // Classified outcome
+	Provider        string           `json:"provider"`                   // Name of the provider (or the miner)
+	RequestedAt     time.Time        `json:"requested_at"`               // Time of the request
+	ResponseCode    string           `json:"response_code,omitempty"`    // Raw response code (IE: mAPI return result, node RPC code)
+	ResponseMessage string           `json:"response_message,omitempty"` // Raw response message (IE: the error)
+}
+
+// BroadcastAttemptsHandler is given the attempts of the providers that responded after the first
+// successful broadcast (called from the broadcast goroutine)
+type BroadcastAttemptsHandler func(attempts []*BroadcastAttempt)
+
+// broadcastAttempts are the attempts of a broadcast (safe for the parallel providers)
+type broadcastAttempts struct {
+	attempts []*BroadcastAttempt
+	mu       sync.Mutex
+}
+
+// add will add the attempt
+func (b *broadcastAttempts) add(attempt *BroadcastAttempt) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.attempts = append(b.attempts, attempt)
+}
+
+// list will return the attempts so far (in order of completion, the attempts are only appended)
+func (b *broadcastAttempts) list() []*BroadcastAttempt {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]*BroadcastAttempt{}, b.attempts...)
+}
+
+// complete will set the outcome (and the response if not set by the provider) of the attempt
+func (a *BroadcastAttempt) complete(err error) {
+	if err == nil {
+		a.Outcome = BroadcastOutcomeSuccess
+		return
+	}
+
+	if len(a.ResponseMessage) == 0 {
+		a.ResponseMessage = err.Error()
+	}
+	var nodeErr *NodeError
+	if len(a.ResponseCode) == 0 && errors.As(err, &nodeErr) {
+		a.ResponseCode = strconv.Itoa(nodeErr.Code)
+	}
+	a.Outcome = classifyBroadcastOutcome(err, a.ResponseMessage)
+}
+
+// classifyBroadcastOutcome will return the outcome for the broadcast error (and the raw response message)
+func classifyBroadcastOutcome(err error, response string) BroadcastOutcome {
+	message := response + ", " + err.Error()
+	switch classifyBroadcastError(message) {
+	case ErrBroadcastDoubleSpend:
+		return BroadcastOutcomeDoubleSpend
+	case ErrBroadcastMempoolConflict:
+		return BroadcastOutcomeMempoolConflict
+	case ErrBroadcastTooLongChain:
+		return BroadcastOutcomeTooLongChain
+	case ErrBroadcastFeeTooLow:
+		return BroadcastOutcomeFeeTooLow
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) ||
+		doesErrorContain(message, broadcastProviderErrors) || doesErrorContain(message, providerRateLimitErrors) {
+		return BroadcastOutcomeError
+	}
+	return BroadcastOutcomeRejected
+}
@@ -13,7 +13,7 @@ import (
 // generic broadcast provider
 type txBroadcastProvider interface {
 	getName() string
-	broadcast(ctx context.Context, c *Client) error
+	broadcast(ctx context.Context, c *Client, attempt *BroadcastAttempt) error
 }
 
 // mAPI provider
@@ -26,12 +26,13 @@ func (provider mapiBroadcastProvider) getName() string {
 	return provider.miner.Miner.Name
 }
 
-func (provider mapiBroadcastProvider) broadcast(ctx context.Context, c *Client) error {
-	return broadcastMAPI(ctx, c, provider.miner.Miner, provider.txID, provider.txHex)
+func (provider mapiBroadcastProvider) broadcast(ctx context.Context, c *Client, attempt *BroadcastAttempt) error {
+	return broadcastMAPI(ctx, c, provider.miner.Miner, provider.txID, provider.txHex, attempt)
 }
 
-// broadcastMAPI will broadcast a transaction to a miner using mAPI
-func broadcastMAPI(ctx context.Context, client ClientInterface, miner *minercraft.Miner, id, hex string) error {
+// broadcastMAPI will broadcast a transaction to a miner using mAPI (the response is set on the attempt)
+func broadcastMAPI(ctx context.Context, client ClientInterface, miner *minercraft.Miner, id, hex string,
+	attempt *BroadcastAttempt) error {
 	debugLog(client, id, "executing broadcast request in mapi using miner: "+miner.Name)
 
 	resp, err := client.Minercraft().SubmitTransaction(ctx, miner, &minercraft.Transaction{
@@ -49,7 +50,13 @@ func broadcastMAPI(ctx context.Context, client ClientInterface, miner *minercraf
 	}
 
 	// Something went wrong - got back an id that does not match
-	if resp == nil || !strings.EqualFold(resp.Results.TxID, id) {
+	if resp == nil || resp.Results == nil {
+		return incorrectTxIDReturnedErr("", id)
+	}
+	attempt.MinerID = resp.Results.MinerID
+	attempt.ResponseCode = resp.Results.ReturnResult
+	attempt.ResponseMessage = resp.Results.ResultDescription
+	if !strings.EqualFold(resp.Results.TxID, id) {
 		return incorrectTxIDReturnedErr(resp.Results.TxID, id)
 	}
 
@@ -78,7 +85,7 @@ func (provider whatsOnChainBroadcastProvider) getName() string {
 	return ProviderWhatsOnChain
 }
 
-func (provider whatsOnChainBroadcastProvider) broadcast(ctx context.Context, c *Client) error {
+func (provider whatsOnChainBroadcastProvider) broadcast(ctx context.Context, c *Client, _ *BroadcastAttempt) error {
 	return broadcastWhatsOnChain(ctx, c, provider.txID, provider.txHex)
 }
 
@@ -117,7 +124,7 @@ func (provider nowNodesBroadcastProvider) getName() string {
 }
 
 // Broadcast using NowNodes
-func (provider nowNodesBroadcastProvider) broadcast(ctx context.Context, c *Client) error {
+func (provider nowNodesBroadcastProvider) broadcast(ctx context.Context, c *Client, _ *BroadcastAttempt) error {
 	return broadcastNowNodes(ctx, c, provider.uniqueID, provider.txID, provider.txHex)
 }
 
@@ -146,6 +153,45 @@ func broadcastNowNodes(ctx context.Context, client ClientInterface, uniqueID, tx
 
 ////
 
+// Node provider
+type nodeBroadcastProvider struct {
+	txID, txHex string
+}
+
+func (provider nodeBroadcastProvider) getName() string {
+	return ProviderNode
+}
+
+// Broadcast using the node
+func (provider nodeBroadcastProvider) broadcast(ctx context.Context, c *Client, _ *BroadcastAttempt) error {
+	return broadcastNode(ctx, c, provider.txID, provider.txHex)
+}
+
+// broadcastNode will broadcast a transaction to the node (sendrawtransaction)
+func broadcastNode(ctx context.Context, client ClientInterface, id, hex string) error {
+	debugLog(client, id, "executing broadcast request for "+ProviderNode)
+
+	txID, err := client.Node().SendRawTransaction(ctx, hex)
+	if err != nil {
+
+		// Check error message (for success error message)
+		if doesErrorContain(err.Error(), broadcastSuccessErrors) {
+			return nil
+		}
+		return err
+	}
+
+	// Something went wrong - got back an id that does not match
+	if !strings.EqualFold(txID, id) {
+		return incorrectTxIDReturnedErr(txID, id)
+	}
+
+	// Success
+	return nil
+}
+
+////
+
 func incorrectTxIDReturnedErr(actualTxID, expectedTxID string) error {
 	return fmt.Errorf("returned tx id [%s] does not match given tx id [%s]", actualTxID, expectedTxID)
 }
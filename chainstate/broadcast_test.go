@@ -2,8 +2,11 @@ package chainstate
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -27,6 +30,38 @@ func Test_doesErrorContain(t *testing.T) {
 	})
 }
 
+func Test_classifyBroadcastError(t *testing.T) {
+	t.Run("double spend", func(t *testing.T) {
+		err := classifyBroadcastError("mapi: -25: bad-txns-inputs-spent")
+		assert.ErrorIs(t, err, ErrBroadcastDoubleSpend)
+		assert.True(t, IsConflictError(err))
+	})
+
+	t.Run("mempool conflict", func(t *testing.T) {
+		err := classifyBroadcastError("whatsonchain: -26: 258: txn-mempool-conflict, mapi: TX_FEE_TOO_LOW")
+		assert.ErrorIs(t, err, ErrBroadcastMempoolConflict)
+		assert.True(t, IsConflictError(err))
+	})
+
+	t.Run("too long chain", func(t *testing.T) {
+		err := classifyBroadcastError("mapi: -26: too-long-mempool-chain")
+		assert.ErrorIs(t, err, ErrBroadcastTooLongChain)
+		assert.False(t, IsConflictError(err))
+	})
+
+	t.Run("fee too low", func(t *testing.T) {
+		err := classifyBroadcastError("nownodes: -26: 66: mempool min fee not met")
+		assert.ErrorIs(t, err, ErrBroadcastFeeTooLow)
+		assert.False(t, IsConflictError(err))
+	})
+
+	t.Run("unknown", func(t *testing.T) {
+		err := classifyBroadcastError("mapi: DUST")
+		assert.ErrorIs(t, err, ErrBroadcastRejected)
+		assert.False(t, IsConflictError(err))
+	})
+}
+
 // TestClient_Broadcast_Success will test the method Broadcast()
 func TestClient_Broadcast_Success(t *testing.T) {
 	t.Parallel()
@@ -275,6 +310,20 @@ func TestClient_Broadcast(t *testing.T) {
 		require.Error(t, err)
 		assert.Equal(t, ProviderAll, provider)
 	})
+
+	t.Run("broadcast - conflict", func(t *testing.T) {
+		c := NewTestClient(
+			context.Background(), t,
+			WithNowNodes(&nowNodesTxNotFound{}),         // Not found
+			WithWhatsOnChain(&whatsOnChainTxNotFound{}), // Mempool conflict
+			WithMinercraft(&minerCraftTxNotFound{}),     // Not Found
+		)
+		_, err := c.Broadcast(
+			context.Background(), broadcastExample1TxID, broadcastExample1TxHex, defaultBroadcastTimeOut,
+		)
+		require.Error(t, err)
+		assert.True(t, IsConflictError(err))
+	})
 }
 
 func containsAtLeastOneElement(coll1 []string, coll2 ...string) bool {
@@ -293,3 +342,127 @@ func containsAtLeastOneElement(coll1 []string, coll2 ...string) bool {
 
 	return false
 }
+
+// TestClient_BroadcastWithAttempts will test the method BroadcastWithAttempts()
+func TestClient_BroadcastWithAttempts(t *testing.T) {
+	t.Parallel()
+
+	t.Run("error - missing tx id", func(t *testing.T) {
+		c := NewTestClient(context.Background(), t)
+		provider, attempts, err := c.BroadcastWithAttempts(
+			context.Background(), "", onChainExample1TxHex, defaultBroadcastTimeOut, nil,
+		)
+		require.ErrorIs(t, err, ErrInvalidTransactionID)
+		assert.Empty(t, provider)
+		assert.Empty(t, attempts)
+	})
+
+	t.Run("broadcast - all providers reject", func(t *testing.T) {
+		c := NewTestClient(
+			context.Background(), t,
+			WithNowNodes(&nowNodesTxNotFound{}),         // Mempool conflict
+			WithWhatsOnChain(&whatsOnChainTxNotFound{}), // Mempool conflict
+			WithMinercraft(&minerCraftTxNotFound{}),     // Mempool conflict
+		)
+		provider, attempts, err := c.BroadcastWithAttempts(
+			context.Background(), broadcastExample1TxID, broadcastExample1TxHex, defaultBroadcastTimeOut, nil,
+		)
+		require.ErrorIs(t, err, ErrBroadcastMempoolConflict)
+		assert.Equal(t, ProviderAll, provider)
+		require.Len(t, attempts, len(c.BroadcastMiners())+2)
+
+		for _, attempt := range attempts {
+			assert.NotEmpty(t, attempt.Provider)
+			assert.False(t, attempt.RequestedAt.IsZero())
+			assert.Equal(t, BroadcastOutcomeMempoolConflict, attempt.Outcome)
+			assert.Contains(t, strings.ToLower(attempt.ResponseMessage), "mempool conflict")
+			if attempt.Provider != ProviderWhatsOnChain && attempt.Provider != ProviderNowNodes {
+				assert.NotEmpty(t, attempt.MinerID)
+				assert.Equal(t, mAPIFailure, attempt.ResponseCode)
+			}
+		}
+	})
+
+	t.Run("broadcast - success", func(t *testing.T) {
+		c := NewTestClient(
+			context.Background(), t,
+			WithNowNodes(&nowNodesTxNotFound{}),
+			WithWhatsOnChain(&whatsOnChainBroadcastSuccess{}),
+			WithMinercraft(&minerCraftTxNotFound{}),
+			WithBroadcastRouting(RoutingPrimaryFallback),
+			WithExcludedProviders([]string{ProviderMAPI}),
+		)
+		provider, attempts, err := c.BroadcastWithAttempts(
+			context.Background(), broadcastExample1TxID, broadcastExample1TxHex, defaultBroadcastTimeOut, nil,
+		)
+		require.NoError(t, err)
+		assert.Equal(t, ProviderWhatsOnChain, provider)
+		require.Len(t, attempts, 1)
+		assert.Equal(t, ProviderWhatsOnChain, attempts[0].Provider)
+		assert.Equal(t, BroadcastOutcomeSuccess, attempts[0].Outcome)
+		assert.Empty(t, attempts[0].ResponseMessage)
+	})
+
+	t.Run("broadcast - success, late attempts of the slow providers", func(t *testing.T) {
+		c := NewTestClient(
+			context.Background(), t,
+			WithNowNodes(&nowNodesTxNotFoundSlow{}), // Responds after the successful broadcast
+			WithWhatsOnChain(&whatsOnChainBroadcastSuccess{}),
+			WithMinercraft(&minerCraftTxNotFound{}),
+			WithBroadcastRouting(RoutingAll),
+		)
+		lateCh := make(chan []*BroadcastAttempt, 1)
+		provider, attempts, err := c.BroadcastWithAttempts(
+			context.Background(), broadcastExample1TxID, broadcastExample1TxHex, defaultBroadcastTimeOut,
+			func(late []*BroadcastAttempt) {
+				lateCh <- late
+			},
+		)
+		require.NoError(t, err)
+		assert.NotEmpty(t, provider)
+
+		// Returned on the first success (without waiting for the slow provider)
+		var succeeded bool
+		for _, attempt := range attempts {
+			assert.NotEqual(t, ProviderNowNodes, attempt.Provider)
+			if attempt.Provider == ProviderWhatsOnChain {
+				succeeded = attempt.Outcome == BroadcastOutcomeSuccess
+			}
+		}
+		assert.True(t, succeeded)
+
+		select {
+		case late := <-lateCh:
+			require.NotEmpty(t, late)
+			assert.Equal(t, ProviderNowNodes, late[len(late)-1].Provider)
+			assert.Equal(t, BroadcastOutcomeMempoolConflict, late[len(late)-1].Outcome)
+			assert.Len(t, append(attempts, late...), len(c.BroadcastMiners())+2)
+		case <-time.After(defaultBroadcastTimeOut):
+			t.Fatal("late attempts were not given to the handler")
+		}
+	})
+}
+
+func Test_classifyBroadcastOutcome(t *testing.T) {
+	t.Run("rejections", func(t *testing.T) {
+		assert.Equal(t, BroadcastOutcomeDoubleSpend, classifyBroadcastOutcome(errors.New("-25: bad-txns-inputs-spent"), ""))
+		assert.Equal(t, BroadcastOutcomeMempoolConflict, classifyBroadcastOutcome(errors.New("failed"), "ERROR: Mempool conflict"))
+		assert.Equal(t, BroadcastOutcomeTooLongChain, classifyBroadcastOutcome(errors.New("too-long-mempool-chain"), ""))
+		assert.Equal(t, BroadcastOutcomeFeeTooLow, classifyBroadcastOutcome(errors.New("TX_FEE_TOO_LOW"), ""))
+		assert.Equal(t, BroadcastOutcomeRejected, classifyBroadcastOutcome(errors.New("DUST"), ""))
+	})
+
+	t.Run("provider errors", func(t *testing.T) {
+		assert.Equal(t, BroadcastOutcomeError, classifyBroadcastOutcome(context.DeadlineExceeded, ""))
+		assert.Equal(t, BroadcastOutcomeError, classifyBroadcastOutcome(errors.New("unexpected response code 429: too many requests"), ""))
+		assert.Equal(t, BroadcastOutcomeError, classifyBroadcastOutcome(fmt.Errorf("%w: status code 503", ErrNodeRequest), ""))
+	})
+
+	t.Run("node error code", func(t *testing.T) {
+		attempt := &BroadcastAttempt{Provider: ProviderNode}
+		attempt.complete(&NodeError{Code: -26, Message: "258: txn-mempool-conflict"})
+		assert.Equal(t, "-26", attempt.ResponseCode)
+		assert.Equal(t, "node request failed: -26: 258: txn-mempool-conflict", attempt.ResponseMessage)
+		assert.Equal(t, BroadcastOutcomeMempoolConflict, attempt.Outcome)
+	})
+}
@@ -1,6 +1,7 @@
 package chainstate
 
 import (
+	"errors"
 	"fmt"
 	"strings"
 	"sync"
@@ -71,6 +72,27 @@ func doesErrorContain(err string, messages []string) bool {
 	return false
 }
 
+// classifyBroadcastError will return the typed error for the broadcast error message(s)
+//
+// Conflicts are checked first, any provider reporting a double-spend wins over other errors
+func classifyBroadcastError(message string) error {
+	if doesErrorContain(message, broadcastDoubleSpendErrors) {
+		return ErrBroadcastDoubleSpend
+	} else if doesErrorContain(message, broadcastConflictErrors) {
+		return ErrBroadcastMempoolConflict
+	} else if doesErrorContain(message, broadcastTooLongChainErrors) {
+		return ErrBroadcastTooLongChain
+	} else if doesErrorContain(message, broadcastFeeErrors) {
+		return ErrBroadcastFeeTooLow
+	}
+	return ErrBroadcastRejected
+}
+
+// IsConflictError will return true if the broadcast failed because the inputs are spent by another transaction
+func IsConflictError(err error) bool {
+	return errors.Is(err, ErrBroadcastDoubleSpend) || errors.Is(err, ErrBroadcastMempoolConflict)
+}
+
 func debugLog(c ClientInterface, txID, msg string) {
 	c.DebugLog(fmt.Sprintf("[txID: %s]: %s", txID, msg))
 }
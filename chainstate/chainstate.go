@@ -7,6 +7,8 @@ import (
 	"context"
 	"fmt"
 	"time"
+
+	"github.com/BuxOrg/bux/utils"
 )
 
 // MonitorBlockHeaders will start up a block headers monitor
@@ -16,11 +18,22 @@ func (c *Client) MonitorBlockHeaders(_ context.Context) error {
 
 // Broadcast will attempt to broadcast a transaction using the given providers
 func (c *Client) Broadcast(ctx context.Context, id, txHex string, timeout time.Duration) (string, error) {
+	provider, _, err := c.BroadcastWithAttempts(ctx, id, txHex, timeout, nil)
+	return provider, err
+}
+
+// BroadcastWithAttempts will attempt to broadcast a transaction using the given providers
+// and return the response of each provider (attempt)
+//
+// NOTE: returns on the first success, the attempts of the providers still running (RoutingAll) are
+// given to lateAttempts (if set) once they are done (up to the timeout)
+func (c *Client) BroadcastWithAttempts(ctx context.Context, id, txHex string,
+	timeout time.Duration, lateAttempts BroadcastAttemptsHandler) (string, []*BroadcastAttempt, error) {
 	// Basic validation
 	if len(id) < 50 {
-		return "", ErrInvalidTransactionID
+		return "", nil, ErrInvalidTransactionID
 	} else if len(txHex) <= 0 { // todo: validate the tx hex
-		return "", ErrInvalidTransactionHex
+		return "", nil, ErrInvalidTransactionHex
 	}
 
 	// Debug the id and hex
@@ -30,18 +43,44 @@ func (c *Client) Broadcast(ctx context.Context, id, txHex string, timeout time.D
 	// Broadcast or die
 	successCompleteCh := make(chan string)
 	errorCh := make(chan string)
-
-	go c.broadcast(ctx, id, txHex, timeout, successCompleteCh, errorCh)
+	attempts := &broadcastAttempts{}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		c.broadcast(ctx, id, txHex, timeout, attempts, successCompleteCh, errorCh)
+	}()
 
 	// wait for first success
 	success := <-successCompleteCh
 	if success != "" {
-		return success, nil
+		list := attempts.list()
+		if lateAttempts != nil {
+			go waitForLateAttempts(done, timeout, attempts, len(list), lateAttempts)
+		}
+		return success, list, nil
 	}
 
 	// successCompleteCh closed without any values
 	errorMessage := <-errorCh
-	return ProviderAll, fmt.Errorf("broadcast failed, errors: %s", errorMessage)
+	return ProviderAll, attempts.list(), fmt.Errorf(
+		"broadcast failed: %w, errors: %s", classifyBroadcastError(errorMessage), errorMessage,
+	)
+}
+
+// waitForLateAttempts will wait for the broadcast to be done (up to the timeout) and give the attempts
+// after the first returned attempts (known) to the handler (if any)
+func waitForLateAttempts(done <-chan struct{}, timeout time.Duration, attempts *broadcastAttempts,
+	known int, lateAttempts BroadcastAttemptsHandler) {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case <-done:
+	case <-timer.C:
+	}
+	if list := attempts.list(); len(list) > known {
+		lateAttempts(list[known:])
+	}
 }
 
 // QueryTransaction will get the transaction info from all providers returning the "first" valid result
@@ -85,3 +124,29 @@ func (c *Client) QueryTransactionFastest(
 	}
 	return info, nil
 }
+
+// QuerySpendingTransaction will find the transaction that spends the given output (id:index)
+//
+// Note: this uses the history of the locking script of the output (WhatsOnChain), ErrOutputUnspent is
+// only returned if the output is listed as unspent (including the mempool), ErrSpendingTransactionNotFound otherwise
+func (c *Client) QuerySpendingTransaction(
+	ctx context.Context, id string, index uint32, lockingScript string, timeout time.Duration,
+) (string, error) {
+	// Basic validation
+	if len(id) < 50 {
+		return "", ErrInvalidTransactionID
+	} else if len(lockingScript) == 0 {
+		return "", ErrInvalidLockingScript
+	}
+
+	// Only supported by WhatsOnChain
+	if utils.StringInSlice(ProviderWhatsOnChain, c.options.config.excludedProviders) || c.WhatsOnChain() == nil {
+		return "", ErrSpendingTransactionNotFound
+	}
+
+	// Create a context (to cancel or timeout)
+	ctxWithCancel, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	return querySpendingWhatsOnChain(ctxWithCancel, c, id, index, lockingScript)
+}
@@ -27,6 +27,7 @@ type (
 	clientOptions struct {
 		config          *syncConfig                 // Configuration for broadcasting and other chain-state actions
 		debug           bool                        // For extra logs and additional debug information
+		health          *providerHealth             // Health of the providers (latency, errors, circuit breakers)
 		logger          zLogger.GormLoggerInterface // Internal logger interface
 		monitor         MonitorService              // Monitor service
 		newRelicEnabled bool                        // If NewRelic is enabled (parent application)
@@ -35,13 +36,27 @@ type (
 
 	// syncConfig holds all the configuration about the different sync processes
 	syncConfig struct {
+		addressHistory     AddressHistoryProvider       // Address history provider (importing xPubs)
+		addressHistoryFrom string                       // Name of the provider to use for the address history
+		blockHeadersSource BlockHeadersSource           // Source of block headers (syncing block headers)
+		blockHeadersToken  string                       // Auth token for the block headers service
+		blockHeadersURL    string                       // URL of the block headers service (Pulse-like API)
+		broadcastRouting   RoutingPolicy                // Routing of the broadcasts to the providers
+		circuitCooldown    time.Duration                // Time a failing provider is skipped (circuit breaker)
+		circuitThreshold   int                          // Consecutive failures before a provider is skipped (circuit breaker)
 		excludedProviders  []string                     // List of provider names
 		httpClient         HTTPInterface                // Custom HTTP client (Minercraft, WOC)
 		minercraftConfig   *minercraftConfig            // minercraftConfig configuration
 		minercraft         minercraft.ClientInterface   // Minercraft client
 		network            Network                      // Current network (mainnet, testnet, stn)
+		node               NodeInterface                // Node (JSON-RPC) client
+		nodePassword       string                       // RPC password of the node
+		nodeURL            string                       // RPC url of the node
+		nodeUser           string                       // RPC user of the node
+		nodeZMQAddress     string                       // ZMQ address of the node (replaces the bux-agent monitor)
 		nowNodes           nownodes.ClientInterface     // NOWNodes client
 		nowNodesAPIKey     string                       // If set, use this key
+		queryRouting       RoutingPolicy                // Routing of the transaction queries to the providers
 		queryTimeout       time.Duration                // Timeout for transaction query
 		whatsOnChain       whatsonchain.ClientInterface // WhatsOnChain client
 		whatsOnChainAPIKey string                       // If set, use this key
@@ -87,6 +102,11 @@ func NewClient(ctx context.Context, opts ...ClientOps) (ClientInterface, error)
 		client.options.logger = zLogger.NewGormLogger(client.IsDebug(), 4)
 	}
 
+	// Track the health of the providers
+	client.options.health = newProviderHealth(
+		client.options.config.circuitThreshold, client.options.config.circuitCooldown, defaultProviderHealthWindow,
+	)
+
 	// Start Minercraft
 	if err := client.startMinerCraft(ctx); err != nil {
 		return nil, err
@@ -98,6 +118,20 @@ func NewClient(ctx context.Context, opts ...ClientOps) (ClientInterface, error)
 	// Start NowNodes
 	client.startNowNodes(ctx)
 
+	// Start the node client (if configured)
+	client.startNode(ctx)
+
+	// The monitor uses the node notifications (if configured)
+	if monitor, ok := client.options.monitor.(*Monitor); ok {
+		monitor.SetChainstateOptions(client.options)
+	}
+
+	// Start the address history provider (after the provider clients)
+	client.startAddressHistory(ctx)
+
+	// Start the block headers source (if configured)
+	client.startBlockHeadersSource(ctx)
+
 	// Return the client
 	return client, nil
 }
@@ -124,6 +158,21 @@ func (c *Client) Close(ctx context.Context) {
 			c.options.config.nowNodes = nil
 		}
 
+		// Close the node client
+		if c.options.config.node != nil {
+			c.options.config.node = nil
+		}
+
+		// Close the address history provider
+		if c.options.config.addressHistory != nil {
+			c.options.config.addressHistory = nil
+		}
+
+		// Close the block headers source
+		if c.options.config.blockHeadersSource != nil {
+			c.options.config.blockHeadersSource = nil
+		}
+
 		// Stop the active Monitor (if not already stopped)
 		if c.options.monitor != nil {
 			_ = c.options.monitor.Stop(ctx)
@@ -164,6 +213,16 @@ func (c *Client) Network() Network {
 	return c.options.config.network
 }
 
+// PowLimit will return the compact target (bits) of the minimum difficulty of the network
+func (c *Client) PowLimit() string {
+	return c.Network().PowLimit()
+}
+
+// DifficultyRules will return the difficulty adjustment rules of the block headers of the network
+func (c *Client) DifficultyRules() *DifficultyRules {
+	return c.Network().DifficultyRules()
+}
+
 // Minercraft will return the Minercraft client
 func (c *Client) Minercraft() minercraft.ClientInterface {
 	return c.options.config.minercraft
@@ -174,6 +233,16 @@ func (c *Client) Monitor() MonitorService {
 	return c.options.monitor
 }
 
+// AddressHistory will return the address history provider
+func (c *Client) AddressHistory() AddressHistoryProvider {
+	return c.options.config.addressHistory
+}
+
+// BlockHeadersSource will return the block headers source (nil if not configured)
+func (c *Client) BlockHeadersSource() BlockHeadersSource {
+	return c.options.config.blockHeadersSource
+}
+
 // WhatsOnChain will return the WhatsOnChain client
 func (c *Client) WhatsOnChain() whatsonchain.ClientInterface {
 	return c.options.config.whatsOnChain
@@ -184,6 +253,16 @@ func (c *Client) NowNodes() nownodes.ClientInterface {
 	return c.options.config.nowNodes
 }
 
+// Node will return the node (JSON-RPC) client
+func (c *Client) Node() NodeInterface {
+	return c.options.config.node
+}
+
+// ProviderStats will return the health of the providers (latency, errors, circuit breakers)
+func (c *Client) ProviderStats() []*ProviderStats {
+	return c.options.health.stats()
+}
+
 // QueryTimeout will return the query timeout
 func (c *Client) QueryTimeout() time.Duration {
 	return c.options.config.queryTimeout
@@ -100,6 +100,49 @@ func (c *Client) startWhatsOnChain(ctx context.Context) {
 	}
 }
 
+// startAddressHistory will start the address history provider (if no custom provider is found)
+//
+// Uses NowNodes if selected (and loaded), WhatsOnChain is the default
+func (c *Client) startAddressHistory(ctx context.Context) {
+	if txn := newrelic.FromContext(ctx); txn != nil {
+		defer txn.StartSegment("start_address_history").End()
+	}
+
+	if c.AddressHistory() == nil {
+		if c.options.config.addressHistoryFrom == ProviderNowNodes && c.NowNodes() != nil {
+			c.options.config.addressHistory = NewNowNodesHistoryProvider(c.NowNodes())
+		} else {
+			c.options.config.addressHistory = NewWhatsOnChainHistoryProvider(c.WhatsOnChain())
+		}
+	}
+}
+
+// startBlockHeadersSource will start the block headers source using the headers service (if no custom source is found)
+func (c *Client) startBlockHeadersSource(ctx context.Context) {
+	if txn := newrelic.FromContext(ctx); txn != nil {
+		defer txn.StartSegment("start_block_headers_source").End()
+	}
+
+	if c.BlockHeadersSource() == nil && len(c.options.config.blockHeadersURL) > 0 {
+		c.options.config.blockHeadersSource = NewPulseHeadersSource(
+			c.options.config.blockHeadersURL, c.options.config.blockHeadersToken, c.HTTPClient(),
+		)
+	}
+}
+
+// startNode will start the node client if the RPC url is set (if no custom client is found)
+func (c *Client) startNode(ctx context.Context) {
+	if txn := newrelic.FromContext(ctx); txn != nil {
+		defer txn.StartSegment("start_node").End()
+	}
+
+	if c.Node() == nil && len(c.options.config.nodeURL) > 0 {
+		c.options.config.node = NewNodeClient(
+			c.options.config.nodeURL, c.options.config.nodeUser, c.options.config.nodePassword, c.HTTPClient(),
+		)
+	}
+}
+
 // startNowNodes will start NowNodes if API key is set (if no custom client is found)
 func (c *Client) startNowNodes(ctx context.Context) {
 	if txn := newrelic.FromContext(ctx); txn != nil {
@@ -27,7 +27,10 @@ func defaultClientOptions() *clientOptions {
 	// Set the default options
 	return &clientOptions{
 		config: &syncConfig{
-			httpClient: nil,
+			broadcastRouting: RoutingAll,
+			circuitCooldown:  defaultCircuitBreakerCooldown,
+			circuitThreshold: defaultCircuitBreakerThreshold,
+			httpClient:       nil,
 			minercraftConfig: &minercraftConfig{
 				broadcastMiners:     bm,
 				queryMiners:         qm,
@@ -37,6 +40,7 @@ func defaultClientOptions() *clientOptions {
 			},
 			minercraft:   nil,
 			network:      MainNet,
+			queryRouting: RoutingPrimaryFallback,
 			queryTimeout: defaultQueryTimeOut,
 			whatsOnChain: nil,
 		},
@@ -138,6 +142,74 @@ func WithWhatsOnChain(client whatsonchain.ClientInterface) ClientOps {
 	}
 }
 
+// WithAddressHistoryProvider will set a custom address history provider (IE: a fixture for testing)
+func WithAddressHistoryProvider(provider AddressHistoryProvider) ClientOps {
+	return func(c *clientOptions) {
+		if provider != nil {
+			c.config.addressHistory = provider
+		}
+	}
+}
+
+// WithAddressHistoryFrom will set the provider to use for the address history (whatsonchain or nownodes)
+func WithAddressHistoryFrom(providerName string) ClientOps {
+	return func(c *clientOptions) {
+		if len(providerName) > 0 {
+			c.config.addressHistoryFrom = providerName
+		}
+	}
+}
+
+// WithBlockHeadersSource will set a custom block headers source (IE: a local stand-in)
+func WithBlockHeadersSource(source BlockHeadersSource) ClientOps {
+	return func(c *clientOptions) {
+		if source != nil {
+			c.config.blockHeadersSource = source
+		}
+	}
+}
+
+// WithBlockHeadersService will set the URL (and optional auth token) of a Pulse-like block headers service
+func WithBlockHeadersService(url, authToken string) ClientOps {
+	return func(c *clientOptions) {
+		if len(url) > 0 {
+			c.config.blockHeadersURL = url
+			c.config.blockHeadersToken = authToken
+		}
+	}
+}
+
+// WithNode will set a custom node (JSON-RPC) client
+func WithNode(client NodeInterface) ClientOps {
+	return func(c *clientOptions) {
+		if client != nil {
+			c.config.node = client
+		}
+	}
+}
+
+// WithNodeRPC will set the RPC url (IE: http://localhost:8332) and credentials of a node
+func WithNodeRPC(url, user, password string) ClientOps {
+	return func(c *clientOptions) {
+		if len(url) > 0 {
+			c.config.nodeURL = url
+			c.config.nodeUser = user
+			c.config.nodePassword = password
+		}
+	}
+}
+
+// WithNodeZMQ will set the ZMQ address (IE: tcp://localhost:28332) of a node for the monitor
+//
+// The node must publish hashblock and rawtx, the monitor uses the node instead of the bux-agent
+func WithNodeZMQ(address string) ClientOps {
+	return func(c *clientOptions) {
+		if len(address) > 0 {
+			c.config.nodeZMQAddress = address
+		}
+	}
+}
+
 // WithNowNodes will set a custom NowNodes client
 func WithNowNodes(client nownodes.ClientInterface) ClientOps {
 	return func(c *clientOptions) {
@@ -260,3 +332,31 @@ func WithMinercraftAPIs(apis []*minercraft.MinerAPIs) ClientOps {
 		c.config.minercraftConfig.minerAPIs = apis
 	}
 }
+
+// WithBroadcastRouting will set the routing of the broadcasts (default: all providers at once)
+func WithBroadcastRouting(policy RoutingPolicy) ClientOps {
+	return func(c *clientOptions) {
+		if isValidRoutingPolicy(policy) {
+			c.config.broadcastRouting = policy
+		}
+	}
+}
+
+// WithQueryRouting will set the routing of the transaction queries (default: primary with fallback)
+func WithQueryRouting(policy RoutingPolicy) ClientOps {
+	return func(c *clientOptions) {
+		if isValidRoutingPolicy(policy) {
+			c.config.queryRouting = policy
+		}
+	}
+}
+
+// WithCircuitBreaker will skip a provider for the cooldown after the threshold of consecutive failures
+func WithCircuitBreaker(threshold int, cooldown time.Duration) ClientOps {
+	return func(c *clientOptions) {
+		if threshold > 0 && cooldown > 0 {
+			c.config.circuitThreshold = threshold
+			c.config.circuitCooldown = cooldown
+		}
+	}
+}
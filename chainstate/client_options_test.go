@@ -197,6 +197,210 @@ func TestWithNowNodes(t *testing.T) {
 	})
 }
 
+// TestWithAddressHistoryProvider will test the method WithAddressHistoryProvider()
+func TestWithAddressHistoryProvider(t *testing.T) {
+	t.Parallel()
+
+	t.Run("check type", func(t *testing.T) {
+		opt := WithAddressHistoryProvider(nil)
+		assert.IsType(t, *new(ClientOps), opt)
+	})
+
+	t.Run("test applying nil", func(t *testing.T) {
+		options := &clientOptions{
+			config: &syncConfig{},
+		}
+		opt := WithAddressHistoryProvider(nil)
+		opt(options)
+		assert.Nil(t, options.config.addressHistory)
+	})
+
+	t.Run("test applying option", func(t *testing.T) {
+		options := &clientOptions{
+			config: &syncConfig{},
+		}
+		provider := NewNowNodesHistoryProvider(&nowNodesTxOnChain{})
+		opt := WithAddressHistoryProvider(provider)
+		opt(options)
+		assert.Equal(t, provider, options.config.addressHistory)
+	})
+}
+
+// TestWithAddressHistoryFrom will test the method WithAddressHistoryFrom()
+func TestWithAddressHistoryFrom(t *testing.T) {
+	t.Parallel()
+
+	t.Run("check type", func(t *testing.T) {
+		opt := WithAddressHistoryFrom("")
+		assert.IsType(t, *new(ClientOps), opt)
+	})
+
+	t.Run("test applying empty string", func(t *testing.T) {
+		options := &clientOptions{
+			config: &syncConfig{},
+		}
+		opt := WithAddressHistoryFrom("")
+		opt(options)
+		assert.Equal(t, "", options.config.addressHistoryFrom)
+	})
+
+	t.Run("test applying option", func(t *testing.T) {
+		options := &clientOptions{
+			config: &syncConfig{},
+		}
+		opt := WithAddressHistoryFrom(ProviderNowNodes)
+		opt(options)
+		assert.Equal(t, ProviderNowNodes, options.config.addressHistoryFrom)
+	})
+}
+
+// TestWithBlockHeadersSource will test the method WithBlockHeadersSource()
+func TestWithBlockHeadersSource(t *testing.T) {
+	t.Parallel()
+
+	t.Run("check type", func(t *testing.T) {
+		opt := WithBlockHeadersSource(nil)
+		assert.IsType(t, *new(ClientOps), opt)
+	})
+
+	t.Run("test applying nil", func(t *testing.T) {
+		options := &clientOptions{
+			config: &syncConfig{},
+		}
+		opt := WithBlockHeadersSource(nil)
+		opt(options)
+		assert.Nil(t, options.config.blockHeadersSource)
+	})
+
+	t.Run("test applying option", func(t *testing.T) {
+		options := &clientOptions{
+			config: &syncConfig{},
+		}
+		source := NewStaticHeadersSource(nil)
+		opt := WithBlockHeadersSource(source)
+		opt(options)
+		assert.Equal(t, source, options.config.blockHeadersSource)
+	})
+}
+
+// TestWithBlockHeadersService will test the method WithBlockHeadersService()
+func TestWithBlockHeadersService(t *testing.T) {
+	t.Parallel()
+
+	t.Run("check type", func(t *testing.T) {
+		opt := WithBlockHeadersService("", "")
+		assert.IsType(t, *new(ClientOps), opt)
+	})
+
+	t.Run("test applying empty string", func(t *testing.T) {
+		options := &clientOptions{
+			config: &syncConfig{},
+		}
+		opt := WithBlockHeadersService("", testDummyKey)
+		opt(options)
+		assert.Equal(t, "", options.config.blockHeadersURL)
+		assert.Equal(t, "", options.config.blockHeadersToken)
+	})
+
+	t.Run("test applying option", func(t *testing.T) {
+		options := &clientOptions{
+			config: &syncConfig{},
+		}
+		opt := WithBlockHeadersService("https://headers.example.com", testDummyKey)
+		opt(options)
+		assert.Equal(t, "https://headers.example.com", options.config.blockHeadersURL)
+		assert.Equal(t, testDummyKey, options.config.blockHeadersToken)
+	})
+}
+
+// TestWithNode will test the method WithNode()
+func TestWithNode(t *testing.T) {
+	t.Parallel()
+
+	t.Run("check type", func(t *testing.T) {
+		opt := WithNode(nil)
+		assert.IsType(t, *new(ClientOps), opt)
+	})
+
+	t.Run("test applying nil", func(t *testing.T) {
+		options := &clientOptions{
+			config: &syncConfig{},
+		}
+		opt := WithNode(nil)
+		opt(options)
+		assert.Nil(t, options.config.node)
+	})
+
+	t.Run("test applying option", func(t *testing.T) {
+		options := &clientOptions{
+			config: &syncConfig{},
+		}
+		customClient := NewNodeClient("http://localhost:8332", "", "", nil)
+		opt := WithNode(customClient)
+		opt(options)
+		assert.Equal(t, customClient, options.config.node)
+	})
+}
+
+// TestWithNodeRPC will test the method WithNodeRPC()
+func TestWithNodeRPC(t *testing.T) {
+	t.Parallel()
+
+	t.Run("check type", func(t *testing.T) {
+		opt := WithNodeRPC("", "", "")
+		assert.IsType(t, *new(ClientOps), opt)
+	})
+
+	t.Run("test applying empty string", func(t *testing.T) {
+		options := &clientOptions{
+			config: &syncConfig{},
+		}
+		opt := WithNodeRPC("", "user", testDummyKey)
+		opt(options)
+		assert.Equal(t, "", options.config.nodeURL)
+		assert.Equal(t, "", options.config.nodeUser)
+	})
+
+	t.Run("test applying option", func(t *testing.T) {
+		options := &clientOptions{
+			config: &syncConfig{},
+		}
+		opt := WithNodeRPC("http://localhost:8332", "user", testDummyKey)
+		opt(options)
+		assert.Equal(t, "http://localhost:8332", options.config.nodeURL)
+		assert.Equal(t, "user", options.config.nodeUser)
+		assert.Equal(t, testDummyKey, options.config.nodePassword)
+	})
+}
+
+// TestWithNodeZMQ will test the method WithNodeZMQ()
+func TestWithNodeZMQ(t *testing.T) {
+	t.Parallel()
+
+	t.Run("check type", func(t *testing.T) {
+		opt := WithNodeZMQ("")
+		assert.IsType(t, *new(ClientOps), opt)
+	})
+
+	t.Run("test applying empty string", func(t *testing.T) {
+		options := &clientOptions{
+			config: &syncConfig{},
+		}
+		opt := WithNodeZMQ("")
+		opt(options)
+		assert.Equal(t, "", options.config.nodeZMQAddress)
+	})
+
+	t.Run("test applying option", func(t *testing.T) {
+		options := &clientOptions{
+			config: &syncConfig{},
+		}
+		opt := WithNodeZMQ("tcp://localhost:28332")
+		opt(options)
+		assert.Equal(t, "tcp://localhost:28332", options.config.nodeZMQAddress)
+	})
+}
+
 // TestWithNowNodesAPIKey will test the method WithNowNodesAPIKey()
 func TestWithNowNodesAPIKey(t *testing.T) {
 	t.Parallel()
@@ -424,3 +628,77 @@ func TestWithExcludedProviders(t *testing.T) {
 		assert.Equal(t, ProviderWhatsOnChain, options.config.excludedProviders[0])
 	})
 }
+
+// TestWithBroadcastRouting will test the method WithBroadcastRouting()
+func TestWithBroadcastRouting(t *testing.T) {
+	t.Parallel()
+
+	t.Run("check type", func(t *testing.T) {
+		opt := WithBroadcastRouting("")
+		assert.IsType(t, *new(ClientOps), opt)
+	})
+
+	t.Run("test applying unknown policy", func(t *testing.T) {
+		options := defaultClientOptions()
+		opt := WithBroadcastRouting("unknown")
+		opt(options)
+		assert.Equal(t, RoutingAll, options.config.broadcastRouting)
+	})
+
+	t.Run("test applying option", func(t *testing.T) {
+		options := defaultClientOptions()
+		opt := WithBroadcastRouting(RoutingFastestHealthy)
+		opt(options)
+		assert.Equal(t, RoutingFastestHealthy, options.config.broadcastRouting)
+	})
+}
+
+// TestWithQueryRouting will test the method WithQueryRouting()
+func TestWithQueryRouting(t *testing.T) {
+	t.Parallel()
+
+	t.Run("check type", func(t *testing.T) {
+		opt := WithQueryRouting("")
+		assert.IsType(t, *new(ClientOps), opt)
+	})
+
+	t.Run("test applying unknown policy", func(t *testing.T) {
+		options := defaultClientOptions()
+		opt := WithQueryRouting("unknown")
+		opt(options)
+		assert.Equal(t, RoutingPrimaryFallback, options.config.queryRouting)
+	})
+
+	t.Run("test applying option", func(t *testing.T) {
+		options := defaultClientOptions()
+		opt := WithQueryRouting(RoutingAll)
+		opt(options)
+		assert.Equal(t, RoutingAll, options.config.queryRouting)
+	})
+}
+
+// TestWithCircuitBreaker will test the method WithCircuitBreaker()
+func TestWithCircuitBreaker(t *testing.T) {
+	t.Parallel()
+
+	t.Run("check type", func(t *testing.T) {
+		opt := WithCircuitBreaker(0, 0)
+		assert.IsType(t, *new(ClientOps), opt)
+	})
+
+	t.Run("test applying zero values", func(t *testing.T) {
+		options := defaultClientOptions()
+		opt := WithCircuitBreaker(0, 0)
+		opt(options)
+		assert.Equal(t, defaultCircuitBreakerThreshold, options.config.circuitThreshold)
+		assert.Equal(t, defaultCircuitBreakerCooldown, options.config.circuitCooldown)
+	})
+
+	t.Run("test applying option", func(t *testing.T) {
+		options := defaultClientOptions()
+		opt := WithCircuitBreaker(3, time.Minute)
+		opt(options)
+		assert.Equal(t, 3, options.config.circuitThreshold)
+		assert.Equal(t, time.Minute, options.config.circuitCooldown)
+	})
+}
@@ -70,6 +70,68 @@ func TestNewClient(t *testing.T) {
 		assert.NotNil(t, c.WhatsOnChain())
 	})
 
+	t.Run("default address history provider", func(t *testing.T) {
+		c, err := NewClient(
+			context.Background(),
+			WithMinercraft(&MinerCraftBase{}),
+		)
+		require.NoError(t, err)
+		require.NotNil(t, c)
+		require.NotNil(t, c.AddressHistory())
+		assert.Equal(t, ProviderWhatsOnChain, c.AddressHistory().Name())
+	})
+
+	t.Run("address history from nownodes", func(t *testing.T) {
+		c, err := NewClient(
+			context.Background(),
+			WithNowNodes(&nowNodesTxOnChain{}),
+			WithAddressHistoryFrom(ProviderNowNodes),
+			WithMinercraft(&MinerCraftBase{}),
+		)
+		require.NoError(t, err)
+		require.NotNil(t, c)
+		require.NotNil(t, c.AddressHistory())
+		assert.Equal(t, ProviderNowNodes, c.AddressHistory().Name())
+	})
+
+	t.Run("custom address history provider", func(t *testing.T) {
+		provider, err := NewFixtureHistoryProvider([]byte(`{}`))
+		require.NoError(t, err)
+
+		var c ClientInterface
+		c, err = NewClient(
+			context.Background(),
+			WithAddressHistoryProvider(provider),
+			WithMinercraft(&MinerCraftBase{}),
+		)
+		require.NoError(t, err)
+		require.NotNil(t, c)
+		assert.Equal(t, provider, c.AddressHistory())
+	})
+
+	t.Run("block headers service", func(t *testing.T) {
+		c, err := NewClient(
+			context.Background(),
+			WithBlockHeadersService("https://headers.example.com", testDummyKey),
+			WithMinercraft(&MinerCraftBase{}),
+		)
+		require.NoError(t, err)
+		require.NotNil(t, c)
+		require.NotNil(t, c.BlockHeadersSource())
+		assert.Equal(t, ProviderPulse, c.BlockHeadersSource().Name())
+	})
+
+	t.Run("node rpc", func(t *testing.T) {
+		c, err := NewClient(
+			context.Background(),
+			WithNodeRPC("http://localhost:8332", "user", testDummyKey),
+			WithMinercraft(&MinerCraftBase{}),
+		)
+		require.NoError(t, err)
+		require.NotNil(t, c)
+		assert.NotNil(t, c.Node())
+	})
+
 	t.Run("custom minercraft client", func(t *testing.T) {
 		customClient, err := minercraft.NewClient(
 			minercraft.DefaultClientOptions(), nil, "", nil, nil,
@@ -0,0 +1,70 @@
+package chainstate
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+
+	"github.com/libsv/go-bt/v2"
+)
+
+// querySpendingWhatsOnChain will look through the script history for the transaction spending the output
+//
+// The script history only contains confirmed transactions, so not finding a spender does not prove anything:
+// the output is only reported as unspent if it is listed in the unspent outputs of the script (includes the mempool)
+func querySpendingWhatsOnChain(ctx context.Context, client ClientInterface, id string, index uint32,
+	lockingScript string) (string, error) {
+
+	scriptHash, err := getScriptHash(lockingScript)
+	if err != nil {
+		return "", err
+	}
+
+	client.DebugLog("executing script history request in whatsonchain")
+	history, err := client.WhatsOnChain().GetScriptHistory(ctx, scriptHash)
+	if err != nil {
+		client.DebugLog("error executing script history request in whatsonchain: " + err.Error())
+		return "", err
+	}
+
+	// Check the inputs of each transaction in the history
+	for _, record := range history {
+		if record == nil || strings.EqualFold(record.TxHash, id) {
+			continue
+		}
+		txInfo, txErr := client.WhatsOnChain().GetTxByHash(ctx, record.TxHash)
+		if txErr != nil || txInfo == nil {
+			continue
+		}
+		for _, vin := range txInfo.Vin {
+			if strings.EqualFold(vin.TxID, id) && uint32(vin.Vout) == index {
+				return txInfo.TxID, nil
+			}
+		}
+	}
+
+	// Only proven to be unspent if the output is still listed in the unspent outputs (confirmed and mempool)
+	client.DebugLog("executing script unspent request in whatsonchain")
+	unspent, err := client.WhatsOnChain().GetScriptUnspentTransactions(ctx, scriptHash)
+	if err != nil {
+		client.DebugLog("error executing script unspent request in whatsonchain: " + err.Error())
+		return "", ErrSpendingTransactionNotFound
+	}
+	for _, record := range unspent {
+		if record != nil && strings.EqualFold(record.TxHash, id) && uint32(record.TxPos) == index {
+			return "", ErrOutputUnspent
+		}
+	}
+	return "", ErrSpendingTransactionNotFound
+}
+
+// getScriptHash will return the script hash (reversed sha256 of the locking script) used by indexers
+func getScriptHash(lockingScript string) (string, error) {
+	script, err := hex.DecodeString(lockingScript)
+	if err != nil {
+		return "", ErrInvalidLockingScript
+	}
+	hash := sha256.Sum256(script)
+	return hex.EncodeToString(bt.ReverseBytes(hash[:])), nil
+}
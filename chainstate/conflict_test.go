@@ -0,0 +1,91 @@
+package chainstate
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testConflictLockingScript is a standard P2PKH locking script
+const testConflictLockingScript = "76a914777242b335bc7781f43e1b05c60d8c2f2d08b44c88ac"
+
+// TestClient_QuerySpendingTransaction will test the method QuerySpendingTransaction()
+func TestClient_QuerySpendingTransaction(t *testing.T) {
+	t.Parallel()
+
+	t.Run("error - missing tx id", func(t *testing.T) {
+		c := NewTestClient(context.Background(), t, WithWhatsOnChain(&whatsOnChainSpent{}))
+		txID, err := c.QuerySpendingTransaction(
+			context.Background(), "", 0, testConflictLockingScript, defaultQueryTimeOut,
+		)
+		require.ErrorIs(t, err, ErrInvalidTransactionID)
+		assert.Empty(t, txID)
+	})
+
+	t.Run("error - invalid locking script", func(t *testing.T) {
+		c := NewTestClient(context.Background(), t, WithWhatsOnChain(&whatsOnChainSpent{}))
+		txID, err := c.QuerySpendingTransaction(
+			context.Background(), broadcastExample1TxID, 0, "invalid-script", defaultQueryTimeOut,
+		)
+		require.ErrorIs(t, err, ErrInvalidLockingScript)
+		assert.Empty(t, txID)
+	})
+
+	t.Run("found spending tx", func(t *testing.T) {
+		c := NewTestClient(context.Background(), t, WithWhatsOnChain(&whatsOnChainSpent{}))
+		txID, err := c.QuerySpendingTransaction(
+			context.Background(), broadcastExample1TxID, 0, testConflictLockingScript, defaultQueryTimeOut,
+		)
+		require.NoError(t, err)
+		assert.Equal(t, onChainExample1TxID, txID)
+	})
+
+	t.Run("output not spent", func(t *testing.T) {
+		c := NewTestClient(context.Background(), t, WithWhatsOnChain(&whatsOnChainSpent{}))
+		txID, err := c.QuerySpendingTransaction(
+			context.Background(), broadcastExample1TxID, 1, testConflictLockingScript, defaultQueryTimeOut,
+		)
+		require.ErrorIs(t, err, ErrOutputUnspent)
+		assert.Empty(t, txID)
+	})
+
+	t.Run("not in the history, not unspent (spent in the mempool)", func(t *testing.T) {
+		c := NewTestClient(context.Background(), t, WithWhatsOnChain(&whatsOnChainSpent{}))
+		txID, err := c.QuerySpendingTransaction(
+			context.Background(), broadcastExample1TxID, 2, testConflictLockingScript, defaultQueryTimeOut,
+		)
+		require.ErrorIs(t, err, ErrSpendingTransactionNotFound)
+		assert.Empty(t, txID)
+	})
+
+	t.Run("whatsonchain not available", func(t *testing.T) {
+		c := NewTestClient(
+			context.Background(), t, WithWhatsOnChain(&whatsOnChainSpent{}),
+			WithExcludedProviders([]string{ProviderWhatsOnChain}),
+		)
+		txID, err := c.QuerySpendingTransaction(
+			context.Background(), broadcastExample1TxID, 1, testConflictLockingScript, defaultQueryTimeOut,
+		)
+		require.ErrorIs(t, err, ErrSpendingTransactionNotFound)
+		assert.Empty(t, txID)
+	})
+}
+
+// Test_getScriptHash will test the method getScriptHash()
+func Test_getScriptHash(t *testing.T) {
+	t.Parallel()
+
+	t.Run("valid script", func(t *testing.T) {
+		hash, err := getScriptHash(testConflictLockingScript)
+		require.NoError(t, err)
+		assert.Len(t, hash, 64)
+	})
+
+	t.Run("invalid script", func(t *testing.T) {
+		hash, err := getScriptHash("zz")
+		require.ErrorIs(t, err, ErrInvalidLockingScript)
+		assert.Empty(t, hash)
+	})
+}
@@ -4,15 +4,19 @@ import (
 	"time"
 
 	"github.com/BuxOrg/bux/utils"
+	"github.com/libsv/go-bc"
 )
 
 // Chainstate configuration defaults
 const (
 	defaultBroadcastTimeOut        = 15 * time.Second
+	defaultCircuitBreakerCooldown  = 30 * time.Second
+	defaultCircuitBreakerThreshold = 5
 	defaultFalsePositiveRate       = 0.01
 	defaultFeeLastCheckIgnore      = 2 * time.Minute
 	defaultMaxNumberOfDestinations = 100000
 	defaultMonitorDays             = 7
+	defaultProviderHealthWindow    = 100
 	defaultQueryTimeOut            = 15 * time.Second
 	whatsOnChainRateLimitWithKey   = 20
 )
@@ -34,6 +38,16 @@ const (
 	testNetAlt = "test"    // Public test network
 )
 
+// powLimitBits is the compact target of the minimum difficulty (proof-of-work limit) of the public networks
+const powLimitBits = "1d00ffff"
+
+// Activation heights of the difficulty adjustment algorithm (DAA)
+const (
+	mainNetDAAHeight = 504031
+	stnDAAHeight     = 2200
+	testNetDAAHeight = 1188697
+)
+
 // Requirements and providers
 const (
 	mAPIFailure       = "failure"  // Minercraft result was a failure / error
@@ -45,19 +59,25 @@ const (
 // List of providers
 const (
 	ProviderAll          = "all"          // All providers (used for errors etc)
+	ProviderFixture      = "fixture"      // Address history provider using a local (JSON) fixture
+	ProviderOffline      = "offline"      // Local (in-process) chainstate, IE: testing or air-gapped usage
+	ProviderPulse        = "pulse"        // Block headers source using a Pulse-like headers service
+	ProviderStatic       = "static"       // Block headers source using local block headers
 	ProviderMAPI         = "mapi"         // Query & broadcast provider for mAPI (using given miners)
+	ProviderNode         = "node"         // Query & broadcast provider using a node (JSON-RPC)
 	ProviderNowNodes     = "nownodes"     // Query & broadcast provider for NowNodes
 	ProviderWhatsOnChain = "whatsonchain" // Query & broadcast provider for WhatsOnChain
 )
 
 // TransactionInfo is the universal information about the transaction found from a chain provider
 type TransactionInfo struct {
-	BlockHash     string `json:"block_hash,omitempty"`    // mAPI, WOC
-	BlockHeight   int64  `json:"block_height"`            // mAPI, WOC
-	Confirmations int64  `json:"confirmations,omitempty"` // mAPI, WOC
-	ID            string `json:"id"`                      // Transaction ID (Hex)
-	MinerID       string `json:"miner_id,omitempty"`      // mAPI ONLY - miner_id found
-	Provider      string `json:"provider,omitempty"`      // Provider is our internal source
+	BlockHash     string          `json:"block_hash,omitempty"`    // mAPI, WOC
+	BlockHeight   int64           `json:"block_height"`            // mAPI, WOC
+	Confirmations int64           `json:"confirmations,omitempty"` // mAPI, WOC
+	ID            string          `json:"id"`                      // Transaction ID (Hex)
+	MerkleProof   *bc.MerkleProof `json:"merkle_proof,omitempty"`  // mAPI, node - merkle proof (TSC format) if confirmed
+	MinerID       string          `json:"miner_id,omitempty"`      // mAPI ONLY - miner_id found
+	Provider      string          `json:"provider,omitempty"`      // Provider is our internal source
 }
 
 var (
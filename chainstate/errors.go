@@ -25,3 +25,51 @@ var ErrMissingQueryMiners = errors.New("missing: query miners")
 
 // ErrMonitorNotAvailable is when the monitor processor is not available
 var ErrMonitorNotAvailable = errors.New("monitor processor not available")
+
+// ErrBroadcastRejected is when the transaction was rejected by all broadcast providers
+var ErrBroadcastRejected = errors.New("transaction rejected")
+
+// ErrBroadcastDoubleSpend is when the inputs of the transaction are already spent on-chain
+var ErrBroadcastDoubleSpend = errors.New("transaction inputs already spent (double spend)")
+
+// ErrBroadcastMempoolConflict is when the inputs of the transaction are spent by a transaction in the mempool
+var ErrBroadcastMempoolConflict = errors.New("transaction conflicts with a transaction in the mempool")
+
+// ErrBroadcastTooLongChain is when the chain of unconfirmed ancestors is too long
+var ErrBroadcastTooLongChain = errors.New("too long chain of unconfirmed ancestors")
+
+// ErrBroadcastFeeTooLow is when the fee of the transaction was not accepted
+var ErrBroadcastFeeTooLow = errors.New("transaction fee too low")
+
+// ErrSpendingTransactionNotFound is when no transaction spending the output was found
+var ErrSpendingTransactionNotFound = errors.New("spending transaction not found")
+
+// ErrOutputUnspent is when the output is proven to be unspent (listed in the unspent outputs, including the mempool)
+var ErrOutputUnspent = errors.New("output is not spent")
+
+// ErrInvalidLockingScript is when the locking script is missing or invalid
+var ErrInvalidLockingScript = errors.New("invalid locking script")
+
+// ErrInvalidAddressHistoryFixture is when the address history fixture could not be parsed
+var ErrInvalidAddressHistoryFixture = errors.New("invalid address history fixture")
+
+// ErrBlockHeadersSourceRequest is when the request to the block headers service failed
+var ErrBlockHeadersSourceRequest = errors.New("block headers service request failed")
+
+// ErrBlockHeadersSourceEmpty is when the block headers source has no block headers
+var ErrBlockHeadersSourceEmpty = errors.New("block headers source has no block headers")
+
+// ErrInvalidBlockHeadersFile is when the block headers file could not be parsed
+var ErrInvalidBlockHeadersFile = errors.New("invalid block headers file")
+
+// ErrTransactionInputsNotFound is when the previous outputs spent by the transaction were not found
+var ErrTransactionInputsNotFound = errors.New("transaction inputs not found")
+
+// ErrNodeRequest is when the JSON-RPC request to the node failed
+var ErrNodeRequest = errors.New("node request failed")
+
+// ErrZMQHandshake is when the ZMQ handshake with the node failed
+var ErrZMQHandshake = errors.New("zmq handshake failed")
+
+// ErrInvalidZMQFrame is when a ZMQ frame could not be read
+var ErrInvalidZMQFrame = errors.New("invalid zmq frame")
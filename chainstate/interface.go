@@ -27,6 +27,12 @@ type Logger interface {
 // ChainService is the chain related methods
 type ChainService interface {
 	Broadcast(ctx context.Context, id, txHex string, timeout time.Duration) (string, error)
+	BroadcastWithAttempts(
+		ctx context.Context, id, txHex string, timeout time.Duration, lateAttempts BroadcastAttemptsHandler,
+	) (string, []*BroadcastAttempt, error)
+	QuerySpendingTransaction(
+		ctx context.Context, id string, index uint32, lockingScript string, timeout time.Duration,
+	) (string, error)
 	QueryTransaction(
 		ctx context.Context, id string, requiredIn RequiredIn, timeout time.Duration,
 	) (*TransactionInfo, error)
@@ -35,9 +41,26 @@ type ChainService interface {
 	) (*TransactionInfo, error)
 }
 
+// AddressHistoryProvider is the provider for the transaction history of addresses (used for importing xPubs)
+type AddressHistoryProvider interface {
+	AddressHistory(ctx context.Context, address string) ([]*AddressHistoryRecord, error)
+	Name() string
+	RawTransactions(ctx context.Context, txIDs []string) ([]*RawTransaction, error)
+}
+
+// BlockHeadersSource is a source of block headers on the longest chain (used for syncing block headers)
+type BlockHeadersSource interface {
+	GetHeadersByHeight(ctx context.Context, height uint32, count int) ([]*BlockHeaderInfo, error)
+	GetTipHeight(ctx context.Context) (uint32, error)
+	Name() string
+}
+
 // ProviderServices is the chainstate providers interface
 type ProviderServices interface {
+	AddressHistory() AddressHistoryProvider
+	BlockHeadersSource() BlockHeadersSource
 	Minercraft() minercraft.ClientInterface
+	Node() NodeInterface
 	NowNodes() nownodes.ClientInterface
 	WhatsOnChain() whatsonchain.ClientInterface
 }
@@ -58,11 +81,14 @@ type ClientInterface interface {
 	Close(ctx context.Context)
 	Debug(on bool)
 	DebugLog(text string)
+	DifficultyRules() *DifficultyRules
 	HTTPClient() HTTPInterface
 	IsDebug() bool
 	IsNewRelicEnabled() bool
 	Monitor() MonitorService
 	Network() Network
+	PowLimit() string
+	ProviderStats() []*ProviderStats
 	QueryTimeout() time.Duration
 }
 
@@ -78,7 +104,7 @@ type MonitorClient interface {
 type MonitorHandler interface {
 	whatsonchain.SocketHandler
 	GetWhatsOnChain() whatsonchain.ClientInterface
-	RecordBlockHeader(ctx context.Context, bh bc.BlockHeader) error
+	RecordBlockHeader(ctx context.Context, height uint32, bh bc.BlockHeader) error
 	RecordTransaction(ctx context.Context, txHex string) error
 	SetMonitor(monitor *Monitor)
 }
@@ -153,6 +153,17 @@ func (n *nowNodesTxNotFound) GetTransaction(_ context.Context, _ nownodes.Blockc
 	return nil, errors.New("Transaction '" + txID + "' not found")
 }
 
+type nowNodesTxNotFoundSlow struct {
+	nowNodesTxNotFound
+}
+
+func (n *nowNodesTxNotFoundSlow) SendRawTransaction(ctx context.Context, chain nownodes.Blockchain,
+	txID, txHex string,
+) (*nownodes.BroadcastResult, error) {
+	time.Sleep(100 * time.Millisecond)
+	return n.nowNodesTxNotFound.SendRawTransaction(ctx, chain, txID, txHex)
+}
+
 type nowNodesBroadcastTimeout struct {
 	nowNodesBase
 }
@@ -268,3 +268,33 @@ func (w *whatsOnChainBroadcastTimeout) BroadcastTx(_ context.Context, _ string)
 	time.Sleep(defaultBroadcastTimeOut * 2)
 	return "", errors.New("unexpected response code 500: 257: txn-already-known")
 }
+
+type whatsOnChainSpent struct {
+	whatsOnChainBase
+}
+
+func (w *whatsOnChainSpent) GetScriptHistory(context.Context, string) (history whatsonchain.ScriptList, err error) {
+	return whatsonchain.ScriptList{
+		{TxHash: broadcastExample1TxID},
+		{TxHash: onChainExample1TxID},
+	}, nil
+}
+
+func (w *whatsOnChainSpent) GetScriptUnspentTransactions(context.Context, string) (scriptList whatsonchain.ScriptList, err error) {
+	return whatsonchain.ScriptList{
+		{TxHash: broadcastExample1TxID, TxPos: 1},
+	}, nil
+}
+
+func (w *whatsOnChainSpent) GetTxByHash(_ context.Context, hash string) (txInfo *whatsonchain.TxInfo, err error) {
+	if hash == onChainExample1TxID {
+		txInfo = &whatsonchain.TxInfo{
+			TxID: onChainExample1TxID,
+			Vin: []whatsonchain.VinInfo{
+				{TxID: notFoundExample1TxID, Vout: 1},
+				{TxID: broadcastExample1TxID, Vout: 0},
+			},
+		}
+	}
+	return
+}
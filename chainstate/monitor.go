@@ -316,10 +316,17 @@ func (m *Monitor) Start(ctx context.Context, handler MonitorHandler, onStop func
 	if m.client == nil {
 		handler.SetMonitor(m)
 		m.handler = handler
-		m.logger.Info(ctx, fmt.Sprintf("[MONITOR] Starting, connecting to server: %s", m.buxAgentURL))
-		m.client = newCentrifugeClient(m.buxAgentURL, handler)
-		if m.authToken != "" {
-			m.client.SetToken(m.authToken)
+		if m.chainstateOptions != nil && len(m.chainstateOptions.config.nodeZMQAddress) > 0 {
+			m.logger.Info(ctx, fmt.Sprintf("[MONITOR] Starting, connecting to node: %s", m.chainstateOptions.config.nodeZMQAddress))
+			m.client = newNodeMonitorClient(
+				m, handler, m.chainstateOptions.config.nodeZMQAddress, m.chainstateOptions.config.node,
+			)
+		} else {
+			m.logger.Info(ctx, fmt.Sprintf("[MONITOR] Starting, connecting to server: %s", m.buxAgentURL))
+			m.client = newCentrifugeClient(m.buxAgentURL, handler)
+			if m.authToken != "" {
+				m.client.SetToken(m.authToken)
+			}
 		}
 	}
 
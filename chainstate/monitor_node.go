@@ -0,0 +1,155 @@
+package chainstate
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"sync"
+
+	"github.com/centrifugal/centrifuge-go"
+	"github.com/libsv/go-bc"
+)
+
+// NodeMonitorClient implements MonitorClient using the ZMQ notifications of a node (instead of the bux-agent)
+//
+// Transactions (rawtx) are filtered locally by the monitor processor, new blocks (hashblock) are
+// recorded using the block header from the node (JSON-RPC)
+type NodeMonitorClient struct {
+	address    string
+	handler    MonitorHandler
+	monitor    *Monitor
+	mu         sync.Mutex
+	node       NodeInterface
+	subscriber *zmqSubscriber
+}
+
+// newNodeMonitorClient will return a monitor client subscribing to the ZMQ address of the node
+func newNodeMonitorClient(monitor *Monitor, handler MonitorHandler, address string,
+	node NodeInterface) *NodeMonitorClient {
+	return &NodeMonitorClient{
+		address: address,
+		handler: handler,
+		monitor: monitor,
+		node:    node,
+	}
+}
+
+// AddFilter does nothing, the filters are applied by the monitor processor
+func (n *NodeMonitorClient) AddFilter(_, _ string) (centrifuge.PublishResult, error) {
+	return centrifuge.PublishResult{}, nil
+}
+
+// Connect will subscribe to the node notifications (if not already connected)
+func (n *NodeMonitorClient) Connect() error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.subscriber != nil {
+		return nil
+	}
+
+	ctx := context.Background()
+	subscriber, err := dialZMQSubscriber(ctx, n.address, zmqTopicHashBlock, zmqTopicRawTx)
+	if err != nil {
+		return err
+	}
+	n.subscriber = subscriber
+	n.monitor.Connected()
+	n.monitor.logger.Info(ctx, fmt.Sprintf("[MONITOR] Connected to node: %s", n.address))
+
+	go n.listen(ctx, subscriber)
+	return nil
+}
+
+// Disconnect will close the subscription
+func (n *NodeMonitorClient) Disconnect() error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.subscriber == nil {
+		return nil
+	}
+	err := n.subscriber.Close()
+	n.subscriber = nil
+	n.monitor.Disconnected()
+	return err
+}
+
+// SetToken does nothing (no authentication)
+func (n *NodeMonitorClient) SetToken(_ string) {}
+
+// listen will process the notifications until the subscription is closed
+func (n *NodeMonitorClient) listen(ctx context.Context, subscriber *zmqSubscriber) {
+	for {
+		message, err := subscriber.ReadMessage()
+		if err != nil {
+			n.mu.Lock()
+			if n.subscriber == subscriber { // Not closed by Disconnect()
+				n.monitor.logger.Error(ctx, fmt.Sprintf("[MONITOR] ERROR reading from node: %s", err.Error()))
+				_ = subscriber.Close()
+				n.subscriber = nil
+				n.monitor.Disconnected()
+			}
+			n.mu.Unlock()
+			return
+		}
+
+		// Topic, body and sequence number
+		if len(message) < 2 {
+			continue
+		}
+		if err = n.process(ctx, string(message[0]), message[1]); err != nil {
+			n.monitor.logger.Error(ctx, fmt.Sprintf("[MONITOR] ERROR processing %s: %s", message[0], err.Error()))
+		}
+	}
+}
+
+// process will process a notification of the node
+func (n *NodeMonitorClient) process(ctx context.Context, topic string, body []byte) error {
+	switch topic {
+	case zmqTopicRawTx:
+		txHex, err := n.monitor.processor.FilterTransaction(hex.EncodeToString(body))
+		if err != nil || len(txHex) == 0 {
+			return err
+		}
+		return n.handler.RecordTransaction(ctx, txHex)
+	case zmqTopicHashBlock:
+		header, err := n.node.GetBlockHeader(ctx, hex.EncodeToString(body))
+		if err != nil {
+			return err
+		}
+		var bh *bc.BlockHeader
+		if bh, err = header.blockHeader(); err != nil {
+			return err
+		}
+		if n.monitor.debug {
+			n.monitor.logger.Info(ctx, fmt.Sprintf("[MONITOR] new block %d: %s", header.Height, header.Hash))
+		}
+		return n.handler.RecordBlockHeader(ctx, header.Height, *bh)
+	}
+	return nil
+}
+
+// blockHeader will return the block header (the previous hash is empty for the genesis block)
+func (h *NodeBlockHeader) blockHeader() (*bc.BlockHeader, error) {
+	bh := &bc.BlockHeader{
+		HashPrevBlock: make([]byte, 32),
+		Nonce:         h.Nonce,
+		Time:          h.Time,
+		Version:       h.Version,
+	}
+
+	var err error
+	if len(h.PreviousBlockHash) > 0 {
+		if bh.HashPrevBlock, err = hex.DecodeString(h.PreviousBlockHash); err != nil {
+			return nil, err
+		}
+	}
+	if bh.HashMerkleRoot, err = hex.DecodeString(h.MerkleRoot); err != nil {
+		return nil, err
+	}
+	if bh.Bits, err = hex.DecodeString(h.Bits); err != nil {
+		return nil, err
+	}
+	return bh, nil
+}
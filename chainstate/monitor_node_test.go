@@ -0,0 +1,162 @@
+package chainstate
+
+import (
+	"bufio"
+	"context"
+	"encoding/hex"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/BuxOrg/bux/utils"
+	"github.com/libsv/go-bc"
+	"github.com/libsv/go-bk/crypto"
+	"github.com/libsv/go-bt/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testNodeMonitorHandler receives the transactions and block headers from the monitor
+type testNodeMonitorHandler struct {
+	MonitorHandler
+	blockHeaders chan bc.BlockHeader
+	heights      chan uint32
+	transactions chan string
+}
+
+// RecordBlockHeader will send the block header to the channel
+func (h *testNodeMonitorHandler) RecordBlockHeader(_ context.Context, height uint32, bh bc.BlockHeader) error {
+	h.heights <- height
+	h.blockHeaders <- bh
+	return nil
+}
+
+// RecordTransaction will send the transaction to the channel
+func (h *testNodeMonitorHandler) RecordTransaction(_ context.Context, txHex string) error {
+	h.transactions <- txHex
+	return nil
+}
+
+// SetMonitor does nothing
+func (h *testNodeMonitorHandler) SetMonitor(_ *Monitor) {}
+
+// startTestZMQPublisher will start a ZMQ publisher (a stand-in for a node) sending the messages to the subscriber
+func startTestZMQPublisher(t *testing.T, messages ...[][]byte) net.Listener {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer func() {
+			_ = conn.Close()
+		}()
+		reader := bufio.NewReader(conn)
+
+		// Handshake (greetings and READY commands)
+		_, _ = conn.Write(zmqGreeting())
+		greeting := make([]byte, 64)
+		if _, err = io.ReadFull(reader, greeting); err != nil {
+			return
+		}
+		if _, _, err = readZMQFrame(reader); err != nil {
+			return
+		}
+		_ = writeZMQFrame(conn, zmqFlagCommand, zmqReadyCommand("PUB"))
+
+		// Subscriptions
+		for _, topic := range []string{zmqTopicHashBlock, zmqTopicRawTx} {
+			var body []byte
+			if _, body, err = readZMQFrame(reader); err != nil {
+				return
+			}
+			assert.Equal(t, append([]byte{0x01}, topic...), body)
+		}
+
+		for _, message := range messages {
+			for index, frame := range message {
+				var flags byte
+				if index < len(message)-1 {
+					flags = zmqFlagMore
+				}
+				_ = writeZMQFrame(conn, flags, frame)
+			}
+		}
+
+		// Wait until the subscriber disconnects
+		_, _ = reader.ReadByte()
+	}()
+
+	return listener
+}
+
+// TestNodeMonitorClient will test the monitor using the ZMQ notifications of a node
+func TestNodeMonitorClient(t *testing.T) {
+	t.Parallel()
+
+	server := newTestNodeServer(t)
+	defer server.Close()
+
+	txBytes, err := hex.DecodeString(broadcastExample1TxHex)
+	require.NoError(t, err)
+	otherTxBytes, err := hex.DecodeString(onChainExample1TxHex)
+	require.NoError(t, err)
+	blockHash, err := hex.DecodeString(testBlockHeaders[1].Hash)
+	require.NoError(t, err)
+
+	sequence := []byte{0, 0, 0, 0}
+	publisher := startTestZMQPublisher(t,
+		[][]byte{[]byte(zmqTopicRawTx), otherTxBytes, sequence},
+		[][]byte{[]byte(zmqTopicRawTx), txBytes, sequence},
+		[][]byte{[]byte(zmqTopicHashBlock), blockHash, sequence},
+	)
+	defer func() {
+		_ = publisher.Close()
+	}()
+
+	ctx := context.Background()
+	c, err := NewClient(
+		ctx,
+		WithNodeRPC(server.URL, "user", testDummyKey),
+		WithNodeZMQ("tcp://"+publisher.Addr().String()),
+		WithMonitoring(ctx, &MonitorOptions{}),
+		WithMinercraft(&MinerCraftBase{}),
+	)
+	require.NoError(t, err)
+
+	// Monitor the first output of the transaction
+	monitor := c.Monitor()
+	require.NoError(t, monitor.Processor().Add(
+		utils.P2PKHRegexpString, "76a914777242b335bc7781f43e1b05c60d8c2f2d08b44c88ac",
+	))
+
+	handler := &testNodeMonitorHandler{
+		blockHeaders: make(chan bc.BlockHeader, 1),
+		heights:      make(chan uint32, 1),
+		transactions: make(chan string, 2),
+	}
+	require.NoError(t, monitor.Start(ctx, handler, nil))
+	assert.True(t, monitor.IsConnected())
+
+	select {
+	case txHex := <-handler.transactions:
+		assert.Equal(t, broadcastExample1TxHex, txHex)
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "transaction was not recorded")
+	}
+
+	select {
+	case bh := <-handler.blockHeaders:
+		assert.Equal(t, testBlockHeaders[1].Hash, hex.EncodeToString(bt.ReverseBytes(crypto.Sha256d(bh.Bytes()))))
+		assert.Equal(t, uint32(1), <-handler.heights)
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "block header was not recorded")
+	}
+	assert.Empty(t, handler.transactions)
+
+	require.NoError(t, monitor.Stop(ctx))
+	assert.False(t, monitor.IsConnected())
+}
@@ -46,3 +46,28 @@ func (n Network) Alternate() string {
 		return ""
 	}
 }
+
+// PowLimit is the compact target (bits) of the minimum difficulty of the network
+func (n Network) PowLimit() string {
+	return powLimitBits // Same limit for all the public networks
+}
+
+// DifficultyRules are the difficulty adjustment rules of the block headers of a network
+type DifficultyRules struct {
+	AllowMinDifficultyBlocks bool   // A block more than 20 minutes after the previous block has the minimum difficulty
+	DAAHeight                uint32 // The blocks after this height use the DAA (EDA & 2016 block retargets before)
+	NoRetargeting            bool   // The difficulty never changes (regtest)
+	PowLimit                 string // Compact target (bits) of the minimum difficulty
+}
+
+// DifficultyRules will return the difficulty adjustment rules of the network
+func (n Network) DifficultyRules() *DifficultyRules {
+	switch n {
+	case TestNet:
+		return &DifficultyRules{AllowMinDifficultyBlocks: true, DAAHeight: testNetDAAHeight, PowLimit: powLimitBits}
+	case StressTestNet:
+		return &DifficultyRules{AllowMinDifficultyBlocks: true, DAAHeight: stnDAAHeight, PowLimit: powLimitBits}
+	default:
+		return &DifficultyRules{DAAHeight: mainNetDAAHeight, PowLimit: powLimitBits}
+	}
+}
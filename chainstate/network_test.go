@@ -54,3 +54,14 @@ func TestNetwork_Alternate(t *testing.T) {
 		assert.Equal(t, "", un.Alternate())
 	})
 }
+
+// TestNetwork_PowLimit will test the method PowLimit()
+func TestNetwork_PowLimit(t *testing.T) {
+	t.Parallel()
+
+	t.Run("test all networks", func(t *testing.T) {
+		assert.Equal(t, powLimitBits, MainNet.PowLimit())
+		assert.Equal(t, powLimitBits, StressTestNet.PowLimit())
+		assert.Equal(t, powLimitBits, TestNet.PowLimit())
+	})
+}
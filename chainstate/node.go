@@ -0,0 +1,195 @@
+package chainstate
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/libsv/go-bc"
+)
+
+// NodeInterface is the JSON-RPC interface of a Bitcoin (SV) node
+type NodeInterface interface {
+	GetBlockHeader(ctx context.Context, hash string) (*NodeBlockHeader, error)
+	GetMerkleProof(ctx context.Context, blockHash, txID string) (*NodeMerkleProof, error)
+	GetRawTransaction(ctx context.Context, txID string) (*NodeTransaction, error)
+	SendRawTransaction(ctx context.Context, txHex string) (string, error)
+}
+
+// NodeBlockHeader is the block header returned by the node (getblockheader)
+type NodeBlockHeader struct {
+	Bits              string `json:"bits"`
+	Confirmations     int64  `json:"confirmations"` // -1 if not on the main chain
+	Hash              string `json:"hash"`
+	Height            uint32 `json:"height"`
+	MerkleRoot        string `json:"merkleroot"`
+	NextBlockHash     string `json:"nextblockhash"`
+	Nonce             uint32 `json:"nonce"`
+	PreviousBlockHash string `json:"previousblockhash"`
+	Time              uint32 `json:"time"`
+	Version           uint32 `json:"version"`
+}
+
+// NodeMerkleProof is the merkle proof (TSC format) returned by the node (getmerkleproof2)
+type NodeMerkleProof struct {
+	Index      uint64   `json:"index"`
+	Nodes      []string `json:"nodes"`
+	Target     string   `json:"target"`     // Merkle root
+	TargetType string   `json:"targetType"` // Always "merkleroot"
+	TxOrID     string   `json:"txOrId"`
+}
+
+// NodeTransaction is the transaction returned by the node (getrawtransaction, verbose)
+type NodeTransaction struct {
+	BlockHash     string `json:"blockhash"`
+	BlockHeight   int64  `json:"blockheight"`
+	Confirmations int64  `json:"confirmations"`
+	Hex           string `json:"hex"`
+	TxID          string `json:"txid"`
+}
+
+// nodeClient is the JSON-RPC client of a Bitcoin (SV) node
+type nodeClient struct {
+	httpClient HTTPInterface
+	password   string
+	url        string
+	user       string
+}
+
+// nodeRequest is a JSON-RPC request
+type nodeRequest struct {
+	ID      string        `json:"id"`
+	JSONRPC string        `json:"jsonrpc"`
+	Method  string        `json:"method"`
+	Params  []interface{} `json:"params"`
+}
+
+// nodeResponse is a JSON-RPC response
+type nodeResponse struct {
+	Error  *NodeError      `json:"error"`
+	Result json.RawMessage `json:"result"`
+}
+
+// NodeError is a JSON-RPC error returned by the node (IE: -26: 258: txn-mempool-conflict)
+type NodeError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// Error will return the error message (wraps ErrNodeRequest)
+func (e *NodeError) Error() string {
+	return fmt.Sprintf("%s: %d: %s", ErrNodeRequest, e.Code, e.Message)
+}
+
+// Unwrap will return ErrNodeRequest
+func (e *NodeError) Unwrap() error {
+	return ErrNodeRequest
+}
+
+// NewNodeClient will return a JSON-RPC client for a Bitcoin (SV) node
+//
+// url is the RPC url (IE: http://localhost:8332), user and password are the RPC credentials
+func NewNodeClient(url, user, password string, httpClient HTTPInterface) NodeInterface {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &nodeClient{
+		httpClient: httpClient,
+		password:   password,
+		url:        strings.TrimSuffix(url, "/"),
+		user:       user,
+	}
+}
+
+// GetBlockHeader will return the block header for the given block hash
+func (n *nodeClient) GetBlockHeader(ctx context.Context, hash string) (*NodeBlockHeader, error) {
+	header := new(NodeBlockHeader)
+	if err := n.call(ctx, "getblockheader", []interface{}{hash, true}, header); err != nil {
+		return nil, err
+	}
+	return header, nil
+}
+
+// GetMerkleProof will return the merkle proof of the transaction (to the merkle root of the block)
+//
+// blockHash is optional (the node needs the transaction index to find the block)
+func (n *nodeClient) GetMerkleProof(ctx context.Context, blockHash, txID string) (*NodeMerkleProof, error) {
+	proof := new(NodeMerkleProof)
+	if err := n.call(
+		ctx, "getmerkleproof2", []interface{}{blockHash, txID, false, "merkleroot"}, proof,
+	); err != nil {
+		return nil, err
+	}
+	return proof, nil
+}
+
+// merkleProof will return the merkle proof (TSC format) of the node merkle proof
+func (p *NodeMerkleProof) merkleProof() *bc.MerkleProof {
+	return &bc.MerkleProof{
+		Index:      p.Index,
+		Nodes:      p.Nodes,
+		Target:     p.Target,
+		TargetType: p.TargetType,
+		TxOrID:     p.TxOrID,
+	}
+}
+
+// GetRawTransaction will return the transaction (the block information is empty if unconfirmed)
+func (n *nodeClient) GetRawTransaction(ctx context.Context, txID string) (*NodeTransaction, error) {
+	transaction := new(NodeTransaction)
+	if err := n.call(ctx, "getrawtransaction", []interface{}{txID, true}, transaction); err != nil {
+		return nil, err
+	}
+	return transaction, nil
+}
+
+// SendRawTransaction will broadcast the transaction and return the tx id
+func (n *nodeClient) SendRawTransaction(ctx context.Context, txHex string) (string, error) {
+	var txID string
+	if err := n.call(ctx, "sendrawtransaction", []interface{}{txHex}, &txID); err != nil {
+		return "", err
+	}
+	return txID, nil
+}
+
+// call will fire a JSON-RPC request to the node and decode the result
+func (n *nodeClient) call(ctx context.Context, method string, params []interface{}, result interface{}) error {
+	data, err := json.Marshal(&nodeRequest{
+		ID:      method,
+		JSONRPC: "1.0",
+		Method:  method,
+		Params:  params,
+	})
+	if err != nil {
+		return err
+	}
+
+	var req *http.Request
+	if req, err = http.NewRequestWithContext(ctx, http.MethodPost, n.url, bytes.NewReader(data)); err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if len(n.user) > 0 {
+		req.SetBasicAuth(n.user, n.password)
+	}
+
+	var resp *http.Response
+	if resp, err = n.httpClient.Do(req); err != nil {
+		return err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	// The node returns RPC errors with a status code 500 (or 404 for unknown methods)
+	response := new(nodeResponse)
+	if err = json.NewDecoder(resp.Body).Decode(response); err != nil {
+		return fmt.Errorf("%w: status code %d", ErrNodeRequest, resp.StatusCode)
+	} else if response.Error != nil {
+		return response.Error
+	}
+	return json.Unmarshal(response.Result, result)
+}
@@ -0,0 +1,166 @@
+package chainstate
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/libsv/go-bk/crypto"
+	"github.com/libsv/go-bt/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testNodeBlockHeader is the block header (height 1) returned by the test node
+const testNodeBlockHeader = `{
+	"hash":"00000000839a8e6886ab5951d76f411475428afc90947ee320161bbf18eb6048",
+	"confirmations":1,
+	"height":1,
+	"version":1,
+	"merkleroot":"0e3e2357e806b6cdb1f70b54c3a3a17b6714ee1f0e68bebb44a74b1efd512098",
+	"time":1231469665,
+	"nonce":2573394689,
+	"bits":"1d00ffff",
+	"previousblockhash":"000000000019d6689c085ae165831e934ff763ae46a2a6c172b3f1b60a8ce26f"
+}`
+
+// newTestNodeServer will return a JSON-RPC server (a stand-in for a node)
+func newTestNodeServer(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, password, ok := r.BasicAuth(); !ok || user != "user" || password != testDummyKey {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		req := new(nodeRequest)
+		require.NoError(t, json.NewDecoder(r.Body).Decode(req))
+
+		var result string
+		switch req.Method {
+		case "getblockheader":
+			result = testNodeBlockHeader
+		case "getmerkleproof2":
+			assert.Equal(t, "merkleroot", req.Params[3])
+			result = `{"index":0,"txOrId":"` + req.Params[1].(string) + `","target":"` +
+				testBlockHeaders[1].HashMerkleRoot + `","nodes":[],"targetType":"merkleroot"}`
+		case "getrawtransaction":
+			if req.Params[0] != onChainExample1TxID {
+				w.WriteHeader(http.StatusInternalServerError)
+				_, _ = w.Write([]byte(`{"result":null,"error":{"code":-5,"message":"No such mempool or blockchain transaction"},"id":"1"}`))
+				return
+			}
+			result = `{"txid":"` + onChainExample1TxID + `","hex":"` + onChainExample1TxHex + `","blockhash":"` +
+				onChainExample1BlockHash + `","blockheight":723229,"confirmations":314}`
+		case "sendrawtransaction":
+			if req.Params[0] == onChainExample1TxHex {
+				w.WriteHeader(http.StatusInternalServerError)
+				_, _ = w.Write([]byte(`{"result":null,"error":{"code":-26,"message":"257: txn-already-known"},"id":"1"}`))
+				return
+			}
+			result = `"` + broadcastExample1TxID + `"`
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"result":null,"error":{"code":-32601,"message":"Method not found"},"id":"1"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"result":` + result + `,"error":null,"id":"1"}`))
+	}))
+}
+
+// TestNodeClient will test the node (JSON-RPC) client
+func TestNodeClient(t *testing.T) {
+	t.Parallel()
+
+	server := newTestNodeServer(t)
+	defer server.Close()
+
+	ctx := context.Background()
+	node := NewNodeClient(server.URL, "user", testDummyKey, server.Client())
+
+	t.Run("get block header", func(t *testing.T) {
+		header, err := node.GetBlockHeader(ctx, testBlockHeaders[1].Hash)
+		require.NoError(t, err)
+		assert.Equal(t, uint32(1), header.Height)
+
+		bh, err := header.blockHeader()
+		require.NoError(t, err)
+		assert.Equal(t, testBlockHeaders[1].Hash, hex.EncodeToString(bt.ReverseBytes(crypto.Sha256d(bh.Bytes()))))
+	})
+
+	t.Run("get merkle proof", func(t *testing.T) {
+		proof, err := node.GetMerkleProof(ctx, "", testBlockHeaders[1].HashMerkleRoot)
+		require.NoError(t, err)
+		assert.Equal(t, testBlockHeaders[1].HashMerkleRoot, proof.Target)
+		assert.Equal(t, "merkleroot", proof.TargetType)
+	})
+
+	t.Run("get raw transaction", func(t *testing.T) {
+		transaction, err := node.GetRawTransaction(ctx, onChainExample1TxID)
+		require.NoError(t, err)
+		assert.Equal(t, onChainExample1BlockHash, transaction.BlockHash)
+		assert.Equal(t, onChainExample1BlockHeight, transaction.BlockHeight)
+
+		_, err = node.GetRawTransaction(ctx, notFoundExample1TxID)
+		require.ErrorIs(t, err, ErrNodeRequest)
+	})
+
+	t.Run("send raw transaction", func(t *testing.T) {
+		txID, err := node.SendRawTransaction(ctx, broadcastExample1TxHex)
+		require.NoError(t, err)
+		assert.Equal(t, broadcastExample1TxID, txID)
+	})
+
+	t.Run("invalid credentials", func(t *testing.T) {
+		_, err := NewNodeClient(server.URL, "user", "", server.Client()).GetBlockHeader(ctx, testBlockHeaders[1].Hash)
+		require.ErrorIs(t, err, ErrNodeRequest)
+	})
+}
+
+// TestClient_Node will test the node as a chainstate provider
+func TestClient_Node(t *testing.T) {
+	t.Parallel()
+
+	server := newTestNodeServer(t)
+	defer server.Close()
+
+	ctx := context.Background()
+	c, err := NewClient(
+		ctx,
+		WithNodeRPC(server.URL, "user", testDummyKey),
+		WithExcludedProviders([]string{ProviderMAPI, ProviderNowNodes, ProviderWhatsOnChain}),
+		WithMinercraft(&MinerCraftBase{}),
+	)
+	require.NoError(t, err)
+
+	t.Run("broadcast", func(t *testing.T) {
+		provider, err := c.Broadcast(ctx, broadcastExample1TxID, broadcastExample1TxHex, defaultBroadcastTimeOut)
+		require.NoError(t, err)
+		assert.Equal(t, ProviderNode, provider)
+
+		// Already known
+		provider, err = c.Broadcast(ctx, onChainExample1TxID, onChainExample1TxHex, defaultBroadcastTimeOut)
+		require.NoError(t, err)
+		assert.Equal(t, ProviderNode, provider)
+	})
+
+	t.Run("query transaction", func(t *testing.T) {
+		info, err := c.QueryTransaction(ctx, onChainExample1TxID, RequiredOnChain, defaultQueryTimeOut)
+		require.NoError(t, err)
+		assert.Equal(t, ProviderNode, info.Provider)
+		assert.Equal(t, onChainExample1BlockHash, info.BlockHash)
+		assert.Equal(t, onChainExample1Confirmations, info.Confirmations)
+		require.NotNil(t, info.MerkleProof)
+		assert.Equal(t, onChainExample1TxID, info.MerkleProof.TxOrID)
+		assert.Equal(t, testBlockHeaders[1].HashMerkleRoot, info.MerkleProof.Target)
+
+		info, err = c.QueryTransactionFastest(ctx, onChainExample1TxID, RequiredOnChain, defaultQueryTimeOut)
+		require.NoError(t, err)
+		assert.Equal(t, ProviderNode, info.Provider)
+
+		_, err = c.QueryTransaction(ctx, notFoundExample1TxID, RequiredInMempool, defaultQueryTimeOut)
+		require.ErrorIs(t, err, ErrTransactionNotFound)
+	})
+}
@@ -0,0 +1,588 @@
+package chainstate
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/BuxOrg/bux/utils"
+	"github.com/libsv/go-bc"
+	"github.com/libsv/go-bk/crypto"
+	"github.com/libsv/go-bt/v2"
+	"github.com/libsv/go-bt/v2/bscript"
+	"github.com/libsv/go-bt/v2/bscript/interpreter"
+	zLogger "github.com/mrz1836/go-logger"
+	"github.com/mrz1836/go-nownodes"
+	"github.com/mrz1836/go-whatsonchain"
+	"github.com/tonicpow/go-minercraft/v2"
+)
+
+// offlineBits is the compact target of the mined blocks (regtest difficulty)
+const offlineBits = "207fffff"
+
+// OfflineClient is a local (in-process) chainstate: a regtest-like mempool and block simulator
+//
+// Broadcast transactions are validated (inputs must exist and be unspent, scripts must be valid) and
+// added to the mempool, blocks are only mined on demand (MineBlocks). No network is used.
+type OfflineClient struct {
+	addresses    map[string][]string            // Address => tx ids (P2PKH outputs & spent outputs)
+	blocks       []*offlineBlock                // Mined blocks (the height is the index)
+	debug        bool                           // For extra logs and additional debug information
+	feeUnit      *utils.FeeUnit                 // Fee unit returned for fee quotes
+	fundings     uint64                         // Number of funding transactions (makes each one unique)
+	handler      MonitorHandler                 // Receives the mined block headers
+	logger       zLogger.GormLoggerInterface    // Internal logger interface
+	mempool      []string                       // Unconfirmed tx ids (in the order they were accepted)
+	mu           sync.RWMutex                   // Guards the chain state
+	network      Network                        // Network used for the addresses etc.
+	recorded     int                            // Number of blocks sent to the monitor handler
+	spends       map[string]string              // Outpoint (txid:index) => spending tx id
+	transactions map[string]*offlineTransaction // Known transactions by tx id
+}
+
+// offlineBlock is a block mined by the offline client
+type offlineBlock struct {
+	hash   string
+	header bc.BlockHeader
+	height uint32
+	txIDs  []string
+}
+
+// offlineTransaction is a transaction known by the offline client
+type offlineTransaction struct {
+	block *offlineBlock // Nil if unconfirmed (mempool)
+	hex   string
+	tx    *bt.Tx
+}
+
+// NewOfflineClient will return a local chainstate client (only the genesis block has been mined)
+func NewOfflineClient(network Network) *OfflineClient {
+	c := &OfflineClient{
+		addresses:    make(map[string][]string),
+		feeUnit:      DefaultFee,
+		logger:       zLogger.NewGormLogger(false, 4),
+		network:      network,
+		spends:       make(map[string]string),
+		transactions: make(map[string]*offlineTransaction),
+	}
+	c.mineBlock()
+	return c
+}
+
+// SetMonitorHandler will set the handler that receives the mined block headers (IE: the bux monitor handler)
+func (c *OfflineClient) SetMonitorHandler(handler MonitorHandler) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.handler = handler
+	c.recorded = 0
+}
+
+// Fund will create a transaction paying the satoshis to the (P2PKH) address
+//
+// The funding transaction has no inputs (like a coinbase), it is added to the mempool and
+// confirmed in the next mined block
+func (c *OfflineClient) Fund(address string, satoshis uint64) (*bt.Tx, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.fundings++
+	tx := newOfflineCoinbase(append([]byte("fund"), uint64Bytes(c.fundings)...))
+	if err := tx.AddP2PKHOutputFromAddress(address, satoshis); err != nil {
+		return nil, err
+	}
+	c.addTransaction(tx)
+	return tx, nil
+}
+
+// MineBlocks will mine the number of blocks (the first block contains the mempool transactions)
+//
+// After mining, all block headers not yet sent to the monitor handler (if set) are sent in order,
+// starting with the genesis block
+func (c *OfflineClient) MineBlocks(ctx context.Context, count int) ([]*BlockHeaderInfo, error) {
+	c.mu.Lock()
+	infos := make([]*BlockHeaderInfo, 0, count)
+	for i := 0; i < count; i++ {
+		infos = append(infos, c.mineBlock().info())
+	}
+	handler := c.handler
+	pending := c.blocks[c.recorded:]
+	c.mu.Unlock()
+
+	if handler == nil {
+		return infos, nil
+	}
+	for _, block := range pending {
+		if err := handler.RecordBlockHeader(ctx, block.height, block.header); err != nil {
+			return infos, err
+		}
+		c.mu.Lock()
+		c.recorded = int(block.height) + 1
+		c.mu.Unlock()
+	}
+	return infos, nil
+}
+
+// BroadcastWithAttempts will validate the transaction and add it to the mempool (a single attempt, never late)
+func (c *OfflineClient) BroadcastWithAttempts(ctx context.Context, id, txHex string,
+	timeout time.Duration, _ BroadcastAttemptsHandler) (string, []*BroadcastAttempt, error) {
+	attempt := &BroadcastAttempt{Provider: ProviderOffline, RequestedAt: time.Now().UTC()}
+	provider, err := c.Broadcast(ctx, id, txHex, timeout)
+	attempt.Latency = time.Since(attempt.RequestedAt)
+	attempt.complete(err)
+	return provider, []*BroadcastAttempt{attempt}, err
+}
+
+// Broadcast will validate the transaction and add it to the mempool
+func (c *OfflineClient) Broadcast(_ context.Context, id, txHex string, _ time.Duration) (string, error) {
+	// Basic validation
+	if len(id) < 50 {
+		return ProviderOffline, ErrInvalidTransactionID
+	} else if len(txHex) == 0 {
+		return ProviderOffline, ErrInvalidTransactionHex
+	}
+
+	tx, err := bt.NewTxFromString(txHex)
+	if err != nil {
+		return ProviderOffline, ErrInvalidTransactionHex
+	} else if tx.TxID() != id {
+		return ProviderOffline, ErrTransactionIDMismatch
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// Already known (same as a miner accepting a transaction that is already in the mempool)
+	if c.transactions[id] != nil {
+		return ProviderOffline, nil
+	}
+
+	if err = c.validateTransaction(tx); err != nil {
+		return ProviderOffline, err
+	}
+	c.addTransaction(tx)
+	c.DebugLog(fmt.Sprintf("offline: accepted transaction %s", id))
+	return ProviderOffline, nil
+}
+
+// QuerySpendingTransaction will return the id of the transaction spending the output
+func (c *OfflineClient) QuerySpendingTransaction(_ context.Context, id string, index uint32,
+	lockingScript string, _ time.Duration) (string, error) {
+
+	// Basic validation
+	if len(id) < 50 {
+		return "", ErrInvalidTransactionID
+	} else if len(lockingScript) == 0 {
+		return "", ErrInvalidLockingScript
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	spendingID, ok := c.spends[outpointKey(id, index)]
+	if !ok {
+		return "", ErrOutputUnspent
+	}
+	return spendingID, nil
+}
+
+// QueryTransaction will return the transaction info (mempool or mined)
+func (c *OfflineClient) QueryTransaction(_ context.Context, id string, requiredIn RequiredIn,
+	_ time.Duration) (*TransactionInfo, error) {
+
+	// Basic validation
+	if len(id) < 50 {
+		return nil, ErrInvalidTransactionID
+	} else if requiredIn != RequiredOnChain && requiredIn != RequiredInMempool {
+		return nil, ErrInvalidRequirements
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	transaction := c.transactions[id]
+	if transaction == nil || (transaction.block == nil && requiredIn == RequiredOnChain) {
+		return nil, ErrTransactionNotFound
+	}
+
+	info := &TransactionInfo{
+		ID:       id,
+		Provider: ProviderOffline,
+	}
+	if transaction.block != nil {
+		info.BlockHash = transaction.block.hash
+		info.BlockHeight = int64(transaction.block.height)
+		info.Confirmations = int64(c.tip().height-transaction.block.height) + 1
+	}
+	return info, nil
+}
+
+// QueryTransactionFastest will return the transaction info (same as QueryTransaction, there is a single source)
+func (c *OfflineClient) QueryTransactionFastest(ctx context.Context, id string, requiredIn RequiredIn,
+	timeout time.Duration) (*TransactionInfo, error) {
+	return c.QueryTransaction(ctx, id, requiredIn, timeout)
+}
+
+// AddressHistory will return the address history provider (using the offline transactions)
+func (c *OfflineClient) AddressHistory() AddressHistoryProvider {
+	return &offlineHistoryProvider{client: c}
+}
+
+// BlockHeadersSource will return the block headers source (using the mined blocks)
+func (c *OfflineClient) BlockHeadersSource() BlockHeadersSource {
+	return &offlineHeadersSource{client: c}
+}
+
+// Minercraft will return nil (no miners)
+func (c *OfflineClient) Minercraft() minercraft.ClientInterface {
+	return nil
+}
+
+// Node will return nil (no network)
+func (c *OfflineClient) Node() NodeInterface {
+	return nil
+}
+
+// NowNodes will return nil (no network)
+func (c *OfflineClient) NowNodes() nownodes.ClientInterface {
+	return nil
+}
+
+// WhatsOnChain will return nil (no network)
+func (c *OfflineClient) WhatsOnChain() whatsonchain.ClientInterface {
+	return nil
+}
+
+// BroadcastMiners will return nil (no miners)
+func (c *OfflineClient) BroadcastMiners() []*Miner {
+	return nil
+}
+
+// QueryMiners will return nil (no miners)
+func (c *OfflineClient) QueryMiners() []*Miner {
+	return nil
+}
+
+// ValidateMiners does nothing (no miners)
+func (c *OfflineClient) ValidateMiners(_ context.Context) {}
+
+// FeeUnit will return the fee unit (DefaultFee)
+func (c *OfflineClient) FeeUnit() *utils.FeeUnit {
+	return c.feeUnit
+}
+
+// Close does nothing (the state is kept in memory)
+func (c *OfflineClient) Close(_ context.Context) {}
+
+// Debug will set the debug flag
+func (c *OfflineClient) Debug(on bool) {
+	c.debug = on
+}
+
+// DebugLog will display verbose logs
+func (c *OfflineClient) DebugLog(text string) {
+	if c.debug {
+		c.logger.Info(context.Background(), text)
+	}
+}
+
+// HTTPClient will return nil (no network)
+func (c *OfflineClient) HTTPClient() HTTPInterface {
+	return nil
+}
+
+// IsDebug will return the debug flag
+func (c *OfflineClient) IsDebug() bool {
+	return c.debug
+}
+
+// IsNewRelicEnabled will return false
+func (c *OfflineClient) IsNewRelicEnabled() bool {
+	return false
+}
+
+// Monitor will return nil (block headers are sent to the monitor handler when mining)
+func (c *OfflineClient) Monitor() MonitorService {
+	return nil
+}
+
+// Network will return the network
+func (c *OfflineClient) Network() Network {
+	return c.network
+}
+
+// PowLimit will return the compact target (bits) of the mined blocks (regtest difficulty)
+func (c *OfflineClient) PowLimit() string {
+	return offlineBits
+}
+
+// DifficultyRules will return the difficulty rules of the mined blocks (regtest: the difficulty never changes)
+func (c *OfflineClient) DifficultyRules() *DifficultyRules {
+	return &DifficultyRules{NoRetargeting: true, PowLimit: offlineBits}
+}
+
+// ProviderStats will return nil (no providers)
+func (c *OfflineClient) ProviderStats() []*ProviderStats {
+	return nil
+}
+
+// QueryTimeout will return the default query timeout
+func (c *OfflineClient) QueryTimeout() time.Duration {
+	return defaultQueryTimeOut
+}
+
+// validateTransaction will check the inputs (existing & unspent), the scripts and the amounts
+func (c *OfflineClient) validateTransaction(tx *bt.Tx) error {
+	if len(tx.Inputs) == 0 || len(tx.Outputs) == 0 || tx.IsCoinbase() {
+		return ErrBroadcastRejected
+	}
+
+	var totalInputs uint64
+	spending := make(map[string]bool, len(tx.Inputs))
+	for index, input := range tx.Inputs {
+		key := outpointKey(input.PreviousTxIDStr(), input.PreviousTxOutIndex)
+		previous := c.transactions[input.PreviousTxIDStr()]
+		if previous == nil || int(input.PreviousTxOutIndex) >= len(previous.tx.Outputs) {
+			return ErrTransactionInputsNotFound
+		} else if spending[key] {
+			return ErrBroadcastRejected
+		}
+		spending[key] = true
+
+		if spendingID, ok := c.spends[key]; ok {
+			if c.transactions[spendingID].block != nil {
+				return ErrBroadcastDoubleSpend
+			}
+			return ErrBroadcastMempoolConflict
+		}
+
+		output := previous.tx.Outputs[input.PreviousTxOutIndex]
+		if err := interpreter.NewEngine().Execute(
+			interpreter.WithTx(tx, index, output),
+			interpreter.WithForkID(),
+			interpreter.WithAfterGenesis(),
+		); err != nil {
+			return fmt.Errorf("%w: input %d: %s", ErrBroadcastRejected, index, err.Error())
+		}
+		totalInputs += output.Satoshis
+	}
+
+	if tx.TotalOutputSatoshis() > totalInputs {
+		return ErrBroadcastRejected
+	}
+	return nil
+}
+
+// addTransaction will add the transaction to the mempool (the inputs have been validated)
+func (c *OfflineClient) addTransaction(tx *bt.Tx) {
+	id := tx.TxID()
+	c.transactions[id] = &offlineTransaction{
+		hex: tx.String(),
+		tx:  tx,
+	}
+	c.mempool = append(c.mempool, id)
+
+	if !tx.IsCoinbase() {
+		for _, input := range tx.Inputs {
+			c.spends[outpointKey(input.PreviousTxIDStr(), input.PreviousTxOutIndex)] = id
+			previous := c.transactions[input.PreviousTxIDStr()].tx
+			c.addAddresses(id, previous.Outputs[input.PreviousTxOutIndex])
+		}
+	}
+	for _, output := range tx.Outputs {
+		c.addAddresses(id, output)
+	}
+}
+
+// addAddresses will add the transaction to the history of the addresses of the output
+func (c *OfflineClient) addAddresses(id string, output *bt.Output) {
+	if !output.LockingScript.IsP2PKH() {
+		return
+	}
+	addresses, err := output.LockingScript.Addresses()
+	if err != nil {
+		return
+	}
+	for _, address := range addresses {
+		if history := c.addresses[address]; len(history) == 0 || history[len(history)-1] != id {
+			c.addresses[address] = append(history, id)
+		}
+	}
+}
+
+// mineBlock will mine a block with a coinbase and the mempool transactions
+func (c *OfflineClient) mineBlock() *offlineBlock {
+	block := &offlineBlock{
+		header: bc.BlockHeader{
+			Version:       1,
+			HashPrevBlock: make([]byte, 32),
+			Time:          uint32(time.Now().Unix()),
+		},
+		height: uint32(len(c.blocks)),
+	}
+	block.header.Bits, _ = hex.DecodeString(offlineBits)
+	if len(c.blocks) > 0 {
+		previous := c.tip()
+		block.header.HashPrevBlock, _ = hex.DecodeString(previous.hash)
+		if block.header.Time <= previous.header.Time {
+			block.header.Time = previous.header.Time + 1
+		}
+	}
+
+	// The coinbase makes each block (and the merkle root) unique
+	coinbase := newOfflineCoinbase(uint64Bytes(uint64(block.height)))
+	_ = coinbase.AddOpReturnOutput([]byte(ProviderOffline))
+	c.transactions[coinbase.TxID()] = &offlineTransaction{hex: coinbase.String(), tx: coinbase}
+	block.txIDs = append([]string{coinbase.TxID()}, c.mempool...)
+	c.mempool = nil
+
+	merkleRoot, _ := bc.BuildMerkleRoot(block.txIDs)
+	block.header.HashMerkleRoot, _ = hex.DecodeString(merkleRoot)
+
+	// Proof of work (about half of the hashes are valid at regtest difficulty)
+	for !block.header.Valid() {
+		block.header.Nonce++
+	}
+	block.hash = hex.EncodeToString(bt.ReverseBytes(crypto.Sha256d(block.header.Bytes())))
+
+	for _, id := range block.txIDs {
+		c.transactions[id].block = block
+	}
+	c.blocks = append(c.blocks, block)
+	return block
+}
+
+// tip will return the last mined block
+func (c *OfflineClient) tip() *offlineBlock {
+	return c.blocks[len(c.blocks)-1]
+}
+
+// info will return the block header info of the block
+func (b *offlineBlock) info() *BlockHeaderInfo {
+	return &BlockHeaderInfo{
+		Bits:           hex.EncodeToString(b.header.Bits),
+		Hash:           b.hash,
+		HashMerkleRoot: hex.EncodeToString(b.header.HashMerkleRoot),
+		HashPrevBlock:  hex.EncodeToString(b.header.HashPrevBlock),
+		Height:         b.height,
+		Nonce:          b.header.Nonce,
+		Time:           b.header.Time,
+		Version:        b.header.Version,
+	}
+}
+
+// newOfflineCoinbase will return a transaction with a coinbase input (the data makes the tx id unique)
+func newOfflineCoinbase(data []byte) *bt.Tx {
+	unlockingScript := &bscript.Script{}
+	_ = unlockingScript.AppendPushData(data)
+
+	input := &bt.Input{
+		PreviousTxOutIndex: bt.DefaultSequenceNumber,
+		SequenceNumber:     bt.DefaultSequenceNumber,
+		UnlockingScript:    unlockingScript,
+	}
+	_ = input.PreviousTxIDAdd(make([]byte, 32))
+
+	tx := bt.NewTx()
+	tx.Inputs = append(tx.Inputs, input)
+	return tx
+}
+
+// outpointKey will return the key of the output (txid:index)
+func outpointKey(id string, index uint32) string {
+	return fmt.Sprintf("%s:%d", id, index)
+}
+
+// uint64Bytes will return the little endian bytes of the number
+func uint64Bytes(num uint64) []byte {
+	b := make([]byte, 8)
+	binary.LittleEndian.PutUint64(b, num)
+	return b
+}
+
+// offlineHistoryProvider is the address history provider using the offline transactions
+type offlineHistoryProvider struct {
+	client *OfflineClient
+}
+
+// AddressHistory will return the transaction history of an address
+func (p *offlineHistoryProvider) AddressHistory(_ context.Context, address string) ([]*AddressHistoryRecord, error) {
+	p.client.mu.RLock()
+	defer p.client.mu.RUnlock()
+
+	history := p.client.addresses[address]
+	records := make([]*AddressHistoryRecord, 0, len(history))
+	for _, txID := range history {
+		records = append(records, &AddressHistoryRecord{
+			BlockHeight: p.client.transactions[txID].blockHeight(),
+			TxID:        txID,
+		})
+	}
+	return records, nil
+}
+
+// Name will return the name of the provider
+func (p *offlineHistoryProvider) Name() string {
+	return ProviderOffline
+}
+
+// RawTransactions will return the raw transactions for the given tx ids
+func (p *offlineHistoryProvider) RawTransactions(_ context.Context, txIDs []string) ([]*RawTransaction, error) {
+	p.client.mu.RLock()
+	defer p.client.mu.RUnlock()
+
+	transactions := make([]*RawTransaction, 0, len(txIDs))
+	for _, txID := range txIDs {
+		transaction := p.client.transactions[txID]
+		if transaction == nil {
+			return nil, ErrTransactionNotFound
+		}
+		transactions = append(transactions, &RawTransaction{
+			BlockHeight: transaction.blockHeight(),
+			Hex:         transaction.hex,
+			TxID:        txID,
+		})
+	}
+	return transactions, nil
+}
+
+// blockHeight will return the height of the block (zero if unconfirmed)
+func (t *offlineTransaction) blockHeight() int64 {
+	if t.block == nil {
+		return 0
+	}
+	return int64(t.block.height)
+}
+
+// offlineHeadersSource is the block headers source using the mined blocks
+type offlineHeadersSource struct {
+	client *OfflineClient
+}
+
+// GetHeadersByHeight will return the block headers starting at the given height
+func (s *offlineHeadersSource) GetHeadersByHeight(_ context.Context, height uint32,
+	count int) ([]*BlockHeaderInfo, error) {
+
+	s.client.mu.RLock()
+	defer s.client.mu.RUnlock()
+
+	headers := make([]*BlockHeaderInfo, 0, count)
+	for num := int(height); num < len(s.client.blocks) && len(headers) < count; num++ {
+		headers = append(headers, s.client.blocks[num].info())
+	}
+	return headers, nil
+}
+
+// GetTipHeight will return the height of the last mined block
+func (s *offlineHeadersSource) GetTipHeight(_ context.Context) (uint32, error) {
+	s.client.mu.RLock()
+	defer s.client.mu.RUnlock()
+	return s.client.tip().height, nil
+}
+
+// Name will return the name of the source
+func (s *offlineHeadersSource) Name() string {
+	return ProviderOffline
+}
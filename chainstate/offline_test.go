@@ -0,0 +1,194 @@
+package chainstate
+
+import (
+	"context"
+	"encoding/hex"
+	"testing"
+
+	"github.com/libsv/go-bc"
+	"github.com/libsv/go-bk/bec"
+	"github.com/libsv/go-bt/v2"
+	"github.com/libsv/go-bt/v2/bscript"
+	"github.com/libsv/go-bt/v2/unlocker"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testOfflineHandler records the block headers sent by the offline client
+type testOfflineHandler struct {
+	MonitorHandler
+	headers []bc.BlockHeader
+	heights []uint32
+}
+
+// RecordBlockHeader will record the block header
+func (h *testOfflineHandler) RecordBlockHeader(_ context.Context, height uint32, bh bc.BlockHeader) error {
+	h.headers = append(h.headers, bh)
+	h.heights = append(h.heights, height)
+	return nil
+}
+
+// newTestOfflineKey will return a new private key and its address
+func newTestOfflineKey(t *testing.T) (*bec.PrivateKey, string) {
+	key, err := bec.NewPrivateKey(bec.S256())
+	require.NoError(t, err)
+	address, err := bscript.NewAddressFromPublicKey(key.PubKey(), true)
+	require.NoError(t, err)
+	return key, address.AddressString
+}
+
+// newTestOfflineSpend will return a signed transaction spending the first output of the funding transaction
+func newTestOfflineSpend(t *testing.T, key *bec.PrivateKey, funding *bt.Tx, address string,
+	satoshis uint64) *bt.Tx {
+
+	tx := bt.NewTx()
+	require.NoError(t, tx.From(
+		funding.TxID(), 0, funding.Outputs[0].LockingScript.String(), funding.Outputs[0].Satoshis,
+	))
+	require.NoError(t, tx.AddP2PKHOutputFromAddress(address, satoshis))
+	require.NoError(t, tx.FillAllInputs(context.Background(), &unlocker.Getter{PrivateKey: key}))
+	return tx
+}
+
+// TestOfflineClient will test the offline chainstate client
+func TestOfflineClient(t *testing.T) {
+	t.Parallel()
+
+	t.Run("implements the client interface", func(t *testing.T) {
+		var c ClientInterface = NewOfflineClient(MainNet)
+		assert.Equal(t, MainNet, c.Network())
+		assert.Equal(t, DefaultFee, c.FeeUnit())
+		assert.Nil(t, c.Monitor())
+		assert.Nil(t, c.WhatsOnChain())
+		assert.Equal(t, ProviderOffline, c.AddressHistory().Name())
+		assert.Equal(t, ProviderOffline, c.BlockHeadersSource().Name())
+	})
+
+	t.Run("broadcast, mine and query", func(t *testing.T) {
+		ctx := context.Background()
+		c := NewOfflineClient(MainNet)
+		handler := &testOfflineHandler{}
+		c.SetMonitorHandler(handler)
+
+		key, address := newTestOfflineKey(t)
+		_, otherAddress := newTestOfflineKey(t)
+		funding, err := c.Fund(address, 10000)
+		require.NoError(t, err)
+
+		tx := newTestOfflineSpend(t, key, funding, otherAddress, 9000)
+		provider, err := c.Broadcast(ctx, tx.TxID(), tx.String(), defaultQueryTimeOut)
+		require.NoError(t, err)
+		assert.Equal(t, ProviderOffline, provider)
+
+		// In the mempool
+		info, err := c.QueryTransaction(ctx, tx.TxID(), RequiredInMempool, defaultQueryTimeOut)
+		require.NoError(t, err)
+		assert.Equal(t, tx.TxID(), info.ID)
+		assert.Empty(t, info.BlockHash)
+
+		_, err = c.QueryTransaction(ctx, tx.TxID(), RequiredOnChain, defaultQueryTimeOut)
+		require.ErrorIs(t, err, ErrTransactionNotFound)
+
+		var spendingID string
+		spendingID, err = c.QuerySpendingTransaction(
+			ctx, funding.TxID(), 0, funding.Outputs[0].LockingScript.String(), defaultQueryTimeOut,
+		)
+		require.NoError(t, err)
+		assert.Equal(t, tx.TxID(), spendingID)
+
+		// Mine two blocks (the first one contains the transactions)
+		var headers []*BlockHeaderInfo
+		headers, err = c.MineBlocks(ctx, 2)
+		require.NoError(t, err)
+		require.Len(t, headers, 2)
+		require.Len(t, handler.headers, 3) // Including the genesis block
+		assert.Equal(t, []uint32{0, 1, 2}, handler.heights)
+		assert.Equal(t, uint32(1), headers[0].Height)
+		assert.Equal(t, headers[0].Hash, headers[1].HashPrevBlock)
+		assert.True(t, handler.headers[1].Valid())
+		assert.Equal(t, headers[0].HashMerkleRoot, hex.EncodeToString(handler.headers[1].HashMerkleRoot))
+
+		_, err = c.MineBlocks(ctx, 1)
+		require.NoError(t, err)
+		require.Len(t, handler.headers, 4)
+
+		info, err = c.QueryTransactionFastest(ctx, tx.TxID(), RequiredOnChain, defaultQueryTimeOut)
+		require.NoError(t, err)
+		assert.Equal(t, headers[0].Hash, info.BlockHash)
+		assert.Equal(t, int64(1), info.BlockHeight)
+		assert.Equal(t, int64(3), info.Confirmations)
+
+		// Block headers source
+		var tip uint32
+		tip, err = c.BlockHeadersSource().GetTipHeight(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, uint32(3), tip)
+
+		var infos []*BlockHeaderInfo
+		infos, err = c.BlockHeadersSource().GetHeadersByHeight(ctx, 1, 2)
+		require.NoError(t, err)
+		assert.Equal(t, headers, infos)
+
+		// Address history
+		var records []*AddressHistoryRecord
+		records, err = c.AddressHistory().AddressHistory(ctx, address)
+		require.NoError(t, err)
+		require.Len(t, records, 2)
+		assert.Equal(t, funding.TxID(), records[0].TxID)
+		assert.Equal(t, tx.TxID(), records[1].TxID)
+		assert.Equal(t, int64(1), records[1].BlockHeight)
+
+		var transactions []*RawTransaction
+		transactions, err = c.AddressHistory().RawTransactions(ctx, []string{tx.TxID()})
+		require.NoError(t, err)
+		require.Len(t, transactions, 1)
+		assert.Equal(t, tx.String(), transactions[0].Hex)
+	})
+
+	t.Run("invalid transactions", func(t *testing.T) {
+		ctx := context.Background()
+		c := NewOfflineClient(MainNet)
+
+		key, address := newTestOfflineKey(t)
+		otherKey, otherAddress := newTestOfflineKey(t)
+		funding, err := c.Fund(address, 10000)
+		require.NoError(t, err)
+
+		_, err = c.Broadcast(ctx, "invalid", "", defaultQueryTimeOut)
+		require.ErrorIs(t, err, ErrInvalidTransactionID)
+
+		// Wrong key (invalid script)
+		tx := newTestOfflineSpend(t, otherKey, funding, otherAddress, 9000)
+		_, err = c.Broadcast(ctx, tx.TxID(), tx.String(), defaultQueryTimeOut)
+		require.ErrorIs(t, err, ErrBroadcastRejected)
+
+		// More outputs than inputs
+		tx = newTestOfflineSpend(t, key, funding, otherAddress, 20000)
+		_, err = c.Broadcast(ctx, tx.TxID(), tx.String(), defaultQueryTimeOut)
+		require.ErrorIs(t, err, ErrBroadcastRejected)
+
+		// Unknown input
+		unknown := newTestOfflineSpend(t, key, funding, otherAddress, 9000)
+		tx = newTestOfflineSpend(t, otherKey, unknown, address, 8000)
+		_, err = c.Broadcast(ctx, tx.TxID(), tx.String(), defaultQueryTimeOut)
+		require.ErrorIs(t, err, ErrTransactionInputsNotFound)
+
+		// Conflicts (mempool & mined)
+		tx = newTestOfflineSpend(t, key, funding, otherAddress, 9000)
+		_, err = c.Broadcast(ctx, tx.TxID(), tx.String(), defaultQueryTimeOut)
+		require.NoError(t, err)
+
+		conflict := newTestOfflineSpend(t, key, funding, otherAddress, 8000)
+		_, err = c.Broadcast(ctx, conflict.TxID(), conflict.String(), defaultQueryTimeOut)
+		require.ErrorIs(t, err, ErrBroadcastMempoolConflict)
+
+		_, err = c.MineBlocks(ctx, 1)
+		require.NoError(t, err)
+		_, err = c.Broadcast(ctx, conflict.TxID(), conflict.String(), defaultQueryTimeOut)
+		require.ErrorIs(t, err, ErrBroadcastDoubleSpend)
+
+		// Already known
+		_, err = c.Broadcast(ctx, tx.TxID(), tx.String(), defaultQueryTimeOut)
+		require.NoError(t, err)
+	})
+}
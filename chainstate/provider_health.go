@@ -0,0 +1,300 @@
+package chainstate
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RoutingPolicy is the policy for routing broadcasts and queries to the providers
+type RoutingPolicy string
+
+const (
+	// RoutingAll will use all providers at once (the first valid result wins)
+	RoutingAll RoutingPolicy = "all"
+
+	// RoutingFastestHealthy will use one provider at a time, the healthiest and fastest providers first
+	RoutingFastestHealthy RoutingPolicy = "fastest_healthy"
+
+	// RoutingPrimaryFallback will use one provider at a time, in the default order of the providers
+	RoutingPrimaryFallback RoutingPolicy = "primary_fallback"
+)
+
+// CircuitState is the state of the circuit breaker of a provider
+type CircuitState string
+
+const (
+	// CircuitClosed is a healthy provider (requests are allowed)
+	CircuitClosed CircuitState = "closed"
+
+	// CircuitHalfOpen is a failing provider after the cooldown (requests are allowed, the next failure opens it again)
+	CircuitHalfOpen CircuitState = "half_open"
+
+	// CircuitOpen is a failing provider (requests are skipped until the cooldown has passed)
+	CircuitOpen CircuitState = "open"
+)
+
+var (
+	// providerRateLimitErrors are a list of errors when the provider is rate limiting the requests
+	providerRateLimitErrors = []string{
+		"429",
+		"rate limit",
+		"too many requests",
+	}
+
+	// providerNotFoundErrors are a list of errors when the provider does not know the transaction (a valid response)
+	providerNotFoundErrors = []string{
+		"not found",
+		"no such mempool or blockchain transaction",
+		"unknown transaction",
+	}
+)
+
+// ProviderStats is the health of a provider (IE: for dashboards)
+type ProviderStats struct {
+	Circuit             CircuitState  `json:"circuit"`
+	ConsecutiveFailures int           `json:"consecutive_failures"`
+	ErrorRate           float64       `json:"error_rate"` // Failed requests in the recent requests (0-1)
+	Errors              uint64        `json:"errors"`
+	LastError           string        `json:"last_error,omitempty"`
+	LastErrorAt         time.Time     `json:"last_error_at,omitempty"`
+	LastSuccessAt       time.Time     `json:"last_success_at,omitempty"`
+	LatencyP50          time.Duration `json:"latency_p50"` // Latency percentiles of the recent requests
+	LatencyP90          time.Duration `json:"latency_p90"`
+	LatencyP99          time.Duration `json:"latency_p99"`
+	Name                string        `json:"name"`
+	RateLimited         uint64        `json:"rate_limited"`
+	Requests            uint64        `json:"requests"`
+}
+
+// providerHealth tracks the health of the providers (latency, errors, circuit breakers)
+type providerHealth struct {
+	cooldown  time.Duration              // Time a circuit stays open
+	mu        sync.RWMutex               // Guards the providers
+	providers map[string]*providerRecord // Health by provider name
+	threshold int                        // Consecutive failures that open the circuit
+	window    int                        // Number of recent requests used for the latency and error rate
+}
+
+// providerRecord is the health of a single provider
+type providerRecord struct {
+	consecutiveFailures int
+	errors              uint64
+	lastError           string
+	lastErrorAt         time.Time
+	lastSuccessAt       time.Time
+	openedAt            time.Time
+	rateLimited         uint64
+	requests            uint64
+	samples             []providerSample // Ring buffer of the recent requests
+}
+
+// providerSample is a recent request of a provider
+type providerSample struct {
+	failed  bool
+	latency time.Duration
+}
+
+// newProviderHealth will return the health tracker of the providers
+func newProviderHealth(threshold int, cooldown time.Duration, window int) *providerHealth {
+	return &providerHealth{
+		cooldown:  cooldown,
+		providers: make(map[string]*providerRecord),
+		threshold: threshold,
+		window:    window,
+	}
+}
+
+// record will record the result of a request to the provider
+//
+// Only provider failures (IE: timeouts, server errors, rate limits) count as errors, valid responses
+// (IE: transaction not found, double spend) are successful requests. Cancelled requests are ignored.
+func (h *providerHealth) record(name string, latency time.Duration, err error) {
+	if h == nil || errors.Is(err, context.Canceled) ||
+		(err != nil && strings.Contains(err.Error(), context.Canceled.Error())) {
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	r := h.providers[name]
+	if r == nil {
+		r = &providerRecord{}
+		h.providers[name] = r
+	}
+
+	now := time.Now()
+	failed := isProviderFailure(err)
+	r.requests++
+	sample := providerSample{failed: failed, latency: latency}
+	if len(r.samples) < h.window {
+		r.samples = append(r.samples, sample)
+	} else {
+		r.samples[(r.requests-1)%uint64(h.window)] = sample
+	}
+
+	if !failed {
+		r.consecutiveFailures = 0
+		r.lastSuccessAt = now
+		return
+	}
+
+	r.errors++
+	r.consecutiveFailures++
+	r.lastError = err.Error()
+	r.lastErrorAt = now
+	if doesErrorContain(err.Error(), providerRateLimitErrors) {
+		r.rateLimited++
+	}
+
+	// Open (or re-open after a failed half-open request) the circuit
+	if r.consecutiveFailures >= h.threshold {
+		r.openedAt = now
+	}
+}
+
+// route will return the indexes of the providers (by name) to use, in order
+//
+// Providers with an open circuit are skipped, unless all circuits are open
+func (h *providerHealth) route(policy RoutingPolicy, names []string) []int {
+	indexes := make([]int, 0, len(names))
+	for index, name := range names {
+		if h.state(name) != CircuitOpen {
+			indexes = append(indexes, index)
+		}
+	}
+	if len(indexes) == 0 {
+		for index := range names {
+			indexes = append(indexes, index)
+		}
+	}
+
+	if policy == RoutingFastestHealthy && h != nil {
+		h.mu.RLock()
+		defer h.mu.RUnlock()
+		sort.SliceStable(indexes, func(i, j int) bool {
+			a, b := h.providers[names[indexes[i]]].stats(), h.providers[names[indexes[j]]].stats()
+			if a.ErrorRate != b.ErrorRate {
+				return a.ErrorRate < b.ErrorRate
+			}
+			return a.LatencyP50 < b.LatencyP50
+		})
+	}
+	return indexes
+}
+
+// state will return the state of the circuit breaker of the provider
+func (h *providerHealth) state(name string) CircuitState {
+	if h == nil {
+		return CircuitClosed
+	}
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.circuit(h.providers[name])
+}
+
+// circuit will return the state of the circuit breaker (the lock must be held)
+func (h *providerHealth) circuit(r *providerRecord) CircuitState {
+	if r == nil || r.consecutiveFailures < h.threshold {
+		return CircuitClosed
+	} else if time.Since(r.openedAt) < h.cooldown {
+		return CircuitOpen
+	}
+	return CircuitHalfOpen
+}
+
+// stats will return the health of all providers (sorted by name)
+func (h *providerHealth) stats() []*ProviderStats {
+	if h == nil {
+		return nil
+	}
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	stats := make([]*ProviderStats, 0, len(h.providers))
+	for name, r := range h.providers {
+		s := r.stats()
+		s.Circuit = h.circuit(r)
+		s.Name = name
+		stats = append(stats, s)
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		return stats[i].Name < stats[j].Name
+	})
+	return stats
+}
+
+// stats will return the health of the provider (without the name and the circuit state)
+func (r *providerRecord) stats() *ProviderStats {
+	s := &ProviderStats{}
+	if r == nil {
+		return s
+	}
+
+	s.ConsecutiveFailures = r.consecutiveFailures
+	s.Errors = r.errors
+	s.LastError = r.lastError
+	s.LastErrorAt = r.lastErrorAt
+	s.LastSuccessAt = r.lastSuccessAt
+	s.RateLimited = r.rateLimited
+	s.Requests = r.requests
+
+	if len(r.samples) == 0 {
+		return s
+	}
+	latencies := make([]time.Duration, 0, len(r.samples))
+	var failed int
+	for _, sample := range r.samples {
+		latencies = append(latencies, sample.latency)
+		if sample.failed {
+			failed++
+		}
+	}
+	sort.Slice(latencies, func(i, j int) bool {
+		return latencies[i] < latencies[j]
+	})
+	s.ErrorRate = float64(failed) / float64(len(r.samples))
+	s.LatencyP50 = percentile(latencies, 50)
+	s.LatencyP90 = percentile(latencies, 90)
+	s.LatencyP99 = percentile(latencies, 99)
+	return s
+}
+
+// percentile will return the percentile of the sorted latencies (nearest rank)
+func percentile(latencies []time.Duration, p int) time.Duration {
+	rank := (p*len(latencies) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return latencies[rank-1]
+}
+
+// isValidRoutingPolicy will return true if the routing policy is known
+func isValidRoutingPolicy(policy RoutingPolicy) bool {
+	return policy == RoutingAll || policy == RoutingFastestHealthy || policy == RoutingPrimaryFallback
+}
+
+// isProviderFailure will return true if the error is a failure of the provider (not a valid response)
+func isProviderFailure(err error) bool {
+	if err == nil || errors.Is(err, ErrTransactionIDMismatch) {
+		return false
+	}
+	message := err.Error()
+	return !doesErrorContain(message, providerNotFoundErrors) &&
+		!doesErrorContain(message, broadcastQuestionableErrors) &&
+		!isBroadcastRejection(message)
+}
+
+// isBroadcastRejection will return true if the provider rejected the transaction (a valid response)
+func isBroadcastRejection(message string) bool {
+	return doesErrorContain(message, broadcastDoubleSpendErrors) ||
+		doesErrorContain(message, broadcastConflictErrors) ||
+		doesErrorContain(message, broadcastTooLongChainErrors) ||
+		doesErrorContain(message, broadcastFeeErrors)
+}
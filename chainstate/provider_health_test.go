@@ -0,0 +1,188 @@
+package chainstate
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestProviderHealth will test the health tracking and the circuit breakers of the providers
+func TestProviderHealth(t *testing.T) {
+	t.Parallel()
+
+	t.Run("nil health", func(t *testing.T) {
+		var h *providerHealth
+		h.record(ProviderNode, time.Millisecond, errors.New("failed"))
+		assert.Nil(t, h.stats())
+		assert.Equal(t, []int{0, 1}, h.route(RoutingFastestHealthy, []string{ProviderNode, ProviderWhatsOnChain}))
+	})
+
+	t.Run("valid responses are not failures", func(t *testing.T) {
+		h := newProviderHealth(1, time.Minute, 10)
+		h.record(ProviderNode, time.Millisecond, nil)
+		h.record(ProviderNode, time.Millisecond, errors.New("-5: No such mempool or blockchain transaction"))
+		h.record(ProviderNode, time.Millisecond, errors.New("-26: 258: txn-mempool-conflict"))
+		h.record(ProviderNode, time.Millisecond, ErrTransactionIDMismatch)
+		h.record(ProviderNode, time.Millisecond, context.Canceled)
+
+		stats := h.stats()
+		require.Len(t, stats, 1)
+		assert.Equal(t, ProviderNode, stats[0].Name)
+		assert.Equal(t, CircuitClosed, stats[0].Circuit)
+		assert.Equal(t, uint64(4), stats[0].Requests)
+		assert.Equal(t, uint64(0), stats[0].Errors)
+		assert.Equal(t, float64(0), stats[0].ErrorRate)
+		assert.False(t, stats[0].LastSuccessAt.IsZero())
+	})
+
+	t.Run("circuit breaker", func(t *testing.T) {
+		h := newProviderHealth(2, 50*time.Millisecond, 10)
+		h.record(ProviderNode, time.Millisecond, errors.New("unexpected response code 429: too many requests"))
+		assert.Equal(t, CircuitClosed, h.state(ProviderNode))
+		h.record(ProviderNode, time.Millisecond, errors.New("context deadline exceeded"))
+		assert.Equal(t, CircuitOpen, h.state(ProviderNode))
+
+		stats := h.stats()
+		require.Len(t, stats, 1)
+		assert.Equal(t, uint64(2), stats[0].Errors)
+		assert.Equal(t, uint64(1), stats[0].RateLimited)
+		assert.Equal(t, 2, stats[0].ConsecutiveFailures)
+		assert.Equal(t, float64(1), stats[0].ErrorRate)
+		assert.Equal(t, "context deadline exceeded", stats[0].LastError)
+
+		// Open circuits are skipped (unless all are open)
+		names := []string{ProviderNode, ProviderWhatsOnChain}
+		assert.Equal(t, []int{1}, h.route(RoutingPrimaryFallback, names))
+		assert.Equal(t, []int{0}, h.route(RoutingPrimaryFallback, names[:1]))
+
+		// Half-open after the cooldown, closed after a success
+		time.Sleep(60 * time.Millisecond)
+		assert.Equal(t, CircuitHalfOpen, h.state(ProviderNode))
+		assert.Equal(t, []int{0, 1}, h.route(RoutingPrimaryFallback, names))
+		h.record(ProviderNode, time.Millisecond, nil)
+		assert.Equal(t, CircuitClosed, h.state(ProviderNode))
+	})
+
+	t.Run("fastest healthy", func(t *testing.T) {
+		h := newProviderHealth(5, time.Minute, 10)
+		h.record(ProviderNode, 30*time.Millisecond, nil)
+		h.record(ProviderWhatsOnChain, 10*time.Millisecond, nil)
+		h.record(ProviderNowNodes, time.Millisecond, errors.New("unexpected response code 500"))
+
+		names := []string{ProviderNode, ProviderNowNodes, ProviderWhatsOnChain}
+		assert.Equal(t, []int{0, 1, 2}, h.route(RoutingPrimaryFallback, names))
+		assert.Equal(t, []int{2, 0, 1}, h.route(RoutingFastestHealthy, names))
+	})
+
+	t.Run("latency percentiles", func(t *testing.T) {
+		h := newProviderHealth(5, time.Minute, 10)
+		for i := 1; i <= 20; i++ {
+			h.record(ProviderNode, time.Duration(i)*time.Millisecond, nil)
+		}
+
+		// Only the last 10 requests
+		stats := h.stats()
+		require.Len(t, stats, 1)
+		assert.Equal(t, uint64(20), stats[0].Requests)
+		assert.Equal(t, 15*time.Millisecond, stats[0].LatencyP50)
+		assert.Equal(t, 19*time.Millisecond, stats[0].LatencyP90)
+		assert.Equal(t, 20*time.Millisecond, stats[0].LatencyP99)
+	})
+}
+
+// TestClient_ProviderRouting will test the routing of the queries and broadcasts to the providers
+func TestClient_ProviderRouting(t *testing.T) {
+	t.Parallel()
+
+	// A node that is down
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	t.Run("query skips the failing provider", func(t *testing.T) {
+		ctx := context.Background()
+		c, err := NewClient(
+			ctx,
+			WithNodeRPC(server.URL, "user", testDummyKey),
+			WithWhatsOnChain(&whatsOnChainTxOnChain{}),
+			WithExcludedProviders([]string{ProviderMAPI, ProviderNowNodes}),
+			WithCircuitBreaker(2, time.Minute),
+			WithMinercraft(&MinerCraftBase{}),
+		)
+		require.NoError(t, err)
+
+		for i := 0; i < 3; i++ {
+			info, queryErr := c.QueryTransaction(ctx, onChainExample1TxID, RequiredOnChain, defaultQueryTimeOut)
+			require.NoError(t, queryErr)
+			assert.Equal(t, ProviderWhatsOnChain, info.Provider)
+		}
+
+		stats := c.ProviderStats()
+		require.Len(t, stats, 2)
+		assert.Equal(t, ProviderNode, stats[0].Name)
+		assert.Equal(t, CircuitOpen, stats[0].Circuit)
+		assert.Equal(t, uint64(2), stats[0].Requests)
+		assert.Equal(t, uint64(2), stats[0].Errors)
+		assert.Equal(t, ProviderWhatsOnChain, stats[1].Name)
+		assert.Equal(t, CircuitClosed, stats[1].Circuit)
+		assert.Equal(t, uint64(3), stats[1].Requests)
+	})
+
+	t.Run("broadcast with fallback", func(t *testing.T) {
+		ctx := context.Background()
+		c, err := NewClient(
+			ctx,
+			WithNodeRPC(server.URL, "user", testDummyKey),
+			WithWhatsOnChain(&whatsOnChainBroadcastSuccess{}),
+			WithExcludedProviders([]string{ProviderMAPI, ProviderNowNodes}),
+			WithBroadcastRouting(RoutingPrimaryFallback),
+			WithMinercraft(&MinerCraftBase{}),
+		)
+		require.NoError(t, err)
+
+		provider, err := c.Broadcast(ctx, broadcastExample1TxID, broadcastExample1TxHex, defaultBroadcastTimeOut)
+		require.NoError(t, err)
+		assert.Equal(t, ProviderWhatsOnChain, provider)
+
+		stats := c.ProviderStats()
+		require.Len(t, stats, 2)
+		assert.Equal(t, uint64(1), stats[0].Errors)
+		assert.Equal(t, uint64(0), stats[1].Errors)
+	})
+
+	t.Run("broadcast rejection is final", func(t *testing.T) {
+		// A node rejecting the transaction (double spend)
+		rejecting := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+			_, _ = w.Write([]byte(`{"result":null,"error":{"code":-25,"message":"bad-txns-inputs-spent"},"id":"1"}`))
+		}))
+		defer rejecting.Close()
+
+		ctx := context.Background()
+		c, err := NewClient(
+			ctx,
+			WithNodeRPC(rejecting.URL, "user", testDummyKey),
+			WithWhatsOnChain(&whatsOnChainBroadcastSuccess{}),
+			WithExcludedProviders([]string{ProviderMAPI, ProviderNowNodes}),
+			WithBroadcastRouting(RoutingPrimaryFallback),
+			WithMinercraft(&MinerCraftBase{}),
+		)
+		require.NoError(t, err)
+
+		// WhatsOnChain is not used
+		_, err = c.Broadcast(ctx, broadcastExample1TxID, broadcastExample1TxHex, defaultBroadcastTimeOut)
+		require.ErrorIs(t, err, ErrBroadcastDoubleSpend)
+
+		stats := c.ProviderStats()
+		require.Len(t, stats, 1)
+		assert.Equal(t, ProviderNode, stats[0].Name)
+		assert.Equal(t, uint64(0), stats[0].Errors)
+	})
+}
@@ -11,46 +11,97 @@ import (
 	"github.com/tonicpow/go-minercraft/v2"
 )
 
-// query will try ALL providers in order and return the first "valid" response based on requirements
-func (c *Client) query(ctx context.Context, id string, requiredIn RequiredIn,
-	timeout time.Duration) *TransactionInfo {
+// txQueryProvider is a provider for querying transaction information
+type txQueryProvider struct {
+	name  string
+	query func(ctx context.Context) (*TransactionInfo, error)
+}
 
-	// Create a context (to cancel or timeout)
-	ctxWithCancel, cancel := context.WithTimeout(ctx, timeout)
-	defer cancel()
+// createQueryProviders will return the active providers for querying, in the default order
+func createQueryProviders(c *Client, id string) []txQueryProvider {
+	providers := make([]txQueryProvider, 0, 10)
+
+	// First: the node (if configured)
+	if !utils.StringInSlice(ProviderNode, c.options.config.excludedProviders) && c.Node() != nil {
+		providers = append(providers, txQueryProvider{name: ProviderNode, query: func(ctx context.Context) (*TransactionInfo, error) {
+			return queryNode(ctx, c, id)
+		}})
+	}
 
-	// First: try all mAPI miners (Only supported on main and test right now)
+	// Next: all mAPI miners (Only supported on main and test right now)
 	if !utils.StringInSlice(ProviderMAPI, c.options.config.excludedProviders) {
 		if c.Network() == MainNet || c.Network() == TestNet {
-			for index := range c.options.config.minercraftConfig.queryMiners {
-				if c.options.config.minercraftConfig.queryMiners[index] != nil {
-					if res, err := queryMinercraft(
-						ctxWithCancel, c, c.options.config.minercraftConfig.queryMiners[index].Miner, id,
-					); err == nil && checkRequirement(requiredIn, id, res) {
-						return res
-					}
+			for _, miner := range c.options.config.minercraftConfig.queryMiners {
+				if miner == nil {
+					continue
 				}
+				m := miner.Miner
+				providers = append(providers, txQueryProvider{name: m.Name, query: func(ctx context.Context) (*TransactionInfo, error) {
+					return queryMinercraft(ctx, c, m, id)
+				}})
 			}
 		}
 	}
 
-	// Next: try WhatsOnChain
+	// Next: WhatsOnChain
 	if !utils.StringInSlice(ProviderWhatsOnChain, c.options.config.excludedProviders) {
-		if resp, err := queryWhatsOnChain(
-			ctxWithCancel, c, id,
-		); err == nil && checkRequirement(requiredIn, id, resp) {
-			return resp
-		}
+		providers = append(providers, txQueryProvider{name: ProviderWhatsOnChain, query: func(ctx context.Context) (*TransactionInfo, error) {
+			return queryWhatsOnChain(ctx, c, id)
+		}})
 	}
 
-	// Next: try NowNodes (if loaded)
+	// Next: NowNodes (if loaded)
 	if !utils.StringInSlice(ProviderNowNodes, c.options.config.excludedProviders) {
 		if c.NowNodes() != nil && c.Network() == MainNet {
-			if resp, err := queryNowNodes(
-				ctxWithCancel, c, id,
-			); err == nil && checkRequirement(requiredIn, id, resp) {
-				return resp
-			}
+			providers = append(providers, txQueryProvider{name: ProviderNowNodes, query: func(ctx context.Context) (*TransactionInfo, error) {
+				return queryNowNodes(ctx, c, id)
+			}})
+		}
+	}
+
+	return providers
+}
+
+// routeQueryProviders will return the providers to use (by the routing policy and the circuit breakers)
+func routeQueryProviders(c *Client, policy RoutingPolicy, providers []txQueryProvider) []txQueryProvider {
+	names := make([]string, 0, len(providers))
+	for _, provider := range providers {
+		names = append(names, provider.name)
+	}
+
+	routed := make([]txQueryProvider, 0, len(providers))
+	for _, index := range c.options.health.route(policy, names) {
+		routed = append(routed, providers[index])
+	}
+	return routed
+}
+
+// queryWithProvider will query the provider and record the health of the provider
+func queryWithProvider(ctx context.Context, c *Client, provider txQueryProvider) (*TransactionInfo, error) {
+	start := time.Now()
+	res, err := provider.query(ctx)
+	c.options.health.record(provider.name, time.Since(start), err)
+	return res, err
+}
+
+// query will try the providers in order (by the routing policy) and return the first "valid" response based on requirements
+func (c *Client) query(ctx context.Context, id string, requiredIn RequiredIn,
+	timeout time.Duration) *TransactionInfo {
+
+	// All providers at once
+	if c.options.config.queryRouting == RoutingAll {
+		return c.fastestQuery(ctx, id, requiredIn, timeout)
+	}
+
+	// Create a context (to cancel or timeout)
+	ctxWithCancel, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	for _, provider := range routeQueryProviders(c, c.options.config.queryRouting, createQueryProviders(c, id)) {
+		if res, err := queryWithProvider(
+			ctxWithCancel, c, provider,
+		); err == nil && checkRequirement(requiredIn, id, res) {
+			return res
 		}
 	}
 
@@ -65,61 +116,24 @@ func (c *Client) fastestQuery(ctx context.Context, id string, requiredIn Require
 	// The channel for the internal results
 	resultsChannel := make(
 		chan *TransactionInfo,
-		// len(c.options.config.mAPI.queryMiners)+2,
-	) // All miners & WhatsOnChain
+	) // All providers
 
 	// Create a context (to cancel or timeout)
 	ctxWithCancel, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
-	// Loop each miner (break into a Go routine for each query)
+	// Loop each provider (break into a Go routine for each query)
 	var wg sync.WaitGroup
-	if !utils.StringInSlice(ProviderMAPI, c.options.config.excludedProviders) {
-		if c.Network() == MainNet || c.Network() == TestNet {
-			for index := range c.options.config.minercraftConfig.queryMiners {
-				wg.Add(1)
-				go func(
-					ctx context.Context, client *Client,
-					wg *sync.WaitGroup, miner *minercraft.Miner,
-					id string, requiredIn RequiredIn,
-				) {
-					defer wg.Done()
-					if res, err := queryMinercraft(
-						ctx, client, miner, id,
-					); err == nil && checkRequirement(requiredIn, id, res) {
-						resultsChannel <- res
-					}
-				}(ctxWithCancel, c, &wg, c.options.config.minercraftConfig.queryMiners[index].Miner, id, requiredIn)
-			}
-		}
-	}
-
-	// Backup: WhatsOnChain
-	if !utils.StringInSlice(ProviderWhatsOnChain, c.options.config.excludedProviders) {
+	for _, provider := range routeQueryProviders(c, RoutingAll, createQueryProviders(c, id)) {
 		wg.Add(1)
-		go func(ctx context.Context, client *Client, id string, requiredIn RequiredIn) {
+		go func(ctx context.Context, provider txQueryProvider) {
 			defer wg.Done()
-			if resp, err := queryWhatsOnChain(
-				ctx, client, id,
-			); err == nil && checkRequirement(requiredIn, id, resp) {
-				resultsChannel <- resp
+			if res, err := queryWithProvider(
+				ctx, c, provider,
+			); err == nil && checkRequirement(requiredIn, id, res) {
+				resultsChannel <- res
 			}
-		}(ctxWithCancel, c, id, requiredIn)
-	}
-
-	// Backup: NowNodes
-	if !utils.StringInSlice(ProviderNowNodes, c.options.config.excludedProviders) {
-		if c.NowNodes() != nil && c.Network() == MainNet {
-			wg.Add(1)
-			go func(ctx context.Context, client *Client, id string, requiredIn RequiredIn) {
-				defer wg.Done()
-				if resp, err := queryNowNodes(
-					ctx, client, id,
-				); err == nil && checkRequirement(requiredIn, id, resp) {
-					resultsChannel <- resp
-				}
-			}(ctxWithCancel, c, id, requiredIn)
-		}
+		}(ctxWithCancel, provider)
 	}
 
 	// Waiting for all requests to finish
@@ -143,6 +157,7 @@ func queryMinercraft(ctx context.Context, client ClientInterface, miner *minercr
 			BlockHeight:   resp.Query.BlockHeight,
 			Confirmations: resp.Query.Confirmations,
 			ID:            resp.Query.TxID,
+			MerkleProof:   resp.Query.MerkleProof,
 			MinerID:       resp.Query.MinerID,
 			Provider:      miner.Name,
 		}, nil
@@ -187,3 +202,33 @@ func queryNowNodes(ctx context.Context, client ClientInterface, id string) (*Tra
 	}
 	return nil, ErrTransactionIDMismatch
 }
+
+// queryNode will request the node for transaction information (getrawtransaction)
+//
+// The merkle proof of a confirmed transaction is requested from the node (getmerkleproof2)
+func queryNode(ctx context.Context, client ClientInterface, id string) (*TransactionInfo, error) {
+	client.DebugLog("executing request in node")
+	if resp, err := client.Node().GetRawTransaction(ctx, id); err != nil {
+		client.DebugLog("error executing request in node: " + err.Error())
+		return nil, err
+	} else if resp != nil && strings.EqualFold(resp.TxID, id) {
+		info := &TransactionInfo{
+			BlockHash:     resp.BlockHash,
+			BlockHeight:   resp.BlockHeight,
+			Confirmations: resp.Confirmations,
+			ID:            resp.TxID,
+			Provider:      ProviderNode,
+			MinerID:       "",
+		}
+		if len(resp.BlockHash) > 0 {
+			var proof *NodeMerkleProof
+			if proof, err = client.Node().GetMerkleProof(ctx, resp.BlockHash, id); err != nil {
+				client.DebugLog("error getting the merkle proof in node: " + err.Error())
+				return nil, err
+			}
+			info.MerkleProof = proof.merkleProof()
+		}
+		return info, nil
+	}
+	return nil, ErrTransactionIDMismatch
+}
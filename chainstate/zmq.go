@@ -0,0 +1,172 @@
+package chainstate
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"strings"
+)
+
+// ZMQ (ZMTP 3.0) frame flags
+const (
+	zmqFlagMore    byte = 0x01
+	zmqFlagLong    byte = 0x02
+	zmqFlagCommand byte = 0x04
+
+	zmqMaxFrameSize = 1 << 30 // Largest frame accepted (1 GB)
+)
+
+// Topics published by the node (-zmqpubhashblock, -zmqpubrawtx)
+const (
+	zmqTopicHashBlock = "hashblock"
+	zmqTopicRawTx     = "rawtx"
+)
+
+// zmqSubscriber is a minimal ZeroMQ SUB socket (ZMTP 3.0, NULL mechanism) for the node notifications
+type zmqSubscriber struct {
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+// dialZMQSubscriber will connect to the publisher (IE: tcp://localhost:28332) and subscribe to the topics
+func dialZMQSubscriber(ctx context.Context, address string, topics ...string) (*zmqSubscriber, error) {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", strings.TrimPrefix(address, "tcp://"))
+	if err != nil {
+		return nil, err
+	}
+
+	s := &zmqSubscriber{conn: conn, reader: bufio.NewReader(conn)}
+	if err = s.handshake("SUB"); err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+
+	// Subscriptions are messages starting with 0x01 (ZMTP 3.0)
+	for _, topic := range topics {
+		if err = writeZMQFrame(conn, 0, append([]byte{0x01}, topic...)); err != nil {
+			_ = conn.Close()
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// Close will close the connection
+func (s *zmqSubscriber) Close() error {
+	return s.conn.Close()
+}
+
+// ReadMessage will read the next message (all frames), commands are skipped
+func (s *zmqSubscriber) ReadMessage() ([][]byte, error) {
+	var message [][]byte
+	for {
+		flags, body, err := readZMQFrame(s.reader)
+		if err != nil {
+			return nil, err
+		} else if flags&zmqFlagCommand != 0 {
+			continue
+		}
+		message = append(message, body)
+		if flags&zmqFlagMore == 0 {
+			return message, nil
+		}
+	}
+}
+
+// handshake will exchange the greetings and the READY commands
+func (s *zmqSubscriber) handshake(socketType string) error {
+	if _, err := s.conn.Write(zmqGreeting()); err != nil {
+		return err
+	}
+
+	greeting := make([]byte, 64)
+	if _, err := io.ReadFull(s.reader, greeting); err != nil {
+		return err
+	} else if greeting[0] != 0xff || greeting[9] != 0x7f || greeting[10] < 3 ||
+		!bytes.Equal(bytes.TrimRight(greeting[12:32], "\x00"), []byte("NULL")) {
+		return ErrZMQHandshake
+	}
+
+	if err := writeZMQFrame(s.conn, zmqFlagCommand, zmqReadyCommand(socketType)); err != nil {
+		return err
+	}
+
+	flags, body, err := readZMQFrame(s.reader)
+	if err != nil {
+		return err
+	} else if flags&zmqFlagCommand == 0 || len(body) < 6 || string(body[1:6]) != "READY" {
+		return ErrZMQHandshake
+	}
+	return nil
+}
+
+// zmqGreeting will return the ZMTP 3.0 greeting (NULL mechanism, client)
+func zmqGreeting() []byte {
+	greeting := make([]byte, 64)
+	greeting[0] = 0xff // Signature
+	greeting[9] = 0x7f
+	greeting[10] = 3 // Version 3.0
+	copy(greeting[12:32], "NULL")
+	return greeting
+}
+
+// zmqReadyCommand will return the body of the READY command with the socket type
+func zmqReadyCommand(socketType string) []byte {
+	body := append([]byte{5}, "READY"...)
+	body = append(body, byte(len("Socket-Type")))
+	body = append(body, "Socket-Type"...)
+	size := make([]byte, 4)
+	binary.BigEndian.PutUint32(size, uint32(len(socketType)))
+	body = append(body, size...)
+	return append(body, socketType...)
+}
+
+// writeZMQFrame will write a frame (short or long)
+func writeZMQFrame(w io.Writer, flags byte, body []byte) error {
+	var header []byte
+	if len(body) > 255 {
+		header = make([]byte, 9)
+		header[0] = flags | zmqFlagLong
+		binary.BigEndian.PutUint64(header[1:], uint64(len(body)))
+	} else {
+		header = []byte{flags, byte(len(body))}
+	}
+	_, err := w.Write(append(header, body...))
+	return err
+}
+
+// readZMQFrame will read a frame and return the flags and the body
+func readZMQFrame(r *bufio.Reader) (byte, []byte, error) {
+	flags, err := r.ReadByte()
+	if err != nil {
+		return 0, nil, err
+	}
+
+	var size uint64
+	if flags&zmqFlagLong != 0 {
+		header := make([]byte, 8)
+		if _, err = io.ReadFull(r, header); err != nil {
+			return 0, nil, err
+		}
+		size = binary.BigEndian.Uint64(header)
+	} else {
+		var b byte
+		if b, err = r.ReadByte(); err != nil {
+			return 0, nil, err
+		}
+		size = uint64(b)
+	}
+	if size > zmqMaxFrameSize {
+		return 0, nil, ErrInvalidZMQFrame
+	}
+
+	body := make([]byte, size)
+	if _, err = io.ReadFull(r, body); err != nil {
+		return 0, nil, err
+	}
+	return flags, body, nil
+}
@@ -26,22 +26,33 @@ type (
 
 	// clientOptions holds all the configuration for the client
 	clientOptions struct {
+		approvals             *ApprovalPolicy             // Approval policy for drafts that require approval
+		auditLog              *auditLogOptions            // Configuration options for the audit log (nil = disabled)
+		bhi                   bool                        // (Block Header Index) True will load the in-memory index of the (compact) block headers
+		bhv                   bool                        // (Block Header Validation) True will validate the proof-of-work & chaining of recorded block headers
+		authClockSkew         time.Duration               // Allowed clock skew between the client and the server for signed requests
+		authTokenTTL          time.Duration               // TTL for bearer tokens
 		cacheStore            *cacheStoreOptions          // Configuration options for Cachestore (ristretto, redis, etc.)
 		cluster               *clusterOptions             // Configuration options for the cluster coordinator
 		chainstate            *chainstateOptions          // Configuration options for Chainstate (broadcast, sync, etc.)
 		dataStore             *dataStoreOptions           // Configuration options for the DataStore (MySQL, etc.)
 		debug                 bool                        // If the client is in debug mode
-		encryptionKey         string                      // Encryption key for encrypting sensitive information (IE: paymail xPub) (hex encoded key)
+		encryption            *encryptionOptions          // Configuration options for encryption at rest (IE: paymail xPub, sensitive metadata)
+		finality              uint64                      // Number of confirmations before a transaction is final
+		headerIndex           *BlockHeaderIndex           // In-memory index of the (compact) block headers, loaded on startup (nil if disabled)
 		httpClient            HTTPInterface               // HTTP interface to use
 		importBlockHeadersURL string                      // The URL of the block headers zip file to import old block headers on startup. if block 0 is found in the DB, block headers will mpt be downloaded
 		itc                   bool                        // (Incoming Transactions Check) True will check incoming transactions via Miners (real-world)
 		iuc                   bool                        // (Input UTXO Check) True will check input utxos when saving transactions
 		logger                zLogger.GormLoggerInterface // Internal logging
+		maxUnconfirmed        uint32                      // Maximum depth of the unconfirmed ancestor chain when spending utxos (0 = unlimited)
 		models                *modelOptions               // Configuration options for the loaded models
 		newRelic              *newRelicOptions            // Configuration options for NewRelic
 		notifications         *notificationsOptions       // Configuration options for Notifications
 		paymail               *paymailOptions             // Paymail options & client
+		spendingLimits        *SpendingLimits             // Default spending limits for xPubs without their own limits
 		taskManager           *taskManagerOptions         // Configuration options for the TaskManager (TaskQ, etc.)
+		trustedProxies        []string                    // IPs or CIDRs of the proxies that can set the client IP (X-Forwarded-For, X-Real-IP)
 		userAgent             string                      // User agent for all outgoing requests
 	}
 
@@ -55,6 +66,17 @@ type (
 		syncOnChain                bool                   // Default value for all transactions
 	}
 
+	// auditLogOptions holds the configuration for the audit log
+	auditLogOptions struct {
+		excludedModels []ModelName // Models that are not written to the audit log
+	}
+
+	// encryptionOptions holds the configuration for encryption at rest
+	encryptionOptions struct {
+		provider          EncryptionProvider // Provider for encrypting sensitive information (nil = disabled)
+		sensitiveMetadata []string           // Metadata keys that hold sensitive values (encrypted at rest)
+	}
+
 	// cacheStoreOptions holds the cache configuration and client
 	cacheStoreOptions struct {
 		cachestore.ClientInterface                        // Client for Cachestore
@@ -163,6 +185,9 @@ func NewClient(ctx context.Context, opts ...ClientOps) (ClientInterface, error)
 		return nil, err
 	}
 
+	// Load the block header index (after the migrations, IE: block headers import)
+	client.loadBlockHeaderIndex(ctx)
+
 	// Load the Chainstate client
 	if err = client.loadChainstate(ctx); err != nil {
 		return nil, err
@@ -247,6 +272,11 @@ func (c *Client) Cachestore() cachestore.ClientInterface {
 	return nil
 }
 
+// BlockHeaderIndex will return the in-memory block header index (if loaded)
+func (c *Client) BlockHeaderIndex() *BlockHeaderIndex {
+	return c.options.headerIndex
+}
+
 // Cluster will return the cluster coordinator client
 func (c *Client) Cluster() cluster.ClientInterface {
 	if c.options.cluster != nil && c.options.cluster.ClientInterface != nil {
@@ -298,6 +328,7 @@ func (c *Client) Close(ctx context.Context) error {
 		}
 		c.options.dataStore.ClientInterface = nil
 	}
+	c.options.headerIndex = nil
 
 	// Close Taskmanager
 	tm := c.Taskmanager()
@@ -417,19 +448,67 @@ func (c *Client) IsMempoolMonitoringEnabled() bool {
 	return c.options.chainstate.IsNewRelicEnabled()
 }
 
+// IsAuditLogEnabled will return the flag (bool) if the audit log is enabled
+func (c *Client) IsAuditLogEnabled() bool {
+	return c.options.auditLog != nil
+}
+
+// AuditLogExcludedModels will return the models that are not written to the audit log
+func (c *Client) AuditLogExcludedModels() []ModelName {
+	if c.options.auditLog == nil {
+		return nil
+	}
+	return c.options.auditLog.excludedModels
+}
+
 // IsITCEnabled will return the flag (bool)
 func (c *Client) IsITCEnabled() bool {
 	return c.options.itc
 }
 
+// IsBlockHeaderValidationEnabled will return the flag (bool)
+func (c *Client) IsBlockHeaderValidationEnabled() bool {
+	return c.options.bhv
+}
+
 // IsIUCEnabled will return the flag (bool)
 func (c *Client) IsIUCEnabled() bool {
 	return c.options.iuc
 }
 
-// IsEncryptionKeySet will return the flag (bool) if the encryption key has been set
+// FinalityConfirmations will return the number of confirmations before a transaction is final
+func (c *Client) FinalityConfirmations() uint64 {
+	return c.options.finality
+}
+
+// ApprovalPolicy will return the approval policy for drafts that require approval
+func (c *Client) ApprovalPolicy() *ApprovalPolicy {
+	return c.options.approvals
+}
+
+// DefaultSpendingLimits will return the default spending limits for xPubs without their own limits
+func (c *Client) DefaultSpendingLimits() *SpendingLimits {
+	return c.options.spendingLimits
+}
+
+// MaxUnconfirmedAncestors will return the maximum depth of the unconfirmed ancestor chain (0 = unlimited)
+func (c *Client) MaxUnconfirmedAncestors() uint32 {
+	return c.options.maxUnconfirmed
+}
+
+// IsEncryptionKeySet will return the flag (bool) if the encryption key (provider) has been set
 func (c *Client) IsEncryptionKeySet() bool {
-	return len(c.options.encryptionKey) > 0
+	return c.options.encryption.provider != nil
+}
+
+// Encryption will return the encryption provider for sensitive values (nil if not set)
+func (c *Client) Encryption() EncryptionProvider {
+	return c.options.encryption.provider
+}
+
+// SensitiveMetadataKeys will return the metadata keys that hold sensitive values (encrypted at rest)
+func (c *Client) SensitiveMetadataKeys() []string {
+	return c.options.encryption.sensitiveMetadata
 }
 
 // IsMigrationEnabled will return the flag (bool)
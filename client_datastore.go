@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 	"go.mongodb.org/mongo-driver/x/bsonx"
 )
 
@@ -87,6 +88,19 @@ func processXpubMetadataConditions(conditions *map[string]interface{}) {
 func getMongoIndexes() map[string][]mongo.IndexModel {
 
 	return map[string][]mongo.IndexModel{
+		"audit_logs": {
+			mongo.IndexModel{Keys: bsonx.Doc{{
+				Key:   "sequence",
+				Value: bsonx.Int32(1),
+			}}, Options: options.Index().SetUnique(true)},
+			mongo.IndexModel{Keys: bsonx.Doc{{
+				Key:   "model_name",
+				Value: bsonx.Int32(1),
+			}, {
+				Key:   "model_id",
+				Value: bsonx.Int32(1),
+			}}},
+		},
 		"block_headers": {
 			mongo.IndexModel{Keys: bsonx.Doc{{
 				Key:   "height",
@@ -96,6 +110,10 @@ func getMongoIndexes() map[string][]mongo.IndexModel {
 				Key:   "synced",
 				Value: bsonx.Int32(1),
 			}}},
+			mongo.IndexModel{Keys: bsonx.Doc{{
+				Key:   "orphaned",
+				Value: bsonx.Int32(1),
+			}}},
 		},
 		"destinations": {
 			mongo.IndexModel{Keys: bsonx.Doc{{
@@ -115,6 +133,13 @@ func getMongoIndexes() map[string][]mongo.IndexModel {
 				Key:   "status",
 				Value: bsonx.Int32(1),
 			}}},
+			mongo.IndexModel{Keys: bsonx.Doc{{
+				Key:   "access_key_id",
+				Value: bsonx.Int32(1),
+			}, {
+				Key:   "created_at",
+				Value: bsonx.Int32(1),
+			}}},
 		},
 		"transactions": {
 			mongo.IndexModel{Keys: bsonx.Doc{{
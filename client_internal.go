@@ -46,6 +46,16 @@ func (c *Client) loadChainstate(ctx context.Context) (err error) {
 		c.options.chainstate.ClientInterface, err = chainstate.NewClient(ctx, c.options.chainstate.options...)
 	}
 
+	// The offline chainstate has no monitor, the mined block headers are recorded directly
+	if offline, ok := c.options.chainstate.ClientInterface.(*chainstate.OfflineClient); ok {
+		offline.SetMonitorHandler(&MonitorEventHandler{
+			buxClient: c,
+			ctx:       ctx,
+			debug:     c.IsDebug(),
+			logger:    c.Logger(),
+		})
+	}
+
 	return
 }
 
@@ -184,6 +194,32 @@ func (c *Client) loadMonitor(ctx context.Context) (err error) {
 	return nil
 }
 
+// loadBlockHeaderIndex will load the (compact) block headers into the in-memory index
+//
+// Datastore is required to be loaded before this method is called. If the index is disabled or cannot be loaded,
+// the block header lookups use the Datastore
+func (c *Client) loadBlockHeaderIndex(ctx context.Context) {
+	ds := c.Datastore()
+	if ds == nil || !c.options.bhi {
+		return
+	}
+
+	index := newBlockHeaderIndex()
+	if err := index.load(ctx, ds); err != nil {
+		c.Logger().Error(ctx, "error loading the block header index: "+err.Error())
+		return
+	}
+	c.options.headerIndex = index
+}
+
+// difficultyRules will return the difficulty adjustment rules of the block headers of the network
+func (c *Client) difficultyRules() *chainstate.DifficultyRules {
+	if cs := c.Chainstate(); cs != nil {
+		return cs.DifficultyRules()
+	}
+	return chainstate.MainNet.DifficultyRules()
+}
+
 // runModelMigrations will run the model Migrate() method for all models
 func (c *Client) runModelMigrations(models ...interface{}) (err error) {
 
@@ -217,7 +253,42 @@ func (c *Client) runModelRegisterTasks(models ...interface{}) (err error) {
 // registerAllTasks will register all tasks for all models
 func (c *Client) registerAllTasks() error {
 	c.Taskmanager().ResetCron()
-	return c.runModelRegisterTasks(c.options.models.models...)
+	if err := c.runModelRegisterTasks(c.options.models.models...); err != nil {
+		return err
+	}
+	return c.registerEncryptionTask()
+}
+
+// registerEncryptionTask will register the task for rotating the encryption (if an encryption provider is set)
+func (c *Client) registerEncryptionTask() error {
+
+	// No task manager loaded or no encryption?
+	tm := c.Taskmanager()
+	if tm == nil || c.Encryption() == nil {
+		return nil
+	}
+
+	// Register the task
+	ctx := context.Background()
+	if err := tm.RegisterTask(&taskmanager.Task{
+		Name:       encryptionRotateTask,
+		RetryLimit: 1,
+		Handler: func(client ClientInterface) error {
+			if taskErr := taskRotateEncryption(ctx, client.Logger(), WithClient(client)); taskErr != nil {
+				client.Logger().Error(ctx, "error running "+encryptionRotateTask+" task: "+taskErr.Error())
+			}
+			return nil
+		},
+	}); err != nil {
+		return err
+	}
+
+	// Run the task periodically
+	return tm.RunTask(ctx, &taskmanager.TaskOptions{
+		Arguments:      []interface{}{c},
+		RunEveryPeriod: c.GetTaskPeriod(encryptionRotateTask),
+		TaskName:       encryptionRotateTask,
+	})
 }
 
 // loadDefaultPaymailConfig will load the default paymail server configuration
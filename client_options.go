@@ -11,6 +11,7 @@ import (
 	"github.com/BuxOrg/bux/cluster"
 	"github.com/BuxOrg/bux/notifications"
 	"github.com/BuxOrg/bux/taskmanager"
+	"github.com/BuxOrg/bux/utils"
 	"github.com/coocood/freecache"
 	"github.com/go-redis/redis/v8"
 	"github.com/mrz1836/go-cache"
@@ -42,6 +43,27 @@ func defaultClientOptions() *clientOptions {
 		// By default check input utxos (unless disabled by the user)
 		iuc: true,
 
+		// By default load the in-memory index of the block headers
+		bhi: true,
+
+		// By default validate the recorded block headers (proof-of-work & chaining)
+		bhv: true,
+
+		// Transactions are final after this number of confirmations
+		finality: defaultFinalityConfirmations,
+
+		// Allowed clock skew for signed requests
+		authClockSkew: defaultAuthClockSkew,
+
+		// Bearer tokens are short-lived
+		authTokenTTL: defaultAuthTokenTTL,
+
+		// Drafts exceeding a spending limit require a single approval
+		approvals: &ApprovalPolicy{
+			ExpiresIn:         defaultApprovalExpiresIn,
+			RequiredApprovals: defaultRequiredApprovals,
+		},
+
 		// Blank chainstate config
 		chainstate: &chainstateOptions{
 			ClientInterface:  nil,
@@ -68,6 +90,9 @@ func defaultClientOptions() *clientOptions {
 			options:         []datastore.ClientOps{},
 		},
 
+		// Blank encryption config (disabled)
+		encryption: &encryptionOptions{},
+
 		// Default http client
 		httpClient: &http.Client{
 			Timeout: defaultHTTPTimeout,
@@ -103,8 +128,12 @@ func defaultClientOptions() *clientOptions {
 		taskManager: &taskManagerOptions{
 			ClientInterface: nil,
 			cronTasks: map[string]time.Duration{
+				ModelAuditLog.String() + "_seal":                          taskIntervalAuditLogSeal,
+				ModelBlockHeader.String() + "_sync":                       taskIntervalBlockHeaderSync,
 				ModelDestination.String() + "_monitor":                    taskIntervalMonitorCheck,
 				ModelDraftTransaction.String() + "_clean_up":              taskIntervalDraftCleanup,
+				encryptionRotateTask:                                      taskIntervalEncryptionRotation,
+				ModelImportJob.String() + "_process":                      taskIntervalImportJobProcess,
 				ModelIncomingTransaction.String() + "_process":            taskIntervalProcessIncomingTxs,
 				ModelSyncTransaction.String() + "_" + syncActionBroadcast: taskIntervalSyncActionBroadcast,
 				ModelSyncTransaction.String() + "_" + syncActionP2P:       taskIntervalSyncActionP2P,
@@ -167,9 +196,6 @@ func (c *Client) DefaultModelOptions(opts ...ModelOps) []ModelOps {
 	// Set the Client from the bux.Client onto the model
 	opts = append(opts, WithClient(c))
 
-	// Set the encryption key (if found)
-	opts = append(opts, WithEncryptionKey(c.options.encryptionKey))
-
 	// Return the new options
 	return opts
 }
@@ -225,10 +251,34 @@ func WithDebugging() ClientOps {
 }
 
 // WithEncryption will set the encryption key and encrypt values using this key
+//
+// Uses a static key encryption provider, use WithEncryptionProvider() for key rotation
 func WithEncryption(key string) ClientOps {
 	return func(c *clientOptions) {
 		if len(key) > 0 {
-			c.encryptionKey = key
+			c.encryption.provider = NewStaticKeyEncryption(key)
+		}
+	}
+}
+
+// WithEncryptionProvider will set the provider for encrypting sensitive values (IE: NewEnvelopeEncryption())
+func WithEncryptionProvider(provider EncryptionProvider) ClientOps {
+	return func(c *clientOptions) {
+		if provider != nil {
+			c.encryption.provider = provider
+		}
+	}
+}
+
+// WithSensitiveMetadata will set the metadata keys that hold sensitive values (encrypted at rest)
+//
+// Encrypted values can not be used in metadata conditions, see DecryptMetadata() for reading the values
+func WithSensitiveMetadata(keys ...string) ClientOps {
+	return func(c *clientOptions) {
+		for _, key := range keys {
+			if len(key) > 0 && !utils.StringInSlice(key, c.encryption.sensitiveMetadata) {
+				c.encryption.sensitiveMetadata = append(c.encryption.sensitiveMetadata, key)
+			}
 		}
 	}
 }
@@ -251,6 +301,21 @@ func WithITCDisabled() ClientOps {
 	}
 }
 
+// WithBlockHeaderIndexDisabled will disable the in-memory index of the block headers (the block header lookups
+// use the Datastore, IE: memory constrained servers)
+func WithBlockHeaderIndexDisabled() ClientOps {
+	return func(c *clientOptions) {
+		c.bhi = false
+	}
+}
+
+// WithBlockHeaderValidationDisabled will disable the validation of recorded block headers (IE: regtest or testing)
+func WithBlockHeaderValidationDisabled() ClientOps {
+	return func(c *clientOptions) {
+		c.bhv = false
+	}
+}
+
 // WithIUCDisabled will disable checking the input utxos
 func WithIUCDisabled() ClientOps {
 	return func(c *clientOptions) {
@@ -258,6 +323,101 @@ func WithIUCDisabled() ClientOps {
 	}
 }
 
+// WithMaxUnconfirmedAncestors will set the default maximum depth of the unconfirmed ancestor chain
+// (for xPubs without their own limit, see UpdateXpubMaxUnconfirmedAncestors)
+//
+// Utxos from transactions that would exceed this depth will not be selected for new drafts,
+// and recording a transaction that exceeds this depth will fail (0 = unlimited)
+func WithMaxUnconfirmedAncestors(depth uint32) ClientOps {
+	return func(c *clientOptions) {
+		c.maxUnconfirmed = depth
+	}
+}
+
+// WithAuthClockSkew will set the allowed clock skew between the client and the server for signed requests
+//
+// Signatures with a time further in the future than the skew are rejected,
+// and expired signatures are accepted for the duration of the skew
+func WithAuthClockSkew(skew time.Duration) ClientOps {
+	return func(c *clientOptions) {
+		if skew >= 0 {
+			c.authClockSkew = skew
+		}
+	}
+}
+
+// WithAuthTokenTTL will set the TTL (lifetime) of the bearer tokens created with NewAuthToken()
+func WithAuthTokenTTL(ttl time.Duration) ClientOps {
+	return func(c *clientOptions) {
+		if ttl > 0 {
+			c.authTokenTTL = ttl
+		}
+	}
+}
+
+// WithTrustedProxies will set the IPs or CIDRs of the (reverse) proxies in front of the server
+//
+// The client IP of requests from a trusted proxy is read from X-Forwarded-For or X-Real-IP (access key IP restrictions)
+func WithTrustedProxies(proxies ...string) ClientOps {
+	return func(c *clientOptions) {
+		for _, proxy := range proxies {
+			if len(proxy) > 0 {
+				c.trustedProxies = append(c.trustedProxies, proxy)
+			}
+		}
+	}
+}
+
+// WithFinalityConfirmations will set the number of confirmations before a transaction is final
+func WithFinalityConfirmations(confirmations uint64) ClientOps {
+	return func(c *clientOptions) {
+		if confirmations > 0 {
+			c.finality = confirmations
+		}
+	}
+}
+
+// WithSpendingLimits will set the default spending limits for xPubs that do not have their own limits
+//
+// Drafts exceeding a limit will require approval before they can be recorded
+func WithSpendingLimits(limits *SpendingLimits) ClientOps {
+	return func(c *clientOptions) {
+		if !limits.IsEmpty() {
+			c.spendingLimits = limits
+		}
+	}
+}
+
+// WithApprovalPolicy will set the approval policy for drafts that require approval (four-eyes control)
+//
+// Drafts exceeding a spending limit or the threshold of the policy can only be recorded after the required approvals
+func WithApprovalPolicy(policy *ApprovalPolicy) ClientOps {
+	return func(c *clientOptions) {
+		if policy != nil {
+			approvals := *policy // copy (the policy of the caller is not modified)
+			approvals.Approvers = append([]string{}, policy.Approvers...)
+			c.approvals = &approvals
+			if c.approvals.RequiredApprovals == 0 {
+				c.approvals.RequiredApprovals = defaultRequiredApprovals
+			}
+			if c.approvals.ExpiresIn <= 0 {
+				c.approvals.ExpiresIn = defaultApprovalExpiresIn
+			}
+		}
+	}
+}
+
+// WithAuditLog will write all mutating operations on the models to the (append-only, hash chained) audit log
+//
+// excludedModels are models that are not written to the audit log (IE: high volume models)
+func WithAuditLog(excludedModels ...ModelName) ClientOps {
+	return func(c *clientOptions) {
+		c.auditLog = &auditLogOptions{
+			excludedModels: excludedModels,
+		}
+	}
+}
+
 // WithImportBlockHeaders will import block headers on startup
 func WithImportBlockHeaders(importBlockHeadersURL string) ClientOps {
 	return func(c *clientOptions) {
@@ -659,6 +819,60 @@ func WithNowNodesAPIKey(apiKey string) ClientOps {
 	}
 }
 
+// WithAddressHistoryProvider will set a custom address history provider (used for importing xPubs)
+func WithAddressHistoryProvider(provider chainstate.AddressHistoryProvider) ClientOps {
+	return func(c *clientOptions) {
+		if provider != nil {
+			c.chainstate.options = append(c.chainstate.options, chainstate.WithAddressHistoryProvider(provider))
+		}
+	}
+}
+
+// WithAddressHistoryFrom will set the provider to use for the address history (whatsonchain or nownodes)
+func WithAddressHistoryFrom(providerName string) ClientOps {
+	return func(c *clientOptions) {
+		if len(providerName) > 0 {
+			c.chainstate.options = append(c.chainstate.options, chainstate.WithAddressHistoryFrom(providerName))
+		}
+	}
+}
+
+// WithBlockHeadersSource will set a custom block headers source (used for syncing the block headers)
+func WithBlockHeadersSource(source chainstate.BlockHeadersSource) ClientOps {
+	return func(c *clientOptions) {
+		if source != nil {
+			c.chainstate.options = append(c.chainstate.options, chainstate.WithBlockHeadersSource(source))
+		}
+	}
+}
+
+// WithBlockHeadersService will set the url (and optional auth token) of a Pulse-like block headers service
+func WithBlockHeadersService(url, authToken string) ClientOps {
+	return func(c *clientOptions) {
+		if len(url) > 0 {
+			c.chainstate.options = append(c.chainstate.options, chainstate.WithBlockHeadersService(url, authToken))
+		}
+	}
+}
+
+// WithNodeRPC will set the RPC url and credentials of a node (used for broadcasting and querying transactions)
+func WithNodeRPC(url, user, password string) ClientOps {
+	return func(c *clientOptions) {
+		if len(url) > 0 {
+			c.chainstate.options = append(c.chainstate.options, chainstate.WithNodeRPC(url, user, password))
+		}
+	}
+}
+
+// WithNodeZMQ will set the ZMQ address of a node (the monitor uses the node instead of the bux-agent)
+func WithNodeZMQ(address string) ClientOps {
+	return func(c *clientOptions) {
+		if len(address) > 0 {
+			c.chainstate.options = append(c.chainstate.options, chainstate.WithNodeZMQ(address))
+		}
+	}
+}
+
 // WithExcludedProviders will set a list of excluded providers
 func WithExcludedProviders(providers []string) ClientOps {
 	return func(c *clientOptions) {
@@ -668,6 +882,33 @@ func WithExcludedProviders(providers []string) ClientOps {
 	}
 }
 
+// WithBroadcastRouting will set the routing of the broadcasts to the providers
+func WithBroadcastRouting(policy chainstate.RoutingPolicy) ClientOps {
+	return func(c *clientOptions) {
+		if len(policy) > 0 {
+			c.chainstate.options = append(c.chainstate.options, chainstate.WithBroadcastRouting(policy))
+		}
+	}
+}
+
+// WithQueryRouting will set the routing of the transaction queries to the providers
+func WithQueryRouting(policy chainstate.RoutingPolicy) ClientOps {
+	return func(c *clientOptions) {
+		if len(policy) > 0 {
+			c.chainstate.options = append(c.chainstate.options, chainstate.WithQueryRouting(policy))
+		}
+	}
+}
+
+// WithCircuitBreaker will skip a failing provider for the cooldown after the threshold of consecutive failures
+func WithCircuitBreaker(threshold int, cooldown time.Duration) ClientOps {
+	return func(c *clientOptions) {
+		if threshold > 0 && cooldown > 0 {
+			c.chainstate.options = append(c.chainstate.options, chainstate.WithCircuitBreaker(threshold, cooldown))
+		}
+	}
+}
+
 // WithMonitoring will create a new monitorConfig interface with the given options
 func WithMonitoring(ctx context.Context, monitorOptions *chainstate.MonitorOptions) ClientOps {
 	return func(c *clientOptions) {
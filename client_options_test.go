@@ -246,6 +246,73 @@ func TestWithEncryption(t *testing.T) {
 	})
 }
 
+// TestWithEncryptionProvider will test the method WithEncryptionProvider()
+func TestWithEncryptionProvider(t *testing.T) {
+	t.Parallel()
+
+	t.Run("check type", func(t *testing.T) {
+		opt := WithEncryptionProvider(nil)
+		assert.IsType(t, *new(ClientOps), opt)
+	})
+
+	t.Run("empty provider", func(t *testing.T) {
+		opts := DefaultClientOpts(false, true)
+		opts = append(opts, WithEncryptionProvider(nil))
+
+		tc, err := NewClient(tester.GetNewRelicCtx(t, defaultNewRelicApp, defaultNewRelicTx), opts...)
+		require.NoError(t, err)
+		require.NotNil(t, tc)
+		defer CloseClient(context.Background(), t, tc)
+
+		assert.Equal(t, false, tc.IsEncryptionKeySet())
+		assert.Nil(t, tc.Encryption())
+	})
+
+	t.Run("custom provider", func(t *testing.T) {
+		key, _ := utils.RandomHex(32)
+		kms, err := NewLocalKMS("key-1", map[string]string{"key-1": key})
+		require.NoError(t, err)
+
+		var provider EncryptionProvider
+		provider, err = NewEnvelopeEncryption(kms, "")
+		require.NoError(t, err)
+
+		opts := DefaultClientOpts(false, true)
+		opts = append(opts, WithEncryptionProvider(provider))
+
+		var tc ClientInterface
+		tc, err = NewClient(tester.GetNewRelicCtx(t, defaultNewRelicApp, defaultNewRelicTx), opts...)
+		require.NoError(t, err)
+		require.NotNil(t, tc)
+		defer CloseClient(context.Background(), t, tc)
+
+		assert.Equal(t, true, tc.IsEncryptionKeySet())
+		assert.Equal(t, provider, tc.Encryption())
+	})
+}
+
+// TestWithSensitiveMetadata will test the method WithSensitiveMetadata()
+func TestWithSensitiveMetadata(t *testing.T) {
+	t.Parallel()
+
+	t.Run("check type", func(t *testing.T) {
+		opt := WithSensitiveMetadata()
+		assert.IsType(t, *new(ClientOps), opt)
+	})
+
+	t.Run("set keys", func(t *testing.T) {
+		opts := DefaultClientOpts(false, true)
+		opts = append(opts, WithSensitiveMetadata("secret", "", "pin"))
+
+		tc, err := NewClient(tester.GetNewRelicCtx(t, defaultNewRelicApp, defaultNewRelicTx), opts...)
+		require.NoError(t, err)
+		require.NotNil(t, tc)
+		defer CloseClient(context.Background(), t, tc)
+
+		assert.Equal(t, []string{"secret", "pin"}, tc.SensitiveMetadataKeys())
+	})
+}
+
 // TestWithRedis will test the method WithRedis()
 func TestWithRedis(t *testing.T) {
 	t.Run("check type", func(t *testing.T) {
@@ -557,9 +624,9 @@ func TestWithModels(t *testing.T) {
 		defer CloseClient(context.Background(), t, tc)
 
 		assert.Equal(t, []string{
-			ModelXPub.String(), ModelAccessKey.String(),
-			ModelDraftTransaction.String(), ModelIncomingTransaction.String(),
-			ModelTransaction.String(), ModelBlockHeader.String(),
+			ModelXPub.String(), ModelAccessKey.String(), ModelAdminKey.String(), ModelAuditLog.String(),
+			ModelDraftTransaction.String(), ModelDraftRecipient.String(), ModelBatchPayout.String(), ModelImportJob.String(), ModelIncomingTransaction.String(),
+			ModelTransaction.String(), ModelBlockHeader.String(), ModelCompactBlockHeader.String(),
 			ModelSyncTransaction.String(), ModelDestination.String(),
 			ModelUtxo.String(),
 		}, tc.GetModelNames())
@@ -575,9 +642,9 @@ func TestWithModels(t *testing.T) {
 		defer CloseClient(context.Background(), t, tc)
 
 		assert.Equal(t, []string{
-			ModelXPub.String(), ModelAccessKey.String(),
-			ModelDraftTransaction.String(), ModelIncomingTransaction.String(),
-			ModelTransaction.String(), ModelBlockHeader.String(),
+			ModelXPub.String(), ModelAccessKey.String(), ModelAdminKey.String(), ModelAuditLog.String(),
+			ModelDraftTransaction.String(), ModelDraftRecipient.String(), ModelBatchPayout.String(), ModelImportJob.String(), ModelIncomingTransaction.String(),
+			ModelTransaction.String(), ModelBlockHeader.String(), ModelCompactBlockHeader.String(),
 			ModelSyncTransaction.String(), ModelDestination.String(),
 			ModelUtxo.String(), ModelPaymailAddress.String(),
 		}, tc.GetModelNames())
@@ -617,6 +684,63 @@ func TestWithITCDisabled(t *testing.T) {
 	})
 }
 
+// TestWithBlockHeaderIndexDisabled will test the method WithBlockHeaderIndexDisabled()
+func TestWithBlockHeaderIndexDisabled(t *testing.T) {
+	t.Parallel()
+
+	t.Run("check type", func(t *testing.T) {
+		opt := WithBlockHeaderIndexDisabled()
+		assert.IsType(t, *new(ClientOps), opt)
+	})
+
+	t.Run("default options", func(t *testing.T) {
+		_, tc, deferMe := CreateTestSQLiteClient(t, false, false)
+		defer deferMe()
+
+		assert.NotNil(t, tc.BlockHeaderIndex())
+	})
+
+	t.Run("index disabled", func(t *testing.T) {
+		_, tc, deferMe := CreateTestSQLiteClient(t, false, false, WithBlockHeaderIndexDisabled())
+		defer deferMe()
+
+		assert.Nil(t, tc.BlockHeaderIndex())
+	})
+}
+
+// TestWithBlockHeaderValidationDisabled will test the method WithBlockHeaderValidationDisabled()
+func TestWithBlockHeaderValidationDisabled(t *testing.T) {
+	t.Parallel()
+
+	t.Run("check type", func(t *testing.T) {
+		opt := WithBlockHeaderValidationDisabled()
+		assert.IsType(t, *new(ClientOps), opt)
+	})
+
+	t.Run("default options", func(t *testing.T) {
+		opts := DefaultClientOpts(false, true)
+
+		tc, err := NewClient(tester.GetNewRelicCtx(t, defaultNewRelicApp, defaultNewRelicTx), opts...)
+		require.NoError(t, err)
+		require.NotNil(t, tc)
+		defer CloseClient(context.Background(), t, tc)
+
+		assert.Equal(t, true, tc.IsBlockHeaderValidationEnabled())
+	})
+
+	t.Run("validation disabled", func(t *testing.T) {
+		opts := DefaultClientOpts(false, true)
+		opts = append(opts, WithBlockHeaderValidationDisabled())
+
+		tc, err := NewClient(tester.GetNewRelicCtx(t, defaultNewRelicApp, defaultNewRelicTx), opts...)
+		require.NoError(t, err)
+		require.NotNil(t, tc)
+		defer CloseClient(context.Background(), t, tc)
+
+		assert.Equal(t, false, tc.IsBlockHeaderValidationEnabled())
+	})
+}
+
 // TestWithIUCDisabled will test the method WithIUCDisabled()
 func TestWithIUCDisabled(t *testing.T) {
 	t.Parallel()
@@ -650,6 +774,39 @@ func TestWithIUCDisabled(t *testing.T) {
 	})
 }
 
+// TestWithMaxUnconfirmedAncestors will test the method WithMaxUnconfirmedAncestors()
+func TestWithMaxUnconfirmedAncestors(t *testing.T) {
+	t.Parallel()
+
+	t.Run("check type", func(t *testing.T) {
+		opt := WithMaxUnconfirmedAncestors(0)
+		assert.IsType(t, *new(ClientOps), opt)
+	})
+
+	t.Run("default options", func(t *testing.T) {
+		opts := DefaultClientOpts(false, true)
+
+		tc, err := NewClient(tester.GetNewRelicCtx(t, defaultNewRelicApp, defaultNewRelicTx), opts...)
+		require.NoError(t, err)
+		require.NotNil(t, tc)
+		defer CloseClient(context.Background(), t, tc)
+
+		assert.Equal(t, uint32(0), tc.MaxUnconfirmedAncestors())
+	})
+
+	t.Run("custom depth", func(t *testing.T) {
+		opts := DefaultClientOpts(false, true)
+		opts = append(opts, WithMaxUnconfirmedAncestors(25))
+
+		tc, err := NewClient(tester.GetNewRelicCtx(t, defaultNewRelicApp, defaultNewRelicTx), opts...)
+		require.NoError(t, err)
+		require.NotNil(t, tc)
+		defer CloseClient(context.Background(), t, tc)
+
+		assert.Equal(t, uint32(25), tc.MaxUnconfirmedAncestors())
+	})
+}
+
 // TestWithImportBlockHeaders will test the method WithImportBlockHeaders()
 func TestWithImportBlockHeaders(t *testing.T) {
 	t.Parallel()
@@ -823,10 +980,16 @@ func TestWithAutoMigrate(t *testing.T) {
 		assert.Equal(t, []string{
 			ModelXPub.String(),
 			ModelAccessKey.String(),
+			ModelAdminKey.String(),
+			ModelAuditLog.String(),
 			ModelDraftTransaction.String(),
+			ModelDraftRecipient.String(),
+			ModelBatchPayout.String(),
+			ModelImportJob.String(),
 			ModelIncomingTransaction.String(),
 			ModelTransaction.String(),
 			ModelBlockHeader.String(),
+			ModelCompactBlockHeader.String(),
 			ModelSyncTransaction.String(),
 			ModelDestination.String(),
 			ModelUtxo.String(),
@@ -845,10 +1008,16 @@ func TestWithAutoMigrate(t *testing.T) {
 		assert.Equal(t, []string{
 			ModelXPub.String(),
 			ModelAccessKey.String(),
+			ModelAdminKey.String(),
+			ModelAuditLog.String(),
 			ModelDraftTransaction.String(),
+			ModelDraftRecipient.String(),
+			ModelBatchPayout.String(),
+			ModelImportJob.String(),
 			ModelIncomingTransaction.String(),
 			ModelTransaction.String(),
 			ModelBlockHeader.String(),
+			ModelCompactBlockHeader.String(),
 			ModelSyncTransaction.String(),
 			ModelDestination.String(),
 			ModelUtxo.String(),
@@ -889,3 +1058,201 @@ func TestWithMigrationDisabled(t *testing.T) {
 		assert.Equal(t, false, tc.IsMigrationEnabled())
 	})
 }
+
+// TestWithFinalityConfirmations will test the method WithFinalityConfirmations()
+func TestWithFinalityConfirmations(t *testing.T) {
+	t.Parallel()
+
+	t.Run("check type", func(t *testing.T) {
+		opt := WithFinalityConfirmations(0)
+		assert.IsType(t, *new(ClientOps), opt)
+	})
+
+	t.Run("default options", func(t *testing.T) {
+		opts := DefaultClientOpts(false, true)
+
+		tc, err := NewClient(tester.GetNewRelicCtx(t, defaultNewRelicApp, defaultNewRelicTx), opts...)
+		require.NoError(t, err)
+		require.NotNil(t, tc)
+		defer CloseClient(context.Background(), t, tc)
+
+		assert.Equal(t, defaultFinalityConfirmations, tc.FinalityConfirmations())
+	})
+
+	t.Run("zero is ignored", func(t *testing.T) {
+		opts := DefaultClientOpts(false, true)
+		opts = append(opts, WithFinalityConfirmations(0))
+
+		tc, err := NewClient(tester.GetNewRelicCtx(t, defaultNewRelicApp, defaultNewRelicTx), opts...)
+		require.NoError(t, err)
+		require.NotNil(t, tc)
+		defer CloseClient(context.Background(), t, tc)
+
+		assert.Equal(t, defaultFinalityConfirmations, tc.FinalityConfirmations())
+	})
+
+	t.Run("custom confirmations", func(t *testing.T) {
+		opts := DefaultClientOpts(false, true)
+		opts = append(opts, WithFinalityConfirmations(100))
+
+		tc, err := NewClient(tester.GetNewRelicCtx(t, defaultNewRelicApp, defaultNewRelicTx), opts...)
+		require.NoError(t, err)
+		require.NotNil(t, tc)
+		defer CloseClient(context.Background(), t, tc)
+
+		assert.Equal(t, uint64(100), tc.FinalityConfirmations())
+	})
+}
+
+// TestWithAuthClockSkew will test the method WithAuthClockSkew()
+func TestWithAuthClockSkew(t *testing.T) {
+	t.Parallel()
+
+	t.Run("check type", func(t *testing.T) {
+		opt := WithAuthClockSkew(0)
+		assert.IsType(t, *new(ClientOps), opt)
+	})
+
+	t.Run("test applying", func(t *testing.T) {
+		options := &clientOptions{authClockSkew: defaultAuthClockSkew}
+		opt := WithAuthClockSkew(30 * time.Second)
+		opt(options)
+		assert.Equal(t, 30*time.Second, options.authClockSkew)
+	})
+
+	t.Run("negative skew is ignored", func(t *testing.T) {
+		options := &clientOptions{authClockSkew: defaultAuthClockSkew}
+		opt := WithAuthClockSkew(-1 * time.Second)
+		opt(options)
+		assert.Equal(t, defaultAuthClockSkew, options.authClockSkew)
+	})
+}
+
+// TestWithTrustedProxies will test the method WithTrustedProxies()
+func TestWithTrustedProxies(t *testing.T) {
+	t.Parallel()
+
+	t.Run("check type", func(t *testing.T) {
+		opt := WithTrustedProxies()
+		assert.IsType(t, *new(ClientOps), opt)
+	})
+
+	t.Run("test applying", func(t *testing.T) {
+		options := &clientOptions{}
+		opt := WithTrustedProxies("10.0.0.1", "", "192.168.0.0/16")
+		opt(options)
+		assert.Equal(t, []string{"10.0.0.1", "192.168.0.0/16"}, options.trustedProxies)
+	})
+}
+
+// TestWithApprovalPolicy will test the method WithApprovalPolicy()
+func TestWithApprovalPolicy(t *testing.T) {
+	t.Parallel()
+
+	t.Run("check type", func(t *testing.T) {
+		opt := WithApprovalPolicy(nil)
+		assert.IsType(t, *new(ClientOps), opt)
+	})
+
+	t.Run("default options", func(t *testing.T) {
+		opts := DefaultClientOpts(false, true)
+
+		tc, err := NewClient(tester.GetNewRelicCtx(t, defaultNewRelicApp, defaultNewRelicTx), opts...)
+		require.NoError(t, err)
+		require.NotNil(t, tc)
+		defer CloseClient(context.Background(), t, tc)
+
+		require.NotNil(t, tc.ApprovalPolicy())
+		assert.Equal(t, defaultRequiredApprovals, tc.ApprovalPolicy().RequiredApprovals)
+		assert.Equal(t, defaultApprovalExpiresIn, tc.ApprovalPolicy().ExpiresIn)
+		assert.Equal(t, uint64(0), tc.ApprovalPolicy().ThresholdSatoshis)
+	})
+
+	t.Run("custom policy", func(t *testing.T) {
+		opts := DefaultClientOpts(false, true)
+		opts = append(opts, WithApprovalPolicy(&ApprovalPolicy{
+			Approvers:         []string{testXPubID},
+			RequiredApprovals: 3,
+			ThresholdSatoshis: 10000,
+		}))
+
+		tc, err := NewClient(tester.GetNewRelicCtx(t, defaultNewRelicApp, defaultNewRelicTx), opts...)
+		require.NoError(t, err)
+		require.NotNil(t, tc)
+		defer CloseClient(context.Background(), t, tc)
+
+		require.NotNil(t, tc.ApprovalPolicy())
+		assert.Equal(t, uint32(3), tc.ApprovalPolicy().RequiredApprovals)
+		assert.Equal(t, uint64(10000), tc.ApprovalPolicy().ThresholdSatoshis)
+		assert.Equal(t, defaultApprovalExpiresIn, tc.ApprovalPolicy().ExpiresIn)
+		assert.Equal(t, []string{testXPubID}, tc.ApprovalPolicy().Approvers)
+	})
+	t.Run("policy of the caller is not modified", func(t *testing.T) {
+		policy := &ApprovalPolicy{Approvers: []string{testXPubID}}
+		options := &clientOptions{}
+		opt := WithApprovalPolicy(policy)
+		opt(options)
+
+		assert.Equal(t, uint32(0), policy.RequiredApprovals)
+		assert.Equal(t, time.Duration(0), policy.ExpiresIn)
+		assert.Equal(t, defaultRequiredApprovals, options.approvals.RequiredApprovals)
+
+		policy.Approvers[0] = testApproverID1
+		assert.Equal(t, []string{testXPubID}, options.approvals.Approvers)
+	})
+}
+
+// TestWithAuditLog will test the method WithAuditLog()
+func TestWithAuditLog(t *testing.T) {
+	t.Parallel()
+
+	t.Run("check type", func(t *testing.T) {
+		opt := WithAuditLog()
+		assert.IsType(t, *new(ClientOps), opt)
+	})
+
+	t.Run("disabled by default", func(t *testing.T) {
+		opts := DefaultClientOpts(false, true)
+
+		tc, err := NewClient(tester.GetNewRelicCtx(t, defaultNewRelicApp, defaultNewRelicTx), opts...)
+		require.NoError(t, err)
+		require.NotNil(t, tc)
+		defer CloseClient(context.Background(), t, tc)
+
+		assert.False(t, tc.IsAuditLogEnabled())
+		assert.Nil(t, tc.AuditLogExcludedModels())
+	})
+
+	t.Run("enabled with excluded models", func(t *testing.T) {
+		opts := DefaultClientOpts(false, true)
+		opts = append(opts, WithAuditLog(ModelSyncTransaction, ModelBlockHeader))
+
+		tc, err := NewClient(tester.GetNewRelicCtx(t, defaultNewRelicApp, defaultNewRelicTx), opts...)
+		require.NoError(t, err)
+		require.NotNil(t, tc)
+		defer CloseClient(context.Background(), t, tc)
+
+		assert.True(t, tc.IsAuditLogEnabled())
+		assert.Equal(t, []ModelName{ModelSyncTransaction, ModelBlockHeader}, tc.AuditLogExcludedModels())
+	})
+}
+
+// TestWithAuthTokenTTL will test the method WithAuthTokenTTL()
+func TestWithAuthTokenTTL(t *testing.T) {
+	t.Parallel()
+
+	t.Run("check type", func(t *testing.T) {
+		opt := WithAuthTokenTTL(0)
+		assert.IsType(t, *new(ClientOps), opt)
+	})
+
+	t.Run("test applying", func(t *testing.T) {
+		options := &clientOptions{authTokenTTL: defaultAuthTokenTTL}
+
+		WithAuthTokenTTL(0)(options)
+		assert.Equal(t, defaultAuthTokenTTL, options.authTokenTTL)
+
+		WithAuthTokenTTL(time.Hour)(options)
+		assert.Equal(t, time.Hour, options.authTokenTTL)
+	})
+}
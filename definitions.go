@@ -7,21 +7,43 @@ import (
 // Defaults for engine functionality
 const (
 	changeOutputSize               = uint64(35)       // Average size in bytes of a change output
+	defaultBatchMaxConcurrency     = 10               // Default number of concurrent recipient resolutions for batch payouts
+	defaultBatchMaxOutputs         = 1000             // Default maximum number of outputs per batch payout transaction
+	defaultBatchMaxTxSize          = uint64(1000000)  // Default maximum size in bytes per batch payout transaction
+	defaultBatchResolutionsPerSec  = 20               // Default rate limit for paymail resolutions in batch payouts
 	databaseLongReadTimeout        = 30 * time.Second // For all "GET" or "SELECT" methods
+	defaultApprovalExpiresIn       = 24 * time.Hour   // Default TTL for draft transactions pending approval
+	defaultAuditLogVerifyPageSize  = 100              // Number of audit log entries per page when verifying the chain
+	defaultAuthClockSkew           = 5 * time.Second  // Default allowed clock skew for signed requests
+	defaultAuthTokenTTL            = 15 * time.Minute // Default TTL for bearer tokens
 	defaultBroadcastTimeout        = 25 * time.Second // Default timeout for broadcasting
 	defaultCacheLockTTL            = 20               // in Seconds
 	defaultCacheLockTTW            = 10               // in Seconds
 	defaultDatabaseReadTimeout     = 20 * time.Second // For all "GET" or "SELECT" methods
 	defaultDraftTxExpiresIn        = 20 * time.Second // Default TTL for draft transactions
+	defaultEncryptionPageSize      = 100              // Number of records per page when rotating the encryption
+	defaultFinalityConfirmations   = uint64(6)        // Default number of confirmations before a transaction is final
+	defaultHeaderIndexOverlap      = 5 * time.Second  // Overlap of the block header index refresh (records saved while loading)
+	defaultHeaderIndexPageSize     = 10000            // Number of block headers per page when loading the block header index
+	defaultHeaderSyncBatchSize     = 500              // Number of block headers requested from the headers source at once
+	defaultHeaderSyncMaxHeaders    = 10000            // Maximum number of block headers recorded per header sync run
+	defaultHeaderSyncMaxReorgDepth = 100              // Maximum number of block headers to step back when following a reorg
 	defaultHTTPTimeout             = 20 * time.Second // Default timeout for HTTP requests
+	defaultImportGapLimit          = 10               // Default number of addresses without transactions before an import chain is done
+	defaultImportMaxRetries        = 5                // Number of retries of a failed import job step (before the job stops)
+	defaultImportRecordBatchSize   = 10               // Number of transactions recorded per import job step
+	defaultImportRetryDelay        = 5 * time.Second  // Delay before retrying a failed import job step (doubled per retry)
+	defaultImportTaskSteps         = 10               // Number of import job steps processed per job per task run
 	defaultMonitorHeartbeat        = 60               // in Seconds (heartbeat for active monitor)
 	defaultMonitorSleep            = 2 * time.Second
 	defaultMonitorLockTTL          = 10                // in seconds - should be larger than defaultMonitorSleep
 	defaultOverheadSize            = uint64(8)         // 8 bytes is the default overhead in a transaction = 4 bytes version + 4 bytes nLockTime
 	defaultQueryTxTimeout          = 10 * time.Second  // Default timeout for syncing on-chain information
+	defaultRequiredApprovals       = uint32(1)         // Default number of approvals for drafts pending approval
 	defaultSleepForNewBlockHeaders = 30 * time.Second  // Default wait before checking for a new unprocessed block
 	defaultUserAgent               = "bux: " + version // Default user agent
 	dustLimit                      = uint64(1)         // Dust limit
+	maxBroadcastAttempts           = 50                // Number of broadcast attempts (provider responses) kept per sync transaction
 	//mongoTestVersion               = "4.2.1"           // Mongo Testing Version
 	mongoTestVersion  = "6.0.4"   // Mongo Testing Version
 	sqliteTestVersion = "3.37.0"  // SQLite Testing Version (dummy version for now)
@@ -30,7 +52,11 @@ const (
 
 // Defaults for task cron jobs (tasks)
 const (
+	taskIntervalAuditLogSeal        = 10 * time.Second                      // Default task time for cron jobs (seconds)
+	taskIntervalBlockHeaderSync     = 30 * time.Second                      // Default task time for cron jobs (seconds)
 	taskIntervalDraftCleanup        = 60 * time.Second                      // Default task time for cron jobs (seconds)
+	taskIntervalEncryptionRotation  = 60 * time.Minute                      // Default task time for cron jobs (seconds)
+	taskIntervalImportJobProcess    = 30 * time.Second                      // Default task time for cron jobs (seconds)
 	taskIntervalMonitorCheck        = defaultMonitorHeartbeat * time.Second // Default task time for cron jobs (seconds)
 	taskIntervalProcessIncomingTxs  = 30 * time.Second                      // Default task time for cron jobs (seconds)
 	taskIntervalSyncActionBroadcast = 30 * time.Second                      // Default task time for cron jobs (seconds)
@@ -42,9 +68,15 @@ const (
 // All the base models
 const (
 	ModelAccessKey           ModelName = "access_key"
+	ModelAdminKey            ModelName = "admin_key"
+	ModelAuditLog            ModelName = "audit_log"
+	ModelBatchPayout         ModelName = "batch_payout"
 	ModelBlockHeader         ModelName = "block_header"
+	ModelCompactBlockHeader  ModelName = "compact_block_header"
 	ModelDestination         ModelName = "destination"
+	ModelDraftRecipient      ModelName = "draft_recipient"
 	ModelDraftTransaction    ModelName = "draft_transaction"
+	ModelImportJob           ModelName = "import_job"
 	ModelIncomingTransaction ModelName = "incoming_transaction"
 	ModelMetadata            ModelName = "metadata"
 	ModelNameEmpty           ModelName = "empty"
@@ -59,8 +91,14 @@ var (
 	// AllModelNames is a list of all models
 	AllModelNames = []ModelName{
 		ModelAccessKey,
+		ModelAdminKey,
+		ModelAuditLog,
+		ModelBatchPayout,
 		ModelBlockHeader,
+		ModelCompactBlockHeader,
 		ModelDestination,
+		ModelDraftRecipient,
+		ModelImportJob,
 		ModelIncomingTransaction,
 		ModelMetadata,
 		ModelPaymailAddress,
@@ -75,9 +113,15 @@ var (
 // Internal table names
 const (
 	tableAccessKeys           = "access_keys"
+	tableAdminKeys            = "admin_keys"
+	tableAuditLogs            = "audit_logs"
+	tableBatchPayouts         = "batch_payouts"
 	tableBlockHeaders         = "block_headers"
+	tableCompactBlockHeaders  = "compact_block_headers"
 	tableDestinations         = "destinations"
+	tableDraftRecipients      = "draft_recipients"
 	tableDraftTransactions    = "draft_transactions"
+	tableImportJobs           = "import_jobs"
 	tableIncomingTransactions = "incoming_transactions"
 	tablePaymailAddresses     = "paymail_addresses"
 	tableSyncTransactions     = "sync_transactions"
@@ -91,26 +135,38 @@ const (
 	ReferenceIDField = "reference_id"
 
 	// Internal field names
-	aliasField           = "alias"
-	broadcastStatusField = "broadcast_status"
-	createdAtField       = "created_at"
-	currentBalanceField  = "current_balance"
-	domainField          = "domain"
-	draftIDField         = "draft_id"
-	idField              = "id"
-	metadataField        = "metadata"
-	nextExternalNumField = "next_external_num"
-	nextInternalNumField = "next_internal_num"
-	p2pStatusField       = "p2p_status"
-	satoshisField        = "satoshis"
-	spendingTxIDField    = "spending_tx_id"
-	statusField          = "status"
-	syncStatusField      = "sync_status"
-	typeField            = "type"
-	xPubIDField          = "xpub_id"
-	xPubMetadataField    = "xpub_metadata"
-	blockHeightField     = "block_height"
-	blockHashField       = "block_hash"
+	accessKeyIDField      = "access_key_id"
+	actorXpubIDField      = "actor_xpub_id"
+	aliasField            = "alias"
+	broadcastStatusField  = "broadcast_status"
+	createdAtField        = "created_at"
+	currentBalanceField   = "current_balance"
+	deletedAtField        = "deleted_at"
+	domainField           = "domain"
+	draftIDField          = "draft_id"
+	externalXpubKeyField  = "external_xpub_key"
+	heightField           = "height"
+	idField               = "id"
+	metadataField         = "metadata"
+	modelIDField          = "model_id"
+	nextExternalNumField  = "next_external_num"
+	nextInternalNumField  = "next_internal_num"
+	p2pStatusField        = "p2p_status"
+	satoshisField         = "satoshis"
+	sequenceField         = "sequence"
+	spendingTxIDField     = "spending_tx_id"
+	statusField           = "status"
+	syncStatusField       = "sync_status"
+	timestampField        = "timestamp"
+	typeField             = "type"
+	unconfirmedDepthField = "unconfirmed_depth"
+	updatedAtField        = "updated_at"
+	xPubIDField           = "xpub_id"
+	xPubKeyField          = "xpub_key"
+	xPubMetadataField     = "xpub_metadata"
+	xPubOutputValueField  = "xpub_output_value"
+	blockHeightField      = "block_height"
+	blockHashField        = "block_hash"
 
 	// Universal statuses
 	statusCanceled   = "canceled"
@@ -118,6 +174,10 @@ const (
 	statusDraft      = "draft"
 	statusError      = "error"
 	statusExpired    = "expired"
+	statusFinal      = "final"
+	statusMempool    = "mempool"
+	statusMined      = "mined"
+	statusPartial    = "partial"
 	statusPending    = "pending"
 	statusProcessing = "processing"
 	statusReady      = "ready"
@@ -143,9 +203,12 @@ const (
 
 // Cache keys for model caching
 const (
+	cacheKeyAdminKeyModel                   = "admin-key-id-%s"               // model-id-<xpub_id>
+	cacheKeyAuthToken                       = "auth-token-%s"                 // auth-token-<token_hash>
 	cacheKeyDestinationModel                = "destination-id-%s"             // model-id-<destination_id>
 	cacheKeyDestinationModelByAddress       = "destination-address-%s"        // model-address-<address>
 	cacheKeyDestinationModelByLockingScript = "destination-locking-script-%s" // model-locking-script-<script>
+	cacheKeyEncryptionRotation              = "encryption-rotation"           // fingerprint of the last completed rotation
 	cacheKeyXpubModel                       = "xpub-id-%s"                    // model-id-<xpub_id>
 )
 
@@ -164,11 +227,36 @@ var (
 			Model: *NewBaseModel(ModelAccessKey),
 		},
 
+		// Admin keys (xPubs with admin access, by role)
+		&AdminKey{
+			Model: *NewBaseModel(ModelAdminKey),
+		},
+
+		// Audit log of all mutating operations (append-only, hash chained)
+		&AuditLog{
+			Model: *NewBaseModel(ModelAuditLog),
+		},
+
 		// Draft transactions are created before the final transaction is completed
 		&DraftTransaction{
 			Model: *NewBaseModel(ModelDraftTransaction),
 		},
 
+		// Recipients of the completed drafts (related to Draft)
+		&DraftRecipient{
+			Model: *NewBaseModel(ModelDraftRecipient),
+		},
+
+		// Batch payouts are split into multiple draft transactions (related to Draft)
+		&BatchPayout{
+			Model: *NewBaseModel(ModelBatchPayout),
+		},
+
+		// Import jobs of xPubs (related to Destination & Transaction)
+		&ImportJob{
+			Model: *NewBaseModel(ModelImportJob),
+		},
+
 		// Incoming transactions (external & unknown) (related to Transaction & Draft)
 		&IncomingTransaction{
 			Model: *NewBaseModel(ModelIncomingTransaction),
@@ -184,6 +272,11 @@ var (
 			Model: *NewBaseModel(ModelBlockHeader),
 		},
 
+		// Compact block headers (related to BlockHeader, loaded into the block header index)
+		&CompactBlockHeaderRecord{
+			Model: *NewBaseModel(ModelCompactBlockHeader),
+		},
+
 		// Sync configuration for transactions (on-chain) (related to Transaction)
 		&SyncTransaction{
 			Model: *NewBaseModel(ModelSyncTransaction),
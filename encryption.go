@@ -0,0 +1,256 @@
+package bux
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/BuxOrg/bux/utils"
+)
+
+const (
+	// envelopeEncryptionPrefix is the prefix (and version) of values encrypted using envelope encryption
+	//
+	// Format: env1:<key id>:<wrapped data key>:<encrypted value>
+	envelopeEncryptionPrefix = "env1:"
+
+	// encryptedMetadataPrefix is the prefix of (sensitive) metadata values that are encrypted
+	encryptedMetadataPrefix = "encrypted:"
+)
+
+// staticKeyEncryption encrypts all values using a single static key (see WithEncryption())
+type staticKeyEncryption struct {
+	key string // Encryption key (hex encoded key)
+}
+
+// NewStaticKeyEncryption will return an encryption provider using a single static key (hex encoded key)
+//
+// Values are encrypted using utils.Encrypt() (same as WithEncryption()), the key can not be rotated
+func NewStaticKeyEncryption(key string) EncryptionProvider {
+	return &staticKeyEncryption{key: key}
+}
+
+// ActiveKeyID will return an empty key id (the static key has no key id)
+func (s *staticKeyEncryption) ActiveKeyID() string {
+	return ""
+}
+
+// Encrypt will encrypt the value using the static key
+func (s *staticKeyEncryption) Encrypt(value string) (string, error) {
+	return utils.Encrypt(s.key, value)
+}
+
+// Decrypt will decrypt the value using the static key
+func (s *staticKeyEncryption) Decrypt(value string) (string, error) {
+	return utils.Decrypt(s.key, value)
+}
+
+// IsCurrent will always return true (there is only one key)
+func (s *staticKeyEncryption) IsCurrent(_ string) bool {
+	return true
+}
+
+// envelopeEncryption encrypts every value using a new data key, the data key is wrapped by the KMS (using a key id)
+type envelopeEncryption struct {
+	kms       KeyManagementService // KMS for wrapping & unwrapping the data keys
+	legacyKey string               // Static key for decrypting values from before the envelope encryption (optional)
+}
+
+// NewEnvelopeEncryption will return an encryption provider using envelope encryption
+//
+// Every value is encrypted with a new data key, and the data key is wrapped by the KMS using the active key id.
+// legacyKey is the static key (WithEncryption()) for decrypting existing values (optional)
+func NewEnvelopeEncryption(kms KeyManagementService, legacyKey string) (EncryptionProvider, error) {
+	if kms == nil {
+		return nil, ErrMissingKeyManagementService
+	}
+	return &envelopeEncryption{kms: kms, legacyKey: legacyKey}, nil
+}
+
+// ActiveKeyID will return the key id used for wrapping new data keys
+func (e *envelopeEncryption) ActiveKeyID() string {
+	return e.kms.ActiveKeyID()
+}
+
+// Encrypt will encrypt the value using a new data key (wrapped using the active key id)
+func (e *envelopeEncryption) Encrypt(value string) (string, error) {
+
+	// Create a new data key
+	dataKey, err := utils.RandomHex(32)
+	if err != nil {
+		return "", err
+	}
+
+	// Wrap the data key using the active key
+	keyID := e.kms.ActiveKeyID()
+	var wrappedKey string
+	if wrappedKey, err = e.kms.WrapKey(keyID, dataKey); err != nil {
+		return "", err
+	}
+
+	// Encrypt the value using the data key
+	var encrypted string
+	if encrypted, err = utils.Encrypt(dataKey, value); err != nil {
+		return "", err
+	}
+
+	return envelopeEncryptionPrefix + keyID + ":" + wrappedKey + ":" + encrypted, nil
+}
+
+// Decrypt will decrypt the value (unwrapping the data key using the key id of the value)
+func (e *envelopeEncryption) Decrypt(value string) (string, error) {
+
+	// Value from before the envelope encryption (static key)
+	if !strings.HasPrefix(value, envelopeEncryptionPrefix) {
+		if len(e.legacyKey) == 0 {
+			return "", ErrEncryptedValueInvalid
+		}
+		return utils.Decrypt(e.legacyKey, value)
+	}
+
+	keyID, wrappedKey, encrypted, err := parseEnvelopeValue(value)
+	if err != nil {
+		return "", err
+	}
+
+	// Unwrap the data key
+	var dataKey string
+	if dataKey, err = e.kms.UnwrapKey(keyID, wrappedKey); err != nil {
+		return "", err
+	}
+
+	return utils.Decrypt(dataKey, encrypted)
+}
+
+// IsCurrent will return true if the value was encrypted using the active key id
+func (e *envelopeEncryption) IsCurrent(value string) bool {
+	if !strings.HasPrefix(value, envelopeEncryptionPrefix) {
+		return false
+	}
+	keyID, _, _, err := parseEnvelopeValue(value)
+	return err == nil && keyID == e.kms.ActiveKeyID()
+}
+
+// parseEnvelopeValue will parse the key id, wrapped data key and the encrypted value from the value
+func parseEnvelopeValue(value string) (keyID, wrappedKey, encrypted string, err error) {
+	parts := strings.Split(strings.TrimPrefix(value, envelopeEncryptionPrefix), ":")
+	if len(parts) != 3 || len(parts[0]) == 0 || len(parts[1]) == 0 || len(parts[2]) == 0 {
+		err = ErrEncryptedValueInvalid
+		return
+	}
+	return parts[0], parts[1], parts[2], nil
+}
+
+// localKMS is a local (in memory) stand-in for a key management service
+type localKMS struct {
+	activeKeyID string            // Key id for wrapping new data keys
+	keys        map[string]string // Key encryption keys by key id (hex encoded keys)
+}
+
+// NewLocalKMS will return a local (in memory) key management service using the given keys (key id: hex encoded key)
+//
+// Used for development & testing, or as a stand-in for a real KMS. To rotate the keys: add a new key,
+// make it the active key and keep the old key(s) until all values have been re-encrypted (see RotateEncryption())
+func NewLocalKMS(activeKeyID string, keys map[string]string) (KeyManagementService, error) {
+	kms := &localKMS{
+		activeKeyID: activeKeyID,
+		keys:        make(map[string]string, len(keys)),
+	}
+	for keyID, key := range keys {
+		if len(keyID) == 0 || strings.Contains(keyID, ":") {
+			return nil, ErrInvalidEncryptionKeyID
+		} else if len(key) == 0 {
+			return nil, ErrMissingEncryptionKey
+		}
+		kms.keys[keyID] = key
+	}
+	if _, ok := kms.keys[activeKeyID]; !ok {
+		return nil, ErrMissingEncryptionKey
+	}
+	return kms, nil
+}
+
+// ActiveKeyID will return the key id used for wrapping new data keys
+func (k *localKMS) ActiveKeyID() string {
+	return k.activeKeyID
+}
+
+// WrapKey will encrypt the data key using the key (by key id)
+func (k *localKMS) WrapKey(keyID, dataKey string) (string, error) {
+	key, ok := k.keys[keyID]
+	if !ok {
+		return "", ErrMissingEncryptionKey
+	}
+	return utils.Encrypt(key, dataKey)
+}
+
+// UnwrapKey will decrypt the data key using the key (by key id)
+func (k *localKMS) UnwrapKey(keyID, wrappedKey string) (string, error) {
+	key, ok := k.keys[keyID]
+	if !ok {
+		return "", ErrMissingEncryptionKey
+	}
+	return utils.Decrypt(key, wrappedKey)
+}
+
+// encryptMetadataValue will encrypt the (JSON encoded) metadata value
+func encryptMetadataValue(provider EncryptionProvider, value interface{}) (string, error) {
+	b, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	var encrypted string
+	if encrypted, err = provider.Encrypt(string(b)); err != nil {
+		return "", err
+	}
+	return encryptedMetadataPrefix + encrypted, nil
+}
+
+// decryptMetadataValue will decrypt the metadata value (if the value was encrypted)
+func decryptMetadataValue(provider EncryptionProvider, value interface{}) (interface{}, error) {
+	encrypted, ok := getEncryptedMetadataValue(value)
+	if !ok {
+		return value, nil
+	} else if provider == nil {
+		return nil, ErrMissingEncryptionProvider
+	}
+	decrypted, err := provider.Decrypt(encrypted)
+	if err != nil {
+		return nil, err
+	}
+	var decoded interface{}
+	if err = json.Unmarshal([]byte(decrypted), &decoded); err != nil {
+		return nil, fmt.Errorf("failed decoding the metadata value: %w", err)
+	}
+	return decoded, nil
+}
+
+// getEncryptedMetadataValue will return the encrypted value (without the prefix) if the metadata value was encrypted
+func getEncryptedMetadataValue(value interface{}) (string, bool) {
+	s, ok := value.(string)
+	if !ok || !strings.HasPrefix(s, encryptedMetadataPrefix) {
+		return "", false
+	}
+	return strings.TrimPrefix(s, encryptedMetadataPrefix), true
+}
+
+// sensitiveFieldsEncrypter is a model with sensitive fields that are encrypted at rest (all models, see Model)
+type sensitiveFieldsEncrypter interface {
+	encryptSensitiveFields() (bool, error)
+	getEncryptedFields() map[string]interface{}
+}
+
+// DecryptMetadata will return a copy of the metadata with all the encrypted (sensitive) values decrypted
+func (c *Client) DecryptMetadata(metadata Metadata) (Metadata, error) {
+	if metadata == nil {
+		return nil, nil
+	}
+	decrypted := make(Metadata, len(metadata))
+	for key, value := range metadata {
+		var err error
+		if decrypted[key], err = decryptMetadataValue(c.Encryption(), value); err != nil {
+			return nil, err
+		}
+	}
+	return decrypted, nil
+}
@@ -0,0 +1,266 @@
+package bux
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/BuxOrg/bux/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestLocalKMS will return a local KMS with two keys (key-1 is active)
+func newTestLocalKMS(t *testing.T) *localKMS {
+	key1, err := utils.RandomHex(32)
+	require.NoError(t, err)
+	var key2 string
+	key2, err = utils.RandomHex(32)
+	require.NoError(t, err)
+
+	var kms KeyManagementService
+	kms, err = NewLocalKMS("key-1", map[string]string{"key-1": key1, "key-2": key2})
+	require.NoError(t, err)
+	return kms.(*localKMS)
+}
+
+// TestNewStaticKeyEncryption will test the method NewStaticKeyEncryption()
+func TestNewStaticKeyEncryption(t *testing.T) {
+	t.Parallel()
+
+	t.Run("encrypt & decrypt", func(t *testing.T) {
+		provider := NewStaticKeyEncryption(testEncryption)
+		require.NotNil(t, provider)
+		assert.Equal(t, "", provider.ActiveKeyID())
+
+		encrypted, err := provider.Encrypt(testXPub)
+		require.NoError(t, err)
+		assert.NotEqual(t, testXPub, encrypted)
+		assert.Equal(t, true, provider.IsCurrent(encrypted))
+
+		var decrypted string
+		decrypted, err = provider.Decrypt(encrypted)
+		require.NoError(t, err)
+		assert.Equal(t, testXPub, decrypted)
+
+		// Same format as utils.Encrypt()
+		decrypted, err = utils.Decrypt(testEncryption, encrypted)
+		require.NoError(t, err)
+		assert.Equal(t, testXPub, decrypted)
+	})
+}
+
+// TestNewLocalKMS will test the method NewLocalKMS()
+func TestNewLocalKMS(t *testing.T) {
+	t.Parallel()
+
+	t.Run("error - missing active key", func(t *testing.T) {
+		kms, err := NewLocalKMS("key-2", map[string]string{"key-1": testEncryption})
+		require.ErrorIs(t, err, ErrMissingEncryptionKey)
+		require.Nil(t, kms)
+	})
+
+	t.Run("error - invalid key id", func(t *testing.T) {
+		kms, err := NewLocalKMS("key:1", map[string]string{"key:1": testEncryption})
+		require.ErrorIs(t, err, ErrInvalidEncryptionKeyID)
+		require.Nil(t, kms)
+	})
+
+	t.Run("error - empty key", func(t *testing.T) {
+		kms, err := NewLocalKMS("key-1", map[string]string{"key-1": ""})
+		require.ErrorIs(t, err, ErrMissingEncryptionKey)
+		require.Nil(t, kms)
+	})
+
+	t.Run("wrap & unwrap", func(t *testing.T) {
+		kms := newTestLocalKMS(t)
+		assert.Equal(t, "key-1", kms.ActiveKeyID())
+
+		dataKey, err := utils.RandomHex(32)
+		require.NoError(t, err)
+
+		var wrapped string
+		wrapped, err = kms.WrapKey("key-2", dataKey)
+		require.NoError(t, err)
+
+		var unwrapped string
+		unwrapped, err = kms.UnwrapKey("key-2", wrapped)
+		require.NoError(t, err)
+		assert.Equal(t, dataKey, unwrapped)
+
+		_, err = kms.UnwrapKey("key-3", wrapped)
+		require.ErrorIs(t, err, ErrMissingEncryptionKey)
+	})
+}
+
+// TestNewEnvelopeEncryption will test the method NewEnvelopeEncryption()
+func TestNewEnvelopeEncryption(t *testing.T) {
+	t.Parallel()
+
+	t.Run("error - missing kms", func(t *testing.T) {
+		provider, err := NewEnvelopeEncryption(nil, "")
+		require.ErrorIs(t, err, ErrMissingKeyManagementService)
+		require.Nil(t, provider)
+	})
+
+	t.Run("encrypt & decrypt - rotate key", func(t *testing.T) {
+		kms := newTestLocalKMS(t)
+		provider, err := NewEnvelopeEncryption(kms, "")
+		require.NoError(t, err)
+		assert.Equal(t, "key-1", provider.ActiveKeyID())
+
+		var encrypted string
+		encrypted, err = provider.Encrypt(testXPub)
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(encrypted, envelopeEncryptionPrefix+"key-1:"))
+		assert.Equal(t, true, provider.IsCurrent(encrypted))
+
+		// Every value uses a new data key
+		var encrypted2 string
+		encrypted2, err = provider.Encrypt(testXPub)
+		require.NoError(t, err)
+		assert.NotEqual(t, encrypted, encrypted2)
+
+		// Rotate the active key
+		kms.activeKeyID = "key-2"
+		assert.Equal(t, false, provider.IsCurrent(encrypted))
+
+		var decrypted string
+		decrypted, err = provider.Decrypt(encrypted)
+		require.NoError(t, err)
+		assert.Equal(t, testXPub, decrypted)
+
+		// Remove the old key
+		delete(kms.keys, "key-1")
+		_, err = provider.Decrypt(encrypted)
+		require.ErrorIs(t, err, ErrMissingEncryptionKey)
+	})
+
+	t.Run("legacy key", func(t *testing.T) {
+		legacyValue, err := utils.Encrypt(testEncryption, testXPub)
+		require.NoError(t, err)
+
+		var provider EncryptionProvider
+		provider, err = NewEnvelopeEncryption(newTestLocalKMS(t), testEncryption)
+		require.NoError(t, err)
+		assert.Equal(t, false, provider.IsCurrent(legacyValue))
+
+		var decrypted string
+		decrypted, err = provider.Decrypt(legacyValue)
+		require.NoError(t, err)
+		assert.Equal(t, testXPub, decrypted)
+
+		// Without the legacy key
+		provider, err = NewEnvelopeEncryption(newTestLocalKMS(t), "")
+		require.NoError(t, err)
+		_, err = provider.Decrypt(legacyValue)
+		require.ErrorIs(t, err, ErrEncryptedValueInvalid)
+	})
+
+	t.Run("error - invalid value", func(t *testing.T) {
+		provider, err := NewEnvelopeEncryption(newTestLocalKMS(t), "")
+		require.NoError(t, err)
+
+		_, err = provider.Decrypt(envelopeEncryptionPrefix + "key-1:missing-parts")
+		require.ErrorIs(t, err, ErrEncryptedValueInvalid)
+		assert.Equal(t, false, provider.IsCurrent(envelopeEncryptionPrefix+"key-1:missing-parts"))
+	})
+}
+
+// TestModel_encryptSensitiveFields will test the method encryptSensitiveFields()
+func TestModel_encryptSensitiveFields(t *testing.T) {
+
+	t.Run("no encryption", func(t *testing.T) {
+		_, client, deferMe := CreateTestSQLiteClient(t, false, false, WithSensitiveMetadata("secret"))
+		defer deferMe()
+
+		m := NewBaseModel(ModelXPub, WithClient(client))
+		m.Metadata = Metadata{"secret": "value"}
+		changed, err := m.encryptSensitiveFields()
+		require.NoError(t, err)
+		assert.Equal(t, false, changed)
+		assert.Equal(t, "value", m.Metadata["secret"])
+	})
+
+	t.Run("encrypt & rotate", func(t *testing.T) {
+		kms := newTestLocalKMS(t)
+		provider, err := NewEnvelopeEncryption(kms, "")
+		require.NoError(t, err)
+
+		_, client, deferMe := CreateTestSQLiteClient(
+			t, false, false, WithEncryptionProvider(provider), WithSensitiveMetadata("secret"),
+		)
+		defer deferMe()
+
+		metadata := Metadata{"secret": map[string]interface{}{"pin": "1234"}, "public": "value"}
+		m := NewBaseModel(ModelXPub, WithClient(client))
+		m.Metadata = metadata
+
+		var changed bool
+		changed, err = m.encryptSensitiveFields()
+		require.NoError(t, err)
+		assert.Equal(t, true, changed)
+		assert.Equal(t, "value", m.Metadata["public"])
+		encrypted, ok := getEncryptedMetadataValue(m.Metadata["secret"])
+		require.True(t, ok)
+		assert.Equal(t, true, provider.IsCurrent(encrypted))
+
+		// Original map is not changed
+		assert.Equal(t, map[string]interface{}{"pin": "1234"}, metadata["secret"])
+
+		// Already encrypted
+		changed, err = m.encryptSensitiveFields()
+		require.NoError(t, err)
+		assert.Equal(t, false, changed)
+
+		// Rotate the key
+		kms.activeKeyID = "key-2"
+		changed, err = m.encryptSensitiveFields()
+		require.NoError(t, err)
+		assert.Equal(t, true, changed)
+		encrypted, ok = getEncryptedMetadataValue(m.Metadata["secret"])
+		require.True(t, ok)
+		assert.Equal(t, true, provider.IsCurrent(encrypted))
+
+		var decrypted Metadata
+		decrypted, err = client.DecryptMetadata(m.Metadata)
+		require.NoError(t, err)
+		assert.Equal(t, Metadata{"secret": map[string]interface{}{"pin": "1234"}, "public": "value"}, decrypted)
+	})
+}
+
+// TestClient_DecryptMetadata will test the method DecryptMetadata()
+func TestClient_DecryptMetadata(t *testing.T) {
+
+	t.Run("nil metadata", func(t *testing.T) {
+		_, client, deferMe := CreateTestSQLiteClient(t, false, false)
+		defer deferMe()
+
+		metadata, err := client.DecryptMetadata(nil)
+		require.NoError(t, err)
+		assert.Nil(t, metadata)
+	})
+
+	t.Run("error - missing encryption", func(t *testing.T) {
+		_, client, deferMe := CreateTestSQLiteClient(t, false, false)
+		defer deferMe()
+
+		encrypted, err := encryptMetadataValue(NewStaticKeyEncryption(testEncryption), "value")
+		require.NoError(t, err)
+
+		_, err = client.DecryptMetadata(Metadata{"secret": encrypted})
+		require.ErrorIs(t, err, ErrMissingEncryptionProvider)
+	})
+
+	t.Run("decrypt values", func(t *testing.T) {
+		_, client, deferMe := CreateTestSQLiteClient(t, false, false, WithEncryption(testEncryption))
+		defer deferMe()
+
+		encrypted, err := encryptMetadataValue(client.Encryption(), float64(42))
+		require.NoError(t, err)
+
+		var metadata Metadata
+		metadata, err = client.DecryptMetadata(Metadata{"secret": encrypted, "public": "value"})
+		require.NoError(t, err)
+		assert.Equal(t, Metadata{"secret": float64(42), "public": "value"}, metadata)
+	})
+}
@@ -2,6 +2,8 @@ package bux
 
 import (
 	"errors"
+
+	"github.com/BuxOrg/bux/auth"
 )
 
 // ErrCannotConvertToIDs is the error when the conversion fails from interface into type IDs
@@ -106,12 +108,39 @@ var ErrMissingTxHex = errors.New("transaction hex is empty or id is missing")
 // ErrMissingBlockHeaderHash is when the hash is missing or invalid and creates an empty id
 var ErrMissingBlockHeaderHash = errors.New("block header hash is empty or id is missing")
 
+// ErrTooManyUnconfirmedAncestors is when spending the utxos would exceed the maximum unconfirmed ancestor chain depth
+var ErrTooManyUnconfirmedAncestors = errors.New("transaction exceeds the maximum depth of unconfirmed ancestors")
+
 // ErrUtxoAlreadySpent is when the utxo is already spent, but is trying to be used
 var ErrUtxoAlreadySpent = errors.New("utxo has already been spent")
 
 // ErrDraftNotFound is when the requested draft transaction was not found
 var ErrDraftNotFound = errors.New("corresponding draft transaction not found")
 
+// ErrDraftRequiresApproval is when the draft transaction exceeds a spending limit and has not been approved
+var ErrDraftRequiresApproval = errors.New("draft transaction exceeds a spending limit and requires approval")
+
+// ErrDraftRejected is when the draft transaction was rejected by an approver
+var ErrDraftRejected = errors.New("draft transaction was rejected")
+
+// ErrDraftNotPendingApproval is when the draft transaction is not pending approval
+var ErrDraftNotPendingApproval = errors.New("draft transaction is not pending approval")
+
+// ErrDraftApproverIsOwner is when the owner of the draft tries to approve (or reject) its own draft
+var ErrDraftApproverIsOwner = errors.New("draft transaction can not be approved by its owner")
+
+// ErrDraftApproverNotAllowed is when the approver is not in the list of approvers of the approval policy
+var ErrDraftApproverNotAllowed = errors.New("approver is not allowed to approve draft transactions")
+
+// ErrDraftApproverNotAuthenticated is when the approver is not the xPub (or admin) that authenticated the request
+var ErrDraftApproverNotAuthenticated = errors.New("approver is not the authenticated xpub")
+
+// ErrDraftApproverUnknown is when the approver is not a known xPub or admin key
+var ErrDraftApproverUnknown = errors.New("approver is not a known xpub or admin key")
+
+// ErrDraftAlreadyDecided is when the approver already approved (or rejected) the draft
+var ErrDraftAlreadyDecided = errors.New("approver already approved or rejected the draft transaction")
+
 // ErrTaskManagerNotLoaded is when the taskmanager was not loaded
 var ErrTaskManagerNotLoaded = errors.New("taskmanager must be loaded")
 
@@ -140,31 +169,58 @@ var ErrUnsupportedDestinationType = errors.New("unsupported destination type")
 var ErrMissingAuthHeader = errors.New("missing authentication header")
 
 // ErrMissingSignature is when the signature is missing from the request
-var ErrMissingSignature = errors.New("signature missing")
+var ErrMissingSignature = auth.ErrMissingSignature
 
 // ErrAuhHashMismatch is when the auth hash does not match the body hash
-var ErrAuhHashMismatch = errors.New("auth hash and body hash do not match")
+var ErrAuhHashMismatch = auth.ErrAuthHashMismatch
+
+// ErrAuthTokenInvalid is when the bearer token is unknown, revoked or expired
+var ErrAuthTokenInvalid = errors.New("auth token is invalid or expired")
 
 // ErrAuthAccessKeyNotFound is when the auth access key could not be found in the database
 var ErrAuthAccessKeyNotFound = errors.New("auth access key could not be found")
 
 // ErrSignatureExpired is when the signature TTL expired
-var ErrSignatureExpired = errors.New("signature has expired")
+var ErrSignatureExpired = auth.ErrSignatureExpired
+
+// ErrSignatureInFuture is when the signature time is too far in the future (beyond the allowed clock skew)
+var ErrSignatureInFuture = auth.ErrSignatureInFuture
+
+// ErrMissingSignatureNonce is when the nonce is missing from the signature
+var ErrMissingSignatureNonce = errors.New("signature nonce missing")
+
+// ErrSignatureNonceReused is when the nonce of the signature has already been used (replayed request)
+var ErrSignatureNonceReused = errors.New("signature nonce has already been used")
 
 // ErrNotAdminKey is when the xpub being used is not considered an admin key
 var ErrNotAdminKey = errors.New("xpub provided is not an admin key")
 
+// ErrInvalidAdminRole is when the admin role is unknown
+var ErrInvalidAdminRole = errors.New("invalid admin role")
+
+// ErrAdminKeyExists is when the xpub is already an (active) admin key
+var ErrAdminKeyExists = errors.New("xpub is already an admin key")
+
+// ErrAdminKeysExist is when bootstrapping the first admin key, but there are already admin keys
+var ErrAdminKeysExist = errors.New("admin keys already exist")
+
+// ErrMissingAdminKey is when the admin key could not be found
+var ErrMissingAdminKey = errors.New("missing admin key")
+
+// ErrAdminNotAllowed is when the role of the admin does not allow the operation
+var ErrAdminNotAllowed = errors.New("admin role does not allow this operation")
+
 // ErrMissingXPriv is when the xPriv is missing
-var ErrMissingXPriv = errors.New("missing xPriv key")
+var ErrMissingXPriv = auth.ErrMissingXPriv
 
 // ErrMissingAccessKey is when the access key is missing
-var ErrMissingAccessKey = errors.New("missing access key")
+var ErrMissingAccessKey = auth.ErrMissingAccessKey
 
 // ErrMissingBody is when the body is missing
 var ErrMissingBody = errors.New("missing body")
 
 // ErrSignatureInvalid is when the signature failed to be valid
-var ErrSignatureInvalid = errors.New("signature invalid")
+var ErrSignatureInvalid = auth.ErrSignatureInvalid
 
 // ErrUnknownAccessKey is when the access key is unknown or not found
 var ErrUnknownAccessKey = errors.New("unknown access key")
@@ -172,6 +228,18 @@ var ErrUnknownAccessKey = errors.New("unknown access key")
 // ErrAccessKeyRevoked is when the access key has been revoked
 var ErrAccessKeyRevoked = errors.New("access key has been revoked")
 
+// ErrAccessKeyExpired is when the access key has expired
+var ErrAccessKeyExpired = errors.New("access key has expired")
+
+// ErrAccessKeyIPNotAllowed is when the access key is used from an ip that is not allowed by its scope
+var ErrAccessKeyIPNotAllowed = errors.New("access key is not allowed from this ip")
+
+// ErrInvalidAccessKeyScope is when the access key scope is invalid
+var ErrInvalidAccessKeyScope = errors.New("invalid access key scope")
+
+// ErrAccessKeyExpiresInPast is when the access key expiry time is in the past
+var ErrAccessKeyExpiresInPast = errors.New("access key expiry time is in the past")
+
 // ErrMissingPaymail missing paymail
 var ErrMissingPaymail = errors.New("missing paymail")
 
@@ -195,3 +263,88 @@ var ErrMissingPaymailXPubID = errors.New("missing xpub_id in paymail")
 
 // ErrMissingClient missing client from model
 var ErrMissingClient = errors.New("client is missing from model, cannot save")
+
+// ErrMissingBatchRecipients is when a batch payout has no recipients
+var ErrMissingBatchRecipients = errors.New("batch payout is missing recipients")
+
+// ErrBatchPayoutNotFound is when the batch payout could not be found
+var ErrBatchPayoutNotFound = errors.New("batch payout not found")
+
+// ErrBatchPayoutComplete is when the batch payout has already been completed
+var ErrBatchPayoutComplete = errors.New("batch payout is already complete")
+
+// ErrBatchPayoutFailed is when a transaction of the batch payout was not broadcast (the remaining recipients failed)
+var ErrBatchPayoutFailed = errors.New("batch payout failed, a transaction was not broadcast")
+
+// ErrBatchPayoutDraftPending is when the current draft of the batch payout has not been recorded yet
+var ErrBatchPayoutDraftPending = errors.New("current batch payout draft transaction has not been recorded")
+
+// ErrBatchRecipientsUnresolved is when none of the batch payout recipients could be resolved
+var ErrBatchRecipientsUnresolved = errors.New("none of the batch payout recipients could be resolved")
+
+// ErrAuditLogTampered is when the hash chain of the audit log is broken
+var ErrAuditLogTampered = errors.New("audit log hash chain is broken")
+
+// ErrAuditLogAppendOnly is when an audit log entry is being changed
+var ErrAuditLogAppendOnly = errors.New("audit log entries can not be changed")
+
+// ErrMissingEncryptionProvider is when a value is encrypted, but no encryption provider (key) is set
+var ErrMissingEncryptionProvider = errors.New("missing encryption provider")
+
+// ErrMissingKeyManagementService is when the envelope encryption has no key management service
+var ErrMissingKeyManagementService = errors.New("missing key management service")
+
+// ErrMissingEncryptionKey is when the encryption key (by key id) could not be found
+var ErrMissingEncryptionKey = errors.New("encryption key could not be found")
+
+// ErrInvalidEncryptionKeyID is when the key id is empty or contains invalid characters
+var ErrInvalidEncryptionKeyID = errors.New("encryption key id is invalid")
+
+// ErrEncryptedValueInvalid is when the encrypted value is not in a known format
+var ErrEncryptedValueInvalid = errors.New("encrypted value is invalid")
+
+// ErrImportJobNotFound is when the import job could not be found
+var ErrImportJobNotFound = errors.New("import job not found")
+
+// ErrImportJobFinished is when the import job has already finished (complete or error)
+var ErrImportJobFinished = errors.New("import job has already finished")
+
+// ErrMissingImportJobXpub is when the import job is missing the xPub to import
+var ErrMissingImportJobXpub = errors.New("import job is missing the xpub")
+
+// ErrImportTransactionNotFound is when the raw transaction of a found transaction could not be retrieved
+var ErrImportTransactionNotFound = errors.New("raw transaction not found for import")
+
+// ErrImportJobCanceled is when the import job was canceled before it was finished
+var ErrImportJobCanceled = errors.New("import job was canceled")
+
+// ErrMissingAddressHistoryProvider is when the address history provider (chainstate) is missing
+var ErrMissingAddressHistoryProvider = errors.New("missing address history provider")
+
+// ErrUnknownImportProfile is when the import profile is not found
+var ErrUnknownImportProfile = errors.New("unknown import profile")
+
+// ErrInvalidImportPath is when the derivation path of the import profile is invalid (or hardened)
+var ErrInvalidImportPath = errors.New("invalid import derivation path")
+
+// ErrInvalidImportDescriptor is when the output descriptor could not be parsed (or uses different keys)
+var ErrInvalidImportDescriptor = errors.New("invalid import descriptor")
+
+// ErrInvalidBlockHeaderHash is when the block header data does not match the block hash
+var ErrInvalidBlockHeaderHash = errors.New("block header data does not match the block hash")
+
+// ErrInvalidBlockHeaderPoW is when the block header hash does not meet the target (proof-of-work)
+var ErrInvalidBlockHeaderPoW = errors.New("block header hash does not meet the proof-of-work target")
+
+// ErrInvalidBlockHeaderDifficulty is when the difficulty (bits) of the block header is not the difficulty expected
+// from the previous block headers (difficulty adjustment rules of the network)
+var ErrInvalidBlockHeaderDifficulty = errors.New("block header difficulty does not match the expected difficulty")
+
+// ErrBlockHeaderChainMismatch is when the previous block hash does not match the block header at the previous height
+var ErrBlockHeaderChainMismatch = errors.New("block header does not chain to the previous block header")
+
+// ErrBlockHeaderHeightMismatch is when the height of the block header does not follow the height of the previous block
+var ErrBlockHeaderHeightMismatch = errors.New("block header height does not follow the previous block header")
+
+// ErrBlockHeaderNotFound is when the block header could not be found (on the longest chain)
+var ErrBlockHeaderNotFound = errors.New("block header not found")
@@ -1,30 +1,37 @@
 package bux
 
 import (
-	"bufio"
-	"bytes"
 	"context"
-	"errors"
-	"io"
-	"net/http"
-	"os"
-	"strconv"
+	"database/sql/driver"
+	"sort"
 
+	"github.com/BuxOrg/bux/chainstate"
 	"github.com/BuxOrg/bux/utils"
+	"github.com/libsv/go-bt"
 	"github.com/mrz1836/go-whatsonchain"
-	"github.com/tidwall/gjson"
 )
 
 // ImportResults are the results from the import
 type ImportResults struct {
 	ExternalAddresses         int      `json:"external_addresses"`
 	InternalAddresses         int      `json:"internal_addresses"`
+	OtherAddresses            int      `json:"other_addresses"` // Addresses on the other paths of the import profile
 	AddressesWithTransactions []string `json:"addresses_with_transactions"`
 	Key                       string   `json:"key"`
 	TransactionsFound         int      `json:"transactions_found"`
 	TransactionsImported      int      `json:"transactions_imported"`
 }
 
+// Scan will scan the value into Struct, implements sql.Scanner interface
+func (r *ImportResults) Scan(value interface{}) error {
+	return scanJSONValue(value, r)
+}
+
+// Value return json value, implement driver.Valuer interface
+func (r ImportResults) Value() (driver.Value, error) {
+	return jsonValue(r)
+}
+
 /*
 // getUnspentTransactionsFromAddresses will get all unspent transactions related to address
 func getUnspentTransactionsFromAddresses(ctx context.Context, client whatsonchain.ClientInterface, addressList whatsonchain.AddressList) ([]*whatsonchain.HistoryRecord, error) {
@@ -62,104 +69,179 @@ func getAllTransactionsFromAddresses(ctx context.Context, client whatsonchain.Cl
 }
 */
 
-// deriveAddresses will derive a new set of addresses for a xpub
-func (c *Client) deriveAddresses(ctx context.Context, xpub string, chain uint32, amount int) ([]string, error) {
-	var addressList []string
-	for i := 0; i < amount; i++ {
-		destination, err := c.NewDestination(ctx, xpub, chain, utils.ScriptTypePubKeyHash, false, c.DefaultModelOptions()...)
-		if err != nil {
-			return []string{}, err
+// deriveAddresses will derive (and save) a set of addresses for the xPub chain, starting at the given num
+//
+// Derivation by num is deterministic (existing destinations are skipped), and the next num of the xPub
+// is moved past the derived addresses
+func deriveAddresses(ctx context.Context, rawXpubKey string, chain, fromNum uint32, amount int,
+	opts ...ModelOps) ([]string, error) {
+
+	// Get the xPub
+	xPub, err := getXpubByID(ctx, utils.Hash(rawXpubKey), opts...)
+	if err != nil {
+		return nil, err
+	} else if xPub == nil {
+		return nil, ErrMissingXpub
+	}
+
+	addressList := make([]string, 0, amount)
+	for num := fromNum; num < fromNum+uint32(amount); num++ {
+		var destination *Destination
+		if destination, err = newAddress(
+			rawXpubKey, chain, num, append(opts, New())...,
+		); err != nil {
+			return nil, err
 		}
 		addressList = append(addressList, destination.Address)
+
+		// Save the destination (if not found)
+		var existing *Destination
+		if existing, err = getDestinationByID(ctx, destination.ID, opts...); err != nil {
+			return nil, err
+		} else if existing != nil {
+			continue
+		}
+		if err = destination.Save(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	// Make sure new destinations are not derived from the same num
+	if err = xPub.incrementNextNumTo(ctx, chain, fromNum+uint32(amount)); err != nil {
+		return nil, err
 	}
+
 	return addressList, nil
 }
 
-// removeDuplicates will remove duplicate transactions
-func removeDuplicates(transactions []*whatsonchain.HistoryRecord) []*whatsonchain.HistoryRecord {
-	keys := make(map[string]bool)
-	var list []*whatsonchain.HistoryRecord
-
-	for _, tx := range transactions {
-		if _, value := keys[tx.TxHash]; !value {
-			keys[tx.TxHash] = true
-			list = append(list, tx)
-		}
+// getAddressHistoryProvider will return the address history provider from chainstate
+func getAddressHistoryProvider(client ClientInterface) (chainstate.AddressHistoryProvider, error) {
+	if client.Chainstate() == nil || client.Chainstate().AddressHistory() == nil {
+		return nil, ErrMissingAddressHistoryProvider
 	}
-	return list
+	return client.Chainstate().AddressHistory(), nil
 }
 
-func getTransactionsFromAddressesViaBitbus(addresses []string) ([]*whatsonchain.HistoryRecord, error) {
-	var transactions []*whatsonchain.HistoryRecord
-	parentQuery := []byte(`
-  {
-    "q": {
-			"find": { "$or": [ { "out.e.a": "ADDRESS" }, {"in.e.a": "ADDRESS"} ] },
-      "sort": { "blk.i": 1 },
-      "project": { "blk": 1, "tx.h": 1, "out.f3": 1 },
-      "limit": LIMIT,
-			"skip": OFFSET
-    }
-  }`)
+// getAddressHistory will get all the (unique) transactions from the history of the given addresses
+//
+// The block height of a transaction is zero if not known (or unconfirmed)
+func getAddressHistory(ctx context.Context, client ClientInterface,
+	addresses []string) ([]*chainstate.AddressHistoryRecord, error) {
+
+	provider, err := getAddressHistoryProvider(client)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]*chainstate.AddressHistoryRecord)
+	var records []*chainstate.AddressHistoryRecord
 	for _, address := range addresses {
-		doneWithPagination := false
-		offset := 0
-		limit := 100
-		for !doneWithPagination {
-			query := bytes.Replace(parentQuery, []byte("ADDRESS"), []byte(address), 2)
-			query = bytes.Replace(query, []byte("OFFSET"), []byte(strconv.Itoa(offset)), 1)
-			query = bytes.Replace(query, []byte("LIMIT"), []byte(strconv.Itoa(limit)), 1)
-			txs, err := bitbusRequest(query)
-			if err != nil {
-				return transactions, err
+		var history []*chainstate.AddressHistoryRecord
+		if history, err = provider.AddressHistory(ctx, address); err != nil {
+			return nil, err
+		}
+		for _, record := range history {
+			if existing, ok := keys[record.TxID]; ok {
+				if existing.BlockHeight <= 0 {
+					existing.BlockHeight = record.BlockHeight
+				}
+				continue
 			}
-			transactions = append(transactions, txs...)
-			if len(txs) < 100 {
-				doneWithPagination = true
+			keys[record.TxID] = &chainstate.AddressHistoryRecord{
+				BlockHeight: record.BlockHeight,
+				TxID:        record.TxID,
 			}
-			offset += 100
+			records = append(records, keys[record.TxID])
 		}
 	}
-
-	return transactions, nil
+	return records, nil
 }
 
-func bitbusRequest(query []byte) ([]*whatsonchain.HistoryRecord, error) {
-	planariaToken := os.Getenv("PLANARIA_TOKEN")
-	client := http.Client{}
-	var transactions []*whatsonchain.HistoryRecord
-	req, err := http.NewRequestWithContext(
-		context.Background(), http.MethodPost, "https://txo.bitbus.network/block", bytes.NewBuffer(query),
-	)
+// getRawTransactions will get the raw transaction data (hex, block height & inputs) for the given tx ids
+func getRawTransactions(ctx context.Context, client ClientInterface, txIDs []string) (whatsonchain.TxList, error) {
+
+	provider, err := getAddressHistoryProvider(client)
 	if err != nil {
 		return nil, err
 	}
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("token", planariaToken)
 
-	var resp *http.Response
-	if resp, err = client.Do(req); err != nil {
+	// Get the raw transactions from the provider
+	var transactions []*chainstate.RawTransaction
+	if transactions, err = provider.RawTransactions(ctx, txIDs); err != nil {
 		return nil, err
 	}
-	defer func() {
-		_ = resp.Body.Close()
-	}()
-	reader := bufio.NewReader(resp.Body)
-	for {
-		var line []byte
-		line, err = reader.ReadBytes('\n')
-		if errors.Is(err, io.EOF) {
-			break
+
+	// Loop and build from the inputs
+	txInfos := make(whatsonchain.TxList, 0, len(transactions))
+	var tx *bt.Tx
+	for _, transaction := range transactions {
+		if tx, err = bt.NewTxFromString(
+			transaction.Hex,
+		); err != nil {
+			return nil, err
 		}
-		json := gjson.ParseBytes(line)
-		txID := gjson.Get(json.String(), "tx.h")
-		if txID.Str == "" {
-			break
+		var inputs []whatsonchain.VinInfo
+		for _, in := range tx.Inputs {
+			// todo: upgrade and use go-bt v2
+			vin := whatsonchain.VinInfo{
+				TxID: in.PreviousTxID,
+			}
+			inputs = append(inputs, vin)
 		}
-		record := &whatsonchain.HistoryRecord{
-			TxHash: txID.Str,
+		txInfos = append(txInfos, &whatsonchain.TxInfo{
+			BlockHeight: transaction.BlockHeight,
+			Hash:        transaction.TxID,
+			Hex:         transaction.Hex,
+			TxID:        transaction.TxID,
+			Vin:         inputs,
+		})
+	}
+
+	return txInfos, nil
+}
+
+// sortTransactionInfos will sort the transactions by block height, and by previous tx within the same block
+//
+// Unconfirmed transactions (no block height) are sorted last
+func sortTransactionInfos(txInfos whatsonchain.TxList) {
+
+	// Sort all transactions by block height
+	sort.SliceStable(txInfos, func(i, j int) bool {
+		if txInfos[i].BlockHeight <= 0 || txInfos[j].BlockHeight <= 0 {
+			return txInfos[i].BlockHeight > 0 && txInfos[j].BlockHeight <= 0
+		}
+		return txInfos[i].BlockHeight < txInfos[j].BlockHeight
+	})
+
+	// Sort transactions that are in the same block by previous tx
+	for i := 0; i < len(txInfos); i++ {
+		info := txInfos[i]
+		bh := info.BlockHeight
+		var sameBlockTxs []*whatsonchain.TxInfo
+		sameBlockTxs = append(sameBlockTxs, info)
+
+		// Loop through all remaining txs until block height is not the same
+		for j := i + 1; j < len(txInfos); j++ {
+			if txInfos[j].BlockHeight == bh {
+				sameBlockTxs = append(sameBlockTxs, txInfos[j])
+			} else {
+				break
+			}
+		}
+		if len(sameBlockTxs) == 1 {
+			continue
 		}
-		transactions = append(transactions, record)
+
+		// Sort transactions by whether previous txs are referenced in the inputs
+		sort.Slice(sameBlockTxs, func(i, j int) bool {
+			for _, in := range sameBlockTxs[i].Vin {
+				if in.TxID == sameBlockTxs[j].Hash {
+					return false
+				}
+			}
+			return true
+		})
+		copy(txInfos[i:i+len(sameBlockTxs)], sameBlockTxs)
+		i += len(sameBlockTxs) - 1
 	}
-	return transactions, nil
 }
@@ -0,0 +1,219 @@
+package bux
+
+import (
+	"context"
+	"strings"
+
+	"github.com/BuxOrg/bux/utils"
+)
+
+// ImportProfile describes the derivation layout (relative to the imported xPub) used by a wallet
+//
+// Hardened derivations cannot be derived from an xPub: wallets using hardened account paths
+// (IE: m/44'/236'/0' or m/44'/0'/0') are imported using the account xPub of that path
+//
+// Mnemonic exports are not imported by the engine (it never receives private keys): the wallet (client)
+// derives the account xPub of the mnemonic and imports the xPub using the profile of the wallet
+type ImportProfile struct {
+	Name  string   `json:"name"`  // Name of the profile
+	Paths []string `json:"paths"` // Derivation paths relative to the xPub, "*" is the address num (IE: "0/*")
+}
+
+const (
+	// ImportProfileBux is the default bux layout (xpub/0/n external & xpub/1/n internal), this is also the layout
+	// below the account xPub of BIP44 wallets
+	ImportProfileBux = "bux"
+
+	// ImportProfileFlat is the layout of wallets that derive all addresses directly from the xPub (xpub/n)
+	ImportProfileFlat = "flat"
+
+	// ImportProfileSingleAddress is the layout of legacy single address exports (only xpub/0/0)
+	ImportProfileSingleAddress = "single_address"
+
+	// ImportProfileDescriptor is the profile name of the paths found in output descriptors
+	ImportProfileDescriptor = "descriptor"
+
+	// importPathWildcard is the address num (index) in the derivation path
+	importPathWildcard = "*"
+)
+
+// importProfiles are the built-in import profiles
+var importProfiles = map[string]*ImportProfile{
+	ImportProfileBux: {
+		Name:  ImportProfileBux,
+		Paths: []string{"0/*", "1/*"},
+	},
+	ImportProfileFlat: {
+		Name:  ImportProfileFlat,
+		Paths: []string{"*"},
+	},
+	ImportProfileSingleAddress: {
+		Name:  ImportProfileSingleAddress,
+		Paths: []string{"0/0"},
+	},
+}
+
+// GetImportProfile will get a built-in import profile by name
+func GetImportProfile(name string) (*ImportProfile, error) {
+	profile, ok := importProfiles[name]
+	if !ok {
+		return nil, ErrUnknownImportProfile
+	}
+	return &ImportProfile{
+		Name:  profile.Name,
+		Paths: append([]string{}, profile.Paths...),
+	}, nil
+}
+
+// ParseImportDescriptors will parse the (pkh) output descriptors exported by another wallet
+// into the xPub and the import job configuration
+//
+// IE: pkh([d34db33f/44'/236'/0']xpub.../0/*) and pkh([d34db33f/44'/236'/0']xpub.../1/*)
+//
+// All descriptors must use the same xPub, the key origin and checksum are ignored
+func ParseImportDescriptors(descriptors ...string) (xPubKey string, config *ImportJobConfig, err error) {
+	if len(descriptors) == 0 {
+		return "", nil, ErrInvalidImportDescriptor
+	}
+
+	config = &ImportJobConfig{Profile: ImportProfileDescriptor}
+	for _, descriptor := range descriptors {
+
+		// Remove the checksum
+		if index := strings.Index(descriptor, "#"); index >= 0 {
+			descriptor = descriptor[:index]
+		}
+		descriptor = strings.TrimSpace(descriptor)
+
+		// Only P2PKH outputs are supported
+		if !strings.HasPrefix(descriptor, "pkh(") || !strings.HasSuffix(descriptor, ")") {
+			return "", nil, ErrInvalidImportDescriptor
+		}
+		key := strings.TrimSuffix(strings.TrimPrefix(descriptor, "pkh("), ")")
+
+		// Remove the key origin
+		if strings.HasPrefix(key, "[") {
+			index := strings.Index(key, "]")
+			if index < 0 {
+				return "", nil, ErrInvalidImportDescriptor
+			}
+			key = key[index+1:]
+		}
+
+		// Split the xPub and the derivation path
+		path := ""
+		if index := strings.Index(key, "/"); index >= 0 {
+			key, path = key[:index], key[index+1:]
+		}
+		if _, err = utils.ValidateXPub(key); err != nil {
+			return "", nil, err
+		} else if len(xPubKey) > 0 && xPubKey != key {
+			return "", nil, ErrInvalidImportDescriptor
+		}
+		xPubKey = key
+
+		var p *importPath
+		if p, err = parseImportPath(path); err != nil {
+			return "", nil, err
+		}
+		config.Paths = append(config.Paths, p.path)
+	}
+
+	return xPubKey, config, nil
+}
+
+// importPath is a parsed derivation path of an import profile
+type importPath struct {
+	path     string   // Formatted path (IE: "0/*")
+	prefix   []uint32 // Path to the addresses
+	wildcard bool     // The last num of the path is the address num (scanned using the gap limit)
+}
+
+// parseImportPath will parse a derivation path of an import profile (hardened paths are not supported)
+func parseImportPath(path string) (*importPath, error) {
+	p := &importPath{}
+	path = strings.TrimPrefix(strings.TrimPrefix(path, "m"), "/")
+	if path == importPathWildcard || strings.HasSuffix(path, "/"+importPathWildcard) {
+		p.wildcard = true
+		path = strings.TrimSuffix(strings.TrimSuffix(path, importPathWildcard), "/")
+	} else if len(path) == 0 {
+		return nil, ErrInvalidImportPath
+	}
+
+	var err error
+	if p.prefix, err = utils.ParseDerivationPath(path); err != nil {
+		return nil, ErrInvalidImportPath
+	}
+
+	p.path = utils.FormatDerivationPath(p.prefix)
+	if p.wildcard {
+		if len(p.path) > 0 {
+			p.path += "/"
+		}
+		p.path += importPathWildcard
+	}
+	return p, nil
+}
+
+// standard will return the chain/num if the address is in the bux layout (xpub/0/n or xpub/1/n)
+func (p *importPath) standard(num uint32) (chain, addressNum uint32, ok bool) {
+	switch {
+	case p.wildcard && len(p.prefix) == 1:
+		chain, addressNum = p.prefix[0], num
+	case !p.wildcard && len(p.prefix) == 2:
+		chain, addressNum = p.prefix[0], p.prefix[1]
+	default:
+		return 0, 0, false
+	}
+	return chain, addressNum, chain == utils.ChainExternal || chain == utils.ChainInternal
+}
+
+// derivationPath will return the full derivation path of the address num
+func (p *importPath) derivationPath(num uint32) []uint32 {
+	path := append([]uint32{}, p.prefix...)
+	if p.wildcard {
+		path = append(path, num)
+	}
+	return path
+}
+
+// deriveImportPathAddresses will derive (and save) a set of addresses on the import path, starting at the given num
+//
+// Addresses in the bux layout are derived by chain/num, other addresses are saved with their derivation path
+func deriveImportPathAddresses(ctx context.Context, rawXpubKey string, path *importPath, fromNum uint32,
+	amount int, opts ...ModelOps) ([]string, error) {
+
+	// Only a single address on a fixed path
+	if !path.wildcard {
+		amount = 1
+	}
+
+	// Same layout as bux
+	if chain, num, ok := path.standard(fromNum); ok {
+		return deriveAddresses(ctx, rawXpubKey, chain, num, amount, opts...)
+	}
+
+	addressList := make([]string, 0, amount)
+	for num := fromNum; num < fromNum+uint32(amount); num++ {
+		destination, err := newAddressFromPath(
+			rawXpubKey, path.derivationPath(num), append(opts, New())...,
+		)
+		if err != nil {
+			return nil, err
+		}
+		addressList = append(addressList, destination.Address)
+
+		// Save the destination (if not found)
+		var existing *Destination
+		if existing, err = getDestinationByID(ctx, destination.ID, opts...); err != nil {
+			return nil, err
+		} else if existing != nil {
+			continue
+		}
+		if err = destination.Save(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	return addressList, nil
+}
@@ -0,0 +1,157 @@
+package bux
+
+import (
+	"testing"
+
+	"github.com/BuxOrg/bux/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestGetImportProfile will test the method GetImportProfile()
+func TestGetImportProfile(t *testing.T) {
+	t.Parallel()
+
+	t.Run("unknown profile", func(t *testing.T) {
+		profile, err := GetImportProfile("unknown")
+		require.ErrorIs(t, err, ErrUnknownImportProfile)
+		assert.Nil(t, profile)
+	})
+
+	t.Run("built-in profiles", func(t *testing.T) {
+		for _, name := range []string{ImportProfileBux, ImportProfileFlat, ImportProfileSingleAddress} {
+			profile, err := GetImportProfile(name)
+			require.NoError(t, err)
+			require.NotNil(t, profile)
+			assert.Equal(t, name, profile.Name)
+			for _, path := range profile.Paths {
+				_, err = parseImportPath(path)
+				require.NoError(t, err)
+			}
+		}
+	})
+
+	t.Run("returns a copy", func(t *testing.T) {
+		profile, err := GetImportProfile(ImportProfileBux)
+		require.NoError(t, err)
+		profile.Paths[0] = "2/*"
+		assert.Equal(t, "0/*", importProfiles[ImportProfileBux].Paths[0])
+	})
+}
+
+// Test_parseImportPath will test the method parseImportPath()
+func Test_parseImportPath(t *testing.T) {
+	t.Parallel()
+
+	t.Run("valid paths", func(t *testing.T) {
+		path, err := parseImportPath("m/0/*")
+		require.NoError(t, err)
+		assert.Equal(t, "0/*", path.path)
+		assert.Equal(t, []uint32{0}, path.prefix)
+		assert.True(t, path.wildcard)
+		assert.Equal(t, []uint32{0, 4}, path.derivationPath(4))
+
+		path, err = parseImportPath("*")
+		require.NoError(t, err)
+		assert.Equal(t, "*", path.path)
+		assert.Empty(t, path.prefix)
+		assert.Equal(t, []uint32{4}, path.derivationPath(4))
+
+		path, err = parseImportPath("0/0")
+		require.NoError(t, err)
+		assert.False(t, path.wildcard)
+		assert.Equal(t, []uint32{0, 0}, path.derivationPath(4))
+	})
+
+	t.Run("invalid paths", func(t *testing.T) {
+		for _, value := range []string{"", "m", "44'/0'/0'/0/*", "0/*/1", "a/*"} {
+			_, err := parseImportPath(value)
+			assert.ErrorIs(t, err, ErrInvalidImportPath, value)
+		}
+	})
+
+	t.Run("bux layout", func(t *testing.T) {
+		path, err := parseImportPath("1/*")
+		require.NoError(t, err)
+		chain, num, ok := path.standard(7)
+		assert.True(t, ok)
+		assert.Equal(t, utils.ChainInternal, chain)
+		assert.Equal(t, uint32(7), num)
+
+		path, err = parseImportPath("0/3")
+		require.NoError(t, err)
+		chain, num, ok = path.standard(7)
+		assert.True(t, ok)
+		assert.Equal(t, utils.ChainExternal, chain)
+		assert.Equal(t, uint32(3), num)
+
+		for _, value := range []string{"*", "2/*", "0/0/*"} {
+			path, err = parseImportPath(value)
+			require.NoError(t, err)
+			_, _, ok = path.standard(0)
+			assert.False(t, ok, value)
+		}
+	})
+}
+
+// TestParseImportDescriptors will test the method ParseImportDescriptors()
+func TestParseImportDescriptors(t *testing.T) {
+	t.Parallel()
+
+	t.Run("receive and change descriptors", func(t *testing.T) {
+		xPubKey, config, err := ParseImportDescriptors(
+			"pkh([d34db33f/44'/236'/0']"+testXPub+"/0/*)#abcd1234",
+			"pkh([d34db33f/44'/236'/0']"+testXPub+"/1/*)",
+		)
+		require.NoError(t, err)
+		require.NotNil(t, config)
+		assert.Equal(t, testXPub, xPubKey)
+		assert.Equal(t, ImportProfileDescriptor, config.Profile)
+		assert.Equal(t, []string{"0/*", "1/*"}, config.Paths)
+		assert.NoError(t, config.validate())
+	})
+
+	t.Run("without key origin", func(t *testing.T) {
+		xPubKey, config, err := ParseImportDescriptors("pkh(" + testXPub + "/*)")
+		require.NoError(t, err)
+		assert.Equal(t, testXPub, xPubKey)
+		assert.Equal(t, []string{"*"}, config.Paths)
+	})
+
+	t.Run("invalid descriptors", func(t *testing.T) {
+		_, _, err := ParseImportDescriptors()
+		require.ErrorIs(t, err, ErrInvalidImportDescriptor)
+
+		_, _, err = ParseImportDescriptors("wpkh(" + testXPub + "/0/*)")
+		require.ErrorIs(t, err, ErrInvalidImportDescriptor)
+
+		_, _, err = ParseImportDescriptors("pkh([d34db33f" + testXPub + "/0/*)")
+		require.ErrorIs(t, err, ErrInvalidImportDescriptor)
+
+		_, _, err = ParseImportDescriptors("pkh(" + testXPub + "/0'/*)")
+		require.ErrorIs(t, err, ErrInvalidImportPath)
+
+		_, _, err = ParseImportDescriptors("pkh(xpub-invalid/0/*)")
+		require.Error(t, err)
+	})
+
+	t.Run("different keys", func(t *testing.T) {
+		otherXPub := "xpub661MyMwAqRbcH3WGvLjupmr43L1GVH3MP2WQWvdreDraBeFJy64Xxv4LLX9ZVWWz3ZjZkMuZtSsc9qH9JZR74bR4PWkmtEvP423r6DJR8kA"
+		_, _, err := ParseImportDescriptors(
+			"pkh("+testXPub+"/0/*)",
+			"pkh("+otherXPub+"/1/*)",
+		)
+		require.ErrorIs(t, err, ErrInvalidImportDescriptor)
+	})
+}
+
+// TestImportJobConfig_validate will test the method validate()
+func TestImportJobConfig_validate(t *testing.T) {
+	t.Parallel()
+
+	assert.NoError(t, (&ImportJobConfig{}).validate())
+	assert.NoError(t, (&ImportJobConfig{Profile: ImportProfileFlat}).validate())
+	assert.NoError(t, (&ImportJobConfig{Profile: "custom", Paths: []string{"2/*"}}).validate())
+	assert.ErrorIs(t, (&ImportJobConfig{Profile: "unknown"}).validate(), ErrUnknownImportProfile)
+	assert.ErrorIs(t, (&ImportJobConfig{Paths: []string{"44'/*"}}).validate(), ErrInvalidImportPath)
+}
@@ -28,11 +28,23 @@ type AccessKeyService interface {
 	GetAccessKeysByXPubIDCount(ctx context.Context, xPubID string, metadata *Metadata,
 		conditions *map[string]interface{}, opts ...ModelOps) (int64, error)
 	NewAccessKey(ctx context.Context, rawXpubKey string, opts ...ModelOps) (*AccessKey, error)
+	NewScopedAccessKey(ctx context.Context, rawXpubKey string, scope *AccessKeyScope,
+		expiresAt time.Time, opts ...ModelOps) (*AccessKey, error)
 	RevokeAccessKey(ctx context.Context, rawXpubKey, id string, opts ...ModelOps) (*AccessKey, error)
 }
 
 // AdminService is the bux admin service interface comprised of all services available for admins
 type AdminService interface {
+	BootstrapAdminKey(ctx context.Context, rawXpubKey string, opts ...ModelOps) (*AdminKey, error)
+	EraseXpub(ctx context.Context, xPubID string) (*ErasureResults, error)
+	ExportXpubData(ctx context.Context, xPubID string) (*XpubExport, error)
+	GetAdminKey(ctx context.Context, xPubID string) (*AdminKey, error)
+	GetAdminKeys(ctx context.Context, metadataConditions *Metadata, conditions *map[string]interface{},
+		queryParams *datastore.QueryParams, opts ...ModelOps) ([]*AdminKey, error)
+	GetAuditLogs(ctx context.Context, metadataConditions *Metadata, conditions *map[string]interface{},
+		queryParams *datastore.QueryParams, opts ...ModelOps) ([]*AuditLog, error)
+	GetAuditLogsCount(ctx context.Context, metadataConditions *Metadata,
+		conditions *map[string]interface{}, opts ...ModelOps) (int64, error)
 	GetStats(ctx context.Context, opts ...ModelOps) (*AdminStats, error)
 	GetPaymailAddresses(ctx context.Context, metadataConditions *Metadata, conditions *map[string]interface{},
 		queryParams *datastore.QueryParams, opts ...ModelOps) ([]*PaymailAddress, error)
@@ -42,23 +54,42 @@ type AdminService interface {
 		conditions *map[string]interface{}, queryParams *datastore.QueryParams, opts ...ModelOps) ([]*Xpub, error)
 	GetXPubsCount(ctx context.Context, metadataConditions *Metadata,
 		conditions *map[string]interface{}, opts ...ModelOps) (int64, error)
+	NewAdminKey(ctx context.Context, rawXpubKey string, role AdminRole, byAdminXPubID string,
+		opts ...ModelOps) (*AdminKey, error)
+	RevokeAdminKey(ctx context.Context, xPubID, byAdminXPubID string, opts ...ModelOps) (*AdminKey, error)
+	RotateEncryption(ctx context.Context) (int, error)
+	VerifyAuditLog(ctx context.Context) error
+}
+
+// BatchPayoutService is the batch payout actions
+type BatchPayoutService interface {
+	GetBatchPayout(ctx context.Context, xPubID, id string) (*BatchPayout, error)
+	GetBatchPayouts(ctx context.Context, metadata *Metadata, conditions *map[string]interface{},
+		queryParams *datastore.QueryParams, opts ...ModelOps) ([]*BatchPayout, error)
+	NewBatchPayout(ctx context.Context, rawXpubKey string, recipients []*TransactionOutput,
+		config *BatchPayoutConfig, opts ...ModelOps) (*BatchPayout, error)
+	ProcessBatchPayout(ctx context.Context, rawXpubKey, id string) (*BatchPayout, error)
 }
 
 // BlockHeaderService is the block header actions
 type BlockHeaderService interface {
+	GetBlockHeaderByHash(ctx context.Context, hash string) (*BlockHeader, error)
 	GetBlockHeaderByHeight(ctx context.Context, height uint32) (*BlockHeader, error)
 	GetBlockHeaders(ctx context.Context, metadata *Metadata, conditions *map[string]interface{},
 		queryParams *datastore.QueryParams, opts ...ModelOps) ([]*BlockHeader, error)
 	GetBlockHeadersCount(ctx context.Context, metadata *Metadata, conditions *map[string]interface{},
 		opts ...ModelOps) (int64, error)
 	GetLastBlockHeader(ctx context.Context) (*BlockHeader, error)
+	GetMerkleRootAtHeight(ctx context.Context, height uint32) (string, error)
 	GetUnsyncedBlockHeaders(ctx context.Context) ([]*BlockHeader, error)
+	IsValidMerkleRoot(ctx context.Context, root string, height uint32) (bool, error)
 	RecordBlockHeader(ctx context.Context, hash string, height uint32, bh bc.BlockHeader,
 		opts ...ModelOps) (*BlockHeader, error)
 }
 
 // ClientService is the client related services
 type ClientService interface {
+	BlockHeaderIndex() *BlockHeaderIndex
 	Cachestore() cachestore.ClientInterface
 	Cluster() cluster.ClientInterface
 	Chainstate() chainstate.ClientInterface
@@ -96,10 +127,22 @@ type DestinationService interface {
 
 // DraftTransactionService is the draft transactions actions
 type DraftTransactionService interface {
+	ApproveDraftTransaction(ctx context.Context, id, approverXPubID, note string,
+		opts ...ModelOps) (*DraftTransaction, error)
 	GetDraftTransactions(ctx context.Context, metadata *Metadata, conditions *map[string]interface{},
 		queryParams *datastore.QueryParams, opts ...ModelOps) ([]*DraftTransaction, error)
 	GetDraftTransactionsCount(ctx context.Context, metadata *Metadata,
 		conditions *map[string]interface{}, opts ...ModelOps) (int64, error)
+	RejectDraftTransaction(ctx context.Context, id, approverXPubID, note string,
+		opts ...ModelOps) (*DraftTransaction, error)
+}
+
+// EncryptionProvider is the provider for encrypting sensitive values at rest (IE: paymail xPub, sensitive metadata)
+type EncryptionProvider interface {
+	ActiveKeyID() string
+	Decrypt(value string) (string, error)
+	Encrypt(value string) (string, error)
+	IsCurrent(value string) bool
 }
 
 // HTTPInterface is the HTTP client interface
@@ -107,6 +150,13 @@ type HTTPInterface interface {
 	Do(req *http.Request) (*http.Response, error)
 }
 
+// KeyManagementService is the key management service (KMS) for wrapping the data keys of the envelope encryption
+type KeyManagementService interface {
+	ActiveKeyID() string
+	UnwrapKey(keyID, wrappedKey string) (string, error)
+	WrapKey(keyID, dataKey string) (string, error)
+}
+
 // ModelService is the "model" related services
 type ModelService interface {
 	AddModels(ctx context.Context, autoMigrate bool, models ...interface{}) error
@@ -114,6 +164,16 @@ type ModelService interface {
 	GetModelNames() []string
 }
 
+// ImportJobService is the import job actions
+type ImportJobService interface {
+	CancelImportJob(ctx context.Context, xPubID, id string) (*ImportJob, error)
+	GetImportJob(ctx context.Context, xPubID, id string) (*ImportJob, error)
+	GetImportJobs(ctx context.Context, metadata *Metadata, conditions *map[string]interface{},
+		queryParams *datastore.QueryParams, opts ...ModelOps) ([]*ImportJob, error)
+	NewImportJob(ctx context.Context, xPubKey string, config *ImportJobConfig, opts ...ModelOps) (*ImportJob, error)
+	ResumeImportJob(ctx context.Context, xPubID, id string) (*ImportJob, error)
+}
+
 // PaymailService is the paymail actions & services
 type PaymailService interface {
 	DeletePaymailAddress(ctx context.Context, address string, opts ...ModelOps) error
@@ -134,6 +194,7 @@ type TransactionService interface {
 	GetTransaction(ctx context.Context, xPubID, txID string) (*Transaction, error)
 	GetTransactionByID(ctx context.Context, txID string) (*Transaction, error)
 	GetTransactionByHex(ctx context.Context, hex string) (*Transaction, error)
+	GetTransactionBroadcastAttempts(ctx context.Context, txID string) ([]*chainstate.BroadcastAttempt, error)
 	GetTransactions(ctx context.Context, metadata *Metadata, conditions *map[string]interface{},
 		queryParams *datastore.QueryParams, opts ...ModelOps) ([]*Transaction, error)
 	GetTransactionsCount(ctx context.Context, metadata *Metadata,
@@ -142,6 +203,9 @@ type TransactionService interface {
 		queryParams *datastore.QueryParams) ([]*Transaction, error)
 	GetTransactionsByXpubIDCount(ctx context.Context, xPubID string, metadata *Metadata,
 		conditions *map[string]interface{}) (int64, error)
+	GetUnconfirmedTransactionsByXpubID(ctx context.Context, xPubID string, confirmations uint64,
+		metadata *Metadata, conditions *map[string]interface{},
+		queryParams *datastore.QueryParams) ([]*Transaction, error)
 	NewTransaction(ctx context.Context, rawXpubKey string, config *TransactionConfig,
 		opts ...ModelOps) (*DraftTransaction, error)
 	RecordTransaction(ctx context.Context, xPubKey, txHex, draftID string,
@@ -171,16 +235,20 @@ type XPubService interface {
 	ImportXpub(ctx context.Context, xPubKey string, opts ...ModelOps) (*ImportResults, error)
 	NewXpub(ctx context.Context, xPubKey string, opts ...ModelOps) (*Xpub, error)
 	UpdateXpubMetadata(ctx context.Context, xPubID string, metadata Metadata) (*Xpub, error)
+	UpdateXpubMaxUnconfirmedAncestors(ctx context.Context, xPubID string, depth *uint32) (*Xpub, error)
+	UpdateXpubSpendingLimits(ctx context.Context, xPubID string, limits *SpendingLimits) (*Xpub, error)
 }
 
 // ClientInterface is the client (bux engine) interface comprised of all services/actions
 type ClientInterface interface {
 	AccessKeyService
 	AdminService
+	BatchPayoutService
 	BlockHeaderService
 	ClientService
 	DestinationService
 	DraftTransactionService
+	ImportJobService
 	ModelService
 	PaymailService
 	TransactionService
@@ -188,20 +256,32 @@ type ClientInterface interface {
 	XPubService
 	AuthenticateRequest(ctx context.Context, req *http.Request, adminXPubs []string,
 		adminRequired, requireSigning, signingDisabled bool) (*http.Request, error)
+	ApprovalPolicy() *ApprovalPolicy
+	AuditLogExcludedModels() []ModelName
 	Close(ctx context.Context) error
 	Debug(on bool)
+	DecryptMetadata(metadata Metadata) (Metadata, error)
+	DefaultSpendingLimits() *SpendingLimits
 	DefaultSyncConfig() *SyncConfig
 	EnableNewRelic()
+	Encryption() EncryptionProvider
+	FinalityConfirmations() uint64
 	GetOrStartTxn(ctx context.Context, name string) context.Context
 	GetTaskPeriod(name string) time.Duration
 	ImportBlockHeadersFromURL() string
+	IsAuditLogEnabled() bool
+	IsBlockHeaderValidationEnabled() bool
 	IsDebug() bool
 	IsEncryptionKeySet() bool
 	IsITCEnabled() bool
 	IsIUCEnabled() bool
 	IsMigrationEnabled() bool
 	IsNewRelicEnabled() bool
+	MaxUnconfirmedAncestors() uint32
 	ModifyTaskPeriod(name string, period time.Duration) error
+	NewAuthToken(ctx context.Context, req *http.Request) (*AuthToken, error)
+	RevokeAuthToken(ctx context.Context, token string) error
+	SensitiveMetadataKeys() []string
 	SetNotificationsClient(notifications.ClientInterface)
 	UserAgent() string
 	Version() string
@@ -7,7 +7,12 @@ import (
 )
 
 const (
+	lockKeyApproveDraft       = "action-approve-draft-%s"          // + Draft ID
+	lockKeyAuditLog           = "audit-log"                        // Single chain
+	lockKeyAuthNonce          = "auth-nonce-%s-%s"                 // + Xpub/Access Key ID + Nonce
+	lockKeyBootstrapAdminKey  = "bootstrap-admin-key"              // Single bootstrap
 	lockKeyMonitorLockID      = "monitor-lock-id-%s"               // + Lock ID
+	lockKeyProcessImportJob   = "process-import-job-%s"            // + Import Job ID
 	lockKeyProcessBroadcastTx = "process-broadcast-transaction-%s" // + Tx ID
 	lockKeyProcessIncomingTx  = "process-incoming-transaction-%s"  // + Tx ID
 	lockKeyProcessP2PTx       = "process-p2p-transaction-%s"       // + Tx ID
@@ -16,6 +21,8 @@ const (
 	lockKeyRecordBlockHeader  = "action-record-block-header-%s"    // + Hash id
 	lockKeyRecordTx           = "action-record-transaction-%s"     // + Tx ID
 	lockKeyReserveUtxo        = "utxo-reserve-xpub-id-%s"          // + Xpub ID
+	lockKeyRotateEncryption   = "rotate-encryption"                // Single rotation
+	lockKeySyncBlockHeaders   = "sync-block-headers"               // Single sync
 )
 
 // newWriteLock will take care of creating a lock and defer
@@ -2,6 +2,8 @@ package bux
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"time"
 
 	"github.com/BuxOrg/bux/chainstate"
@@ -19,6 +21,16 @@ func (c *chainStateBase) Broadcast(context.Context, string, string, time.Duratio
 	return "", nil
 }
 
+func (c *chainStateBase) BroadcastWithAttempts(context.Context, string, string,
+	time.Duration, chainstate.BroadcastAttemptsHandler) (string, []*chainstate.BroadcastAttempt, error) {
+	return "", nil, nil
+}
+
+func (c *chainStateBase) QuerySpendingTransaction(context.Context, string, uint32,
+	string, time.Duration) (string, error) {
+	return "", chainstate.ErrSpendingTransactionNotFound
+}
+
 func (c *chainStateBase) QueryTransaction(context.Context, string,
 	chainstate.RequiredIn, time.Duration) (*chainstate.TransactionInfo, error) {
 	return nil, nil
@@ -51,10 +63,22 @@ func (c *chainStateBase) IsNewRelicEnabled() bool {
 	return true
 }
 
+func (c *chainStateBase) AddressHistory() chainstate.AddressHistoryProvider {
+	return nil
+}
+
+func (c *chainStateBase) BlockHeadersSource() chainstate.BlockHeadersSource {
+	return nil
+}
+
 func (c *chainStateBase) Minercraft() minercraft.ClientInterface {
 	return nil
 }
 
+func (c *chainStateBase) Node() chainstate.NodeInterface {
+	return nil
+}
+
 func (c *chainStateBase) NowNodes() nownodes.ClientInterface {
 	return nil
 }
@@ -67,6 +91,18 @@ func (c *chainStateBase) Network() chainstate.Network {
 	return chainstate.MainNet
 }
 
+func (c *chainStateBase) PowLimit() string {
+	return chainstate.MainNet.PowLimit()
+}
+
+func (c *chainStateBase) DifficultyRules() *chainstate.DifficultyRules {
+	return chainstate.MainNet.DifficultyRules()
+}
+
+func (c *chainStateBase) ProviderStats() []*chainstate.ProviderStats {
+	return nil
+}
+
 func (c *chainStateBase) QueryMiners() []*chainstate.Miner {
 	return nil
 }
@@ -89,6 +125,11 @@ func (c *chainStateEverythingInMempool) Broadcast(context.Context, string, strin
 	return "", nil
 }
 
+func (c *chainStateEverythingInMempool) BroadcastWithAttempts(context.Context, string, string,
+	time.Duration, chainstate.BroadcastAttemptsHandler) (string, []*chainstate.BroadcastAttempt, error) {
+	return "", nil, nil
+}
+
 func (c *chainStateEverythingInMempool) QueryTransaction(_ context.Context, id string,
 	_ chainstate.RequiredIn, _ time.Duration) (*chainstate.TransactionInfo, error) {
 
@@ -156,3 +197,112 @@ func (c *chainStateEverythingOnChain) QueryTransactionFastest(_ context.Context,
 func (c *chainStateEverythingOnChain) FeeUnit() *utils.FeeUnit {
 	return chainstate.DefaultFee
 }
+
+type chainStateDoubleSpend struct {
+	chainStateBase
+	conflictingTxID string
+	queryErr        error
+}
+
+func (c *chainStateDoubleSpend) Broadcast(context.Context, string, string, time.Duration) (string, error) {
+	return chainstate.ProviderAll, fmt.Errorf(
+		"broadcast failed: %w, errors: mapi: 258: txn-mempool-conflict", chainstate.ErrBroadcastMempoolConflict,
+	)
+}
+
+func (c *chainStateDoubleSpend) BroadcastWithAttempts(ctx context.Context, id, txHex string,
+	timeout time.Duration, _ chainstate.BroadcastAttemptsHandler) (string, []*chainstate.BroadcastAttempt, error) {
+	provider, err := c.Broadcast(ctx, id, txHex, timeout)
+	return provider, []*chainstate.BroadcastAttempt{{
+		Outcome:         chainstate.BroadcastOutcomeMempoolConflict,
+		Provider:        "mapi",
+		RequestedAt:     time.Now().UTC(),
+		ResponseCode:    "failure",
+		ResponseMessage: "258: txn-mempool-conflict",
+	}}, err
+}
+
+func (c *chainStateDoubleSpend) QuerySpendingTransaction(_ context.Context, _ string, _ uint32,
+	_ string, _ time.Duration) (string, error) {
+	return c.conflictingTxID, c.queryErr
+}
+
+func (c *chainStateDoubleSpend) FeeUnit() *utils.FeeUnit {
+	return chainstate.DefaultFee
+}
+
+func (c *chainStateDoubleSpend) Monitor() chainstate.MonitorService {
+	return nil
+}
+
+type chainStateLateAttempts struct {
+	chainStateEverythingOnChain
+}
+
+func (c *chainStateLateAttempts) BroadcastWithAttempts(_ context.Context, _, _ string,
+	_ time.Duration, lateAttempts chainstate.BroadcastAttemptsHandler) (string, []*chainstate.BroadcastAttempt, error) {
+	go lateAttempts([]*chainstate.BroadcastAttempt{{
+		Outcome:         chainstate.BroadcastOutcomeError,
+		Provider:        chainstate.ProviderNowNodes,
+		RequestedAt:     time.Now().UTC(),
+		ResponseMessage: "context deadline exceeded",
+	}})
+	return chainstate.ProviderWhatsOnChain, []*chainstate.BroadcastAttempt{{
+		Outcome:     chainstate.BroadcastOutcomeSuccess,
+		Provider:    chainstate.ProviderWhatsOnChain,
+		RequestedAt: time.Now().UTC(),
+	}}, nil
+}
+
+type chainStateImport struct {
+	chainStateEverythingOnChain
+	history chainstate.AddressHistoryProvider
+}
+
+func (c *chainStateImport) AddressHistory() chainstate.AddressHistoryProvider {
+	return c.history
+}
+
+// historyProviderFailing fails the address history lookups (a number of times) and counts the raw transaction lookups
+type historyProviderFailing struct {
+	chainstate.AddressHistoryProvider
+	failures        int
+	rawTransactions int
+}
+
+func (p *historyProviderFailing) AddressHistory(ctx context.Context,
+	address string) ([]*chainstate.AddressHistoryRecord, error) {
+	if p.failures > 0 {
+		p.failures--
+		return nil, errors.New("provider is not available")
+	}
+	return p.AddressHistoryProvider.AddressHistory(ctx, address)
+}
+
+func (p *historyProviderFailing) RawTransactions(ctx context.Context,
+	txIDs []string) ([]*chainstate.RawTransaction, error) {
+	p.rawTransactions++
+	return p.AddressHistoryProvider.RawTransactions(ctx, txIDs)
+}
+
+type chainStateHeaders struct {
+	chainStateEverythingOnChain
+	source chainstate.BlockHeadersSource
+}
+
+func (c *chainStateHeaders) BlockHeadersSource() chainstate.BlockHeadersSource {
+	return c.source
+}
+
+type chainStateDifficulty struct {
+	chainStateEverythingOnChain
+	rules *chainstate.DifficultyRules
+}
+
+func (c *chainStateDifficulty) DifficultyRules() *chainstate.DifficultyRules {
+	return c.rules
+}
+
+func (c *chainStateDifficulty) PowLimit() string {
+	return c.rules.PowLimit
+}
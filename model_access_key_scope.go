@@ -0,0 +1,161 @@
+package bux
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"net"
+	"strings"
+)
+
+// AccessKeyPermission is an operation that an access key is allowed to perform
+type AccessKeyPermission string
+
+const (
+	// AccessKeyPermissionRead allows reading (xPub, destinations, transactions, utxos etc.)
+	AccessKeyPermissionRead AccessKeyPermission = "read"
+
+	// AccessKeyPermissionCreateDestination allows creating new destinations
+	AccessKeyPermissionCreateDestination AccessKeyPermission = "create_destination"
+
+	// AccessKeyPermissionSend allows sending (draft & record transactions), optionally limited per day
+	AccessKeyPermissionSend AccessKeyPermission = "send"
+
+	// AccessKeyPermissionPaymail allows managing paymail addresses
+	AccessKeyPermissionPaymail AccessKeyPermission = "paymail"
+)
+
+// accessKeyPermissions are all the known access key permissions
+var accessKeyPermissions = []AccessKeyPermission{
+	AccessKeyPermissionRead,
+	AccessKeyPermissionCreateDestination,
+	AccessKeyPermissionSend,
+	AccessKeyPermissionPaymail,
+}
+
+// AccessKeyScope is the scope (permission set) of an access key
+//
+// An empty scope (no permissions) is an unrestricted key, which can do everything the xPub can (legacy keys)
+type AccessKeyScope struct {
+	AllowedIPs        []string              `json:"allowed_ips,omitempty" toml:"allowed_ips" yaml:"allowed_ips" bson:"allowed_ips,omitempty"`                                     // IPs or CIDRs that can use the key (empty = any)
+	MaxSatoshisPerDay uint64                `json:"max_satoshis_per_day,omitempty" toml:"max_satoshis_per_day" yaml:"max_satoshis_per_day" bson:"max_satoshis_per_day,omitempty"` // Max satoshis that can be sent per day (0 = no limit)
+	Permissions       []AccessKeyPermission `json:"permissions,omitempty" toml:"permissions" yaml:"permissions" bson:"permissions,omitempty"`                                     // Allowed operations (empty = all)
+}
+
+// IsUnrestricted will return true if the scope does not restrict any operation
+func (s *AccessKeyScope) IsUnrestricted() bool {
+	return s == nil || len(s.Permissions) == 0
+}
+
+// HasPermission will return true if the scope allows the given operation
+func (s *AccessKeyScope) HasPermission(permission AccessKeyPermission) bool {
+	if s.IsUnrestricted() {
+		return true
+	}
+	for _, p := range s.Permissions {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// IsIPAllowed will return true if the given ip (or host:port) is allowed to use the key
+func (s *AccessKeyScope) IsIPAllowed(ip string) bool {
+	if s == nil || len(s.AllowedIPs) == 0 {
+		return true
+	}
+
+	return ipInList(parseIP(ip), s.AllowedIPs)
+}
+
+// parseIP will parse the given ip (or host:port), returns nil if invalid
+func parseIP(ip string) net.IP {
+	ip = strings.TrimSpace(ip)
+
+	// Strip the port if found
+	if host, _, err := net.SplitHostPort(ip); err == nil {
+		ip = host
+	}
+	return net.ParseIP(ip)
+}
+
+// ipInList will return true if the ip matches any of the IPs or CIDRs in the list
+func ipInList(ip net.IP, list []string) bool {
+	if ip == nil {
+		return false
+	}
+	for _, allowed := range list {
+		if strings.Contains(allowed, "/") {
+			if _, network, err := net.ParseCIDR(allowed); err == nil && network.Contains(ip) {
+				return true
+			}
+		} else if allowedIP := net.ParseIP(allowed); allowedIP != nil && allowedIP.Equal(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate will check the scope for unknown permissions and invalid IPs/CIDRs
+func (s *AccessKeyScope) Validate() error {
+	if s == nil {
+		return nil
+	}
+	for _, p := range s.Permissions {
+		known := false
+		for _, k := range accessKeyPermissions {
+			if p == k {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return fmt.Errorf("%w: unknown permission %s", ErrInvalidAccessKeyScope, p)
+		}
+	}
+	if s.MaxSatoshisPerDay > 0 && !s.HasPermission(AccessKeyPermissionSend) {
+		return fmt.Errorf("%w: send limit requires the %s permission", ErrInvalidAccessKeyScope, AccessKeyPermissionSend)
+	}
+	for _, allowed := range s.AllowedIPs {
+		if strings.Contains(allowed, "/") {
+			if _, _, err := net.ParseCIDR(allowed); err != nil {
+				return fmt.Errorf("%w: invalid cidr %s", ErrInvalidAccessKeyScope, allowed)
+			}
+		} else if net.ParseIP(allowed) == nil {
+			return fmt.Errorf("%w: invalid ip %s", ErrInvalidAccessKeyScope, allowed)
+		}
+	}
+	return nil
+}
+
+// Scan will scan the value into Struct, implements sql.Scanner interface
+func (s *AccessKeyScope) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+
+	xType := fmt.Sprintf("%T", value)
+	var byteValue []byte
+	if xType == ValueTypeString {
+		byteValue = []byte(value.(string))
+	} else {
+		byteValue = value.([]byte)
+	}
+	if bytes.Equal(byteValue, []byte("")) || bytes.Equal(byteValue, []byte("\"\"")) {
+		return nil
+	}
+
+	return json.Unmarshal(byteValue, &s)
+}
+
+// Value return json value, implement driver.Valuer interface
+func (s AccessKeyScope) Value() (driver.Value, error) {
+	marshal, err := json.Marshal(s)
+	if err != nil {
+		return nil, err
+	}
+
+	return string(marshal), nil
+}
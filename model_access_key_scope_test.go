@@ -0,0 +1,117 @@
+package bux
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestAccessKeyScope_HasPermission will test the method HasPermission()
+func TestAccessKeyScope_HasPermission(t *testing.T) {
+	t.Parallel()
+
+	t.Run("nil scope is unrestricted", func(t *testing.T) {
+		var scope *AccessKeyScope
+		assert.True(t, scope.IsUnrestricted())
+		assert.True(t, scope.HasPermission(AccessKeyPermissionPaymail))
+	})
+
+	t.Run("empty scope is unrestricted", func(t *testing.T) {
+		scope := &AccessKeyScope{}
+		assert.True(t, scope.IsUnrestricted())
+		assert.True(t, scope.HasPermission(AccessKeyPermissionSend))
+	})
+
+	t.Run("read only", func(t *testing.T) {
+		scope := &AccessKeyScope{Permissions: []AccessKeyPermission{AccessKeyPermissionRead}}
+		assert.False(t, scope.IsUnrestricted())
+		assert.True(t, scope.HasPermission(AccessKeyPermissionRead))
+		assert.False(t, scope.HasPermission(AccessKeyPermissionCreateDestination))
+		assert.False(t, scope.HasPermission(AccessKeyPermissionSend))
+		assert.False(t, scope.HasPermission(AccessKeyPermissionPaymail))
+	})
+}
+
+// TestAccessKeyScope_IsIPAllowed will test the method IsIPAllowed()
+func TestAccessKeyScope_IsIPAllowed(t *testing.T) {
+	t.Parallel()
+
+	scope := &AccessKeyScope{AllowedIPs: []string{"192.168.1.10", "10.0.0.0/8", "2001:db8::/32"}}
+
+	t.Run("no restriction", func(t *testing.T) {
+		assert.True(t, (&AccessKeyScope{}).IsIPAllowed("1.2.3.4"))
+	})
+
+	t.Run("exact ip", func(t *testing.T) {
+		assert.True(t, scope.IsIPAllowed("192.168.1.10"))
+		assert.True(t, scope.IsIPAllowed("192.168.1.10:4000"))
+		assert.False(t, scope.IsIPAllowed("192.168.1.11"))
+	})
+
+	t.Run("cidr", func(t *testing.T) {
+		assert.True(t, scope.IsIPAllowed("10.20.30.40:80"))
+		assert.True(t, scope.IsIPAllowed("[2001:db8::1]:443"))
+		assert.False(t, scope.IsIPAllowed("11.0.0.1"))
+	})
+
+	t.Run("invalid ip", func(t *testing.T) {
+		assert.False(t, scope.IsIPAllowed(""))
+		assert.False(t, scope.IsIPAllowed("not-an-ip"))
+	})
+}
+
+// TestAccessKeyScope_Validate will test the method Validate()
+func TestAccessKeyScope_Validate(t *testing.T) {
+	t.Parallel()
+
+	t.Run("valid scope", func(t *testing.T) {
+		scope := &AccessKeyScope{
+			AllowedIPs:        []string{"127.0.0.1", "10.0.0.0/8"},
+			MaxSatoshisPerDay: 1000,
+			Permissions:       []AccessKeyPermission{AccessKeyPermissionRead, AccessKeyPermissionSend},
+		}
+		require.NoError(t, scope.Validate())
+	})
+
+	t.Run("unknown permission", func(t *testing.T) {
+		scope := &AccessKeyScope{Permissions: []AccessKeyPermission{"admin"}}
+		require.ErrorIs(t, scope.Validate(), ErrInvalidAccessKeyScope)
+	})
+
+	t.Run("send limit without send permission", func(t *testing.T) {
+		scope := &AccessKeyScope{
+			MaxSatoshisPerDay: 1000,
+			Permissions:       []AccessKeyPermission{AccessKeyPermissionRead},
+		}
+		require.ErrorIs(t, scope.Validate(), ErrInvalidAccessKeyScope)
+	})
+
+	t.Run("invalid ip and cidr", func(t *testing.T) {
+		require.ErrorIs(t, (&AccessKeyScope{AllowedIPs: []string{"1.2.3"}}).Validate(), ErrInvalidAccessKeyScope)
+		require.ErrorIs(t, (&AccessKeyScope{AllowedIPs: []string{"10.0.0.0/99"}}).Validate(), ErrInvalidAccessKeyScope)
+	})
+}
+
+// TestAccessKeyScope_Value will test the methods Value() and Scan()
+func TestAccessKeyScope_Value(t *testing.T) {
+	t.Parallel()
+
+	scope := AccessKeyScope{
+		AllowedIPs:        []string{"10.0.0.0/8"},
+		MaxSatoshisPerDay: 5000,
+		Permissions:       []AccessKeyPermission{AccessKeyPermissionSend},
+	}
+	value, err := scope.Value()
+	require.NoError(t, err)
+
+	scanned := AccessKeyScope{}
+	err = scanned.Scan(value)
+	require.NoError(t, err)
+	assert.Equal(t, scope, scanned)
+
+	empty := AccessKeyScope{}
+	require.NoError(t, empty.Scan(nil))
+	require.NoError(t, empty.Scan(""))
+	assert.True(t, empty.IsUnrestricted())
+}
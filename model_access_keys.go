@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"encoding/hex"
 	"errors"
+	"time"
 
 	"github.com/BuxOrg/bux/utils"
 	"github.com/bitcoinschema/go-bitcoin/v2"
@@ -28,6 +29,8 @@ type AccessKey struct {
 	ID        string               `json:"id" toml:"id" yaml:"id" gorm:"<-:create;type:char(64);primaryKey;comment:This is the unique access key id" bson:"_id"`
 	XpubID    string               `json:"xpub_id" toml:"xpub_id" yaml:"hash" gorm:"<-:create;type:char(64);index;comment:This is the related xPub id" bson:"xpub_id"`
 	RevokedAt customTypes.NullTime `json:"revoked_at" toml:"revoked_at" yaml:"revoked_at" gorm:"<-;comment:When the key was revoked" bson:"revoked_at,omitempty"`
+	ExpiresAt customTypes.NullTime `json:"expires_at" toml:"expires_at" yaml:"expires_at" gorm:"<-:create;comment:When the key expires" bson:"expires_at,omitempty"`
+	Scope     AccessKeyScope       `json:"scope" toml:"scope" yaml:"scope" gorm:"<-:create;type:text;comment:This is the scope (permissions) of the key in JSON" bson:"scope"`
 
 	// Private fields
 	Key string `json:"key" gorm:"-" bson:"-"` // Used on "CREATE", shown to the user "once" only
@@ -51,6 +54,11 @@ func newAccessKey(xPubID string, opts ...ModelOps) *AccessKey {
 	}
 }
 
+// IsExpired will return true if the access key has expired
+func (m *AccessKey) IsExpired() bool {
+	return m.ExpiresAt.Valid && !m.ExpiresAt.Time.After(time.Now().UTC())
+}
+
 // getAccessKey will get the model with a given ID
 func getAccessKey(ctx context.Context, id string, opts ...ModelOps) (*AccessKey, error) {
 
@@ -185,6 +193,11 @@ func (m *AccessKey) BeforeCreating(_ context.Context) error {
 		return ErrMissingFieldID
 	}
 
+	// Make sure the scope is valid
+	if err := m.Scope.Validate(); err != nil {
+		return err
+	}
+
 	m.DebugLog("end: " + m.Name() + " BeforeCreating hook")
 	return nil
 }
@@ -85,6 +85,54 @@ func Test_newAccessKey(t *testing.T) {
 	})
 }
 
+// TestAccessKey_Scope will test the scope & expiry of the access key model
+func TestAccessKey_Scope(t *testing.T) {
+	t.Run("save scope and expiry", func(t *testing.T) {
+		ctx, client, deferMe := CreateTestSQLiteClient(t, false, false, WithCustomTaskManager(&taskManagerMockBase{}))
+		defer deferMe()
+
+		expiresAt := time.Now().UTC().Add(24 * time.Hour).Truncate(time.Second)
+		key := newAccessKey(testXPubID, append(client.DefaultModelOptions(), New())...)
+		key.ExpiresAt.Valid = true
+		key.ExpiresAt.Time = expiresAt
+		key.Scope = AccessKeyScope{
+			AllowedIPs:        []string{"10.0.0.0/8"},
+			MaxSatoshisPerDay: 10000,
+			Permissions:       []AccessKeyPermission{AccessKeyPermissionRead, AccessKeyPermissionSend},
+		}
+		err := key.Save(ctx)
+		require.NoError(t, err)
+
+		var accessKey *AccessKey
+		accessKey, err = getAccessKey(ctx, key.ID, client.DefaultModelOptions()...)
+		require.NoError(t, err)
+		require.NotNil(t, accessKey)
+		assert.True(t, accessKey.ExpiresAt.Valid)
+		assert.True(t, expiresAt.Equal(accessKey.ExpiresAt.Time))
+		assert.False(t, accessKey.IsExpired())
+		assert.Equal(t, key.Scope, accessKey.Scope)
+	})
+
+	t.Run("error - invalid scope", func(t *testing.T) {
+		ctx, client, deferMe := CreateTestSQLiteClient(t, false, false, WithCustomTaskManager(&taskManagerMockBase{}))
+		defer deferMe()
+
+		key := newAccessKey(testXPubID, append(client.DefaultModelOptions(), New())...)
+		key.Scope = AccessKeyScope{Permissions: []AccessKeyPermission{"unknown"}}
+		err := key.Save(ctx)
+		require.ErrorIs(t, err, ErrInvalidAccessKeyScope)
+	})
+
+	t.Run("is expired", func(t *testing.T) {
+		key := newAccessKey(testXPubID)
+		assert.False(t, key.IsExpired())
+
+		key.ExpiresAt.Valid = true
+		key.ExpiresAt.Time = time.Now().UTC().Add(-1 * time.Second)
+		assert.True(t, key.IsExpired())
+	})
+}
+
 // TestAccessKey_GetAccessKey will test the method getAccessKey()
 func TestAccessKey_GetAccessKey(t *testing.T) {
 	t.Run("not found", func(t *testing.T) {
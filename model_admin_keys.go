@@ -0,0 +1,236 @@
+package bux
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/mrz1836/go-datastore"
+	customTypes "github.com/mrz1836/go-datastore/custom_types"
+)
+
+// AdminRole is the role of an admin key
+type AdminRole string
+
+const (
+	// AdminRoleSuperAdmin can do everything, including managing the admin keys
+	AdminRoleSuperAdmin AdminRole = "super_admin"
+
+	// AdminRoleAuditor has read-only access to the admin endpoints
+	AdminRoleAuditor AdminRole = "auditor"
+
+	// AdminRoleSupport can use the admin endpoints, but can not manage the admin keys
+	AdminRoleSupport AdminRole = "support"
+)
+
+// adminRoles are all the known admin roles
+var adminRoles = []AdminRole{
+	AdminRoleSuperAdmin,
+	AdminRoleAuditor,
+	AdminRoleSupport,
+}
+
+// IsValid will return true if the role is a known admin role
+func (r AdminRole) IsValid() bool {
+	for _, role := range adminRoles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// IsReadOnly will return true if the role can only read
+func (r AdminRole) IsReadOnly() bool {
+	return r == AdminRoleAuditor
+}
+
+// CanManageAdmins will return true if the role can add and revoke admin keys
+func (r AdminRole) CanManageAdmins() bool {
+	return r == AdminRoleSuperAdmin
+}
+
+// AdminKey is an object representing an admin key (an xPub with admin access)
+//
+// Gorm related models & indexes: https://gorm.io/docs/models.html - https://gorm.io/docs/indexes.html
+type AdminKey struct {
+	// Base model
+	Model `bson:",inline"`
+
+	// Model specific fields
+	ID        string               `json:"id" toml:"id" yaml:"id" gorm:"<-:create;type:char(64);primaryKey;comment:This is the xPub id of the admin" bson:"_id"`
+	Role      AdminRole            `json:"role" toml:"role" yaml:"role" gorm:"<-;type:varchar(20);index;comment:This is the role of the admin" bson:"role"`
+	CreatedBy string               `json:"created_by" toml:"created_by" yaml:"created_by" gorm:"<-;type:varchar(64);comment:This is the xPub id of the admin that added the key" bson:"created_by"`
+	RevokedAt customTypes.NullTime `json:"revoked_at" toml:"revoked_at" yaml:"revoked_at" gorm:"<-;comment:When the key was revoked" bson:"revoked_at,omitempty"`
+	RevokedBy string               `json:"revoked_by" toml:"revoked_by" yaml:"revoked_by" gorm:"<-;type:varchar(64);comment:This is the xPub id of the admin that revoked the key" bson:"revoked_by,omitempty"`
+}
+
+// newAdminKey will start a new model
+func newAdminKey(xPubID string, role AdminRole, createdBy string, opts ...ModelOps) *AdminKey {
+	return &AdminKey{
+		CreatedBy: createdBy,
+		ID:        xPubID,
+		Model:     *NewBaseModel(ModelAdminKey, opts...),
+		Role:      role,
+	}
+}
+
+// IsActive will return true if the admin key has not been revoked
+func (m *AdminKey) IsActive() bool {
+	return !m.RevokedAt.Valid
+}
+
+// getAdminKey will get the model with a given ID (xPub ID)
+func getAdminKey(ctx context.Context, id string, opts ...ModelOps) (*AdminKey, error) {
+
+	// Construct an empty model
+	key := &AdminKey{
+		ID: id,
+	}
+	key.enrich(ModelAdminKey, opts...)
+
+	// Get the record
+	if err := Get(ctx, key, nil, false, defaultDatabaseReadTimeout, false); err != nil {
+		if errors.Is(err, datastore.ErrNoResults) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return key, nil
+}
+
+// getAdminKeyWithCache will try to get the admin key from cache first, then the Datastore
+//
+// Returns nil if the xPub is not an admin
+func getAdminKeyWithCache(ctx context.Context, client ClientInterface,
+	xPubID string, opts ...ModelOps) (*AdminKey, error) {
+
+	// Attempt to get from cache
+	cacheKey := fmt.Sprintf(cacheKeyAdminKeyModel, xPubID)
+	adminKey := new(AdminKey)
+	found, err := getModelFromCache(
+		ctx, client.Cachestore(), cacheKey, adminKey,
+	)
+	if err != nil { // Found a real error
+		return nil, err
+	} else if found { // Return the cached model
+		adminKey.enrich(ModelAdminKey, opts...) // Enrich the model with our parent options
+		return adminKey, nil
+	}
+
+	// Get the admin key
+	if adminKey, err = getAdminKey(
+		ctx, xPubID, opts...,
+	); err != nil {
+		return nil, err
+	} else if adminKey == nil {
+		return nil, nil
+	}
+
+	// Save to cache
+	if err = saveToCache(
+		ctx, []string{cacheKey}, adminKey, 0,
+	); err != nil {
+		return nil, err
+	}
+
+	// Return the model
+	return adminKey, nil
+}
+
+// getAdminKeys will get all the admin keys with the given conditions
+func getAdminKeys(ctx context.Context, metadata *Metadata, conditions *map[string]interface{},
+	queryParams *datastore.QueryParams, opts ...ModelOps) ([]*AdminKey, error) {
+
+	modelItems := make([]*AdminKey, 0)
+	if err := getModelsByConditions(ctx, ModelAdminKey, &modelItems, metadata, conditions, queryParams, opts...); err != nil {
+		return nil, err
+	}
+
+	return modelItems, nil
+}
+
+// getAdminKeysCount will get a count of all the admin keys (including revoked keys) with the given conditions
+func getAdminKeysCount(ctx context.Context, metadata *Metadata, conditions *map[string]interface{},
+	opts ...ModelOps) (int64, error) {
+
+	return getModelCountByConditions(ctx, ModelAdminKey, AdminKey{}, metadata, conditions, opts...)
+}
+
+// GetModelName will get the name of the current model
+func (m *AdminKey) GetModelName() string {
+	return ModelAdminKey.String()
+}
+
+// GetModelTableName will get the db table name of the current model
+func (m *AdminKey) GetModelTableName() string {
+	return tableAdminKeys
+}
+
+// Save will save the model into the Datastore
+func (m *AdminKey) Save(ctx context.Context) error {
+	return Save(ctx, m)
+}
+
+// GetID will get the ID
+func (m *AdminKey) GetID() string {
+	return m.ID
+}
+
+// BeforeCreating will fire before the model is being inserted into the Datastore
+func (m *AdminKey) BeforeCreating(_ context.Context) error {
+	m.DebugLog("starting: [" + m.name.String() + "] BeforeCreating hook...")
+
+	// Make sure ID is valid
+	if len(m.ID) == 0 {
+		return ErrMissingFieldID
+	}
+
+	// Make sure the role is valid
+	if !m.Role.IsValid() {
+		return ErrInvalidAdminRole
+	}
+
+	m.DebugLog("end: " + m.Name() + " BeforeCreating hook")
+	return nil
+}
+
+// AfterCreated will fire after the model is created in the Datastore
+func (m *AdminKey) AfterCreated(ctx context.Context) error {
+	m.DebugLog("starting: " + m.Name() + " AfterCreated hook...")
+
+	// Store in the cache (replaces any previous revoked key)
+	if err := saveToCache(
+		ctx, []string{fmt.Sprintf(cacheKeyAdminKeyModel, m.GetID())}, m, 0,
+	); err != nil {
+		return err
+	}
+
+	m.DebugLog("end: " + m.Name() + " AfterCreated hook")
+	return nil
+}
+
+// AfterUpdated will fire after a successful update into the Datastore
+func (m *AdminKey) AfterUpdated(ctx context.Context) error {
+	m.DebugLog("starting: " + m.Name() + " AfterUpdated hook...")
+
+	// Store in the cache (revocation is effective on all servers right away)
+	if err := saveToCache(
+		ctx, []string{fmt.Sprintf(cacheKeyAdminKeyModel, m.GetID())}, m, 0,
+	); err != nil {
+		return err
+	}
+
+	m.DebugLog("end: " + m.Name() + " AfterUpdated hook")
+	return nil
+}
+
+// RegisterTasks will register the model specific tasks on client initialization
+func (m *AdminKey) RegisterTasks() error {
+	return nil
+}
+
+// Migrate model specific migration on startup
+func (m *AdminKey) Migrate(client datastore.ClientInterface) error {
+	return client.IndexMetadata(client.GetTableName(tableAdminKeys), metadataField)
+}
@@ -0,0 +1,678 @@
+package bux
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/BuxOrg/bux/taskmanager"
+	"github.com/BuxOrg/bux/utils"
+	"github.com/mrz1836/go-datastore"
+)
+
+// AuditOperation is the operation that was audited
+type AuditOperation string
+
+const (
+	// AuditOperationCreate is when a record was created
+	AuditOperationCreate AuditOperation = "create"
+
+	// AuditOperationUpdate is when a record was updated
+	AuditOperationUpdate AuditOperation = "update"
+
+	// AuditOperationDelete is when a record was (soft) deleted
+	AuditOperationDelete AuditOperation = "delete"
+)
+
+// auditRedactedFields are the fields that are never written (in plain) to the audit log
+var auditRedactedFields = map[string]bool{
+	"external_xpub_key": true,
+	"key":               true,
+}
+
+// auditPersonalDataFields are the personal data fields that are not written in plain to the (chained) changes:
+// their values are kept in the personal data of the entry (erasable), the changes only hold a salted digest
+var auditPersonalDataFields = map[string]bool{
+	aliasField:        true,
+	"avatar":          true,
+	domainField:       true,
+	metadataField:     true,
+	"public_name":     true,
+	xPubMetadataField: true,
+}
+
+// auditRedactedValue is the value for redacted fields
+const auditRedactedValue = "[redacted]"
+
+// auditDigestPrefix is the prefix of the (salted) digest of a personal data value in the changes
+const auditDigestPrefix = "digest:"
+
+// auditContextKey is a context key for the audit log
+type auditContextKey string
+
+// auditRedactPersonalDataKey is set on the context while erasing the personal data of an xPub
+const auditRedactPersonalDataKey auditContextKey = "audit_redact_personal_data"
+
+// withAuditPersonalDataRedacted will redact the personal data fields in the entries written using the context
+func withAuditPersonalDataRedacted(ctx context.Context) context.Context {
+	return context.WithValue(ctx, auditRedactPersonalDataKey, true)
+}
+
+// isAuditPersonalDataRedacted will return true if the personal data fields are redacted (see withAuditPersonalDataRedacted)
+func isAuditPersonalDataRedacted(ctx context.Context) bool {
+	redacted, _ := ctx.Value(auditRedactPersonalDataKey).(bool)
+	return redacted
+}
+
+// AuditFieldChange is the change of a field of an audited record
+type AuditFieldChange struct {
+	New interface{} `json:"new,omitempty"`
+	Old interface{} `json:"old,omitempty"`
+}
+
+// auditPersonalData is the personal data of an entry (the plain values of the personal data fields)
+type auditPersonalData struct {
+	Changes map[string]*AuditFieldChange `json:"changes"`
+	Salt    string                       `json:"salt"`
+}
+
+// AuditLog is an append-only record of a mutating operation on a model
+//
+// Entries are written in the same Datastore transaction as the change, and sealed in batches afterwards
+// (audit log task): each sealed entry is chained to the previous entry (by hash), which makes the log tamper-evident.
+//
+// The personal data fields are not chained in plain: the changes hold a digest of each value (salted per entry),
+// the values and the salt are kept in the personal data of the entry, which is not part of the hash. Erasing the
+// personal data of an xPub (see EraseXpub) clears the personal data of its entries, the chain stays verifiable.
+//
+// Gorm related models & indexes: https://gorm.io/docs/models.html - https://gorm.io/docs/indexes.html
+type AuditLog struct {
+	// Base model
+	Model `bson:",inline"`
+
+	// Model specific fields
+	ID               string         `json:"id" toml:"id" yaml:"id" gorm:"<-:create;type:char(64);primaryKey;comment:This is the unique id of the entry" bson:"_id"`
+	Sequence         uint64         `json:"sequence" toml:"sequence" yaml:"sequence" gorm:"<-;index;comment:This is the position of the entry in the chain (0 until sealed)" bson:"sequence"`
+	PreviousHash     string         `json:"previous_hash" toml:"previous_hash" yaml:"previous_hash" gorm:"<-;type:varchar(64);comment:This is the hash of the previous entry" bson:"previous_hash"`
+	Hash             string         `json:"hash" toml:"hash" yaml:"hash" gorm:"<-;type:varchar(64);comment:This is the hash of the entry (chained)" bson:"hash"`
+	Timestamp        int64          `json:"timestamp" toml:"timestamp" yaml:"timestamp" gorm:"<-:create;index;comment:This is the time of the operation (unix nano)" bson:"timestamp"`
+	ActorXpubID      string         `json:"actor_xpub_id" toml:"actor_xpub_id" yaml:"actor_xpub_id" gorm:"<-:create;type:varchar(64);index;comment:This is the xPub id that made the change" bson:"actor_xpub_id,omitempty"`
+	ActorAccessKeyID string         `json:"actor_access_key_id" toml:"actor_access_key_id" yaml:"actor_access_key_id" gorm:"<-:create;type:varchar(64);index;comment:This is the access key id that made the change" bson:"actor_access_key_id,omitempty"`
+	ActorAdminXpubID string         `json:"actor_admin_xpub_id" toml:"actor_admin_xpub_id" yaml:"actor_admin_xpub_id" gorm:"<-:create;type:varchar(64);index;comment:This is the admin xPub id that made the change" bson:"actor_admin_xpub_id,omitempty"`
+	Operation        AuditOperation `json:"operation" toml:"operation" yaml:"operation" gorm:"<-:create;type:varchar(10);comment:This is the operation (create, update, delete)" bson:"operation"`
+	ModelName        string         `json:"model_name" toml:"model_name" yaml:"model_name" gorm:"<-:create;type:varchar(64);index;comment:This is the name of the changed model" bson:"model_name"`
+	ModelID          string         `json:"model_id" toml:"model_id" yaml:"model_id" gorm:"<-:create;type:varchar(255);index;comment:This is the id of the changed record" bson:"model_id"`
+	Changes          string         `json:"changes" toml:"changes" yaml:"changes" gorm:"<-:create;type:text;comment:This is the JSON diff of the changed fields" bson:"changes"`
+	PersonalData     string         `json:"personal_data,omitempty" toml:"personal_data" yaml:"personal_data" gorm:"<-;type:text;comment:This is the JSON of the personal data changes (not chained, erasable)" bson:"personal_data,omitempty"`
+
+	// Private fields
+	erasing bool // The personal data of the entry is being erased (allowed update)
+	sealing bool // The entry is being sealed (chained), allowed update
+}
+
+// auditActor is a model that knows who is saving it
+type auditActor interface {
+	getAuditActor() (xPubID, accessKeyID, adminXPubID string)
+}
+
+// auditFieldsProvider is a model with stored fields that are not in its json (IE: xpub_metadata)
+type auditFieldsProvider interface {
+	getAuditFields() map[string]interface{}
+}
+
+// auditSnapshotHolder is a model that keeps the stored state of its audited fields
+type auditSnapshotHolder interface {
+	getAuditBefore() map[string]interface{}
+	setAuditBefore(before map[string]interface{})
+}
+
+// auditSnapshot is the state of a record before it was saved
+type auditSnapshot struct {
+	after     map[string]interface{}
+	before    map[string]interface{}
+	operation AuditOperation
+}
+
+// GetChanges will get the diff of the changed fields (with the personal data, unless erased)
+func (m *AuditLog) GetChanges() (map[string]*AuditFieldChange, error) {
+	changes := make(map[string]*AuditFieldChange)
+	if len(m.Changes) == 0 {
+		return changes, nil
+	}
+	if err := json.Unmarshal([]byte(m.Changes), &changes); err != nil {
+		return nil, err
+	}
+	personalData, err := m.getPersonalData()
+	if err != nil {
+		return nil, err
+	} else if personalData != nil {
+		for field, change := range personalData.Changes {
+			changes[field] = change
+		}
+	}
+	return changes, nil
+}
+
+// getPersonalData will get the personal data of the entry (nil if none, or erased)
+func (m *AuditLog) getPersonalData() (*auditPersonalData, error) {
+	if len(m.PersonalData) == 0 {
+		return nil, nil
+	}
+	personalData := new(auditPersonalData)
+	if err := json.Unmarshal([]byte(m.PersonalData), personalData); err != nil {
+		return nil, err
+	}
+	return personalData, nil
+}
+
+// verifyPersonalData will verify the personal data of the entry against the digests in the (chained) changes
+func (m *AuditLog) verifyPersonalData() bool {
+	personalData, err := m.getPersonalData()
+	if err != nil {
+		return false
+	} else if personalData == nil {
+		return true
+	}
+	changes := make(map[string]*AuditFieldChange)
+	if err = json.Unmarshal([]byte(m.Changes), &changes); err != nil {
+		return false
+	}
+	for field, change := range personalData.Changes {
+		digests, ok := changes[field]
+		if !ok ||
+			digests.Old != auditPersonalDataDigest(personalData.Salt, field, change.Old) ||
+			digests.New != auditPersonalDataDigest(personalData.Salt, field, change.New) {
+			return false
+		}
+	}
+	return true
+}
+
+// auditPersonalDataDigest will return the salted digest of a personal data value (nil for no value)
+func auditPersonalDataDigest(salt, field string, value interface{}) interface{} {
+	if value == nil {
+		return nil
+	}
+	valueJSON, err := json.Marshal(value)
+	if err != nil {
+		return nil
+	}
+	return auditDigestPrefix + utils.Hash(salt+"|"+field+"|"+string(valueJSON))
+}
+
+// calculateHash will calculate the (chained) hash of the entry
+func (m *AuditLog) calculateHash() string {
+	return utils.Hash(fmt.Sprintf(
+		"%s|%s|%d|%d|%s|%s|%s|%s|%s|%s|%s",
+		m.ID, m.PreviousHash, m.Sequence, m.Timestamp,
+		m.ActorXpubID, m.ActorAccessKeyID, m.ActorAdminXpubID,
+		m.Operation, m.ModelName, m.ModelID, m.Changes,
+	))
+}
+
+// getAuditLogs will get all the audit logs with the given conditions
+func getAuditLogs(ctx context.Context, metadata *Metadata, conditions *map[string]interface{},
+	queryParams *datastore.QueryParams, opts ...ModelOps) ([]*AuditLog, error) {
+
+	modelItems := make([]*AuditLog, 0)
+	if err := getModelsByConditions(ctx, ModelAuditLog, &modelItems, metadata, conditions, queryParams, opts...); err != nil {
+		return nil, err
+	}
+
+	return modelItems, nil
+}
+
+// getAuditLogsCount will get a count of all the audit logs with the given conditions
+func getAuditLogsCount(ctx context.Context, metadata *Metadata, conditions *map[string]interface{},
+	opts ...ModelOps) (int64, error) {
+
+	return getModelCountByConditions(ctx, ModelAuditLog, AuditLog{}, metadata, conditions, opts...)
+}
+
+// getLastAuditLog will get the last sealed entry of the chain (nil if no entry is sealed)
+func getLastAuditLog(ctx context.Context, opts ...ModelOps) (*AuditLog, error) {
+	conditions := map[string]interface{}{
+		sequenceField: map[string]interface{}{
+			"$gt": 0,
+		},
+	}
+	auditLogs, err := getAuditLogs(ctx, nil, &conditions, &datastore.QueryParams{
+		Page:          1,
+		PageSize:      1,
+		OrderByField:  sequenceField,
+		SortDirection: datastore.SortDesc,
+	}, opts...)
+	if err != nil {
+		return nil, err
+	} else if len(auditLogs) == 0 {
+		return nil, nil
+	}
+	return auditLogs[0], nil
+}
+
+// isAuditedModel will return true if changes to the model are written to the audit log
+//
+// The compact block headers are not audited (derived from the block headers)
+func isAuditedModel(c ClientInterface, model ModelInterface) bool {
+	if c == nil || !c.IsAuditLogEnabled() || model.GetModelName() == ModelAuditLog.String() ||
+		model.GetModelName() == ModelCompactBlockHeader.String() {
+		return false
+	}
+	for _, name := range c.AuditLogExcludedModels() {
+		if name.String() == model.GetModelName() {
+			return false
+		}
+	}
+	return true
+}
+
+// auditModelToMap will convert the model to a map of (json) fields, redacting the sensitive fields
+func auditModelToMap(model interface{}) (map[string]interface{}, error) {
+	b, err := json.Marshal(model)
+	if err != nil {
+		return nil, err
+	}
+	fields := make(map[string]interface{})
+	if err = json.Unmarshal(b, &fields); err != nil {
+		return nil, err
+	}
+
+	// Stored fields that are not in the json of the model (normalized to json values)
+	if provider, ok := model.(auditFieldsProvider); ok {
+		if b, err = json.Marshal(provider.getAuditFields()); err != nil {
+			return nil, err
+		} else if err = json.Unmarshal(b, &fields); err != nil {
+			return nil, err
+		}
+	}
+	for field := range fields {
+		if auditRedactedFields[field] {
+			fields[field] = auditRedactedValue
+		}
+	}
+	return fields, nil
+}
+
+// setAuditSnapshot will keep the stored state of the audited fields on the (loaded) model
+//
+// Only for audited models, the state is used by the next save of the model (see getAuditSnapshot)
+func setAuditSnapshot(model ModelInterface) error {
+	holder, ok := model.(auditSnapshotHolder)
+	if !ok || !isAuditedModel(model.Client(), model) {
+		return nil
+	}
+	before, err := auditModelToMap(model)
+	if err != nil {
+		return err
+	}
+	holder.setAuditBefore(before)
+	return nil
+}
+
+// getAuditSnapshot will get the stored state of the model before it is saved
+//
+// Uses the state kept when the model was loaded (or saved), the stored record is only read if the
+// model was not loaded using Get (IE: loaded from the cache or in a list)
+func getAuditSnapshot(ctx context.Context, model ModelInterface) (*auditSnapshot, error) {
+	if model.IsNew() {
+		return &auditSnapshot{operation: AuditOperationCreate}, nil
+	}
+
+	// The state of the loaded model
+	snapshot := &auditSnapshot{operation: AuditOperationUpdate}
+	if holder, ok := model.(auditSnapshotHolder); ok && holder.getAuditBefore() != nil {
+		snapshot.before = holder.getAuditBefore()
+		return snapshot, nil
+	}
+
+	// Load the stored record into a new model of the same type
+	stored, ok := reflect.New(reflect.TypeOf(model).Elem()).Interface().(ModelInterface)
+	if !ok {
+		return snapshot, nil
+	}
+	stored.SetOptions(WithClient(model.Client()))
+	if idField := reflect.ValueOf(stored).Elem().FieldByName("ID"); idField.IsValid() && idField.Kind() == reflect.String {
+		idField.SetString(model.GetID())
+	}
+
+	if err := Get(ctx, stored, nil, false, defaultDatabaseReadTimeout, true); err != nil {
+		if errors.Is(err, datastore.ErrNoResults) {
+			snapshot.operation = AuditOperationCreate
+			return snapshot, nil
+		}
+		return nil, err
+	}
+
+	var err error
+	if snapshot.before, err = auditModelToMap(stored); err != nil {
+		return nil, err
+	}
+	return snapshot, nil
+}
+
+// getAuditChanges will get the diff of the fields between the stored state and the saved model
+func getAuditChanges(before, after map[string]interface{}) map[string]*AuditFieldChange {
+	changes := make(map[string]*AuditFieldChange)
+	for field, value := range after {
+		if field == updatedAtField {
+			continue
+		}
+		if old, ok := before[field]; !ok || !reflect.DeepEqual(old, value) {
+			changes[field] = &AuditFieldChange{New: value, Old: old}
+		}
+	}
+	for field, old := range before {
+		if _, ok := after[field]; !ok {
+			changes[field] = &AuditFieldChange{Old: old}
+		}
+	}
+	return changes
+}
+
+// newAuditLogEntry will create the (unsealed) entry for the saved model (nil if nothing changed)
+func newAuditLogEntry(ctx context.Context, model ModelInterface, snapshot *auditSnapshot) (*AuditLog, error) {
+
+	after, err := auditModelToMap(model)
+	if err != nil {
+		return nil, err
+	}
+	snapshot.after = after
+	changes := getAuditChanges(snapshot.before, after)
+
+	// Nothing changed
+	if snapshot.operation == AuditOperationUpdate && len(changes) == 0 {
+		return nil, nil
+	}
+
+	// Soft deletes are updates setting the deleted_at field
+	operation := snapshot.operation
+	if deleted, ok := changes[deletedAtField]; ok && operation == AuditOperationUpdate && deleted.Old == nil {
+		operation = AuditOperationDelete
+	}
+
+	// The personal data is only kept (in plain) in the personal data of the entry, the changes get the digests
+	// (the erased personal data is not written again to the audit log)
+	redacted := isAuditPersonalDataRedacted(ctx)
+	var personalData *auditPersonalData
+	for field, change := range changes {
+		if !auditPersonalDataFields[field] {
+			continue
+		}
+		if redacted {
+			if change.Old != nil {
+				change.Old = auditRedactedValue
+			}
+			if change.New != nil {
+				change.New = auditRedactedValue
+			}
+			continue
+		}
+		if personalData == nil {
+			personalData = &auditPersonalData{Changes: make(map[string]*AuditFieldChange)}
+			if personalData.Salt, err = utils.RandomHex(32); err != nil {
+				return nil, err
+			}
+		}
+		personalData.Changes[field] = &AuditFieldChange{New: change.New, Old: change.Old}
+		change.Old = auditPersonalDataDigest(personalData.Salt, field, change.Old)
+		change.New = auditPersonalDataDigest(personalData.Salt, field, change.New)
+	}
+
+	var changesJSON []byte
+	if changesJSON, err = json.Marshal(changes); err != nil {
+		return nil, err
+	}
+
+	var personalDataJSON []byte
+	if personalData != nil {
+		if personalDataJSON, err = json.Marshal(personalData); err != nil {
+			return nil, err
+		}
+	}
+
+	var id string
+	if id, err = utils.RandomHex(32); err != nil {
+		return nil, err
+	}
+
+	auditLog := &AuditLog{
+		Changes:      string(changesJSON),
+		ID:           id,
+		Model:        *NewBaseModel(ModelAuditLog, WithClient(model.Client()), New()),
+		ModelID:      model.GetID(),
+		ModelName:    model.GetModelName(),
+		Operation:    operation,
+		PersonalData: string(personalDataJSON),
+		Timestamp:    time.Now().UTC().UnixNano(),
+	}
+	if actor, ok := model.(auditActor); ok {
+		auditLog.ActorXpubID, auditLog.ActorAccessKeyID, auditLog.ActorAdminXpubID = actor.getAuditActor()
+	}
+	auditLog.SetRecordTime(true)
+	return auditLog, nil
+}
+
+// sealAuditLogs will chain the unsealed entries of the audit log (in the order they were written)
+//
+// Writers do not wait on the chain: the entries are sealed in batches by the audit log task (or VerifyAuditLog),
+// one sealer at a time. If wait is false and another sealer is running, nothing is done (sealed by that sealer)
+func sealAuditLogs(ctx context.Context, c ClientInterface, wait bool) error {
+
+	// Only one sealer at a time
+	var unlock func()
+	var err error
+	if wait {
+		unlock, err = newWaitWriteLock(ctx, lockKeyAuditLog, c.Cachestore())
+	} else {
+		unlock, err = newWriteLock(ctx, lockKeyAuditLog, c.Cachestore())
+	}
+	defer unlock()
+	if err != nil {
+		if !wait {
+			return nil
+		}
+		return err
+	}
+
+	// Chain to the last sealed entry
+	opts := c.DefaultModelOptions()
+	var last *AuditLog
+	if last, err = getLastAuditLog(ctx, opts...); err != nil {
+		return err
+	}
+	var previousHash string
+	var sequence uint64
+	if last != nil {
+		previousHash = last.Hash
+		sequence = last.Sequence
+	}
+
+	conditions := map[string]interface{}{
+		sequenceField: 0,
+	}
+	queryParams := &datastore.QueryParams{
+		Page:          1,
+		PageSize:      defaultAuditLogVerifyPageSize,
+		OrderByField:  timestampField,
+		SortDirection: datastore.SortAsc,
+	}
+
+	// Sealed entries leave the unsealed pages (always the first page)
+	for {
+		var auditLogs []*AuditLog
+		if auditLogs, err = getAuditLogs(ctx, nil, &conditions, queryParams, opts...); err != nil {
+			return err
+		}
+		for _, auditLog := range auditLogs {
+			sequence++
+			auditLog.enrich(ModelAuditLog, opts...)
+			auditLog.PreviousHash = previousHash
+			auditLog.Sequence = sequence
+			auditLog.Hash = auditLog.calculateHash()
+			auditLog.sealing = true
+			if err = auditLog.Save(ctx); err != nil {
+				return err
+			}
+			previousHash = auditLog.Hash
+		}
+		if len(auditLogs) < queryParams.PageSize {
+			return nil
+		}
+	}
+}
+
+// eraseAuditLogsPersonalData will erase the personal data of the audit log entries of the records, and of the
+// entries made by the xPub (returns the number of erased entries)
+//
+// The entries are sealed first, and the erasure holds the audit log lock: a sealer never saves back the personal data
+func eraseAuditLogsPersonalData(ctx context.Context, c ClientInterface, xPubID string,
+	recordIDs []string) (int, error) {
+
+	if err := sealAuditLogs(ctx, c, true); err != nil {
+		return 0, err
+	}
+
+	unlock, err := newWaitWriteLock(ctx, lockKeyAuditLog, c.Cachestore())
+	defer unlock()
+	if err != nil {
+		return 0, err
+	}
+
+	opts := c.DefaultModelOptions()
+	conditions := []map[string]interface{}{{actorXpubIDField: xPubID}}
+	for _, id := range recordIDs {
+		conditions = append(conditions, map[string]interface{}{modelIDField: id})
+	}
+
+	erased := 0
+	for start := 0; start < len(conditions); start += defaultAuditLogVerifyPageSize {
+		end := start + defaultAuditLogVerifyPageSize
+		if end > len(conditions) {
+			end = len(conditions)
+		}
+		var auditLogs []*AuditLog
+		if auditLogs, err = getAuditLogs(
+			ctx, nil, &map[string]interface{}{"$or": conditions[start:end]}, nil, opts...,
+		); err != nil {
+			return erased, err
+		}
+		for _, auditLog := range auditLogs {
+			if len(auditLog.PersonalData) == 0 {
+				continue
+			}
+			auditLog.enrich(ModelAuditLog, opts...)
+			auditLog.PersonalData = ""
+			auditLog.erasing = true
+			if err = auditLog.Save(ctx); err != nil {
+				return erased, err
+			}
+			erased++
+		}
+	}
+	return erased, nil
+}
+
+// verifyAuditLogs will verify the chain of the audit logs (in sequence order)
+//
+// Returns the sequence of the first broken entry
+func verifyAuditLogs(auditLogs []*AuditLog, previousHash string, sequence uint64) (uint64, error) {
+	for _, auditLog := range auditLogs {
+		if auditLog.Sequence != sequence ||
+			auditLog.PreviousHash != previousHash ||
+			auditLog.Hash != auditLog.calculateHash() ||
+			!auditLog.verifyPersonalData() {
+			return sequence, fmt.Errorf("%w: entry %d", ErrAuditLogTampered, sequence)
+		}
+		previousHash = auditLog.Hash
+		sequence++
+	}
+	return sequence, nil
+}
+
+// GetModelName will get the name of the current model
+func (m *AuditLog) GetModelName() string {
+	return ModelAuditLog.String()
+}
+
+// GetModelTableName will get the db table name of the current model
+func (m *AuditLog) GetModelTableName() string {
+	return tableAuditLogs
+}
+
+// Save will save the model into the Datastore
+func (m *AuditLog) Save(ctx context.Context) error {
+	return Save(ctx, m)
+}
+
+// GetID will get the ID
+func (m *AuditLog) GetID() string {
+	return m.ID
+}
+
+// BeforeCreating will fire before the model is being inserted into the Datastore
+func (m *AuditLog) BeforeCreating(_ context.Context) error {
+	m.DebugLog("starting: [" + m.name.String() + "] BeforeCreating hook...")
+
+	// Make sure ID is valid
+	if len(m.ID) == 0 {
+		return ErrMissingFieldID
+	}
+
+	m.DebugLog("end: " + m.Name() + " BeforeCreating hook")
+	return nil
+}
+
+// BeforeUpdating will fire before the model is being updated in the Datastore
+func (m *AuditLog) BeforeUpdating(_ context.Context) error {
+
+	// The audit log is append-only (entries are only sealed, or their personal data erased)
+	if !m.sealing && !m.erasing {
+		return ErrAuditLogAppendOnly
+	}
+	m.erasing = false
+	m.sealing = false
+	return nil
+}
+
+// RegisterTasks will register the model specific tasks on client initialization
+func (m *AuditLog) RegisterTasks() error {
+
+	// No task manager loaded? (or the audit log is disabled)
+	tm := m.Client().Taskmanager()
+	if tm == nil || !m.Client().IsAuditLogEnabled() {
+		return nil
+	}
+
+	// Register the task locally (cron task - set the defaults)
+	sealTask := m.Name() + "_seal"
+	ctx := context.Background()
+
+	// Register the task
+	if err := tm.RegisterTask(&taskmanager.Task{
+		Name:       sealTask,
+		RetryLimit: 1,
+		Handler: func(client ClientInterface) error {
+			if taskErr := taskSealAuditLogs(ctx, client.Logger(), WithClient(client)); taskErr != nil {
+				client.Logger().Error(ctx, "error running "+sealTask+" task: "+taskErr.Error())
+			}
+			return nil
+		},
+	}); err != nil {
+		return err
+	}
+
+	// Run the task periodically
+	return tm.RunTask(ctx, &taskmanager.TaskOptions{
+		Arguments:      []interface{}{m.Client()},
+		RunEveryPeriod: m.Client().GetTaskPeriod(sealTask),
+		TaskName:       sealTask,
+	})
+}
+
+// Migrate model specific migration on startup
+func (m *AuditLog) Migrate(client datastore.ClientInterface) error {
+	return client.IndexMetadata(client.GetTableName(tableAuditLogs), metadataField)
+}
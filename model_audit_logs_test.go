@@ -0,0 +1,319 @@
+package bux
+
+import (
+	"testing"
+
+	"github.com/mrz1836/go-datastore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestAuditLog_calculateHash will test the method calculateHash()
+func TestAuditLog_calculateHash(t *testing.T) {
+	t.Parallel()
+
+	auditLog := &AuditLog{
+		Changes:   `{"metadata":{"new":{"test":"value"}}}`,
+		ModelID:   testXPubID,
+		ModelName: ModelXPub.String(),
+		Operation: AuditOperationUpdate,
+		Sequence:  1,
+		Timestamp: 1643828414038,
+	}
+	hash := auditLog.calculateHash()
+	assert.Len(t, hash, 64)
+	assert.Equal(t, hash, auditLog.calculateHash())
+
+	auditLog.PreviousHash = testTxID
+	assert.NotEqual(t, hash, auditLog.calculateHash())
+}
+
+// TestAuditLog_getAuditChanges will test the method getAuditChanges()
+func TestAuditLog_getAuditChanges(t *testing.T) {
+	t.Parallel()
+
+	changes := getAuditChanges(map[string]interface{}{
+		"current_balance":   float64(100),
+		"next_internal_num": float64(1),
+		"removed":           "value",
+		"updated_at":        "2022-01-01",
+	}, map[string]interface{}{
+		"current_balance":   float64(200),
+		"next_internal_num": float64(1),
+		"updated_at":        "2022-01-02",
+	})
+	require.Len(t, changes, 2)
+	assert.Equal(t, float64(100), changes["current_balance"].Old)
+	assert.Equal(t, float64(200), changes["current_balance"].New)
+	assert.Equal(t, "value", changes["removed"].Old)
+	assert.Nil(t, changes["removed"].New)
+
+	fields, err := auditModelToMap(&AccessKey{ID: testTxID, Key: "secret"})
+	require.NoError(t, err)
+	assert.Equal(t, auditRedactedValue, fields["key"])
+
+	// Stored fields that are not in the json of the model
+	fields, err = auditModelToMap(&Transaction{
+		XpubMetadata:    XpubMetadata{testXPubID: Metadata{"test-key": "test-value"}},
+		XpubOutputValue: XpubOutputValue{testXPubID: 100},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		testXPubID: map[string]interface{}{"test-key": "test-value"},
+	}, fields[xPubMetadataField])
+	assert.Equal(t, map[string]interface{}{testXPubID: float64(100)}, fields[xPubOutputValueField])
+}
+
+// TestClient_AuditLog will test writing, querying and verifying the audit log
+func TestClient_AuditLog(t *testing.T) {
+
+	t.Run("disabled by default", func(t *testing.T) {
+		ctx, client, deferMe := initSimpleTestCase(t)
+		defer deferMe()
+
+		count, err := client.GetAuditLogsCount(ctx, nil, nil)
+		require.NoError(t, err)
+		assert.Equal(t, int64(0), count)
+	})
+
+	t.Run("create, update and delete", func(t *testing.T) {
+		ctx, client, deferMe := CreateTestSQLiteClient(t, false, true, WithAuditLog(ModelDestination))
+		defer deferMe()
+
+		xPub, err := client.NewXpub(ctx, testXPub, client.DefaultModelOptions()...)
+		require.NoError(t, err)
+
+		_, err = client.UpdateXpubMetadata(ctx, xPub.ID, Metadata{"test-key": "test-value"})
+		require.NoError(t, err)
+
+		// The entries are sealed (chained) by the audit log task
+		require.NoError(t, taskSealAuditLogs(ctx, client.Logger(), WithClient(client)))
+
+		conditions := map[string]interface{}{
+			"model_name": ModelXPub.String(),
+		}
+		var auditLogs []*AuditLog
+		auditLogs, err = client.GetAuditLogs(ctx, nil, &conditions, &datastore.QueryParams{
+			OrderByField:  sequenceField,
+			SortDirection: datastore.SortAsc,
+		})
+		require.NoError(t, err)
+		require.GreaterOrEqual(t, len(auditLogs), 2)
+
+		for _, auditLog := range auditLogs {
+			assert.Greater(t, auditLog.Sequence, uint64(0))
+			assert.Equal(t, auditLog.calculateHash(), auditLog.Hash)
+		}
+
+		// Created by the xPub itself
+		assert.Equal(t, AuditOperationCreate, auditLogs[0].Operation)
+		assert.Equal(t, xPub.ID, auditLogs[0].ModelID)
+		assert.Equal(t, xPub.ID, auditLogs[0].ActorXpubID)
+
+		// Metadata update has the diff
+		last := auditLogs[len(auditLogs)-1]
+		assert.Equal(t, AuditOperationUpdate, last.Operation)
+		var changes map[string]*AuditFieldChange
+		changes, err = last.GetChanges()
+		require.NoError(t, err)
+		require.NotNil(t, changes[metadataField])
+		assert.Equal(t, map[string]interface{}{"test-key": "test-value"}, changes[metadataField].New)
+		assert.Nil(t, changes[updatedAtField])
+
+		// Excluded models are not written
+		conditions = map[string]interface{}{
+			"model_name": ModelDestination.String(),
+		}
+		var count int64
+		count, err = client.GetAuditLogsCount(ctx, nil, &conditions)
+		require.NoError(t, err)
+		assert.Equal(t, int64(0), count)
+
+		// Admin actions are recorded with the admin as the actor
+		superXPub, superID := newTestAdminXPub(t)
+		_, err = client.BootstrapAdminKey(ctx, superXPub)
+		require.NoError(t, err)
+		var adminKey *AdminKey
+		adminKey, err = client.NewAdminKey(ctx, testXPub, AdminRoleSupport, superID)
+		require.NoError(t, err)
+		_, err = client.RevokeAdminKey(ctx, adminKey.ID, superID)
+		require.NoError(t, err)
+
+		conditions = map[string]interface{}{
+			"actor_admin_xpub_id": superID,
+		}
+		auditLogs, err = client.GetAuditLogs(ctx, nil, &conditions, nil)
+		require.NoError(t, err)
+		require.Len(t, auditLogs, 2)
+		for _, auditLog := range auditLogs {
+			assert.Equal(t, ModelAdminKey.String(), auditLog.ModelName)
+			assert.Equal(t, adminKey.ID, auditLog.ModelID)
+		}
+
+		// The chain is valid
+		require.NoError(t, client.VerifyAuditLog(ctx))
+	})
+
+	t.Run("soft delete", func(t *testing.T) {
+		ctx, client, deferMe := CreateTestSQLiteClient(t, false, true, WithAuditLog())
+		defer deferMe()
+
+		accessKey := newAccessKey(testXPubID, append(client.DefaultModelOptions(), New())...)
+		require.NoError(t, accessKey.Save(ctx))
+
+		accessKey.DeletedAt.Valid = true
+		accessKey.DeletedAt.Time = accessKey.CreatedAt
+		require.NoError(t, accessKey.Save(ctx))
+		require.NoError(t, sealAuditLogs(ctx, client, true))
+
+		conditions := map[string]interface{}{
+			"model_id": accessKey.ID,
+		}
+		auditLogs, err := client.GetAuditLogs(ctx, nil, &conditions, &datastore.QueryParams{
+			OrderByField:  sequenceField,
+			SortDirection: datastore.SortAsc,
+		})
+		require.NoError(t, err)
+		require.Len(t, auditLogs, 2)
+		assert.Equal(t, AuditOperationCreate, auditLogs[0].Operation)
+		assert.Equal(t, AuditOperationDelete, auditLogs[1].Operation)
+		assert.NotContains(t, auditLogs[0].Changes, accessKey.Key)
+	})
+
+	t.Run("snapshot of the loaded model", func(t *testing.T) {
+		ctx, client, deferMe := CreateTestSQLiteClient(t, false, true, WithAuditLog())
+		defer deferMe()
+
+		accessKey := newAccessKey(testXPubID, append(client.DefaultModelOptions(), New())...)
+		require.NoError(t, accessKey.Save(ctx))
+
+		// The stored state is kept when loading the model (no read when saving)
+		loaded, err := getAccessKey(ctx, accessKey.ID, client.DefaultModelOptions()...)
+		require.NoError(t, err)
+		require.NotNil(t, loaded.getAuditBefore())
+
+		loaded.Metadata = Metadata{"first": "value"}
+		require.NoError(t, loaded.Save(ctx))
+		loaded.Metadata = Metadata{"first": "value", "second": "value"}
+		require.NoError(t, loaded.Save(ctx))
+		require.NoError(t, sealAuditLogs(ctx, client, true))
+
+		conditions := map[string]interface{}{
+			"model_id": accessKey.ID,
+		}
+		var auditLogs []*AuditLog
+		auditLogs, err = client.GetAuditLogs(ctx, nil, &conditions, &datastore.QueryParams{
+			OrderByField:  sequenceField,
+			SortDirection: datastore.SortAsc,
+		})
+		require.NoError(t, err)
+		require.Len(t, auditLogs, 3)
+
+		// The second update has the state of the first update as the old value
+		var changes map[string]*AuditFieldChange
+		changes, err = auditLogs[2].GetChanges()
+		require.NoError(t, err)
+		require.NotNil(t, changes[metadataField])
+		assert.Equal(t, map[string]interface{}{"first": "value"}, changes[metadataField].Old)
+	})
+
+	t.Run("tampered entry", func(t *testing.T) {
+		ctx, client, deferMe := CreateTestSQLiteClient(t, false, true, WithAuditLog())
+		defer deferMe()
+
+		_, err := client.NewXpub(ctx, testXPub, client.DefaultModelOptions()...)
+		require.NoError(t, err)
+		require.NoError(t, client.VerifyAuditLog(ctx))
+
+		var auditLogs []*AuditLog
+		auditLogs, err = client.GetAuditLogs(ctx, nil, nil, nil)
+		require.NoError(t, err)
+		require.NotEmpty(t, auditLogs)
+
+		// Entries can not be changed through the engine
+		auditLogs[0].enrich(ModelAuditLog, client.DefaultModelOptions()...)
+		auditLogs[0].Changes = `{}`
+		require.ErrorIs(t, auditLogs[0].Save(ctx), ErrAuditLogAppendOnly)
+
+		// Change the entry directly in the datastore
+		tx := client.Datastore().Execute(
+			"UPDATE " + client.Datastore().GetTableName(tableAuditLogs) +
+				" SET changes = '{}' WHERE id = '" + auditLogs[0].ID + "'",
+		)
+		require.NoError(t, tx.Error)
+		require.Equal(t, int64(1), tx.RowsAffected)
+
+		require.ErrorIs(t, client.VerifyAuditLog(ctx), ErrAuditLogTampered)
+	})
+
+	t.Run("personal data", func(t *testing.T) {
+		ctx, client, deferMe := CreateTestSQLiteClient(t, false, true, WithAuditLog())
+		defer deferMe()
+
+		xPub, err := client.NewXpub(ctx, testXPub, client.DefaultModelOptions()...)
+		require.NoError(t, err)
+		_, err = client.UpdateXpubMetadata(ctx, xPub.ID, Metadata{"name": "Tester"})
+		require.NoError(t, err)
+		require.NoError(t, client.VerifyAuditLog(ctx))
+
+		conditions := map[string]interface{}{
+			"model_id": xPub.ID,
+		}
+		var auditLogs []*AuditLog
+		auditLogs, err = client.GetAuditLogs(ctx, nil, &conditions, &datastore.QueryParams{
+			OrderByField:  sequenceField,
+			SortDirection: datastore.SortAsc,
+		})
+		require.NoError(t, err)
+		last := auditLogs[len(auditLogs)-1]
+
+		// The changes are chained with the digest, the value is in the personal data
+		assert.NotContains(t, last.Changes, "Tester")
+		assert.Contains(t, last.Changes, auditDigestPrefix)
+		assert.Contains(t, last.PersonalData, "Tester")
+
+		var changes map[string]*AuditFieldChange
+		changes, err = last.GetChanges()
+		require.NoError(t, err)
+		assert.Equal(t, map[string]interface{}{"name": "Tester"}, changes[metadataField].New)
+
+		// The personal data must match the digests
+		tx := client.Datastore().Execute(
+			"UPDATE " + client.Datastore().GetTableName(tableAuditLogs) +
+				" SET personal_data = REPLACE(personal_data, 'Tester', 'Someone') WHERE id = '" + last.ID + "'",
+		)
+		require.NoError(t, tx.Error)
+		require.Equal(t, int64(1), tx.RowsAffected)
+		require.ErrorIs(t, client.VerifyAuditLog(ctx), ErrAuditLogTampered)
+	})
+
+	t.Run("unsealed entries are sealed on verify", func(t *testing.T) {
+		ctx, client, deferMe := CreateTestSQLiteClient(t, false, true, WithAuditLog())
+		defer deferMe()
+
+		// The entry is written, but not chained (yet)
+		_, err := client.NewXpub(ctx, testXPub, client.DefaultModelOptions()...)
+		require.NoError(t, err)
+
+		var auditLogs []*AuditLog
+		auditLogs, err = client.GetAuditLogs(ctx, nil, nil, nil)
+		require.NoError(t, err)
+		require.NotEmpty(t, auditLogs)
+		for _, auditLog := range auditLogs {
+			assert.Equal(t, uint64(0), auditLog.Sequence)
+			assert.Empty(t, auditLog.Hash)
+		}
+
+		require.NoError(t, client.VerifyAuditLog(ctx))
+
+		auditLogs, err = client.GetAuditLogs(ctx, nil, nil, &datastore.QueryParams{
+			OrderByField:  sequenceField,
+			SortDirection: datastore.SortAsc,
+		})
+		require.NoError(t, err)
+		for index, auditLog := range auditLogs {
+			assert.Equal(t, uint64(index+1), auditLog.Sequence)
+			assert.NotEmpty(t, auditLog.Hash)
+		}
+	})
+}
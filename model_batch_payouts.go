@@ -0,0 +1,636 @@
+package bux
+
+import (
+	"bytes"
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/BuxOrg/bux/chainstate"
+	"github.com/BuxOrg/bux/notifications"
+	"github.com/BuxOrg/bux/utils"
+	"github.com/korovkin/limiter"
+	"github.com/mrz1836/go-datastore"
+)
+
+// BatchPayout is an object representing a payout to many recipients at once
+//
+// The recipients are resolved concurrently and split into one or more draft transactions.
+// Each draft (except the last) carries the funds for the remaining drafts to a change output,
+// which is spent by the next draft once the previous transaction has been recorded.
+//
+// Gorm related models & indexes: https://gorm.io/docs/models.html - https://gorm.io/docs/indexes.html
+type BatchPayout struct {
+	// Base model
+	Model `bson:",inline"`
+
+	// Model specific fields
+	ID            string                  `json:"id" toml:"id" yaml:"id" gorm:"<-:create;type:char(64);primaryKey;comment:This is the unique batch payout id" bson:"_id"`
+	XpubID        string                  `json:"xpub_id" toml:"xpub_id" yaml:"xpub_id" gorm:"<-:create;type:char(64);index;comment:This is the related xPub" bson:"xpub_id"`
+	Configuration BatchPayoutConfig       `json:"configuration" toml:"configuration" yaml:"configuration" gorm:"<-:create;type:text;comment:This is the configuration struct in JSON" bson:"configuration"`
+	Recipients    BatchRecipients         `json:"recipients" toml:"recipients" yaml:"recipients" gorm:"<-;type:text;comment:This is the list of recipients and their status in JSON" bson:"recipients"`
+	Transactions  BatchPayoutTransactions `json:"transactions" toml:"transactions" yaml:"transactions" gorm:"<-;type:text;comment:This is the list of drafted transactions in JSON" bson:"transactions"`
+	Status        BatchPayoutStatus       `json:"status" toml:"status" yaml:"status" gorm:"<-;type:varchar(10);index;comment:This is the status of the batch payout" bson:"status"`
+}
+
+// BatchPayoutConfig is the configuration used to start a batch payout
+type BatchPayoutConfig struct {
+	ExpiresIn                time.Duration  `json:"expires_in" toml:"expires_in" yaml:"expires_in" bson:"expires_in"`                                                                     // The expiration time for each draft
+	FeeUnit                  *utils.FeeUnit `json:"fee_unit" toml:"fee_unit" yaml:"fee_unit" bson:"fee_unit"`                                                                             // Fee unit to use (overrides chainstate if set)
+	MaxConcurrency           int            `json:"max_concurrency" toml:"max_concurrency" yaml:"max_concurrency" bson:"max_concurrency"`                                                 // Maximum number of concurrent recipient resolutions
+	MaxOutputsPerTransaction int            `json:"max_outputs_per_transaction" toml:"max_outputs_per_transaction" yaml:"max_outputs_per_transaction" bson:"max_outputs_per_transaction"` // Split into a new transaction when reached
+	MaxTransactionSize       uint64         `json:"max_transaction_size" toml:"max_transaction_size" yaml:"max_transaction_size" bson:"max_transaction_size"`                             // Split into a new transaction when reached (bytes)
+	ResolutionsPerSecond     int            `json:"resolutions_per_second" toml:"resolutions_per_second" yaml:"resolutions_per_second" bson:"resolutions_per_second"`                     // Rate limit for paymail resolutions
+	Sync                     *SyncConfig    `json:"sync" toml:"sync" yaml:"sync" bson:"sync"`                                                                                             // Sync config for broadcasting and on-chain sync
+}
+
+// BatchRecipient is a single recipient of the batch payout
+type BatchRecipient struct {
+	DraftID          string               `json:"draft_id,omitempty"` // Draft transaction including this recipient
+	Error            string               `json:"error,omitempty"`    // Resolution error (if failed)
+	Output           *TransactionOutput   `json:"output"`             // The (resolved) output
+	Status           BatchRecipientStatus `json:"status"`             // Status of the recipient
+	TransactionIndex int                  `json:"transaction_index"`  // Index of the transaction (split) for this recipient
+	TxID             string               `json:"tx_id,omitempty"`    // Final transaction id (once recorded)
+}
+
+// BatchRecipients is the list of recipients of the batch payout
+type BatchRecipients []*BatchRecipient
+
+// BatchPayoutTransaction is a single (split) transaction of the batch payout
+type BatchPayoutTransaction struct {
+	CarryAddress string `json:"carry_address,omitempty"` // Own address receiving the funds for the remaining transactions
+	DraftID      string `json:"draft_id"`                // Current draft transaction id
+	TxID         string `json:"tx_id,omitempty"`         // Final transaction id (once recorded)
+}
+
+// BatchPayoutTransactions is the list of (split) transactions of the batch payout
+type BatchPayoutTransactions []*BatchPayoutTransaction
+
+// BatchPayoutStatus batch payout status
+type BatchPayoutStatus string
+
+const (
+	// BatchPayoutStatusProcessing is when the batch payout has drafts left to be recorded
+	BatchPayoutStatusProcessing BatchPayoutStatus = statusProcessing
+
+	// BatchPayoutStatusComplete is when all transactions of the batch payout have been recorded
+	BatchPayoutStatusComplete BatchPayoutStatus = statusComplete
+
+	// BatchPayoutStatusPartial is when all transactions have been recorded, but some recipients failed
+	BatchPayoutStatusPartial BatchPayoutStatus = statusPartial
+
+	// BatchPayoutStatusError is when the batch payout could not be processed
+	BatchPayoutStatusError BatchPayoutStatus = statusError
+)
+
+// BatchRecipientStatus batch payout recipient status
+type BatchRecipientStatus string
+
+const (
+	// BatchRecipientStatusPending is when the recipient has not been resolved yet
+	BatchRecipientStatusPending BatchRecipientStatus = statusPending
+
+	// BatchRecipientStatusResolved is when the recipient has been resolved and is waiting for a draft
+	BatchRecipientStatusResolved BatchRecipientStatus = "resolved"
+
+	// BatchRecipientStatusDrafted is when the recipient is part of a draft transaction
+	BatchRecipientStatusDrafted BatchRecipientStatus = statusDraft
+
+	// BatchRecipientStatusComplete is when the transaction for the recipient has been recorded
+	BatchRecipientStatusComplete BatchRecipientStatus = statusComplete
+
+	// BatchRecipientStatusFailed is when the recipient could not be resolved (or the transaction was not broadcast)
+	BatchRecipientStatusFailed BatchRecipientStatus = statusError
+)
+
+// newBatchPayout will start a new batch payout model
+func newBatchPayout(rawXpubKey string, recipients []*TransactionOutput, config *BatchPayoutConfig,
+	opts ...ModelOps) *BatchPayout {
+
+	// Random GUID
+	id, _ := utils.RandomHex(32)
+
+	batch := &BatchPayout{
+		Configuration: *config,
+		ID:            id,
+		Recipients:    make(BatchRecipients, 0, len(recipients)),
+		Status:        BatchPayoutStatusProcessing,
+		Transactions:  make(BatchPayoutTransactions, 0),
+		XpubID:        utils.Hash(rawXpubKey),
+		Model: *NewBaseModel(
+			ModelBatchPayout,
+			append(opts, WithXPub(rawXpubKey))...,
+		),
+	}
+
+	// Set the defaults
+	if batch.Configuration.MaxConcurrency <= 0 {
+		batch.Configuration.MaxConcurrency = defaultBatchMaxConcurrency
+	}
+	if batch.Configuration.MaxOutputsPerTransaction <= 0 {
+		batch.Configuration.MaxOutputsPerTransaction = defaultBatchMaxOutputs
+	}
+	if batch.Configuration.MaxTransactionSize <= 0 {
+		batch.Configuration.MaxTransactionSize = defaultBatchMaxTxSize
+	}
+	if batch.Configuration.ResolutionsPerSecond <= 0 {
+		batch.Configuration.ResolutionsPerSecond = defaultBatchResolutionsPerSec
+	}
+
+	// Set the fee (if not found) (if chainstate is loaded, use the first miner)
+	if batch.Configuration.FeeUnit == nil {
+		if c := batch.Client(); c != nil {
+			batch.Configuration.FeeUnit = c.Chainstate().FeeUnit()
+		} else {
+			batch.Configuration.FeeUnit = chainstate.DefaultFee
+		}
+	}
+
+	// Start all recipients as pending
+	for _, output := range recipients {
+		batch.Recipients = append(batch.Recipients, &BatchRecipient{
+			Output: output,
+			Status: BatchRecipientStatusPending,
+		})
+	}
+
+	return batch
+}
+
+// getBatchPayout will get the batch payout with the given conditions
+func getBatchPayout(ctx context.Context, xPubID, id string, opts ...ModelOps) (*BatchPayout, error) {
+
+	// Construct an empty model
+	batch := &BatchPayout{}
+	batch.enrich(ModelBatchPayout, opts...)
+
+	conditions := map[string]interface{}{
+		idField: id,
+	}
+	if len(xPubID) > 0 {
+		conditions[xPubIDField] = xPubID
+	}
+
+	// Get the record
+	if err := Get(ctx, batch, conditions, false, defaultDatabaseReadTimeout, false); err != nil {
+		if errors.Is(err, datastore.ErrNoResults) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return batch, nil
+}
+
+// getBatchPayouts will get all the batch payouts with the given conditions
+func getBatchPayouts(ctx context.Context, metadata *Metadata, conditions *map[string]interface{},
+	queryParams *datastore.QueryParams, opts ...ModelOps) ([]*BatchPayout, error) {
+
+	modelItems := make([]*BatchPayout, 0)
+	if err := getModelsByConditions(ctx, ModelBatchPayout, &modelItems, metadata, conditions, queryParams, opts...); err != nil {
+		return nil, err
+	}
+
+	return modelItems, nil
+}
+
+// GetModelName will get the name of the current model
+func (m *BatchPayout) GetModelName() string {
+	return ModelBatchPayout.String()
+}
+
+// GetModelTableName will get the db table name of the current model
+func (m *BatchPayout) GetModelTableName() string {
+	return tableBatchPayouts
+}
+
+// Save will save the model into the Datastore
+func (m *BatchPayout) Save(ctx context.Context) error {
+	return Save(ctx, m)
+}
+
+// GetID will get the model ID
+func (m *BatchPayout) GetID() string {
+	return m.ID
+}
+
+// BeforeCreating will fire before the model is being inserted into the Datastore
+func (m *BatchPayout) BeforeCreating(_ context.Context) error {
+	m.DebugLog("starting: [" + m.name.String() + "] BeforeCreating hook...")
+
+	// Make sure ID is valid
+	if len(m.ID) == 0 {
+		return ErrMissingFieldID
+	}
+	if len(m.Recipients) == 0 {
+		return ErrMissingBatchRecipients
+	}
+
+	m.DebugLog("end: " + m.Name() + " BeforeCreating hook")
+	return nil
+}
+
+// AfterCreated will fire after the model is created in the Datastore
+func (m *BatchPayout) AfterCreated(_ context.Context) error {
+	m.DebugLog("starting: " + m.Name() + " AfterCreated hook...")
+
+	notify(notifications.EventTypeCreate, m)
+
+	m.DebugLog("end: " + m.Name() + " AfterCreated hook")
+	return nil
+}
+
+// AfterUpdated will fire after the model is updated in the Datastore
+func (m *BatchPayout) AfterUpdated(_ context.Context) error {
+	m.DebugLog("starting: " + m.Name() + " AfterUpdated hook...")
+
+	notify(notifications.EventTypeUpdate, m)
+
+	m.DebugLog("end: " + m.Name() + " AfterUpdated hook")
+	return nil
+}
+
+// RegisterTasks will register the model specific tasks on client initialization
+func (m *BatchPayout) RegisterTasks() error {
+	return nil
+}
+
+// Migrate model specific migration on startup
+func (m *BatchPayout) Migrate(client datastore.ClientInterface) error {
+	return client.IndexMetadata(client.GetTableName(tableBatchPayouts), metadataField)
+}
+
+// resolveRecipients will resolve all pending recipients concurrently
+//
+// Paymail resolutions are rate limited, bitcoin addresses, scripts and op_returns are processed directly
+func (m *BatchPayout) resolveRecipients(ctx context.Context) error {
+
+	// Get the client
+	c := m.Client()
+	paymailFrom := getSenderPaymail(ctx, c, m.XpubID)
+
+	// Rate limit the remote resolutions
+	ticker := time.NewTicker(time.Second / time.Duration(m.Configuration.ResolutionsPerSecond))
+	defer ticker.Stop()
+
+	var mu sync.Mutex
+	limit := limiter.NewConcurrencyLimiter(m.Configuration.MaxConcurrency)
+	for _, recipient := range m.Recipients {
+		if recipient.Status != BatchRecipientStatusPending {
+			continue
+		}
+		if recipient.Output.requiresResolution() {
+			select {
+			case <-ctx.Done():
+				_ = limit.WaitAndClose()
+				return ctx.Err()
+			case <-ticker.C:
+			}
+		}
+
+		r := recipient
+		if _, err := limit.Execute(func() {
+			if r.Output.Scripts == nil {
+				r.Output.Scripts = make([]*ScriptOutput, 0)
+			}
+			err := r.Output.processOutput(
+				ctx, c.Cachestore(),
+				c.PaymailClient(),
+				paymailFrom,
+				c.GetPaymailConfig().DefaultNote,
+				true,
+			)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				r.Status = BatchRecipientStatusFailed
+				r.Error = err.Error()
+				return
+			}
+			r.Status = BatchRecipientStatusResolved
+		}); err != nil {
+			_ = limit.WaitAndClose()
+			return err
+		}
+	}
+
+	return limit.WaitAndClose()
+}
+
+// splitRecipients will assign a transaction index to each resolved recipient
+//
+// A new transaction is started when the max number of outputs or the max transaction size would be exceeded
+func (m *BatchPayout) splitRecipients() int {
+	index := -1
+	outputs := 0
+	size := uint64(0)
+	for _, recipient := range m.Recipients {
+		if recipient.Status != BatchRecipientStatusResolved {
+			continue
+		}
+
+		recipientOutputs := len(recipient.Output.Scripts)
+		recipientSize := recipient.Output.estimateSize()
+		if index < 0 ||
+			outputs+recipientOutputs > m.Configuration.MaxOutputsPerTransaction ||
+			size+recipientSize > m.Configuration.MaxTransactionSize {
+			index++
+			outputs = 0
+			size = batchTransactionOverhead()
+		}
+
+		recipient.TransactionIndex = index
+		outputs += recipientOutputs
+		size += recipientSize
+	}
+
+	return index + 1
+}
+
+// numberOfTransactions will return the number of (split) transactions for the batch
+func (m *BatchPayout) numberOfTransactions() (count int) {
+	for _, recipient := range m.Recipients {
+		if recipient.Status != BatchRecipientStatusFailed && recipient.TransactionIndex >= count {
+			count = recipient.TransactionIndex + 1
+		}
+	}
+	return
+}
+
+// getTransactionRecipients will return the resolved recipients for the given transaction index
+func (m *BatchPayout) getTransactionRecipients(index int) (recipients []*BatchRecipient) {
+	for _, recipient := range m.Recipients {
+		if recipient.Status != BatchRecipientStatusFailed && recipient.TransactionIndex == index {
+			recipients = append(recipients, recipient)
+		}
+	}
+	return
+}
+
+// FailedRecipients will return the recipients that could not be paid (see the error of each recipient)
+func (m *BatchPayout) FailedRecipients() (recipients []*BatchRecipient) {
+	for _, recipient := range m.Recipients {
+		if recipient.Status == BatchRecipientStatusFailed {
+			recipients = append(recipients, recipient)
+		}
+	}
+	return
+}
+
+// getCarrySatoshis will return the satoshis needed to fund all transactions after the given index
+func (m *BatchPayout) getCarrySatoshis(index int) uint64 {
+	satoshis := uint64(0)
+	count := m.numberOfTransactions()
+	for i := index + 1; i < count; i++ {
+		size := batchTransactionOverhead()
+		for _, recipient := range m.getTransactionRecipients(i) {
+			satoshis += recipient.Output.Satoshis
+			size += recipient.Output.estimateSize()
+		}
+		satoshis += uint64(math.Ceil(
+			float64(size) * (float64(m.Configuration.FeeUnit.Satoshis) / float64(m.Configuration.FeeUnit.Bytes)),
+		))
+	}
+	return satoshis
+}
+
+// createDraft will create the draft transaction for the given index, spending the given utxos (if set)
+func (m *BatchPayout) createDraft(ctx context.Context, index int, fromUtxos []*UtxoPointer) (*DraftTransaction, error) {
+
+	// Set the outputs for this transaction
+	recipients := m.getTransactionRecipients(index)
+	outputs := make([]*TransactionOutput, 0, len(recipients)+1)
+	for _, recipient := range recipients {
+		outputs = append(outputs, recipient.Output)
+	}
+
+	// Carry the funds for the remaining transactions to our own address
+	var carryAddress string
+	if index < m.numberOfTransactions()-1 {
+		destination, err := m.Client().NewDestination(
+			ctx, m.rawXpubKey, utils.ChainInternal, utils.ScriptTypePubKeyHash, false,
+		)
+		if err != nil {
+			return nil, err
+		}
+		carryAddress = destination.Address
+
+		carry := &TransactionOutput{
+			Satoshis: m.getCarrySatoshis(index),
+			Scripts:  make([]*ScriptOutput, 0),
+			To:       carryAddress,
+		}
+		if err = carry.processAddressOutput(); err != nil {
+			return nil, err
+		}
+		outputs = append(outputs, carry)
+	}
+
+	// Create the draft (outputs have already been resolved)
+	draft := newDraftTransaction(
+		m.rawXpubKey, &TransactionConfig{
+			ExpiresIn: m.Configuration.ExpiresIn,
+			FeeUnit:   m.Configuration.FeeUnit,
+			FromUtxos: fromUtxos,
+			Outputs:   outputs,
+			Sync:      m.Configuration.Sync,
+		}, append(m.GetOptions(false), New())...,
+	)
+	draft.outputsProcessed = true
+	if err := draft.Save(ctx); err != nil {
+		return nil, err
+	}
+
+	// Update the recipients and the list of transactions
+	for _, recipient := range recipients {
+		recipient.DraftID = draft.ID
+		recipient.Status = BatchRecipientStatusDrafted
+	}
+	if index < len(m.Transactions) {
+		m.Transactions[index].DraftID = draft.ID
+		m.Transactions[index].CarryAddress = carryAddress
+	} else {
+		m.Transactions = append(m.Transactions, &BatchPayoutTransaction{
+			CarryAddress: carryAddress,
+			DraftID:      draft.ID,
+		})
+	}
+
+	return draft, nil
+}
+
+// completeTransaction will mark the recipients of the given transaction index as complete
+func (m *BatchPayout) completeTransaction(index int, txID string) {
+	m.Transactions[index].TxID = txID
+	for _, recipient := range m.getTransactionRecipients(index) {
+		recipient.Status = BatchRecipientStatusComplete
+		recipient.TxID = txID
+	}
+	if index == m.numberOfTransactions()-1 {
+		m.Status = BatchPayoutStatusComplete
+		if len(m.FailedRecipients()) > 0 {
+			m.Status = BatchPayoutStatusPartial
+		}
+	}
+}
+
+// failTransaction will mark the recipients of the given transaction index as failed
+//
+// The remaining transactions cannot be funded (the carry output was never broadcast), so the batch stops
+func (m *BatchPayout) failTransaction(index int, reason string) {
+	count := m.numberOfTransactions()
+	for i := index; i < count; i++ {
+		for _, recipient := range m.getTransactionRecipients(i) {
+			recipient.Status = BatchRecipientStatusFailed
+			recipient.Error = reason
+		}
+	}
+	m.Status = BatchPayoutStatusError
+}
+
+// isFinished will return true if the batch payout has no transactions left to process
+func (m *BatchPayout) isFinished() bool {
+	return m.Status == BatchPayoutStatusComplete || m.Status == BatchPayoutStatusPartial ||
+		m.Status == BatchPayoutStatusError
+}
+
+// getChainedUtxos will return the outputs of the recorded draft that were paid back to the xPub
+// (carry & change outputs), these are spent by the next transaction in the batch
+func getChainedUtxos(draft *DraftTransaction, carryAddress string) []*UtxoPointer {
+	ownAddresses := []string{carryAddress}
+	for _, destination := range draft.Configuration.ChangeDestinations {
+		ownAddresses = append(ownAddresses, destination.Address)
+	}
+
+	pointers := make([]*UtxoPointer, 0)
+	outputIndex := uint32(0)
+	for _, output := range draft.Configuration.Outputs {
+		for _, script := range output.Scripts {
+			if len(script.Address) > 0 && utils.StringInSlice(script.Address, ownAddresses) {
+				pointers = append(pointers, &UtxoPointer{
+					OutputIndex:   outputIndex,
+					TransactionID: draft.FinalTxID,
+				})
+			}
+			outputIndex++
+		}
+	}
+	return pointers
+}
+
+// batchTransactionOverhead is the estimated size of a batch transaction without the recipient outputs
+// (version, locktime, a few inputs and the carry & change outputs)
+func batchTransactionOverhead() uint64 {
+	return defaultOverheadSize + 3*utils.GetInputSizeForType(utils.ScriptTypePubKeyHash) + 2*changeOutputSize
+}
+
+// requiresResolution will return true if the output needs a remote (paymail) lookup
+func (t *TransactionOutput) requiresResolution() bool {
+	return strings.Contains(t.To, "@") || strings.Contains(t.To, handleHandcashPrefix) ||
+		(len(t.To) < handleMaxLength && len(t.To) > 1 && t.To[:1] == handleRelayPrefix)
+}
+
+// estimateSize will return the estimated size of all the scripts of the output
+func (t *TransactionOutput) estimateSize() (size uint64) {
+	for _, s := range t.Scripts {
+		size += utils.GetOutputSize(s.Script)
+	}
+	return
+}
+
+// Scan will scan the value into Struct, implements sql.Scanner interface
+func (t *BatchPayoutConfig) Scan(value interface{}) error {
+	return scanJSONValue(value, t)
+}
+
+// Value return json value, implement driver.Valuer interface
+func (t BatchPayoutConfig) Value() (driver.Value, error) {
+	return jsonValue(t)
+}
+
+// Scan will scan the value into Struct, implements sql.Scanner interface
+func (t *BatchRecipients) Scan(value interface{}) error {
+	return scanJSONValue(value, t)
+}
+
+// Value return json value, implement driver.Valuer interface
+func (t BatchRecipients) Value() (driver.Value, error) {
+	return jsonValue(t)
+}
+
+// Scan will scan the value into Struct, implements sql.Scanner interface
+func (t *BatchPayoutTransactions) Scan(value interface{}) error {
+	return scanJSONValue(value, t)
+}
+
+// Value return json value, implement driver.Valuer interface
+func (t BatchPayoutTransactions) Value() (driver.Value, error) {
+	return jsonValue(t)
+}
+
+// Scan will scan the value into Struct, implements sql.Scanner interface
+func (t *BatchPayoutStatus) Scan(value interface{}) error {
+	xType := fmt.Sprintf("%T", value)
+	var stringValue string
+	if xType == ValueTypeString {
+		stringValue = value.(string)
+	} else {
+		stringValue = string(value.([]byte))
+	}
+
+	switch stringValue {
+	case statusProcessing:
+		*t = BatchPayoutStatusProcessing
+	case statusComplete:
+		*t = BatchPayoutStatusComplete
+	case statusPartial:
+		*t = BatchPayoutStatusPartial
+	case statusError:
+		*t = BatchPayoutStatusError
+	}
+
+	return nil
+}
+
+// Value return json value, implement driver.Valuer interface
+func (t BatchPayoutStatus) Value() (driver.Value, error) {
+	return string(t), nil
+}
+
+// scanJSONValue will unmarshal a JSON (string or bytes) value into the given struct
+func scanJSONValue(value interface{}, v interface{}) error {
+	if value == nil {
+		return nil
+	}
+
+	xType := fmt.Sprintf("%T", value)
+	var byteValue []byte
+	if xType == ValueTypeString {
+		byteValue = []byte(value.(string))
+	} else {
+		byteValue = value.([]byte)
+	}
+	if bytes.Equal(byteValue, []byte("")) || bytes.Equal(byteValue, []byte("\"\"")) {
+		return nil
+	}
+
+	return json.Unmarshal(byteValue, v)
+}
+
+// jsonValue will marshal the given struct into a JSON string value
+func jsonValue(v interface{}) (driver.Value, error) {
+	marshal, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	return string(marshal), nil
+}
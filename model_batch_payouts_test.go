@@ -0,0 +1,157 @@
+package bux
+
+import (
+	"context"
+	"testing"
+
+	"github.com/BuxOrg/bux/chainstate"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestBatchPayout_newBatchPayout will test the method newBatchPayout()
+func TestBatchPayout_newBatchPayout(t *testing.T) {
+	t.Run("default config", func(t *testing.T) {
+		batch := newBatchPayout(testXPub, []*TransactionOutput{{
+			To:       testExternalAddress,
+			Satoshis: 1000,
+		}}, &BatchPayoutConfig{}, New())
+		require.NotNil(t, batch)
+		assert.Len(t, batch.ID, 64)
+		assert.Equal(t, testXPubID, batch.XpubID)
+		assert.Equal(t, BatchPayoutStatusProcessing, batch.Status)
+		assert.Equal(t, defaultBatchMaxConcurrency, batch.Configuration.MaxConcurrency)
+		assert.Equal(t, defaultBatchMaxOutputs, batch.Configuration.MaxOutputsPerTransaction)
+		assert.Equal(t, defaultBatchMaxTxSize, batch.Configuration.MaxTransactionSize)
+		assert.Equal(t, defaultBatchResolutionsPerSec, batch.Configuration.ResolutionsPerSecond)
+		assert.Equal(t, chainstate.DefaultFee, batch.Configuration.FeeUnit)
+		require.Len(t, batch.Recipients, 1)
+		assert.Equal(t, BatchRecipientStatusPending, batch.Recipients[0].Status)
+	})
+
+	t.Run("missing recipients", func(t *testing.T) {
+		batch := newBatchPayout(testXPub, nil, &BatchPayoutConfig{}, New())
+		err := batch.BeforeCreating(context.Background())
+		require.ErrorIs(t, err, ErrMissingBatchRecipients)
+	})
+}
+
+// TestBatchPayout_resolveRecipients will test the method resolveRecipients()
+func TestBatchPayout_resolveRecipients(t *testing.T) {
+	ctx, client, deferMe := CreateTestSQLiteClient(t, false, false, WithCustomTaskManager(&taskManagerMockBase{}))
+	defer deferMe()
+
+	batch := newBatchPayout(testXPub, []*TransactionOutput{
+		{To: testExternalAddress, Satoshis: 1000},
+		{To: "invalid-address", Satoshis: 1000},
+		{To: testExternalAddress, Satoshis: 0},
+	}, &BatchPayoutConfig{MaxConcurrency: 2}, append(client.DefaultModelOptions(), New())...)
+
+	err := batch.resolveRecipients(ctx)
+	require.NoError(t, err)
+
+	assert.Equal(t, BatchRecipientStatusResolved, batch.Recipients[0].Status)
+	require.Len(t, batch.Recipients[0].Output.Scripts, 1)
+	assert.Equal(t, uint64(1000), batch.Recipients[0].Output.Scripts[0].Satoshis)
+
+	assert.Equal(t, BatchRecipientStatusFailed, batch.Recipients[1].Status)
+	assert.NotEmpty(t, batch.Recipients[1].Error)
+
+	assert.Equal(t, BatchRecipientStatusFailed, batch.Recipients[2].Status)
+	assert.Equal(t, ErrOutputValueTooLow.Error(), batch.Recipients[2].Error)
+}
+
+// TestBatchPayout_splitRecipients will test the method splitRecipients()
+func TestBatchPayout_splitRecipients(t *testing.T) {
+	newResolvedBatch := func(config *BatchPayoutConfig, count int) *BatchPayout {
+		outputs := make([]*TransactionOutput, 0, count)
+		for i := 0; i < count; i++ {
+			output := &TransactionOutput{To: testExternalAddress, Satoshis: 1000}
+			require.NoError(t, output.processAddressOutput())
+			outputs = append(outputs, output)
+		}
+		batch := newBatchPayout(testXPub, outputs, config, New())
+		for _, recipient := range batch.Recipients {
+			recipient.Status = BatchRecipientStatusResolved
+		}
+		return batch
+	}
+
+	t.Run("single transaction", func(t *testing.T) {
+		batch := newResolvedBatch(&BatchPayoutConfig{}, 10)
+		assert.Equal(t, 1, batch.splitRecipients())
+		assert.Equal(t, 1, batch.numberOfTransactions())
+		assert.Equal(t, uint64(0), batch.getCarrySatoshis(0))
+	})
+
+	t.Run("split by outputs", func(t *testing.T) {
+		batch := newResolvedBatch(&BatchPayoutConfig{MaxOutputsPerTransaction: 4}, 10)
+		assert.Equal(t, 3, batch.splitRecipients())
+		assert.Len(t, batch.getTransactionRecipients(0), 4)
+		assert.Len(t, batch.getTransactionRecipients(1), 4)
+		assert.Len(t, batch.getTransactionRecipients(2), 2)
+		assert.Greater(t, batch.getCarrySatoshis(0), uint64(6000))
+		assert.Greater(t, batch.getCarrySatoshis(1), uint64(2000))
+		assert.Equal(t, uint64(0), batch.getCarrySatoshis(2))
+	})
+
+	t.Run("split by size", func(t *testing.T) {
+		batch := newResolvedBatch(&BatchPayoutConfig{
+			MaxTransactionSize: batchTransactionOverhead() + 100,
+		}, 6)
+		assert.Equal(t, 3, batch.splitRecipients())
+	})
+
+	t.Run("skip failed recipients", func(t *testing.T) {
+		batch := newResolvedBatch(&BatchPayoutConfig{MaxOutputsPerTransaction: 2}, 4)
+		batch.Recipients[1].Status = BatchRecipientStatusFailed
+		assert.Equal(t, 2, batch.splitRecipients())
+		assert.Len(t, batch.getTransactionRecipients(0), 2)
+		assert.Len(t, batch.getTransactionRecipients(1), 1)
+	})
+}
+
+// TestBatchPayout_completeTransaction will test the methods completeTransaction() and failTransaction()
+func TestBatchPayout_completeTransaction(t *testing.T) {
+	newSplitBatch := func() *BatchPayout {
+		outputs := make([]*TransactionOutput, 0, 4)
+		for i := 0; i < 4; i++ {
+			output := &TransactionOutput{To: testExternalAddress, Satoshis: 1000}
+			require.NoError(t, output.processAddressOutput())
+			outputs = append(outputs, output)
+		}
+		batch := newBatchPayout(testXPub, outputs, &BatchPayoutConfig{MaxOutputsPerTransaction: 2}, New())
+		for _, recipient := range batch.Recipients {
+			recipient.Status = BatchRecipientStatusResolved
+		}
+		require.Equal(t, 2, batch.splitRecipients())
+		batch.Transactions = BatchPayoutTransactions{{DraftID: "draft-1"}, {DraftID: "draft-2"}}
+		return batch
+	}
+
+	t.Run("complete", func(t *testing.T) {
+		batch := newSplitBatch()
+		batch.completeTransaction(0, testTxID)
+		assert.Equal(t, BatchPayoutStatusProcessing, batch.Status)
+		batch.completeTransaction(1, testTxID2)
+		assert.Equal(t, BatchPayoutStatusComplete, batch.Status)
+		assert.Empty(t, batch.FailedRecipients())
+	})
+
+	t.Run("partial", func(t *testing.T) {
+		batch := newSplitBatch()
+		batch.Recipients[3].Status = BatchRecipientStatusFailed
+		batch.completeTransaction(0, testTxID)
+		batch.completeTransaction(1, testTxID2)
+		assert.Equal(t, BatchPayoutStatusPartial, batch.Status)
+		assert.Len(t, batch.FailedRecipients(), 1)
+	})
+
+	t.Run("not broadcast", func(t *testing.T) {
+		batch := newSplitBatch()
+		batch.failTransaction(0, "double spend")
+		assert.Equal(t, BatchPayoutStatusError, batch.Status)
+		assert.Len(t, batch.FailedRecipients(), 4)
+		assert.Equal(t, "double spend", batch.Recipients[3].Error)
+	})
+}
@@ -12,6 +12,7 @@ import (
 	"strconv"
 	"time"
 
+	"github.com/BuxOrg/bux/taskmanager"
 	"github.com/BuxOrg/bux/utils"
 	"github.com/libsv/go-bc"
 	"github.com/mrz1836/go-datastore"
@@ -27,7 +28,7 @@ type BlockHeader struct {
 
 	// Model specific fields
 	ID                string               `json:"id" toml:"id" yaml:"id" gorm:"<-:create;type:char(64);primaryKey;comment:This is the block hash" bson:"_id"`
-	Height            uint32               `json:"height" toml:"height" yaml:"height" gorm:"<-create;uniqueIndex;comment:This is the block height" bson:"height"`
+	Height            uint32               `json:"height" toml:"height" yaml:"height" gorm:"<-create;comment:This is the block height" bson:"height"`
 	Time              uint32               `json:"time" toml:"time" yaml:"time" gorm:"<-create;index;comment:This is the time the block was mined" bson:"time"`
 	Nonce             uint32               `json:"nonce" toml:"nonce" yaml:"nonce" gorm:"<-create;comment:This is the nonce" bson:"nonce"`
 	Version           uint32               `json:"version" toml:"version" yaml:"version" gorm:"<-create;comment:This is the version" bson:"version"`
@@ -35,6 +36,7 @@ type BlockHeader struct {
 	HashMerkleRoot    string               `json:"hash_merkle_root" toml:"hash_merkle_root" yaml:"hash_merkle_root" gorm:"<-;type:char(64);index;comment:This is the hash of the merkle root" bson:"hash_merkle_root"`
 	Bits              string               `json:"bits" toml:"bits" yaml:"bits" gorm:"<-:create;comment:This is the block difficulty" bson:"bits"`
 	Synced            customTypes.NullTime `json:"synced" toml:"synced" yaml:"synced" gorm:"type:timestamp;index;comment:This is when the block was last synced to the bux server" bson:"synced,omitempty"`
+	Orphaned          customTypes.NullTime `json:"orphaned" toml:"orphaned" yaml:"orphaned" gorm:"type:timestamp;index;comment:This is when the block was orphaned by a chain reorganization" bson:"orphaned,omitempty"`
 }
 
 // newBlockHeader will start a new block header model
@@ -87,7 +89,8 @@ func getUnsyncedBlockHeaders(ctx context.Context, opts ...ModelOps) ([]*BlockHea
 	// Construct an empty model
 	var models []BlockHeader
 	conditions := map[string]interface{}{
-		"synced": nil,
+		"orphaned": nil,
+		"synced":   nil,
 	}
 
 	// Get the records
@@ -114,6 +117,14 @@ func getUnsyncedBlockHeaders(ctx context.Context, opts ...ModelOps) ([]*BlockHea
 // getLastBlockHeader will return the last block header in the database
 func getLastBlockHeader(ctx context.Context, opts ...ModelOps) (*BlockHeader, error) {
 
+	// Use the block header index (if loaded), fall back to the Datastore on a miss
+	index := getBlockHeaderIndex(opts...)
+	if index != nil {
+		if tip := index.Tip(); tip != nil {
+			return tip.blockHeader(opts...), nil
+		}
+	}
+
 	// Construct an empty model
 	var model []BlockHeader
 
@@ -124,10 +135,14 @@ func getLastBlockHeader(ctx context.Context, opts ...ModelOps) (*BlockHeader, er
 		SortDirection: "desc",
 	}
 
+	conditions := map[string]interface{}{
+		"orphaned": nil,
+	}
+
 	// Get the records
 	if err := getModels(
 		ctx, NewBaseModel(ModelBlockHeader, opts...).Client().Datastore(),
-		&model, nil, queryParams, defaultDatabaseReadTimeout,
+		&model, conditions, queryParams, defaultDatabaseReadTimeout,
 	); err != nil {
 		if errors.Is(err, datastore.ErrNoResults) {
 			return nil, nil
@@ -138,12 +153,70 @@ func getLastBlockHeader(ctx context.Context, opts ...ModelOps) (*BlockHeader, er
 	if len(model) == 1 {
 		blockHeader := model[0]
 		blockHeader.enrich(ModelBlockHeader, opts...)
+		index.putMissed(&blockHeader)
 		return &blockHeader, nil
 	}
 
 	return nil, nil
 }
 
+// getPreviousBlockHeader will return the highest block header below the given height
+func getPreviousBlockHeader(ctx context.Context, height uint32, opts ...ModelOps) (*BlockHeader, error) {
+
+	// Use the block header index (if loaded), fall back to the Datastore on a miss
+	index := getBlockHeaderIndex(opts...)
+	if index != nil {
+		if previous := index.GetPrevious(height); previous != nil {
+			return previous.blockHeader(opts...), nil
+		}
+	}
+
+	// Construct an empty model
+	var model []BlockHeader
+
+	queryParams := &datastore.QueryParams{
+		Page:          1,
+		PageSize:      1,
+		OrderByField:  "height",
+		SortDirection: "desc",
+	}
+
+	conditions := map[string]interface{}{
+		"height": map[string]interface{}{
+			"$lt": height,
+		},
+		"orphaned": nil,
+	}
+
+	// Get the records
+	if err := getModels(
+		ctx, NewBaseModel(ModelBlockHeader, opts...).Client().Datastore(),
+		&model, conditions, queryParams, defaultDatabaseReadTimeout,
+	); err != nil {
+		if errors.Is(err, datastore.ErrNoResults) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	if len(model) == 1 {
+		blockHeader := model[0]
+		blockHeader.enrich(ModelBlockHeader, opts...)
+		index.putMissed(&blockHeader)
+		return &blockHeader, nil
+	}
+
+	return nil, nil
+}
+
+// getBlockHeaderIndex will return the block header index of the client (if loaded)
+func getBlockHeaderIndex(opts ...ModelOps) *BlockHeaderIndex {
+	if client := NewBaseModel(ModelNameEmpty, opts...).Client(); client != nil {
+		return client.BlockHeaderIndex()
+	}
+	return nil
+}
+
 // Save will save the model into the Datastore
 func (m *BlockHeader) Save(ctx context.Context) (err error) {
 	return Save(ctx, m)
@@ -172,13 +245,22 @@ func (m *BlockHeader) GetID() string {
 // getBlockHeaderByHeight will get the block header given by height
 func getBlockHeaderByHeight(ctx context.Context, height uint32, opts ...ModelOps) (*BlockHeader, error) {
 
+	// Use the block header index (if loaded), fall back to the Datastore on a miss
+	index := getBlockHeaderIndex(opts...)
+	if index != nil {
+		if blockHeader := index.GetByHeight(height); blockHeader != nil {
+			return blockHeader.blockHeader(opts...), nil
+		}
+	}
+
 	// Construct an empty model
 	blockHeader := &BlockHeader{
-		Model: *NewBaseModel(ModelDestination, opts...),
+		Model: *NewBaseModel(ModelBlockHeader, opts...),
 	}
 
 	conditions := map[string]interface{}{
-		"height": height,
+		"height":   height,
+		"orphaned": nil,
 	}
 
 	// Get the record
@@ -189,9 +271,60 @@ func getBlockHeaderByHeight(ctx context.Context, height uint32, opts ...ModelOps
 		return nil, err
 	}
 
+	index.putMissed(blockHeader)
 	return blockHeader, nil
 }
 
+// getBlockHeaderByID will get the block header given by id (hash), including orphaned block headers
+func getBlockHeaderByID(ctx context.Context, id string, opts ...ModelOps) (*BlockHeader, error) {
+
+	// Use the block header index (if loaded), fall back to the Datastore on a miss
+	index := getBlockHeaderIndex(opts...)
+	if index != nil {
+		if blockHeader := index.GetByHash(id); blockHeader != nil {
+			return blockHeader.blockHeader(opts...), nil
+		}
+	}
+
+	blockHeader, err := getBlockHeaderRecordByID(ctx, id, opts...)
+	if err != nil {
+		return nil, err
+	} else if blockHeader != nil {
+		index.putMissed(blockHeader)
+	}
+	return blockHeader, nil
+}
+
+// getBlockHeaderRecordByID will get the block header record given by id (hash) from the Datastore
+//
+// Used when the block header is updated (the block header index only holds the compact block header)
+func getBlockHeaderRecordByID(ctx context.Context, id string, opts ...ModelOps) (*BlockHeader, error) {
+
+	// Construct an empty model
+	blockHeader := &BlockHeader{
+		Model: *NewBaseModel(ModelBlockHeader, opts...),
+	}
+
+	conditions := map[string]interface{}{
+		idField: id,
+	}
+
+	// Get the record
+	if err := Get(ctx, blockHeader, conditions, true, defaultDatabaseReadTimeout, false); err != nil {
+		if errors.Is(err, datastore.ErrNoResults) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return blockHeader, nil
+}
+
+// ChildModels will get any related sub models (the compact record, saved with the block header)
+func (m *BlockHeader) ChildModels() (childModels []ModelInterface) {
+	return append(childModels, newCompactBlockHeaderRecord(m, m.GetOptions(m.IsNew())...))
+}
+
 // BeforeCreating will fire before the model is being inserted into the Datastore
 func (m *BlockHeader) BeforeCreating(_ context.Context) error {
 
@@ -210,17 +343,75 @@ func (m *BlockHeader) BeforeCreating(_ context.Context) error {
 func (m *BlockHeader) AfterCreated(_ context.Context) error {
 	m.DebugLog("starting: " + m.Name() + " AfterCreated hook...")
 
+	// Add to the block header index
+	if index := m.Client().BlockHeaderIndex(); index != nil {
+		index.put(m)
+	}
+
 	m.DebugLog("end: " + m.Name() + " AfterCreated hook")
 	return nil
 }
 
+// AfterUpdated will fire after the model is updated in the Datastore
+func (m *BlockHeader) AfterUpdated(_ context.Context) error {
+	m.DebugLog("starting: " + m.Name() + " AfterUpdated hook...")
+
+	// Update the block header index (IE: orphaned or synced)
+	if index := m.Client().BlockHeaderIndex(); index != nil {
+		index.put(m)
+	}
+
+	m.DebugLog("end: " + m.Name() + " AfterUpdated hook")
+	return nil
+}
+
 // Display filter the model for display
 func (m *BlockHeader) Display() interface{} {
 	return m
 }
 
+// RegisterTasks will register the model specific tasks on client initialization
+func (m *BlockHeader) RegisterTasks() error {
+
+	// No task manager loaded?
+	tm := m.Client().Taskmanager()
+	if tm == nil {
+		return nil
+	}
+
+	// Register the task locally (cron task - set the defaults)
+	syncTask := m.Name() + "_sync"
+	ctx := context.Background()
+
+	// Register the task
+	if err := tm.RegisterTask(&taskmanager.Task{
+		Name:       syncTask,
+		RetryLimit: 1,
+		Handler: func(client ClientInterface) error {
+			if taskErr := taskSyncBlockHeaders(ctx, client.Logger(), WithClient(client)); taskErr != nil {
+				client.Logger().Error(ctx, "error running "+syncTask+" task: "+taskErr.Error())
+			}
+			return nil
+		},
+	}); err != nil {
+		return err
+	}
+
+	// Run the task periodically
+	return tm.RunTask(ctx, &taskmanager.TaskOptions{
+		Arguments:      []interface{}{m.Client()},
+		RunEveryPeriod: m.Client().GetTaskPeriod(syncTask),
+		TaskName:       syncTask,
+	})
+}
+
 // Migrate model specific migration on startup
 func (m *BlockHeader) Migrate(client datastore.ClientInterface) error {
+	// the height is no longer unique (orphaned block headers are kept), replace the old unique index
+	if err := m.migrateHeightIndex(client); err != nil {
+		return err
+	}
+
 	// import all previous block headers from file
 	blockHeadersFile := m.Client().ImportBlockHeadersFromURL()
 	if blockHeadersFile != "" {
@@ -228,25 +419,79 @@ func (m *BlockHeader) Migrate(client datastore.ClientInterface) error {
 		// check whether we have block header 0, then we do not import
 		blockHeader0, err := getBlockHeaderByHeight(ctx, 0, m.Client().DefaultModelOptions()...)
 		if err != nil {
-			// stop execution if block headers import is not successful
-			// the block headers state can be messed up if they are not imported, or half imported
-			panic(err.Error())
-		} else {
-			if blockHeader0 == nil {
-				// import block headers in the background
-				m.Client().Logger().Info(ctx, "Importing block headers into database")
-				err = m.importBlockHeaders(ctx, client, blockHeadersFile)
-				if err != nil {
-					// stop execution if block headers import is not successful
-					// the block headers state can be messed up if they are not imported, or half imported
-					panic(err.Error())
-				} else {
-					m.Client().Logger().Info(ctx, "Successfully imported all block headers into database")
-				}
+			// a failed (or partial) import is not fatal, the missing block headers are backfilled by the header sync
+			m.Client().Logger().Error(ctx, "error checking block headers: "+err.Error())
+		} else if blockHeader0 == nil {
+			m.Client().Logger().Info(ctx, "Importing block headers into database")
+			if err = m.importBlockHeaders(ctx, client, blockHeadersFile); err != nil {
+				m.Client().Logger().Error(ctx, "error importing block headers: "+err.Error())
+			} else {
+				m.Client().Logger().Info(ctx, "Successfully imported all block headers into database")
 			}
 		}
 	}
 
+	// create the missing compact records of the block headers (the block header index is loaded from them)
+	ctx := context.Background()
+	if err := migrateCompactBlockHeaders(ctx, client, m.Client().DefaultModelOptions()...); err != nil {
+		m.Client().Logger().Error(ctx, "error migrating the compact block headers: "+err.Error())
+	}
+
+	return nil
+}
+
+// migrateHeightIndex will replace the (legacy) unique index on the block height with a non-unique index
+// (orphaned block headers are kept), the height stays unique for the block headers on the chain
+func (m *BlockHeader) migrateHeightIndex(client datastore.ClientInterface) error {
+	tableName := client.GetTableName(tableBlockHeaders)
+	oldIdxName := "idx_" + tableName + "_height"
+	idxName := "idx_" + tableName + "_height_orphaned"
+	chainIdxName := "idx_" + tableName + "_chain_height"
+	if client.Engine() == datastore.MySQL {
+		idxExists, err := client.IndexExists(tableName, oldIdxName)
+		if err != nil {
+			return err
+		}
+		if idxExists {
+			if tx := client.Execute("DROP INDEX `" + oldIdxName + "` ON `" + tableName + "`"); tx.Error != nil {
+				return tx.Error
+			}
+		}
+		if idxExists, err = client.IndexExists(tableName, idxName); err != nil {
+			return err
+		}
+		if !idxExists {
+			tx := client.Execute("CREATE INDEX `" + idxName + "` ON `" + tableName + "` (height,orphaned)")
+			if tx.Error != nil {
+				return tx.Error
+			}
+		}
+
+		// MySQL has no partial indexes, use a generated column (NULL for orphaned block headers)
+		if idxExists, err = client.IndexExists(tableName, chainIdxName); err != nil {
+			return err
+		}
+		if !idxExists {
+			if tx := client.Execute("ALTER TABLE `" + tableName + "` ADD COLUMN `chain_height` INT UNSIGNED " +
+				"AS (IF(orphaned IS NULL, height, NULL)) STORED"); tx.Error != nil {
+				return tx.Error
+			}
+			tx := client.Execute("CREATE UNIQUE INDEX `" + chainIdxName + "` ON `" + tableName + "` (chain_height)")
+			return tx.Error
+		}
+	} else if client.Engine() == datastore.PostgreSQL || client.Engine() == datastore.SQLite {
+		if tx := client.Execute(`DROP INDEX IF EXISTS "` + oldIdxName + `"`); tx.Error != nil {
+			return tx.Error
+		}
+		tx := client.Execute(`CREATE INDEX IF NOT EXISTS "` + idxName + `" ON "` + tableName + `" ("height","orphaned")`)
+		if tx.Error != nil {
+			return tx.Error
+		}
+		tx = client.Execute(`CREATE UNIQUE INDEX IF NOT EXISTS "` + chainIdxName + `" ON "` + tableName +
+			`" ("height") WHERE "orphaned" IS NULL`)
+		return tx.Error
+	}
+
 	return nil
 }
 
@@ -0,0 +1,239 @@
+package bux
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"math/big"
+	"time"
+
+	"github.com/BuxOrg/bux/chainstate"
+	"github.com/BuxOrg/bux/notifications"
+	"github.com/mrz1836/go-datastore"
+	customTypes "github.com/mrz1836/go-datastore/custom_types"
+)
+
+// detectReorg will detect a chain reorganization for the (new) block header
+//
+// Returns the height from which the stored block headers are no longer on the chain of the new block header
+// (a different block at the same height, or a different previous block), and true if a reorg was detected
+func (m *BlockHeader) detectReorg(ctx context.Context) (uint32, bool, error) {
+	opts := m.GetOptions(false)
+
+	// A different block at the previous height (the new block builds on another chain)
+	if m.Height > 0 && len(m.HashPreviousBlock) > 0 {
+		previous, err := getBlockHeaderByHeight(ctx, m.Height-1, opts...)
+		if err != nil {
+			return 0, false, err
+		} else if previous != nil && previous.ID != m.HashPreviousBlock {
+			return m.Height - 1, true, nil
+		}
+	}
+
+	// A different block at the same height
+	current, err := getBlockHeaderByHeight(ctx, m.Height, opts...)
+	if err != nil {
+		return 0, false, err
+	} else if current != nil && current.ID != m.ID {
+		return m.Height, true, nil
+	}
+
+	return 0, false, nil
+}
+
+// hasMoreWork will return true if the chain of the (competing) block header has more (cumulative) work
+// than the current chain
+//
+// The work of the competing block headers (the block header and its orphaned ancestors) is compared to the
+// work of the current chain from the lowest competing height up to the tip. A competing block header never
+// orphans the current tip on its own, it is kept as orphaned until its chain has more work than the current chain
+func (m *BlockHeader) hasMoreWork(ctx context.Context, tip *BlockHeader) (bool, error) {
+	if tip == nil {
+		return true, nil
+	}
+	opts := m.GetOptions(false)
+
+	// Work of the competing chain
+	work := blockHeaderWork(m.Bits)
+	hash, height := m.HashPreviousBlock, m.Height
+	for height > 0 {
+		ancestor, err := getBlockHeaderByID(ctx, hash, opts...)
+		if err != nil {
+			return false, err
+		} else if ancestor == nil || !ancestor.Orphaned.Valid || ancestor.Height >= height {
+			break
+		}
+		work.Add(work, blockHeaderWork(ancestor.Bits))
+		hash, height = ancestor.HashPreviousBlock, ancestor.Height
+	}
+
+	// Work of the current chain (from the tip down, until it has at least the same work)
+	currentWork := new(big.Int)
+	for num := int64(tip.Height); num >= int64(height) && currentWork.Cmp(work) < 0; num-- {
+		current, err := getBlockHeaderByHeight(ctx, uint32(num), opts...)
+		if err != nil {
+			return false, err
+		} else if current != nil {
+			currentWork.Add(currentWork, blockHeaderWork(current.Bits))
+		}
+	}
+	return work.Cmp(currentWork) > 0, nil
+}
+
+// reorganizeChain will reorganize the chain to the (longer) chain of the block header
+//
+// The block headers from the fork point (and up) are orphaned and the orphaned ancestors
+// of the block header are restored to the chain
+func (m *BlockHeader) reorganizeChain(ctx context.Context, reorgHeight uint32) error {
+	opts := m.GetOptions(false)
+
+	// Find the orphaned ancestors (the competing chain below the block header)
+	var ancestors []*BlockHeader
+	hash, height := m.HashPreviousBlock, m.Height
+	for height > 0 {
+		ancestor, err := getBlockHeaderByID(ctx, hash, opts...)
+		if err != nil {
+			return err
+		} else if ancestor == nil || !ancestor.Orphaned.Valid || ancestor.Height >= height {
+			break
+		}
+		ancestors = append(ancestors, ancestor)
+		if ancestor.Height < reorgHeight {
+			reorgHeight = ancestor.Height
+		}
+		hash, height = ancestor.HashPreviousBlock, ancestor.Height
+	}
+
+	// Orphan the block headers of the current chain from the fork point
+	if _, err := orphanBlockHeaders(ctx, reorgHeight, opts...); err != nil {
+		return err
+	}
+
+	// Restore the ancestors
+	for _, ancestor := range ancestors {
+		record, err := getBlockHeaderRecordByID(ctx, ancestor.ID, opts...)
+		if err != nil {
+			return err
+		} else if record == nil {
+			continue
+		}
+		record.Orphaned.Valid = false
+		if err = record.Save(ctx); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// orphanBlockHeaders will mark all the block headers from the given height (and up) as orphaned
+//
+// All the transactions that were recorded in the orphaned blocks are reset to unconfirmed
+// and re-queued for syncing (on-chain check)
+func orphanBlockHeaders(ctx context.Context, fromHeight uint32, opts ...ModelOps) ([]*BlockHeader, error) {
+
+	// Get all the (non-orphaned) block headers from the given height
+	var models []BlockHeader
+	conditions := map[string]interface{}{
+		"height": map[string]interface{}{
+			"$gte": fromHeight,
+		},
+		"orphaned": nil,
+	}
+	if err := getModels(
+		ctx, NewBaseModel(ModelBlockHeader, opts...).Client().Datastore(),
+		&models, conditions, nil, defaultDatabaseReadTimeout,
+	); err != nil {
+		if errors.Is(err, datastore.ErrNoResults) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	blockHeaders := make([]*BlockHeader, 0)
+	for index := range models {
+		blockHeader := &models[index]
+		blockHeader.enrich(ModelBlockHeader, opts...)
+
+		// Mark the block header as orphaned
+		blockHeader.Orphaned = customTypes.NullTime{NullTime: sql.NullTime{
+			Time:  time.Now().UTC(),
+			Valid: true,
+		}}
+		if err := blockHeader.Save(ctx); err != nil {
+			return nil, err
+		}
+
+		// Reset all the transactions in the block
+		if err := resetOrphanedTransactions(ctx, blockHeader); err != nil {
+			return nil, err
+		}
+
+		// Fire notifications
+		notify(notifications.EventTypeReorg, blockHeader)
+
+		blockHeaders = append(blockHeaders, blockHeader)
+	}
+
+	return blockHeaders, nil
+}
+
+// resetOrphanedTransactions will reset all the transactions recorded in the (orphaned) block to unconfirmed
+func resetOrphanedTransactions(ctx context.Context, blockHeader *BlockHeader) error {
+	opts := blockHeader.GetOptions(false)
+
+	transactions, err := getTransactionsInternal(ctx, map[string]interface{}{
+		"block_hash": blockHeader.ID,
+	}, "", nil, opts...)
+	if err != nil {
+		return err
+	}
+
+	for _, transaction := range transactions {
+
+		// Remove the block information
+		transaction.BlockHash = ""
+		transaction.BlockHeight = 0
+		if err = transaction.Save(ctx); err != nil {
+			return err
+		}
+
+		// Re-queue the transaction for syncing
+		if err = requeueSyncTransaction(ctx, transaction, blockHeader); err != nil {
+			return err
+		}
+
+		// Fire notifications
+		notify(notifications.EventTypeReorg, transaction)
+	}
+
+	return nil
+}
+
+// requeueSyncTransaction will set the on-chain sync of the transaction back to ready
+func requeueSyncTransaction(ctx context.Context, transaction *Transaction, blockHeader *BlockHeader) error {
+	opts := transaction.GetOptions(false)
+
+	syncTx, err := GetSyncTransactionByID(ctx, transaction.ID, opts...)
+	if err != nil {
+		return err
+	}
+
+	// Not found? Create a new sync transaction (only syncing on-chain)
+	if syncTx == nil {
+		syncTx = newSyncTransaction(
+			transaction.ID,
+			&SyncConfig{
+				SyncOnChain: true,
+			},
+			append(opts, New())...,
+		)
+		return syncTx.Save(ctx)
+	}
+
+	bailAndSaveSyncTransaction(
+		ctx, syncTx, SyncStatusReady, syncActionSync, chainstate.ProviderAll,
+		"block "+blockHeader.ID+" was orphaned by a chain reorganization",
+	)
+	return nil
+}
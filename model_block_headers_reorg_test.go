@@ -0,0 +1,285 @@
+package bux
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"testing"
+
+	"github.com/libsv/go-bc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testBlockHash0  = "000000000019d6689c085ae165831e934ff763ae46a2a6c172b3f1b60a8ce26f"
+	testBlockHash1  = "00000000839a8e6886ab5951d76f411475428afc90947ee320161bbf18eb6048"
+	testBlockHash1B = "0000000000000000000000000000000000000000000000000000000000000b01"
+	testBlockHash2  = "000000006a625f06636b8bb6ac7b960a8d03705d1ace08b1a19da3fdcc99ddbd"
+	testBlockHash2B = "0000000000000000000000000000000000000000000000000000000000000b02"
+	testBlockHash3  = "0000000082b5015589a3fdf2d4baff403e6f0be035a5d9742c1cae6295464449"
+	testBlockHash3B = "0000000000000000000000000000000000000000000000000000000000000b03"
+)
+
+// recordTestBlockHeader will record a block header with the given previous block hash
+func recordTestBlockHeader(ctx context.Context, t *testing.T, client ClientInterface,
+	hash string, height uint32, previousHash string) *BlockHeader {
+
+	previous, err := hex.DecodeString(previousHash)
+	require.NoError(t, err)
+
+	var blockHeader *BlockHeader
+	blockHeader, err = client.RecordBlockHeader(ctx, hash, height, bc.BlockHeader{
+		HashPrevBlock:  previous,
+		HashMerkleRoot: []byte{},
+		Bits:           []byte{0x1d, 0x00, 0xff, 0xff},
+	})
+	require.NoError(t, err)
+	require.NotNil(t, blockHeader)
+	return blockHeader
+}
+
+// initBlockHeaderReorgTestCase will create a client that records (fake) block headers without validation
+func initBlockHeaderReorgTestCase(t *testing.T) (context.Context, ClientInterface, func()) {
+	return CreateTestSQLiteClient(t, false, true,
+		WithCustomTaskManager(&taskManagerMockBase{}),
+		WithBlockHeaderValidationDisabled(),
+	)
+}
+
+// TestClient_RecordBlockHeader_Reorg will test the reorg detection of the method RecordBlockHeader()
+func TestClient_RecordBlockHeader_Reorg(t *testing.T) {
+
+	t.Run("no reorg", func(t *testing.T) {
+		ctx, client, deferMe := initBlockHeaderReorgTestCase(t)
+		defer deferMe()
+
+		recordTestBlockHeader(ctx, t, client, testBlockHash0, 0, testBlockHash0)
+		recordTestBlockHeader(ctx, t, client, testBlockHash1, 1, testBlockHash0)
+		recordTestBlockHeader(ctx, t, client, testBlockHash2, 2, testBlockHash1)
+
+		blockHeader, err := client.GetLastBlockHeader(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, testBlockHash2, blockHeader.ID)
+
+		// recording a known block header again
+		blockHeader = recordTestBlockHeader(ctx, t, client, testBlockHash1, 1, testBlockHash0)
+		assert.False(t, blockHeader.Orphaned.Valid)
+
+		blockHeader, err = client.GetLastBlockHeader(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, testBlockHash2, blockHeader.ID)
+	})
+
+	t.Run("competing block at the same height", func(t *testing.T) {
+		ctx, client, deferMe := initBlockHeaderReorgTestCase(t)
+		defer deferMe()
+
+		recordTestBlockHeader(ctx, t, client, testBlockHash0, 0, testBlockHash0)
+		recordTestBlockHeader(ctx, t, client, testBlockHash1, 1, testBlockHash0)
+		recordTestBlockHeader(ctx, t, client, testBlockHash2, 2, testBlockHash1)
+
+		// the competing chain is shorter, the block header is kept as orphaned
+		blockHeader := recordTestBlockHeader(ctx, t, client, testBlockHash1B, 1, testBlockHash0)
+		assert.True(t, blockHeader.Orphaned.Valid)
+
+		var err error
+		blockHeader, err = client.GetBlockHeaderByHeight(ctx, 1)
+		require.NoError(t, err)
+		assert.Equal(t, testBlockHash1, blockHeader.ID)
+
+		// the competing chain has the same length
+		blockHeader = recordTestBlockHeader(ctx, t, client, testBlockHash2B, 2, testBlockHash1B)
+		assert.True(t, blockHeader.Orphaned.Valid)
+
+		blockHeader, err = client.GetLastBlockHeader(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, testBlockHash2, blockHeader.ID)
+
+		// the competing chain is longer, the old blocks are orphaned
+		recordTestBlockHeader(ctx, t, client, testBlockHash3B, 3, testBlockHash2B)
+
+		for _, hash := range []string{testBlockHash1, testBlockHash2} {
+			blockHeader, err = getBlockHeaderByID(ctx, hash, client.DefaultModelOptions()...)
+			require.NoError(t, err)
+			assert.True(t, blockHeader.Orphaned.Valid)
+		}
+
+		for height, hash := range []string{testBlockHash0, testBlockHash1B, testBlockHash2B, testBlockHash3B} {
+			blockHeader, err = client.GetBlockHeaderByHeight(ctx, uint32(height))
+			require.NoError(t, err)
+			require.NotNil(t, blockHeader)
+			assert.Equal(t, hash, blockHeader.ID)
+		}
+	})
+
+	t.Run("different previous block", func(t *testing.T) {
+		ctx, client, deferMe := initBlockHeaderReorgTestCase(t)
+		defer deferMe()
+
+		recordTestBlockHeader(ctx, t, client, testBlockHash0, 0, testBlockHash0)
+		recordTestBlockHeader(ctx, t, client, testBlockHash1, 1, testBlockHash0)
+		recordTestBlockHeader(ctx, t, client, testBlockHash2B, 2, testBlockHash1B)
+
+		blockHeader, err := client.GetBlockHeaderByHeight(ctx, 1)
+		require.NoError(t, err)
+		assert.Nil(t, blockHeader)
+
+		blockHeader, err = client.GetBlockHeaderByHeight(ctx, 2)
+		require.NoError(t, err)
+		assert.Equal(t, testBlockHash2B, blockHeader.ID)
+	})
+
+	t.Run("orphaned block returns to the chain", func(t *testing.T) {
+		ctx, client, deferMe := initBlockHeaderReorgTestCase(t)
+		defer deferMe()
+
+		recordTestBlockHeader(ctx, t, client, testBlockHash0, 0, testBlockHash0)
+		recordTestBlockHeader(ctx, t, client, testBlockHash1, 1, testBlockHash0)
+		recordTestBlockHeader(ctx, t, client, testBlockHash1B, 1, testBlockHash0)
+		recordTestBlockHeader(ctx, t, client, testBlockHash2B, 2, testBlockHash1B)
+
+		blockHeader, err := getBlockHeaderByID(ctx, testBlockHash1, client.DefaultModelOptions()...)
+		require.NoError(t, err)
+		assert.True(t, blockHeader.Orphaned.Valid)
+
+		// recording the orphaned block again does not change the (longer) chain
+		blockHeader = recordTestBlockHeader(ctx, t, client, testBlockHash1, 1, testBlockHash0)
+		assert.True(t, blockHeader.Orphaned.Valid)
+
+		// extending the orphaned block makes it the longest chain
+		recordTestBlockHeader(ctx, t, client, testBlockHash2, 2, testBlockHash1)
+		recordTestBlockHeader(ctx, t, client, testBlockHash3, 3, testBlockHash2)
+
+		blockHeader, err = client.GetBlockHeaderByHeight(ctx, 1)
+		require.NoError(t, err)
+		assert.Equal(t, testBlockHash1, blockHeader.ID)
+
+		blockHeader, err = client.GetLastBlockHeader(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, testBlockHash3, blockHeader.ID)
+
+		for _, hash := range []string{testBlockHash1B, testBlockHash2B} {
+			blockHeader, err = getBlockHeaderByID(ctx, hash, client.DefaultModelOptions()...)
+			require.NoError(t, err)
+			assert.True(t, blockHeader.Orphaned.Valid)
+		}
+	})
+
+	t.Run("shorter competing chain with more work", func(t *testing.T) {
+		ctx, client, deferMe := initBlockHeaderReorgTestCase(t)
+		defer deferMe()
+
+		recordTestBlockHeader(ctx, t, client, testBlockHash0, 0, testBlockHash0)
+		recordTestBlockHeader(ctx, t, client, testBlockHash1, 1, testBlockHash0)
+		recordTestBlockHeader(ctx, t, client, testBlockHash2, 2, testBlockHash1)
+
+		previous, err := hex.DecodeString(testBlockHash0)
+		require.NoError(t, err)
+
+		// the competing block has a higher difficulty than both blocks of the current chain
+		var blockHeader *BlockHeader
+		blockHeader, err = client.RecordBlockHeader(ctx, testBlockHash1B, 1, bc.BlockHeader{
+			HashPrevBlock:  previous,
+			HashMerkleRoot: []byte{},
+			Bits:           []byte{0x1c, 0x00, 0xff, 0xff},
+		})
+		require.NoError(t, err)
+		assert.False(t, blockHeader.Orphaned.Valid)
+
+		blockHeader, err = client.GetLastBlockHeader(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, testBlockHash1B, blockHeader.ID)
+
+		for _, hash := range []string{testBlockHash1, testBlockHash2} {
+			blockHeader, err = getBlockHeaderByID(ctx, hash, client.DefaultModelOptions()...)
+			require.NoError(t, err)
+			assert.True(t, blockHeader.Orphaned.Valid)
+		}
+	})
+
+	t.Run("one block header on the chain per height", func(t *testing.T) {
+		ctx, client, deferMe := initBlockHeaderReorgTestCase(t)
+		defer deferMe()
+
+		recordTestBlockHeader(ctx, t, client, testBlockHash0, 0, testBlockHash0)
+		recordTestBlockHeader(ctx, t, client, testBlockHash1, 1, testBlockHash0)
+
+		previous, err := hex.DecodeString(testBlockHash0)
+		require.NoError(t, err)
+
+		// a second (non-orphaned) block header at the same height is rejected
+		blockHeader := newBlockHeader(testBlockHash1B, 1, bc.BlockHeader{
+			HashPrevBlock:  previous,
+			HashMerkleRoot: []byte{},
+			Bits:           []byte{},
+		}, append(client.DefaultModelOptions(), New())...)
+		require.Error(t, blockHeader.Save(ctx))
+
+		// an orphaned block header at the same height is kept
+		blockHeader.Orphaned.Valid = true
+		require.NoError(t, blockHeader.Save(ctx))
+	})
+
+	t.Run("transactions are reset to unconfirmed", func(t *testing.T) {
+		ctx, client, deferMe := initBlockHeaderReorgTestCase(t)
+		defer deferMe()
+
+		recordTestBlockHeader(ctx, t, client, testBlockHash0, 0, testBlockHash0)
+		recordTestBlockHeader(ctx, t, client, testBlockHash1, 1, testBlockHash0)
+
+		opts := client.DefaultModelOptions()
+
+		// mined transaction with a completed sync
+		tx := newTransaction(testTxHex, append(opts, New())...)
+		tx.BlockHash = testBlockHash1
+		tx.BlockHeight = 1
+		err := tx.Save(ctx)
+		require.NoError(t, err)
+
+		syncTx := newSyncTransaction(tx.ID, &SyncConfig{SyncOnChain: true}, append(opts, New())...)
+		syncTx.SyncStatus = SyncStatusComplete
+		err = syncTx.Save(ctx)
+		require.NoError(t, err)
+
+		// mined transaction without a sync transaction
+		txIDs := []string{tx.ID}
+		tx = newTransaction(testTx2Hex, append(opts, New())...)
+		tx.BlockHash = testBlockHash1
+		tx.BlockHeight = 1
+		err = tx.Save(ctx)
+		require.NoError(t, err)
+		txIDs = append(txIDs, tx.ID)
+
+		recordTestBlockHeader(ctx, t, client, testBlockHash1B, 1, testBlockHash0)
+		recordTestBlockHeader(ctx, t, client, testBlockHash2B, 2, testBlockHash1B)
+
+		for _, txID := range txIDs {
+			tx, err = getTransactionByID(ctx, "", txID, opts...)
+			require.NoError(t, err)
+			assert.Equal(t, "", tx.BlockHash)
+			assert.Equal(t, uint64(0), tx.BlockHeight)
+
+			syncTx, err = GetSyncTransactionByID(ctx, txID, opts...)
+			require.NoError(t, err)
+			require.NotNil(t, syncTx)
+			assert.Equal(t, SyncStatusReady, syncTx.SyncStatus)
+		}
+	})
+}
+
+// testBlockHashAt will return a (fake) block hash for the given height
+func testBlockHashAt(height uint32) string {
+	return fmt.Sprintf("%064x", height+1)
+}
+
+// recordTestBlockHeaders will record a chain of (fake) block headers from height 0 to count-1
+func recordTestBlockHeaders(ctx context.Context, t *testing.T, client ClientInterface, count uint32) {
+	for height := uint32(0); height < count; height++ {
+		previous := testBlockHashAt(0)
+		if height > 0 {
+			previous = testBlockHashAt(height - 1)
+		}
+		recordTestBlockHeader(ctx, t, client, testBlockHashAt(height), height, previous)
+	}
+}
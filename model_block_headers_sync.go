@@ -0,0 +1,265 @@
+package bux
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/BuxOrg/bux/chainstate"
+	"github.com/libsv/go-bc"
+	zLogger "github.com/mrz1836/go-logger"
+)
+
+// blockHeaderSync is a single run of the block header sync (following a block headers source)
+type blockHeaderSync struct {
+	client    ClientInterface
+	opts      []ModelOps
+	recorded  int
+	remaining int
+	source    chainstate.BlockHeadersSource
+}
+
+// syncBlockHeaders will sync the block headers from the block headers source (if loaded)
+//
+// First the gaps below the unsynced block headers are backfilled (these are historical block headers
+// and are marked as synced), then all the new block headers up to the tip of the source are recorded.
+// A fresh datastore starts at the tip and backfills the chain over the following runs.
+//
+// maxHeaders is the maximum number of block headers to record in this run
+func syncBlockHeaders(ctx context.Context, logClient zLogger.GormLoggerInterface, maxHeaders int,
+	opts ...ModelOps) (int, error) {
+
+	client := NewBaseModel(ModelNameEmpty, opts...).Client()
+	if client == nil || client.Chainstate() == nil {
+		return 0, nil
+	}
+	source := client.Chainstate().BlockHeadersSource()
+	if source == nil {
+		return 0, nil
+	}
+
+	// Only one sync at a time
+	unlock, err := newWriteLock(ctx, lockKeySyncBlockHeaders, client.Cachestore())
+	defer unlock()
+	if err != nil {
+		return 0, err
+	}
+
+	// The finality of the transactions is checked once per run (not per block header)
+	s := &blockHeaderSync{
+		client:    client,
+		opts:      append(append([]ModelOps{}, opts...), withoutFinalityCheck()),
+		remaining: maxHeaders,
+		source:    source,
+	}
+	err = s.backfill(ctx)
+	if err == nil {
+		err = s.follow(ctx)
+	}
+	if s.recorded > 0 {
+		if finalErr := processFinalTransactions(
+			ctx, client.FinalityConfirmations(), opts...,
+		); finalErr != nil && err == nil {
+			err = finalErr
+		}
+	}
+	if err != nil {
+		return s.recorded, err
+	}
+
+	if s.recorded > 0 {
+		logClient.Info(ctx, fmt.Sprintf("synced %d block header(s) from %s", s.recorded, source.Name()))
+	}
+	return s.recorded, nil
+}
+
+// backfill will record the missing block headers below any unsynced block header
+func (s *blockHeaderSync) backfill(ctx context.Context) error {
+	unsynced, err := getUnsyncedBlockHeaders(ctx, s.opts...)
+	if err != nil {
+		return err
+	}
+
+	for _, blockHeader := range unsynced {
+		if blockHeader.Height == 0 || s.remaining <= 0 {
+			continue
+		}
+
+		// The previous block header is known (no gap)
+		var previous *BlockHeader
+		if previous, err = getBlockHeaderByHeight(ctx, blockHeader.Height-1, s.opts...); err != nil {
+			return err
+		} else if previous != nil {
+			continue
+		}
+
+		// Record the gap (from the highest known block header below)
+		var from uint32
+		if previous, err = getPreviousBlockHeader(ctx, blockHeader.Height, s.opts...); err != nil {
+			return err
+		} else if previous != nil {
+			from = previous.Height + 1
+		}
+		// A known block header that does not chain to the source (stale chain) is replaced by follow (reorg)
+		if _, err = s.recordRange(
+			ctx, from, blockHeader.Height-1, true,
+		); errors.Is(err, ErrBlockHeaderChainMismatch) {
+			s.client.Logger().Warn(ctx, fmt.Sprintf("skipping block header gap below %d: %s", blockHeader.Height, err.Error()))
+		} else if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// follow will record the new block headers up to the tip of the source
+//
+// If a new block header does not chain to the known block header at the previous height,
+// the sync steps back (up to defaultHeaderSyncMaxReorgDepth) until the chains connect.
+// Recording the block headers of the new chain will orphan the stale block headers (reorg)
+func (s *blockHeaderSync) follow(ctx context.Context) error {
+	tip, err := s.source.GetTipHeight(ctx)
+	if err != nil {
+		if errors.Is(err, chainstate.ErrBlockHeadersSourceEmpty) {
+			return nil
+		}
+		return err
+	}
+
+	// Start at the tip (fresh datastore) or after the last block header
+	from := tip
+	var last *BlockHeader
+	if last, err = getLastBlockHeader(ctx, s.opts...); err != nil {
+		return err
+	} else if last != nil {
+		if last.Height >= tip {
+			return nil
+		}
+		from = last.Height + 1
+	}
+
+	for depth := 0; ; depth++ {
+		var failed uint32
+		if failed, err = s.recordRange(ctx, from, tip, false); !errors.Is(err, ErrBlockHeaderChainMismatch) {
+			return err
+		} else if depth >= defaultHeaderSyncMaxReorgDepth || failed == 0 {
+			return err
+		}
+		from = failed - 1
+	}
+}
+
+// recordRange will record the block headers from the source between the given heights (inclusive)
+//
+// Returns the height of the block header that failed to record
+func (s *blockHeaderSync) recordRange(ctx context.Context, from, to uint32, synced bool) (uint32, error) {
+	for from <= to && s.remaining > 0 {
+		count := defaultHeaderSyncBatchSize
+		if int(to-from)+1 < count {
+			count = int(to-from) + 1
+		}
+		if s.remaining < count {
+			count = s.remaining
+		}
+
+		headers, err := s.source.GetHeadersByHeight(ctx, from, count)
+		if err != nil {
+			return from, err
+		} else if len(headers) == 0 {
+			return from, nil
+		}
+
+		for _, info := range headers {
+			if info.Height != from {
+				return from, fmt.Errorf("%w: expected height %d got %d", ErrBlockHeaderChainMismatch, from, info.Height)
+			}
+			if err = s.record(ctx, info, synced); err != nil {
+				return from, err
+			}
+			from++
+		}
+	}
+	return from, nil
+}
+
+// record will validate and record a block header from the source
+//
+// Historical block headers (synced, backfilling a gap) must also chain to the known block header at the next height
+func (s *blockHeaderSync) record(ctx context.Context, info *chainstate.BlockHeaderInfo, synced bool) error {
+	bh, err := newBlockHeaderFromInfo(info, s.client.Chainstate().PowLimit())
+	if err != nil {
+		return err
+	}
+
+	// The block header must chain to a known block header (or to an empty previous height)
+	if info.Height > 0 {
+		var previous *BlockHeader
+		if previous, err = getBlockHeaderByID(ctx, info.HashPrevBlock, s.opts...); err != nil {
+			return err
+		} else if previous == nil {
+			if previous, err = getBlockHeaderByHeight(ctx, info.Height-1, s.opts...); err != nil {
+				return err
+			} else if previous != nil {
+				return fmt.Errorf("%w: block %d (%s)", ErrBlockHeaderChainMismatch, info.Height, info.Hash)
+			}
+		}
+	}
+	if synced {
+		var next *BlockHeader
+		if next, err = getBlockHeaderByHeight(ctx, info.Height+1, s.opts...); err != nil {
+			return err
+		} else if next != nil && next.HashPreviousBlock != info.Hash {
+			return fmt.Errorf("%w: block %d (%s) is not the previous block of %s",
+				ErrBlockHeaderChainMismatch, info.Height, info.Hash, next.ID)
+		}
+	}
+
+	var blockHeader *BlockHeader
+	if blockHeader, err = s.client.RecordBlockHeader(
+		ctx, info.Hash, info.Height, *bh, s.opts...,
+	); err != nil {
+		return err
+	}
+
+	// Historical block headers do not need to be synced by the monitor
+	if synced && !blockHeader.Synced.Valid {
+		blockHeader.Synced.Valid = true
+		blockHeader.Synced.Time = time.Now().UTC()
+		if err = blockHeader.Save(ctx); err != nil {
+			return err
+		}
+	}
+
+	s.recorded++
+	s.remaining--
+	return nil
+}
+
+// newBlockHeaderFromInfo will create a block header from the source info and validate
+// the block hash and the proof-of-work (powLimit is the minimum difficulty of the network)
+func newBlockHeaderFromInfo(info *chainstate.BlockHeaderInfo, powLimit string) (*bc.BlockHeader, error) {
+	bh := &bc.BlockHeader{
+		Nonce:   info.Nonce,
+		Time:    info.Time,
+		Version: info.Version,
+	}
+
+	var err error
+	if bh.HashPrevBlock, err = hex.DecodeString(info.HashPrevBlock); err != nil {
+		return nil, ErrInvalidBlockHeaderHash
+	}
+	if bh.HashMerkleRoot, err = hex.DecodeString(info.HashMerkleRoot); err != nil {
+		return nil, ErrInvalidBlockHeaderHash
+	}
+	if bh.Bits, err = hex.DecodeString(info.Bits); err != nil {
+		return nil, ErrInvalidBlockHeaderPoW
+	}
+
+	if err = validateBlockHeader(info.Hash, bh, powLimit); err != nil {
+		return nil, err
+	}
+	return bh, nil
+}
@@ -0,0 +1,235 @@
+package bux
+
+import (
+	"context"
+	"encoding/hex"
+	"testing"
+
+	"github.com/BuxOrg/bux/chainstate"
+	"github.com/libsv/go-bk/crypto"
+	"github.com/libsv/go-bt/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testBlockHeaderInfos are the first block headers of the main network
+var testBlockHeaderInfos = []*chainstate.BlockHeaderInfo{{
+	Bits:           "1d00ffff",
+	Hash:           testBlockHash0,
+	HashMerkleRoot: "4a5e1e4baab89f3a32518a88c31bc87f618f76673e2cc77ab2127b7afdeda33b",
+	HashPrevBlock:  "0000000000000000000000000000000000000000000000000000000000000000",
+	Height:         0,
+	Nonce:          2083236893,
+	Time:           1231006505,
+	Version:        1,
+}, {
+	Bits:           "1d00ffff",
+	Hash:           testBlockHash1,
+	HashMerkleRoot: "0e3e2357e806b6cdb1f70b54c3a3a17b6714ee1f0e68bebb44a74b1efd512098",
+	HashPrevBlock:  testBlockHash0,
+	Height:         1,
+	Nonce:          2573394689,
+	Time:           1231469665,
+	Version:        1,
+}, {
+	Bits:           "1d00ffff",
+	Hash:           testBlockHash2,
+	HashMerkleRoot: "9b0fc92260312ce44e74ef369f5c66bbb85848f2eddd5a7a1cde251e54ccfdd5",
+	HashPrevBlock:  testBlockHash1,
+	Height:         2,
+	Nonce:          1639830024,
+	Time:           1231469744,
+	Version:        1,
+}, {
+	Bits:           "1d00ffff",
+	Hash:           testBlockHash3,
+	HashMerkleRoot: "999e1c837c76a1b7fbb7e57baf87b309960f5ffefbf2a9b95dd890602272f644",
+	HashPrevBlock:  testBlockHash2,
+	Height:         3,
+	Nonce:          1844305925,
+	Time:           1231470173,
+	Version:        1,
+}}
+
+// initBlockHeaderSyncTestCase will create a client using a local block headers source
+//
+// The validation is disabled on the client (to record fake block headers), the synced block headers are
+// validated by the sync
+func initBlockHeaderSyncTestCase(t *testing.T, headers []*chainstate.BlockHeaderInfo) (context.Context, ClientInterface, func()) {
+	return CreateTestSQLiteClient(t, false, true,
+		WithCustomTaskManager(&taskManagerMockBase{}),
+		WithCustomChainstate(&chainStateHeaders{source: chainstate.NewStaticHeadersSource(headers)}),
+		WithBlockHeaderValidationDisabled(),
+	)
+}
+
+// Test_newBlockHeaderFromInfo will test the method newBlockHeaderFromInfo()
+func Test_newBlockHeaderFromInfo(t *testing.T) {
+	t.Parallel()
+
+	t.Run("valid block headers", func(t *testing.T) {
+		for _, info := range testBlockHeaderInfos {
+			bh, err := newBlockHeaderFromInfo(info, chainstate.MainNet.PowLimit())
+			require.NoError(t, err)
+			require.NotNil(t, bh)
+			assert.Equal(t, info.HashPrevBlock, hex.EncodeToString(bh.HashPrevBlock))
+		}
+	})
+
+	t.Run("hash mismatch", func(t *testing.T) {
+		info := *testBlockHeaderInfos[1]
+		info.Nonce++
+		_, err := newBlockHeaderFromInfo(&info, chainstate.MainNet.PowLimit())
+		require.ErrorIs(t, err, ErrInvalidBlockHeaderHash)
+
+		info = *testBlockHeaderInfos[1]
+		info.HashPrevBlock = "invalid"
+		_, err = newBlockHeaderFromInfo(&info, chainstate.MainNet.PowLimit())
+		require.ErrorIs(t, err, ErrInvalidBlockHeaderHash)
+	})
+
+	t.Run("invalid proof-of-work", func(t *testing.T) {
+		info := *testBlockHeaderInfos[1]
+		info.Nonce++
+		bh, err := newBlockHeaderFromInfo(testBlockHeaderInfos[1], chainstate.MainNet.PowLimit())
+		require.NoError(t, err)
+		bh.Nonce = info.Nonce
+		info.Hash = hex.EncodeToString(bt.ReverseBytes(crypto.Sha256d(bh.Bytes())))
+
+		_, err = newBlockHeaderFromInfo(&info, chainstate.MainNet.PowLimit())
+		require.ErrorIs(t, err, ErrInvalidBlockHeaderPoW)
+	})
+}
+
+// Test_syncBlockHeaders will test the method syncBlockHeaders()
+func Test_syncBlockHeaders(t *testing.T) {
+
+	t.Run("no source", func(t *testing.T) {
+		ctx, client, deferMe := CreateTestSQLiteClient(t, false, true, WithCustomTaskManager(&taskManagerMockBase{}))
+		defer deferMe()
+
+		recorded, err := syncBlockHeaders(ctx, client.Logger(), 10, client.DefaultModelOptions()...)
+		require.NoError(t, err)
+		assert.Equal(t, 0, recorded)
+	})
+
+	t.Run("follow the tip, then backfill", func(t *testing.T) {
+		ctx, client, deferMe := initBlockHeaderSyncTestCase(t, testBlockHeaderInfos)
+		defer deferMe()
+
+		recorded, err := syncBlockHeaders(ctx, client.Logger(), 10, client.DefaultModelOptions()...)
+		require.NoError(t, err)
+		assert.Equal(t, 1, recorded)
+
+		var blockHeader *BlockHeader
+		blockHeader, err = client.GetLastBlockHeader(ctx)
+		require.NoError(t, err)
+		require.NotNil(t, blockHeader)
+		assert.Equal(t, testBlockHash3, blockHeader.ID)
+		assert.False(t, blockHeader.Synced.Valid)
+
+		recorded, err = syncBlockHeaders(ctx, client.Logger(), 10, client.DefaultModelOptions()...)
+		require.NoError(t, err)
+		assert.Equal(t, 3, recorded)
+
+		for height, hash := range []string{testBlockHash0, testBlockHash1, testBlockHash2} {
+			blockHeader, err = client.GetBlockHeaderByHeight(ctx, uint32(height))
+			require.NoError(t, err)
+			require.NotNil(t, blockHeader)
+			assert.Equal(t, hash, blockHeader.ID)
+			assert.True(t, blockHeader.Synced.Valid)
+		}
+
+		// nothing left to sync
+		recorded, err = syncBlockHeaders(ctx, client.Logger(), 10, client.DefaultModelOptions()...)
+		require.NoError(t, err)
+		assert.Equal(t, 0, recorded)
+	})
+
+	t.Run("follow new block headers", func(t *testing.T) {
+		ctx, client, deferMe := initBlockHeaderSyncTestCase(t, testBlockHeaderInfos)
+		defer deferMe()
+
+		recordTestBlockHeader(ctx, t, client, testBlockHash0, 0, testBlockHeaderInfos[0].HashPrevBlock)
+
+		recorded, err := syncBlockHeaders(ctx, client.Logger(), 2, client.DefaultModelOptions()...)
+		require.NoError(t, err)
+		assert.Equal(t, 2, recorded)
+
+		recorded, err = syncBlockHeaders(ctx, client.Logger(), 10, client.DefaultModelOptions()...)
+		require.NoError(t, err)
+		assert.Equal(t, 1, recorded)
+
+		var blockHeader *BlockHeader
+		blockHeader, err = client.GetLastBlockHeader(ctx)
+		require.NoError(t, err)
+		require.NotNil(t, blockHeader)
+		assert.Equal(t, testBlockHash3, blockHeader.ID)
+		assert.Equal(t, testBlockHash2, blockHeader.HashPreviousBlock)
+	})
+
+	t.Run("reorg", func(t *testing.T) {
+		ctx, client, deferMe := initBlockHeaderSyncTestCase(t, testBlockHeaderInfos)
+		defer deferMe()
+
+		recordTestBlockHeader(ctx, t, client, testBlockHash0, 0, testBlockHeaderInfos[0].HashPrevBlock)
+		recordTestBlockHeader(ctx, t, client, testBlockHash1, 1, testBlockHash0)
+		recordTestBlockHeader(ctx, t, client, testBlockHash2B, 2, testBlockHash1)
+
+		recorded, err := syncBlockHeaders(ctx, client.Logger(), 10, client.DefaultModelOptions()...)
+		require.NoError(t, err)
+		assert.Equal(t, 2, recorded)
+
+		var blockHeader *BlockHeader
+		blockHeader, err = client.GetBlockHeaderByHeight(ctx, 2)
+		require.NoError(t, err)
+		require.NotNil(t, blockHeader)
+		assert.Equal(t, testBlockHash2, blockHeader.ID)
+
+		blockHeader, err = getBlockHeaderByID(ctx, testBlockHash2B, client.DefaultModelOptions()...)
+		require.NoError(t, err)
+		require.NotNil(t, blockHeader)
+		assert.True(t, blockHeader.Orphaned.Valid)
+	})
+
+	t.Run("backfill must chain to the next block header", func(t *testing.T) {
+		ctx, client, deferMe := initBlockHeaderSyncTestCase(t, testBlockHeaderInfos)
+		defer deferMe()
+
+		recordTestBlockHeader(ctx, t, client, testBlockHash3, 3, testBlockHash2B)
+
+		recorded, err := syncBlockHeaders(ctx, client.Logger(), 10, client.DefaultModelOptions()...)
+		require.NoError(t, err)
+		assert.Equal(t, 2, recorded)
+
+		var blockHeader *BlockHeader
+		blockHeader, err = client.GetBlockHeaderByHeight(ctx, 1)
+		require.NoError(t, err)
+		require.NotNil(t, blockHeader)
+		assert.Equal(t, testBlockHash1, blockHeader.ID)
+
+		blockHeader, err = client.GetBlockHeaderByHeight(ctx, 2)
+		require.NoError(t, err)
+		assert.Nil(t, blockHeader)
+	})
+
+	t.Run("invalid block header", func(t *testing.T) {
+		info := *testBlockHeaderInfos[1]
+		info.Nonce++
+		ctx, client, deferMe := initBlockHeaderSyncTestCase(t, []*chainstate.BlockHeaderInfo{
+			testBlockHeaderInfos[0], &info,
+		})
+		defer deferMe()
+
+		recordTestBlockHeader(ctx, t, client, testBlockHash0, 0, testBlockHeaderInfos[0].HashPrevBlock)
+
+		recorded, err := syncBlockHeaders(ctx, client.Logger(), 10, client.DefaultModelOptions()...)
+		require.ErrorIs(t, err, ErrInvalidBlockHeaderHash)
+		assert.Equal(t, 0, recorded)
+
+		var blockHeader *BlockHeader
+		blockHeader, err = client.GetBlockHeaderByHeight(ctx, 1)
+		require.NoError(t, err)
+		assert.Nil(t, blockHeader)
+	})
+}
@@ -0,0 +1,316 @@
+package bux
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/BuxOrg/bux/chainstate"
+	"github.com/libsv/go-bc"
+	"github.com/libsv/go-bk/crypto"
+	"github.com/libsv/go-bt/v2"
+)
+
+// Difficulty adjustment (consensus rules of the network)
+const (
+	blockTargetSpacing = 600                    // Target time between blocks (seconds)
+	daaWindow          = 144                    // Blocks of the DAA window
+	edaMedianTimespan  = 12 * 3600              // EDA: the difficulty drops when 6 blocks took more than 12 hours
+	medianTimeBlocks   = 11                     // Blocks of the median time past
+	minDifficultyDelay = 2 * blockTargetSpacing // Testnet: the minimum difficulty after 20 minutes without a block
+	retargetInterval   = 2016                   // Blocks between the (legacy) retargets
+	retargetTimespan   = 14 * 24 * 3600         // Target time of the (legacy) retarget interval
+)
+
+// validateBlockHeader will validate the block header data against the block hash and the
+// difficulty target (proof-of-work)
+//
+// The previous block hash is part of the hashed data, so the linkage is covered by the hash.
+// The difficulty target cannot be easier than the minimum difficulty of the network (powLimit, compact target)
+func validateBlockHeader(hash string, bh *bc.BlockHeader, powLimit string) error {
+	if len(bh.HashPrevBlock) != 32 || len(bh.HashMerkleRoot) != 32 {
+		return ErrInvalidBlockHeaderHash
+	} else if len(bh.Bits) != 4 {
+		return ErrInvalidBlockHeaderPoW
+	}
+
+	if hex.EncodeToString(bt.ReverseBytes(crypto.Sha256d(bh.Bytes()))) != hash {
+		return ErrInvalidBlockHeaderHash
+	}
+
+	// Check the difficulty target against the minimum difficulty
+	target, err := bc.ExpandTargetFromAsInt(hex.EncodeToString(bh.Bits))
+	if err != nil || target.Sign() <= 0 {
+		return ErrInvalidBlockHeaderPoW
+	}
+	var limit *big.Int
+	if limit, err = bc.ExpandTargetFromAsInt(powLimit); err != nil || target.Cmp(limit) > 0 {
+		return ErrInvalidBlockHeaderPoW
+	}
+
+	if !bh.Valid() {
+		return ErrInvalidBlockHeaderPoW
+	}
+	return nil
+}
+
+// blockHeaderWork will return the expected work (number of hashes) of the compact target (bits) of a block header
+//
+// work = 2^256 / (target + 1), invalid bits have no work
+func blockHeaderWork(bits string) *big.Int {
+	if len(bits) != 8 {
+		return new(big.Int)
+	}
+	target, err := bc.ExpandTargetFromAsInt(bits)
+	if err != nil || target.Sign() <= 0 {
+		return new(big.Int)
+	}
+	return new(big.Int).Div(
+		new(big.Int).Lsh(big.NewInt(1), 256),
+		target.Add(target, big.NewInt(1)),
+	)
+}
+
+// validateHeight will check the height continuity: the previous block (if known) must be at the previous height
+func (m *BlockHeader) validateHeight(ctx context.Context) error {
+	if m.Height == 0 {
+		return nil
+	}
+
+	previous, err := getBlockHeaderByID(ctx, m.HashPreviousBlock, m.GetOptions(false)...)
+	if err != nil {
+		return err
+	} else if previous != nil && previous.Height+1 != m.Height {
+		return ErrBlockHeaderHeightMismatch
+	}
+	return nil
+}
+
+// validateDifficulty will check the difficulty (bits) of the block header against the difficulty expected from the
+// previous block headers (DAA, or EDA & 2016 block retargets before the DAA activation)
+//
+// The check needs the previous block headers of the chain of the block header (difficulty window), the difficulty
+// of a block header without (enough) known previous block headers is only checked against the minimum difficulty
+func (m *BlockHeader) validateDifficulty(ctx context.Context, rules *chainstate.DifficultyRules) error {
+	if m.Height == 0 {
+		return nil
+	}
+
+	ancestors, err := m.getAncestors(ctx, difficultyAncestorsCount(rules, m.Height))
+	if err != nil {
+		return err
+	}
+	if bits, ok := expectedBlockHeaderBits(rules, m, ancestors); ok && bits != m.Bits {
+		return fmt.Errorf("%w: block %d (%s) expected %s got %s",
+			ErrInvalidBlockHeaderDifficulty, m.Height, m.ID, bits, m.Bits)
+	}
+	return nil
+}
+
+// getAncestors will get up to count previous block headers (previous block first) of the chain of the block header
+//
+// The previous block hashes are followed (orphaned or not), stops at the first unknown block header
+func (m *BlockHeader) getAncestors(ctx context.Context, count int) ([]*BlockHeader, error) {
+	opts := m.GetOptions(false)
+	ancestors := make([]*BlockHeader, 0, count)
+	hash, height := m.HashPreviousBlock, m.Height
+	for len(ancestors) < count && height > 0 {
+		ancestor, err := getBlockHeaderByID(ctx, hash, opts...)
+		if err != nil {
+			return nil, err
+		} else if ancestor == nil || ancestor.Height+1 != height {
+			break
+		}
+		ancestors = append(ancestors, ancestor)
+		hash, height = ancestor.HashPreviousBlock, ancestor.Height
+	}
+	return ancestors, nil
+}
+
+// difficultyAncestorsCount will return the number of previous block headers needed for the difficulty at the height
+func difficultyAncestorsCount(rules *chainstate.DifficultyRules, height uint32) int {
+	switch {
+	case rules.NoRetargeting:
+		return 1
+	case height-1 >= rules.DAAHeight:
+		return daaWindow + 3
+	case height%retargetInterval == 0 || rules.AllowMinDifficultyBlocks:
+		return retargetInterval
+	default:
+		return medianTimeBlocks + 6
+	}
+}
+
+// expectedBlockHeaderBits will return the expected difficulty (bits) of the block header given its previous block
+// headers (previous block first), false if the previous block headers are not enough to know the difficulty
+func expectedBlockHeaderBits(rules *chainstate.DifficultyRules, m *BlockHeader,
+	ancestors []*BlockHeader) (string, bool) {
+
+	if len(ancestors) == 0 {
+		return "", false
+	}
+	previous := ancestors[0]
+	if rules.NoRetargeting {
+		return previous.Bits, true
+	}
+	minDifficulty := rules.AllowMinDifficultyBlocks && int64(m.Time) > int64(previous.Time)+minDifficultyDelay
+
+	// DAA (every block)
+	if previous.Height >= rules.DAAHeight {
+		if minDifficulty {
+			return rules.PowLimit, true
+		}
+		return daaBits(rules, ancestors)
+	}
+
+	// Legacy retarget (every 2016 blocks)
+	if m.Height%retargetInterval == 0 {
+		if len(ancestors) < retargetInterval {
+			return "", false
+		}
+		return retargetBits(rules, previous, ancestors[retargetInterval-1].Time)
+	}
+
+	// Testnet: the difficulty of the last block that does not have the minimum difficulty (special rule)
+	if rules.AllowMinDifficultyBlocks {
+		if minDifficulty {
+			return rules.PowLimit, true
+		}
+		for _, ancestor := range ancestors {
+			if ancestor.Height%retargetInterval == 0 || ancestor.Bits != rules.PowLimit {
+				return ancestor.Bits, true
+			}
+		}
+		return "", false
+	}
+
+	// EDA: the difficulty drops by 20% when the last 6 blocks took more than 12 hours (median time past)
+	if previous.Bits == rules.PowLimit {
+		return rules.PowLimit, true
+	} else if !hasAncestors(ancestors, medianTimeBlocks+6) {
+		return "", false
+	}
+	if medianTimePast(ancestors)-medianTimePast(ancestors[6:]) < edaMedianTimespan {
+		return previous.Bits, true
+	}
+	target, err := bc.ExpandTargetFromAsInt(previous.Bits)
+	if err != nil {
+		return "", false
+	}
+	return limitedTargetBits(rules, target.Add(target, new(big.Int).Rsh(target, 2))), true
+}
+
+// daaBits will return the difficulty of the DAA: the work of the last 144 blocks over their time span
+// (between the median blocks of the first & last 3 blocks of the window)
+func daaBits(rules *chainstate.DifficultyRules, ancestors []*BlockHeader) (string, bool) {
+	if len(ancestors) < daaWindow+3 {
+		return "", false
+	}
+	last := suitableBlockHeader(ancestors[0:3])
+	first := suitableBlockHeader(ancestors[daaWindow : daaWindow+3])
+
+	work := new(big.Int)
+	for _, ancestor := range ancestors {
+		if ancestor.Height > first.Height && ancestor.Height <= last.Height {
+			work.Add(work, blockHeaderWork(ancestor.Bits))
+		}
+	}
+	work.Mul(work, big.NewInt(blockTargetSpacing))
+
+	timespan := int64(last.Time) - int64(first.Time)
+	if timespan > 288*blockTargetSpacing {
+		timespan = 288 * blockTargetSpacing
+	} else if timespan < 72*blockTargetSpacing {
+		timespan = 72 * blockTargetSpacing
+	}
+	work.Div(work, big.NewInt(timespan))
+	if work.Sign() <= 0 {
+		return rules.PowLimit, true
+	}
+
+	// target = (2^256 - work) / work
+	target := new(big.Int).Lsh(big.NewInt(1), 256)
+	target.Sub(target, work).Div(target, work)
+	return limitedTargetBits(rules, target), true
+}
+
+// retargetBits will return the difficulty of the (legacy) retarget: the previous target adjusted by the time
+// span of the last 2016 blocks (limited to a factor of 4)
+func retargetBits(rules *chainstate.DifficultyRules, previous *BlockHeader, firstTime uint32) (string, bool) {
+	timespan := int64(previous.Time) - int64(firstTime)
+	if timespan < retargetTimespan/4 {
+		timespan = retargetTimespan / 4
+	} else if timespan > retargetTimespan*4 {
+		timespan = retargetTimespan * 4
+	}
+	target, err := bc.ExpandTargetFromAsInt(previous.Bits)
+	if err != nil {
+		return "", false
+	}
+	target.Mul(target, big.NewInt(timespan)).Div(target, big.NewInt(retargetTimespan))
+	return limitedTargetBits(rules, target), true
+}
+
+// suitableBlockHeader will return the median block header (by time) of 3 consecutive block headers
+func suitableBlockHeader(blockHeaders []*BlockHeader) *BlockHeader {
+	blocks := [3]*BlockHeader{blockHeaders[2], blockHeaders[1], blockHeaders[0]}
+	if blocks[0].Time > blocks[2].Time {
+		blocks[0], blocks[2] = blocks[2], blocks[0]
+	}
+	if blocks[0].Time > blocks[1].Time {
+		blocks[0], blocks[1] = blocks[1], blocks[0]
+	}
+	if blocks[1].Time > blocks[2].Time {
+		blocks[1], blocks[2] = blocks[2], blocks[1]
+	}
+	return blocks[1]
+}
+
+// medianTimePast will return the median time of the (up to 11) block headers
+func medianTimePast(blockHeaders []*BlockHeader) int64 {
+	times := make([]int64, 0, medianTimeBlocks)
+	for _, blockHeader := range blockHeaders {
+		if len(times) == medianTimeBlocks {
+			break
+		}
+		times = append(times, int64(blockHeader.Time))
+	}
+	sort.Slice(times, func(i, j int) bool { return times[i] < times[j] })
+	return times[len(times)/2]
+}
+
+// hasAncestors will return true if there are count previous block headers (or all of them, down to the genesis)
+func hasAncestors(ancestors []*BlockHeader, count int) bool {
+	return len(ancestors) >= count || (len(ancestors) > 0 && ancestors[len(ancestors)-1].Height == 0)
+}
+
+// limitedTargetBits will return the compact target (bits), limited to the minimum difficulty of the network
+func limitedTargetBits(rules *chainstate.DifficultyRules, target *big.Int) string {
+	if limit, err := bc.ExpandTargetFromAsInt(rules.PowLimit); err == nil && target.Cmp(limit) > 0 {
+		return rules.PowLimit
+	}
+	return targetToBits(target)
+}
+
+// targetToBits will return the compact form (bits) of the target
+func targetToBits(target *big.Int) string {
+	if target.Sign() <= 0 {
+		return "00000000"
+	}
+	size := uint((target.BitLen() + 7) / 8)
+	var mantissa uint64
+	if size <= 3 {
+		mantissa = new(big.Int).Lsh(target, 8*(3-size)).Uint64()
+	} else {
+		mantissa = new(big.Int).Rsh(target, 8*(size-3)).Uint64()
+	}
+
+	// The sign bit is set, use a larger exponent
+	if mantissa&0x00800000 != 0 {
+		mantissa >>= 8
+		size++
+	}
+	return fmt.Sprintf("%08x", uint32(size)<<24|uint32(mantissa))
+}
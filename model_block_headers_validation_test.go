@@ -0,0 +1,371 @@
+package bux
+
+import (
+	"context"
+	"encoding/hex"
+	"math/big"
+	"testing"
+
+	"github.com/BuxOrg/bux/chainstate"
+	"github.com/libsv/go-bc"
+	"github.com/libsv/go-bk/crypto"
+	"github.com/libsv/go-bt/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordValidBlockHeaders will record the (valid) block headers of the main network up to the given height
+func recordValidBlockHeaders(ctx context.Context, t *testing.T, client ClientInterface, toHeight uint32) {
+	for _, info := range testBlockHeaderInfos[:toHeight+1] {
+		bh, err := newBlockHeaderFromInfo(info, chainstate.MainNet.PowLimit())
+		require.NoError(t, err)
+		_, err = client.RecordBlockHeader(ctx, info.Hash, info.Height, *bh)
+		require.NoError(t, err)
+	}
+}
+
+// Test_validateBlockHeader will test the method validateBlockHeader()
+func Test_validateBlockHeader(t *testing.T) {
+	t.Parallel()
+
+	t.Run("valid block headers", func(t *testing.T) {
+		for _, info := range testBlockHeaderInfos {
+			bh, err := newBlockHeaderFromInfo(info, chainstate.MainNet.PowLimit())
+			require.NoError(t, err)
+			assert.NoError(t, validateBlockHeader(info.Hash, bh, chainstate.MainNet.PowLimit()))
+		}
+	})
+
+	t.Run("invalid data", func(t *testing.T) {
+		bh, err := newBlockHeaderFromInfo(testBlockHeaderInfos[1], chainstate.MainNet.PowLimit())
+		require.NoError(t, err)
+
+		assert.ErrorIs(t, validateBlockHeader(testBlockHash2, bh, chainstate.MainNet.PowLimit()), ErrInvalidBlockHeaderHash)
+
+		invalid := *bh
+		invalid.HashMerkleRoot = []byte{}
+		assert.ErrorIs(t, validateBlockHeader(testBlockHash1, &invalid, chainstate.MainNet.PowLimit()), ErrInvalidBlockHeaderHash)
+
+		invalid = *bh
+		invalid.Bits = []byte{}
+		assert.ErrorIs(t, validateBlockHeader(testBlockHash1, &invalid, chainstate.MainNet.PowLimit()), ErrInvalidBlockHeaderPoW)
+	})
+
+	t.Run("difficulty below the network minimum", func(t *testing.T) {
+		bh, err := newBlockHeaderFromInfo(testBlockHeaderInfos[1], chainstate.MainNet.PowLimit())
+		require.NoError(t, err)
+
+		// mine the block header with the regtest difficulty
+		regTest := *bh
+		regTest.Bits, err = hex.DecodeString("207fffff")
+		require.NoError(t, err)
+		for !regTest.Valid() {
+			regTest.Nonce++
+		}
+		hash := hex.EncodeToString(bt.ReverseBytes(crypto.Sha256d(regTest.Bytes())))
+
+		assert.ErrorIs(t, validateBlockHeader(hash, &regTest, chainstate.MainNet.PowLimit()), ErrInvalidBlockHeaderPoW)
+		assert.NoError(t, validateBlockHeader(hash, &regTest, "207fffff"))
+	})
+}
+
+// Test_blockHeaderWork will test the method blockHeaderWork()
+func Test_blockHeaderWork(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "4295032833", blockHeaderWork("1d00ffff").String())
+	assert.Equal(t, "2", blockHeaderWork("207fffff").String())
+	assert.Equal(t, "0", blockHeaderWork("").String())
+	assert.Equal(t, "0", blockHeaderWork("00000000").String())
+}
+
+// Test_targetToBits will test the method targetToBits()
+func Test_targetToBits(t *testing.T) {
+	t.Parallel()
+
+	for _, bits := range []string{"1d00ffff", "1c100000", "207fffff", "1803a30c", "03123456"} {
+		target, err := bc.ExpandTargetFromAsInt(bits)
+		require.NoError(t, err)
+		assert.Equal(t, bits, targetToBits(target))
+	}
+
+	// The sign bit of the mantissa is not set
+	assert.Equal(t, "04008000", targetToBits(big.NewInt(0x800000)))
+	assert.Equal(t, "00000000", targetToBits(new(big.Int)))
+}
+
+// testDifficultyAncestors will return the (fake) previous block headers of the height (previous block first)
+func testDifficultyAncestors(height uint32, count int, spacing uint32, bits string) []*BlockHeader {
+	ancestors := make([]*BlockHeader, 0, count)
+	for num := int64(height) - 1; num >= 0 && len(ancestors) < count; num-- {
+		ancestors = append(ancestors, &BlockHeader{
+			Bits:   bits,
+			Height: uint32(num),
+			Time:   1600000000 + uint32(num)*spacing,
+		})
+	}
+	return ancestors
+}
+
+// Test_expectedBlockHeaderBits will test the method expectedBlockHeaderBits()
+func Test_expectedBlockHeaderBits(t *testing.T) {
+	t.Parallel()
+
+	mainNet := chainstate.MainNet.DifficultyRules()
+	testNet := chainstate.TestNet.DifficultyRules()
+	daaHeight := mainNet.DAAHeight + 1000
+
+	// nextBlockHeader will return the block header after the ancestors
+	nextBlockHeader := func(ancestors []*BlockHeader, spacing uint32) *BlockHeader {
+		return &BlockHeader{
+			Height: ancestors[0].Height + 1,
+			Time:   ancestors[0].Time + spacing,
+		}
+	}
+
+	t.Run("no retargeting", func(t *testing.T) {
+		ancestors := testDifficultyAncestors(100, 1, 1, "207fffff")
+		bits, ok := expectedBlockHeaderBits(
+			&chainstate.DifficultyRules{NoRetargeting: true, PowLimit: "207fffff"}, nextBlockHeader(ancestors, 1), ancestors,
+		)
+		assert.True(t, ok)
+		assert.Equal(t, "207fffff", bits)
+	})
+
+	t.Run("not enough previous block headers", func(t *testing.T) {
+		_, ok := expectedBlockHeaderBits(mainNet, &BlockHeader{Height: daaHeight}, nil)
+		assert.False(t, ok)
+
+		ancestors := testDifficultyAncestors(daaHeight, daaWindow, blockTargetSpacing, "1c100000")
+		_, ok = expectedBlockHeaderBits(mainNet, nextBlockHeader(ancestors, blockTargetSpacing), ancestors)
+		assert.False(t, ok)
+	})
+
+	t.Run("daa", func(t *testing.T) {
+		ancestors := testDifficultyAncestors(daaHeight, daaWindow+3, blockTargetSpacing, "1c100000")
+		bits, ok := expectedBlockHeaderBits(mainNet, nextBlockHeader(ancestors, blockTargetSpacing), ancestors)
+		assert.True(t, ok)
+		assert.Equal(t, "1c100000", bits)
+
+		// Blocks twice as fast, twice the difficulty
+		ancestors = testDifficultyAncestors(daaHeight, daaWindow+3, blockTargetSpacing/2, "1c100000")
+		bits, ok = expectedBlockHeaderBits(mainNet, nextBlockHeader(ancestors, blockTargetSpacing), ancestors)
+		assert.True(t, ok)
+		assert.Equal(t, "1c080000", bits)
+
+		// Never below the minimum difficulty
+		ancestors = testDifficultyAncestors(daaHeight, daaWindow+3, 4*blockTargetSpacing, mainNet.PowLimit)
+		bits, ok = expectedBlockHeaderBits(mainNet, nextBlockHeader(ancestors, blockTargetSpacing), ancestors)
+		assert.True(t, ok)
+		assert.Equal(t, mainNet.PowLimit, bits)
+	})
+
+	t.Run("daa - minimum difficulty blocks (testnet)", func(t *testing.T) {
+		height := testNet.DAAHeight + 1000
+		ancestors := testDifficultyAncestors(height, daaWindow+3, blockTargetSpacing, "1c100000")
+
+		bits, ok := expectedBlockHeaderBits(testNet, nextBlockHeader(ancestors, blockTargetSpacing), ancestors)
+		assert.True(t, ok)
+		assert.Equal(t, "1c100000", bits)
+
+		bits, ok = expectedBlockHeaderBits(testNet, nextBlockHeader(ancestors, minDifficultyDelay+1), ancestors)
+		assert.True(t, ok)
+		assert.Equal(t, testNet.PowLimit, bits)
+
+		// Not on the main network
+		bits, ok = expectedBlockHeaderBits(mainNet, nextBlockHeader(ancestors, minDifficultyDelay+1), ancestors)
+		assert.True(t, ok)
+		assert.Equal(t, "1c100000", bits)
+	})
+
+	t.Run("retarget", func(t *testing.T) {
+		ancestors := testDifficultyAncestors(200*retargetInterval, retargetInterval, blockTargetSpacing/10, "1c100000")
+		bits, ok := expectedBlockHeaderBits(mainNet, nextBlockHeader(ancestors, blockTargetSpacing), ancestors)
+		assert.True(t, ok)
+		assert.Equal(t, "1c040000", bits) // Limited to a factor of 4
+
+		ancestors = testDifficultyAncestors(200*retargetInterval, retargetInterval-1, blockTargetSpacing, "1c100000")
+		_, ok = expectedBlockHeaderBits(mainNet, nextBlockHeader(ancestors, blockTargetSpacing), ancestors)
+		assert.False(t, ok)
+	})
+
+	t.Run("eda", func(t *testing.T) {
+		height := uint32(200*retargetInterval + 100)
+		ancestors := testDifficultyAncestors(height, retargetInterval, blockTargetSpacing, "1c100000")
+		bits, ok := expectedBlockHeaderBits(mainNet, nextBlockHeader(ancestors, blockTargetSpacing), ancestors)
+		assert.True(t, ok)
+		assert.Equal(t, "1c100000", bits)
+
+		// The last 6 blocks took more than 12 hours, the difficulty drops by 20%
+		ancestors = testDifficultyAncestors(height, retargetInterval, 3*3600, "1c100000")
+		bits, ok = expectedBlockHeaderBits(mainNet, nextBlockHeader(ancestors, blockTargetSpacing), ancestors)
+		assert.True(t, ok)
+		assert.Equal(t, "1c140000", bits)
+	})
+
+	t.Run("legacy - minimum difficulty blocks (testnet)", func(t *testing.T) {
+		height := uint32(200*retargetInterval + 100)
+		ancestors := testDifficultyAncestors(height, retargetInterval, blockTargetSpacing, "1c100000")
+		ancestors[0].Bits = testNet.PowLimit
+		ancestors[1].Bits = testNet.PowLimit
+
+		// The difficulty of the last block without the minimum difficulty
+		bits, ok := expectedBlockHeaderBits(testNet, nextBlockHeader(ancestors, blockTargetSpacing), ancestors)
+		assert.True(t, ok)
+		assert.Equal(t, "1c100000", bits)
+
+		bits, ok = expectedBlockHeaderBits(testNet, nextBlockHeader(ancestors, minDifficultyDelay+1), ancestors)
+		assert.True(t, ok)
+		assert.Equal(t, testNet.PowLimit, bits)
+	})
+}
+
+// mineTestBlockHeader will mine a block header (easy difficulty) on the previous block hash
+func mineTestBlockHeader(t *testing.T, previousHash string, time uint32, bits string) (string, bc.BlockHeader) {
+	previous, err := hex.DecodeString(previousHash)
+	require.NoError(t, err)
+	bh := bc.BlockHeader{
+		Bits:           []byte{},
+		HashMerkleRoot: make([]byte, 32),
+		HashPrevBlock:  previous,
+		Time:           time,
+		Version:        1,
+	}
+	bh.Bits, err = hex.DecodeString(bits)
+	require.NoError(t, err)
+	for !bh.Valid() {
+		bh.Nonce++
+	}
+	return hex.EncodeToString(bt.ReverseBytes(crypto.Sha256d(bh.Bytes()))), bh
+}
+
+// TestClient_RecordBlockHeader_Validation will test the validation of the method RecordBlockHeader()
+func TestClient_RecordBlockHeader_Validation(t *testing.T) {
+
+	t.Run("valid block headers", func(t *testing.T) {
+		ctx, client, deferMe := CreateTestSQLiteClient(t, false, true, WithCustomTaskManager(&taskManagerMockBase{}))
+		defer deferMe()
+
+		recordValidBlockHeaders(ctx, t, client, 3)
+
+		blockHeader, err := client.GetLastBlockHeader(ctx)
+		require.NoError(t, err)
+		require.NotNil(t, blockHeader)
+		assert.Equal(t, testBlockHash3, blockHeader.ID)
+	})
+
+	t.Run("invalid block header", func(t *testing.T) {
+		ctx, client, deferMe := CreateTestSQLiteClient(t, false, true, WithCustomTaskManager(&taskManagerMockBase{}))
+		defer deferMe()
+
+		bh, err := newBlockHeaderFromInfo(testBlockHeaderInfos[1], chainstate.MainNet.PowLimit())
+		require.NoError(t, err)
+
+		bh.Nonce++
+		_, err = client.RecordBlockHeader(ctx, testBlockHash1, 1, *bh)
+		require.ErrorIs(t, err, ErrInvalidBlockHeaderHash)
+
+		var blockHeader *BlockHeader
+		blockHeader, err = client.GetBlockHeaderByHash(ctx, testBlockHash1)
+		require.NoError(t, err)
+		assert.Nil(t, blockHeader)
+	})
+
+	t.Run("height continuity", func(t *testing.T) {
+		ctx, client, deferMe := CreateTestSQLiteClient(t, false, true, WithCustomTaskManager(&taskManagerMockBase{}))
+		defer deferMe()
+
+		recordValidBlockHeaders(ctx, t, client, 0)
+
+		bh, err := newBlockHeaderFromInfo(testBlockHeaderInfos[1], chainstate.MainNet.PowLimit())
+		require.NoError(t, err)
+
+		_, err = client.RecordBlockHeader(ctx, testBlockHash1, 5, *bh)
+		require.ErrorIs(t, err, ErrBlockHeaderHeightMismatch)
+
+		_, err = client.RecordBlockHeader(ctx, testBlockHash1, 1, *bh)
+		require.NoError(t, err)
+	})
+
+	t.Run("difficulty", func(t *testing.T) {
+		rules := &chainstate.DifficultyRules{PowLimit: "207fffff"}
+		ctx, client, deferMe := CreateTestSQLiteClient(t, false, true,
+			WithCustomTaskManager(&taskManagerMockBase{}),
+			WithCustomChainstate(&chainStateDifficulty{rules: rules}),
+		)
+		defer deferMe()
+
+		// The DAA window (blocks mined every second)
+		previousHash := hex.EncodeToString(make([]byte, 32))
+		startTime := uint32(1600000000)
+		height := uint32(0)
+		for ; height < daaWindow+3; height++ {
+			hash, bh := mineTestBlockHeader(t, previousHash, startTime+height, rules.PowLimit)
+			_, err := client.RecordBlockHeader(ctx, hash, height, bh)
+			require.NoError(t, err)
+			previousHash = hash
+		}
+
+		// The minimum difficulty is rejected for the next block
+		hash, bh := mineTestBlockHeader(t, previousHash, startTime+height, rules.PowLimit)
+		_, err := client.RecordBlockHeader(ctx, hash, height, bh)
+		require.ErrorIs(t, err, ErrInvalidBlockHeaderDifficulty)
+
+		var blockHeader *BlockHeader
+		blockHeader, err = client.GetBlockHeaderByHash(ctx, hash)
+		require.NoError(t, err)
+		assert.Nil(t, blockHeader)
+
+		// The expected difficulty is accepted
+		hash, bh = mineTestBlockHeader(t, previousHash, startTime+height, "203fffff")
+		blockHeader, err = client.RecordBlockHeader(ctx, hash, height, bh)
+		require.NoError(t, err)
+		require.NotNil(t, blockHeader)
+		assert.Equal(t, "203fffff", blockHeader.Bits)
+	})
+}
+
+// TestClient_IsValidMerkleRoot will test the methods GetMerkleRootAtHeight() and IsValidMerkleRoot()
+func TestClient_IsValidMerkleRoot(t *testing.T) {
+	ctx, client, deferMe := CreateTestSQLiteClient(t, false, true, WithCustomTaskManager(&taskManagerMockBase{}))
+	defer deferMe()
+
+	recordValidBlockHeaders(ctx, t, client, 2)
+
+	t.Run("merkle root at height", func(t *testing.T) {
+		root, err := client.GetMerkleRootAtHeight(ctx, 1)
+		require.NoError(t, err)
+		assert.Equal(t, testBlockHeaderInfos[1].HashMerkleRoot, root)
+
+		_, err = client.GetMerkleRootAtHeight(ctx, 3)
+		require.ErrorIs(t, err, ErrBlockHeaderNotFound)
+	})
+
+	t.Run("valid merkle root", func(t *testing.T) {
+		valid, err := client.IsValidMerkleRoot(ctx, testBlockHeaderInfos[2].HashMerkleRoot, 2)
+		require.NoError(t, err)
+		assert.True(t, valid)
+	})
+
+	t.Run("invalid merkle root", func(t *testing.T) {
+		valid, err := client.IsValidMerkleRoot(ctx, testBlockHeaderInfos[2].HashMerkleRoot, 1)
+		require.NoError(t, err)
+		assert.False(t, valid)
+
+		valid, err = client.IsValidMerkleRoot(ctx, "", 1)
+		require.NoError(t, err)
+		assert.False(t, valid)
+
+		valid, err = client.IsValidMerkleRoot(ctx, testBlockHeaderInfos[3].HashMerkleRoot, 3)
+		require.NoError(t, err)
+		assert.False(t, valid)
+	})
+
+	t.Run("block header by hash", func(t *testing.T) {
+		blockHeader, err := client.GetBlockHeaderByHash(ctx, testBlockHash2)
+		require.NoError(t, err)
+		require.NotNil(t, blockHeader)
+		assert.Equal(t, uint32(2), blockHeader.Height)
+		assert.Equal(t, testBlockHash1, blockHeader.HashPreviousBlock)
+	})
+}
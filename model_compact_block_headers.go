@@ -0,0 +1,215 @@
+package bux
+
+import (
+	"context"
+	"errors"
+
+	"github.com/mrz1836/go-datastore"
+	customTypes "github.com/mrz1836/go-datastore/custom_types"
+)
+
+// CompactBlockHeaderRecord is the compact (persisted) form of a block header: the raw (80-byte) block header
+// keyed by hash & height. The block header index is loaded from these records (see BlockHeaderIndex)
+//
+// The record is saved with its block header (same Datastore transaction, see BlockHeader.ChildModels)
+//
+// Gorm related models & indexes: https://gorm.io/docs/models.html - https://gorm.io/docs/indexes.html
+type CompactBlockHeaderRecord struct {
+	// Base model
+	Model `bson:",inline"`
+
+	// Model specific fields
+	ID       string               `json:"id" toml:"id" yaml:"id" gorm:"<-:create;type:char(64);primaryKey;comment:This is the block hash" bson:"_id"`
+	Height   uint32               `json:"height" toml:"height" yaml:"height" gorm:"<-:create;index;comment:This is the block height" bson:"height"`
+	Raw      []byte               `json:"raw" toml:"raw" yaml:"raw" gorm:"<-;comment:This is the raw (80-byte) block header" bson:"raw"`
+	Orphaned customTypes.NullTime `json:"orphaned" toml:"orphaned" yaml:"orphaned" gorm:"type:timestamp;comment:This is when the block was orphaned by a chain reorganization" bson:"orphaned,omitempty"`
+	Synced   customTypes.NullTime `json:"synced" toml:"synced" yaml:"synced" gorm:"type:timestamp;comment:This is when the block was last synced to the bux server" bson:"synced,omitempty"`
+}
+
+// compactBlockHeaderResult is the projection of the compact block header records (loading the index)
+type compactBlockHeaderResult struct {
+	ID       string               `json:"id" toml:"id" yaml:"id" bson:"_id"`
+	Height   uint32               `json:"height" toml:"height" yaml:"height" bson:"height"`
+	Raw      []byte               `json:"raw" toml:"raw" yaml:"raw" bson:"raw"`
+	Orphaned customTypes.NullTime `json:"orphaned" toml:"orphaned" yaml:"orphaned" bson:"orphaned,omitempty"`
+	Synced   customTypes.NullTime `json:"synced" toml:"synced" yaml:"synced" bson:"synced,omitempty"`
+}
+
+// newCompactBlockHeaderRecord will start the compact record of the block header
+func newCompactBlockHeaderRecord(m *BlockHeader, opts ...ModelOps) *CompactBlockHeaderRecord {
+	h := newCompactBlockHeader(m)
+	record := &CompactBlockHeaderRecord{
+		ID:       h.Hash,
+		Height:   h.Height,
+		Model:    *NewBaseModel(ModelCompactBlockHeader, opts...),
+		Orphaned: m.Orphaned,
+		Raw:      h.Raw[:],
+		Synced:   m.Synced,
+	}
+	record.CreatedAt = m.CreatedAt
+	return record
+}
+
+// compact will return the compact block header of the record
+func (r *compactBlockHeaderResult) compact() *CompactBlockHeader {
+	h := &CompactBlockHeader{
+		Hash:   r.ID,
+		Height: r.Height,
+	}
+	copy(h.Raw[:], r.Raw)
+	if r.Orphaned.Valid {
+		h.Orphaned = r.Orphaned.Time
+	}
+	if r.Synced.Valid {
+		h.Synced = r.Synced.Time
+	}
+	return h
+}
+
+// heightPage will return the number of records of a (full) page sorted by height to keep, and the height for the
+// next page (keyset: height > last)
+//
+// The records at the highest height of a full page are loaded again with the next page (a height can have more than
+// one block header), unless the whole page is at the same height
+func heightPage(count, pageSize int, height func(index int) uint32) (int, int64, bool) {
+	if count == 0 {
+		return 0, 0, true
+	}
+	last := height(count - 1)
+	if count < pageSize {
+		return count, int64(last), true
+	}
+	keep := count
+	for keep > 0 && height(keep-1) == last {
+		keep--
+	}
+	if keep == 0 {
+		return count, int64(last), false
+	}
+	return keep, int64(last) - 1, false
+}
+
+// migrateCompactBlockHeaders will create the missing compact records of the block headers
+// (IE: block headers saved before the compact records existed, or imported from a file)
+func migrateCompactBlockHeaders(ctx context.Context, client datastore.ClientInterface, opts ...ModelOps) error {
+	blockHeaders, err := getModelCount(ctx, client, &BlockHeader{}, map[string]interface{}{}, databaseLongReadTimeout)
+	if err != nil {
+		return err
+	}
+	var records int64
+	if records, err = getModelCount(
+		ctx, client, &CompactBlockHeaderRecord{}, map[string]interface{}{}, databaseLongReadTimeout,
+	); err != nil {
+		return err
+	} else if records >= blockHeaders {
+		return nil
+	}
+
+	lastHeight := int64(-1)
+	for {
+		conditions := map[string]interface{}{}
+		if lastHeight >= 0 {
+			conditions[heightField] = map[string]interface{}{"$gt": lastHeight}
+		}
+		var models []*BlockHeader
+		if err = getModels(
+			ctx, client, &models, conditions, &datastore.QueryParams{
+				Page:          1,
+				PageSize:      defaultHeaderIndexPageSize,
+				OrderByField:  heightField,
+				SortDirection: datastore.SortAsc,
+			}, databaseLongReadTimeout,
+		); err != nil {
+			if errors.Is(err, datastore.ErrNoResults) {
+				return nil
+			}
+			return err
+		}
+
+		keep, next, done := heightPage(len(models), defaultHeaderIndexPageSize, func(index int) uint32 {
+			return models[index].Height
+		})
+		if err = createMissingCompactBlockHeaders(ctx, client, models[:keep], opts...); err != nil {
+			return err
+		} else if done {
+			return nil
+		}
+		lastHeight = next
+	}
+}
+
+// createMissingCompactBlockHeaders will create the compact records of the block headers (sorted by height)
+// that do not have one
+func createMissingCompactBlockHeaders(ctx context.Context, client datastore.ClientInterface,
+	models []*BlockHeader, opts ...ModelOps) error {
+
+	if len(models) == 0 {
+		return nil
+	}
+
+	conditions := map[string]interface{}{
+		heightField: map[string]interface{}{
+			"$gte": models[0].Height,
+			"$lte": models[len(models)-1].Height,
+		},
+	}
+	var existing []*compactBlockHeaderResult
+	if err := client.GetModels(
+		ctx, &[]*CompactBlockHeaderRecord{}, conditions, nil, &existing, databaseLongReadTimeout,
+	); err != nil && !errors.Is(err, datastore.ErrNoResults) {
+		return err
+	}
+	known := make(map[string]bool, len(existing))
+	for _, record := range existing {
+		known[record.ID] = true
+	}
+
+	records := make([]*CompactBlockHeaderRecord, 0, len(models))
+	for _, m := range models {
+		if !known[m.ID] {
+			records = append(records, newCompactBlockHeaderRecord(m, opts...))
+		}
+	}
+	if len(records) == 0 {
+		return nil
+	}
+	return client.CreateInBatches(ctx, records, defaultHeaderIndexPageSize)
+}
+
+// GetModelName will get the name of the current model
+func (r *CompactBlockHeaderRecord) GetModelName() string {
+	return ModelCompactBlockHeader.String()
+}
+
+// GetModelTableName will get the db table name of the current model
+func (r *CompactBlockHeaderRecord) GetModelTableName() string {
+	return tableCompactBlockHeaders
+}
+
+// Save will save the model into the Datastore
+func (r *CompactBlockHeaderRecord) Save(ctx context.Context) error {
+	return Save(ctx, r)
+}
+
+// GetID will get the ID
+func (r *CompactBlockHeaderRecord) GetID() string {
+	return r.ID
+}
+
+// BeforeCreating will fire before the model is being inserted into the Datastore
+func (r *CompactBlockHeaderRecord) BeforeCreating(_ context.Context) error {
+	r.DebugLog("starting: [" + r.name.String() + "] BeforeCreating hook...")
+
+	// Make sure ID is valid
+	if len(r.ID) == 0 {
+		return ErrMissingFieldHash
+	}
+
+	r.DebugLog("end: " + r.Name() + " BeforeCreating hook")
+	return nil
+}
+
+// Migrate model specific migration on startup
+func (r *CompactBlockHeaderRecord) Migrate(client datastore.ClientInterface) error {
+	return client.IndexMetadata(client.GetTableName(tableCompactBlockHeaders), metadataField)
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/BuxOrg/bux/notifications"
 	"github.com/BuxOrg/bux/utils"
 	"github.com/bitcoinschema/go-bitcoin/v2"
+	"github.com/libsv/go-bk/bip32"
 	"github.com/mrz1836/go-datastore"
 	customTypes "github.com/mrz1836/go-datastore/custom_types"
 )
@@ -21,15 +22,16 @@ type Destination struct {
 	Model `bson:",inline"`
 
 	// Model specific fields
-	ID            string               `json:"id" toml:"id" yaml:"id" gorm:"<-:create;type:char(64);primaryKey;comment:This is the hash of the locking script" bson:"_id"`
-	XpubID        string               `json:"xpub_id" toml:"xpub_id" yaml:"xpub_id" gorm:"<-:create;type:char(64);index;comment:This is the related xPub" bson:"xpub_id"`
-	LockingScript string               `json:"locking_script" toml:"locking_script" yaml:"locking_script" gorm:"<-:create;type:text;comment:This is Bitcoin output script in hex" bson:"locking_script"`
-	Type          string               `json:"type" toml:"type" yaml:"type" gorm:"<-:create;type:text;comment:Type of output" bson:"type"`
-	Chain         uint32               `json:"chain" toml:"chain" yaml:"chain" gorm:"<-:create;type:int;comment:This is the (chain)/num location of the address related to the xPub" bson:"chain"`
-	Num           uint32               `json:"num" toml:"num" yaml:"num" gorm:"<-:create;type:int;comment:This is the chain/(num) location of the address related to the xPub" bson:"num"`
-	Address       string               `json:"address" toml:"address" yaml:"address" gorm:"<-:create;type:varchar(35);index;comment:This is the BitCoin address" bson:"address"`
-	DraftID       string               `json:"draft_id" toml:"draft_id" yaml:"draft_id" gorm:"<-:create;type:varchar(64);index;comment:This is the related draft id (if internal tx)" bson:"draft_id,omitempty"`
-	Monitor       customTypes.NullTime `json:"monitor" toml:"monitor" yaml:"monitor" gorm:";index;comment:When this address was last used for an external transaction, for monitoring" bson:"monitor,omitempty"`
+	ID             string               `json:"id" toml:"id" yaml:"id" gorm:"<-:create;type:char(64);primaryKey;comment:This is the hash of the locking script" bson:"_id"`
+	XpubID         string               `json:"xpub_id" toml:"xpub_id" yaml:"xpub_id" gorm:"<-:create;type:char(64);index;comment:This is the related xPub" bson:"xpub_id"`
+	LockingScript  string               `json:"locking_script" toml:"locking_script" yaml:"locking_script" gorm:"<-:create;type:text;comment:This is Bitcoin output script in hex" bson:"locking_script"`
+	Type           string               `json:"type" toml:"type" yaml:"type" gorm:"<-:create;type:text;comment:Type of output" bson:"type"`
+	Chain          uint32               `json:"chain" toml:"chain" yaml:"chain" gorm:"<-:create;type:int;comment:This is the (chain)/num location of the address related to the xPub" bson:"chain"`
+	Num            uint32               `json:"num" toml:"num" yaml:"num" gorm:"<-:create;type:int;comment:This is the chain/(num) location of the address related to the xPub" bson:"num"`
+	DerivationPath string               `json:"derivation_path,omitempty" toml:"derivation_path" yaml:"derivation_path" gorm:"<-:create;type:varchar(64);comment:This is the non-standard derivation path of the address related to the xPub (imported)" bson:"derivation_path,omitempty"`
+	Address        string               `json:"address" toml:"address" yaml:"address" gorm:"<-:create;type:varchar(35);index;comment:This is the BitCoin address" bson:"address"`
+	DraftID        string               `json:"draft_id" toml:"draft_id" yaml:"draft_id" gorm:"<-:create;type:varchar(64);index;comment:This is the related draft id (if internal tx)" bson:"draft_id,omitempty"`
+	Monitor        customTypes.NullTime `json:"monitor" toml:"monitor" yaml:"monitor" gorm:";index;comment:When this address was last used for an external transaction, for monitoring" bson:"monitor,omitempty"`
 }
 
 // newDestination will start a new Destination model for a locking script
@@ -85,6 +87,48 @@ func newAddress(rawXpubKey string, chain, num uint32, opts ...ModelOps) (*Destin
 	return destination, nil
 }
 
+// newAddressFromPath will start a new Destination model for a legacy Bitcoin address using a non-standard
+// derivation path relative to the xPub (IE: addresses imported from other wallets)
+//
+// The chain is set to utils.ChainImported, the key must be derived from the derivation path
+func newAddressFromPath(rawXpubKey string, path []uint32, opts ...ModelOps) (*Destination, error) {
+
+	// Check the xPub
+	hdKey, err := utils.ValidateXPub(rawXpubKey)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create the model (the chain/num is not used to derive the address)
+	destination := &Destination{
+		Chain:          utils.ChainImported,
+		DerivationPath: utils.FormatDerivationPath(path),
+		Model:          *NewBaseModel(ModelDestination, opts...),
+		XpubID:         utils.Hash(rawXpubKey),
+	}
+
+	// Derive the address
+	if destination.Address, err = utils.DeriveAddressFromPath(
+		hdKey, path,
+	); err != nil {
+		return nil, err
+	}
+
+	// Set the locking script
+	if destination.LockingScript, err = bitcoin.ScriptFromAddress(
+		destination.Address,
+	); err != nil {
+		return nil, err
+	}
+
+	// Determine the type if the locking script is provided
+	destination.Type = utils.GetDestinationType(destination.LockingScript)
+	destination.ID = utils.Hash(destination.LockingScript)
+
+	// Return the destination (address)
+	return destination, nil
+}
+
 // getDestinationByID will get the destination by the given id
 func getDestinationByID(ctx context.Context, id string, opts ...ModelOps) (*Destination, error) {
 
@@ -385,6 +429,28 @@ func (m *Destination) setAddress(rawXpubKey string) error {
 	return nil
 }
 
+// deriveChildKey will derive the child key of the destination from the (xPub or xPriv) key
+//
+// Uses the non-standard derivation path if set (imported destinations), otherwise chain/num
+func (m *Destination) deriveChildKey(hdKey *bip32.ExtendedKey) (*bip32.ExtendedKey, error) {
+	path := []uint32{m.Chain, m.Num}
+	if len(m.DerivationPath) > 0 {
+		var err error
+		if path, err = utils.ParseDerivationPath(m.DerivationPath); err != nil {
+			return nil, err
+		}
+	}
+
+	child := hdKey
+	for _, num := range path {
+		var err error
+		if child, err = child.Child(num); err != nil {
+			return nil, err
+		}
+	}
+	return child, nil
+}
+
 // Migrate model specific migration on startup
 func (m *Destination) Migrate(client datastore.ClientInterface) error {
 	return client.IndexMetadata(client.GetTableName(tableDestinations), metadataField)
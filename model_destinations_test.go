@@ -6,6 +6,7 @@ import (
 	"github.com/BuxOrg/bux/tester"
 	"github.com/BuxOrg/bux/utils"
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/libsv/go-bk/bip32"
 	bscript2 "github.com/libsv/go-bt/v2/bscript"
 	"github.com/mrz1836/go-cache"
 	"github.com/mrz1836/go-datastore"
@@ -89,6 +90,76 @@ func TestDestination_newAddress(t *testing.T) {
 
 }
 
+// TestDestination_newAddressFromPath will test the method newAddressFromPath()
+func TestDestination_newAddressFromPath(t *testing.T) {
+	t.Parallel()
+
+	t.Run("invalid xPub", func(t *testing.T) {
+		address, err := newAddressFromPath("test", []uint32{0}, New())
+		assert.Nil(t, address)
+		assert.Error(t, err)
+	})
+
+	t.Run("same as chain / num", func(t *testing.T) {
+		address, err := newAddressFromPath(testXPub, []uint32{0, 0}, New())
+		require.NoError(t, err)
+		require.NotNil(t, address)
+		assert.Equal(t, testXPubID, address.XpubID)
+		assert.Equal(t, testExternalAddress, address.Address)
+		assert.Equal(t, testLockingScript, address.LockingScript)
+		assert.Equal(t, testAddressID, address.GetID())
+		assert.Equal(t, "0/0", address.DerivationPath)
+		assert.Equal(t, utils.ChainImported, address.Chain)
+	})
+
+	t.Run("other path", func(t *testing.T) {
+		address, err := newAddressFromPath(testXPub, []uint32{5}, New())
+		require.NoError(t, err)
+		require.NotNil(t, address)
+		assert.Equal(t, "5", address.DerivationPath)
+		assert.Equal(t, utils.ChainImported, address.Chain)
+		assert.NotEqual(t, testExternalAddress, address.Address)
+		assert.Equal(t, bscript2.ScriptTypePubKeyHash, address.Type)
+	})
+}
+
+// TestDestination_deriveChildKey will test the method deriveChildKey()
+func TestDestination_deriveChildKey(t *testing.T) {
+	t.Parallel()
+
+	xPriv, err := bip32.NewKeyFromString(testXPriv)
+	require.NoError(t, err)
+
+	t.Run("chain / num", func(t *testing.T) {
+		destination, errA := newAddress(testXPub, utils.ChainInternal, 3, New())
+		require.NoError(t, errA)
+
+		key, errK := destination.deriveChildKey(xPriv)
+		require.NoError(t, errK)
+		address, errD := utils.DeriveAddressFromPath(key, nil)
+		require.NoError(t, errD)
+		assert.Equal(t, destination.Address, address)
+	})
+
+	t.Run("derivation path", func(t *testing.T) {
+		destination, errA := newAddressFromPath(testXPub, []uint32{2, 7, 1}, New())
+		require.NoError(t, errA)
+
+		key, errK := destination.deriveChildKey(xPriv)
+		require.NoError(t, errK)
+		address, errD := utils.DeriveAddressFromPath(key, nil)
+		require.NoError(t, errD)
+		assert.Equal(t, destination.Address, address)
+	})
+
+	t.Run("invalid derivation path", func(t *testing.T) {
+		destination := &Destination{DerivationPath: "44'/0"}
+		key, errK := destination.deriveChildKey(xPriv)
+		require.ErrorIs(t, errK, utils.ErrHardenedDerivationPath)
+		assert.Nil(t, key)
+	})
+}
+
 // TestDestination_GetModelName will test the method GetModelName()
 func TestDestination_GetModelName(t *testing.T) {
 	t.Parallel()
@@ -0,0 +1,243 @@
+package bux
+
+import (
+	"bytes"
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/BuxOrg/bux/notifications"
+	"github.com/BuxOrg/bux/utils"
+)
+
+// ApprovalPolicy is the policy for drafts that require approval (four-eyes control)
+type ApprovalPolicy struct {
+	Approvers         []string      `json:"approvers,omitempty" toml:"approvers" yaml:"approvers"`                            // xPub IDs (or admin xPub IDs) allowed to approve (empty = admin keys only)
+	ExpiresIn         time.Duration `json:"expires_in,omitempty" toml:"expires_in" yaml:"expires_in"`                         // Expiration time for drafts pending approval (and their utxos)
+	RequiredApprovals uint32        `json:"required_approvals" toml:"required_approvals" yaml:"required_approvals"`           // Number of approvals required before the draft can be recorded
+	ThresholdSatoshis uint64        `json:"threshold_satoshis,omitempty" toml:"threshold_satoshis" yaml:"threshold_satoshis"` // Drafts sending more than this require approval (0 = only when exceeding spending limits)
+}
+
+// DraftApprovalStatus is the decision of an approver
+type DraftApprovalStatus string
+
+const (
+	// DraftApprovalStatusApproved is when the approver signed off on the draft
+	DraftApprovalStatusApproved DraftApprovalStatus = "approved"
+
+	// DraftApprovalStatusRejected is when the approver rejected the draft
+	DraftApprovalStatusRejected DraftApprovalStatus = "rejected"
+)
+
+// DraftApproval is an approval (or rejection) of a draft by an approver
+type DraftApproval struct {
+	ApproverID string              `json:"approver_id" toml:"approver_id" yaml:"approver_id" bson:"approver_id"` // xPub ID of the approver
+	CreatedAt  time.Time           `json:"created_at" toml:"created_at" yaml:"created_at" bson:"created_at"`     // When the decision was made
+	Note       string              `json:"note,omitempty" toml:"note" yaml:"note" bson:"note,omitempty"`         // Note or reason given by the approver
+	Status     DraftApprovalStatus `json:"status" toml:"status" yaml:"status" bson:"status"`                     // Approved or rejected
+}
+
+// DraftApprovals are all the approvals (and rejections) of a draft
+type DraftApprovals []*DraftApproval
+
+// Scan will scan the value into Struct, implements sql.Scanner interface
+func (a *DraftApprovals) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+
+	xType := fmt.Sprintf("%T", value)
+	var byteValue []byte
+	if xType == ValueTypeString {
+		byteValue = []byte(value.(string))
+	} else {
+		byteValue = value.([]byte)
+	}
+	if bytes.Equal(byteValue, []byte("")) || bytes.Equal(byteValue, []byte("\"\"")) {
+		return nil
+	}
+
+	return json.Unmarshal(byteValue, &a)
+}
+
+// Value return json value, implement driver.Valuer interface
+func (a DraftApprovals) Value() (driver.Value, error) {
+	marshal, err := json.Marshal(a)
+	if err != nil {
+		return nil, err
+	}
+
+	return string(marshal), nil
+}
+
+// approvedCount will return the number of approvals
+func (m *DraftTransaction) approvedCount() (count uint32) {
+	for _, approval := range m.Approvals {
+		if approval.Status == DraftApprovalStatusApproved {
+			count++
+		}
+	}
+	return
+}
+
+// IsApproved will return true if the draft required approval and received all the required approvals
+func (m *DraftTransaction) IsApproved() bool {
+	return m.RequiredApprovals > 0 && m.approvedCount() >= m.RequiredApprovals
+}
+
+// getApprovalPolicy will get the approval policy of the client (or the default)
+func (m *DraftTransaction) getApprovalPolicy() *ApprovalPolicy {
+	if c := m.Client(); c != nil && c.ApprovalPolicy() != nil {
+		return c.ApprovalPolicy()
+	}
+	return &ApprovalPolicy{ExpiresIn: defaultApprovalExpiresIn, RequiredApprovals: defaultRequiredApprovals}
+}
+
+// requireApproval will put the draft into pending approval
+func (m *DraftTransaction) requireApproval(reason string) {
+	m.DebugLog("draft " + m.ID + " requires approval: " + reason)
+	m.Status = DraftStatusPendingApproval
+	m.ApprovalReason = reason
+	policy := m.getApprovalPolicy()
+	m.RequiredApprovals = policy.RequiredApprovals
+	if m.RequiredApprovals == 0 {
+		m.RequiredApprovals = defaultRequiredApprovals
+	}
+
+	// Give the approvers time (expiration can only be set when creating)
+	if m.IsNew() && policy.ExpiresIn > 0 {
+		m.ExpiresAt = time.Now().UTC().Add(policy.ExpiresIn)
+	}
+}
+
+// applyApprovalThreshold will put the draft into pending approval if it exceeds the approval threshold
+func (m *DraftTransaction) applyApprovalThreshold(ctx context.Context) (bool, error) {
+	if m.Status == DraftStatusPendingApproval {
+		return true, nil
+	}
+	policy := m.getApprovalPolicy()
+	if policy.ThresholdSatoshis == 0 {
+		return false, nil
+	}
+	spent, err := m.spentSatoshis(ctx)
+	if err != nil {
+		return false, err
+	} else if spent <= policy.ThresholdSatoshis {
+		return false, nil
+	}
+	m.requireApproval(fmt.Sprintf(
+		"transaction of %d satoshis exceeds the approval threshold of %d satoshis",
+		spent, policy.ThresholdSatoshis,
+	))
+	return true, nil
+}
+
+// checkApprover will check that the approver is a known xPub (or an admin key) allowed by the policy
+//
+// Without approvers in the policy, only admin keys (not read-only) can approve. The configured admin xPubs
+// (no admin key in the Datastore) use the role of the authenticated request
+func (m *DraftTransaction) checkApprover(ctx context.Context, approverID string) error {
+	opts := m.GetOptions(false)
+
+	// Resolve the approver (admin key or xPub)
+	adminKey, err := getAdminKey(ctx, approverID, opts...)
+	if err != nil {
+		return err
+	}
+	role := getAdminRoleFromContext(ctx, approverID)
+	if adminKey != nil && adminKey.IsActive() {
+		role = adminKey.Role
+	}
+	isAdmin := role.IsValid()
+	if !isAdmin {
+		var xPub *Xpub
+		if xPub, err = getXpubByID(ctx, approverID, opts...); err != nil {
+			return err
+		} else if xPub == nil {
+			return ErrDraftApproverUnknown
+		}
+	}
+
+	// Approver must be allowed by the policy
+	if policy := m.getApprovalPolicy(); len(policy.Approvers) > 0 {
+		if !utils.StringInSlice(approverID, policy.Approvers) {
+			return ErrDraftApproverNotAllowed
+		}
+	} else if !isAdmin {
+		return ErrDraftApproverNotAllowed
+	}
+
+	// Read-only admins can not approve
+	if isAdmin && role.IsReadOnly() {
+		return ErrDraftApproverNotAllowed
+	}
+	return nil
+}
+
+// addApprovalDecision will add the decision of the approver to the draft (does not save)
+func (m *DraftTransaction) addApprovalDecision(ctx context.Context, approverID, note string,
+	status DraftApprovalStatus) error {
+
+	// Only drafts pending approval
+	if m.Status != DraftStatusPendingApproval {
+		return ErrDraftNotPendingApproval
+	}
+
+	// Four-eyes: the owner can not approve its own draft
+	if approverID == m.XpubID {
+		return ErrDraftApproverIsOwner
+	}
+
+	// Approver must be known and allowed by the policy
+	if err := m.checkApprover(ctx, approverID); err != nil {
+		return err
+	}
+
+	// One decision per approver
+	for _, approval := range m.Approvals {
+		if approval.ApproverID == approverID {
+			return ErrDraftAlreadyDecided
+		}
+	}
+
+	m.Approvals = append(m.Approvals, &DraftApproval{
+		ApproverID: approverID,
+		CreatedAt:  time.Now().UTC(),
+		Note:       note,
+		Status:     status,
+	})
+
+	// Set the new status of the draft
+	if status == DraftApprovalStatusRejected {
+		m.Status = DraftStatusRejected
+	} else if m.IsApproved() {
+		m.Status = DraftStatusDraft
+	}
+	return nil
+}
+
+// approve will approve the draft by the approver, the draft can be recorded once it has all the approvals
+func (m *DraftTransaction) approve(ctx context.Context, approverID, note string) error {
+	if err := m.addApprovalDecision(ctx, approverID, note, DraftApprovalStatusApproved); err != nil {
+		return err
+	}
+	if err := m.Save(ctx); err != nil {
+		return err
+	}
+	notify(notifications.EventTypeApproved, m)
+	return nil
+}
+
+// reject will reject the draft by the approver, the reserved utxos are released
+func (m *DraftTransaction) reject(ctx context.Context, approverID, note string) error {
+	if err := m.addApprovalDecision(ctx, approverID, note, DraftApprovalStatusRejected); err != nil {
+		return err
+	}
+	if err := m.Save(ctx); err != nil {
+		return err
+	}
+	notify(notifications.EventTypeRejected, m)
+	return nil
+}
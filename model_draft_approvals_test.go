@@ -0,0 +1,269 @@
+package bux
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/BuxOrg/bux/utils"
+	"github.com/libsv/go-bk/bip32"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	testApproverID1    = utils.Hash("test-approver-1")
+	testApproverID2    = utils.Hash("test-approver-2")
+	testApproverXPub   = "xpub661MyMwAqRbcFGX8a3K99DKPZahQBj1z8DsMTE7gqKtYj9yaWv45nkjHYcWdwUcQkGdZMv62HVKNCF4MNqXK2oiRKcfSE7U7iu5hAcyMzUS"
+	testApproverXPubID = utils.Hash(testApproverXPub)
+)
+
+// addTestApprovers will add the approvers as admin keys
+func addTestApprovers(ctx context.Context, t *testing.T, client ClientInterface, approverIDs ...string) {
+	for _, approverID := range approverIDs {
+		adminKey := newAdminKey(approverID, AdminRoleSupport, "", append(client.DefaultModelOptions(), New())...)
+		require.NoError(t, adminKey.Save(ctx))
+	}
+}
+
+// withTestApprover will set the approver as the xPub that authenticated the request
+func withTestApprover(ctx context.Context, approverID string) context.Context {
+	return context.WithValue(ctx, ParamXPubHashKey, approverID)
+}
+
+// TestDraftApprovals_Value will test the methods Value() and Scan()
+func TestDraftApprovals_Value(t *testing.T) {
+	t.Parallel()
+
+	approvals := DraftApprovals{{
+		ApproverID: testApproverID1,
+		CreatedAt:  time.Now().UTC().Truncate(time.Second),
+		Note:       "looks good",
+		Status:     DraftApprovalStatusApproved,
+	}}
+	value, err := approvals.Value()
+	require.NoError(t, err)
+
+	scanned := DraftApprovals{}
+	require.NoError(t, scanned.Scan(value))
+	require.Len(t, scanned, 1)
+	assert.Equal(t, *approvals[0], *scanned[0])
+}
+
+// TestClient_ApproveDraftTransaction will test the method ApproveDraftTransaction()
+func TestClient_ApproveDraftTransaction(t *testing.T) {
+
+	t.Run("draft below the threshold", func(t *testing.T) {
+		ctx, client, deferMe := initSimpleTestCase(t, WithApprovalPolicy(&ApprovalPolicy{
+			ThresholdSatoshis: 5000,
+		}))
+		defer deferMe()
+
+		draft := newTestSpendingDraft(ctx, t, client, testSpendingAddress, 1000)
+		assert.Equal(t, DraftStatusDraft, draft.Status)
+		assert.Equal(t, uint32(0), draft.RequiredApprovals)
+
+		_, err := client.ApproveDraftTransaction(withTestApprover(ctx, testApproverID1), draft.ID, testApproverID1, "")
+		require.ErrorIs(t, err, ErrDraftNotPendingApproval)
+	})
+
+	t.Run("draft not found", func(t *testing.T) {
+		ctx, client, deferMe := initSimpleTestCase(t)
+		defer deferMe()
+
+		_, err := client.ApproveDraftTransaction(withTestApprover(ctx, testApproverID1), testDraftID, testApproverID1, "")
+		require.ErrorIs(t, err, ErrDraftNotFound)
+	})
+
+	t.Run("multiple approvals and record", func(t *testing.T) {
+		ctx, client, deferMe := initSimpleTestCase(t, WithApprovalPolicy(&ApprovalPolicy{
+			RequiredApprovals: 2,
+			ThresholdSatoshis: 2000,
+		}))
+		defer deferMe()
+		addTestApprovers(ctx, t, client, testApproverID1, testApproverID2)
+
+		draft := newTestSpendingDraft(ctx, t, client, testSpendingAddress, 3000)
+		assert.Equal(t, DraftStatusPendingApproval, draft.Status)
+		assert.Equal(t, uint32(2), draft.RequiredApprovals)
+		assert.NotEmpty(t, draft.ApprovalReason)
+		assert.True(t, draft.ExpiresAt.After(time.Now().UTC().Add(time.Hour)))
+
+		// The owner can not approve
+		_, err := client.ApproveDraftTransaction(withTestApprover(ctx, testXPubID), draft.ID, testXPubID, "")
+		require.ErrorIs(t, err, ErrDraftApproverIsOwner)
+
+		// First approval
+		draft, err = client.ApproveDraftTransaction(withTestApprover(ctx, testApproverID1), draft.ID, testApproverID1, "approved by finance")
+		require.NoError(t, err)
+		assert.Equal(t, DraftStatusPendingApproval, draft.Status)
+		assert.False(t, draft.IsApproved())
+
+		// Same approver can not approve twice
+		_, err = client.ApproveDraftTransaction(withTestApprover(ctx, testApproverID1), draft.ID, testApproverID1, "")
+		require.ErrorIs(t, err, ErrDraftAlreadyDecided)
+
+		// Second approval
+		draft, err = client.ApproveDraftTransaction(withTestApprover(ctx, testApproverID2), draft.ID, testApproverID2, "")
+		require.NoError(t, err)
+		assert.Equal(t, DraftStatusDraft, draft.Status)
+		assert.True(t, draft.IsApproved())
+
+		// Approvals are persisted
+		draft, err = getDraftTransactionID(ctx, testXPubID, draft.ID, client.DefaultModelOptions()...)
+		require.NoError(t, err)
+		require.Len(t, draft.Approvals, 2)
+		assert.Equal(t, testApproverID1, draft.Approvals[0].ApproverID)
+		assert.Equal(t, "approved by finance", draft.Approvals[0].Note)
+		assert.Equal(t, DraftApprovalStatusApproved, draft.Approvals[0].Status)
+		assert.Equal(t, testApproverID2, draft.Approvals[1].ApproverID)
+
+		// The approved draft can be recorded
+		var xPriv *bip32.ExtendedKey
+		xPriv, err = bip32.NewKeyFromString(testXPriv)
+		require.NoError(t, err)
+		var hex string
+		hex, err = draft.SignInputs(xPriv)
+		require.NoError(t, err)
+
+		var transaction *Transaction
+		transaction, err = client.RecordTransaction(ctx, testXPub, hex, draft.ID, client.DefaultModelOptions()...)
+		require.NoError(t, err)
+		require.NotNil(t, transaction)
+	})
+
+	t.Run("approver not allowed", func(t *testing.T) {
+		ctx, client, deferMe := initSimpleTestCase(t, WithApprovalPolicy(&ApprovalPolicy{
+			Approvers:         []string{testApproverID1},
+			ThresholdSatoshis: 2000,
+		}))
+		defer deferMe()
+		addTestApprovers(ctx, t, client, testApproverID1, testApproverID2)
+
+		draft := newTestSpendingDraft(ctx, t, client, testSpendingAddress, 3000)
+		assert.Equal(t, DraftStatusPendingApproval, draft.Status)
+
+		_, err := client.ApproveDraftTransaction(withTestApprover(ctx, testApproverID2), draft.ID, testApproverID2, "")
+		require.ErrorIs(t, err, ErrDraftApproverNotAllowed)
+
+		draft, err = client.ApproveDraftTransaction(withTestApprover(ctx, testApproverID1), draft.ID, testApproverID1, "")
+		require.NoError(t, err)
+		assert.Equal(t, DraftStatusDraft, draft.Status)
+	})
+	t.Run("approver must be known", func(t *testing.T) {
+		ctx, client, deferMe := initSimpleTestCase(t, WithApprovalPolicy(&ApprovalPolicy{
+			Approvers:         []string{testApproverID1, testApproverXPubID},
+			ThresholdSatoshis: 2000,
+		}))
+		defer deferMe()
+
+		draft := newTestSpendingDraft(ctx, t, client, testSpendingAddress, 3000)
+		assert.Equal(t, DraftStatusPendingApproval, draft.Status)
+
+		// in the list of approvers, but not an xPub or admin key
+		_, err := client.ApproveDraftTransaction(withTestApprover(ctx, testApproverID1), draft.ID, testApproverID1, "")
+		require.ErrorIs(t, err, ErrDraftApproverUnknown)
+
+		// a known xPub in the list of approvers
+		xPub := newXpub(testApproverXPub, append(client.DefaultModelOptions(), New())...)
+		require.NoError(t, xPub.Save(ctx))
+
+		draft, err = client.ApproveDraftTransaction(withTestApprover(ctx, testApproverXPubID), draft.ID, testApproverXPubID, "")
+		require.NoError(t, err)
+		assert.Equal(t, DraftStatusDraft, draft.Status)
+	})
+
+	t.Run("only admin keys without approvers", func(t *testing.T) {
+		ctx, client, deferMe := initSimpleTestCase(t, WithApprovalPolicy(&ApprovalPolicy{
+			ThresholdSatoshis: 2000,
+		}))
+		defer deferMe()
+
+		draft := newTestSpendingDraft(ctx, t, client, testSpendingAddress, 3000)
+		assert.Equal(t, DraftStatusPendingApproval, draft.Status)
+
+		// a known xPub that is not an admin
+		xPub := newXpub(testApproverXPub, append(client.DefaultModelOptions(), New())...)
+		require.NoError(t, xPub.Save(ctx))
+
+		_, err := client.ApproveDraftTransaction(withTestApprover(ctx, testApproverXPubID), draft.ID, testApproverXPubID, "")
+		require.ErrorIs(t, err, ErrDraftApproverNotAllowed)
+
+		// read-only admins can not approve
+		adminKey := newAdminKey(testApproverID1, AdminRoleAuditor, "", append(client.DefaultModelOptions(), New())...)
+		require.NoError(t, adminKey.Save(ctx))
+
+		_, err = client.ApproveDraftTransaction(withTestApprover(ctx, testApproverID1), draft.ID, testApproverID1, "")
+		require.ErrorIs(t, err, ErrDraftApproverNotAllowed)
+
+		// the approver must be the authenticated xPub
+		addTestApprovers(ctx, t, client, testApproverID2)
+		_, err = client.ApproveDraftTransaction(ctx, draft.ID, testApproverID2, "")
+		require.ErrorIs(t, err, ErrDraftApproverNotAuthenticated)
+		_, err = client.ApproveDraftTransaction(withTestApprover(ctx, testApproverID1), draft.ID, testApproverID2, "")
+		require.ErrorIs(t, err, ErrDraftApproverNotAuthenticated)
+
+		draft, err = client.ApproveDraftTransaction(withTestApprover(ctx, testApproverID2), draft.ID, testApproverID2, "")
+		require.NoError(t, err)
+		assert.Equal(t, DraftStatusDraft, draft.Status)
+	})
+}
+
+// TestDraftTransaction_checkApprover will test the method checkApprover()
+func TestDraftTransaction_checkApprover(t *testing.T) {
+	ctx, client, deferMe := initSimpleTestCase(t)
+	defer deferMe()
+
+	draft := &DraftTransaction{Model: *NewBaseModel(ModelDraftTransaction, client.DefaultModelOptions()...)}
+
+	// a configured admin xPub (no admin key), using the role of the authenticated request
+	adminCtx := context.WithValue(withTestApprover(ctx, testApproverXPubID), ParamAdminRole, string(AdminRoleSupport))
+	require.NoError(t, draft.checkApprover(adminCtx, testApproverXPubID))
+
+	adminCtx = context.WithValue(withTestApprover(ctx, testApproverXPubID), ParamAdminRole, string(AdminRoleAuditor))
+	require.ErrorIs(t, draft.checkApprover(adminCtx, testApproverXPubID), ErrDraftApproverNotAllowed)
+}
+
+// TestClient_RejectDraftTransaction will test the method RejectDraftTransaction()
+func TestClient_RejectDraftTransaction(t *testing.T) {
+
+	t.Run("reject releases the utxos", func(t *testing.T) {
+		ctx, client, deferMe := initSimpleTestCase(t, WithApprovalPolicy(&ApprovalPolicy{
+			ThresholdSatoshis: 2000,
+		}))
+		defer deferMe()
+		addTestApprovers(ctx, t, client, testApproverID1, testApproverID2)
+
+		draft := newTestSpendingDraft(ctx, t, client, testSpendingAddress, 3000)
+		assert.Equal(t, DraftStatusPendingApproval, draft.Status)
+
+		utxos, err := getUtxosByDraftID(ctx, draft.ID, nil, client.DefaultModelOptions()...)
+		require.NoError(t, err)
+		require.NotEmpty(t, utxos)
+
+		draft, err = client.RejectDraftTransaction(withTestApprover(ctx, testApproverID1), draft.ID, testApproverID1, "unknown recipient")
+		require.NoError(t, err)
+		assert.Equal(t, DraftStatusRejected, draft.Status)
+		require.Len(t, draft.Approvals, 1)
+		assert.Equal(t, DraftApprovalStatusRejected, draft.Approvals[0].Status)
+
+		utxos, err = getUtxosByDraftID(ctx, draft.ID, nil, client.DefaultModelOptions()...)
+		require.NoError(t, err)
+		assert.Empty(t, utxos)
+
+		// Rejected drafts can not be approved anymore
+		_, err = client.ApproveDraftTransaction(withTestApprover(ctx, testApproverID2), draft.ID, testApproverID2, "")
+		require.ErrorIs(t, err, ErrDraftNotPendingApproval)
+
+		// Rejected drafts can not be recorded
+		var xPriv *bip32.ExtendedKey
+		xPriv, err = bip32.NewKeyFromString(testXPriv)
+		require.NoError(t, err)
+		var hex string
+		hex, err = draft.SignInputs(xPriv)
+		require.NoError(t, err)
+
+		_, err = client.RecordTransaction(ctx, testXPub, hex, draft.ID, client.DefaultModelOptions()...)
+		require.ErrorIs(t, err, ErrDraftRejected)
+	})
+}
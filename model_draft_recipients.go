@@ -0,0 +1,113 @@
+package bux
+
+import (
+	"context"
+	"errors"
+
+	"github.com/BuxOrg/bux/utils"
+	"github.com/mrz1836/go-datastore"
+)
+
+// DraftRecipient is an object representing a recipient (to, or script) an xPub has sent to with a completed draft,
+// used by the spending limits to count the new recipients of a draft
+//
+// Gorm related models & indexes: https://gorm.io/docs/models.html - https://gorm.io/docs/indexes.html
+type DraftRecipient struct {
+	// Base model
+	Model `bson:",inline"`
+
+	// Model specific fields
+	ID      string `json:"id" toml:"id" yaml:"id" gorm:"<-:create;type:char(64);primaryKey;comment:This is the hash of the xPub id and the recipient" bson:"_id"`
+	XpubID  string `json:"xpub_id" toml:"xpub_id" yaml:"xpub_id" gorm:"<-:create;type:char(64);index;comment:This is the related xPub" bson:"xpub_id"`
+	DraftID string `json:"draft_id" toml:"draft_id" yaml:"draft_id" gorm:"<-:create;type:char(64);comment:This is the first completed draft to the recipient" bson:"draft_id"`
+}
+
+// newDraftRecipient will start a new model
+func newDraftRecipient(xPubID, recipient, draftID string, opts ...ModelOps) *DraftRecipient {
+	return &DraftRecipient{
+		DraftID: draftID,
+		ID:      draftRecipientID(xPubID, recipient),
+		Model:   *NewBaseModel(ModelDraftRecipient, opts...),
+		XpubID:  xPubID,
+	}
+}
+
+// draftRecipientID will return the id of the recipient of the xPub
+func draftRecipientID(xPubID, recipient string) string {
+	return utils.Hash(xPubID + recipient)
+}
+
+// getDraftRecipient will get the model with a given ID
+func getDraftRecipient(ctx context.Context, id string, opts ...ModelOps) (*DraftRecipient, error) {
+
+	// Construct an empty model
+	recipient := &DraftRecipient{
+		ID: id,
+	}
+	recipient.enrich(ModelDraftRecipient, opts...)
+
+	// Get the record
+	if err := Get(ctx, recipient, nil, false, defaultDatabaseReadTimeout, false); err != nil {
+		if errors.Is(err, datastore.ErrNoResults) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return recipient, nil
+}
+
+// countKnownDraftRecipients will count the given recipients the xPub has already sent to
+func countKnownDraftRecipients(ctx context.Context, xPubID string, recipients []string,
+	opts ...ModelOps) (int64, error) {
+
+	ids := make([]map[string]interface{}, 0, len(recipients))
+	for _, recipient := range recipients {
+		ids = append(ids, map[string]interface{}{
+			idField: draftRecipientID(xPubID, recipient),
+		})
+	}
+	conditions := map[string]interface{}{
+		"$or": ids,
+	}
+	return getModelCountByConditions(ctx, ModelDraftRecipient, DraftRecipient{}, nil, &conditions, opts...)
+}
+
+// GetModelName will get the name of the current model
+func (m *DraftRecipient) GetModelName() string {
+	return ModelDraftRecipient.String()
+}
+
+// GetModelTableName will get the db table name of the current model
+func (m *DraftRecipient) GetModelTableName() string {
+	return tableDraftRecipients
+}
+
+// Save will save the model into the Datastore
+func (m *DraftRecipient) Save(ctx context.Context) error {
+	return Save(ctx, m)
+}
+
+// GetID will get the ID
+func (m *DraftRecipient) GetID() string {
+	return m.ID
+}
+
+// BeforeCreating will fire before the model is being inserted into the Datastore
+func (m *DraftRecipient) BeforeCreating(_ context.Context) error {
+	m.DebugLog("starting: [" + m.name.String() + "] BeforeCreating hook...")
+
+	// Make sure ID is valid
+	if len(m.ID) == 0 {
+		return ErrMissingFieldID
+	} else if len(m.XpubID) == 0 {
+		return ErrMissingFieldXpubID
+	}
+
+	m.DebugLog("end: " + m.Name() + " BeforeCreating hook")
+	return nil
+}
+
+// Migrate model specific migration on startup
+func (m *DraftRecipient) Migrate(client datastore.ClientInterface) error {
+	return client.IndexMetadata(client.GetTableName(tableDraftRecipients), metadataField)
+}
@@ -32,11 +32,19 @@ type DraftTransaction struct {
 	TransactionBase `bson:",inline"`
 
 	// Model specific fields
-	XpubID        string            `json:"xpub_id" toml:"xpub_id" yaml:"xpub_id" gorm:"<-:create;type:char(64);index;comment:This is the related xPub" bson:"xpub_id"`
-	ExpiresAt     time.Time         `json:"expires_at" toml:"expires_at" yaml:"expires_at" gorm:"<-:create;comment:Time when the draft expires" bson:"expires_at"`
-	Configuration TransactionConfig `json:"configuration" toml:"configuration" yaml:"configuration" gorm:"<-;type:text;comment:This is the configuration struct in JSON" bson:"configuration"`
-	Status        DraftStatus       `json:"status" toml:"status" yaml:"status" gorm:"<-;type:varchar(10);index;comment:This is the status of the draft" bson:"status"`
-	FinalTxID     string            `json:"final_tx_id,omitempty" toml:"final_tx_id" yaml:"final_tx_id" gorm:"<-;type:char(64);index;comment:This is the final tx ID" bson:"final_tx_id,omitempty"`
+	XpubID            string            `json:"xpub_id" toml:"xpub_id" yaml:"xpub_id" gorm:"<-:create;type:char(64);index;comment:This is the related xPub" bson:"xpub_id"`
+	ExpiresAt         time.Time         `json:"expires_at" toml:"expires_at" yaml:"expires_at" gorm:"<-:create;comment:Time when the draft expires" bson:"expires_at"`
+	Configuration     TransactionConfig `json:"configuration" toml:"configuration" yaml:"configuration" gorm:"<-;type:text;comment:This is the configuration struct in JSON" bson:"configuration"`
+	Status            DraftStatus       `json:"status" toml:"status" yaml:"status" gorm:"<-;type:varchar(20);index;comment:This is the status of the draft" bson:"status"`
+	FinalTxID         string            `json:"final_tx_id,omitempty" toml:"final_tx_id" yaml:"final_tx_id" gorm:"<-;type:char(64);index;comment:This is the final tx ID" bson:"final_tx_id,omitempty"`
+	AccessKeyID       string            `json:"access_key_id,omitempty" toml:"access_key_id" yaml:"access_key_id" gorm:"<-:create;type:varchar(64);index;comment:This is the access key that created the draft" bson:"access_key_id,omitempty"`
+	ApprovalReason    string            `json:"approval_reason,omitempty" toml:"approval_reason" yaml:"approval_reason" gorm:"<-;type:text;comment:This is the reason the draft requires approval" bson:"approval_reason,omitempty"`
+	RequiredApprovals uint32            `json:"required_approvals,omitempty" toml:"required_approvals" yaml:"required_approvals" gorm:"<-;comment:This is the number of approvals required" bson:"required_approvals,omitempty"`
+	Approvals         DraftApprovals    `json:"approvals,omitempty" toml:"approvals" yaml:"approvals" gorm:"<-;type:text;comment:This is the approvals (and rejections) of the draft in JSON" bson:"approvals,omitempty"`
+	SpentSatoshis     uint64            `json:"spent_satoshis,omitempty" toml:"spent_satoshis" yaml:"spent_satoshis" gorm:"<-;comment:This is the satoshis leaving the wallet (fee and outputs to others)" bson:"spent_satoshis,omitempty"`
+
+	// Private fields
+	outputsProcessed bool // Outputs have already been resolved (IE: batch payouts), skip processing
 }
 
 // newDraftTransaction will start a new draft tx
@@ -64,6 +72,9 @@ func newDraftTransaction(rawXpubKey string, config *TransactionConfig, opts ...M
 		),
 	}
 
+	// Set the access key that created the draft (if any)
+	draft.AccessKeyID = draft.Model.accessKeyID
+
 	// Set the fee (if not found) (if chainstate is loaded, use the first miner)
 	// todo: make this more intelligent or allow the config to dictate the miner selection
 	if config.FeeUnit == nil {
@@ -158,17 +169,17 @@ func (m *DraftTransaction) GetID() string {
 // doing any lookups and creating locking scripts
 func (m *DraftTransaction) processConfigOutputs(ctx context.Context) error {
 
+	// Outputs were already resolved before the draft was created
+	if m.outputsProcessed {
+		return nil
+	}
+
 	// Get the client
 	c := m.Client()
+
 	// Get sender's paymail
-	paymailFrom := c.GetPaymailConfig().DefaultFromPaymail
-	conditions := map[string]interface{}{
-		xPubIDField: m.XpubID,
-	}
-	paymails, err := c.GetPaymailAddressesByXPubID(ctx, m.XpubID, nil, &conditions, nil)
-	if err == nil && len(paymails) != 0 {
-		paymailFrom = fmt.Sprintf("%s@%s", paymails[0].Alias, paymails[0].Domain)
-	}
+	paymailFrom := getSenderPaymail(ctx, c, m.XpubID)
+
 	// Special case where we are sending all funds to a single (address, paymail, handle)
 	if m.Configuration.SendAllTo != nil {
 		outputs := m.Configuration.Outputs
@@ -226,6 +237,18 @@ func (m *DraftTransaction) processConfigOutputs(ctx context.Context) error {
 	return nil
 }
 
+// getSenderPaymail will return the first paymail of the xPub, or the default "from" paymail
+func getSenderPaymail(ctx context.Context, c ClientInterface, xPubID string) string {
+	conditions := map[string]interface{}{
+		xPubIDField: xPubID,
+	}
+	paymails, err := c.GetPaymailAddressesByXPubID(ctx, xPubID, nil, &conditions, nil)
+	if err == nil && len(paymails) != 0 {
+		return fmt.Sprintf("%s@%s", paymails[0].Alias, paymails[0].Domain)
+	}
+	return c.GetPaymailConfig().DefaultFromPaymail
+}
+
 // createTransactionHex will create the transaction with the given inputs and outputs
 func (m *DraftTransaction) createTransactionHex(ctx context.Context) (err error) {
 
@@ -751,6 +774,22 @@ func (m *DraftTransaction) BeforeCreating(ctx context.Context) (err error) {
 		return
 	}
 
+	// Set the access key that authenticated the request (if not set using WithAccessKeyID)
+	if len(m.AccessKeyID) == 0 {
+		m.AccessKeyID = getAccessKeyIDFromContext(ctx)
+	}
+
+	// Set the satoshis spent by the draft (used by the spending limits of the next drafts)
+	if m.SpentSatoshis, err = m.spentSatoshis(ctx); err != nil {
+		return
+	}
+
+	// Check the spending limits & approval threshold (requires approval if exceeded)
+	if _, err = m.applySpendingLimits(ctx); err != nil {
+		return
+	}
+	_, err = m.applyApprovalThreshold(ctx)
+
 	m.DebugLog("end: " + m.Name() + " BeforeCreating hook")
 	return
 }
@@ -762,7 +801,7 @@ func (m *DraftTransaction) AfterUpdated(ctx context.Context) error {
 	// todo: run these in go routines?
 
 	// remove reservation from all utxos related to this draft transaction
-	if m.Status == DraftStatusCanceled || m.Status == DraftStatusExpired {
+	if m.Status == DraftStatusCanceled || m.Status == DraftStatusExpired || m.Status == DraftStatusRejected {
 		utxos, err := getUtxosByDraftID(
 			ctx, m.ID,
 			nil,
@@ -860,18 +899,10 @@ func (m *DraftTransaction) SignInputs(xPriv *bip32.ExtendedKey) (signedHex strin
 		txDraft.Inputs[index].PreviousTxScript = ls
 		txDraft.Inputs[index].PreviousTxSatoshis = input.Satoshis
 
-		// Derive the child key (chain)
-		var chainKey *bip32.ExtendedKey
-		if chainKey, err = xPriv.Child(
-			input.Destination.Chain,
-		); err != nil {
-			return
-		}
-
-		// Derive the child key (num)
+		// Derive the child key (chain/num, or the non-standard derivation path of imported destinations)
 		var numKey *bip32.ExtendedKey
-		if numKey, err = chainKey.Child(
-			input.Destination.Num,
+		if numKey, err = input.Destination.deriveChildKey(
+			xPriv,
 		); err != nil {
 			return
 		}
@@ -1505,8 +1505,10 @@ func TestDraftTransaction_SignInputs(t *testing.T) {
 	}
 }
 
-func initSimpleTestCase(t *testing.T) (context.Context, ClientInterface, func()) {
-	ctx, client, deferMe := CreateTestSQLiteClient(t, false, true, WithCustomTaskManager(&taskManagerMockBase{}))
+func initSimpleTestCase(t *testing.T, opts ...ClientOps) (context.Context, ClientInterface, func()) {
+	ctx, client, deferMe := CreateTestSQLiteClient(
+		t, false, true, append([]ClientOps{WithCustomTaskManager(&taskManagerMockBase{})}, opts...)...,
+	)
 
 	xPub := newXpub(testXPub, append(client.DefaultModelOptions(), New())...)
 	xPub.CurrentBalance = 100000
@@ -30,7 +30,12 @@ func Get(
 	*/
 
 	// Attempt to Get the model (by model fields & given conditions)
-	return model.Client().Datastore().GetModel(ctx, model, conditions, timeout, forceWriteDB)
+	if err := model.Client().Datastore().GetModel(ctx, model, conditions, timeout, forceWriteDB); err != nil {
+		return err
+	}
+
+	// Keep the stored state for the audit log (no extra read when the model is saved)
+	return setAuditSnapshot(model)
 }
 
 // getModels will retrieve model(s) from the Cachestore or Datastore using the provided conditions
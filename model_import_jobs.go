@@ -0,0 +1,842 @@
+package bux
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/BuxOrg/bux/chainstate"
+	"github.com/BuxOrg/bux/notifications"
+	"github.com/BuxOrg/bux/taskmanager"
+	"github.com/BuxOrg/bux/utils"
+	"github.com/mrz1836/go-datastore"
+	zLogger "github.com/mrz1836/go-logger"
+	"github.com/mrz1836/go-whatsonchain"
+)
+
+// ImportJob is an object representing the (resumable) import of an xPub and all related destinations & transactions
+//
+// The import is processed in small steps (by the task manager or ImportXpub), the state is saved after every step:
+// first all paths of the import profile (both chains by default) are scanned until a full gap of addresses without
+// any transactions is found, then the found transactions are sorted and recorded in order. Failed steps are
+// retried (with a backoff) before the import job stops on the error, see ResumeImportJob().
+//
+// Gorm related models & indexes: https://gorm.io/docs/models.html - https://gorm.io/docs/indexes.html
+type ImportJob struct {
+	// Base model
+	Model `bson:",inline"`
+
+	// Model specific fields
+	ID            string           `json:"id" toml:"id" yaml:"id" gorm:"<-:create;type:char(64);primaryKey;comment:This is the unique import job id" bson:"_id"`
+	XpubID        string           `json:"xpub_id" toml:"xpub_id" yaml:"xpub_id" gorm:"<-:create;type:char(64);index;comment:This is the related xPub" bson:"xpub_id"`
+	XpubKey       string           `json:"-" toml:"-" yaml:"-" gorm:"<-;type:varchar(512);comment:This is the xPub to import (encrypted if encryption is enabled)" bson:"xpub_key"`
+	Configuration ImportJobConfig  `json:"configuration" toml:"configuration" yaml:"configuration" gorm:"<-:create;type:text;comment:This is the configuration struct in JSON" bson:"configuration"`
+	Cursors       ImportJobCursors `json:"cursors" toml:"cursors" yaml:"cursors" gorm:"<-;type:text;comment:This is the scan cursor per chain (or path) in JSON" bson:"cursors"`
+	PendingTxIDs  IDs              `json:"pending_tx_ids" toml:"pending_tx_ids" yaml:"pending_tx_ids" gorm:"<-;type:text;comment:This is the list of found transactions that are not recorded yet" bson:"pending_tx_ids"`
+	PendingBlocks ImportJobBlocks  `json:"pending_blocks" toml:"pending_blocks" yaml:"pending_blocks" gorm:"<-;type:text;comment:This is the block height of the pending transactions in JSON" bson:"pending_blocks"`
+	Results       ImportResults    `json:"results" toml:"results" yaml:"results" gorm:"<-;type:text;comment:This is the import results (progress) in JSON" bson:"results"`
+	Status        ImportJobStatus  `json:"status" toml:"status" yaml:"status" gorm:"<-;type:varchar(10);index;comment:This is the status of the import job" bson:"status"`
+	Error         string           `json:"error" toml:"error" yaml:"error" gorm:"<-;type:text;comment:This is the error of the last failed step" bson:"error,omitempty"`
+	Retries       int              `json:"retries" toml:"retries" yaml:"retries" gorm:"<-;comment:This is the number of retries of the failed step" bson:"retries"`
+	RetryAt       time.Time        `json:"retry_at" toml:"retry_at" yaml:"retry_at" gorm:"<-;comment:This is the time of the next retry of the failed step" bson:"retry_at"`
+}
+
+// ImportJobConfig is the configuration used to start an import job
+type ImportJobConfig struct {
+	GapLimit int      `json:"gap_limit" toml:"gap_limit" yaml:"gap_limit" bson:"gap_limit"` // Number of addresses without transactions before a chain is considered done
+	Paths    []string `json:"paths" toml:"paths" yaml:"paths" bson:"paths"`                 // Derivation paths to scan (relative to the xPub), overrides the paths of the profile
+	Profile  string   `json:"profile" toml:"profile" yaml:"profile" bson:"profile"`         // Name of the import profile (derivation layout), defaults to bux
+}
+
+// ImportJobCursor is the scan position of a single chain (internal or external)
+type ImportJobCursor struct {
+	Done bool   `json:"done"` // A full gap of addresses without any transactions was found
+	Next uint32 `json:"next"` // The next address num to derive & scan
+}
+
+// ImportJobCursors are the scan positions of both chains of the xPub (and the other paths of the import profile)
+type ImportJobCursors struct {
+	External ImportJobCursor             `json:"external"`        // External (receiving) chain
+	Internal ImportJobCursor             `json:"internal"`        // Internal (change) chain
+	Paths    map[string]*ImportJobCursor `json:"paths,omitempty"` // Other derivation paths of the import profile
+	Sorted   bool                        `json:"sorted"`          // Pending transactions are sorted in the order to be recorded
+}
+
+// ImportJobBlocks are the block heights of the pending transactions (from the address history, zero if not known)
+type ImportJobBlocks map[string]int64
+
+// ImportJobStatus import job status
+type ImportJobStatus string
+
+const (
+	// ImportJobStatusPending is when the import job has not been started yet
+	ImportJobStatusPending ImportJobStatus = statusPending
+
+	// ImportJobStatusProcessing is when the import job is scanning addresses or recording transactions
+	ImportJobStatusProcessing ImportJobStatus = statusProcessing
+
+	// ImportJobStatusComplete is when all transactions of the xPub have been imported
+	ImportJobStatusComplete ImportJobStatus = statusComplete
+
+	// ImportJobStatusCanceled is when the import job was canceled
+	ImportJobStatusCanceled ImportJobStatus = statusCanceled
+
+	// ImportJobStatusError is when the import job stopped on an error (after the retries)
+	ImportJobStatusError ImportJobStatus = statusError
+)
+
+// newImportJob will start a new import job model
+func newImportJob(rawXpubKey string, config *ImportJobConfig, opts ...ModelOps) *ImportJob {
+
+	// Random GUID
+	id, _ := utils.RandomHex(32)
+
+	job := &ImportJob{
+		Configuration: *config,
+		ID:            id,
+		PendingBlocks: ImportJobBlocks{},
+		PendingTxIDs:  IDs{},
+		Status:        ImportJobStatusPending,
+		XpubID:        utils.Hash(rawXpubKey),
+		Model: *NewBaseModel(
+			ModelImportJob,
+			append(opts, WithXPub(rawXpubKey))...,
+		),
+	}
+
+	// Set the defaults
+	if job.Configuration.GapLimit <= 0 {
+		job.Configuration.GapLimit = defaultImportGapLimit
+	}
+	if len(job.Configuration.Paths) == 0 {
+		if len(job.Configuration.Profile) == 0 {
+			job.Configuration.Profile = ImportProfileBux
+		}
+		if profile, err := GetImportProfile(job.Configuration.Profile); err == nil {
+			job.Configuration.Paths = profile.Paths
+		}
+	}
+
+	return job
+}
+
+// validate will validate the import profile & derivation paths of the configuration
+func (c *ImportJobConfig) validate() error {
+	if len(c.Paths) == 0 && len(c.Profile) > 0 {
+		if _, err := GetImportProfile(c.Profile); err != nil {
+			return err
+		}
+	}
+	for _, path := range c.Paths {
+		if _, err := parseImportPath(path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// getImportJob will get the import job with the given conditions
+func getImportJob(ctx context.Context, xPubID, id string, opts ...ModelOps) (*ImportJob, error) {
+
+	// Construct an empty model
+	job := &ImportJob{}
+	job.enrich(ModelImportJob, opts...)
+
+	conditions := map[string]interface{}{
+		idField: id,
+	}
+	if len(xPubID) > 0 {
+		conditions[xPubIDField] = xPubID
+	}
+
+	// Get the record
+	if err := Get(ctx, job, conditions, false, defaultDatabaseReadTimeout, false); err != nil {
+		if errors.Is(err, datastore.ErrNoResults) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return job, nil
+}
+
+// getImportJobs will get all the import jobs with the given conditions
+func getImportJobs(ctx context.Context, metadata *Metadata, conditions *map[string]interface{},
+	queryParams *datastore.QueryParams, opts ...ModelOps) ([]*ImportJob, error) {
+
+	modelItems := make([]*ImportJob, 0)
+	if err := getModelsByConditions(ctx, ModelImportJob, &modelItems, metadata, conditions, queryParams, opts...); err != nil {
+		return nil, err
+	}
+
+	return modelItems, nil
+}
+
+// getImportJobsToProcess will get the import jobs that are pending or processing (IE: after a restart)
+func getImportJobsToProcess(ctx context.Context, queryParams *datastore.QueryParams,
+	opts ...ModelOps) ([]*ImportJob, error) {
+
+	conditions := map[string]interface{}{
+		"$or": []map[string]interface{}{{
+			statusField: ImportJobStatusPending,
+		}, {
+			statusField: ImportJobStatusProcessing,
+		}},
+	}
+
+	return getImportJobs(ctx, nil, &conditions, queryParams, opts...)
+}
+
+// GetModelName will get the name of the current model
+func (m *ImportJob) GetModelName() string {
+	return ModelImportJob.String()
+}
+
+// GetModelTableName will get the db table name of the current model
+func (m *ImportJob) GetModelTableName() string {
+	return tableImportJobs
+}
+
+// Save will save the model into the Datastore
+func (m *ImportJob) Save(ctx context.Context) error {
+	return Save(ctx, m)
+}
+
+// GetID will get the model ID
+func (m *ImportJob) GetID() string {
+	return m.ID
+}
+
+// BeforeCreating will fire before the model is being inserted into the Datastore
+func (m *ImportJob) BeforeCreating(_ context.Context) error {
+	m.DebugLog("starting: [" + m.name.String() + "] BeforeCreating hook...")
+
+	// Make sure ID is valid
+	if len(m.ID) == 0 {
+		return ErrMissingFieldID
+	}
+	if len(m.rawXpubKey) == 0 {
+		return ErrMissingImportJobXpub
+	}
+
+	// Store the xPub (encrypted if encryption is enabled)
+	var err error
+	if provider := m.getEncryption(); provider != nil {
+		if m.XpubKey, err = provider.Encrypt(m.rawXpubKey); err != nil {
+			return err
+		}
+	} else {
+		m.XpubKey = m.rawXpubKey
+	}
+
+	m.DebugLog("end: " + m.Name() + " BeforeCreating hook")
+	return nil
+}
+
+// BeforeUpdating will fire before the model is being updated in the Datastore
+func (m *ImportJob) BeforeUpdating(_ context.Context) error {
+	m.DebugLog("starting: [" + m.name.String() + "] BeforeUpdating hook...")
+
+	// The xPub is not kept after the import job is done (an import job with an error can be resumed)
+	if m.Status == ImportJobStatusComplete || m.Status == ImportJobStatusCanceled {
+		m.XpubKey = ""
+		m.rawXpubKey = ""
+	}
+
+	m.DebugLog("end: " + m.Name() + " BeforeUpdating hook")
+	return nil
+}
+
+// AfterCreated will fire after the model is created in the Datastore
+func (m *ImportJob) AfterCreated(_ context.Context) error {
+	m.DebugLog("starting: " + m.Name() + " AfterCreated hook...")
+
+	notify(notifications.EventTypeCreate, m)
+
+	m.DebugLog("end: " + m.Name() + " AfterCreated hook")
+	return nil
+}
+
+// AfterUpdated will fire after the model is updated in the Datastore
+//
+// Every processed step of the import job is notified as progress
+func (m *ImportJob) AfterUpdated(_ context.Context) error {
+	m.DebugLog("starting: " + m.Name() + " AfterUpdated hook...")
+
+	if m.Status == ImportJobStatusProcessing {
+		notify(notifications.EventTypeProgress, m)
+	} else {
+		notify(notifications.EventTypeUpdate, m)
+	}
+
+	m.DebugLog("end: " + m.Name() + " AfterUpdated hook")
+	return nil
+}
+
+// RegisterTasks will register the model specific tasks on client initialization
+func (m *ImportJob) RegisterTasks() error {
+
+	// No task manager loaded?
+	tm := m.Client().Taskmanager()
+	if tm == nil {
+		return nil
+	}
+
+	// Register the task locally (cron task - set the defaults)
+	processTask := m.Name() + "_process"
+	ctx := context.Background()
+
+	// Register the task
+	if err := tm.RegisterTask(&taskmanager.Task{
+		Name:       processTask,
+		RetryLimit: 1,
+		Handler: func(client ClientInterface) error {
+			if taskErr := taskProcessImportJobs(ctx, client.Logger(), WithClient(client)); taskErr != nil {
+				client.Logger().Error(ctx, "error running "+processTask+" task: "+taskErr.Error())
+			}
+			return nil
+		},
+	}); err != nil {
+		return err
+	}
+
+	// Run the task periodically
+	return tm.RunTask(ctx, &taskmanager.TaskOptions{
+		Arguments:      []interface{}{m.Client()},
+		RunEveryPeriod: m.Client().GetTaskPeriod(processTask),
+		TaskName:       processTask,
+	})
+}
+
+// Migrate model specific migration on startup
+func (m *ImportJob) Migrate(client datastore.ClientInterface) error {
+	tableName := client.GetTableName(tableImportJobs)
+	if err := m.migrateXpubKeys(client, tableName); err != nil {
+		return err
+	}
+	return client.IndexMetadata(tableName, metadataField)
+}
+
+// migrateXpubKeys will remove the xPub of the import jobs that are done (stored before they were removed)
+func (m *ImportJob) migrateXpubKeys(client datastore.ClientInterface, tableName string) error {
+	if client.Engine() == datastore.MongoDB {
+		return nil
+	}
+	quote := `"`
+	if client.Engine() == datastore.MySQL {
+		quote = "`"
+	}
+	tx := client.Execute(`UPDATE ` + quote + tableName + quote + ` SET xpub_key = '' WHERE status IN ('` +
+		statusComplete + `', '` + statusCanceled + `') AND xpub_key <> ''`)
+	return tx.Error
+}
+
+// encryptSensitiveFields will encrypt the xPub (and the sensitive metadata), and re-encrypt
+// the values that are not encrypted using the active key (rotation)
+//
+// Returns true if any of the values changed
+func (m *ImportJob) encryptSensitiveFields() (bool, error) {
+	changed, err := m.Model.encryptSensitiveFields()
+	if err != nil {
+		return false, err
+	}
+
+	// Nothing to encrypt, or already encrypted using the active key
+	provider := m.getEncryption()
+	if provider == nil || len(m.XpubKey) == 0 || (len(m.XpubKey) != utils.XpubKeyLength &&
+		provider.IsCurrent(m.XpubKey)) {
+		return changed, nil
+	}
+
+	// Decrypt (rotation) and encrypt using the active key
+	if _, err = m.getXpubKey(); err != nil {
+		return false, err
+	}
+	if m.XpubKey, err = provider.Encrypt(m.rawXpubKey); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// getEncryptedFields will get the (stored) values of the columns that are encrypted at rest
+func (m *ImportJob) getEncryptedFields() map[string]interface{} {
+	fields := m.Model.getEncryptedFields()
+	fields[xPubKeyField] = m.XpubKey
+	return fields
+}
+
+// getXpubKey will get the (decrypted) raw xPub key of the import job
+func (m *ImportJob) getXpubKey() (string, error) {
+	if len(m.rawXpubKey) > 0 {
+		return m.rawXpubKey, nil
+	}
+
+	// Check if the xPub was encrypted
+	if len(m.XpubKey) != utils.XpubKeyLength {
+		provider := m.getEncryption()
+		if provider == nil {
+			return "", ErrMissingEncryptionProvider
+		}
+		var err error
+		if m.rawXpubKey, err = provider.Decrypt(m.XpubKey); err != nil {
+			return "", err
+		}
+	} else {
+		m.rawXpubKey = m.XpubKey
+	}
+
+	return m.rawXpubKey, nil
+}
+
+// isFinished will return true if the import job will not be processed anymore
+func (m *ImportJob) isFinished() bool {
+	return m.Status == ImportJobStatusComplete ||
+		m.Status == ImportJobStatusCanceled ||
+		m.Status == ImportJobStatusError
+}
+
+// processStep will process the next step of the import job (scan a path, sort or record transactions)
+func (m *ImportJob) processStep(ctx context.Context, opts ...ModelOps) error {
+	path, cursor, derived, err := m.nextScanPath()
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case path != nil:
+		err = m.scanPath(ctx, path, cursor, derived)
+	case !m.Cursors.Sorted:
+		err = m.sortPendingTransactions(ctx)
+	default:
+		err = m.recordPendingTransactions(ctx, opts...)
+	}
+	if err != nil {
+		return err
+	}
+
+	// Everything was scanned (before sorting) and recorded
+	if m.Cursors.Sorted && len(m.PendingTxIDs) == 0 {
+		m.Status = ImportJobStatusComplete
+	}
+	return nil
+}
+
+// getPaths will return the derivation paths to scan (jobs without paths use the bux layout)
+func (m *ImportJob) getPaths() []string {
+	if len(m.Configuration.Paths) > 0 {
+		return m.Configuration.Paths
+	}
+	return importProfiles[ImportProfileBux].Paths
+}
+
+// getCursor will return the scan cursor of the path and the related address counter of the results
+func (m *ImportJob) getCursor(path string) (*ImportJobCursor, *int) {
+	switch path {
+	case importProfiles[ImportProfileBux].Paths[utils.ChainExternal]:
+		return &m.Cursors.External, &m.Results.ExternalAddresses
+	case importProfiles[ImportProfileBux].Paths[utils.ChainInternal]:
+		return &m.Cursors.Internal, &m.Results.InternalAddresses
+	}
+	if m.Cursors.Paths == nil {
+		m.Cursors.Paths = make(map[string]*ImportJobCursor)
+	}
+	cursor, ok := m.Cursors.Paths[path]
+	if !ok {
+		cursor = &ImportJobCursor{}
+		m.Cursors.Paths[path] = cursor
+	}
+	return cursor, &m.Results.OtherAddresses
+}
+
+// nextScanPath will return the next path (in order) that is not done scanning, nil if all paths are done
+func (m *ImportJob) nextScanPath() (*importPath, *ImportJobCursor, *int, error) {
+	for _, path := range m.getPaths() {
+		p, err := parseImportPath(path)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		if cursor, derived := m.getCursor(p.path); !cursor.Done {
+			return p, cursor, derived, nil
+		}
+	}
+	return nil, nil, nil, nil
+}
+
+// scanPath will derive the next gap of addresses of the path and add any new transactions to the pending list
+//
+// The path is done if none of the addresses have any transactions (or after the single address of a fixed path)
+func (m *ImportJob) scanPath(ctx context.Context, path *importPath, cursor *ImportJobCursor, derived *int) error {
+
+	rawXpubKey, err := m.getXpubKey()
+	if err != nil {
+		return err
+	}
+
+	// Derive the addresses from the cursor (derivation by num is deterministic, so it can be resumed)
+	var addresses []string
+	if addresses, err = deriveImportPathAddresses(
+		ctx, rawXpubKey, path, cursor.Next, m.Configuration.GapLimit, m.Client().DefaultModelOptions()...,
+	); err != nil {
+		return err
+	}
+
+	// Get all transactions for those addresses (using the address history provider)
+	var history []*chainstate.AddressHistoryRecord
+	if history, err = getAddressHistory(ctx, m.Client(), addresses); err != nil {
+		return err
+	}
+
+	// Move the cursor
+	cursor.Next += uint32(len(addresses))
+	*derived += len(addresses)
+	if len(history) == 0 || !path.wildcard {
+		cursor.Done = true
+	}
+
+	// Add any new transactions (and the block height, used for sorting)
+	if m.PendingBlocks == nil {
+		m.PendingBlocks = make(ImportJobBlocks)
+	}
+	pending := make(map[string]bool, len(m.PendingTxIDs))
+	for _, txID := range m.PendingTxIDs {
+		pending[txID] = true
+	}
+	for _, record := range history {
+		if !pending[record.TxID] {
+			pending[record.TxID] = true
+			m.PendingTxIDs = append(m.PendingTxIDs, record.TxID)
+			m.Results.TransactionsFound++
+		}
+		if record.BlockHeight > 0 {
+			m.PendingBlocks[record.TxID] = record.BlockHeight
+		}
+	}
+
+	return nil
+}
+
+// sortPendingTransactions will sort the pending transactions by block height (unconfirmed transactions last)
+//
+// The block heights are taken from the address history, only the transactions without a (known) block height
+// are downloaded. Transactions in the same block are sorted by the previous transactions when recorded.
+func (m *ImportJob) sortPendingTransactions(ctx context.Context) error {
+	if m.PendingBlocks == nil {
+		m.PendingBlocks = make(ImportJobBlocks)
+	}
+
+	// Get the block height of the transactions that are not known
+	var unknown []string
+	for _, txID := range m.PendingTxIDs {
+		if m.PendingBlocks[txID] <= 0 {
+			unknown = append(unknown, txID)
+		}
+	}
+	if len(unknown) > 0 {
+		txInfos, err := getRawTransactions(ctx, m.Client(), unknown)
+		if err != nil {
+			return err
+		}
+		for _, info := range txInfos {
+			if info.BlockHeight > 0 {
+				m.PendingBlocks[info.TxID] = info.BlockHeight
+			}
+		}
+	}
+
+	sort.SliceStable(m.PendingTxIDs, func(i, j int) bool {
+		heightI, heightJ := m.PendingBlocks[m.PendingTxIDs[i]], m.PendingBlocks[m.PendingTxIDs[j]]
+		if heightI <= 0 || heightJ <= 0 {
+			return heightI > 0 && heightJ <= 0
+		}
+		return heightI < heightJ
+	})
+
+	m.Cursors.Sorted = true
+	return nil
+}
+
+// nextRecordBatch will get the next batch of pending transactions to record
+//
+// The batch is extended with the transactions in the same block as the last transaction of the batch, so the
+// transactions of a block can be sorted by the previous transactions
+func (m *ImportJob) nextRecordBatch() []string {
+	size := defaultImportRecordBatchSize
+	if len(m.PendingTxIDs) <= size {
+		return m.PendingTxIDs
+	}
+	height := m.PendingBlocks[m.PendingTxIDs[size-1]]
+	for size < len(m.PendingTxIDs) && m.PendingBlocks[m.PendingTxIDs[size]] == height {
+		size++
+	}
+	return m.PendingTxIDs[:size]
+}
+
+// recordPendingTransactions will record the next batch of (sorted) pending transactions
+//
+// Transactions that already exist are skipped (IE: recorded before a restart or a failed step)
+func (m *ImportJob) recordPendingTransactions(ctx context.Context, opts ...ModelOps) error {
+
+	rawXpubKey, err := m.getXpubKey()
+	if err != nil {
+		return err
+	}
+
+	// Get the next batch of transactions (sorted by block height & previous transactions)
+	batch := m.nextRecordBatch()
+	var txInfos whatsonchain.TxList
+	if txInfos, err = getRawTransactions(ctx, m.Client(), batch); err != nil {
+		return err
+	}
+	sortTransactionInfos(txInfos)
+	if len(txInfos) < len(batch) {
+		found := make(map[string]bool, len(txInfos))
+		for _, info := range txInfos {
+			found[info.TxID] = true
+		}
+		for _, txID := range batch {
+			if !found[txID] {
+				return fmt.Errorf("%w: %s", ErrImportTransactionNotFound, txID)
+			}
+		}
+	}
+
+	// Record transactions in bux (in order)
+	for _, info := range txInfos {
+		var transaction *Transaction
+		if transaction, err = getTransactionByID(
+			ctx, "", info.TxID, m.Client().DefaultModelOptions()...,
+		); err != nil {
+			return err
+		} else if transaction == nil {
+			if _, err = m.Client().RecordTransaction(
+				ctx, rawXpubKey, info.Hex, "", opts...,
+			); err != nil {
+				return err
+			}
+		}
+	}
+
+	// The batch was recorded
+	for _, txID := range batch {
+		delete(m.PendingBlocks, txID)
+	}
+	m.PendingTxIDs = m.PendingTxIDs[len(batch):]
+	m.Results.TransactionsImported += len(batch)
+	return nil
+}
+
+// isRetryableImportError will return true if a failed step of the import job can be retried (IE: a provider error)
+func isRetryableImportError(err error) bool {
+	return !errors.Is(err, ErrMissingEncryptionProvider) &&
+		!errors.Is(err, ErrMissingAddressHistoryProvider) &&
+		!errors.Is(err, ErrMissingImportJobXpub) &&
+		!errors.Is(err, ErrInvalidImportPath) &&
+		!errors.Is(err, ErrUnknownImportProfile)
+}
+
+// processImportJobs will process the import jobs that are pending or processing
+func processImportJobs(ctx context.Context, logClient zLogger.GormLoggerInterface, maxJobs int,
+	opts ...ModelOps) error {
+
+	queryParams := &datastore.QueryParams{
+		Page:          1,
+		PageSize:      maxJobs,
+		OrderByField:  createdAtField,
+		SortDirection: datastore.SortAsc,
+	}
+
+	// Get x records:
+	records, err := getImportJobsToProcess(
+		ctx, queryParams, opts...,
+	)
+	if err != nil {
+		return err
+	} else if len(records) == 0 {
+		return nil
+	}
+
+	if logClient != nil {
+		logClient.Info(ctx, fmt.Sprintf("found %d import jobs to process", len(records)))
+	}
+
+	// Process the import jobs (a limited number of steps per job, the job is continued on the next run)
+	client := NewBaseModel(ModelNameEmpty, opts...).Client()
+	for index := range records {
+		if _, err = processImportJob(
+			ctx, client, records[index].ID, defaultImportTaskSteps,
+		); err != nil && logClient != nil {
+			logClient.Error(ctx, fmt.Sprintf("error processing import job %s: %s", records[index].ID, err.Error()))
+		}
+	}
+
+	return nil
+}
+
+// processImportJob will process the import job for a maximum number of steps (0 = until finished)
+//
+// A failed step is retried after the backoff: until finished waits for the retry, otherwise the job is
+// continued on the next run
+func processImportJob(ctx context.Context, client ClientInterface, id string, maxSteps int,
+	opts ...ModelOps) (job *ImportJob, err error) {
+
+	for step := 0; maxSteps <= 0 || step < maxSteps; step++ {
+		if job, err = processImportJobStep(ctx, client, id, opts...); err != nil || job.isFinished() {
+			return
+		}
+
+		// Wait for the retry of a failed step
+		if wait := time.Until(job.RetryAt); wait > 0 {
+			if maxSteps > 0 {
+				return
+			}
+			select {
+			case <-ctx.Done():
+				return job, ctx.Err()
+			case <-time.After(wait):
+			}
+		}
+	}
+	return
+}
+
+// processImportJobStep will process the next step of the import job (and save the state)
+//
+// The job is (re)loaded inside the lock, so cancellations & other workers are respected
+func processImportJobStep(ctx context.Context, client ClientInterface, id string,
+	opts ...ModelOps) (*ImportJob, error) {
+
+	// Create the lock and set the release for after the function completes (waits for a running step)
+	unlock, err := newWaitWriteLock(
+		ctx, fmt.Sprintf(lockKeyProcessImportJob, id), client.Cachestore(),
+	)
+	defer unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	// Get the import job
+	var job *ImportJob
+	if job, err = getImportJob(ctx, "", id, client.DefaultModelOptions()...); err != nil {
+		return nil, err
+	} else if job == nil {
+		return nil, ErrImportJobNotFound
+	} else if job.isFinished() || time.Now().Before(job.RetryAt) {
+		return job, nil
+	}
+
+	// Successfully capture any panics, convert to readable string and save the error
+	defer func() {
+		if panicErr := recover(); panicErr != nil {
+			client.Logger().Error(ctx,
+				fmt.Sprintf(
+					"panic: %v - stack trace: %v", panicErr,
+					strings.ReplaceAll(string(debug.Stack()), "\n", ""),
+				),
+			)
+			job.Status = ImportJobStatusError
+			job.Error = fmt.Sprintf("panic: %v", panicErr)
+			_ = job.Save(ctx)
+		}
+	}()
+
+	// Process the step
+	job.Status = ImportJobStatusProcessing
+	if err = job.processStep(ctx, opts...); err != nil {
+		job.Error = err.Error()
+
+		// Retry the step (backoff), the state of the job is not changed by a failed step
+		if job.Retries < defaultImportMaxRetries && isRetryableImportError(err) {
+			job.RetryAt = time.Now().UTC().Add(defaultImportRetryDelay << job.Retries)
+			job.Retries++
+			client.Logger().Warn(ctx, fmt.Sprintf(
+				"import job %s step failed (retry %d at %s): %s", job.ID, job.Retries, job.RetryAt, err.Error(),
+			))
+			if err = job.Save(ctx); err != nil {
+				return nil, err
+			}
+			return job, nil
+		}
+
+		job.Status = ImportJobStatusError
+		if saveErr := job.Save(ctx); saveErr != nil {
+			client.Logger().Error(ctx, "error saving import job: "+saveErr.Error())
+		}
+		return job, err
+	}
+	job.Error = ""
+	job.Retries = 0
+	job.RetryAt = time.Time{}
+
+	// Save the progress
+	if err = job.Save(ctx); err != nil {
+		return nil, err
+	}
+
+	return job, nil
+}
+
+// Scan will scan the value into Struct, implements sql.Scanner interface
+func (t *ImportJobConfig) Scan(value interface{}) error {
+	return scanJSONValue(value, t)
+}
+
+// Value return json value, implement driver.Valuer interface
+func (t ImportJobConfig) Value() (driver.Value, error) {
+	return jsonValue(t)
+}
+
+// Scan will scan the value into Struct, implements sql.Scanner interface
+func (t *ImportJobBlocks) Scan(value interface{}) error {
+	return scanJSONValue(value, t)
+}
+
+// Value return json value, implement driver.Valuer interface
+func (t ImportJobBlocks) Value() (driver.Value, error) {
+	return jsonValue(t)
+}
+
+// Scan will scan the value into Struct, implements sql.Scanner interface
+func (t *ImportJobCursors) Scan(value interface{}) error {
+	return scanJSONValue(value, t)
+}
+
+// Value return json value, implement driver.Valuer interface
+func (t ImportJobCursors) Value() (driver.Value, error) {
+	return jsonValue(t)
+}
+
+// Scan will scan the value into Struct, implements sql.Scanner interface
+func (t *ImportJobStatus) Scan(value interface{}) error {
+	xType := fmt.Sprintf("%T", value)
+	var stringValue string
+	if xType == ValueTypeString {
+		stringValue = value.(string)
+	} else {
+		stringValue = string(value.([]byte))
+	}
+
+	switch stringValue {
+	case statusPending:
+		*t = ImportJobStatusPending
+	case statusProcessing:
+		*t = ImportJobStatusProcessing
+	case statusComplete:
+		*t = ImportJobStatusComplete
+	case statusCanceled:
+		*t = ImportJobStatusCanceled
+	case statusError:
+		*t = ImportJobStatusError
+	}
+
+	return nil
+}
+
+// Value return json value, implement driver.Valuer interface
+func (t ImportJobStatus) Value() (driver.Value, error) {
+	return string(t), nil
+}
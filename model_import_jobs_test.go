@@ -0,0 +1,401 @@
+package bux
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/BuxOrg/bux/chainstate"
+	"github.com/BuxOrg/bux/utils"
+	"github.com/mrz1836/go-whatsonchain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newImportJobTestHistory will create the address history (fixture) of the import job tests
+//
+// Only the first external address has a transaction in the address history
+func newImportJobTestHistory(t *testing.T) chainstate.AddressHistoryProvider {
+	history, err := chainstate.NewFixtureHistoryProvider([]byte(`{
+		"addresses": {"` + testExternalAddress + `": ["` + testTxID + `"]},
+		"transactions": {"` + testTxID + `": {"block_height": 600000, "hex": "` + testTxHex + `"}}
+	}`))
+	require.NoError(t, err)
+	return history
+}
+
+// initImportJobTestCase will create an xPub, a transaction (already recorded) and mock the import providers
+//
+// Only the first external address has a transaction in the address history (fixture)
+func initImportJobTestCase(t *testing.T, opts ...ClientOps) (context.Context, ClientInterface, func()) {
+	history := newImportJobTestHistory(t)
+
+	ctx, client, deferMe := CreateTestSQLiteClient(t, false, true, append([]ClientOps{
+		WithCustomTaskManager(&taskManagerMockBase{}),
+		WithCustomChainstate(&chainStateImport{history: history}),
+	}, opts...)...)
+
+	_, err := client.NewXpub(ctx, testXPub, client.DefaultModelOptions()...)
+	require.NoError(t, err)
+
+	tx := newTransaction(testTxHex, append(client.DefaultModelOptions(), New())...)
+	err = tx.Save(ctx)
+	require.NoError(t, err)
+
+	return ctx, client, deferMe
+}
+
+// TestImportJob_newImportJob will test the method newImportJob()
+func TestImportJob_newImportJob(t *testing.T) {
+	t.Parallel()
+
+	t.Run("default config", func(t *testing.T) {
+		job := newImportJob(testXPub, &ImportJobConfig{}, New())
+		require.NotNil(t, job)
+		assert.Len(t, job.ID, 64)
+		assert.Equal(t, testXPubID, job.XpubID)
+		assert.Equal(t, ImportJobStatusPending, job.Status)
+		assert.Equal(t, defaultImportGapLimit, job.Configuration.GapLimit)
+		assert.Equal(t, ModelImportJob.String(), job.GetModelName())
+		assert.Equal(t, tableImportJobs, job.GetModelTableName())
+	})
+
+	t.Run("custom gap limit", func(t *testing.T) {
+		job := newImportJob(testXPub, &ImportJobConfig{GapLimit: 25}, New())
+		require.NotNil(t, job)
+		assert.Equal(t, 25, job.Configuration.GapLimit)
+	})
+}
+
+// TestImportJob_getXpubKey will test the method getXpubKey()
+func TestImportJob_getXpubKey(t *testing.T) {
+
+	t.Run("encrypted xpub", func(t *testing.T) {
+		ctx, client, deferMe := CreateTestSQLiteClient(t, false, false, WithEncryption(testEncryption))
+		defer deferMe()
+
+		job := newImportJob(testXPub, &ImportJobConfig{}, append(client.DefaultModelOptions(), New())...)
+		err := job.Save(ctx)
+		require.NoError(t, err)
+		assert.NotEqual(t, testXPub, job.XpubKey)
+
+		job, err = getImportJob(ctx, testXPubID, job.ID, client.DefaultModelOptions()...)
+		require.NoError(t, err)
+		require.NotNil(t, job)
+
+		var key string
+		key, err = job.getXpubKey()
+		require.NoError(t, err)
+		assert.Equal(t, testXPub, key)
+	})
+
+	t.Run("error - missing encryption", func(t *testing.T) {
+		job := newImportJob(testXPub, &ImportJobConfig{}, New())
+		job.rawXpubKey = ""
+		job.XpubKey = "encrypted-value"
+		_, err := job.getXpubKey()
+		require.ErrorIs(t, err, ErrMissingEncryptionProvider)
+	})
+}
+
+// TestImportJobStatus_Scan will test the method Scan()
+func TestImportJobStatus_Scan(t *testing.T) {
+	t.Parallel()
+
+	t.Run("all statuses", func(t *testing.T) {
+		for _, status := range []ImportJobStatus{
+			ImportJobStatusPending, ImportJobStatusProcessing, ImportJobStatusComplete,
+			ImportJobStatusCanceled, ImportJobStatusError,
+		} {
+			var scanned ImportJobStatus
+			err := scanned.Scan(string(status))
+			require.NoError(t, err)
+			assert.Equal(t, status, scanned)
+
+			err = scanned.Scan([]byte(status))
+			require.NoError(t, err)
+			assert.Equal(t, status, scanned)
+		}
+	})
+}
+
+// Test_processImportJob will test the method processImportJob()
+func Test_processImportJob(t *testing.T) {
+
+	t.Run("error - missing job", func(t *testing.T) {
+		ctx, client, deferMe := CreateTestSQLiteClient(t, false, false, WithCustomTaskManager(&taskManagerMockBase{}))
+		defer deferMe()
+
+		job, err := processImportJob(ctx, client, testTxID, 0)
+		require.ErrorIs(t, err, ErrImportJobNotFound)
+		require.Nil(t, job)
+	})
+
+	t.Run("resume step by step", func(t *testing.T) {
+		ctx, client, deferMe := initImportJobTestCase(t)
+		defer deferMe()
+
+		job, err := client.NewImportJob(ctx, testXPub, nil)
+		require.NoError(t, err)
+
+		// Scan the first gap of the external chain (found a transaction)
+		job, err = processImportJob(ctx, client, job.ID, 1)
+		require.NoError(t, err)
+		assert.Equal(t, ImportJobStatusProcessing, job.Status)
+		assert.Equal(t, uint32(10), job.Cursors.External.Next)
+		assert.False(t, job.Cursors.External.Done)
+		assert.Equal(t, IDs{testTxID}, job.PendingTxIDs)
+		assert.Equal(t, 1, job.Results.TransactionsFound)
+
+		// The state is saved (resumes from the cursor)
+		job, err = getImportJob(ctx, testXPubID, job.ID, client.DefaultModelOptions()...)
+		require.NoError(t, err)
+		require.NotNil(t, job)
+		assert.Equal(t, uint32(10), job.Cursors.External.Next)
+
+		// Finish the import
+		job, err = processImportJob(ctx, client, job.ID, 0)
+		require.NoError(t, err)
+		assert.Equal(t, ImportJobStatusComplete, job.Status)
+		assert.True(t, job.Cursors.External.Done)
+		assert.True(t, job.Cursors.Internal.Done)
+		assert.Empty(t, job.PendingTxIDs)
+		assert.Equal(t, 20, job.Results.ExternalAddresses)
+		assert.Equal(t, 10, job.Results.InternalAddresses)
+		assert.Equal(t, 1, job.Results.TransactionsFound)
+		assert.Equal(t, 1, job.Results.TransactionsImported)
+
+		// New destinations are not derived from the imported nums
+		var xPub *Xpub
+		xPub, err = getXpubByID(ctx, testXPubID, client.DefaultModelOptions()...)
+		require.NoError(t, err)
+		require.NotNil(t, xPub)
+		assert.Equal(t, uint32(20), xPub.NextExternalNum)
+		assert.Equal(t, uint32(10), xPub.NextInternalNum)
+
+		var destination *Destination
+		destination, err = getDestinationByAddress(ctx, testExternalAddress, client.DefaultModelOptions()...)
+		require.NoError(t, err)
+		require.NotNil(t, destination)
+		assert.Equal(t, testXPubID, destination.XpubID)
+	})
+
+	t.Run("flat import profile", func(t *testing.T) {
+		hdKey, err := utils.ValidateXPub(testXPub)
+		require.NoError(t, err)
+		var flatAddress string
+		flatAddress, err = utils.DeriveAddressFromPath(hdKey, []uint32{3})
+		require.NoError(t, err)
+
+		var history chainstate.AddressHistoryProvider
+		history, err = chainstate.NewFixtureHistoryProvider([]byte(`{
+			"addresses": {"` + flatAddress + `": ["` + testTxID + `"]},
+			"transactions": {"` + testTxID + `": {"block_height": 600000, "hex": "` + testTxHex + `"}}
+		}`))
+		require.NoError(t, err)
+
+		ctx, client, deferMe := initImportJobTestCase(t, WithCustomChainstate(&chainStateImport{history: history}))
+		defer deferMe()
+
+		var job *ImportJob
+		job, err = client.NewImportJob(ctx, testXPub, &ImportJobConfig{GapLimit: 5, Profile: ImportProfileFlat})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"*"}, job.Configuration.Paths)
+
+		job, err = processImportJob(ctx, client, job.ID, 0)
+		require.NoError(t, err)
+		assert.Equal(t, ImportJobStatusComplete, job.Status)
+		assert.True(t, job.Cursors.Paths["*"].Done)
+		assert.Equal(t, 10, job.Results.OtherAddresses)
+		assert.Equal(t, 0, job.Results.ExternalAddresses)
+		assert.Equal(t, 1, job.Results.TransactionsFound)
+		assert.Equal(t, 1, job.Results.TransactionsImported)
+
+		// The destination is registered with the derivation path
+		var destination *Destination
+		destination, err = getDestinationByAddress(ctx, flatAddress, client.DefaultModelOptions()...)
+		require.NoError(t, err)
+		require.NotNil(t, destination)
+		assert.Equal(t, testXPubID, destination.XpubID)
+		assert.Equal(t, "3", destination.DerivationPath)
+
+		// The nums of the xPub are not used
+		var xPub *Xpub
+		xPub, err = getXpubByID(ctx, testXPubID, client.DefaultModelOptions()...)
+		require.NoError(t, err)
+		assert.Equal(t, uint32(0), xPub.NextExternalNum)
+		assert.Equal(t, uint32(0), xPub.NextInternalNum)
+	})
+
+	t.Run("single address import profile", func(t *testing.T) {
+		ctx, client, deferMe := initImportJobTestCase(t)
+		defer deferMe()
+
+		job, err := client.NewImportJob(ctx, testXPub, &ImportJobConfig{Profile: ImportProfileSingleAddress})
+		require.NoError(t, err)
+
+		job, err = processImportJob(ctx, client, job.ID, 0)
+		require.NoError(t, err)
+		assert.Equal(t, ImportJobStatusComplete, job.Status)
+		assert.Equal(t, 1, job.Results.OtherAddresses)
+		assert.Equal(t, 1, job.Results.TransactionsFound)
+
+		var destination *Destination
+		destination, err = getDestinationByAddress(ctx, testExternalAddress, client.DefaultModelOptions()...)
+		require.NoError(t, err)
+		require.NotNil(t, destination)
+		assert.Equal(t, "", destination.DerivationPath)
+	})
+
+	t.Run("failed steps are retried", func(t *testing.T) {
+		history := &historyProviderFailing{AddressHistoryProvider: newImportJobTestHistory(t), failures: 1}
+		ctx, client, deferMe := initImportJobTestCase(t, WithCustomChainstate(&chainStateImport{history: history}))
+		defer deferMe()
+
+		job, err := client.NewImportJob(ctx, testXPub, nil)
+		require.NoError(t, err)
+
+		// The step failed, retried later
+		job, err = processImportJob(ctx, client, job.ID, 10)
+		require.NoError(t, err)
+		assert.Equal(t, ImportJobStatusProcessing, job.Status)
+		assert.Equal(t, 1, job.Retries)
+		assert.NotEmpty(t, job.Error)
+		assert.True(t, job.RetryAt.After(time.Now()))
+		assert.Equal(t, uint32(0), job.Cursors.External.Next)
+
+		// Not processed before the retry
+		job, err = processImportJob(ctx, client, job.ID, 10)
+		require.NoError(t, err)
+		assert.Equal(t, 1, job.Retries)
+
+		job.RetryAt = time.Now().UTC().Add(-time.Second)
+		require.NoError(t, job.Save(ctx))
+
+		job, err = processImportJob(ctx, client, job.ID, 0)
+		require.NoError(t, err)
+		assert.Equal(t, ImportJobStatusComplete, job.Status)
+		assert.Equal(t, 0, job.Retries)
+		assert.Empty(t, job.Error)
+		assert.Equal(t, 1, job.Results.TransactionsImported)
+
+		// The raw transactions are only downloaded to be recorded (block heights from the address history)
+		assert.Equal(t, 1, history.rawTransactions)
+
+		// The xPub is not kept after the import
+		job, err = getImportJob(ctx, testXPubID, job.ID, client.DefaultModelOptions()...)
+		require.NoError(t, err)
+		assert.Empty(t, job.XpubKey)
+	})
+
+	t.Run("stops after the retries", func(t *testing.T) {
+		history := &historyProviderFailing{AddressHistoryProvider: newImportJobTestHistory(t), failures: 100}
+		ctx, client, deferMe := initImportJobTestCase(t, WithCustomChainstate(&chainStateImport{history: history}))
+		defer deferMe()
+
+		job, err := client.NewImportJob(ctx, testXPub, nil)
+		require.NoError(t, err)
+		job.Retries = defaultImportMaxRetries
+		require.NoError(t, job.Save(ctx))
+
+		job, err = processImportJob(ctx, client, job.ID, 1)
+		require.Error(t, err)
+		assert.Equal(t, ImportJobStatusError, job.Status)
+
+		// The xPub is kept to resume the import job
+		job, err = getImportJob(ctx, testXPubID, job.ID, client.DefaultModelOptions()...)
+		require.NoError(t, err)
+		assert.NotEmpty(t, job.XpubKey)
+	})
+
+	t.Run("canceled job is not processed", func(t *testing.T) {
+		ctx, client, deferMe := initImportJobTestCase(t)
+		defer deferMe()
+
+		job, err := client.NewImportJob(ctx, testXPub, &ImportJobConfig{GapLimit: 5})
+		require.NoError(t, err)
+
+		_, err = client.CancelImportJob(ctx, testXPubID, job.ID)
+		require.NoError(t, err)
+
+		job, err = processImportJob(ctx, client, job.ID, 0)
+		require.NoError(t, err)
+		assert.Equal(t, ImportJobStatusCanceled, job.Status)
+		assert.Equal(t, 0, job.Results.ExternalAddresses)
+	})
+}
+
+// Test_taskProcessImportJobs will test the method taskProcessImportJobs()
+func Test_taskProcessImportJobs(t *testing.T) {
+	ctx, client, deferMe := initImportJobTestCase(t)
+	defer deferMe()
+
+	job, err := client.NewImportJob(ctx, testXPub, nil)
+	require.NoError(t, err)
+
+	err = taskProcessImportJobs(ctx, client.Logger(), WithClient(client))
+	require.NoError(t, err)
+
+	job, err = client.GetImportJob(ctx, testXPubID, job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, ImportJobStatusComplete, job.Status)
+	assert.Equal(t, 1, job.Results.TransactionsImported)
+}
+
+// Test_sortTransactionInfos will test the method sortTransactionInfos()
+func Test_sortTransactionInfos(t *testing.T) {
+	t.Parallel()
+
+	t.Run("by block height and previous tx", func(t *testing.T) {
+		txInfos := whatsonchain.TxList{
+			{Hash: "c", BlockHeight: 20},
+			{Hash: "b", BlockHeight: 10, Vin: []whatsonchain.VinInfo{{TxID: "a"}}},
+			{Hash: "a", BlockHeight: 10},
+		}
+		sortTransactionInfos(txInfos)
+		assert.Equal(t, "a", txInfos[0].Hash)
+		assert.Equal(t, "b", txInfos[1].Hash)
+		assert.Equal(t, "c", txInfos[2].Hash)
+	})
+
+	t.Run("unconfirmed transactions last", func(t *testing.T) {
+		txInfos := whatsonchain.TxList{
+			{Hash: "c", BlockHeight: 0},
+			{Hash: "b", BlockHeight: 20},
+			{Hash: "a", BlockHeight: 10},
+		}
+		sortTransactionInfos(txInfos)
+		assert.Equal(t, "a", txInfos[0].Hash)
+		assert.Equal(t, "b", txInfos[1].Hash)
+		assert.Equal(t, "c", txInfos[2].Hash)
+	})
+}
+
+// Test_getAddressHistory will test the method getAddressHistory()
+func Test_getAddressHistory(t *testing.T) {
+
+	t.Run("missing address history provider", func(t *testing.T) {
+		ctx, client, deferMe := CreateTestSQLiteClient(t, false, false,
+			WithCustomTaskManager(&taskManagerMockBase{}),
+			WithCustomChainstate(&chainStateEverythingOnChain{}),
+		)
+		defer deferMe()
+
+		history, err := getAddressHistory(ctx, client, []string{testExternalAddress})
+		require.ErrorIs(t, err, ErrMissingAddressHistoryProvider)
+		assert.Nil(t, history)
+	})
+
+	t.Run("unique tx ids", func(t *testing.T) {
+		ctx, client, deferMe := initImportJobTestCase(t)
+		defer deferMe()
+
+		history, err := getAddressHistory(
+			ctx, client, []string{testExternalAddress, testExternalAddress, "unknown"},
+		)
+		require.NoError(t, err)
+		require.Len(t, history, 1)
+		assert.Equal(t, testTxID, history[0].TxID)
+		assert.Equal(t, int64(600000), history[0].BlockHeight)
+	})
+}
@@ -31,6 +31,35 @@ func WithXPub(rawXpubKey string) ModelOps {
 	}
 }
 
+// WithAccessKeyID will set the access key (that made the request) on the model
+func WithAccessKeyID(accessKeyID string) ModelOps {
+	return func(m *Model) {
+		if len(accessKeyID) > 0 {
+			m.accessKeyID = accessKeyID
+		}
+	}
+}
+
+// WithAdminXPubID will set the admin (that made the request) on the model
+//
+// The admin is recorded as the actor in the audit log
+func WithAdminXPubID(adminXPubID string) ModelOps {
+	return func(m *Model) {
+		if len(adminXPubID) > 0 {
+			m.adminXPubID = adminXPubID
+		}
+	}
+}
+
+// withoutFinalityCheck will skip the finality check of the transactions when recording a block header
+//
+// Used when recording block headers in a batch, the finality is checked once after the batch
+func withoutFinalityCheck() ModelOps {
+	return func(m *Model) {
+		m.skipFinality = true
+	}
+}
+
 // WithEncryptionKey will set the encryption key on the model (if needed)
 func WithEncryptionKey(encryptionKey string) ModelOps {
 	return func(m *Model) {
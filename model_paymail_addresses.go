@@ -21,13 +21,13 @@ type PaymailAddress struct {
 	Model `bson:",inline"`
 
 	// Model specific fields
-	ID              string `json:"id" toml:"id" yaml:"id" gorm:"<-:create;type:char(64);primaryKey;comment:This is the unique paymail record id" bson:"_id"`                                                                              // Unique identifier
-	XpubID          string `json:"xpub_id" toml:"xpub_id" yaml:"xpub_id" gorm:"<-:create;type:char(64);index;comment:This is the related xPub" bson:"xpub_id"`                                                                            // Related xPub ID
-	Alias           string `json:"alias" toml:"alias" yaml:"alias" gorm:"<-;type:varchar(64);comment:This is alias@" bson:"alias"`                                                                                                        // Alias part of the paymail
-	Domain          string `json:"domain" toml:"domain" yaml:"domain" gorm:"<-;type:varchar(255);comment:This is @domain.com" bson:"domain"`                                                                                              // Domain of the paymail
-	PublicName      string `json:"public_name" toml:"public_name" yaml:"public_name" gorm:"<-;type:varchar(255);comment:This is public name for public profile" bson:"public_name,omitempty"`                                             // Full username
-	Avatar          string `json:"avatar" toml:"avatar" yaml:"avatar" gorm:"<-;type:text;comment:This is avatar url" bson:"avatar"`                                                                                                       // This is the url of the user (public profile)
-	ExternalXpubKey string `json:"external_xpub_key" toml:"external_xpub_key" yaml:"external_xpub_key" gorm:"<-:create;type:varchar(512);index;comment:This is full xPub for external use, encryption optional" bson:"external_xpub_key"` // PublicKey hex encoded
+	ID              string `json:"id" toml:"id" yaml:"id" gorm:"<-:create;type:char(64);primaryKey;comment:This is the unique paymail record id" bson:"_id"`                                                                       // Unique identifier
+	XpubID          string `json:"xpub_id" toml:"xpub_id" yaml:"xpub_id" gorm:"<-:create;type:char(64);index;comment:This is the related xPub" bson:"xpub_id"`                                                                     // Related xPub ID
+	Alias           string `json:"alias" toml:"alias" yaml:"alias" gorm:"<-;type:varchar(64);comment:This is alias@" bson:"alias"`                                                                                                 // Alias part of the paymail
+	Domain          string `json:"domain" toml:"domain" yaml:"domain" gorm:"<-;type:varchar(255);comment:This is @domain.com" bson:"domain"`                                                                                       // Domain of the paymail
+	PublicName      string `json:"public_name" toml:"public_name" yaml:"public_name" gorm:"<-;type:varchar(255);comment:This is public name for public profile" bson:"public_name,omitempty"`                                      // Full username
+	Avatar          string `json:"avatar" toml:"avatar" yaml:"avatar" gorm:"<-;type:text;comment:This is avatar url" bson:"avatar"`                                                                                                // This is the url of the user (public profile)
+	ExternalXpubKey string `json:"external_xpub_key" toml:"external_xpub_key" yaml:"external_xpub_key" gorm:"<-;type:varchar(512);index;comment:This is full xPub for external use, encryption optional" bson:"external_xpub_key"` // PublicKey hex encoded
 
 	// Private fields
 	externalXpubKeyDecrypted string
@@ -139,8 +139,8 @@ func (m *PaymailAddress) setXPub() error {
 	m.externalXpubKeyDecrypted = paymailExternalKey.String()
 
 	// Encrypt the xPub
-	if len(m.encryptionKey) > 0 {
-		m.ExternalXpubKey, err = utils.Encrypt(m.encryptionKey, m.externalXpubKeyDecrypted)
+	if provider := m.getEncryption(); provider != nil {
+		m.ExternalXpubKey, err = provider.Encrypt(m.externalXpubKeyDecrypted)
 	} else {
 		m.ExternalXpubKey = m.externalXpubKeyDecrypted
 	}
@@ -148,6 +148,40 @@ func (m *PaymailAddress) setXPub() error {
 	return err
 }
 
+// encryptSensitiveFields will encrypt the external xPub (and the sensitive metadata), and re-encrypt
+// the values that are not encrypted using the active key (rotation)
+//
+// Returns true if any of the values changed
+func (m *PaymailAddress) encryptSensitiveFields() (bool, error) {
+	changed, err := m.Model.encryptSensitiveFields()
+	if err != nil {
+		return false, err
+	}
+
+	// Nothing to encrypt, or already encrypted using the active key
+	provider := m.getEncryption()
+	if provider == nil || len(m.ExternalXpubKey) == 0 || (len(m.ExternalXpubKey) != utils.XpubKeyLength &&
+		provider.IsCurrent(m.ExternalXpubKey)) {
+		return changed, nil
+	}
+
+	// Decrypt (rotation) and encrypt using the active key
+	if _, err = m.GetExternalXpub(); err != nil {
+		return false, err
+	}
+	if m.ExternalXpubKey, err = provider.Encrypt(m.externalXpubKeyDecrypted); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// getEncryptedFields will get the (stored) values of the columns that are encrypted at rest
+func (m *PaymailAddress) getEncryptedFields() map[string]interface{} {
+	fields := m.Model.getEncryptedFields()
+	fields[externalXpubKeyField] = m.ExternalXpubKey
+	return fields
+}
+
 // GetIdentityXpub will get the identity related to the xPub
 func (m *PaymailAddress) GetIdentityXpub() (*bip32.ExtendedKey, error) {
 
@@ -168,9 +202,13 @@ func (m *PaymailAddress) GetExternalXpub() (*bip32.ExtendedKey, error) {
 
 	// Check if the xPub was encrypted
 	if len(m.ExternalXpubKey) != utils.XpubKeyLength {
+		provider := m.getEncryption()
+		if provider == nil {
+			return nil, ErrMissingEncryptionProvider
+		}
 		var err error
-		if m.externalXpubKeyDecrypted, err = utils.Decrypt(
-			m.encryptionKey, m.ExternalXpubKey,
+		if m.externalXpubKeyDecrypted, err = provider.Decrypt(
+			m.ExternalXpubKey,
 		); err != nil {
 			return nil, err
 		}
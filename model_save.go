@@ -66,6 +66,25 @@ func Save(ctx context.Context, model ModelInterface) (err error) {
 			modelsToSave = append(modelsToSave, children...)
 		}
 
+		// Encrypt the sensitive fields (encryption at rest)
+		for index := range modelsToSave {
+			if encrypter, ok := modelsToSave[index].(sensitiveFieldsEncrypter); ok {
+				if _, err = encrypter.encryptSensitiveFields(); err != nil {
+					return
+				}
+			}
+		}
+
+		// Get the stored state of the audited models (before any changes are saved)
+		auditSnapshots := make([]*auditSnapshot, len(modelsToSave))
+		for index := range modelsToSave {
+			if isAuditedModel(c, modelsToSave[index]) {
+				if auditSnapshots[index], err = getAuditSnapshot(ctx, modelsToSave[index]); err != nil {
+					return
+				}
+			}
+		}
+
 		// Logs for saving models
 		model.DebugLog(fmt.Sprintf("saving %d models...", len(modelsToSave)))
 
@@ -79,6 +98,22 @@ func Save(ctx context.Context, model ModelInterface) (err error) {
 			}
 		}
 
+		// Write the audit log entries (in the same transaction, sealed by the audit log task)
+		for index := range modelsToSave {
+			if auditSnapshots[index] == nil {
+				continue
+			}
+			var auditLog *AuditLog
+			if auditLog, err = newAuditLogEntry(ctx, modelsToSave[index], auditSnapshots[index]); err != nil {
+				return
+			} else if auditLog == nil {
+				continue
+			}
+			if err = ds.SaveModel(ctx, auditLog, tx, true, false); err != nil {
+				return
+			}
+		}
+
 		// Commit all the model(s) if needed
 		if tx.CanCommit() {
 			model.DebugLog("committing db transaction...")
@@ -87,6 +122,13 @@ func Save(ctx context.Context, model ModelInterface) (err error) {
 			}
 		}
 
+		// The saved state is the stored state for the next save of the models
+		for index := range modelsToSave {
+			if holder, ok := modelsToSave[index].(auditSnapshotHolder); ok && auditSnapshots[index] != nil {
+				holder.setAuditBefore(auditSnapshots[index].after)
+			}
+		}
+
 		// Fire after hooks (only on commit success)
 		var afterErr error
 		for index := range modelsToSave {
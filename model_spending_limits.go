@@ -0,0 +1,349 @@
+package bux
+
+import (
+	"bytes"
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/mrz1836/go-datastore"
+)
+
+// SpendingLimits are the velocity controls for outgoing transactions of an xPub
+//
+// A zero value for a limit means "no limit"
+type SpendingLimits struct {
+	MaxNewRecipients          uint32 `json:"max_new_recipients,omitempty" toml:"max_new_recipients" yaml:"max_new_recipients" bson:"max_new_recipients,omitempty"`                                         // Max outputs to recipients never paid before (per transaction)
+	MaxSatoshisPerDay         uint64 `json:"max_satoshis_per_day,omitempty" toml:"max_satoshis_per_day" yaml:"max_satoshis_per_day" bson:"max_satoshis_per_day,omitempty"`                                 // Max satoshis sent in a rolling 24h window
+	MaxSatoshisPerTransaction uint64 `json:"max_satoshis_per_transaction,omitempty" toml:"max_satoshis_per_transaction" yaml:"max_satoshis_per_transaction" bson:"max_satoshis_per_transaction,omitempty"` // Max satoshis sent in a single transaction
+}
+
+// IsEmpty will return true if no limit is set
+func (l *SpendingLimits) IsEmpty() bool {
+	return l == nil || (l.MaxNewRecipients == 0 && l.MaxSatoshisPerDay == 0 && l.MaxSatoshisPerTransaction == 0)
+}
+
+// Scan will scan the value into Struct, implements sql.Scanner interface
+func (l *SpendingLimits) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+
+	xType := fmt.Sprintf("%T", value)
+	var byteValue []byte
+	if xType == ValueTypeString {
+		byteValue = []byte(value.(string))
+	} else {
+		byteValue = value.([]byte)
+	}
+	if bytes.Equal(byteValue, []byte("")) || bytes.Equal(byteValue, []byte("\"\"")) {
+		return nil
+	}
+
+	return json.Unmarshal(byteValue, &l)
+}
+
+// Value return json value, implement driver.Valuer interface
+func (l SpendingLimits) Value() (driver.Value, error) {
+	marshal, err := json.Marshal(l)
+	if err != nil {
+		return nil, err
+	}
+
+	return string(marshal), nil
+}
+
+// changeAddresses will return the addresses of the change destinations of the draft
+func (m *DraftTransaction) changeAddresses() map[string]bool {
+	change := make(map[string]bool)
+	for _, destination := range m.Configuration.ChangeDestinations {
+		change[destination.Address] = true
+	}
+	return change
+}
+
+// isOwnOutput will return true if the output goes to the change or to destinations of the xPub
+// (IE: the carry output of a batch payout)
+func (m *DraftTransaction) isOwnOutput(ctx context.Context, output *TransactionOutput,
+	change map[string]bool) (bool, error) {
+	if change[output.To] {
+		return true, nil
+	}
+
+	c := m.Client()
+	if c == nil || output.OpReturn != nil || len(output.Scripts) == 0 {
+		return false, nil
+	}
+	for _, script := range output.Scripts {
+		destination, err := getDestinationWithCache(ctx, c, "", "", script.Script, m.GetOptions(false)...)
+		if errors.Is(err, ErrMissingDestination) {
+			return false, nil
+		} else if err != nil {
+			return false, err
+		} else if destination.XpubID != m.XpubID {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// spentSatoshis will return the satoshis leaving the wallet with this draft (fee + outputs not to own destinations)
+func (m *DraftTransaction) spentSatoshis(ctx context.Context) (uint64, error) {
+	change := m.changeAddresses()
+	satoshis := m.Configuration.Fee
+	for _, output := range m.Configuration.Outputs {
+		own, err := m.isOwnOutput(ctx, output, change)
+		if err != nil {
+			return 0, err
+		} else if !own {
+			satoshis += output.Satoshis
+		}
+	}
+	return satoshis, nil
+}
+
+// recipients will return the unique recipients (to, or script) of the draft, skipping own & op_return outputs
+func (m *DraftTransaction) recipients(ctx context.Context) ([]string, error) {
+	change := m.changeAddresses()
+	recipients := make([]string, 0)
+	found := make(map[string]bool)
+	for _, output := range m.Configuration.Outputs {
+		if output.OpReturn != nil {
+			continue
+		}
+		own, err := m.isOwnOutput(ctx, output, change)
+		if err != nil {
+			return nil, err
+		} else if own {
+			continue
+		}
+		recipient := output.To
+		if len(recipient) == 0 {
+			recipient = output.Script
+		}
+		if len(recipient) > 0 && !found[recipient] {
+			found[recipient] = true
+			recipients = append(recipients, recipient)
+		}
+	}
+	return recipients, nil
+}
+
+// getSpendingLimits will get the effective limits for the xPub (xPub limits, or the client defaults)
+func (m *DraftTransaction) getSpendingLimits(ctx context.Context) (*SpendingLimits, error) {
+	xPub, err := getXpubByID(ctx, m.XpubID, m.GetOptions(false)...)
+	if err != nil {
+		return nil, err
+	} else if xPub != nil && !xPub.SpendingLimits.IsEmpty() {
+		return &xPub.SpendingLimits, nil
+	}
+	if c := m.Client(); c != nil {
+		return c.DefaultSpendingLimits(), nil
+	}
+	return nil, nil
+}
+
+// checkSpendingLimits will evaluate the spending limits of the xPub (and access key) against the draft
+//
+// Returns the reason if a limit was exceeded (empty if within limits)
+func (m *DraftTransaction) checkSpendingLimits(ctx context.Context) (string, error) {
+
+	limits, err := m.getSpendingLimits(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	// Get the access key scope (if the draft was created with an access key)
+	var scope *AccessKeyScope
+	if len(m.AccessKeyID) > 0 {
+		var accessKey *AccessKey
+		if accessKey, err = getAccessKey(ctx, m.AccessKeyID, m.GetOptions(false)...); err != nil {
+			return "", err
+		} else if accessKey == nil {
+			return "", ErrUnknownAccessKey
+		}
+		scope = &accessKey.Scope
+	}
+
+	// Nothing to check
+	if limits.IsEmpty() && (scope == nil || scope.MaxSatoshisPerDay == 0) {
+		return "", nil
+	}
+
+	// Limit per transaction
+	spent := m.SpentSatoshis
+	if !limits.IsEmpty() && limits.MaxSatoshisPerTransaction > 0 && spent > limits.MaxSatoshisPerTransaction {
+		return fmt.Sprintf(
+			"transaction of %d satoshis exceeds the limit of %d satoshis per transaction",
+			spent, limits.MaxSatoshisPerTransaction,
+		), nil
+	}
+
+	// Limit per rolling 24h (xPub)
+	since := time.Now().UTC().Add(-24 * time.Hour)
+	if !limits.IsEmpty() && limits.MaxSatoshisPerDay > 0 {
+		var spentToday uint64
+		if spentToday, err = m.getSpentSatoshisSince(
+			ctx, map[string]interface{}{xPubIDField: m.XpubID}, since,
+		); err != nil {
+			return "", err
+		} else if spentToday+spent > limits.MaxSatoshisPerDay {
+			return fmt.Sprintf(
+				"sending %d satoshis exceeds the xpub limit of %d satoshis per day (%d already sent)",
+				spent, limits.MaxSatoshisPerDay, spentToday,
+			), nil
+		}
+	}
+
+	// Limit per rolling 24h (access key)
+	if scope != nil && scope.MaxSatoshisPerDay > 0 {
+		var spentToday uint64
+		if spentToday, err = m.getSpentSatoshisSince(
+			ctx, map[string]interface{}{accessKeyIDField: m.AccessKeyID}, since,
+		); err != nil {
+			return "", err
+		} else if spentToday+spent > scope.MaxSatoshisPerDay {
+			return fmt.Sprintf(
+				"sending %d satoshis exceeds the access key limit of %d satoshis per day (%d already sent)",
+				spent, scope.MaxSatoshisPerDay, spentToday,
+			), nil
+		}
+	}
+
+	// Limit of new recipients
+	if !limits.IsEmpty() && limits.MaxNewRecipients > 0 {
+		var newRecipients uint32
+		if newRecipients, err = m.countNewRecipients(ctx); err != nil {
+			return "", err
+		} else if newRecipients > limits.MaxNewRecipients {
+			return fmt.Sprintf(
+				"transaction has %d new recipients, exceeding the limit of %d new recipients",
+				newRecipients, limits.MaxNewRecipients,
+			), nil
+		}
+	}
+
+	return "", nil
+}
+
+// applySpendingLimits will check the limits and put the draft into pending approval if a limit is exceeded
+func (m *DraftTransaction) applySpendingLimits(ctx context.Context) (bool, error) {
+	reason, err := m.checkSpendingLimits(ctx)
+	if err != nil {
+		return false, err
+	} else if len(reason) == 0 {
+		return false, nil
+	}
+
+	m.requireApproval(reason)
+	return true, nil
+}
+
+// checkRecordSpendingLimits will check the spending limits before recording the final transaction of the draft
+//
+// A draft that (now) exceeds a limit is saved as pending approval
+func (m *DraftTransaction) checkRecordSpendingLimits(ctx context.Context) error {
+	if m.Status == DraftStatusPendingApproval {
+		return ErrDraftRequiresApproval
+	} else if m.Status == DraftStatusRejected {
+		return ErrDraftRejected
+	} else if m.Status != DraftStatusDraft || m.IsApproved() {
+		return nil
+	}
+
+	exceeded, err := m.applySpendingLimits(ctx)
+	if err != nil {
+		return err
+	} else if !exceeded {
+		return nil
+	}
+
+	if err = m.Save(ctx); err != nil {
+		return err
+	}
+	return ErrDraftRequiresApproval
+}
+
+// draftSpending is the spent satoshis of a draft (loaded by getSpentSatoshisSince)
+type draftSpending struct {
+	ID            string `json:"id" toml:"id" yaml:"id" bson:"_id"`
+	SpentSatoshis uint64 `json:"spent_satoshis" toml:"spent_satoshis" yaml:"spent_satoshis" bson:"spent_satoshis"`
+}
+
+// getSpentSatoshisSince will get the satoshis spent by the drafts (open or complete) matching the conditions
+//
+// Canceled, expired and pending approval drafts do not count, neither does the current draft
+func (m *DraftTransaction) getSpentSatoshisSince(ctx context.Context, conditions map[string]interface{},
+	since time.Time) (uint64, error) {
+
+	conditions[createdAtField] = map[string]interface{}{
+		"$gte": since,
+	}
+	conditions["$or"] = []map[string]interface{}{{
+		statusField: DraftStatusDraft,
+	}, {
+		statusField: DraftStatusComplete,
+	}}
+
+	// Only the spent satoshis of the drafts are loaded
+	var drafts []*draftSpending
+	if err := m.Client().Datastore().GetModels(
+		ctx, &[]*DraftTransaction{}, conditions, nil, &drafts, defaultDatabaseReadTimeout,
+	); err != nil && !errors.Is(err, datastore.ErrNoResults) {
+		return 0, err
+	}
+
+	var spent uint64
+	for _, draft := range drafts {
+		if draft.ID != m.ID {
+			spent += draft.SpentSatoshis
+		}
+	}
+	return spent, nil
+}
+
+// countNewRecipients will count the recipients of the draft that the xPub never sent to before
+func (m *DraftTransaction) countNewRecipients(ctx context.Context) (uint32, error) {
+	recipients, err := m.recipients(ctx)
+	if err != nil {
+		return 0, err
+	} else if len(recipients) == 0 {
+		return 0, nil
+	}
+
+	var known int64
+	if known, err = countKnownDraftRecipients(
+		ctx, m.XpubID, recipients, m.GetOptions(false)...,
+	); err != nil {
+		return 0, err
+	}
+	return uint32(int64(len(recipients)) - known), nil
+}
+
+// saveRecipients will store the recipients of the completed draft (known recipients are skipped)
+func (m *DraftTransaction) saveRecipients(ctx context.Context) error {
+	recipients, err := m.recipients(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, recipient := range recipients {
+		var draftRecipient *DraftRecipient
+		if draftRecipient, err = getDraftRecipient(
+			ctx, draftRecipientID(m.XpubID, recipient), m.GetOptions(false)...,
+		); err != nil {
+			return err
+		} else if draftRecipient != nil {
+			continue
+		}
+
+		draftRecipient = newDraftRecipient(m.XpubID, recipient, m.ID, m.GetOptions(true)...)
+		if err = draftRecipient.Save(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,303 @@
+package bux
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/BuxOrg/bux/utils"
+	"github.com/libsv/go-bk/bip32"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testSpendingAddress is an address that is not a destination of the test xPub
+const testSpendingAddress = "1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa"
+
+// addTestSpendingUtxo will add an extra utxo to the test xPub (initSimpleTestCase)
+func addTestSpendingUtxo(ctx context.Context, t *testing.T, client ClientInterface, index uint32) {
+	utxo := newUtxo(testXPubID, testTxID, testLockingScript, index, 100000,
+		append(client.DefaultModelOptions(), New())...)
+	require.NoError(t, utxo.Save(ctx))
+}
+
+// newTestSpendingDraft will create a new draft sending the satoshis to the address
+func newTestSpendingDraft(ctx context.Context, t *testing.T, client ClientInterface, address string,
+	satoshis uint64, opts ...ModelOps) *DraftTransaction {
+	draft, err := client.NewTransaction(ctx, testXPub, &TransactionConfig{
+		Outputs: []*TransactionOutput{{To: address, Satoshis: satoshis}},
+		Sync:    &SyncConfig{},
+	}, append(client.DefaultModelOptions(), opts...)...)
+	require.NoError(t, err)
+	require.NotNil(t, draft)
+	return draft
+}
+
+// TestSpendingLimits_IsEmpty will test the method IsEmpty()
+func TestSpendingLimits_IsEmpty(t *testing.T) {
+	t.Parallel()
+
+	var limits *SpendingLimits
+	assert.True(t, limits.IsEmpty())
+	assert.True(t, (&SpendingLimits{}).IsEmpty())
+	assert.False(t, (&SpendingLimits{MaxNewRecipients: 1}).IsEmpty())
+	assert.False(t, (&SpendingLimits{MaxSatoshisPerDay: 1}).IsEmpty())
+	assert.False(t, (&SpendingLimits{MaxSatoshisPerTransaction: 1}).IsEmpty())
+}
+
+// TestSpendingLimits_Value will test the methods Value() and Scan()
+func TestSpendingLimits_Value(t *testing.T) {
+	t.Parallel()
+
+	limits := SpendingLimits{MaxNewRecipients: 2, MaxSatoshisPerDay: 10000, MaxSatoshisPerTransaction: 5000}
+	value, err := limits.Value()
+	require.NoError(t, err)
+
+	scanned := SpendingLimits{}
+	require.NoError(t, scanned.Scan(value))
+	assert.Equal(t, limits, scanned)
+
+	empty := SpendingLimits{}
+	require.NoError(t, empty.Scan(nil))
+	assert.True(t, empty.IsEmpty())
+}
+
+// TestDraftTransaction_spentSatoshis will test the methods spentSatoshis() and recipients()
+func TestDraftTransaction_spentSatoshis(t *testing.T) {
+	t.Parallel()
+
+	draft := &DraftTransaction{Configuration: TransactionConfig{
+		ChangeDestinations: []*Destination{{Address: "change-address"}},
+		ChangeSatoshis:     5000,
+		Fee:                100,
+		Outputs: []*TransactionOutput{
+			{To: testExternalAddress, Satoshis: 1000},
+			{To: testExternalAddress, Satoshis: 2000},
+			{OpReturn: &OpReturn{StringParts: []string{"test"}}},
+			{To: "change-address", Satoshis: 5000},
+		},
+	}}
+	spent, err := draft.spentSatoshis(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, uint64(3100), spent)
+
+	var recipients []string
+	recipients, err = draft.recipients(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []string{testExternalAddress}, recipients)
+}
+
+// TestClient_NewTransaction_SpendingLimits will test the spending limits of NewTransaction() & RecordTransaction()
+func TestClient_NewTransaction_SpendingLimits(t *testing.T) {
+
+	t.Run("no limits", func(t *testing.T) {
+		ctx, client, deferMe := initSimpleTestCase(t)
+		defer deferMe()
+
+		draft := newTestSpendingDraft(ctx, t, client, testSpendingAddress, 50000)
+		assert.Equal(t, DraftStatusDraft, draft.Status)
+		assert.Empty(t, draft.ApprovalReason)
+	})
+
+	t.Run("per transaction limit (client default)", func(t *testing.T) {
+		ctx, client, deferMe := initSimpleTestCase(t, WithSpendingLimits(&SpendingLimits{
+			MaxSatoshisPerTransaction: 2000,
+		}))
+		defer deferMe()
+		addTestSpendingUtxo(ctx, t, client, 1)
+
+		draft := newTestSpendingDraft(ctx, t, client, testSpendingAddress, 1000)
+		assert.Equal(t, DraftStatusDraft, draft.Status)
+
+		draft = newTestSpendingDraft(ctx, t, client, testSpendingAddress, 3000)
+		assert.Equal(t, DraftStatusPendingApproval, draft.Status)
+		assert.NotEmpty(t, draft.ApprovalReason)
+
+		// Pending approval drafts can not be recorded
+		xPriv, err := bip32.NewKeyFromString(testXPriv)
+		require.NoError(t, err)
+		var hex string
+		hex, err = draft.SignInputs(xPriv)
+		require.NoError(t, err)
+
+		_, err = client.RecordTransaction(ctx, testXPub, hex, draft.ID, client.DefaultModelOptions()...)
+		require.ErrorIs(t, err, ErrDraftRequiresApproval)
+	})
+
+	t.Run("per day limit (xpub)", func(t *testing.T) {
+		ctx, client, deferMe := initSimpleTestCase(t)
+		defer deferMe()
+		addTestSpendingUtxo(ctx, t, client, 1)
+
+		xPub, err := client.UpdateXpubSpendingLimits(ctx, testXPubID, &SpendingLimits{
+			MaxSatoshisPerDay: 3000,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, uint64(3000), xPub.SpendingLimits.MaxSatoshisPerDay)
+
+		draft := newTestSpendingDraft(ctx, t, client, testSpendingAddress, 2000)
+		assert.Equal(t, DraftStatusDraft, draft.Status)
+
+		draft = newTestSpendingDraft(ctx, t, client, testSpendingAddress, 2000)
+		assert.Equal(t, DraftStatusPendingApproval, draft.Status)
+	})
+
+	t.Run("re-evaluated when recording", func(t *testing.T) {
+		ctx, client, deferMe := initSimpleTestCase(t)
+		defer deferMe()
+		addTestSpendingUtxo(ctx, t, client, 1)
+
+		draft1 := newTestSpendingDraft(ctx, t, client, testSpendingAddress, 2000)
+		draft2 := newTestSpendingDraft(ctx, t, client, testSpendingAddress, 1000)
+		assert.Equal(t, DraftStatusDraft, draft2.Status)
+
+		// Both drafts together exceed the new limit
+		spent1, err := draft1.spentSatoshis(ctx)
+		require.NoError(t, err)
+		var spent2 uint64
+		spent2, err = draft2.spentSatoshis(ctx)
+		require.NoError(t, err)
+		_, err = client.UpdateXpubSpendingLimits(ctx, testXPubID, &SpendingLimits{
+			MaxSatoshisPerDay: spent1 + spent2 - 1,
+		})
+		require.NoError(t, err)
+
+		var xPriv *bip32.ExtendedKey
+		xPriv, err = bip32.NewKeyFromString(testXPriv)
+		require.NoError(t, err)
+		var hex string
+		hex, err = draft2.SignInputs(xPriv)
+		require.NoError(t, err)
+
+		_, err = client.RecordTransaction(ctx, testXPub, hex, draft2.ID, client.DefaultModelOptions()...)
+		require.ErrorIs(t, err, ErrDraftRequiresApproval)
+
+		var saved *DraftTransaction
+		saved, err = getDraftTransactionID(ctx, testXPubID, draft2.ID, client.DefaultModelOptions()...)
+		require.NoError(t, err)
+		assert.Equal(t, DraftStatusPendingApproval, saved.Status)
+		assert.NotEmpty(t, saved.ApprovalReason)
+	})
+
+	t.Run("per day limit (access key)", func(t *testing.T) {
+		ctx, client, deferMe := initSimpleTestCase(t)
+		defer deferMe()
+		addTestSpendingUtxo(ctx, t, client, 1)
+
+		accessKey, err := client.NewScopedAccessKey(ctx, testXPub, &AccessKeyScope{
+			MaxSatoshisPerDay: 2500,
+			Permissions:       []AccessKeyPermission{AccessKeyPermissionSend},
+		}, time.Time{})
+		require.NoError(t, err)
+
+		draft := newTestSpendingDraft(ctx, t, client, testSpendingAddress, 1000, WithAccessKeyID(accessKey.ID))
+		assert.Equal(t, DraftStatusDraft, draft.Status)
+		assert.Equal(t, accessKey.ID, draft.AccessKeyID)
+
+		draft = newTestSpendingDraft(ctx, t, client, testSpendingAddress, 1500, WithAccessKeyID(accessKey.ID))
+		assert.Equal(t, DraftStatusPendingApproval, draft.Status)
+	})
+
+	t.Run("per day limit (access key from the request)", func(t *testing.T) {
+		ctx, client, deferMe := initSimpleTestCase(t)
+		defer deferMe()
+		addTestSpendingUtxo(ctx, t, client, 1)
+
+		accessKey, err := client.NewScopedAccessKey(ctx, testXPub, &AccessKeyScope{
+			MaxSatoshisPerDay: 2500,
+			Permissions:       []AccessKeyPermission{AccessKeyPermissionSend},
+		}, time.Time{})
+		require.NoError(t, err)
+
+		// the access key that authenticated the request is on the context
+		ctx = context.WithValue(ctx, ParamAccessKeyID, accessKey.ID)
+
+		draft := newTestSpendingDraft(ctx, t, client, testSpendingAddress, 1000)
+		assert.Equal(t, DraftStatusDraft, draft.Status)
+		assert.Equal(t, accessKey.ID, draft.AccessKeyID)
+
+		draft = newTestSpendingDraft(ctx, t, client, testSpendingAddress, 1500)
+		assert.Equal(t, DraftStatusPendingApproval, draft.Status)
+	})
+
+	t.Run("own destinations are not spent", func(t *testing.T) {
+		ctx, client, deferMe := initSimpleTestCase(t, WithSpendingLimits(&SpendingLimits{
+			MaxNewRecipients:          1,
+			MaxSatoshisPerTransaction: 2000,
+		}))
+		defer deferMe()
+		addTestSpendingUtxo(ctx, t, client, 1)
+
+		// IE: the carry output of a batch payout
+		destination, err := client.NewDestination(
+			ctx, testXPub, utils.ChainInternal, utils.ScriptTypePubKeyHash, false,
+		)
+		require.NoError(t, err)
+
+		var draft *DraftTransaction
+		draft, err = client.NewTransaction(ctx, testXPub, &TransactionConfig{
+			Outputs: []*TransactionOutput{
+				{To: testSpendingAddress, Satoshis: 1000},
+				{To: destination.Address, Satoshis: 5000},
+			},
+			Sync: &SyncConfig{},
+		}, client.DefaultModelOptions()...)
+		require.NoError(t, err)
+		assert.Equal(t, DraftStatusDraft, draft.Status)
+
+		var recipients []string
+		recipients, err = draft.recipients(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []string{testSpendingAddress}, recipients)
+	})
+
+	t.Run("new recipients limit", func(t *testing.T) {
+		ctx, client, deferMe := initSimpleTestCase(t, WithSpendingLimits(&SpendingLimits{
+			MaxNewRecipients: 1,
+		}))
+		defer deferMe()
+		addTestSpendingUtxo(ctx, t, client, 1)
+
+		draft := newTestSpendingDraft(ctx, t, client, testSpendingAddress, 1000)
+		assert.Equal(t, DraftStatusDraft, draft.Status)
+
+		draft, err := client.NewTransaction(ctx, testXPub, &TransactionConfig{
+			Outputs: []*TransactionOutput{
+				{To: testSpendingAddress, Satoshis: 1000},
+				{To: "1A1PjKqjWMNBzTVdcBru27EV1PHcXWc63W", Satoshis: 1000},
+			},
+			Sync: &SyncConfig{},
+		}, client.DefaultModelOptions()...)
+		require.NoError(t, err)
+		assert.Equal(t, DraftStatusPendingApproval, draft.Status)
+	})
+
+	t.Run("recipients of completed drafts are known", func(t *testing.T) {
+		ctx, client, deferMe := initSimpleTestCase(t, WithSpendingLimits(&SpendingLimits{
+			MaxNewRecipients: 1,
+		}))
+		defer deferMe()
+		addTestSpendingUtxo(ctx, t, client, 1)
+
+		// Stored when the draft completes
+		draft := newTestSpendingDraft(ctx, t, client, testSpendingAddress, 1000)
+		assert.Positive(t, draft.SpentSatoshis)
+		require.NoError(t, draft.saveRecipients(ctx))
+		require.NoError(t, draft.saveRecipients(ctx))
+
+		draft, err := client.NewTransaction(ctx, testXPub, &TransactionConfig{
+			Outputs: []*TransactionOutput{
+				{To: testSpendingAddress, Satoshis: 1000},
+				{To: "1A1PjKqjWMNBzTVdcBru27EV1PHcXWc63W", Satoshis: 1000},
+			},
+			Sync: &SyncConfig{},
+		}, client.DefaultModelOptions()...)
+		require.NoError(t, err)
+		assert.Equal(t, DraftStatusDraft, draft.Status)
+
+		var newRecipients uint32
+		newRecipients, err = draft.countNewRecipients(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, uint32(1), newRecipients)
+	})
+}
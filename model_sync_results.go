@@ -6,12 +6,15 @@ import (
 	"encoding/json"
 	"fmt"
 	"time"
+
+	"github.com/BuxOrg/bux/chainstate"
 )
 
 // SyncResults is the results from all sync attempts (broadcast or sync)
 type SyncResults struct {
-	LastMessage string        `json:"last_message"` // Last message (success or failure)
-	Results     []*SyncResult `json:"results"`      // Each result of a sync task
+	BroadcastAttempts []*chainstate.BroadcastAttempt `json:"broadcast_attempts,omitempty"` // Response of each provider to the broadcasts
+	LastMessage       string                         `json:"last_message"`                 // Last message (success or failure)
+	Results           []*SyncResult                  `json:"results"`                      // Each result of a sync task
 }
 
 // Sync actions for syncing transactions
@@ -58,3 +61,11 @@ func (t SyncResults) Value() (driver.Value, error) {
 
 	return string(marshal), nil
 }
+
+// addBroadcastAttempts will add the attempts of a broadcast (keeping the last maxBroadcastAttempts)
+func (t *SyncResults) addBroadcastAttempts(attempts []*chainstate.BroadcastAttempt) {
+	t.BroadcastAttempts = append(t.BroadcastAttempts, attempts...)
+	if len(t.BroadcastAttempts) > maxBroadcastAttempts {
+		t.BroadcastAttempts = t.BroadcastAttempts[len(t.BroadcastAttempts)-maxBroadcastAttempts:]
+	}
+}
@@ -534,14 +534,30 @@ func processBroadcastTransaction(ctx context.Context, syncTx *SyncTransaction) e
 		}
 	}
 
-	// Broadcast
+	// Broadcast (keep the response of each provider, the late responses are saved when they arrive)
 	var provider string
-	if provider, err = syncTx.Client().Chainstate().Broadcast(
-		ctx, syncTx.ID, txHex, defaultBroadcastTimeout,
-	); err != nil {
+	var attempts []*chainstate.BroadcastAttempt
+	provider, attempts, err = syncTx.Client().Chainstate().BroadcastWithAttempts(
+		ctx, syncTx.ID, txHex, defaultBroadcastTimeout, lateBroadcastAttemptsHandler(syncTx),
+	)
+	syncTx.Results.addBroadcastAttempts(attempts)
+	if err != nil {
+		// Lost a double-spend, rollback the transaction (only our own recorded transactions)
+		message := "broadcast error: " + err.Error()
+		if chainstate.IsConflictError(err) && transaction != nil {
+			conflictErr := processBroadcastConflict(ctx, syncTx, transaction, err)
+			if conflictErr == nil {
+				return nil
+			}
+			syncTx.Client().Logger().Error(ctx, "error reverting transaction "+syncTx.ID+": "+conflictErr.Error())
+			message += ", revert error: " + conflictErr.Error()
+		}
 		bailAndSaveSyncTransaction(
-			ctx, syncTx, SyncStatusError, syncActionBroadcast, provider, "broadcast error: "+err.Error(),
+			ctx, syncTx, SyncStatusError, syncActionBroadcast, provider, message,
 		)
+
+		// Fire a notification (with the response of each provider)
+		notify(notifications.EventTypeBroadcastFailed, syncTx)
 		return nil //nolint:nolintlint,nilerr // error is not needed
 	}
 
@@ -617,6 +633,44 @@ func processBroadcastTransaction(ctx context.Context, syncTx *SyncTransaction) e
 	return nil
 }
 
+// lateBroadcastAttemptsHandler will return the handler saving the responses of the providers that responded after
+// the first successful broadcast onto the sync transaction
+//
+// The handler runs in the broadcast goroutine, after the broadcast is processed (the sync transaction is reloaded)
+func lateBroadcastAttemptsHandler(syncTx *SyncTransaction) chainstate.BroadcastAttemptsHandler {
+	return func(attempts []*chainstate.BroadcastAttempt) {
+		ctx := context.Background()
+		if err := saveLateBroadcastAttempts(ctx, syncTx, attempts); err != nil {
+			syncTx.Client().Logger().Error(ctx,
+				"error saving the late broadcast attempts of tx "+syncTx.ID+": "+err.Error(),
+			)
+		}
+	}
+}
+
+// saveLateBroadcastAttempts will add the broadcast attempts to the (saved) sync transaction
+func saveLateBroadcastAttempts(ctx context.Context, syncTx *SyncTransaction,
+	attempts []*chainstate.BroadcastAttempt) error {
+
+	// Wait for the broadcast to be processed (same lock)
+	unlock, err := newWaitWriteLock(
+		ctx, fmt.Sprintf(lockKeyProcessBroadcastTx, syncTx.GetID()), syncTx.Client().Cachestore(),
+	)
+	defer unlock()
+	if err != nil {
+		return err
+	}
+
+	var saved *SyncTransaction
+	if saved, err = GetSyncTransactionByID(ctx, syncTx.ID, syncTx.GetOptions(false)...); err != nil {
+		return err
+	} else if saved == nil {
+		return ErrMissingTransaction
+	}
+	saved.Results.addBroadcastAttempts(attempts)
+	return saved.Save(ctx)
+}
+
 // processSyncTransaction will process the sync transaction record, or save the failure
 func processSyncTransaction(ctx context.Context, syncTx *SyncTransaction, transaction *Transaction) error {
 	// Successfully capture any panics, convert to readable string and log the error
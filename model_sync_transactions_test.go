@@ -3,7 +3,9 @@ package bux
 import (
 	"fmt"
 	"testing"
+	"time"
 
+	"github.com/BuxOrg/bux/chainstate"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -24,6 +26,74 @@ func TestSyncTransaction_GetModelName(t *testing.T) {
 	})
 }
 
+// TestSyncResults_addBroadcastAttempts will test the method addBroadcastAttempts()
+func TestSyncResults_addBroadcastAttempts(t *testing.T) {
+	t.Parallel()
+
+	t.Run("keeps the last attempts", func(t *testing.T) {
+		results := &SyncResults{}
+		for i := 0; i < maxBroadcastAttempts+5; i++ {
+			results.addBroadcastAttempts([]*chainstate.BroadcastAttempt{{
+				Outcome:  chainstate.BroadcastOutcomeError,
+				Provider: fmt.Sprintf("miner-%d", i),
+			}})
+		}
+		require.Len(t, results.BroadcastAttempts, maxBroadcastAttempts)
+		assert.Equal(t, "miner-5", results.BroadcastAttempts[0].Provider)
+		assert.Equal(t, fmt.Sprintf("miner-%d", maxBroadcastAttempts+4), results.BroadcastAttempts[maxBroadcastAttempts-1].Provider)
+	})
+
+	t.Run("stored as json", func(t *testing.T) {
+		results := SyncResults{}
+		results.addBroadcastAttempts([]*chainstate.BroadcastAttempt{{
+			MinerID:      "03ad780153c47df915b3d2e23af727c68facaca4facd5f155bf5018b979b9aeb83",
+			Outcome:      chainstate.BroadcastOutcomeFeeTooLow,
+			Provider:     "Taal",
+			ResponseCode: "failure",
+		}})
+		value, err := results.Value()
+		require.NoError(t, err)
+
+		scanned := SyncResults{}
+		require.NoError(t, scanned.Scan(value))
+		require.Len(t, scanned.BroadcastAttempts, 1)
+		assert.Equal(t, *results.BroadcastAttempts[0], *scanned.BroadcastAttempts[0])
+	})
+}
+
+// Test_processBroadcastTransaction will test the method processBroadcastTransaction()
+func Test_processBroadcastTransaction(t *testing.T) {
+	t.Run("late broadcast attempts are saved", func(t *testing.T) {
+		ctx, client, transaction, _, deferMe := initRevertTransactionData(
+			t, WithCustomChainstate(&chainStateLateAttempts{}),
+		)
+		defer deferMe()
+
+		syncTx, err := GetSyncTransactionByID(ctx, transaction.ID, client.DefaultModelOptions()...)
+		require.NoError(t, err)
+		require.NotNil(t, syncTx)
+
+		require.NoError(t, processBroadcastTransaction(ctx, syncTx))
+
+		assert.Eventually(t, func() bool {
+			attempts, getErr := client.GetTransactionBroadcastAttempts(ctx, transaction.ID)
+			return getErr == nil && len(attempts) == 2
+		}, 5*time.Second, 10*time.Millisecond)
+
+		attempts, err := client.GetTransactionBroadcastAttempts(ctx, transaction.ID)
+		require.NoError(t, err)
+		require.Len(t, attempts, 2)
+		assert.Equal(t, chainstate.ProviderWhatsOnChain, attempts[0].Provider)
+		assert.Equal(t, chainstate.ProviderNowNodes, attempts[1].Provider)
+		assert.Equal(t, chainstate.BroadcastOutcomeError, attempts[1].Outcome)
+
+		// the broadcast result was kept
+		syncTx, err = GetSyncTransactionByID(ctx, transaction.ID, client.DefaultModelOptions()...)
+		require.NoError(t, err)
+		assert.Equal(t, SyncStatusComplete, syncTx.BroadcastStatus)
+	})
+}
+
 func Test_areParentsBroadcast(t *testing.T) {
 	ctx, client, deferMe := CreateTestSQLiteClient(t, false, true, WithCustomTaskManager(&taskManagerMockBase{}))
 	defer deferMe()
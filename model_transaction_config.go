@@ -39,6 +39,10 @@ type TransactionConfig struct {
 }
 
 // TransactionInput is an input on the transaction config
+//
+// The key of the input is derived from the xPub using the chain/num of the destination, unless the destination
+// has a derivation path (imported destinations, chain is utils.ChainImported): then the key is derived
+// using the derivation path (IE: "2/7/1" is xpub/2/7/1)
 type TransactionInput struct {
 	Utxo
 	Destination Destination `json:"destination" toml:"destination" yaml:"destination" bson:"destination"`
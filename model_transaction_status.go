@@ -20,6 +20,12 @@ const (
 
 	// DraftStatusComplete is when the draft transaction is complete
 	DraftStatusComplete DraftStatus = statusComplete
+
+	// DraftStatusPendingApproval is when the draft exceeds a spending limit (or threshold) and requires approval
+	DraftStatusPendingApproval DraftStatus = "pending_approval"
+
+	// DraftStatusRejected is when the draft was rejected by an approver
+	DraftStatusRejected DraftStatus = "rejected"
 )
 
 // Scan will scan the value into Struct, implements sql.Scanner interface
@@ -41,6 +47,10 @@ func (t *DraftStatus) Scan(value interface{}) error {
 		*t = DraftStatusExpired
 	case statusComplete:
 		*t = DraftStatusComplete
+	case string(DraftStatusPendingApproval):
+		*t = DraftStatusPendingApproval
+	case string(DraftStatusRejected):
+		*t = DraftStatusRejected
 	}
 
 	return nil
@@ -50,3 +60,44 @@ func (t *DraftStatus) Scan(value interface{}) error {
 func (t DraftStatus) Value() (driver.Value, error) {
 	return string(t), nil
 }
+
+// ConfirmationStatus transaction confirmation status
+type ConfirmationStatus string
+
+const (
+	// ConfirmationStatusMempool is when the transaction is not (yet) mined
+	ConfirmationStatusMempool ConfirmationStatus = statusMempool
+
+	// ConfirmationStatusMined is when the transaction is mined, but not final
+	ConfirmationStatusMined ConfirmationStatus = statusMined
+
+	// ConfirmationStatusFinal is when the transaction has reached the finality confirmations
+	ConfirmationStatusFinal ConfirmationStatus = statusFinal
+)
+
+// Scan will scan the value into Struct, implements sql.Scanner interface
+func (t *ConfirmationStatus) Scan(value interface{}) error {
+	xType := fmt.Sprintf("%T", value)
+	var stringValue string
+	if xType == ValueTypeString {
+		stringValue = value.(string)
+	} else {
+		stringValue = string(value.([]byte))
+	}
+
+	switch stringValue {
+	case statusMempool:
+		*t = ConfirmationStatusMempool
+	case statusMined:
+		*t = ConfirmationStatusMined
+	case statusFinal:
+		*t = ConfirmationStatusFinal
+	}
+
+	return nil
+}
+
+// Value return json value, implement driver.Valuer interface
+func (t ConfirmationStatus) Value() (driver.Value, error) {
+	return string(t), nil
+}
@@ -47,23 +47,25 @@ type Transaction struct {
 	TransactionBase `bson:",inline"`
 
 	// Model specific fields
-	XpubInIDs       IDs             `json:"xpub_in_ids,omitempty" toml:"xpub_in_ids" yaml:"xpub_in_ids" gorm:"<-;type:json" bson:"xpub_in_ids,omitempty"`
-	XpubOutIDs      IDs             `json:"xpub_out_ids,omitempty" toml:"xpub_out_ids" yaml:"xpub_out_ids" gorm:"<-;type:json" bson:"xpub_out_ids,omitempty"`
-	BlockHash       string          `json:"block_hash" toml:"block_hash" yaml:"block_hash" gorm:"<-;type:char(64);comment:This is the related block when the transaction was mined" bson:"block_hash,omitempty"`
-	BlockHeight     uint64          `json:"block_height" toml:"block_height" yaml:"block_height" gorm:"<-;type:bigint;comment:This is the related block when the transaction was mined" bson:"block_height,omitempty"`
-	Fee             uint64          `json:"fee" toml:"fee" yaml:"fee" gorm:"<-create;type:bigint" bson:"fee,omitempty"`
-	NumberOfInputs  uint32          `json:"number_of_inputs" toml:"number_of_inputs" yaml:"number_of_inputs" gorm:"<-;type:int" bson:"number_of_inputs,omitempty"`
-	NumberOfOutputs uint32          `json:"number_of_outputs" toml:"number_of_outputs" yaml:"number_of_outputs" gorm:"<-;type:int" bson:"number_of_outputs,omitempty"`
-	DraftID         string          `json:"draft_id" toml:"draft_id" yaml:"draft_id" gorm:"<-create;type:varchar(64);index;comment:This is the related draft id" bson:"draft_id,omitempty"`
-	TotalValue      uint64          `json:"total_value" toml:"total_value" yaml:"total_value" gorm:"<-create;type:bigint" bson:"total_value,omitempty"`
-	XpubMetadata    XpubMetadata    `json:"-" toml:"xpub_metadata" gorm:"<-;type:json;xpub_id specific metadata" bson:"xpub_metadata,omitempty"`
-	XpubOutputValue XpubOutputValue `json:"-" toml:"xpub_output_value" gorm:"<-;type:json;xpub_id specific value" bson:"xpub_output_value,omitempty"`
+	XpubInIDs            IDs                `json:"xpub_in_ids,omitempty" toml:"xpub_in_ids" yaml:"xpub_in_ids" gorm:"<-;type:json" bson:"xpub_in_ids,omitempty"`
+	XpubOutIDs           IDs                `json:"xpub_out_ids,omitempty" toml:"xpub_out_ids" yaml:"xpub_out_ids" gorm:"<-;type:json" bson:"xpub_out_ids,omitempty"`
+	BlockHash            string             `json:"block_hash" toml:"block_hash" yaml:"block_hash" gorm:"<-;type:char(64);comment:This is the related block when the transaction was mined" bson:"block_hash,omitempty"`
+	BlockHeight          uint64             `json:"block_height" toml:"block_height" yaml:"block_height" gorm:"<-;type:bigint;comment:This is the related block when the transaction was mined" bson:"block_height,omitempty"`
+	Fee                  uint64             `json:"fee" toml:"fee" yaml:"fee" gorm:"<-create;type:bigint" bson:"fee,omitempty"`
+	NumberOfInputs       uint32             `json:"number_of_inputs" toml:"number_of_inputs" yaml:"number_of_inputs" gorm:"<-;type:int" bson:"number_of_inputs,omitempty"`
+	NumberOfOutputs      uint32             `json:"number_of_outputs" toml:"number_of_outputs" yaml:"number_of_outputs" gorm:"<-;type:int" bson:"number_of_outputs,omitempty"`
+	DraftID              string             `json:"draft_id" toml:"draft_id" yaml:"draft_id" gorm:"<-create;type:varchar(64);index;comment:This is the related draft id" bson:"draft_id,omitempty"`
+	TotalValue           uint64             `json:"total_value" toml:"total_value" yaml:"total_value" gorm:"<-create;type:bigint" bson:"total_value,omitempty"`
+	ConfirmationStatus   ConfirmationStatus `json:"confirmation_status" toml:"confirmation_status" yaml:"confirmation_status" gorm:"<-;type:varchar(10);index;comment:This is the confirmation status (mempool, mined, final)" bson:"confirmation_status,omitempty"`
+	UnconfirmedAncestors uint32             `json:"unconfirmed_ancestors" toml:"unconfirmed_ancestors" yaml:"unconfirmed_ancestors" gorm:"<-;type:int;comment:This is the depth of the unconfirmed ancestor chain" bson:"unconfirmed_ancestors,omitempty"`
+	XpubMetadata         XpubMetadata       `json:"-" toml:"xpub_metadata" gorm:"<-;type:json;xpub_id specific metadata" bson:"xpub_metadata,omitempty"`
+	XpubOutputValue      XpubOutputValue    `json:"-" toml:"xpub_output_value" gorm:"<-;type:json;xpub_id specific value" bson:"xpub_output_value,omitempty"`
 
 	// Virtual Fields
-	OutputValue int64                `json:"output_value" toml:"-" yaml:"-" gorm:"-" bson:"-,omitempty"`
-	Status      SyncStatus           `json:"status" toml:"-" yaml:"-" gorm:"-" bson:"-"`
-	Direction   TransactionDirection `json:"direction" toml:"-" yaml:"-" gorm:"-" bson:"-"`
-	// Confirmations  uint64       `json:"-" toml:"-" yaml:"-" gorm:"-" bson:"-"`
+	OutputValue   int64                `json:"output_value" toml:"-" yaml:"-" gorm:"-" bson:"-,omitempty"`
+	Status        SyncStatus           `json:"status" toml:"-" yaml:"-" gorm:"-" bson:"-"`
+	Direction     TransactionDirection `json:"direction" toml:"-" yaml:"-" gorm:"-" bson:"-"`
+	Confirmations uint64               `json:"confirmations" toml:"-" yaml:"-" gorm:"-" bson:"-"`
 
 	// Private for internal use
 	draftTransaction   *DraftTransaction    `gorm:"-" bson:"-"` // Related draft transaction for processing and recording
@@ -72,6 +74,8 @@ type Transaction struct {
 	utxos              []Utxo               `gorm:"-" bson:"-"` // json:"destinations,omitempty"
 	XPubID             string               `gorm:"-" bson:"-"` // XPub of the user registering this transaction
 	beforeCreateCalled bool                 `gorm:"-" bson:"-"` // Private information that the transaction lifecycle method BeforeCreate was already called
+	statusChanged      bool                 `gorm:"-" bson:"-"` // Private information that the confirmation status was changed (for notifications)
+	depthChanged       bool                 `gorm:"-" bson:"-"` // Private information that the transaction was mined (or reset), the descendants need a new depth
 }
 
 // newTransactionBase creates the standard transaction model base
@@ -158,6 +162,21 @@ func (m *Transaction) setXPubID() {
 	}
 }
 
+// isSharedWithOtherXpubs will return true if any other xPub (than the given xPub) is part of the transaction
+func (m *Transaction) isSharedWithOtherXpubs(xPubID string) bool {
+	for _, id := range append(append([]string{}, m.XpubInIDs...), m.XpubOutIDs...) {
+		if id != xPubID {
+			return true
+		}
+	}
+	for id := range m.XpubOutputValue {
+		if id != xPubID {
+			return true
+		}
+	}
+	return false
+}
+
 // getTransactions will get all the transactions with the given conditions
 func getTransactions(ctx context.Context, metadata *Metadata, conditions *map[string]interface{},
 	queryParams *datastore.QueryParams, opts ...ModelOps,
@@ -486,6 +505,11 @@ func (m *Transaction) BeforeCreating(ctx context.Context) error {
 	// Validations and broadcast config check
 	if m.draftTransaction != nil {
 
+		// Re-evaluate the spending limits (other drafts might have been recorded since)
+		if err = m.draftTransaction.checkRecordSpendingLimits(ctx); err != nil {
+			return err
+		}
+
 		// No config set? Use the default from the client
 		if m.draftTransaction.Configuration.Sync == nil {
 			m.draftTransaction.Configuration.Sync = m.Client().DefaultSyncConfig()
@@ -543,6 +567,11 @@ func (m *Transaction) BeforeCreating(ctx context.Context) error {
 		m.NumberOfOutputs = uint32(len(m.TransactionBase.parsedTx.Outputs))
 	}
 
+	// Set the confirmation status
+	if _, err = m.updateConfirmationStatus(ctx); err != nil {
+		return err
+	}
+
 	m.DebugLog("end: " + m.Name() + " BeforeCreating hook")
 	m.beforeCreateCalled = true
 	return nil
@@ -577,6 +606,11 @@ func (m *Transaction) AfterCreated(ctx context.Context) error {
 		if err := m.draftTransaction.Save(ctx); err != nil {
 			return err
 		}
+
+		// Store the recipients (for the new recipients limit of the next drafts)
+		if err := m.draftTransaction.saveRecipients(ctx); err != nil {
+			return err
+		}
 	}
 
 	// Fire notifications (this is already in a go routine)
@@ -586,13 +620,61 @@ func (m *Transaction) AfterCreated(ctx context.Context) error {
 	return nil
 }
 
+// BeforeUpdating will fire before the model is being updated in the Datastore
+func (m *Transaction) BeforeUpdating(ctx context.Context) error {
+	m.DebugLog("starting: " + m.Name() + " BeforeUpdating hook...")
+
+	// Once mined, the transaction no longer has any unconfirmed ancestors
+	wasMined := m.ConfirmationStatus == ConfirmationStatusMined || m.ConfirmationStatus == ConfirmationStatusFinal
+	if m.BlockHeight > 0 {
+		m.UnconfirmedAncestors = 0
+	}
+
+	// Update the confirmation status (mempool -> mined -> final)
+	var err error
+	if m.statusChanged, err = m.updateConfirmationStatus(ctx); err != nil {
+		return err
+	}
+
+	// Mined (or reset by a reorg), the depth of the unconfirmed chain of the descendants has changed
+	if m.depthChanged = m.statusChanged && wasMined != (m.BlockHeight > 0); m.depthChanged && m.BlockHeight == 0 {
+		if m.UnconfirmedAncestors, err = getUnconfirmedAncestors(ctx, m.ID, m.GetOptions(false)...); err != nil {
+			return err
+		}
+	}
+
+	m.DebugLog("end: " + m.Name() + " BeforeUpdating hook")
+	return nil
+}
+
 // AfterUpdated will fire after the model is updated in the Datastore
-func (m *Transaction) AfterUpdated(_ context.Context) error {
+func (m *Transaction) AfterUpdated(ctx context.Context) error {
 	m.DebugLog("starting: " + m.Name() + " AfterUpdated hook...")
 
+	// Update the unconfirmed depth of the utxos of the descendants
+	if m.depthChanged {
+		m.depthChanged = false
+		if err := m.updateUnconfirmedDepths(ctx); err != nil {
+			return err
+		}
+	}
+
 	// Fire notifications (this is already in a go routine)
 	notify(notifications.EventTypeUpdate, m)
 
+	// Fire the confirmation status notifications
+	if m.statusChanged {
+		m.statusChanged = false
+		switch m.ConfirmationStatus {
+		case ConfirmationStatusMempool:
+			notify(notifications.EventTypeMempool, m)
+		case ConfirmationStatusMined:
+			notify(notifications.EventTypeMined, m)
+		case ConfirmationStatusFinal:
+			notify(notifications.EventTypeFinal, m)
+		}
+	}
+
 	m.DebugLog("end: " + m.Name() + " AfterUpdated hook")
 	return nil
 }
@@ -674,6 +756,7 @@ func (m *Transaction) processOutputs(ctx context.Context) (err error) {
 						destination.XpubID, m.ID, txLockingScript, uint32(index),
 						amount, newOpts...,
 					)
+					utxo.UnconfirmedDepth = unconfirmedDepth(m)
 				}
 				// Append the UTXO model
 				m.utxos = append(m.utxos, *utxo)
@@ -735,6 +818,11 @@ func (m *Transaction) processInputs(ctx context.Context) (err error) {
 				}
 			}
 
+			// Track the depth of the unconfirmed ancestor chain
+			if utxo.UnconfirmedDepth > m.UnconfirmedAncestors {
+				m.UnconfirmedAncestors = utxo.UnconfirmedDepth
+			}
+
 			// Update the output value
 			if _, ok := m.XpubOutputValue[utxo.XpubID]; !ok {
 				m.XpubOutputValue[utxo.XpubID] = 0
@@ -755,9 +843,103 @@ func (m *Transaction) processInputs(ctx context.Context) (err error) {
 		// todo: what if the utxo is nil (not found)?
 	}
 
+	// Only enforce the chain depth on our own (drafted) transactions
+	if m.draftTransaction != nil && client != nil {
+		var maxDepth uint32
+		if maxDepth, err = getMaxUnconfirmedAncestors(
+			ctx, m.draftTransaction.XpubID, m.GetOptions(false)...,
+		); err != nil {
+			return err
+		} else if maxDepth > 0 && m.UnconfirmedAncestors > maxDepth {
+			return ErrTooManyUnconfirmedAncestors
+		}
+	}
+
 	return
 }
 
+// unconfirmedDepth will return the unconfirmed ancestor depth of a transaction spending an output of the parent
+func unconfirmedDepth(parent *Transaction) uint32 {
+	if parent == nil || parent.BlockHeight > 0 {
+		return 0
+	}
+	return parent.UnconfirmedAncestors + 1
+}
+
+// getUnconfirmedAncestors will return the unconfirmed ancestor depth of the transaction (from the utxos it spends)
+func getUnconfirmedAncestors(ctx context.Context, txID string, opts ...ModelOps) (uint32, error) {
+	utxos, err := getUtxosByConditions(ctx, map[string]interface{}{
+		spendingTxIDField: txID,
+	}, nil, opts...)
+	if err != nil {
+		return 0, err
+	}
+
+	depth := uint32(0)
+	for _, utxo := range utxos {
+		if utxo.UnconfirmedDepth > depth {
+			depth = utxo.UnconfirmedDepth
+		}
+	}
+	return depth, nil
+}
+
+// updateUnconfirmedDepths will update the unconfirmed depth of the utxos of the transaction,
+// and the unconfirmed ancestors (and utxos) of all the unconfirmed descendants spending them
+func (m *Transaction) updateUnconfirmedDepths(ctx context.Context) error {
+	opts := m.GetOptions(false)
+	queue := []*Transaction{m}
+	for len(queue) > 0 {
+		transaction := queue[0]
+		queue = queue[1:]
+
+		utxos, err := getUtxosByConditions(ctx, map[string]interface{}{
+			"transaction_id": transaction.ID,
+		}, nil, opts...)
+		if err != nil {
+			return err
+		}
+
+		depth := unconfirmedDepth(transaction)
+		children := make([]string, 0)
+		for _, utxo := range utxos {
+			if utxo.UnconfirmedDepth != depth {
+				utxo.UnconfirmedDepth = depth
+				if err = utxo.Save(ctx); err != nil {
+					return err
+				}
+			}
+			if utxo.SpendingTxID.Valid && !utils.StringInSlice(utxo.SpendingTxID.String, children) {
+				children = append(children, utxo.SpendingTxID.String)
+			}
+		}
+
+		// Only the unconfirmed children with a changed depth need to be updated (and their children)
+		for _, childID := range children {
+			var child *Transaction
+			if child, err = getTransactionByID(ctx, "", childID, opts...); err != nil {
+				return err
+			} else if child == nil || child.BlockHeight > 0 {
+				continue
+			}
+
+			var ancestors uint32
+			if ancestors, err = getUnconfirmedAncestors(ctx, child.ID, opts...); err != nil {
+				return err
+			} else if ancestors == child.UnconfirmedAncestors {
+				continue
+			}
+			child.UnconfirmedAncestors = ancestors
+			if err = child.Save(ctx); err != nil {
+				return err
+			}
+			queue = append(queue, child)
+		}
+	}
+
+	return nil
+}
+
 // IsXpubAssociated will check if this key is associated to this transaction
 func (m *Transaction) IsXpubAssociated(rawXpubKey string) bool {
 	// Hash the raw key
@@ -819,6 +1001,61 @@ func (m *Transaction) Display() interface{} {
 	return m
 }
 
+// encryptSensitiveFields will encrypt the sensitive metadata values (including the xPub specific metadata),
+// and re-encrypt the values that are not encrypted using the active key (rotation)
+//
+// Returns true if any of the values changed
+func (m *Transaction) encryptSensitiveFields() (bool, error) {
+	changed, err := m.Model.encryptSensitiveFields()
+	if err != nil {
+		return false, err
+	}
+
+	provider := m.getEncryption()
+	if provider == nil || len(m.XpubMetadata) == 0 {
+		return changed, nil
+	}
+
+	// Copy the xPub metadata on change (the map can be shared with other models)
+	var xPubMetadata XpubMetadata
+	for xPubID, metadata := range m.XpubMetadata {
+		var encrypted Metadata
+		if encrypted, err = encryptMetadata(provider, metadata, m.getSensitiveMetadataKeys()); err != nil {
+			return false, err
+		} else if encrypted == nil {
+			continue
+		}
+		if xPubMetadata == nil {
+			xPubMetadata = make(XpubMetadata, len(m.XpubMetadata))
+			for id, meta := range m.XpubMetadata {
+				xPubMetadata[id] = meta
+			}
+		}
+		xPubMetadata[xPubID] = encrypted
+	}
+
+	if xPubMetadata == nil {
+		return changed, nil
+	}
+	m.XpubMetadata = xPubMetadata
+	return true, nil
+}
+
+// getEncryptedFields will get the (stored) values of the columns that are encrypted at rest
+func (m *Transaction) getEncryptedFields() map[string]interface{} {
+	fields := m.Model.getEncryptedFields()
+	fields[xPubMetadataField] = m.XpubMetadata
+	return fields
+}
+
+// getAuditFields will get the stored fields that are not in the json of the transaction (audit log)
+func (m *Transaction) getAuditFields() map[string]interface{} {
+	return map[string]interface{}{
+		xPubMetadataField:    m.XpubMetadata,
+		xPubOutputValueField: m.XpubOutputValue,
+	}
+}
+
 // Migrate model specific migration on startup
 func (m *Transaction) Migrate(client datastore.ClientInterface) error {
 	tableName := client.GetTableName(tableTransactions)
@@ -832,9 +1069,36 @@ func (m *Transaction) Migrate(client datastore.ClientInterface) error {
 		}
 	}
 
+	if err := m.migrateConfirmationStatus(client, tableName); err != nil {
+		return err
+	}
+
 	return client.IndexMetadata(tableName, xPubMetadataField)
 }
 
+// migrateConfirmationStatus will set the confirmation status of the transactions stored before the status was tracked
+//
+// Mined transactions are promoted to final on the next change of the chain tip
+func (m *Transaction) migrateConfirmationStatus(client datastore.ClientInterface, tableName string) error {
+	if !datastore.IsSQLEngine(client.Engine()) {
+		return nil
+	}
+
+	quote := `"`
+	if client.Engine() == datastore.MySQL {
+		quote = "`"
+	}
+	unset := `(confirmation_status IS NULL OR confirmation_status = '')`
+	tx := client.Execute(`UPDATE ` + quote + tableName + quote + ` SET confirmation_status = '` +
+		string(ConfirmationStatusMined) + `' WHERE ` + unset + ` AND block_height > 0`)
+	if tx.Error != nil {
+		return tx.Error
+	}
+	tx = client.Execute(`UPDATE ` + quote + tableName + quote + ` SET confirmation_status = '` +
+		string(ConfirmationStatusMempool) + `' WHERE ` + unset)
+	return tx.Error
+}
+
 // migratePostgreSQL is specific migration SQL for Postgresql
 func (m *Transaction) migratePostgreSQL(client datastore.ClientInterface, tableName string) error {
 	tx := client.Execute(`CREATE INDEX IF NOT EXISTS idx_` + tableName + `_xpub_in_ids ON ` +
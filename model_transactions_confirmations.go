@@ -0,0 +1,171 @@
+package bux
+
+import (
+	"context"
+
+	"github.com/mrz1836/go-datastore"
+)
+
+// getChainTipHeight will return the height of the latest (non-orphaned) block header (0 if not found)
+func getChainTipHeight(ctx context.Context, opts ...ModelOps) (uint64, error) {
+	blockHeader, err := getLastBlockHeader(ctx, opts...)
+	if err != nil {
+		return 0, err
+	} else if blockHeader == nil {
+		return 0, nil
+	}
+	return uint64(blockHeader.Height), nil
+}
+
+// setConfirmations will set the number of confirmations given the height of the chain tip
+func (m *Transaction) setConfirmations(tipHeight uint64) {
+	if m.BlockHeight == 0 {
+		m.Confirmations = 0
+	} else if tipHeight < m.BlockHeight {
+		// the block headers are behind, the transaction is at least in a block
+		m.Confirmations = 1
+	} else {
+		m.Confirmations = tipHeight - m.BlockHeight + 1
+	}
+}
+
+// updateConfirmationStatus will set the confirmations and the confirmation status (mempool -> mined -> final)
+//
+// Returns true if the confirmation status was changed
+func (m *Transaction) updateConfirmationStatus(ctx context.Context) (bool, error) {
+	previousStatus := m.ConfirmationStatus
+
+	if m.BlockHeight == 0 {
+		m.Confirmations = 0
+		m.ConfirmationStatus = ConfirmationStatusMempool
+	} else {
+		finality := defaultFinalityConfirmations
+		tipHeight := uint64(0)
+		if c := m.Client(); c != nil {
+			finality = c.FinalityConfirmations()
+
+			var err error
+			if tipHeight, err = getChainTipHeight(ctx, m.GetOptions(false)...); err != nil {
+				return false, err
+			}
+		}
+		m.setConfirmations(tipHeight)
+
+		if m.Confirmations >= finality {
+			m.ConfirmationStatus = ConfirmationStatusFinal
+		} else {
+			m.ConfirmationStatus = ConfirmationStatusMined
+		}
+	}
+
+	return previousStatus != m.ConfirmationStatus, nil
+}
+
+// setTransactionsConfirmations will set the confirmations on all the given transactions
+func setTransactionsConfirmations(ctx context.Context, transactions []*Transaction, opts ...ModelOps) error {
+	if len(transactions) == 0 {
+		return nil
+	}
+
+	tipHeight, err := getChainTipHeight(ctx, opts...)
+	if err != nil {
+		return err
+	}
+	for _, transaction := range transactions {
+		transaction.setConfirmations(tipHeight)
+	}
+	return nil
+}
+
+// unconfirmedConditions will return the db conditions for transactions with fewer than the given confirmations
+//
+// Returns nil if all transactions have fewer confirmations
+func unconfirmedConditions(tipHeight, confirmations uint64) map[string]interface{} {
+	if tipHeight+1 <= confirmations {
+		return nil
+	}
+
+	// confirmations = tip - block height + 1
+	return map[string]interface{}{
+		"$or": []map[string]interface{}{{
+			blockHeightField: 0,
+		}, {
+			blockHeightField: nil,
+		}, {
+			blockHeightField: map[string]interface{}{
+				"$gt": tipHeight + 1 - confirmations,
+			},
+		}},
+	}
+}
+
+// getUnconfirmedTransactionsByXpubID will get all the transactions for a given xpub ID
+// with fewer than the given confirmations (none with fewer than 0 confirmations)
+func getUnconfirmedTransactionsByXpubID(ctx context.Context, xPubID string, confirmations uint64,
+	metadata *Metadata, conditions *map[string]interface{},
+	queryParams *datastore.QueryParams, opts ...ModelOps,
+) ([]*Transaction, error) {
+	if confirmations == 0 {
+		return []*Transaction{}, nil
+	}
+
+	tipHeight, err := getChainTipHeight(ctx, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	// Add the confirmation conditions to the given conditions
+	dbConditions := make(map[string]interface{})
+	if conditions != nil {
+		for key, value := range *conditions {
+			dbConditions[key] = value
+		}
+	}
+	if unconfirmed := unconfirmedConditions(tipHeight, confirmations); unconfirmed != nil {
+		and := make([]map[string]interface{}, 0)
+		if _, ok := dbConditions["$and"]; ok {
+			and = dbConditions["$and"].([]map[string]interface{})
+		}
+		dbConditions["$and"] = append(and, unconfirmed)
+	}
+
+	var transactions []*Transaction
+	if transactions, err = getTransactionsByXpubID(
+		ctx, xPubID, metadata, &dbConditions, queryParams, opts...,
+	); err != nil {
+		return nil, err
+	}
+
+	for _, transaction := range transactions {
+		transaction.setConfirmations(tipHeight)
+	}
+	return transactions, nil
+}
+
+// processFinalTransactions will update all the mined transactions that reached the finality confirmations
+func processFinalTransactions(ctx context.Context, finality uint64, opts ...ModelOps) error {
+	tipHeight, err := getChainTipHeight(ctx, opts...)
+	if err != nil {
+		return err
+	} else if tipHeight+1 < finality {
+		return nil
+	}
+
+	var transactions []*Transaction
+	if transactions, err = getTransactionsInternal(ctx, map[string]interface{}{
+		"confirmation_status": ConfirmationStatusMined,
+		blockHeightField: map[string]interface{}{
+			"$lte": tipHeight + 1 - finality,
+		},
+	}, "", nil, opts...); err != nil {
+		return err
+	}
+
+	// Saving will update the confirmation status (and fire notifications)
+	for _, transaction := range transactions {
+		if err = transaction.Save(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,221 @@
+package bux
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestTransaction_setConfirmations will test the method setConfirmations()
+func TestTransaction_setConfirmations(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name          string
+		blockHeight   uint64
+		tipHeight     uint64
+		confirmations uint64
+	}{
+		{"mempool", 0, 100, 0},
+		{"tip block", 100, 100, 1},
+		{"six blocks deep", 95, 100, 6},
+		{"block headers behind", 101, 100, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transaction := newTransaction(testTxHex, New())
+			transaction.BlockHeight = tt.blockHeight
+			transaction.setConfirmations(tt.tipHeight)
+			assert.Equal(t, tt.confirmations, transaction.Confirmations)
+		})
+	}
+}
+
+// TestTransaction_updateConfirmationStatus will test the method updateConfirmationStatus()
+func TestTransaction_updateConfirmationStatus(t *testing.T) {
+
+	t.Run("mempool", func(t *testing.T) {
+		transaction := newTransaction(testTxHex, New())
+		changed, err := transaction.updateConfirmationStatus(context.Background())
+		require.NoError(t, err)
+		assert.True(t, changed)
+		assert.Equal(t, ConfirmationStatusMempool, transaction.ConfirmationStatus)
+
+		changed, err = transaction.updateConfirmationStatus(context.Background())
+		require.NoError(t, err)
+		assert.False(t, changed)
+	})
+
+	t.Run("mined -> final -> mempool", func(t *testing.T) {
+		ctx, client, deferMe := CreateTestSQLiteClient(
+			t, false, true, WithCustomTaskManager(&taskManagerMockBase{}), WithFinalityConfirmations(3),
+			WithBlockHeaderValidationDisabled(),
+		)
+		defer deferMe()
+
+		recordTestBlockHeaders(ctx, t, client, 5)
+
+		transaction := newTransaction(testTxHex, append(client.DefaultModelOptions(), New())...)
+		transaction.ConfirmationStatus = ConfirmationStatusMempool
+		transaction.BlockHeight = 3
+		changed, err := transaction.updateConfirmationStatus(ctx)
+		require.NoError(t, err)
+		assert.True(t, changed)
+		assert.Equal(t, ConfirmationStatusMined, transaction.ConfirmationStatus)
+		assert.Equal(t, uint64(2), transaction.Confirmations)
+
+		transaction.BlockHeight = 2
+		changed, err = transaction.updateConfirmationStatus(ctx)
+		require.NoError(t, err)
+		assert.True(t, changed)
+		assert.Equal(t, ConfirmationStatusFinal, transaction.ConfirmationStatus)
+		assert.Equal(t, uint64(3), transaction.Confirmations)
+
+		transaction.BlockHeight = 0
+		changed, err = transaction.updateConfirmationStatus(ctx)
+		require.NoError(t, err)
+		assert.True(t, changed)
+		assert.Equal(t, ConfirmationStatusMempool, transaction.ConfirmationStatus)
+		assert.Equal(t, uint64(0), transaction.Confirmations)
+	})
+}
+
+// Test_unconfirmedConditions will test the method unconfirmedConditions()
+func Test_unconfirmedConditions(t *testing.T) {
+	t.Parallel()
+
+	t.Run("short chain", func(t *testing.T) {
+		assert.Nil(t, unconfirmedConditions(4, 6))
+	})
+
+	t.Run("minimum block height", func(t *testing.T) {
+		conditions := unconfirmedConditions(100, 6)
+		require.NotNil(t, conditions)
+		or := conditions["$or"].([]map[string]interface{})
+		require.Len(t, or, 3)
+		assert.Equal(t, map[string]interface{}{"$gt": uint64(95)}, or[2][blockHeightField])
+	})
+}
+
+// TestClient_GetUnconfirmedTransactionsByXpubID will test the method GetUnconfirmedTransactionsByXpubID()
+func TestClient_GetUnconfirmedTransactionsByXpubID(t *testing.T) {
+	ctx, client, deferMe := CreateTestSQLiteClient(
+		t, false, true, WithCustomTaskManager(&taskManagerMockBase{}), WithFinalityConfirmations(6),
+		WithBlockHeaderValidationDisabled(),
+	)
+	defer deferMe()
+
+	opts := append(client.DefaultModelOptions(), New())
+	xPub := newXpub(testXPub, opts...)
+	require.NoError(t, xPub.Save(ctx))
+
+	// destinations for the first output of each test transaction
+	for _, lockingScript := range []string{
+		"76a91413473d21dc9e1fb392f05a028b447b165a052d4d88ac",
+		"76a914e069bd2e2fe3ea702c40d5e65b491b734c01686788ac",
+		"76a914010af176de3faac864f148461340be6a7bb9eff488ac",
+	} {
+		destination := newDestination(testXPubID, lockingScript, opts...)
+		require.NoError(t, destination.Save(ctx))
+	}
+
+	recordTestBlockHeaders(ctx, t, client, 10)
+
+	// 2 confirmations, 8 confirmations (final) & mempool
+	for txHex, blockHeight := range map[string]uint64{
+		testTxHex:  9,
+		testTx2Hex: 3,
+		testTx3Hex: 0,
+	} {
+		transaction := newTransaction(txHex, opts...)
+		transaction.BlockHeight = blockHeight
+		require.NoError(t, transaction.Save(ctx))
+	}
+
+	transactions, err := client.GetUnconfirmedTransactionsByXpubID(
+		ctx, testXPubID, 6, nil, nil, nil,
+	)
+	require.NoError(t, err)
+	require.Len(t, transactions, 2)
+	for _, transaction := range transactions {
+		assert.Less(t, transaction.Confirmations, uint64(6))
+		assert.NotEqual(t, ConfirmationStatusFinal, transaction.ConfirmationStatus)
+	}
+
+	// No transaction has fewer than 0 confirmations
+	transactions, err = client.GetUnconfirmedTransactionsByXpubID(
+		ctx, testXPubID, 0, nil, nil, nil,
+	)
+	require.NoError(t, err)
+	assert.Empty(t, transactions)
+
+	var transaction *Transaction
+	transaction, err = client.GetTransaction(ctx, testXPubID, testTxID3)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(0), transaction.Confirmations)
+	assert.Equal(t, ConfirmationStatusMempool, transaction.ConfirmationStatus)
+}
+
+// Test_processFinalTransactions will test the method processFinalTransactions()
+func Test_processFinalTransactions(t *testing.T) {
+	ctx, client, deferMe := CreateTestSQLiteClient(
+		t, false, true, WithCustomTaskManager(&taskManagerMockBase{}), WithFinalityConfirmations(3),
+		WithBlockHeaderValidationDisabled(),
+	)
+	defer deferMe()
+
+	recordTestBlockHeaders(ctx, t, client, 3)
+
+	transaction := newTransaction(testTxHex, append(client.DefaultModelOptions(), New())...)
+	transaction.BlockHeight = 2
+	require.NoError(t, transaction.Save(ctx))
+	assert.Equal(t, ConfirmationStatusMined, transaction.ConfirmationStatus)
+
+	// new blocks on top of the transaction (the transaction is final with 3 confirmations)
+	recordTestBlockHeader(ctx, t, client, testBlockHashAt(3), 3, testBlockHashAt(2))
+	recordTestBlockHeader(ctx, t, client, testBlockHashAt(4), 4, testBlockHashAt(3))
+
+	var err error
+	transaction, err = getTransactionByID(ctx, "", testTxID, client.DefaultModelOptions()...)
+	require.NoError(t, err)
+	assert.Equal(t, ConfirmationStatusFinal, transaction.ConfirmationStatus)
+}
+
+// TestTransaction_migrateConfirmationStatus will test the method migrateConfirmationStatus()
+func TestTransaction_migrateConfirmationStatus(t *testing.T) {
+	ctx, client, deferMe := CreateTestSQLiteClient(
+		t, false, true, WithCustomTaskManager(&taskManagerMockBase{}),
+		WithBlockHeaderValidationDisabled(),
+	)
+	defer deferMe()
+
+	opts := append(client.DefaultModelOptions(), New())
+	for txHex, blockHeight := range map[string]uint64{
+		testTxHex:  2,
+		testTx2Hex: 0,
+	} {
+		transaction := newTransaction(txHex, opts...)
+		transaction.BlockHeight = blockHeight
+		require.NoError(t, transaction.Save(ctx))
+	}
+
+	// transactions stored before the confirmation status was tracked
+	ds := client.Datastore()
+	tableName := ds.GetTableName(tableTransactions)
+	require.NoError(t, ds.Execute(`UPDATE "`+tableName+`" SET confirmation_status = NULL`).Error)
+
+	transaction := newTransaction(testTxHex, client.DefaultModelOptions()...)
+	require.NoError(t, transaction.migrateConfirmationStatus(ds, tableName))
+
+	for txID, status := range map[string]ConfirmationStatus{
+		testTxID:  ConfirmationStatusMined,
+		testTxID2: ConfirmationStatusMempool,
+	} {
+		var err error
+		transaction, err = getTransactionByID(ctx, "", txID, client.DefaultModelOptions()...)
+		require.NoError(t, err)
+		assert.Equal(t, status, transaction.ConfirmationStatus)
+	}
+}
@@ -0,0 +1,232 @@
+package bux
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/BuxOrg/bux/chainstate"
+	"github.com/BuxOrg/bux/notifications"
+	"github.com/libsv/go-bt/v2"
+)
+
+// Reverted markers used on the reverted transaction and utxo records
+const (
+	revertedDeleted          = "deleted"
+	revertedQuarantined      = "quarantined"
+	revertedXpubID           = "reverted"
+	metadataConflictingTxs   = "conflicting_tx_ids"
+	metadataQuarantinedUtxos = "quarantined_utxo_ids"
+)
+
+// processBroadcastConflict will handle a transaction that lost a double-spend (conflict) when broadcasting
+//
+// The competing transaction(s) will be detected (if possible), the utxo effects of the transaction
+// (and any un-broadcast children) are rolled back, and a double-spend notification is fired.
+// The provider said (some of) the inputs are spent, only the inputs proven to be unspent are released,
+// the other inputs stay spent (by the competing transaction, or quarantined if it was not found).
+func processBroadcastConflict(ctx context.Context, syncTx *SyncTransaction, transaction *Transaction,
+	broadcastErr error,
+) error {
+	// Find the competing transaction(s) for each input
+	conflicts, err := detectConflicts(ctx, transaction)
+	if err != nil {
+		return err
+	}
+
+	// Keep a record of the competing transactions (and the quarantined inputs)
+	conflictingTxIDs := make([]string, 0)
+	quarantinedUtxos := make([]string, 0)
+	for utxoID, txID := range conflicts {
+		if txID == revertedQuarantined {
+			quarantinedUtxos = append(quarantinedUtxos, utxoID)
+		} else {
+			conflictingTxIDs = append(conflictingTxIDs, txID)
+		}
+	}
+	if transaction.Metadata == nil {
+		transaction.Metadata = Metadata{}
+	}
+	transaction.Metadata[metadataConflictingTxs] = conflictingTxIDs
+	if len(quarantinedUtxos) > 0 {
+		transaction.Metadata[metadataQuarantinedUtxos] = quarantinedUtxos
+	}
+
+	// Rollback the effects of the transaction
+	if err = revertTransaction(ctx, transaction, conflicts); err != nil {
+		return err
+	}
+
+	// Cancel the sync transaction
+	syncTx.P2PStatus = SyncStatusCanceled
+	syncTx.SyncStatus = SyncStatusCanceled
+	bailAndSaveSyncTransaction(
+		ctx, syncTx, SyncStatusCanceled, syncActionBroadcast, chainstate.ProviderAll,
+		"double spend, transaction reverted: "+broadcastErr.Error(),
+	)
+
+	// Fire notifications (this is already in a go routine)
+	notify(notifications.EventTypeDoubleSpend, transaction)
+
+	return nil
+}
+
+// detectConflicts will find the transactions spending the inputs of the given transaction
+//
+// Returns a map of utxo ID => competing transaction ID. Inputs without a known competitor are quarantined
+// (revertedQuarantined), unless the provider proved the output is not spent (then they are not returned).
+func detectConflicts(ctx context.Context, transaction *Transaction) (map[string]string, error) {
+	btTx, err := bt.NewTxFromString(transaction.Hex)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := transaction.GetOptions(false)
+	conflicts := make(map[string]string)
+	for _, input := range btTx.Inputs {
+		var utxo *Utxo
+		if utxo, err = getUtxo(
+			ctx, input.PreviousTxIDStr(), input.PreviousTxOutIndex, opts...,
+		); err != nil {
+			return nil, err
+		} else if utxo == nil {
+			continue
+		}
+
+		// Any other error (IE: provider not available) leaves the input quarantined
+		txID, queryErr := transaction.Client().Chainstate().QuerySpendingTransaction(
+			ctx, utxo.TransactionID, utxo.OutputIndex, utxo.ScriptPubKey, defaultQueryTxTimeout,
+		)
+		if queryErr == nil && len(txID) > 0 && txID != transaction.ID {
+			conflicts[utxo.ID] = txID
+		} else if !errors.Is(queryErr, chainstate.ErrOutputUnspent) {
+			conflicts[utxo.ID] = revertedQuarantined
+		}
+	}
+
+	return conflicts, nil
+}
+
+// revertTransaction will revert the utxo effects (and xPub balances) of the transaction
+//
+// Output utxos are marked as deleted, any (un-broadcast) children spending them are reverted first.
+// Input utxos are set back to not spent, unless they are in conflicts (utxo ID => spending tx ID),
+// then they are marked as spent by the competing transaction (or quarantined) and removed from the balance.
+func revertTransaction(ctx context.Context, transaction *Transaction, conflicts map[string]string) error {
+	opts := transaction.GetOptions(false)
+
+	// Get the output utxos of the transaction
+	utxos, err := getUtxosByConditions(ctx, map[string]interface{}{
+		"transaction_id": transaction.ID,
+	}, nil, opts...)
+	if err != nil {
+		return err
+	}
+
+	// Revert any children first (they can never be broadcast)
+	for _, utxo := range utxos {
+		if !utxo.SpendingTxID.Valid || utxo.SpendingTxID.String == revertedDeleted {
+			continue
+		}
+		var child *Transaction
+		if child, err = getTransactionByID(ctx, "", utxo.SpendingTxID.String, opts...); err != nil {
+			return err
+		} else if child != nil {
+			if err = revertTransaction(ctx, child, nil); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Mark output utxos as deleted (no way to delete from Bux yet)
+	for _, utxo := range utxos {
+		utxo.SpendingTxID.Valid = true
+		utxo.SpendingTxID.String = revertedDeleted
+		utxo.DeletedAt.Valid = true
+		utxo.DeletedAt.Time = time.Now()
+		if err = utxo.Save(ctx); err != nil {
+			return err
+		}
+	}
+
+	// Remove the output values of the transaction from all xPubs
+	balances := make(map[string]int64)
+	for xPubID, outputValue := range transaction.XpubOutputValue {
+		balances[xPubID] -= outputValue
+	}
+
+	// Set the inputs (spent utxos) back to not spent, or spent by the competing transaction
+	var btTx *bt.Tx
+	if btTx, err = bt.NewTxFromString(transaction.Hex); err != nil {
+		return err
+	}
+	for _, input := range btTx.Inputs {
+		var utxo *Utxo
+		if utxo, err = getUtxo(
+			ctx, input.PreviousTxIDStr(), input.PreviousTxOutIndex, opts...,
+		); err != nil {
+			return err
+		} else if utxo == nil {
+			continue
+		}
+		if txID, ok := conflicts[utxo.ID]; ok {
+			utxo.SpendingTxID.Valid = true
+			utxo.SpendingTxID.String = txID
+			balances[utxo.XpubID] -= int64(utxo.Satoshis)
+		} else {
+			utxo.SpendingTxID.Valid = false
+			utxo.SpendingTxID.String = ""
+		}
+		utxo.DraftID.Valid = false
+		utxo.ReservedAt.Valid = false
+		if err = utxo.Save(ctx); err != nil {
+			return err
+		}
+	}
+
+	// Update the xPub balances
+	for xPubID, balance := range balances {
+		if balance == 0 {
+			continue
+		}
+		var xPub *Xpub
+		if xPub, err = getXpubWithCache(ctx, transaction.Client(), "", xPubID, opts...); err != nil {
+			return err
+		} else if xPub == nil {
+			return ErrMissingRequiredXpub
+		}
+		if err = xPub.incrementBalance(ctx, balance); err != nil {
+			return err
+		}
+	}
+
+	// Cancel the sync transaction (if found)
+	var syncTx *SyncTransaction
+	if syncTx, err = GetSyncTransactionByID(ctx, transaction.ID, opts...); err != nil {
+		return err
+	} else if syncTx != nil {
+		syncTx.BroadcastStatus = SyncStatusCanceled
+		syncTx.P2PStatus = SyncStatusCanceled
+		syncTx.SyncStatus = SyncStatusCanceled
+		if err = syncTx.Save(ctx); err != nil {
+			return err
+		}
+	}
+
+	// Revert the transaction
+	// this takes the transaction out of any possible list view of the owners of the xpubs,
+	// but keeps a record of what went down
+	if transaction.Metadata == nil {
+		transaction.Metadata = Metadata{}
+	}
+	transaction.Metadata["XpubInIDs"] = transaction.XpubInIDs
+	transaction.Metadata["XpubOutIDs"] = transaction.XpubOutIDs
+	transaction.Metadata["XpubOutputValue"] = transaction.XpubOutputValue
+	transaction.XpubInIDs = IDs{revertedXpubID}
+	transaction.XpubOutIDs = IDs{revertedXpubID}
+	transaction.XpubOutputValue = XpubOutputValue{revertedXpubID: 0}
+	transaction.DeletedAt.Valid = true
+	transaction.DeletedAt.Time = time.Now()
+
+	return transaction.Save(ctx)
+}
@@ -0,0 +1,119 @@
+package bux
+
+import (
+	"testing"
+
+	"github.com/BuxOrg/bux/chainstate"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Test_processBroadcastConflict will test the method processBroadcastConflict()
+func Test_processBroadcastConflict(t *testing.T) {
+	t.Run("conflicting transaction found", func(t *testing.T) {
+		ctx, client, transaction, _, deferMe := initRevertTransactionData(
+			t, WithCustomChainstate(&chainStateDoubleSpend{conflictingTxID: testTxID2}),
+		)
+		defer deferMe()
+
+		syncTx, err := GetSyncTransactionByID(ctx, transaction.ID, client.DefaultModelOptions()...)
+		require.NoError(t, err)
+		require.NotNil(t, syncTx)
+
+		// Broadcast will fail with a mempool conflict
+		err = processBroadcastTransaction(ctx, syncTx)
+		require.NoError(t, err)
+
+		// check sync transaction was canceled
+		syncTx, err = GetSyncTransactionByID(ctx, transaction.ID, client.DefaultModelOptions()...)
+		require.NoError(t, err)
+		assert.Equal(t, SyncStatusCanceled, syncTx.BroadcastStatus)
+		assert.Equal(t, SyncStatusCanceled, syncTx.SyncStatus)
+		assert.Contains(t, syncTx.Results.LastMessage, "double spend")
+
+		// check transaction was reverted
+		var tx *Transaction
+		tx, err = client.GetTransaction(ctx, testXPubID, transaction.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "reverted", tx.XpubInIDs[0])
+		assert.True(t, tx.DeletedAt.Valid)
+		assert.Equal(t, []interface{}{testTxID2}, tx.Metadata[metadataConflictingTxs])
+
+		// the input was spent by the competing transaction
+		var utxo *Utxo
+		utxo, err = getUtxo(ctx, testTxID, 0, client.DefaultModelOptions()...)
+		require.NoError(t, err)
+		assert.Equal(t, testTxID2, utxo.SpendingTxID.String)
+		assert.False(t, utxo.DraftID.Valid)
+
+		// the balance of the input is gone
+		var xPub *Xpub
+		xPub, err = getXpubByID(ctx, testXPubID, client.DefaultModelOptions()...)
+		require.NoError(t, err)
+		assert.Equal(t, uint64(0), xPub.CurrentBalance)
+	})
+
+	t.Run("conflicting transaction not found", func(t *testing.T) {
+		ctx, client, transaction, _, deferMe := initRevertTransactionData(
+			t, WithCustomChainstate(&chainStateDoubleSpend{queryErr: chainstate.ErrSpendingTransactionNotFound}),
+		)
+		defer deferMe()
+
+		syncTx, err := GetSyncTransactionByID(ctx, transaction.ID, client.DefaultModelOptions()...)
+		require.NoError(t, err)
+		require.NotNil(t, syncTx)
+
+		err = processBroadcastTransaction(ctx, syncTx)
+		require.NoError(t, err)
+
+		// the response of the provider was recorded
+		attempts, err := client.GetTransactionBroadcastAttempts(ctx, transaction.ID)
+		require.NoError(t, err)
+		require.Len(t, attempts, 1)
+		assert.Equal(t, chainstate.BroadcastOutcomeMempoolConflict, attempts[0].Outcome)
+		assert.Equal(t, "258: txn-mempool-conflict", attempts[0].ResponseMessage)
+
+		// the input was spent according to the provider, it stays quarantined
+		var utxo *Utxo
+		utxo, err = getUtxo(ctx, testTxID, 0, client.DefaultModelOptions()...)
+		require.NoError(t, err)
+		assert.Equal(t, revertedQuarantined, utxo.SpendingTxID.String)
+		assert.False(t, utxo.DraftID.Valid)
+
+		var tx *Transaction
+		tx, err = client.GetTransaction(ctx, testXPubID, transaction.ID)
+		require.NoError(t, err)
+		assert.Equal(t, []interface{}{utxo.ID}, tx.Metadata[metadataQuarantinedUtxos])
+
+		var xPub *Xpub
+		xPub, err = getXpubByID(ctx, testXPubID, client.DefaultModelOptions()...)
+		require.NoError(t, err)
+		assert.Equal(t, uint64(0), xPub.CurrentBalance)
+	})
+
+	t.Run("input proven unspent", func(t *testing.T) {
+		ctx, client, transaction, _, deferMe := initRevertTransactionData(
+			t, WithCustomChainstate(&chainStateDoubleSpend{queryErr: chainstate.ErrOutputUnspent}),
+		)
+		defer deferMe()
+
+		syncTx, err := GetSyncTransactionByID(ctx, transaction.ID, client.DefaultModelOptions()...)
+		require.NoError(t, err)
+		require.NotNil(t, syncTx)
+
+		err = processBroadcastTransaction(ctx, syncTx)
+		require.NoError(t, err)
+
+		// the input is spendable again
+		var utxo *Utxo
+		utxo, err = getUtxo(ctx, testTxID, 0, client.DefaultModelOptions()...)
+		require.NoError(t, err)
+		assert.False(t, utxo.SpendingTxID.Valid)
+		assert.False(t, utxo.DraftID.Valid)
+
+		var xPub *Xpub
+		xPub, err = getXpubByID(ctx, testXPubID, client.DefaultModelOptions()...)
+		require.NoError(t, err)
+		assert.Equal(t, uint64(100000), xPub.CurrentBalance)
+	})
+}
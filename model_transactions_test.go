@@ -527,6 +527,99 @@ func TestTransaction_processInputs(t *testing.T) {
 		require.NoError(t, err)
 	})
 
+	t.Run("unconfirmed ancestors", func(t *testing.T) {
+		ctx, client, deferMe := CreateTestSQLiteClient(t, false, false, WithCustomTaskManager(&taskManagerMockBase{}), WithIUCDisabled())
+		defer deferMe()
+
+		transaction := newTransaction(testTxHex, append(client.DefaultModelOptions(), New())...)
+		require.NotNil(t, transaction)
+
+		transaction.draftTransaction = &DraftTransaction{
+			TransactionBase: TransactionBase{ID: testDraftID},
+		}
+		transaction.transactionService = transactionServiceMock{
+			utxos: map[string]map[uint32]*Utxo{
+				testTxID2: {
+					uint32(0): {
+						Model: Model{name: ModelUtxo},
+						UtxoPointer: UtxoPointer{
+							OutputIndex:   0,
+							TransactionID: testTxID2,
+						},
+						XpubID:           "test-xpub-id",
+						UnconfirmedDepth: 3,
+					},
+				},
+			},
+		}
+
+		err := transaction.processInputs(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, uint32(3), transaction.UnconfirmedAncestors)
+	})
+
+	t.Run("confirmed parent", func(t *testing.T) {
+		ctx, client, deferMe := CreateTestSQLiteClient(t, false, false, WithCustomTaskManager(&taskManagerMockBase{}), WithIUCDisabled())
+		defer deferMe()
+
+		transaction := newTransaction(testTxHex, append(client.DefaultModelOptions(), New())...)
+		require.NotNil(t, transaction)
+
+		transaction.draftTransaction = &DraftTransaction{
+			TransactionBase: TransactionBase{ID: testDraftID},
+		}
+		transaction.transactionService = transactionServiceMock{
+			utxos: map[string]map[uint32]*Utxo{
+				testTxID2: {
+					uint32(0): {
+						Model: Model{name: ModelUtxo},
+						UtxoPointer: UtxoPointer{
+							OutputIndex:   0,
+							TransactionID: testTxID2,
+						},
+						XpubID: "test-xpub-id",
+					},
+				},
+			},
+		}
+
+		err := transaction.processInputs(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, uint32(0), transaction.UnconfirmedAncestors)
+	})
+
+	t.Run("too many unconfirmed ancestors", func(t *testing.T) {
+		ctx, client, deferMe := CreateTestSQLiteClient(
+			t, false, false, WithCustomTaskManager(&taskManagerMockBase{}), WithIUCDisabled(), WithMaxUnconfirmedAncestors(2),
+		)
+		defer deferMe()
+
+		transaction := newTransaction(testTxHex, append(client.DefaultModelOptions(), New())...)
+		require.NotNil(t, transaction)
+
+		transaction.draftTransaction = &DraftTransaction{
+			TransactionBase: TransactionBase{ID: testDraftID},
+		}
+		transaction.transactionService = transactionServiceMock{
+			utxos: map[string]map[uint32]*Utxo{
+				testTxID2: {
+					uint32(0): {
+						Model: Model{name: ModelUtxo},
+						UtxoPointer: UtxoPointer{
+							OutputIndex:   0,
+							TransactionID: testTxID2,
+						},
+						XpubID:           "test-xpub-id",
+						UnconfirmedDepth: 3,
+					},
+				},
+			},
+		}
+
+		err := transaction.processInputs(ctx)
+		require.ErrorIs(t, err, ErrTooManyUnconfirmedAncestors)
+	})
+
 	t.Run("STAS token input", func(t *testing.T) {
 		ctx := context.Background()
 
@@ -572,6 +665,76 @@ func TestTransaction_processInputs(t *testing.T) {
 	})
 }
 
+// TestTransaction_BeforeUpdating will test the method BeforeUpdating()
+func TestTransaction_BeforeUpdating(t *testing.T) {
+	t.Parallel()
+
+	t.Run("unconfirmed", func(t *testing.T) {
+		transaction := newTransaction(testTxHex, New())
+		transaction.UnconfirmedAncestors = 3
+		require.NoError(t, transaction.BeforeUpdating(context.Background()))
+		assert.Equal(t, uint32(3), transaction.UnconfirmedAncestors)
+	})
+
+	t.Run("mined", func(t *testing.T) {
+		transaction := newTransaction(testTxHex, New())
+		transaction.UnconfirmedAncestors = 3
+		transaction.BlockHeight = 750000
+		require.NoError(t, transaction.BeforeUpdating(context.Background()))
+		assert.Equal(t, uint32(0), transaction.UnconfirmedAncestors)
+	})
+}
+
+// TestTransaction_updateUnconfirmedDepths will test the method updateUnconfirmedDepths()
+func TestTransaction_updateUnconfirmedDepths(t *testing.T) {
+	ctx, client, deferMe := CreateTestSQLiteClient(t, false, true, WithCustomTaskManager(&taskManagerMockBase{}))
+	defer deferMe()
+	opts := append(client.DefaultModelOptions(), New())
+
+	// An unconfirmed parent, spent by an unconfirmed child
+	parent := newTransaction(testTx2Hex, opts...)
+	require.NoError(t, parent.Save(ctx))
+	parentUtxo := newUtxo(testXPubID, testTxID2, testLockingScript, 0, 1225, opts...)
+	parentUtxo.UnconfirmedDepth = 1
+	parentUtxo.SpendingTxID.Valid = true
+	parentUtxo.SpendingTxID.String = testTxID3
+	require.NoError(t, parentUtxo.Save(ctx))
+
+	child := newTransaction(testTx3Hex, opts...)
+	child.UnconfirmedAncestors = 1
+	require.NoError(t, child.Save(ctx))
+	childUtxo := newUtxo(testXPubID, testTxID3, testLockingScript, 0, 1000, opts...)
+	childUtxo.UnconfirmedDepth = 2
+	require.NoError(t, childUtxo.Save(ctx))
+
+	// Mine the parent
+	var err error
+	parent, err = getTransactionByID(ctx, "", testTxID2, client.DefaultModelOptions()...)
+	require.NoError(t, err)
+	parent.BlockHeight = 750000
+	require.NoError(t, parent.Save(ctx))
+
+	parentUtxo, err = getUtxo(ctx, testTxID2, 0, client.DefaultModelOptions()...)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(0), parentUtxo.UnconfirmedDepth)
+
+	child, err = getTransactionByID(ctx, "", testTxID3, client.DefaultModelOptions()...)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(0), child.UnconfirmedAncestors)
+
+	childUtxo, err = getUtxo(ctx, testTxID3, 0, client.DefaultModelOptions()...)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(1), childUtxo.UnconfirmedDepth)
+
+	// Reset the parent (IE: reorg)
+	parent.BlockHeight = 0
+	require.NoError(t, parent.Save(ctx))
+
+	childUtxo, err = getUtxo(ctx, testTxID3, 0, client.DefaultModelOptions()...)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(2), childUtxo.UnconfirmedDepth)
+}
+
 func TestTransaction_Display(t *testing.T) {
 	t.Run("display without xpub data", func(t *testing.T) {
 		tx := Transaction{
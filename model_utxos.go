@@ -3,6 +3,7 @@ package bux
 import (
 	"context"
 	"fmt"
+	"sort"
 	"time"
 
 	"github.com/BuxOrg/bux/utils"
@@ -37,6 +38,9 @@ type Utxo struct {
 	ReservedAt   customTypes.NullTime   `json:"reserved_at" toml:"reserved_at" yaml:"reserved_at" gorm:"<-;comment:When it was reserved" bson:"reserved_at,omitempty"`
 	SpendingTxID customTypes.NullString `json:"spending_tx_id,omitempty" toml:"spending_tx_id" yaml:"spending_tx_id" gorm:"<-;type:char(64);index;comment:This is tx ID of the spend" bson:"spending_tx_id,omitempty"`
 
+	// Depth of the unconfirmed ancestor chain of a transaction spending the utxo (0 = the utxo is confirmed)
+	UnconfirmedDepth uint32 `json:"unconfirmed_depth" toml:"unconfirmed_depth" yaml:"unconfirmed_depth" gorm:"<-;type:int;comment:This is the unconfirmed ancestor depth of a transaction spending the utxo" bson:"unconfirmed_depth"`
+
 	// Virtual field holding the original transaction the utxo originated from
 	// This is needed when signing a new transaction that spends the utxo
 	Transaction *Transaction `json:"transaction,omitempty" toml:"-" yaml:"-" gorm:"-" bson:"-"`
@@ -164,63 +168,74 @@ func reserveUtxos(ctx context.Context, xPubID, draftID string,
 	feeNeeded := uint64(0)
 	reservedSatoshis := uint64(0)
 
-	queryParams := &datastore.QueryParams{}
-	if fromUtxos == nil {
-		// if we are not getting all utxos, paginate the retreival
-		queryParams.Page = 1
-		queryParams.PageSize = m.pageSize
-		if queryParams.PageSize == 0 {
-			queryParams.PageSize = defaultPageSize
-		}
+	// Set vars
+	size := utils.GetInputSizeForType(utils.ScriptTypePubKeyHash)
+	var maxDepth uint32
+	if maxDepth, err = getMaxUnconfirmedAncestors(ctx, xPubID, opts...); err != nil {
+		return nil, err
 	}
 
-reserveUtxoLoop:
-	for {
-		var freeUtxos []*Utxo
-		if freeUtxos, err = getSpendableUtxos(
-			ctx, xPubID, utils.ScriptTypePubKeyHash, queryParams, fromUtxos, opts..., // todo: allow reservation of utxos by a different utxo destination type
+	// Select the candidates, confirmed utxos are preferred over unconfirmed (mempool) utxos
+	var candidates []*Utxo
+	if fromUtxos != nil {
+		if candidates, err = getSpendableUtxos(
+			ctx, xPubID, utils.ScriptTypePubKeyHash, nil, fromUtxos, opts...,
 		); err != nil {
 			return nil, err
 		}
-
-		if len(freeUtxos) == 0 {
-			break reserveUtxoLoop
+		for _, utxo := range candidates {
+			if maxDepth > 0 && utxo.UnconfirmedDepth > maxDepth {
+				return nil, ErrTooManyUnconfirmedAncestors
+			}
 		}
-
-		// Set vars
-		size := utils.GetInputSizeForType(utils.ScriptTypePubKeyHash)
-
-		// Loop the returned utxos
-		for _, utxo := range freeUtxos {
-
-			// Set the values on the UTXO
-			utxo.DraftID.Valid = true
-			utxo.DraftID.String = draftID
-			utxo.ReservedAt.Valid = true
-			utxo.ReservedAt.Time = time.Now().UTC()
-
-			// Accumulate the reserved satoshis
-			reservedSatoshis += utxo.Satoshis
-
-			// Save the UTXO
-			// todo: should occur in 1 DB transaction
-			if err = utxo.Save(ctx); err != nil {
+		sort.SliceStable(candidates, func(i, j int) bool {
+			return candidates[i].UnconfirmedDepth < candidates[j].UnconfirmedDepth
+		})
+	} else {
+		// Only fall back to the unconfirmed utxos (the shortest chains first) if the confirmed utxos are not enough
+		pageSize := m.pageSize
+		if pageSize == 0 {
+			pageSize = defaultPageSize
+		}
+		if candidates, err = getReservableUtxos(
+			ctx, xPubID, confirmedUtxoConditions(), nil, satoshis, feePerByte, pageSize, opts...,
+		); err != nil {
+			return nil, err
+		}
+		if !hasEnoughSatoshis(candidates, satoshis, feePerByte) {
+			if candidates, err = getReservableUtxos(
+				ctx, xPubID, unconfirmedUtxoConditions(maxDepth), candidates, satoshis, feePerByte, pageSize, opts...,
+			); err != nil {
 				return nil, err
 			}
+		}
+	}
 
-			// Add the utxo to the final slice
-			*utxos = append(*utxos, utxo)
+	// Loop the candidates and reserve
+	for _, utxo := range candidates {
 
-			// add fee for this new input
-			feeNeeded += uint64(float64(size) * feePerByte)
-			if reservedSatoshis >= (satoshis + feeNeeded) {
-				break reserveUtxoLoop
-			}
+		// Set the values on the UTXO
+		utxo.DraftID.Valid = true
+		utxo.DraftID.String = draftID
+		utxo.ReservedAt.Valid = true
+		utxo.ReservedAt.Time = time.Now().UTC()
+
+		// Accumulate the reserved satoshis
+		reservedSatoshis += utxo.Satoshis
+
+		// Save the UTXO
+		// todo: should occur in 1 DB transaction
+		if err = utxo.Save(ctx); err != nil {
+			return nil, err
 		}
 
-		if queryParams.PageSize == 0 {
-			// break the loop if we are not paginating
-			break reserveUtxoLoop
+		// Add the utxo to the final slice
+		*utxos = append(*utxos, utxo)
+
+		// add fee for this new input
+		feeNeeded += uint64(float64(size) * feePerByte)
+		if reservedSatoshis >= (satoshis + feeNeeded) {
+			break
 		}
 	}
 
@@ -245,6 +260,80 @@ reserveUtxoLoop:
 	return *utxos, nil
 }
 
+// getReservableUtxos will add the spendable utxos matching the (depth) conditions to the selected utxos,
+// page by page, until the utxos are enough to fund the given satoshis
+func getReservableUtxos(ctx context.Context, xPubID string, depthConditions map[string]interface{},
+	selected []*Utxo, satoshis uint64, feePerByte float64, pageSize int, opts ...ModelOps) ([]*Utxo, error) {
+
+	conditions := map[string]interface{}{
+		draftIDField:      nil,
+		spendingTxIDField: nil,
+		typeField:         utils.ScriptTypePubKeyHash, // todo: allow reservation of utxos by a different utxo destination type
+		xPubIDField:       xPubID,
+	}
+	for key, value := range depthConditions {
+		conditions[key] = value
+	}
+
+	queryParams := &datastore.QueryParams{
+		OrderByField:  unconfirmedDepthField,
+		Page:          1,
+		PageSize:      pageSize,
+		SortDirection: datastore.SortAsc,
+	}
+
+	utxos := append(make([]*Utxo, 0, len(selected)), selected...)
+	for {
+		page, err := getUtxosByConditions(ctx, conditions, queryParams, opts...)
+		if err != nil {
+			return nil, err
+		}
+		utxos = append(utxos, page...)
+
+		// break the loop if there are no more utxos or the utxos are enough
+		if len(page) < pageSize || hasEnoughSatoshis(utxos, satoshis, feePerByte) {
+			return utxos, nil
+		}
+		queryParams.Page++
+	}
+}
+
+// confirmedUtxoConditions will return the db conditions for utxos of mined transactions
+//
+// Utxos stored before the depth was tracked (no value) are considered confirmed
+func confirmedUtxoConditions() map[string]interface{} {
+	return map[string]interface{}{
+		"$or": []map[string]interface{}{
+			{unconfirmedDepthField: 0},
+			{unconfirmedDepthField: nil},
+		},
+	}
+}
+
+// unconfirmedUtxoConditions will return the db conditions for utxos of unconfirmed (mempool) transactions
+// within the maximum depth of the unconfirmed ancestor chain (0 = unlimited)
+func unconfirmedUtxoConditions(maxDepth uint32) map[string]interface{} {
+	depth := map[string]interface{}{
+		"$gt": 0,
+	}
+	if maxDepth > 0 {
+		depth["$lte"] = maxDepth
+	}
+	return map[string]interface{}{
+		unconfirmedDepthField: depth,
+	}
+}
+
+// hasEnoughSatoshis will return true if the utxos fund the satoshis (and the fee of the inputs)
+func hasEnoughSatoshis(utxos []*Utxo, satoshis uint64, feePerByte float64) bool {
+	total := uint64(0)
+	for _, utxo := range utxos {
+		total += utxo.Satoshis
+	}
+	size := utils.GetInputSizeForType(utils.ScriptTypePubKeyHash)
+	return total >= satoshis+uint64(float64(uint64(len(utxos))*size)*feePerByte)
+}
+
 // newUtxoFromTxID will start a new utxo model
 func newUtxoFromTxID(txID string, index uint32, opts ...ModelOps) *Utxo {
 	return &Utxo{
@@ -455,9 +544,33 @@ func (m *Utxo) Migrate(client datastore.ClientInterface) error {
 		}
 	}
 
+	if err := m.migrateUnconfirmedDepth(client, tableName); err != nil {
+		return err
+	}
+
 	return client.IndexMetadata(client.GetTableName(tableUTXOs), metadataField)
 }
 
+// migrateUnconfirmedDepth will set the unconfirmed depth of the utxos stored before the depth was tracked
+// (from the transaction of the utxo)
+func (m *Utxo) migrateUnconfirmedDepth(client datastore.ClientInterface, tableName string) error {
+	if !datastore.IsSQLEngine(client.Engine()) {
+		return nil
+	}
+
+	quote := `"`
+	if client.Engine() == datastore.MySQL {
+		quote = "`"
+	}
+	utxos := quote + tableName + quote
+	transactions := quote + client.GetTableName(tableTransactions) + quote
+	tx := client.Execute(`UPDATE ` + utxos + ` SET unconfirmed_depth = COALESCE((` +
+		`SELECT CASE WHEN t.block_height > 0 THEN 0 ELSE COALESCE(t.unconfirmed_ancestors, 0) + 1 END ` +
+		`FROM ` + transactions + ` t WHERE t.id = ` + utxos + `.transaction_id), 0) ` +
+		`WHERE unconfirmed_depth IS NULL`)
+	return tx.Error
+}
+
 // migratePostgreSQL is specific migration SQL for Postgresql
 func (m *Utxo) migratePostgreSQL(client datastore.ClientInterface, tableName string) error {
 	tx := client.Execute(`CREATE INDEX IF NOT EXISTS "idx_utxo_reserved" ON "` + tableName + `" ("xpub_id","type","draft_id","spending_tx_id")`)
@@ -322,6 +322,102 @@ func TestUtxo_ReserveUtxos(t *testing.T) {
 		_, err = reserveUtxos(ctx, testXPubID, testDraftID2, 2200, 0.05, fromUtxos, client.DefaultModelOptions()...)
 		require.ErrorIs(t, err, ErrDuplicateUTXOs)
 	})
+
+	t.Run("prefer confirmed utxos", func(t *testing.T) {
+		ctx, client, deferMe := CreateTestSQLiteClient(t, false, true, WithCustomTaskManager(&taskManagerMockBase{}))
+		defer deferMe()
+		err := createTestUnconfirmedUtxos(ctx, client, 1)
+		require.NoError(t, err)
+
+		var utxos []*Utxo
+		utxos, err = reserveUtxos(ctx, testXPubID, testDraftID2, 1000, 0.5, nil, client.DefaultModelOptions()...)
+		require.NoError(t, err)
+		require.Len(t, utxos, 1)
+		assert.Equal(t, testTxID3, utxos[0].TransactionID)
+
+		// Fallback to the unconfirmed utxos
+		utxos, err = reserveUtxos(ctx, testXPubID, testDraftID3, 1000, 0.5, nil, client.DefaultModelOptions()...)
+		require.NoError(t, err)
+		require.Len(t, utxos, 1)
+		assert.Equal(t, testTxID2, utxos[0].TransactionID)
+	})
+
+	t.Run("unconfirmed chain too long", func(t *testing.T) {
+		ctx, client, deferMe := CreateTestSQLiteClient(
+			t, false, true, WithCustomTaskManager(&taskManagerMockBase{}), WithMaxUnconfirmedAncestors(2),
+		)
+		defer deferMe()
+		err := createTestUnconfirmedUtxos(ctx, client, 2)
+		require.NoError(t, err)
+
+		_, err = reserveUtxos(ctx, testXPubID, testDraftID2, 2000, 0.5, nil, client.DefaultModelOptions()...)
+		require.ErrorIs(t, err, ErrNotEnoughUtxos)
+
+		fromUtxos := []*UtxoPointer{{
+			TransactionID: testTxID2,
+			OutputIndex:   0,
+		}}
+		_, err = reserveUtxos(ctx, testXPubID, testDraftID2, 1000, 0.5, fromUtxos, client.DefaultModelOptions()...)
+		require.ErrorIs(t, err, ErrTooManyUnconfirmedAncestors)
+	})
+
+	t.Run("limit of the xPub", func(t *testing.T) {
+		ctx, client, deferMe := CreateTestSQLiteClient(
+			t, false, true, WithCustomTaskManager(&taskManagerMockBase{}), WithMaxUnconfirmedAncestors(2),
+		)
+		defer deferMe()
+		err := createTestUnconfirmedUtxos(ctx, client, 2)
+		require.NoError(t, err)
+
+		_, err = client.NewXpub(ctx, testXPub, client.DefaultModelOptions()...)
+		require.NoError(t, err)
+
+		// the limit of the xPub replaces the default
+		depth := uint32(5)
+		var xPub *Xpub
+		xPub, err = client.UpdateXpubMaxUnconfirmedAncestors(ctx, testXPubID, &depth)
+		require.NoError(t, err)
+		require.NotNil(t, xPub.MaxUnconfirmedAncestors)
+		assert.Equal(t, depth, *xPub.MaxUnconfirmedAncestors)
+
+		var utxos []*Utxo
+		utxos, err = reserveUtxos(ctx, testXPubID, testDraftID2, 2000, 0.5, nil, client.DefaultModelOptions()...)
+		require.NoError(t, err)
+		assert.Len(t, utxos, 2)
+		require.NoError(t, unReserveUtxos(ctx, testXPubID, testDraftID2, client.DefaultModelOptions()...))
+
+		// back to the default
+		xPub, err = client.UpdateXpubMaxUnconfirmedAncestors(ctx, testXPubID, nil)
+		require.NoError(t, err)
+		assert.Nil(t, xPub.MaxUnconfirmedAncestors)
+
+		_, err = reserveUtxos(ctx, testXPubID, testDraftID2, 2000, 0.5, nil, client.DefaultModelOptions()...)
+		require.ErrorIs(t, err, ErrNotEnoughUtxos)
+	})
+}
+
+// createTestUnconfirmedUtxos will create an unconfirmed (testTxID2) and a confirmed (testTxID3) utxo
+func createTestUnconfirmedUtxos(ctx context.Context, client ClientInterface, unconfirmedAncestors uint32) error {
+	opts := append(client.DefaultModelOptions(), New())
+
+	unconfirmed := newTransaction(testTx2Hex, opts...)
+	unconfirmed.UnconfirmedAncestors = unconfirmedAncestors
+	if err := unconfirmed.Save(ctx); err != nil {
+		return err
+	}
+
+	confirmed := newTransaction(testTx3Hex, opts...)
+	confirmed.BlockHeight = 750000
+	if err := confirmed.Save(ctx); err != nil {
+		return err
+	}
+
+	utxo := newUtxo(testXPubID, testTxID2, testLockingScript, 0, 1225, opts...)
+	utxo.UnconfirmedDepth = unconfirmedDepth(unconfirmed)
+	if err := utxo.Save(ctx); err != nil {
+		return err
+	}
+	return newUtxo(testXPubID, testTxID3, testLockingScript, 0, 1225, opts...).Save(ctx)
 }
 
 // TestUtxo_GetSpendableUtxos get spendable utxos
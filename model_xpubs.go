@@ -17,10 +17,14 @@ type Xpub struct {
 	Model `bson:",inline"`
 
 	// Model specific fields
-	ID              string `json:"id" toml:"id" yaml:"id" gorm:"<-:create;type:char(64);primaryKey;comment:This is the sha256(xpub) hash" bson:"_id"`
-	CurrentBalance  uint64 `json:"current_balance" toml:"current_balance" yaml:"current_balance" gorm:"<-;comment:The current balance of unspent satoshis" bson:"current_balance"`
-	NextInternalNum uint32 `json:"next_internal_num" toml:"next_internal_num" yaml:"next_internal_num" gorm:"<-;type:int;comment:The next index number for the internal xPub derivation" bson:"next_internal_num"`
-	NextExternalNum uint32 `json:"next_external_num" toml:"next_external_num" yaml:"next_external_num" gorm:"<-;type:int;comment:The next index number for the external xPub derivation" bson:"next_external_num"`
+	ID              string         `json:"id" toml:"id" yaml:"id" gorm:"<-:create;type:char(64);primaryKey;comment:This is the sha256(xpub) hash" bson:"_id"`
+	CurrentBalance  uint64         `json:"current_balance" toml:"current_balance" yaml:"current_balance" gorm:"<-;comment:The current balance of unspent satoshis" bson:"current_balance"`
+	NextInternalNum uint32         `json:"next_internal_num" toml:"next_internal_num" yaml:"next_internal_num" gorm:"<-;type:int;comment:The next index number for the internal xPub derivation" bson:"next_internal_num"`
+	NextExternalNum uint32         `json:"next_external_num" toml:"next_external_num" yaml:"next_external_num" gorm:"<-;type:int;comment:The next index number for the external xPub derivation" bson:"next_external_num"`
+	SpendingLimits  SpendingLimits `json:"spending_limits" toml:"spending_limits" yaml:"spending_limits" gorm:"<-;type:text;comment:This is the spending limits struct in JSON" bson:"spending_limits"`
+
+	// Maximum depth of the unconfirmed ancestor chain of the spent utxos (nil uses the client default, 0 = unlimited)
+	MaxUnconfirmedAncestors *uint32 `json:"max_unconfirmed_ancestors,omitempty" toml:"max_unconfirmed_ancestors" yaml:"max_unconfirmed_ancestors" gorm:"<-;type:int;comment:This is the maximum depth of the unconfirmed ancestor chain (null uses the default)" bson:"max_unconfirmed_ancestors,omitempty"`
 
 	destinations []Destination `gorm:"-" bson:"-"` // json:"destinations,omitempty"
 }
@@ -75,6 +79,23 @@ func getXpubByID(ctx context.Context, xPubID string, opts ...ModelOps) (*Xpub, e
 	return xPub, nil
 }
 
+// getMaxUnconfirmedAncestors will get the maximum depth of the unconfirmed ancestor chain for the xPub
+// (the limit of the xPub, or the client default)
+func getMaxUnconfirmedAncestors(ctx context.Context, xPubID string, opts ...ModelOps) (uint32, error) {
+	if len(xPubID) > 0 {
+		xPub, err := getXpubByID(ctx, xPubID, opts...)
+		if err != nil {
+			return 0, err
+		} else if xPub != nil && xPub.MaxUnconfirmedAncestors != nil {
+			return *xPub.MaxUnconfirmedAncestors, nil
+		}
+	}
+	if c := NewBaseModel(ModelNameEmpty, opts...).Client(); c != nil {
+		return c.MaxUnconfirmedAncestors(), nil
+	}
+	return 0, nil
+}
+
 // getXpubWithCache will try to get from cache first, then datastore
 //
 // key is the raw xPub key or use xPubID
@@ -242,6 +263,38 @@ func (m *Xpub) incrementNextNum(ctx context.Context, chain uint32) (uint32, erro
 	return uint32(newNum - 1), err
 }
 
+// incrementNextNumTo will atomically move the num of the given chain of the xPub to (at least) the given num
+func (m *Xpub) incrementNextNumTo(ctx context.Context, chain, num uint32) error {
+
+	// Choose the field to update
+	fieldName := nextExternalNumField
+	current := m.NextExternalNum
+	if chain == utils.ChainInternal {
+		fieldName = nextInternalNumField
+		current = m.NextInternalNum
+	}
+	if current >= num {
+		return nil
+	}
+
+	// Try to increment the field
+	newNum, err := incrementField(
+		ctx, m, fieldName, int64(num-current),
+	)
+	if err != nil {
+		return err
+	}
+
+	// Update the model
+	if chain == utils.ChainInternal {
+		m.NextInternalNum = uint32(newNum)
+	} else {
+		m.NextExternalNum = uint32(newNum)
+	}
+
+	return m.AfterUpdated(ctx)
+}
+
 // ChildModels will get any related sub models
 func (m *Xpub) ChildModels() (childModels []ModelInterface) {
 	for index := range m.destinations {
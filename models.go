@@ -27,12 +27,16 @@ type Model struct {
 	DeletedAt customTypes.NullTime `json:"deleted_at" toml:"deleted_at" yaml:"deleted_at" gorm:"index;comment:The time the record was marked as deleted" bson:"deleted_at,omitempty"`
 
 	// Private fields
-	client        ClientInterface // Interface of the parent Client that loaded this bux model
-	encryptionKey string          // Use for sensitive values that required encryption (IE: paymail public xpub)
-	name          ModelName       // Name of model (table name)
-	newRecord     bool            // Determine if the record is new (create vs update)
-	pageSize      int             // Number of items per page to get if being used in for method getModels
-	rawXpubKey    string          // Used on "CREATE" on some models
+	client        ClientInterface        // Interface of the parent Client that loaded this bux model
+	encryptionKey string                 // Use for sensitive values that required encryption (IE: paymail public xpub)
+	name          ModelName              // Name of model (table name)
+	newRecord     bool                   // Determine if the record is new (create vs update)
+	pageSize      int                    // Number of items per page to get if being used in for method getModels
+	rawXpubKey    string                 // Used on "CREATE" on some models
+	accessKeyID   string                 // Used on "CREATE" on some models (access key that made the request)
+	adminXPubID   string                 // Admin that made the request (audit log)
+	skipFinality  bool                   // Do not check the finality of the transactions (block headers recorded in a batch)
+	auditBefore   map[string]interface{} // Stored state of the audited fields (loaded using Get, audit log)
 }
 
 // ModelInterface is the interface that all models share
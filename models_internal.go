@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/BuxOrg/bux/notifications"
+	"github.com/BuxOrg/bux/utils"
 )
 
 // AfterDeleted will fire after a successful delete in the Datastore
@@ -94,6 +95,104 @@ func (m *Model) RawXpub() string {
 	return m.rawXpubKey
 }
 
+// getAuditActor will get the actor (xPub ID, access key ID & admin xPub ID) that is saving the model
+func (m *Model) getAuditActor() (xPubID, accessKeyID, adminXPubID string) {
+	if len(m.rawXpubKey) > 0 {
+		xPubID = utils.Hash(m.rawXpubKey)
+	}
+	return xPubID, m.accessKeyID, m.adminXPubID
+}
+
+// getAuditBefore will get the stored state of the audited fields (nil if not loaded)
+func (m *Model) getAuditBefore() map[string]interface{} {
+	return m.auditBefore
+}
+
+// setAuditBefore will set the stored state of the audited fields
+func (m *Model) setAuditBefore(before map[string]interface{}) {
+	m.auditBefore = before
+}
+
+// getEncryption will get the encryption provider for the sensitive values (nil if encryption is not set)
+//
+// An encryption key set on the model (WithEncryptionKey) overrides the provider of the client
+func (m *Model) getEncryption() EncryptionProvider {
+	if len(m.encryptionKey) > 0 {
+		return NewStaticKeyEncryption(m.encryptionKey)
+	} else if m.client != nil {
+		return m.client.Encryption()
+	}
+	return nil
+}
+
+// encryptSensitiveFields will encrypt the sensitive metadata values, and re-encrypt
+// the values that are not encrypted using the active key (rotation)
+//
+// Returns true if any of the values changed
+func (m *Model) encryptSensitiveFields() (bool, error) {
+	provider := m.getEncryption()
+	if provider == nil || len(m.Metadata) == 0 {
+		return false, nil
+	}
+
+	metadata, err := encryptMetadata(provider, m.Metadata, m.getSensitiveMetadataKeys())
+	if err != nil || metadata == nil {
+		return false, err
+	}
+	m.Metadata = metadata
+	return true, nil
+}
+
+// getEncryptedFields will get the (stored) values of the columns that are encrypted at rest
+func (m *Model) getEncryptedFields() map[string]interface{} {
+	return map[string]interface{}{
+		metadataField: m.Metadata,
+	}
+}
+
+// getSensitiveMetadataKeys will get the metadata keys that are encrypted at rest (from the client)
+func (m *Model) getSensitiveMetadataKeys() []string {
+	if m.client != nil {
+		return m.client.SensitiveMetadataKeys()
+	}
+	return nil
+}
+
+// encryptMetadata will encrypt the sensitive values of the metadata, and re-encrypt the values that
+// are not encrypted using the active key (rotation)
+//
+// Returns a copy of the metadata (the map can be shared with other models), or nil if nothing changed
+func encryptMetadata(provider EncryptionProvider, metadata Metadata, sensitiveKeys []string) (Metadata, error) {
+	var encrypted Metadata
+	for key, value := range metadata {
+		encryptedValue, isEncrypted := getEncryptedMetadataValue(value)
+		if isEncrypted && provider.IsCurrent(encryptedValue) {
+			continue
+		} else if !isEncrypted && (value == nil || !utils.StringInSlice(key, sensitiveKeys)) {
+			continue
+		}
+
+		// Decrypt the value (rotation)
+		var err error
+		if isEncrypted {
+			if value, err = decryptMetadataValue(provider, value); err != nil {
+				return nil, err
+			}
+		}
+
+		if encrypted == nil {
+			encrypted = make(Metadata, len(metadata))
+			for k, v := range metadata {
+				encrypted[k] = v
+			}
+		}
+		if encrypted[key], err = encryptMetadataValue(provider, value); err != nil {
+			return nil, err
+		}
+	}
+	return encrypted, nil
+}
+
 // SetRecordTime will set the record timestamps (created is true for a new record)
 func (m *Model) SetRecordTime(created bool) {
 	if created {
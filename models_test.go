@@ -20,9 +20,14 @@ func TestModelName_String(t *testing.T) {
 	t.Parallel()
 
 	t.Run("all model names", func(t *testing.T) {
+		assert.Equal(t, "admin_key", ModelAdminKey.String())
+		assert.Equal(t, "audit_log", ModelAuditLog.String())
+		assert.Equal(t, "batch_payout", ModelBatchPayout.String())
 		assert.Equal(t, "block_header", ModelBlockHeader.String())
+		assert.Equal(t, "compact_block_header", ModelCompactBlockHeader.String())
 		assert.Equal(t, "destination", ModelDestination.String())
 		assert.Equal(t, "empty", ModelNameEmpty.String())
+		assert.Equal(t, "import_job", ModelImportJob.String())
 		assert.Equal(t, "incoming_transaction", ModelIncomingTransaction.String())
 		assert.Equal(t, "metadata", ModelMetadata.String())
 		assert.Equal(t, "paymail_address", ModelPaymailAddress.String())
@@ -31,7 +36,7 @@ func TestModelName_String(t *testing.T) {
 		assert.Equal(t, "transaction", ModelTransaction.String())
 		assert.Equal(t, "utxo", ModelUtxo.String())
 		assert.Equal(t, "xpub", ModelXPub.String())
-		assert.Len(t, AllModelNames, 11)
+		assert.Len(t, AllModelNames, 17)
 	})
 }
 
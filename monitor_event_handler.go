@@ -14,6 +14,7 @@ import (
 	"github.com/centrifugal/centrifuge-go"
 	"github.com/korovkin/limiter"
 	"github.com/libsv/go-bc"
+	"github.com/libsv/go-bk/crypto"
 	"github.com/libsv/go-bt/v2"
 	"github.com/mrz1836/go-whatsonchain"
 )
@@ -213,8 +214,14 @@ func (h *MonitorEventHandler) ProcessBlockHeaders(ctx context.Context, client *c
 		h.logger.Info(h.ctx, "no last block header found, skipping...")
 		return nil
 	}
-	var subscription *centrifuge.Subscription
-	subscription, err = client.NewSubscription("block:headers:history:" + fmt.Sprint(lastBlockHeader.Height))
+
+	h.processBlockHeadersFromHeight(ctx, client, lastBlockHeader.Height)
+	return nil
+}
+
+// processBlockHeadersFromHeight will subscribe to the block header history starting at the given height
+func (h *MonitorEventHandler) processBlockHeadersFromHeight(ctx context.Context, client *centrifuge.Client, height uint32) {
+	subscription, err := client.NewSubscription("block:headers:history:" + fmt.Sprint(height))
 	if err != nil {
 		h.logger.Error(h.ctx, err.Error())
 	} else {
@@ -224,8 +231,6 @@ func (h *MonitorEventHandler) ProcessBlockHeaders(ctx context.Context, client *c
 			h.logger.Error(h.ctx, err.Error())
 		}
 	}
-
-	return nil
 }
 
 // OnError on error event
@@ -298,8 +303,9 @@ func (h *MonitorEventHandler) OnPublish(subscription *centrifuge.Subscription, e
 			}
 			merkleRoot, _ := hex.DecodeString(bi.MerkleRoot)
 			previousBlockHash, _ := hex.DecodeString(bi.PreviousBlockHash)
+			bits, _ := hex.DecodeString(bi.Bits)
 			bh := bc.BlockHeader{
-				Bits:           []byte(bi.Bits),
+				Bits:           bits,
 				HashMerkleRoot: merkleRoot,
 				HashPrevBlock:  previousBlockHash,
 				Nonce:          uint32(bi.Nonce),
@@ -406,13 +412,14 @@ func (h *MonitorEventHandler) processBlockHeaderPublish(client *centrifuge.Clien
 	}
 	merkleRoot, _ := hex.DecodeString(bi.MerkleRoot)
 	previousBlockHash, _ := hex.DecodeString(bi.PreviousBlockHash)
+	bits, _ := hex.DecodeString(bi.Bits)
 	bh := bc.BlockHeader{
 		HashPrevBlock:  previousBlockHash,
 		HashMerkleRoot: merkleRoot,
 		Nonce:          uint32(bi.Nonce),
 		Version:        uint32(bi.Version),
 		Time:           uint32(bi.Time),
-		Bits:           []byte(bi.Bits),
+		Bits:           bits,
 	}
 
 	height := uint32(bi.Height)
@@ -430,11 +437,23 @@ func (h *MonitorEventHandler) processBlockHeaderPublish(client *centrifuge.Clien
 		return
 	}
 
+	// Chain reorganization: the previous block is not the one we have stored
+	reorg := previousBlockHeader.ID != bi.PreviousBlockHash
+	if reorg {
+		h.logger.Info(h.ctx, fmt.Sprintf("[MONITOR] chain reorganization detected at block %d: %s", height, bi.Hash))
+	}
+
 	if _, err = h.buxClient.RecordBlockHeader(h.ctx, bi.Hash, height, bh); err != nil {
 		h.logger.Error(h.ctx, fmt.Sprintf("[MONITOR] ERROR recording block header: %v", err))
 		return
 	}
 
+	// Get the block headers of the new chain that replace the orphaned block headers
+	// (starting below the orphaned previous block, known block headers are skipped)
+	if reorg && previousBlockHeader.Height > 0 {
+		h.processBlockHeadersFromHeight(h.ctx, client, previousBlockHeader.Height-1)
+	}
+
 	if h.debug {
 		h.logger.Info(h.ctx, fmt.Sprintf("[MONITOR] successfully recorded blockheader: %v", bi.Hash))
 	}
@@ -469,9 +488,15 @@ func (h *MonitorEventHandler) RecordTransaction(ctx context.Context, txHex strin
 	return err
 }
 
-// RecordBlockHeader records a block header into bux
-func (h *MonitorEventHandler) RecordBlockHeader(_ context.Context, _ bc.BlockHeader) error {
-	return nil
+// RecordBlockHeader records a block header (at the height given by the chainstate) into bux
+//
+// The previous block header does not need to be known, the gap below the block header is
+// backfilled by the block header sync
+func (h *MonitorEventHandler) RecordBlockHeader(ctx context.Context, height uint32, bh bc.BlockHeader) error {
+	_, err := h.buxClient.RecordBlockHeader(
+		ctx, hex.EncodeToString(bt.ReverseBytes(crypto.Sha256d(bh.Bytes()))), height, bh,
+	)
+	return err
 }
 
 // GetWhatsOnChain returns the WhatsOnChain client interface
@@ -0,0 +1,68 @@
+package bux
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/BuxOrg/bux/chainstate"
+	"github.com/libsv/go-bc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestMonitorEventHandler_RecordBlockHeader will test the method RecordBlockHeader()
+func TestMonitorEventHandler_RecordBlockHeader(t *testing.T) {
+
+	t.Run("offline chainstate", func(t *testing.T) {
+		offline := chainstate.NewOfflineClient(chainstate.MainNet)
+		ctx, client, deferMe := CreateTestSQLiteClient(t, false, true,
+			WithCustomTaskManager(&taskManagerMockBase{}),
+			WithCustomChainstate(offline),
+		)
+		defer deferMe()
+
+		headers, err := offline.MineBlocks(ctx, 2)
+		require.NoError(t, err)
+
+		// The genesis block and the mined blocks are recorded
+		var tip *BlockHeader
+		tip, err = getLastBlockHeader(ctx, client.DefaultModelOptions()...)
+		require.NoError(t, err)
+		require.NotNil(t, tip)
+		assert.Equal(t, headers[1].Hash, tip.ID)
+		assert.Equal(t, uint32(2), tip.Height)
+
+		var blockHeader *BlockHeader
+		blockHeader, err = client.GetBlockHeaderByHash(ctx, headers[0].HashPrevBlock)
+		require.NoError(t, err)
+		require.NotNil(t, blockHeader)
+		assert.Equal(t, uint32(0), blockHeader.Height)
+	})
+
+	t.Run("unknown previous block header", func(t *testing.T) {
+		ctx, client, deferMe := CreateTestSQLiteClient(t, false, true,
+			WithCustomTaskManager(&taskManagerMockBase{}),
+			WithBlockHeaderValidationDisabled(),
+		)
+		defer deferMe()
+
+		previousHash, err := hex.DecodeString(testBlockHash0)
+		require.NoError(t, err)
+
+		// the block header is recorded at the given height (the gap is backfilled by the block header sync)
+		handler := &MonitorEventHandler{buxClient: client, ctx: ctx}
+		err = handler.RecordBlockHeader(ctx, 5, bc.BlockHeader{
+			Bits:           []byte{0x20, 0x7f, 0xff, 0xff},
+			HashMerkleRoot: make([]byte, 32),
+			HashPrevBlock:  previousHash,
+		})
+		require.NoError(t, err)
+
+		var blockHeader *BlockHeader
+		blockHeader, err = client.GetBlockHeaderByHeight(ctx, 5)
+		require.NoError(t, err)
+		require.NotNil(t, blockHeader)
+		assert.Equal(t, testBlockHash0, blockHeader.HashPreviousBlock)
+		assert.False(t, blockHeader.Synced.Valid)
+	})
+}
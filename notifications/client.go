@@ -19,6 +19,36 @@ const (
 
 	// EventTypeBroadcast when a transaction is broadcasted (sync tx)
 	EventTypeBroadcast EventType = "broadcast"
+
+	// EventTypeBroadcastFailed when a broadcast failed, with the response of each provider (sync tx)
+	EventTypeBroadcastFailed EventType = "broadcast_failed"
+
+	// EventTypeDoubleSpend when a transaction lost a double-spend and was reverted (transaction)
+	EventTypeDoubleSpend EventType = "double_spend"
+
+	// EventTypeMempool when a transaction is back in the mempool (transaction)
+	EventTypeMempool EventType = "mempool"
+
+	// EventTypeMined when a transaction is mined (transaction)
+	EventTypeMined EventType = "mined"
+
+	// EventTypeFinal when a transaction has reached the finality confirmations (transaction)
+	EventTypeFinal EventType = "final"
+
+	// EventTypeApproved when a draft transaction is approved by an approver (draft transaction)
+	EventTypeApproved EventType = "approved"
+
+	// EventTypeRejected when a draft transaction is rejected by an approver (draft transaction)
+	EventTypeRejected EventType = "rejected"
+
+	// EventTypeReorg when a block was orphaned by a chain reorganization (block header & transaction)
+	EventTypeReorg EventType = "reorg"
+
+	// EventTypeProgress when a long-running job processed a step (import job)
+	EventTypeProgress EventType = "progress"
+
+	// EventTypeChainTip when a block header became the tip of the longest chain (block header)
+	EventTypeChainTip EventType = "chain_tip"
 )
 
 type (
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"time"
 
+	"github.com/mrz1836/go-cachestore"
 	"github.com/mrz1836/go-datastore"
 	zLogger "github.com/mrz1836/go-logger"
 )
@@ -17,7 +18,11 @@ func taskCleanupDraftTransactions(ctx context.Context, logClient zLogger.GormLog
 	// Construct an empty model
 	var models []DraftTransaction
 	conditions := map[string]interface{}{
-		statusField: DraftStatusDraft,
+		"$or": []map[string]interface{}{{
+			statusField: DraftStatusDraft,
+		}, {
+			statusField: DraftStatusPendingApproval,
+		}},
 		// todo: add DB condition for date "expires_at": map[string]interface{}{"$lte": time.Now()},
 	}
 
@@ -67,6 +72,47 @@ func taskProcessIncomingTransactions(ctx context.Context, logClient zLogger.Gorm
 	return err
 }
 
+// taskProcessImportJobs will process any pending (or interrupted) import jobs
+func taskProcessImportJobs(ctx context.Context, logClient zLogger.GormLoggerInterface, opts ...ModelOps) error {
+
+	logClient.Info(ctx, "running process import job(s) task...")
+
+	err := processImportJobs(ctx, logClient, 10, opts...)
+	if err == nil || errors.Is(err, datastore.ErrNoResults) {
+		return nil
+	}
+	return err
+}
+
+// taskSealAuditLogs will seal (chain) the audit log entries written since the last run
+func taskSealAuditLogs(ctx context.Context, logClient zLogger.GormLoggerInterface, opts ...ModelOps) error {
+
+	logClient.Info(ctx, "running seal audit logs task...")
+
+	client := NewBaseModel(ModelNameEmpty, opts...).Client()
+	if client == nil || !client.IsAuditLogEnabled() {
+		return nil
+	}
+	return sealAuditLogs(ctx, client, false)
+}
+
+// taskSyncBlockHeaders will refresh the block header index and sync the block headers from the block headers source (if loaded)
+func taskSyncBlockHeaders(ctx context.Context, logClient zLogger.GormLoggerInterface, opts ...ModelOps) error {
+
+	logClient.Info(ctx, "running sync block headers task...")
+
+	// Refresh the block header index (block headers saved by another server)
+	client := NewBaseModel(ModelNameEmpty, opts...).Client()
+	if client != nil && client.BlockHeaderIndex() != nil {
+		if err := client.BlockHeaderIndex().refresh(ctx, client.Datastore()); err != nil {
+			return err
+		}
+	}
+
+	_, err := syncBlockHeaders(ctx, logClient, defaultHeaderSyncMaxHeaders, opts...)
+	return err
+}
+
 // taskBroadcastTransactions will broadcast any transactions
 func taskBroadcastTransactions(ctx context.Context, logClient zLogger.GormLoggerInterface, opts ...ModelOps) error {
 
@@ -110,3 +156,27 @@ func taskCheckTransactions(ctx context.Context, logClient zLogger.GormLoggerInte
 
 	return processTransactions(ctx, 1000, opts...)
 }
+
+// taskRotateEncryption will rotate the encryption (re-encrypt all sensitive values using the active key)
+//
+// Skipped if the last rotation was completed using the same key & sensitive metadata keys
+func taskRotateEncryption(ctx context.Context, logClient zLogger.GormLoggerInterface, opts ...ModelOps) error {
+
+	logClient.Info(ctx, "running rotate encryption task...")
+
+	client := NewBaseModel(ModelNameEmpty, opts...).Client()
+	if client == nil || client.Encryption() == nil {
+		return nil
+	}
+
+	// Check the last completed rotation
+	fingerprint, err := client.Cachestore().Get(ctx, cacheKeyEncryptionRotation)
+	if err != nil && !errors.Is(err, cachestore.ErrKeyNotFound) {
+		return err
+	} else if fingerprint == encryptionFingerprint(client) {
+		return nil
+	}
+
+	_, err = client.RotateEncryption(ctx)
+	return err
+}
@@ -18,3 +18,9 @@ var ErrDeriveFailed = errors.New("derive addresses failed, missing addresses")
 
 // ErrCouldNotDetermineDestinationOutput error when token output could not be determined
 var ErrCouldNotDetermineDestinationOutput = errors.New("could not determine token output destination")
+
+// ErrHardenedDerivationPath is when a hardened derivation is used (cannot be derived from a public key)
+var ErrHardenedDerivationPath = errors.New("hardened derivation path cannot be derived from an xpub")
+
+// ErrInvalidDerivationPath is when the derivation path could not be parsed
+var ErrInvalidDerivationPath = errors.New("invalid derivation path")
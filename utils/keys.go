@@ -86,6 +86,37 @@ func DeriveAddress(hdKey *bip32.ExtendedKey, chain uint32, num uint32) (address
 	return addressScript.AddressString, nil
 }
 
+// DeriveAddressFromPath will derive the address from a key using a (non-hardened) derivation path
+func DeriveAddressFromPath(hdKey *bip32.ExtendedKey, path []uint32) (address string, err error) {
+
+	// Don't panic
+	if hdKey == nil {
+		return "", ErrHDKeyNil
+	}
+
+	child := hdKey
+	for _, num := range path {
+		if num >= bip32.HardenedKeyStart {
+			return "", ErrHardenedDerivationPath
+		}
+		if child, err = child.Child(num); err != nil {
+			return "", err
+		}
+	}
+
+	var pubKey *bec.PublicKey
+	if pubKey, err = child.ECPubKey(); err != nil {
+		return "", err
+	}
+
+	var addressScript *bscript.Address
+	if addressScript, err = bitcoin.GetAddressFromPubKey(pubKey, true); err != nil {
+		return "", err
+	}
+
+	return addressScript.AddressString, nil
+}
+
 // DeriveAddresses will derive the internal and external address from a key
 func DeriveAddresses(hdKey *bip32.ExtendedKey, num uint32) (external, internal string, err error) {
 
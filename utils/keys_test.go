@@ -60,6 +60,40 @@ func Test_DeriveAddress(t *testing.T) {
 	})
 }
 
+// Test_DeriveAddressFromPath will test the method DeriveAddressFromPath()
+func Test_DeriveAddressFromPath(t *testing.T) {
+
+	xPub, errX := bip32.NewKeyFromString(testXPub)
+	require.NoError(t, errX)
+
+	t.Run("same as chain / num", func(t *testing.T) {
+		address, err := DeriveAddressFromPath(xPub, []uint32{ChainExternal, 1})
+		require.NoError(t, err)
+		assert.Equal(t, "16fq7PmmXXbFUG5maT5Xvr2zDBUgN1xdMF", address)
+	})
+
+	t.Run("other depths", func(t *testing.T) {
+		address, err := DeriveAddressFromPath(xPub, []uint32{1})
+		require.NoError(t, err)
+		assert.Len(t, address, 34)
+
+		var deeper string
+		deeper, err = DeriveAddressFromPath(xPub, []uint32{1, 0, 0})
+		require.NoError(t, err)
+		assert.NotEqual(t, address, deeper)
+	})
+
+	t.Run("hardened path", func(t *testing.T) {
+		_, err := DeriveAddressFromPath(xPub, []uint32{bip32.HardenedKeyStart})
+		assert.ErrorIs(t, err, ErrHardenedDerivationPath)
+	})
+
+	t.Run("nil key", func(t *testing.T) {
+		_, err := DeriveAddressFromPath(nil, []uint32{0})
+		assert.ErrorIs(t, err, ErrHDKeyNil)
+	})
+}
+
 // Benchmark_DeriveAddresses will benchmark the method DeriveAddresses()
 func Benchmark_DeriveAddresses(b *testing.B) {
 
@@ -9,6 +9,7 @@ import (
 	"encoding/hex"
 	"math"
 	"strconv"
+	"strings"
 
 	"github.com/libsv/go-bt/v2"
 )
@@ -23,6 +24,9 @@ const (
 	// ChainExternal external chain num
 	ChainExternal = uint32(0)
 
+	// ChainImported is the chain num of imported addresses on a non-standard derivation path (see the derivation path)
+	ChainImported = uint32(MaxInt32)
+
 	// MaxInt32 max integer for int32
 	MaxInt32 = int64(1<<(32-1) - 1)
 )
@@ -67,6 +71,39 @@ func GetChildNumsFromHex(hexHash string) ([]uint32, error) {
 	return childNums, nil
 }
 
+// ParseDerivationPath will parse a (non-hardened) derivation path relative to a key (IE: "0/5" or "m/0/5")
+func ParseDerivationPath(path string) ([]uint32, error) {
+	path = strings.TrimPrefix(strings.TrimPrefix(path, "m"), "/")
+	if len(path) == 0 {
+		return []uint32{}, nil
+	}
+
+	parts := strings.Split(path, "/")
+	nums := make([]uint32, 0, len(parts))
+	for _, part := range parts {
+		if strings.HasSuffix(part, "'") || strings.HasSuffix(part, "h") {
+			return nil, ErrHardenedDerivationPath
+		}
+		num, err := strconv.ParseUint(part, 10, 32)
+		if err != nil {
+			return nil, ErrInvalidDerivationPath
+		} else if num > uint64(MaxInt32) {
+			return nil, ErrHardenedDerivationPath
+		}
+		nums = append(nums, uint32(num))
+	}
+	return nums, nil
+}
+
+// FormatDerivationPath will format the derivation path (IE: "0/5")
+func FormatDerivationPath(path []uint32) string {
+	parts := make([]string, 0, len(path))
+	for _, num := range path {
+		parts = append(parts, strconv.FormatUint(uint64(num), 10))
+	}
+	return strings.Join(parts, "/")
+}
+
 // StringInSlice check whether the string already is in the slice
 func StringInSlice(a string, list []string) bool {
 	for _, b := range list {
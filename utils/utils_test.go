@@ -278,6 +278,45 @@ func TestDerivePublicKey(t *testing.T) {
 	})
 }
 
+// TestParseDerivationPath will test the method ParseDerivationPath()
+func TestParseDerivationPath(t *testing.T) {
+	t.Parallel()
+
+	t.Run("valid paths", func(t *testing.T) {
+		path, err := ParseDerivationPath("")
+		require.NoError(t, err)
+		assert.Equal(t, []uint32{}, path)
+
+		path, err = ParseDerivationPath("0/5")
+		require.NoError(t, err)
+		assert.Equal(t, []uint32{0, 5}, path)
+
+		path, err = ParseDerivationPath("m/1/2/3")
+		require.NoError(t, err)
+		assert.Equal(t, []uint32{1, 2, 3}, path)
+		assert.Equal(t, "1/2/3", FormatDerivationPath(path))
+	})
+
+	t.Run("hardened paths", func(t *testing.T) {
+		_, err := ParseDerivationPath("m/44'/236'/0'")
+		assert.ErrorIs(t, err, ErrHardenedDerivationPath)
+
+		_, err = ParseDerivationPath("0h/1")
+		assert.ErrorIs(t, err, ErrHardenedDerivationPath)
+
+		_, err = ParseDerivationPath("2147483648")
+		assert.ErrorIs(t, err, ErrHardenedDerivationPath)
+	})
+
+	t.Run("invalid paths", func(t *testing.T) {
+		_, err := ParseDerivationPath("0/*")
+		assert.ErrorIs(t, err, ErrInvalidDerivationPath)
+
+		_, err = ParseDerivationPath("0//1")
+		assert.ErrorIs(t, err, ErrInvalidDerivationPath)
+	})
+}
+
 // TestStringInSlice will test the method StringInSlice()
 func TestStringInSlice(t *testing.T) {
 	t.Parallel()